@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/ringbuffer"
+)
+
+// BufferHistory returns recent samples of the buffer statistics, e.g. a
+// ringbuffer.StatsHistory.
+type BufferHistory interface {
+	Samples() []ringbuffer.StatsSample
+	Interval() time.Duration
+	Capacity() int
+}
+
+// WithBufferHistory enables GET /api/v1/buffer/history, which returns the
+// sampled buffer statistics as a time series, so operators can follow the
+// buffer's fill and actual window while tuning its window and capacity.
+// Without it the endpoint answers 404.
+func WithBufferHistory(history BufferHistory) Option {
+	return func(s *Server) {
+		s.bufferHistory = history
+	}
+}
+
+// bufferHistoryResponse is the body of GET /api/v1/buffer/history.
+type bufferHistoryResponse struct {
+	// Interval is how often the statistics are sampled
+	Interval string `json:"interval"`
+	// Capacity is how many samples are kept
+	Capacity int `json:"capacity"`
+	// Samples are the samples held, oldest first
+	Samples []ringbuffer.StatsSample `json:"samples"`
+}
+
+// handleBufferHistory returns the sampled buffer statistics as JSON.
+func (s *Server) handleBufferHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.bufferHistory == nil {
+		http.Error(w, "Buffer history is not enabled", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bufferHistoryResponse{
+		Interval: s.bufferHistory.Interval().String(),
+		Capacity: s.bufferHistory.Capacity(),
+		Samples:  s.bufferHistory.Samples(),
+	})
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/ringbuffer"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestBufferHistory validates serving the sampled buffer statistics.
+func TestBufferHistory(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	// get requests the route through the server's full handler chain.
+	get := func(server *Server, method string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/buffer/history", nil)
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("not found when disabled", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false)
+
+		if w := get(server, "GET"); w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 when disabled, got %d", w.Code)
+		}
+	})
+
+	t.Run("returns samples oldest first", func(t *testing.T) {
+		fake := clock.NewFake(now)
+		buffer := ringbuffer.New(time.Minute, ringbuffer.WithClock(fake))
+		history := ringbuffer.NewStatsHistory(buffer, 10*time.Second, 5)
+		for i := 0; i < 2; i++ {
+			buffer.Add(types.TelemetryEntry{Timestamp: fake.Now(), Source: types.SourceSystem, Name: "cpu_usage_percent", Value: i})
+			history.Sample()
+			fake.Advance(30 * time.Second)
+		}
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithBufferHistory(history))
+
+		w := get(server, "GET")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+		var response struct {
+			Interval string                   `json:"interval"`
+			Capacity int                      `json:"capacity"`
+			Samples  []ringbuffer.StatsSample `json:"samples"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Expected JSON, got %v", err)
+		}
+		if response.Interval != "10s" || response.Capacity != 5 {
+			t.Errorf("Expected 10s interval and capacity 5, got %s and %d", response.Interval, response.Capacity)
+		}
+		if len(response.Samples) != 2 {
+			t.Fatalf("Expected 2 samples, got %d", len(response.Samples))
+		}
+		if response.Samples[0].TotalEntries != 1 || response.Samples[1].TotalEntries != 2 {
+			t.Errorf("Expected 1 then 2 entries, got %+v", response.Samples)
+		}
+		if response.Samples[1].ActualWindow != 30*time.Second || !response.Samples[1].Timestamp.Equal(now.Add(30*time.Second)) {
+			t.Errorf("Expected 30s actual window sampled 30s later, got %+v", response.Samples[1])
+		}
+	})
+
+	t.Run("rejects other methods", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithBufferHistory(ringbuffer.NewStatsHistory(ringbuffer.New(time.Minute), 0, 0)))
+
+		if w := get(server, "POST"); w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected 405, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// ListenOptions tunes the socket the API server listens on. The zero value
+// behaves like net.Listen.
+type ListenOptions struct {
+	// Backlog is the length of the queue of pending connections (0 keeps the
+	// system default, net.core.somaxconn on Linux)
+	Backlog int
+	// ReusePort sets SO_REUSEPORT so several daemon workers can bind the same
+	// port and the kernel distributes accepted connections between them
+	ReusePort bool
+}
+
+// WithListenOptions tunes the listen backlog and SO_REUSEPORT for bursts of
+// connections, such as many sidecars reconnecting after a rollout.
+func WithListenOptions(opts ListenOptions) Option {
+	return func(s *Server) {
+		s.listenOptions = opts
+	}
+}
+
+// Listen opens a stream listener ("tcp" or "unix") on the address with the
+// given options applied.
+func Listen(ctx context.Context, network, address string, opts ListenOptions) (net.Listener, error) {
+	config := net.ListenConfig{}
+	if opts.ReusePort {
+		config.Control = func(network, address string, conn syscall.RawConn) error {
+			var sockErr error
+			if err := conn.Control(func(fd uintptr) {
+				sockErr = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+			}); err != nil {
+				return err
+			}
+			if sockErr != nil {
+				return fmt.Errorf("failed to set SO_REUSEPORT: %w", sockErr)
+			}
+			return nil
+		}
+	}
+
+	listener, err := config.Listen(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Backlog > 0 {
+		if err := setBacklog(listener, opts.Backlog); err != nil {
+			listener.Close()
+			return nil, err
+		}
+	}
+	return listener, nil
+}
+
+// setBacklog resizes the pending connection queue of a listening socket. The
+// runtime listens with the system default; calling listen(2) again on the
+// socket updates the backlog in place.
+func setBacklog(listener net.Listener, backlog int) error {
+	sc, ok := listener.(syscall.Conn)
+	if !ok {
+		return fmt.Errorf("listener %T does not expose its socket", listener)
+	}
+	conn, err := sc.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	var listenErr error
+	if err := conn.Control(func(fd uintptr) {
+		listenErr = syscall.Listen(int(fd), backlog)
+	}); err != nil {
+		return err
+	}
+	if listenErr != nil {
+		return fmt.Errorf("failed to set listen backlog %d: %w", backlog, listenErr)
+	}
+	return nil
+}
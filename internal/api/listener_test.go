@@ -0,0 +1,90 @@
+package api
+
+import (
+	"context"
+	"net"
+	"runtime"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// sockoptInt reads an integer socket option from a listener.
+func sockoptInt(t *testing.T, listener net.Listener, level, option int) int {
+	t.Helper()
+	conn, err := listener.(syscall.Conn).SyscallConn()
+	if err != nil {
+		t.Fatalf("Failed to access socket: %v", err)
+	}
+
+	var value int
+	var sockErr error
+	conn.Control(func(fd uintptr) {
+		value, sockErr = unix.GetsockoptInt(int(fd), level, option)
+	})
+	if sockErr != nil {
+		t.Fatalf("Failed to read socket option: %v", sockErr)
+	}
+	return value
+}
+
+// TestListen validates the listen backlog and SO_REUSEPORT options.
+func TestListen(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("socket options are verified on Linux")
+	}
+
+	t.Run("sets SO_REUSEPORT", func(t *testing.T) {
+		first, err := Listen(context.Background(), "tcp", "127.0.0.1:0", ListenOptions{ReusePort: true})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer first.Close()
+
+		if value := sockoptInt(t, first, unix.SOL_SOCKET, unix.SO_REUSEPORT); value != 1 {
+			t.Errorf("Expected SO_REUSEPORT 1, got %d", value)
+		}
+
+		second, err := Listen(context.Background(), "tcp", first.Addr().String(), ListenOptions{ReusePort: true})
+		if err != nil {
+			t.Fatalf("Expected a second listener to share the port, got %v", err)
+		}
+		second.Close()
+	})
+
+	t.Run("defaults to the standard listener", func(t *testing.T) {
+		listener, err := Listen(context.Background(), "tcp", "127.0.0.1:0", ListenOptions{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer listener.Close()
+
+		if value := sockoptInt(t, listener, unix.SOL_SOCKET, unix.SO_REUSEPORT); value != 0 {
+			t.Errorf("Expected SO_REUSEPORT unset, got %d", value)
+		}
+		if _, err := Listen(context.Background(), "tcp", listener.Addr().String(), ListenOptions{}); err == nil {
+			t.Error("Expected the port to be exclusive without SO_REUSEPORT")
+		}
+	})
+
+	t.Run("accepts connections with a custom backlog", func(t *testing.T) {
+		listener, err := Listen(context.Background(), "tcp", "127.0.0.1:0", ListenOptions{Backlog: 16})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer listener.Close()
+
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatalf("Expected to connect, got %v", err)
+		}
+		defer conn.Close()
+
+		accepted, err := listener.Accept()
+		if err != nil {
+			t.Fatalf("Expected to accept, got %v", err)
+		}
+		accepted.Close()
+	})
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// GenerateSwaggerSpec returns the OpenAPI specification of the API served at
+// the root path, e.g. for writing it out as a build artifact.
+func GenerateSwaggerSpec() map[string]interface{} {
+	return generateSwaggerSpec("")
+}
+
+// WriteSwaggerSpec writes the OpenAPI specification as indented JSON to path,
+// so clients can be generated without running the server. It backs the
+// --dump-openapi mode.
+func WriteSwaggerSpec(path string) error {
+	data, err := json.MarshalIndent(GenerateSwaggerSpec(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode OpenAPI spec: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write OpenAPI spec: %w", err)
+	}
+	return nil
+}
+
+// schemaDefinition describes a component schema generated from a Go type.
+type schemaDefinition struct {
+	// value is a zero value of the type
+	value interface{}
+	// description describes the schema
+	description string
+	// required lists the JSON fields the API rejects a request without
+	required []string
+	// fields describes the JSON fields
+	fields map[string]string
+}
+
+// schemaDefinitions are the component schemas. Their properties are generated
+// from the Go structs, so the spec follows the types' fields.
+var schemaDefinitions = map[string]schemaDefinition{
+	"SidecarTelemetry": {
+		value:       types.SidecarTelemetry{},
+		description: "Telemetry submitted by an application sidecar",
+		required:    []string{"pod_name", "namespace"},
+		fields: map[string]string{
+			"pod_name":     "Kubernetes pod name",
+			"namespace":    "Kubernetes namespace",
+			"container_id": "Docker/containerd container ID",
+			"runtime":      "Runtime type, e.g. jvm, nodejs, python, dotnet, go, ruby or custom",
+			"timestamp":    "When the telemetry was sampled (defaults to the time it is received)",
+			"data":         "Runtime-specific metrics keyed by name; values are numbers, strings, booleans or histogram objects",
+		},
+	},
+	"IncidentReport": {
+		value:       types.IncidentReport{},
+		description: "An incident or crash report",
+		fields: map[string]string{
+			"id":           "Incident ID (generated when omitted)",
+			"timestamp":    "When the incident occurred (defaults to the time it is received)",
+			"pod_name":     "Kubernetes pod name",
+			"namespace":    "Kubernetes namespace",
+			"container_id": "Container identifier",
+			"severity":     "Incident severity (defaults to medium)",
+			"type":         "Incident type, e.g. crash, oom, timeout or error (defaults to manual)",
+			"message":      "Human-readable incident description",
+			"context":      "Additional incident context; the daemon adds its version and the schema version",
+		},
+	},
+	"BufferHistory": {
+		value:       bufferHistoryResponse{},
+		description: "Recent samples of the buffer statistics",
+		fields: map[string]string{
+			"interval": "How often the statistics are sampled, e.g. 10s",
+			"capacity": "How many samples are kept",
+			"samples":  "The samples held, oldest first; windows are in nanoseconds",
+		},
+	},
+	"TelemetryEntry": {
+		value:       types.TelemetryEntry{},
+		description: "A buffered telemetry entry",
+		fields: map[string]string{
+			"timestamp": "When the value was sampled",
+			"source":    "Where the entry was collected",
+			"type":      "Telemetry type; custom types registered on the daemon are returned as well",
+			"name":      "Metric name, e.g. cpu_usage_percent",
+			"value":     "Sampled value",
+			"tags":      "Tags identifying the series, e.g. pod_name or interface",
+			"metadata":  "Additional information about the entry",
+		},
+	},
+}
+
+// schemaEnums lists the values of the string types with a fixed set of values.
+var schemaEnums = map[reflect.Type][]string{
+	reflect.TypeOf(types.IncidentSeverity("")): {
+		string(types.SeverityLow), string(types.SeverityMedium), string(types.SeverityHigh), string(types.SeverityCritical),
+	},
+	reflect.TypeOf(types.TelemetrySource("")): {string(types.SourceSystem), string(types.SourceSidecar)},
+	reflect.TypeOf(types.TelemetryType("")):   builtinTypeNames(),
+}
+
+// builtinTypeNames returns the names of the built-in telemetry types.
+func builtinTypeNames() []string {
+	names := make([]string, len(telemetry.BuiltinTypes))
+	for i, builtin := range telemetry.BuiltinTypes {
+		names[i] = string(builtin)
+	}
+	return names
+}
+
+// timeType is the type of time.Time, encoded as an RFC 3339 string.
+var timeType = reflect.TypeOf(time.Time{})
+
+// swaggerSchemas returns the component schemas.
+func swaggerSchemas() map[string]interface{} {
+	schemas := make(map[string]interface{}, len(schemaDefinitions))
+	for name, definition := range schemaDefinitions {
+		schema := typeSchema(reflect.TypeOf(definition.value))
+		schema["description"] = definition.description
+		if len(definition.required) > 0 {
+			schema["required"] = definition.required
+		}
+		for field, description := range definition.fields {
+			if property, ok := schema["properties"].(map[string]interface{})[field].(map[string]interface{}); ok {
+				property["description"] = description
+			}
+		}
+		schemas[name] = schema
+	}
+	return schemas
+}
+
+// typeSchema returns the JSON schema of values of t as encoding/json encodes
+// them. Interface values may hold anything, so their schema is empty.
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == timeType {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		schema := map[string]interface{}{"type": "string"}
+		if enum, ok := schemaEnums[t]; ok {
+			schema["enum"] = enum
+		}
+		return schema
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		var values interface{} = true
+		if t.Elem().Kind() != reflect.Interface {
+			values = typeSchema(t.Elem())
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := jsonFieldName(field)
+			if name == "" {
+				continue
+			}
+			properties[name] = typeSchema(field.Type)
+		}
+		return map[string]interface{}{"type": "object", "properties": properties}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// jsonFieldName returns the name encoding/json gives a struct field, or ""
+// when the field is not encoded.
+func jsonFieldName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	}
+	return name
+}
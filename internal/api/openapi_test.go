@@ -0,0 +1,114 @@
+package api
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// openAPIDocument is the part of an OpenAPI document the tests inspect.
+type openAPIDocument struct {
+	OpenAPI string `json:"openapi"`
+	Info    struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	} `json:"info"`
+	Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Type       string                            `json:"type"`
+			Required   []string                          `json:"required"`
+			Properties map[string]map[string]interface{} `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+// TestGenerateSwaggerSpec validates the spec is a well-formed OpenAPI document.
+func TestGenerateSwaggerSpec(t *testing.T) {
+	data, err := json.Marshal(GenerateSwaggerSpec())
+	if err != nil {
+		t.Fatalf("Expected the spec to encode, got %v", err)
+	}
+	var spec openAPIDocument
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+
+	t.Run("has the required top-level fields", func(t *testing.T) {
+		if !strings.HasPrefix(spec.OpenAPI, "3.") || spec.Info.Title == "" || spec.Info.Version == "" {
+			t.Errorf("Expected openapi 3.x with a title and version, got %q %+v", spec.OpenAPI, spec.Info)
+		}
+		if len(spec.Paths) == 0 {
+			t.Fatal("Expected paths")
+		}
+		for path, operations := range spec.Paths {
+			for method, operation := range operations {
+				if _, ok := operation["responses"]; !ok {
+					t.Errorf("Expected %s %s to define responses", method, path)
+				}
+			}
+		}
+	})
+
+	t.Run("resolves every schema reference", func(t *testing.T) {
+		refs := strings.Split(string(data), `"$ref":"`)[1:]
+		if len(refs) == 0 {
+			t.Fatal("Expected schema references")
+		}
+		for _, ref := range refs {
+			ref = ref[:strings.Index(ref, `"`)]
+			name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+			if _, defined := spec.Components.Schemas[name]; !ok || !defined {
+				t.Errorf("Expected %s to resolve to a component schema", ref)
+			}
+		}
+	})
+
+	t.Run("generates properties from the types", func(t *testing.T) {
+		sidecar := spec.Components.Schemas["SidecarTelemetry"]
+		if sidecar.Type != "object" || len(sidecar.Required) != 2 {
+			t.Errorf("Expected an object requiring pod_name and namespace, got %+v", sidecar)
+		}
+		if sidecar.Properties["timestamp"]["format"] != "date-time" || sidecar.Properties["data"]["type"] != "object" {
+			t.Errorf("Expected a date-time timestamp and object data, got %v", sidecar.Properties)
+		}
+		if sidecar.Properties["pod_name"]["description"] != "Kubernetes pod name" {
+			t.Errorf("Expected field descriptions, got %v", sidecar.Properties["pod_name"])
+		}
+	})
+
+	t.Run("enumerates severities and telemetry types", func(t *testing.T) {
+		severity := spec.Components.Schemas["IncidentReport"].Properties["severity"]["enum"]
+		if values, _ := severity.([]interface{}); len(values) != 4 || values[3] != "critical" {
+			t.Errorf("Expected the four severities, got %v", severity)
+		}
+		telemetryType := spec.Components.Schemas["TelemetryEntry"].Properties["type"]["enum"]
+		if values, _ := telemetryType.([]interface{}); len(values) == 0 || values[0] != "cpu" {
+			t.Errorf("Expected the built-in telemetry types, got %v", telemetryType)
+		}
+	})
+}
+
+// TestWriteSwaggerSpec validates dumping the OpenAPI spec to a file.
+func TestWriteSwaggerSpec(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "openapi.json")
+	if err := WriteSwaggerSpec(path); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read spec: %v", err)
+	}
+	var spec openAPIDocument
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	for _, name := range []string{"SidecarTelemetry", "IncidentReport"} {
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("Expected a %s schema, got %v", name, spec.Components.Schemas)
+		}
+	}
+}
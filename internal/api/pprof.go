@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"strconv"
+	"strings"
+)
+
+// pprofMutexFraction samples one in this many mutex contention events for the
+// mutex profile while profiling is enabled.
+const pprofMutexFraction = 5
+
+// maxPprofSeconds bounds CPU profiles and traces, leaving room to write them
+// within the server's 30 second WriteTimeout; net/http/pprof rejects
+// durations reaching it, including the CPU profile's default of 30 seconds.
+const maxPprofSeconds = 25
+
+// WithPprof serves the Go runtime profiles of the daemon under
+// /api/v1/debug/pprof/, e.g. profile (CPU), heap, goroutine and mutex, to
+// diagnose its own overhead. Unlike the default net/http/pprof registration,
+// the endpoints sit behind the API key. Enabling it also turns on mutex
+// contention sampling. Disabled by default; the endpoints then answer 404.
+func WithPprof(enabled bool) Option {
+	return func(s *Server) {
+		s.pprofEnabled = enabled
+	}
+}
+
+// registerPprof adds the profiling endpoints to mux when profiling is enabled.
+func (s *Server) registerPprof(mux *http.ServeMux) {
+	if !s.pprofEnabled {
+		return
+	}
+	runtime.SetMutexProfileFraction(pprofMutexFraction)
+	mux.HandleFunc(s.path("/api/v1/debug/pprof/"), s.handlePprof)
+}
+
+// handlePprof serves the profile named by the path under
+// /api/v1/debug/pprof/. net/http/pprof's Index only resolves profiles under
+// /debug/pprof/, so named profiles are looked up here.
+func (s *Server) handlePprof(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, s.path("/api/v1/debug/pprof/"))
+	switch name {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, capPprofSeconds(r, 30))
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, capPprofSeconds(r, 1))
+	default:
+		if runtimepprof.Lookup(name) == nil {
+			http.NotFound(w, r)
+			return
+		}
+		pprof.Handler(name).ServeHTTP(w, r)
+	}
+}
+
+// capPprofSeconds returns the request with its seconds parameter, or the
+// handler's default when it is missing, capped at maxPprofSeconds. Invalid
+// values are left for net/http/pprof to reject.
+func capPprofSeconds(r *http.Request, defaultSeconds int) *http.Request {
+	query := r.URL.Query()
+	seconds := defaultSeconds
+	if value := query.Get("seconds"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return r
+		}
+		seconds = parsed
+	}
+	if seconds <= maxPprofSeconds {
+		return r
+	}
+
+	query.Set("seconds", strconv.Itoa(maxPprofSeconds))
+	capped := r.Clone(r.Context())
+	capped.URL.RawQuery = query.Encode()
+	return capped
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestPprof validates the authenticated runtime profile endpoints.
+func TestPprof(t *testing.T) {
+	// get requests the route through the server's full handler chain.
+	get := func(server *Server, route string, authorized bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", route, nil)
+		if authorized {
+			req.Header.Set("Authorization", "Bearer test-api-key-123")
+		}
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("not found when disabled", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false)
+
+		for _, route := range []string{"/api/v1/debug/pprof/", "/api/v1/debug/pprof/heap"} {
+			if w := get(server, route, true); w.Code != http.StatusNotFound {
+				t.Errorf("Expected %s to return 404 when disabled, got %d", route, w.Code)
+			}
+		}
+	})
+
+	t.Run("requires authentication when enabled", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithPprof(true))
+
+		for _, route := range []string{"/api/v1/debug/pprof/", "/api/v1/debug/pprof/heap", "/api/v1/debug/pprof/profile?seconds=1"} {
+			if w := get(server, route, false); w.Code != http.StatusUnauthorized {
+				t.Errorf("Expected %s to return 401 without the API key, got %d", route, w.Code)
+			}
+		}
+	})
+
+	t.Run("serves profiles when enabled", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithPprof(true), WithBasePath("/blackbox"))
+
+		for _, route := range []string{"/", "/heap", "/goroutine?debug=1", "/mutex", "/profile?seconds=1"} {
+			if w := get(server, "/blackbox/api/v1/debug/pprof"+route, true); w.Code != http.StatusOK || w.Body.Len() == 0 {
+				t.Errorf("Expected profile %s served, got %d with %d bytes", route, w.Code, w.Body.Len())
+			}
+		}
+		if w := get(server, "/blackbox/api/v1/debug/pprof/unknown", true); w.Code != http.StatusNotFound {
+			t.Errorf("Expected unknown profile to return 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("caps durations below the write timeout", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false)
+		if maxPprofSeconds >= server.httpServer.WriteTimeout.Seconds() {
+			t.Fatalf("Expected the cap of %ds below the write timeout of %v", maxPprofSeconds, server.httpServer.WriteTimeout)
+		}
+
+		tests := []struct {
+			route          string
+			defaultSeconds int
+			expected       string
+		}{
+			{"/profile", 30, "25"},
+			{"/profile?seconds=120", 30, "25"},
+			{"/profile?seconds=5", 30, "5"},
+			{"/trace", 1, ""},
+			{"/trace?seconds=60", 1, "25"},
+			{"/profile?seconds=abc", 30, "abc"},
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest("GET", "/api/v1/debug/pprof"+tt.route, nil)
+			if seconds := capPprofSeconds(req, tt.defaultSeconds).URL.Query().Get("seconds"); seconds != tt.expected {
+				t.Errorf("Expected %s to profile for %q seconds, got %q", tt.route, tt.expected, seconds)
+			}
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	// RequestIDHeader carries the request ID in requests and responses
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDMetadataKey is the metadata key of the request ID on entries
+	// buffered from a traced request
+	RequestIDMetadataKey = "request_id"
+	// maxRequestIDLength bounds the inbound request IDs honored
+	maxRequestIDLength = 128
+)
+
+// requestIDContextKey is the request context key of the request ID.
+type requestIDContextKey struct{}
+
+// WithRequestTracing assigns every request an ID, honoring a valid inbound
+// X-Request-ID header, returns it in the X-Request-ID response header, logs
+// each request with it and stamps it into the metadata of the telemetry
+// entries buffered from the request. Disabled by default.
+func WithRequestTracing(enabled bool) Option {
+	return func(s *Server) {
+		s.requestTracing = enabled
+	}
+}
+
+// requestID returns the ID of a traced request, or "" when tracing is disabled.
+func requestID(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// tracingMiddleware assigns the request ID and logs the request once it has
+// been served.
+func (s *Server) tracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), requestIDContextKey{}, id)))
+		fmt.Printf("API request %s: %s %s %d %s\n", id, r.Method, r.URL.Path, recorder.status, time.Since(start).Round(time.Microsecond))
+	})
+}
+
+// validRequestID reports whether an inbound request ID is non-empty, bounded
+// and printable ASCII, so it can be echoed and logged safely.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random 128-bit request ID in hex.
+func newRequestID() string {
+	var id [16]byte
+	rand.Read(id[:])
+	return hex.EncodeToString(id[:])
+}
+
+// statusRecorder remembers the status code written to a response.
+type statusRecorder struct {
+	http.ResponseWriter
+	// status is the status code written (200 until WriteHeader is called)
+	status int
+	// wroteHeader records that the status has been written
+	wroteHeader bool
+}
+
+// WriteHeader records the status code and writes it.
+func (sr *statusRecorder) WriteHeader(code int) {
+	if !sr.wroteHeader {
+		sr.status, sr.wroteHeader = code, true
+	}
+	sr.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (sr *statusRecorder) Unwrap() http.ResponseWriter {
+	return sr.ResponseWriter
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRequestTracing validates request IDs in responses and buffered entries.
+func TestRequestTracing(t *testing.T) {
+	body := `{"pod_name":"api-1","namespace":"prod","runtime":"go","data":{"goroutines":42,"heap_bytes":1024}}`
+	submit := func(server *Server, requestID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/telemetry", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		if requestID != "" {
+			req.Header.Set(RequestIDHeader, requestID)
+		}
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("stamps a generated ID into the response and entries", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithRequestTracing(true))
+
+		w := submit(server, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		id := w.Header().Get(RequestIDHeader)
+		if len(id) != 32 {
+			t.Fatalf("Expected a generated 32 character request ID, got %q", id)
+		}
+		if len(buffer.entries) != 2 {
+			t.Fatalf("Expected 2 buffered entries, got %d", len(buffer.entries))
+		}
+		for _, entry := range buffer.entries {
+			if entry.Metadata[RequestIDMetadataKey] != id {
+				t.Errorf("Expected entry %s to reference request %s, got %v", entry.Name, id, entry.Metadata)
+			}
+		}
+
+		if next := submit(server, "").Header().Get(RequestIDHeader); next == id {
+			t.Errorf("Expected a new ID per request, got %q twice", id)
+		}
+	})
+
+	t.Run("honors an inbound request ID", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithRequestTracing(true))
+
+		w := submit(server, "sidecar-7f3a")
+		if got := w.Header().Get(RequestIDHeader); got != "sidecar-7f3a" {
+			t.Errorf("Expected the inbound request ID, got %q", got)
+		}
+		if buffer.entries[0].Metadata[RequestIDMetadataKey] != "sidecar-7f3a" {
+			t.Errorf("Expected entries to reference the inbound ID, got %v", buffer.entries[0].Metadata)
+		}
+
+		if got := submit(server, "bad id\x01").Header().Get(RequestIDHeader); got == "bad id\x01" || len(got) != 32 {
+			t.Errorf("Expected an invalid inbound ID to be replaced, got %q", got)
+		}
+	})
+
+	t.Run("traces rejected requests", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithRequestTracing(true))
+
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/telemetry", strings.NewReader(body)))
+		if w.Code != http.StatusUnauthorized || w.Header().Get(RequestIDHeader) == "" {
+			t.Errorf("Expected a 401 carrying a request ID, got %d %q", w.Code, w.Header().Get(RequestIDHeader))
+		}
+	})
+
+	t.Run("is disabled by default", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false)
+
+		w := submit(server, "sidecar-7f3a")
+		if got := w.Header().Get(RequestIDHeader); got != "" {
+			t.Errorf("Expected no request ID header, got %q", got)
+		}
+		if _, ok := buffer.entries[0].Metadata[RequestIDMetadataKey]; ok {
+			t.Errorf("Expected no request ID in metadata, got %v", buffer.entries[0].Metadata)
+		}
+	})
+}
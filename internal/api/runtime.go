@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KnownRuntimes are the sidecar runtimes accepted in strict runtime mode
+// without further configuration.
+var KnownRuntimes = []string{"jvm", "go", "nodejs", "python", "dotnet"}
+
+// WithStrictRuntimes rejects sidecar telemetry whose runtime is not one of
+// KnownRuntimes or the additional runtimes, so a typo such as "jva" answers
+// 400 instead of being buffered under an unknown runtime. By default any
+// runtime is accepted.
+func WithStrictRuntimes(additional ...string) Option {
+	return func(s *Server) {
+		s.runtimes = make(map[string]bool, len(KnownRuntimes)+len(additional))
+		for _, runtime := range KnownRuntimes {
+			s.runtimes[runtime] = true
+		}
+		for _, runtime := range additional {
+			if runtime = strings.TrimSpace(runtime); runtime != "" {
+				s.runtimes[runtime] = true
+			}
+		}
+	}
+}
+
+// checkRuntime returns an error listing the valid runtimes when strict
+// runtime mode is enabled and runtime is not one of them.
+func (s *Server) checkRuntime(runtime string) error {
+	if s.runtimes == nil || s.runtimes[runtime] {
+		return nil
+	}
+
+	valid := make([]string, 0, len(s.runtimes))
+	for name := range s.runtimes {
+		valid = append(valid, name)
+	}
+	sort.Strings(valid)
+	return fmt.Errorf("unknown runtime %q (expected one of %s)", runtime, strings.Join(valid, ", "))
+}
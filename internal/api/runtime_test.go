@@ -0,0 +1,56 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestStrictRuntimes validates rejecting unknown sidecar runtimes in strict mode.
+func TestStrictRuntimes(t *testing.T) {
+	// submit posts one submission from the given runtime.
+	submit := func(t *testing.T, runtime string, opts ...Option) (*httptest.ResponseRecorder, *mockTelemetryBuffer) {
+		t.Helper()
+		buffer := &mockTelemetryBuffer{}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, opts...)
+
+		jsonData, _ := json.Marshal(types.SidecarTelemetry{
+			PodName:   "api-1",
+			Namespace: "prod",
+			Runtime:   runtime,
+			Data:      map[string]interface{}{"heap_used": 1024},
+		})
+		w := httptest.NewRecorder()
+		server.handleTelemetry(w, httptest.NewRequest("POST", "/api/v1/telemetry", strings.NewReader(string(jsonData))))
+		return w, buffer
+	}
+
+	t.Run("accepts any runtime by default", func(t *testing.T) {
+		if w, buffer := submit(t, "jva"); w.Code != http.StatusOK || len(buffer.entries) != 1 {
+			t.Errorf("Expected unknown runtime accepted, got status %d and %d entries", w.Code, len(buffer.entries))
+		}
+	})
+
+	t.Run("accepts known runtimes", func(t *testing.T) {
+		for _, runtime := range []string{"jvm", "dotnet", "ruby"} {
+			if w, buffer := submit(t, runtime, WithStrictRuntimes("ruby")); w.Code != http.StatusOK || len(buffer.entries) != 1 {
+				t.Errorf("Expected %s accepted, got status %d and %d entries", runtime, w.Code, len(buffer.entries))
+			}
+		}
+	})
+
+	t.Run("rejects unknown runtimes listing the valid ones", func(t *testing.T) {
+		w, buffer := submit(t, "jva", WithStrictRuntimes("ruby"))
+
+		if w.Code != http.StatusBadRequest || len(buffer.entries) != 0 {
+			t.Fatalf("Expected status 400 and nothing buffered, got %d and %d entries", w.Code, len(buffer.entries))
+		}
+		if body := w.Body.String(); !strings.Contains(body, `unknown runtime "jva"`) || !strings.Contains(body, "dotnet, go, jvm, nodejs, python, ruby") {
+			t.Errorf("Expected the valid runtimes listed, got %s", body)
+		}
+	})
+}
@@ -7,10 +7,23 @@ import (
 	"context"
 	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"net"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/incidentstore"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/recovery"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/ringbuffer"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/version"
 	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
 )
 
@@ -21,17 +34,131 @@ type Server struct {
 	httpServer *http.Server
 	// apiKey is the bearer token required for authentication
 	apiKey string
+	// namespaceKeys maps namespaces to bearer tokens that may only submit
+	// telemetry and incidents for that namespace
+	namespaceKeys map[string]string
 	// buffer receives telemetry entries from sidecars
 	buffer TelemetryBuffer
 	// swaggerEnabled controls whether Swagger documentation is available
 	swaggerEnabled bool
 	// incidentHandler processes incident reports
 	incidentHandler IncidentHandler
+	// clock supplies timestamps for defaults and responses
+	clock clock.Clock
+	// maxBatchBytes caps the request body size of batch telemetry uploads
+	maxBatchBytes int64
+	// basePath is prepended to every route, e.g. "/blackbox" behind an ingress
+	basePath string
+	// recoverer turns handler panics into self-incidents (nil leaves panics to net/http)
+	recoverer *recovery.Recoverer
+	// requests counts accepted sidecar submissions (nil disables counting)
+	requests RequestRecorder
+	// flushers are drained, in order, by the admin flush endpoint
+	flushers []namedFlusher
+	// listenOptions tunes the listening socket's backlog and SO_REUSEPORT
+	listenOptions ListenOptions
+	// maxEntries caps the telemetry entries accepted from one sidecar submission (0 is unlimited)
+	maxEntries int
+	// truncateEntries keeps the first maxEntries entries of an oversized
+	// submission instead of rejecting it
+	truncateEntries bool
+	// maxValueBytes caps the serialized size of each sidecar telemetry value (0 is unlimited)
+	maxValueBytes int
+	// truncateValues cuts oversized values to maxValueBytes with a marker
+	// instead of rejecting the submission
+	truncateValues bool
+	// processProfile supplies per-process CPU telemetry for the folded export
+	// (nil when the per-process collector is disabled)
+	processProfile TelemetrySnapshotter
+	// querier serves telemetry queries (nil disables the query endpoint)
+	querier TelemetryQuerier
+	// tailer serves long-polls for new telemetry (nil disables the tail endpoint)
+	tailer TelemetryTailer
+	// skewPolicy selects how sidecar timestamps far from the server clock are
+	// handled (empty accepts them as sent)
+	skewPolicy SkewPolicy
+	// maxSkew is the largest clock offset the skew policy tolerates
+	maxSkew time.Duration
+	// transformer rewrites sidecar entries before they are buffered (nil leaves them unchanged)
+	transformer *telemetry.Transformer
+	// floatNumbers decodes sidecar telemetry numbers as float64 instead of
+	// keeping them exact as json.Number
+	floatNumbers bool
+	// histograms recognizes sidecar telemetry values carrying histograms
+	histograms bool
+	// bindAddress is the host or IP the server listens on (empty listens on all interfaces)
+	bindAddress string
+	// pprofEnabled serves the runtime profiles under /api/v1/debug/pprof/
+	pprofEnabled bool
+	// runtimes are the sidecar runtimes accepted in strict mode (nil accepts any runtime)
+	runtimes map[string]bool
+	// history supplies stored incidents for replay (nil disables the replay endpoint)
+	history IncidentHistory
+	// bufferHistory supplies sampled buffer statistics (nil disables the
+	// buffer history endpoint)
+	bufferHistory BufferHistory
+	// healthChecks are run, in order, by the deep health check
+	healthChecks []namedHealthCheck
+	// readinessGates must all pass before the readiness endpoint reports ready
+	readinessGates []namedHealthCheck
+	// telemetryTypes holds the custom telemetry types inferred for sidecar
+	// metrics and accepted by queries (nil knows only the built-in types)
+	telemetryTypes *telemetry.TypeRegistry
+	// readOnly rejects telemetry submissions and incident reports
+	readOnly bool
+	// requestTracing assigns, returns and logs request IDs and stamps them
+	// into buffered entries
+	requestTracing bool
 }
 
-// TelemetryBuffer interface for adding telemetry entries to storage.
+// DefaultMaxBatchBytes is the default request body limit for batch telemetry uploads.
+const DefaultMaxBatchBytes = 32 << 20
+
+// adminFlushTimeout bounds an admin flush so it completes within the server's write timeout.
+const adminFlushTimeout = 25 * time.Second
+
+// Option configures optional server behavior at construction time.
+type Option func(*Server)
+
+// WithClock sets the clock used for default timestamps, generated IDs, and
+// response timestamps. Defaults to the system clock.
+func WithClock(c clock.Clock) Option {
+	return func(s *Server) {
+		s.clock = c
+	}
+}
+
+// TelemetryBuffer interface for adding telemetry entries to storage. Add
+// returns ringbuffer.ErrBufferFull when the entry was dropped because the
+// buffer is full, which the server answers with 503.
 type TelemetryBuffer interface {
-	Add(entry types.TelemetryEntry)
+	Add(entry types.TelemetryEntry) error
+}
+
+// BatchTelemetryBuffer is implemented by buffers that can insert several entries
+// under a single lock acquisition. The server uses it when available.
+type BatchTelemetryBuffer interface {
+	TelemetryBuffer
+	AddBatch(entries []types.TelemetryEntry) error
+}
+
+// RequestRecorder counts accepted sidecar telemetry submissions, e.g. as
+// Prometheus counters labelled by pod, namespace and runtime.
+type RequestRecorder interface {
+	RecordSidecarRequest(pod, namespace, runtime string)
+}
+
+// EntryLimitRecorder is implemented by RequestRecorders that also count
+// sidecar submissions exceeding the per-request entry limit. The action is
+// "rejected" or "truncated" and dropped is the number of entries discarded.
+type EntryLimitRecorder interface {
+	RecordSidecarEntryLimit(action string, dropped int)
+}
+
+// HistogramRecorder is implemented by RequestRecorders that also export
+// histograms submitted by sidecars, e.g. as Prometheus histograms.
+type HistogramRecorder interface {
+	RecordSidecarHistogram(name, pod, namespace, runtime string, histogram telemetry.Histogram)
 }
 
 // IncidentHandler handles incident reports and triggers appropriate actions.
@@ -39,31 +166,334 @@ type IncidentHandler interface {
 	HandleIncident(report types.IncidentReport)
 }
 
+// WithMaxBatchBytes sets the hard limit on the request body size accepted by the
+// batch telemetry endpoint. Defaults to DefaultMaxBatchBytes.
+func WithMaxBatchBytes(limit int64) Option {
+	return func(s *Server) {
+		s.maxBatchBytes = limit
+	}
+}
+
+// WithEntryLimit caps the telemetry entries, i.e. keys of the data map,
+// accepted from one sidecar submission. Oversized submissions are rejected
+// with 400, or, when truncate is set, cut to their first limit keys in sorted
+// order with a warning. A limit of 0 disables the cap. Submissions are not
+// capped by default.
+func WithEntryLimit(limit int, truncate bool) Option {
+	return func(s *Server) {
+		s.maxEntries = limit
+		s.truncateEntries = truncate
+	}
+}
+
+// WithTransformer applies the transform rules to every sidecar telemetry
+// entry before it is buffered. Share the Transformer with the system collector
+// so rules apply to all telemetry.
+func WithTransformer(transformer *telemetry.Transformer) Option {
+	return func(s *Server) {
+		s.transformer = transformer
+	}
+}
+
+// WithExactNumbers controls whether numbers in sidecar telemetry are kept
+// as json.Number, so integers above 2^53 such as cumulative byte counters
+// round-trip exactly, or decoded as float64. Defaults to exact.
+func WithExactNumbers(exact bool) Option {
+	return func(s *Server) {
+		s.floatNumbers = !exact
+	}
+}
+
+// WithSidecarHistograms controls whether sidecar telemetry values of the form
+// {"type": "histogram", "buckets": [...], "counts": [...]} are recognized as
+// histograms. They are buffered as telemetry.Histogram values and passed to
+// the request recorder when it is a HistogramRecorder; malformed histograms
+// reject the submission with 400. Disabled by default, buffering such values
+// as plain objects.
+func WithSidecarHistograms(enabled bool) Option {
+	return func(s *Server) {
+		s.histograms = enabled
+	}
+}
+
+// WithBindAddress listens on the given host or IP address, e.g. "127.0.0.1"
+// to accept only local connections, instead of every interface. Defaults to
+// all interfaces.
+func WithBindAddress(host string) Option {
+	return func(s *Server) {
+		s.bindAddress = host
+	}
+}
+
+// WithBasePath prefixes every route, including health and Swagger endpoints,
+// with the given path. Leading and trailing slashes are normalized, so
+// "blackbox/" and "/blackbox" are equivalent. Defaults to no prefix.
+func WithBasePath(basePath string) Option {
+	return func(s *Server) {
+		s.basePath = NormalizeBasePath(basePath)
+	}
+}
+
+// WithRecoverer recovers panics in request handlers, answering 500 and
+// reporting a self-incident with the stack trace.
+func WithRecoverer(recoverer *recovery.Recoverer) Option {
+	return func(s *Server) {
+		s.recoverer = recoverer
+	}
+}
+
+// WithRequestRecorder counts every accepted telemetry submission, including each
+// accepted element of a batch, with the submitting sidecar's identity.
+func WithRequestRecorder(recorder RequestRecorder) Option {
+	return func(s *Server) {
+		s.requests = recorder
+	}
+}
+
+// Flusher is implemented by components holding queued work that can be forced
+// out on demand, such as asynchronous emitter queues or a buffer snapshot to
+// disk. Flush blocks until the work is done or ctx expires and returns how many
+// items were flushed.
+type Flusher interface {
+	Flush(ctx context.Context) (int, error)
+}
+
+// namedFlusher is a Flusher reported under a name in flush summaries.
+type namedFlusher struct {
+	name    string
+	flusher Flusher
+}
+
+// WithFlusher registers a component drained by POST /api/v1/admin/flush and
+// reported under the given name, e.g. "emitter_queue" or "buffer_snapshot".
+// Flushers run in registration order.
+func WithFlusher(name string, flusher Flusher) Option {
+	return func(s *Server) {
+		s.flushers = append(s.flushers, namedFlusher{name: name, flusher: flusher})
+	}
+}
+
+// TelemetrySnapshotter returns the telemetry buffered within window before at,
+// e.g. the ring buffer. A zero window returns the whole buffer.
+type TelemetrySnapshotter interface {
+	Snapshot(at time.Time, window time.Duration) []types.TelemetryEntry
+}
+
+// WithProcessProfile enables GET /api/v1/export/folded, which exports the
+// per-process CPU telemetry in source as folded stacks for flame-graph tools.
+// Set it only when the per-process collector is enabled; without it the
+// endpoint answers 404.
+func WithProcessProfile(source TelemetrySnapshotter) Option {
+	return func(s *Server) {
+		s.processProfile = source
+	}
+}
+
+// TelemetryQuerier returns buffered telemetry, e.g. the ring buffer. Filter
+// returns the entries within the buffer window before from that match every
+// predicate, and GetWindowBetween those after start up to and including end.
+type TelemetryQuerier interface {
+	Filter(from time.Time, predicates ...ringbuffer.Predicate) []types.TelemetryEntry
+	GetWindowBetween(start, end time.Time) []types.TelemetryEntry
+}
+
+// TelemetryTailer hands out buffered telemetry by cursor and notifies of new
+// entries, e.g. the ring buffer.
+type TelemetryTailer interface {
+	Since(cursor uint64, limit int) ([]types.TelemetryEntry, uint64)
+	Cursor() uint64
+	Subscribe() (<-chan struct{}, func())
+}
+
+// WithTailer enables GET /api/v1/tail, which long-polls for telemetry newer
+// than a cursor. Without it the endpoint answers 404.
+func WithTailer(tailer TelemetryTailer) Option {
+	return func(s *Server) {
+		s.tailer = tailer
+	}
+}
+
+// WithQuerier enables GET /api/v1/telemetry/query, which returns the buffered
+// telemetry filtered by pod, source and tags, and GET /api/v1/compare, which
+// compares a metric across two windows. Without it both endpoints answer 404.
+func WithQuerier(querier TelemetryQuerier) Option {
+	return func(s *Server) {
+		s.querier = querier
+	}
+}
+
+// IncidentHistory returns the stored incidents with a timestamp at or after
+// since, e.g. the incident store.
+type IncidentHistory interface {
+	IncidentsSince(since time.Time) []types.IncidentReport
+}
+
+// WithIncidentHistory enables POST /api/v1/admin/replay-incidents, which
+// resubmits stored incidents through the incident handler, e.g. after an
+// emitter destination recovers. Without it the endpoint answers 404.
+func WithIncidentHistory(history IncidentHistory) Option {
+	return func(s *Server) {
+		s.history = history
+	}
+}
+
+// WithReadOnly serves only the read APIs, e.g. for a daemon in query mode
+// over a shared buffer or store: telemetry submissions and incident reports
+// answer 404.
+func WithReadOnly(readOnly bool) Option {
+	return func(s *Server) {
+		s.readOnly = readOnly
+	}
+}
+
+// WithTelemetryTypes registers custom telemetry types. Sidecar metrics whose
+// names contain a custom type's keyword are buffered as that type, ahead of the
+// built-in inference, and the query endpoint accepts custom types as filters.
+func WithTelemetryTypes(registry *telemetry.TypeRegistry) Option {
+	return func(s *Server) {
+		s.telemetryTypes = registry
+	}
+}
+
+// HealthChecker reports whether a dependency of the daemon works, e.g. a
+// critical emitter. Check returns nil when it is healthy.
+type HealthChecker interface {
+	Check() error
+}
+
+// namedHealthCheck is a HealthChecker reported under a name in deep health checks.
+type namedHealthCheck struct {
+	name    string
+	checker HealthChecker
+	// degraded reports a failure as degraded instead of unhealthy
+	degraded bool
+}
+
+// WithNamespaceKeys accepts additional API keys, each bound to a namespace,
+// for multi-tenant clusters: requests authenticated with a namespace key may
+// only submit telemetry and incidents for its namespace (403 otherwise) and
+// cannot use the read or admin endpoints. The server's API key stays
+// unrestricted.
+func WithNamespaceKeys(keys map[string]string) Option {
+	return func(s *Server) {
+		s.namespaceKeys = keys
+	}
+}
+
+// namespaceContextKey is the request context key of the namespace bound to the
+// API key that authenticated the request.
+type namespaceContextKey struct{}
+
+// keyNamespace returns the namespace the request's API key is bound to, or
+// false when it was authenticated with the unrestricted API key.
+func keyNamespace(r *http.Request) (string, bool) {
+	namespace, ok := r.Context().Value(namespaceContextKey{}).(string)
+	return namespace, ok
+}
+
+// namespaceAllowed reports whether the request's API key may submit data for
+// the namespace.
+func namespaceAllowed(r *http.Request, namespace string) bool {
+	scoped, ok := keyNamespace(r)
+	return !ok || scoped == namespace
+}
+
+// namespaceForbidden rejects a submission for a namespace the API key is not bound to.
+func namespaceForbidden(w http.ResponseWriter, r *http.Request, namespace string) {
+	scoped, _ := keyNamespace(r)
+	http.Error(w, fmt.Sprintf("API key for namespace %q may not submit data for namespace %q", scoped, namespace), http.StatusForbidden)
+}
+
+// WithHealthCheck registers a dependency checked by GET /api/v1/health?deep=true
+// and reported under the given name, e.g. "emitter_webhook". The deep check
+// answers 503 when any registered check fails.
+func WithHealthCheck(name string, checker HealthChecker) Option {
+	return func(s *Server) {
+		s.healthChecks = append(s.healthChecks, namedHealthCheck{name: name, checker: checker})
+	}
+}
+
+// WithDegradedCheck registers an optional subsystem checked by the deep health
+// check, e.g. the metrics server. Its failure reports the daemon "degraded"
+// but still answers 200, so probes do not restart a daemon that keeps ingesting.
+func WithDegradedCheck(name string, checker HealthChecker) Option {
+	return func(s *Server) {
+		s.healthChecks = append(s.healthChecks, namedHealthCheck{name: name, checker: checker, degraded: true})
+	}
+}
+
+// WithReadinessGate registers a condition GET /api/v1/ready waits for,
+// reported under the given name, e.g. "collector" for the system collector's
+// first collection cycle. The endpoint answers 503 until every gate passes.
+func WithReadinessGate(name string, checker HealthChecker) Option {
+	return func(s *Server) {
+		s.readinessGates = append(s.readinessGates, namedHealthCheck{name: name, checker: checker})
+	}
+}
+
+// NormalizeBasePath returns the base path with a single leading slash and no
+// trailing slash, or an empty string for the root.
+func NormalizeBasePath(basePath string) string {
+	basePath = strings.Trim(basePath, "/")
+	if basePath == "" {
+		return ""
+	}
+	return "/" + basePath
+}
+
 // NewServer creates a new API server with the specified configuration.
 // The server provides authenticated REST endpoints for sidecar communication.
-func NewServer(port int, apiKey string, buffer TelemetryBuffer, incidentHandler IncidentHandler, swaggerEnabled bool) *Server {
+func NewServer(port int, apiKey string, buffer TelemetryBuffer, incidentHandler IncidentHandler, swaggerEnabled bool, opts ...Option) *Server {
 	s := &Server{
 		apiKey:          apiKey,
 		buffer:          buffer,
 		swaggerEnabled:  swaggerEnabled,
 		incidentHandler: incidentHandler,
+		clock:           clock.Real{},
+		maxBatchBytes:   DefaultMaxBatchBytes,
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if incidentHandler == nil && !s.readOnly {
+		fmt.Printf("Warning: API server has no incident handler; incident reports will be answered with 503\n")
 	}
 
 	mux := http.NewServeMux()
 
 	// API endpoints
-	mux.HandleFunc("/api/v1/telemetry", s.handleTelemetry)
-	mux.HandleFunc("/api/v1/incident", s.handleIncident)
-	mux.HandleFunc("/api/v1/health", s.handleHealth)
+	mux.HandleFunc(s.path("/api/v1/telemetry"), s.handleTelemetry)
+	mux.HandleFunc(s.path("/api/v1/telemetry/batch"), s.handleTelemetryBatch)
+	mux.HandleFunc(s.path("/api/v1/telemetry/query"), s.handleTelemetryQuery)
+	mux.HandleFunc(s.path("/api/v1/compare"), s.handleCompare)
+	mux.HandleFunc(s.path("/api/v1/tail"), s.handleTail)
+	mux.HandleFunc(s.path("/api/v1/incident"), s.handleIncident)
+	mux.HandleFunc(s.path("/api/v1/health"), s.handleHealth)
+	mux.HandleFunc(s.path("/api/v1/ready"), s.handleReady)
+	mux.HandleFunc(s.path("/api/v1/admin/flush"), s.handleAdminFlush)
+	mux.HandleFunc(s.path("/api/v1/admin/replay-incidents"), s.handleReplayIncidents)
+	mux.HandleFunc(s.path("/api/v1/export/folded"), s.handleExportFolded)
+	mux.HandleFunc(s.path("/api/v1/buffer/history"), s.handleBufferHistory)
+	s.registerPprof(mux)
 
 	if swaggerEnabled {
-		mux.HandleFunc("/swagger.json", s.handleSwagger)
-		mux.HandleFunc("/swagger/", s.handleSwaggerUI)
+		mux.HandleFunc(s.path("/swagger.json"), s.handleSwagger)
+		mux.HandleFunc(s.path("/swagger/"), s.handleSwaggerUI)
+	}
+
+	handler := s.authMiddleware(mux)
+	if s.requestTracing {
+		handler = s.tracingMiddleware(handler)
+	}
+	if s.recoverer != nil {
+		handler = s.recoverer.Middleware("api", handler)
 	}
 
 	s.httpServer = &http.Server{
-		Addr:         fmt.Sprintf(":%d", port),
-		Handler:      s.authMiddleware(mux),
+		Addr:         net.JoinHostPort(s.bindAddress, strconv.Itoa(port)),
+		Handler:      handler,
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
@@ -71,9 +501,37 @@ func NewServer(port int, apiKey string, buffer TelemetryBuffer, incidentHandler
 	return s
 }
 
+// path returns the route for the given path under the configured base path.
+func (s *Server) path(route string) string {
+	return s.basePath + route
+}
+
 // Start starts the HTTP server and begins accepting requests.
 // The server will shutdown gracefully when the context is cancelled.
 func (s *Server) Start(ctx context.Context) error {
+	return s.Run(ctx, true)
+}
+
+// Run starts the server like Start. When the address cannot be bound and
+// required is false, Run logs a warning and returns nil, so a daemon whose
+// sidecars do not push telemetry keeps collecting and watching pods.
+func (s *Server) Run(ctx context.Context, required bool) error {
+	listener, err := Listen(ctx, "tcp", s.httpServer.Addr, s.listenOptions)
+	if err != nil {
+		if required {
+			return err
+		}
+		fmt.Printf("Warning: API server unavailable, continuing without it: %v\n", err)
+		return nil
+	}
+
+	fmt.Printf("Starting API server on %s\n", s.httpServer.Addr)
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves the API on the listener until the context is cancelled, then
+// shuts the server down gracefully.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
 	go func() {
 		<-ctx.Done()
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -81,8 +539,7 @@ func (s *Server) Start(ctx context.Context) error {
 		s.httpServer.Shutdown(shutdownCtx)
 	}()
 
-	fmt.Printf("Starting API server on %s\n", s.httpServer.Addr)
-	if err := s.httpServer.ListenAndServe(); err != http.ErrServerClosed {
+	if err := s.httpServer.Serve(listener); err != http.ErrServerClosed {
 		return err
 	}
 	return nil
@@ -90,11 +547,14 @@ func (s *Server) Start(ctx context.Context) error {
 
 // authMiddleware provides API key authentication for protected endpoints.
 // Uses constant-time comparison to prevent timing attacks on the API key.
+// Requests authenticated with a namespace key carry its namespace in the
+// request context and may only reach the submission endpoints.
 func (s *Server) authMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Skip auth for health check and swagger endpoints
-		if r.URL.Path == "/api/v1/health" ||
-			(s.swaggerEnabled && (r.URL.Path == "/swagger.json" || r.URL.Path == "/swagger/")) {
+		// Skip auth for health, readiness and swagger endpoints; the swagger UI
+		// is registered as a subtree, so every path under /swagger/ is public
+		if r.URL.Path == s.path("/api/v1/health") || r.URL.Path == s.path("/api/v1/ready") ||
+			(s.swaggerEnabled && (r.URL.Path == s.path("/swagger.json") || strings.HasPrefix(r.URL.Path, s.path("/swagger/")))) {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -103,12 +563,31 @@ func (s *Server) authMiddleware(next http.Handler) http.Handler {
 		expectedAuth := "Bearer " + s.apiKey
 
 		// Use constant-time comparison to prevent timing attacks on API key validation
-		if subtle.ConstantTimeCompare([]byte(authHeader), []byte(expectedAuth)) != 1 {
+		if subtle.ConstantTimeCompare([]byte(authHeader), []byte(expectedAuth)) == 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Namespace keys are compared without stopping at the first match, so
+		// the time taken does not reveal which namespace a key belongs to
+		namespace, matched := "", false
+		for scoped, key := range s.namespaceKeys {
+			if subtle.ConstantTimeCompare([]byte(authHeader), []byte("Bearer "+key)) == 1 {
+				namespace, matched = scoped, true
+			}
+		}
+		if !matched {
 			http.Error(w, "Unauthorized", http.StatusUnauthorized)
 			return
 		}
+		switch r.URL.Path {
+		case s.path("/api/v1/telemetry"), s.path("/api/v1/telemetry/batch"), s.path("/api/v1/incident"):
+		default:
+			http.Error(w, fmt.Sprintf("API key for namespace %q may only submit telemetry and incidents", namespace), http.StatusForbidden)
+			return
+		}
 
-		next.ServeHTTP(w, r)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), namespaceContextKey{}, namespace)))
 	})
 }
 
@@ -118,9 +597,13 @@ func (s *Server) handleTelemetry(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if s.readOnly {
+		http.Error(w, "Telemetry ingestion is not enabled", http.StatusNotFound)
+		return
+	}
 
 	var sidecarTelemetry types.SidecarTelemetry
-	if err := json.NewDecoder(r.Body).Decode(&sidecarTelemetry); err != nil {
+	if err := s.telemetryDecoder(r.Body).Decode(&sidecarTelemetry); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
@@ -130,25 +613,170 @@ func (s *Server) handleTelemetry(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Pod name and namespace are required", http.StatusBadRequest)
 		return
 	}
-
-	// Set timestamp if not provided
-	if sidecarTelemetry.Timestamp.IsZero() {
-		sidecarTelemetry.Timestamp = time.Now()
+	if !namespaceAllowed(r, sidecarTelemetry.Namespace) {
+		namespaceForbidden(w, r, sidecarTelemetry.Namespace)
+		return
 	}
 
 	// Convert sidecar telemetry to individual telemetry entries
-	s.processSidecarTelemetry(sidecarTelemetry)
+	if err := s.processSidecarTelemetry(sidecarTelemetry, requestID(r)); err != nil {
+		if errors.Is(err, ringbuffer.ErrBufferFull) {
+			bufferFull(w)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	response := map[string]interface{}{
 		"status":    "accepted",
-		"timestamp": time.Now(),
+		"timestamp": s.clock.Now(),
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleTelemetryBatch processes a JSON array of sidecar telemetry submissions.
+// The array is decoded one element at a time so memory stays bounded regardless
+// of batch size; each element is buffered as soon as it has been read. Elements
+// missing a pod name or namespace, for a namespace the API key is not bound to,
+// exceeding the entry or value size limit or carrying a malformed histogram, are skipped and
+// counted as rejected. When the buffer drops telemetry because it is full, the
+// response is 503 with the counts so the sidecar backs off.
+func (s *Server) handleTelemetryBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.readOnly {
+		http.Error(w, "Telemetry ingestion is not enabled", http.StatusNotFound)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.maxBatchBytes)
+	decoder := s.telemetryDecoder(r.Body)
+
+	// Expect the opening bracket of the array
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		s.batchDecodeError(w, err, "Expected JSON array")
+		return
+	}
+
+	accepted, rejected, full := 0, 0, false
+	for decoder.More() {
+		var sidecarTelemetry types.SidecarTelemetry
+		if err := decoder.Decode(&sidecarTelemetry); err != nil {
+			s.batchDecodeError(w, err, "Invalid JSON")
+			return
+		}
+
+		if sidecarTelemetry.PodName == "" || sidecarTelemetry.Namespace == "" ||
+			!namespaceAllowed(r, sidecarTelemetry.Namespace) {
+			rejected++
+			continue
+		}
+
+		if err := s.processSidecarTelemetry(sidecarTelemetry, requestID(r)); err != nil {
+			full = full || errors.Is(err, ringbuffer.ErrBufferFull)
+			rejected++
+			continue
+		}
+		accepted++
+	}
+
+	// Consume the closing bracket of the array
+	if _, err := decoder.Token(); err != nil {
+		s.batchDecodeError(w, err, "Invalid JSON")
+		return
+	}
+
+	status, code := "accepted", http.StatusOK
+	if full {
+		// Elements already buffered are kept; the sidecar should back off
+		status, code = "buffer_full", http.StatusServiceUnavailable
+		w.Header().Set("Retry-After", "1")
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	response := map[string]interface{}{
+		"status":    status,
+		"accepted":  accepted,
+		"rejected":  rejected,
+		"timestamp": s.clock.Now(),
 	}
 	json.NewEncoder(w).Encode(response)
 }
 
-// processSidecarTelemetry converts sidecar telemetry into individual telemetry entries
-func (s *Server) processSidecarTelemetry(sidecar types.SidecarTelemetry) {
+// telemetryDecoder returns a decoder for sidecar telemetry, keeping numbers
+// as json.Number unless float decoding is configured.
+func (s *Server) telemetryDecoder(body io.Reader) *json.Decoder {
+	decoder := json.NewDecoder(body)
+	if !s.floatNumbers {
+		decoder.UseNumber()
+	}
+	return decoder
+}
+
+// batchDecodeError responds to a batch decoding failure, distinguishing bodies
+// that exceed the size limit from malformed JSON.
+func (s *Server) batchDecodeError(w http.ResponseWriter, err error, message string) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	http.Error(w, message, http.StatusBadRequest)
+}
+
+// processSidecarTelemetry converts sidecar telemetry into individual telemetry
+// entries. Submissions with a runtime rejected by strict runtime mode return an
+// error. Submissions without a timestamp are stamped with the receipt time;
+// others are checked against the clock skew policy. A submission exceeding the
+// entry limit is truncated or, unless truncation is enabled, rejected with an
+// error before anything is buffered, as is one with a malformed histogram.
+// Values exceeding the value size limit are handled likewise.
+// The request ID of a traced request is stamped into the entries' metadata.
+func (s *Server) processSidecarTelemetry(sidecar types.SidecarTelemetry, requestID string) error {
+	if err := s.checkRuntime(sidecar.Runtime); err != nil {
+		return err
+	}
+
+	var rebased *rebasedTimestamp
+	if sidecar.Timestamp.IsZero() {
+		sidecar.Timestamp = s.clock.Now()
+	} else {
+		var err error
+		if rebased, err = s.applyClockSkew(&sidecar); err != nil {
+			return err
+		}
+	}
+
+	if s.maxEntries > 0 && len(sidecar.Data) > s.maxEntries {
+		if !s.truncateEntries {
+			s.recordEntryLimit("rejected", len(sidecar.Data))
+			return fmt.Errorf("too many telemetry entries: %d exceeds the limit of %d", len(sidecar.Data), s.maxEntries)
+		}
+		dropped := len(sidecar.Data) - s.maxEntries
+		sidecar.Data = truncateData(sidecar.Data, s.maxEntries)
+		s.recordEntryLimit("truncated", dropped)
+		fmt.Printf("Warning: truncated telemetry from %s/%s to %d entries, dropped %d\n", sidecar.Namespace, sidecar.PodName, s.maxEntries, dropped)
+	}
+
+	truncatedSizes, err := s.limitValueSizes(sidecar)
+	if err != nil {
+		return err
+	}
+
+	histograms, err := s.parseHistograms(sidecar.Data)
+	if err != nil {
+		return err
+	}
+
+	if s.requests != nil {
+		s.requests.RecordSidecarRequest(sidecar.PodName, sidecar.Namespace, sidecar.Runtime)
+	}
+
 	baseTags := map[string]string{
 		"pod_name":  sidecar.PodName,
 		"namespace": sidecar.Namespace,
@@ -160,25 +788,130 @@ func (s *Server) processSidecarTelemetry(sidecar types.SidecarTelemetry) {
 	}
 
 	// Process each piece of telemetry data
+	entries := make([]types.TelemetryEntry, 0, len(sidecar.Data))
 	for key, value := range sidecar.Data {
-		entry := types.TelemetryEntry{
+		metadata := map[string]interface{}{
+			"sidecar_runtime": sidecar.Runtime,
+		}
+		if requestID != "" {
+			metadata[RequestIDMetadataKey] = requestID
+		}
+		if size, ok := truncatedSizes[key]; ok {
+			metadata[ValueTruncatedMetadataKey] = size
+		}
+		if histogram, ok := histograms[key]; ok {
+			value = histogram
+			metadata[telemetry.ValueTypeMetadataKey] = telemetry.HistogramType
+		}
+		entries = append(entries, s.transformer.Apply(types.TelemetryEntry{
 			Timestamp: sidecar.Timestamp,
 			Source:    types.SourceSidecar,
 			Type:      s.inferTelemetryType(key, sidecar.Runtime),
 			Name:      key,
 			Value:     value,
 			Tags:      baseTags,
-			Metadata: map[string]interface{}{
-				"sidecar_runtime": sidecar.Runtime,
-			},
+			Metadata:  rebased.metadata(metadata),
+		}))
+	}
+
+	if err := s.addEntries(entries); err != nil {
+		return err
+	}
+	s.recordHistograms(sidecar, histograms)
+	return nil
+}
+
+// parseHistograms returns the values of data carrying histograms by key when
+// histograms are enabled, or an error naming the first malformed one.
+func (s *Server) parseHistograms(data map[string]interface{}) (map[string]telemetry.Histogram, error) {
+	if !s.histograms {
+		return nil, nil
+	}
+
+	var histograms map[string]telemetry.Histogram
+	for key, value := range data {
+		histogram, ok, err := telemetry.ParseHistogram(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid histogram %s: %w", key, err)
+		}
+		if !ok {
+			continue
+		}
+		if histograms == nil {
+			histograms = make(map[string]telemetry.Histogram)
 		}
+		histograms[key] = histogram
+	}
+	return histograms, nil
+}
+
+// recordHistograms passes buffered histograms to the request recorder when it
+// supports them. Histograms of submissions the buffer dropped are not
+// recorded, so a sidecar retrying them is not counted twice.
+func (s *Server) recordHistograms(sidecar types.SidecarTelemetry, histograms map[string]telemetry.Histogram) {
+	recorder, ok := s.requests.(HistogramRecorder)
+	if !ok {
+		return
+	}
+	for key, histogram := range histograms {
+		recorder.RecordSidecarHistogram(key, sidecar.PodName, sidecar.Namespace, sidecar.Runtime, histogram)
+	}
+}
+
+// recordEntryLimit counts a submission exceeding the entry limit when the
+// request recorder supports it.
+func (s *Server) recordEntryLimit(action string, dropped int) {
+	if recorder, ok := s.requests.(EntryLimitRecorder); ok {
+		recorder.RecordSidecarEntryLimit(action, dropped)
+	}
+}
+
+// truncateData returns the entries of data with the first limit keys in
+// sorted order, so the same submission is always cut the same way.
+func truncateData(data map[string]interface{}, limit int) map[string]interface{} {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-		s.buffer.Add(entry)
+	truncated := make(map[string]interface{}, limit)
+	for _, key := range keys[:limit] {
+		truncated[key] = data[key]
 	}
+	return truncated
+}
+
+// addEntries stores entries in the buffer, using a single batch insert when the
+// buffer supports it. Entries the buffer accepts are kept even when others are
+// dropped; the error reports the drop.
+func (s *Server) addEntries(entries []types.TelemetryEntry) error {
+	if batch, ok := s.buffer.(BatchTelemetryBuffer); ok {
+		return batch.AddBatch(entries)
+	}
+
+	var err error
+	for _, entry := range entries {
+		if addErr := s.buffer.Add(entry); addErr != nil {
+			err = addErr
+		}
+	}
+	return err
+}
+
+// bufferFull responds 503 with a Retry-After hint when telemetry was dropped
+// because the buffer is full, so sidecars back off instead of retrying at once.
+func bufferFull(w http.ResponseWriter) {
+	w.Header().Set("Retry-After", "1")
+	http.Error(w, "Telemetry buffer is full", http.StatusServiceUnavailable)
 }
 
 // inferTelemetryType attempts to categorize telemetry based on key name and runtime
 func (s *Server) inferTelemetryType(key, runtime string) types.TelemetryType {
+	if custom, ok := s.telemetryTypes.Infer(key); ok {
+		return custom
+	}
+
 	// Common patterns for different types
 	if contains(key, []string{"memory", "heap", "gc"}) {
 		return types.TypeMemory
@@ -219,19 +952,31 @@ func (s *Server) handleIncident(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if s.readOnly {
+		http.Error(w, "Incident reporting is not enabled", http.StatusNotFound)
+		return
+	}
+	if s.incidentHandler == nil {
+		http.Error(w, "Incident handling is not configured", http.StatusServiceUnavailable)
+		return
+	}
 
 	var report types.IncidentReport
 	if err := json.NewDecoder(r.Body).Decode(&report); err != nil {
 		http.Error(w, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
+	if !namespaceAllowed(r, report.Namespace) {
+		namespaceForbidden(w, r, report.Namespace)
+		return
+	}
 
 	// Set timestamp and ID if not provided
 	if report.Timestamp.IsZero() {
-		report.Timestamp = time.Now()
+		report.Timestamp = s.clock.Now()
 	}
 	if report.ID == "" {
-		report.ID = fmt.Sprintf("manual-%d", time.Now().Unix())
+		report.ID = fmt.Sprintf("manual-%d", s.clock.Now().Unix())
 	}
 
 	// Default severity and type if not specified
@@ -242,45 +987,558 @@ func (s *Server) handleIncident(w http.ResponseWriter, r *http.Request) {
 		report.Type = types.IncidentManual
 	}
 
+	report.Context = version.Tag(report.Context)
+
 	// Process the incident
 	s.incidentHandler.HandleIncident(report)
 
 	w.Header().Set("Content-Type", "application/json")
 	response := map[string]interface{}{
-		"status":      "accepted",
-		"incident_id": report.ID,
-		"timestamp":   time.Now(),
+		"status":         "accepted",
+		"incident_id":    report.ID,
+		"schema_version": version.SchemaVersion(report.Context),
+		"timestamp":      s.clock.Now(),
 	}
 	json.NewEncoder(w).Encode(response)
 }
 
-// handleHealth provides a health check endpoint
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+// handleAdminFlush drains every registered flusher and reports how many items
+// each one flushed. Unlike shutdown, the daemon keeps running afterwards.
+func (s *Server) handleAdminFlush(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	ctx, cancel := context.WithTimeout(r.Context(), adminFlushTimeout)
+	defer cancel()
+
+	flushed := make(map[string]int, len(s.flushers))
+	failures := make(map[string]string)
+	for _, f := range s.flushers {
+		count, err := f.flusher.Flush(ctx)
+		flushed[f.name] = count
+		if err != nil {
+			failures[f.name] = err.Error()
+		}
+	}
+
+	status := http.StatusOK
 	response := map[string]interface{}{
-		"status":    "healthy",
-		"timestamp": time.Now(),
-		"service":   "blackbox-daemon",
-		"version":   "1.0.0",
+		"status":    "flushed",
+		"flushed":   flushed,
+		"timestamp": s.clock.Now(),
+	}
+	if len(failures) > 0 {
+		status = http.StatusInternalServerError
+		response["status"] = "partial"
+		response["errors"] = failures
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
 	json.NewEncoder(w).Encode(response)
 }
 
-// handleSwagger serves the Swagger/OpenAPI specification
-func (s *Server) handleSwagger(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodGet {
+// handleReplayIncidents resubmits the stored incidents through the incident
+// handler, i.e. the current formatter chain and emitters. The optional since
+// query parameter is an RFC 3339 time or a duration before now; it defaults to
+// the whole history. Replayed incidents are marked so the incident store does
+// not record them again, and dry_run=true only counts them.
+func (s *Server) handleReplayIncidents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
+	if s.history == nil {
+		http.Error(w, "Incident history is not enabled", http.StatusNotFound)
+		return
+	}
+	if s.incidentHandler == nil {
+		http.Error(w, "Incident handling is not configured", http.StatusServiceUnavailable)
+		return
+	}
 
-	swagger := generateSwaggerSpec()
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(swagger)
+	query := r.URL.Query()
+	now := s.clock.Now()
+	var since time.Time
+	if val := query.Get("since"); val != "" {
+		if window, err := time.ParseDuration(val); err == nil && window >= 0 {
+			since = now.Add(-window)
+		} else if since, err = time.Parse(time.RFC3339, val); err != nil {
+			http.Error(w, "Invalid since: expected an RFC 3339 time or a duration", http.StatusBadRequest)
+			return
+		}
+	}
+	dryRun := false
+	if val := query.Get("dry_run"); val != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(val); err != nil {
+			http.Error(w, "Invalid dry_run", http.StatusBadRequest)
+			return
+		}
+	}
+
+	incidents := s.history.IncidentsSince(since)
+	status := "dry_run"
+	if !dryRun {
+		status = "replayed"
+		for _, report := range incidents {
+			s.incidentHandler.HandleIncident(incidentstore.MarkReplayed(report, now))
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":    status,
+		"incidents": len(incidents),
+		"since":     since,
+		"timestamp": now,
+	})
+}
+
+// handleExportFolded writes the per-process CPU time within the requested
+// window as folded stacks ("comm;pid ticks"). The optional window query
+// parameter is a duration; it defaults to the whole buffer.
+func (s *Server) handleExportFolded(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.processProfile == nil {
+		http.Error(w, "Per-process collector is not enabled", http.StatusNotFound)
+		return
+	}
+
+	var window time.Duration
+	if val := r.URL.Query().Get("window"); val != "" {
+		parsed, err := time.ParseDuration(val)
+		if err != nil || parsed < 0 {
+			http.Error(w, "Invalid window", http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+
+	entries := s.processProfile.Snapshot(s.clock.Now(), window)
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := telemetry.WriteFoldedCPU(w, entries); err != nil {
+		fmt.Printf("Error writing folded export: %v\n", err)
+	}
+}
+
+// handleTelemetryQuery returns the buffered telemetry matching the query
+// parameters as JSON. The optional window parameter is a duration (defaulting
+// to the whole buffer), pod, source and type select entries exactly, and
+// repeated tag=key:value parameters must all match.
+func (s *Server) handleTelemetryQuery(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.querier == nil {
+		http.Error(w, "Telemetry queries are not enabled", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	now := s.clock.Now()
+	var predicates []ringbuffer.Predicate
+	if val := query.Get("window"); val != "" {
+		window, err := time.ParseDuration(val)
+		if err != nil || window < 0 {
+			http.Error(w, "Invalid window", http.StatusBadRequest)
+			return
+		}
+		cutoff := now.Add(-window)
+		predicates = append(predicates, func(entry types.TelemetryEntry) bool {
+			return entry.Timestamp.After(cutoff)
+		})
+	}
+	if pod := query.Get("pod"); pod != "" {
+		predicates = append(predicates, ringbuffer.MatchPod(pod))
+	}
+	if source := query.Get("source"); source != "" {
+		predicates = append(predicates, ringbuffer.MatchSource(types.TelemetrySource(source)))
+	}
+	if val := query.Get("type"); val != "" {
+		telemetryType := types.TelemetryType(val)
+		if !s.telemetryTypes.Valid(telemetryType) {
+			http.Error(w, fmt.Sprintf("Unknown telemetry type %q", val), http.StatusBadRequest)
+			return
+		}
+		predicates = append(predicates, ringbuffer.MatchType(telemetryType))
+	}
+	tags, err := parseTagFilters(query["tag"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(tags) > 0 {
+		predicates = append(predicates, ringbuffer.MatchTags(tags))
+	}
+
+	entries := s.querier.Filter(now, predicates...)
+	if entries == nil {
+		entries = []types.TelemetryEntry{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"count":   len(entries),
+		"entries": entries,
+	})
+}
+
+// DefaultTailTimeout is how long GET /api/v1/tail waits for new telemetry when
+// no timeout is given.
+const DefaultTailTimeout = 20 * time.Second
+
+// maxTailTimeout bounds a tail long-poll so it completes within the server's
+// write timeout.
+const maxTailTimeout = 25 * time.Second
+
+// TailMaxEntries bounds the entries returned by one tail request; clients
+// read the rest by polling again with the returned cursor.
+const TailMaxEntries = 1000
+
+// TailCursorHeader carries the cursor to pass as since in the next tail request.
+const TailCursorHeader = "X-Blackbox-Cursor"
+
+// handleTail long-polls for telemetry stored at or after the since cursor and
+// writes it as NDJSON, one entry per line, with the next cursor in the
+// TailCursorHeader header. Without since it waits for entries added from now
+// on. When nothing new arrives within the timeout it answers with an empty
+// body and the unchanged cursor. This suits clients behind proxies that do
+// not pass websockets.
+func (s *Server) handleTail(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.tailer == nil {
+		http.Error(w, "Telemetry tailing is not enabled", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	timeout := DefaultTailTimeout
+	if val := query.Get("timeout"); val != "" {
+		parsed, err := time.ParseDuration(val)
+		if err != nil || parsed < 0 || parsed > maxTailTimeout {
+			http.Error(w, fmt.Sprintf("Invalid timeout (expected a duration up to %v)", maxTailTimeout), http.StatusBadRequest)
+			return
+		}
+		timeout = parsed
+	}
+
+	// Subscribe before reading so entries added in between wake the poll
+	notify, unsubscribe := s.tailer.Subscribe()
+	defer unsubscribe()
+
+	cursor := s.tailer.Cursor()
+	if val := query.Get("since"); val != "" {
+		parsed, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid since cursor", http.StatusBadRequest)
+			return
+		}
+		cursor = parsed
+	}
+
+	entries, next := s.tailer.Since(cursor, TailMaxEntries)
+	if len(entries) == 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+	wait:
+		for len(entries) == 0 {
+			select {
+			case <-notify:
+				entries, next = s.tailer.Since(cursor, TailMaxEntries)
+			case <-timer.C:
+				break wait
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set(TailCursorHeader, strconv.FormatUint(next, 10))
+	encoder := json.NewEncoder(w)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			fmt.Printf("Error writing telemetry tail: %v\n", err)
+			return
+		}
+	}
+}
+
+// DefaultCompareWindow is the window compared by GET /api/v1/compare when none
+// is given.
+const DefaultCompareWindow = 5 * time.Minute
+
+// WindowStats aggregates the numeric values of a metric within one window of
+// a comparison. The value fields are null when the window has no numeric values.
+type WindowStats struct {
+	// Start and End bound the window (exclusive start, inclusive end)
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Count is the number of numeric values
+	Count int `json:"count"`
+	// Min, Max, Mean and Last aggregate the values
+	Min  *float64 `json:"min"`
+	Max  *float64 `json:"max"`
+	Mean *float64 `json:"mean"`
+	Last *float64 `json:"last"`
+}
+
+// WindowDelta is the change from the previous to the current window. Each
+// field is null when either window lacks the value, and each percentage is
+// also null when the previous value is zero.
+type WindowDelta struct {
+	Min         *float64 `json:"min"`
+	Max         *float64 `json:"max"`
+	Mean        *float64 `json:"mean"`
+	Last        *float64 `json:"last"`
+	MinPercent  *float64 `json:"min_percent"`
+	MaxPercent  *float64 `json:"max_percent"`
+	MeanPercent *float64 `json:"mean_percent"`
+	LastPercent *float64 `json:"last_percent"`
+}
+
+// WindowComparison is the response of GET /api/v1/compare.
+type WindowComparison struct {
+	// Name is the compared metric
+	Name string `json:"name"`
+	// Window is the length of each window
+	Window string `json:"window"`
+	// Offset is how far the previous window precedes the current one
+	Offset string `json:"offset"`
+	// Current ends now and Previous ends offset ago
+	Current  WindowStats `json:"current"`
+	Previous WindowStats `json:"previous"`
+	// Delta is the change from Previous to Current
+	Delta WindowDelta `json:"delta"`
+}
+
+// handleCompare compares a metric's values in the window ending now with the
+// window ending offset ago, e.g. CPU now against 5 minutes ago. name is
+// required; window defaults to DefaultCompareWindow and offset to the window,
+// comparing adjacent windows. Repeated tag=key:value parameters select series.
+func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if s.querier == nil {
+		http.Error(w, "Telemetry queries are not enabled", http.StatusNotFound)
+		return
+	}
+
+	query := r.URL.Query()
+	name := query.Get("name")
+	if name == "" {
+		http.Error(w, "Missing name", http.StatusBadRequest)
+		return
+	}
+	window := DefaultCompareWindow
+	if val := query.Get("window"); val != "" {
+		parsed, err := time.ParseDuration(val)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid window", http.StatusBadRequest)
+			return
+		}
+		window = parsed
+	}
+	offset := window
+	if val := query.Get("offset"); val != "" {
+		parsed, err := time.ParseDuration(val)
+		if err != nil || parsed <= 0 {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			return
+		}
+		offset = parsed
+	}
+	tags, err := parseTagFilters(query["tag"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	now := s.clock.Now()
+	matches := ringbuffer.MatchTags(tags)
+	windowStats := func(end time.Time) WindowStats {
+		start := end.Add(-window)
+		var entries []types.TelemetryEntry
+		for _, entry := range s.querier.GetWindowBetween(start, end) {
+			if entry.Name == name && matches(entry) {
+				entries = append(entries, entry)
+			}
+		}
+		return newWindowStats(start, end, ringbuffer.Aggregate(entries))
+	}
+	current := windowStats(now)
+	previous := windowStats(now.Add(-offset))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(WindowComparison{
+		Name:     name,
+		Window:   window.String(),
+		Offset:   offset.String(),
+		Current:  current,
+		Previous: previous,
+		Delta: WindowDelta{
+			Min:         difference(current.Min, previous.Min),
+			Max:         difference(current.Max, previous.Max),
+			Mean:        difference(current.Mean, previous.Mean),
+			Last:        difference(current.Last, previous.Last),
+			MinPercent:  percentChange(current.Min, previous.Min),
+			MaxPercent:  percentChange(current.Max, previous.Max),
+			MeanPercent: percentChange(current.Mean, previous.Mean),
+			LastPercent: percentChange(current.Last, previous.Last),
+		},
+	})
+}
+
+// newWindowStats returns the stats of the single metric aggregated in summary.
+func newWindowStats(start, end time.Time, summary ringbuffer.Summary) WindowStats {
+	stats := WindowStats{Start: start, End: end}
+	if len(summary.Metrics) == 0 || summary.Metrics[0].NumericCount == 0 {
+		return stats
+	}
+	metric := summary.Metrics[0]
+	stats.Count = metric.NumericCount
+	stats.Min, stats.Max, stats.Mean = &metric.Min, &metric.Max, &metric.Mean
+	if metric.Numeric {
+		stats.Last = &metric.LastNumber
+	}
+	return stats
+}
+
+// difference returns current minus previous, or nil when either is missing.
+func difference(current, previous *float64) *float64 {
+	if current == nil || previous == nil {
+		return nil
+	}
+	delta := *current - *previous
+	return &delta
+}
+
+// percentChange returns the change from previous to current in percent, or
+// nil when either is missing or previous is zero.
+func percentChange(current, previous *float64) *float64 {
+	if current == nil || previous == nil || *previous == 0 {
+		return nil
+	}
+	change := (*current - *previous) / math.Abs(*previous) * 100
+	return &change
+}
+
+// parseTagFilters parses tag=key:value query parameters into the tags an
+// entry must carry. The value may contain further colons.
+func parseTagFilters(values []string) (map[string]string, error) {
+	tags := make(map[string]string, len(values))
+	for _, value := range values {
+		key, tagValue, ok := strings.Cut(value, ":")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag filter %q: expected key:value", value)
+		}
+		tags[key] = tagValue
+	}
+	return tags, nil
+}
+
+// handleHealth provides a health check endpoint
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	deep := false
+	if value := r.URL.Query().Get("deep"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid deep parameter: %v", err), http.StatusBadRequest)
+			return
+		}
+		deep = parsed
+	}
+
+	status := "healthy"
+	code := http.StatusOK
+	response := map[string]interface{}{
+		"timestamp": s.clock.Now(),
+		"service":   "blackbox-daemon",
+		"version":   version.Version,
+		"commit":    version.Commit,
+	}
+	if deep {
+		checks := make(map[string]string, len(s.healthChecks))
+		for _, check := range s.healthChecks {
+			if err := check.checker.Check(); err != nil {
+				checks[check.name] = err.Error()
+				if check.degraded {
+					if status == "healthy" {
+						status = "degraded"
+					}
+					continue
+				}
+				status = "unhealthy"
+				code = http.StatusServiceUnavailable
+				continue
+			}
+			checks[check.name] = "healthy"
+		}
+		response["checks"] = checks
+	}
+	response["status"] = status
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleReady reports whether every readiness gate passes, answering 503
+// with the pending gates until they do.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	code := http.StatusOK
+	response := map[string]interface{}{
+		"timestamp": s.clock.Now(),
+		"status":    "ready",
+	}
+	pending := make(map[string]string)
+	for _, gate := range s.readinessGates {
+		if err := gate.checker.Check(); err != nil {
+			pending[gate.name] = err.Error()
+		}
+	}
+	if len(pending) > 0 {
+		code = http.StatusServiceUnavailable
+		response["status"] = "not_ready"
+		response["gates"] = pending
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleSwagger serves the Swagger/OpenAPI specification
+func (s *Server) handleSwagger(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	swagger := generateSwaggerSpec(s.basePath)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(swagger)
 }
 
 // handleSwaggerUI serves a basic Swagger UI
@@ -290,7 +1548,7 @@ func (s *Server) handleSwaggerUI(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	html := `<!DOCTYPE html>
+	html := fmt.Sprintf(`<!DOCTYPE html>
 <html>
 <head>
     <title>BlackBox Daemon API</title>
@@ -301,7 +1559,7 @@ func (s *Server) handleSwaggerUI(w http.ResponseWriter, r *http.Request) {
     <script src="https://unpkg.com/swagger-ui-dist@3.52.5/swagger-ui-bundle.js"></script>
     <script>
         SwaggerUIBundle({
-            url: '/swagger.json',
+            url: '%s',
             dom_id: '#swagger-ui',
             presets: [
                 SwaggerUIBundle.presets.apis,
@@ -310,14 +1568,15 @@ func (s *Server) handleSwaggerUI(w http.ResponseWriter, r *http.Request) {
         });
     </script>
 </body>
-</html>`
+</html>`, s.path("/swagger.json"))
 
 	w.Header().Set("Content-Type", "text/html")
 	w.Write([]byte(html))
 }
 
-// generateSwaggerSpec creates the OpenAPI specification
-func generateSwaggerSpec() map[string]interface{} {
+// generateSwaggerSpec creates the OpenAPI specification. The base path is part
+// of the server URL, so the documented paths stay relative to it.
+func generateSwaggerSpec(basePath string) map[string]interface{} {
 	return map[string]interface{}{
 		"openapi": "3.0.0",
 		"info": map[string]interface{}{
@@ -327,7 +1586,7 @@ func generateSwaggerSpec() map[string]interface{} {
 		},
 		"servers": []map[string]interface{}{
 			{
-				"url":         "http://localhost:8080",
+				"url":         "http://localhost:8080" + basePath,
 				"description": "BlackBox Daemon API Server",
 			},
 		},
@@ -344,6 +1603,7 @@ func generateSwaggerSpec() map[string]interface{} {
 					"description": "API key for sidecar authentication",
 				},
 			},
+			"schemas": swaggerSchemas(),
 		},
 		"paths": map[string]interface{}{
 			"/api/v1/telemetry": map[string]interface{}{
@@ -373,6 +1633,51 @@ func generateSwaggerSpec() map[string]interface{} {
 						"401": map[string]interface{}{
 							"description": "Unauthorized",
 						},
+						"403": map[string]interface{}{
+							"description": "API key bound to another namespace",
+						},
+						"404": map[string]interface{}{
+							"description": "Telemetry ingestion is not enabled (read-only server)",
+						},
+					},
+				},
+			},
+			"/api/v1/telemetry/batch": map[string]interface{}{
+				"post": map[string]interface{}{
+					"summary":     "Submit a batch of sidecar telemetry",
+					"description": "Submit an array of sidecar telemetry submissions, decoded and buffered one element at a time",
+					"security": []map[string]interface{}{
+						{"bearerAuth": []string{}},
+					},
+					"requestBody": map[string]interface{}{
+						"required": true,
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{
+								"schema": map[string]interface{}{
+									"type": "array",
+									"items": map[string]interface{}{
+										"$ref": "#/components/schemas/SidecarTelemetry",
+									},
+								},
+							},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Batch accepted",
+						},
+						"400": map[string]interface{}{
+							"description": "Invalid request",
+						},
+						"401": map[string]interface{}{
+							"description": "Unauthorized",
+						},
+						"404": map[string]interface{}{
+							"description": "Telemetry ingestion is not enabled (read-only server)",
+						},
+						"413": map[string]interface{}{
+							"description": "Request body too large",
+						},
 					},
 				},
 			},
@@ -395,7 +1700,7 @@ func generateSwaggerSpec() map[string]interface{} {
 					},
 					"responses": map[string]interface{}{
 						"200": map[string]interface{}{
-							"description": "Incident accepted",
+							"description": "Incident accepted; the response carries its incident_id and schema_version",
 						},
 						"400": map[string]interface{}{
 							"description": "Invalid request",
@@ -403,17 +1708,322 @@ func generateSwaggerSpec() map[string]interface{} {
 						"401": map[string]interface{}{
 							"description": "Unauthorized",
 						},
+						"403": map[string]interface{}{
+							"description": "API key bound to another namespace",
+						},
+						"404": map[string]interface{}{
+							"description": "Incident reporting is not enabled (read-only server)",
+						},
+					},
+				},
+			},
+			"/api/v1/admin/flush": map[string]interface{}{
+				"post": map[string]interface{}{
+					"summary":     "Flush queues and buffers",
+					"description": "Drain asynchronous emitter queues and snapshot the buffer to disk, if configured, without stopping the daemon",
+					"security": []map[string]interface{}{
+						{"bearerAuth": []string{}},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Everything was flushed; the body counts the items flushed per component",
+						},
+						"401": map[string]interface{}{
+							"description": "Unauthorized",
+						},
+						"500": map[string]interface{}{
+							"description": "A component failed to flush",
+						},
+					},
+				},
+			},
+			"/api/v1/admin/replay-incidents": map[string]interface{}{
+				"post": map[string]interface{}{
+					"summary":     "Replay stored incidents",
+					"description": "Resubmit stored incidents through the formatter chain and emitters, e.g. after an emitter destination recovers; replayed incidents are not stored again",
+					"security": []map[string]interface{}{
+						{"bearerAuth": []string{}},
+					},
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "since",
+							"in":          "query",
+							"description": "RFC 3339 time or duration before now, e.g. 2h, of the oldest incident to replay (defaults to the whole history)",
+							"schema":      map[string]interface{}{"type": "string"},
+						},
+						{
+							"name":        "dry_run",
+							"in":          "query",
+							"description": "Only count the incidents that would be replayed",
+							"schema":      map[string]interface{}{"type": "boolean"},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "The incidents were replayed, or counted in a dry run",
+						},
+						"400": map[string]interface{}{
+							"description": "Invalid since or dry_run",
+						},
+						"401": map[string]interface{}{
+							"description": "Unauthorized",
+						},
+						"404": map[string]interface{}{
+							"description": "Incident history is not enabled",
+						},
+					},
+				},
+			},
+			"/api/v1/telemetry/query": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary":     "Query buffered telemetry",
+					"description": "Return the buffered telemetry entries matching every filter",
+					"security": []map[string]interface{}{
+						{"bearerAuth": []string{}},
+					},
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "window",
+							"in":          "query",
+							"description": "Duration of telemetry to return, e.g. 5m (defaults to the whole buffer)",
+							"schema":      map[string]interface{}{"type": "string"},
+						},
+						{
+							"name":        "pod",
+							"in":          "query",
+							"description": "Pod name the entries belong to",
+							"schema":      map[string]interface{}{"type": "string"},
+						},
+						{
+							"name":        "source",
+							"in":          "query",
+							"description": "Telemetry source, e.g. system or sidecar",
+							"schema":      map[string]interface{}{"type": "string"},
+						},
+						{
+							"name":        "type",
+							"in":          "query",
+							"description": "Telemetry type, built-in (e.g. memory) or custom",
+							"schema":      map[string]interface{}{"type": "string"},
+						},
+						{
+							"name":        "tag",
+							"in":          "query",
+							"description": "Tag filter as key:value, e.g. interface:eth0; repeat to require several tags",
+							"schema":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+							"explode":     true,
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Matching telemetry entries",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{
+										"type": "object",
+										"properties": map[string]interface{}{
+											"count": map[string]interface{}{"type": "integer"},
+											"entries": map[string]interface{}{
+												"type":  "array",
+												"items": map[string]interface{}{"$ref": "#/components/schemas/TelemetryEntry"},
+											},
+										},
+									},
+								},
+							},
+						},
+						"400": map[string]interface{}{
+							"description": "Invalid window, type or tag filter",
+						},
+						"401": map[string]interface{}{
+							"description": "Unauthorized",
+						},
+						"404": map[string]interface{}{
+							"description": "Telemetry queries are not enabled",
+						},
+					},
+				},
+			},
+			"/api/v1/tail": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary":     "Long-poll for new telemetry",
+					"description": "Return telemetry stored at or after a cursor as NDJSON, waiting up to the timeout for new entries; the next cursor is returned in the X-Blackbox-Cursor header",
+					"security": []map[string]interface{}{
+						{"bearerAuth": []string{}},
+					},
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "since",
+							"in":          "query",
+							"description": "Cursor returned by the previous request (defaults to entries added from now on)",
+							"schema":      map[string]interface{}{"type": "integer", "minimum": 0},
+						},
+						{
+							"name":        "timeout",
+							"in":          "query",
+							"description": "How long to wait for new entries, e.g. 10s (defaults to 20s, at most 25s)",
+							"schema":      map[string]interface{}{"type": "string"},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "New telemetry entries as NDJSON, empty when none arrived within the timeout",
+						},
+						"400": map[string]interface{}{
+							"description": "Invalid cursor or timeout",
+						},
+						"401": map[string]interface{}{
+							"description": "Unauthorized",
+						},
+						"404": map[string]interface{}{
+							"description": "Telemetry tailing is not enabled",
+						},
+					},
+				},
+			},
+			"/api/v1/compare": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary":     "Compare a metric across two windows",
+					"description": "Return the min, max, mean and last value of a metric in the window ending now and the window ending offset ago, with their change",
+					"security": []map[string]interface{}{
+						{"bearerAuth": []string{}},
+					},
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "name",
+							"in":          "query",
+							"required":    true,
+							"description": "Metric name, e.g. cpu_usage_percent",
+							"schema":      map[string]interface{}{"type": "string"},
+						},
+						{
+							"name":        "window",
+							"in":          "query",
+							"description": "Length of each window, e.g. 5m (defaults to 5m)",
+							"schema":      map[string]interface{}{"type": "string"},
+						},
+						{
+							"name":        "offset",
+							"in":          "query",
+							"description": "How far the previous window precedes the current one, e.g. 1h (defaults to the window)",
+							"schema":      map[string]interface{}{"type": "string"},
+						},
+						{
+							"name":        "tag",
+							"in":          "query",
+							"description": "Tag filter as key:value, e.g. core:0; repeat to require several tags",
+							"schema":      map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+							"explode":     true,
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Stats of both windows and their change",
+						},
+						"400": map[string]interface{}{
+							"description": "Missing name, or invalid window, offset or tag filter",
+						},
+						"401": map[string]interface{}{
+							"description": "Unauthorized",
+						},
+						"404": map[string]interface{}{
+							"description": "Telemetry queries are not enabled",
+						},
+					},
+				},
+			},
+			"/api/v1/export/folded": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary":     "Export per-process CPU as folded stacks",
+					"description": "Export the CPU time of tracked processes as \"comm;pid ticks\" lines for flame-graph tools; requires the per-process collector",
+					"security": []map[string]interface{}{
+						{"bearerAuth": []string{}},
+					},
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "window",
+							"in":          "query",
+							"description": "Duration of telemetry to export, e.g. 5m (defaults to the whole buffer)",
+							"schema":      map[string]interface{}{"type": "string"},
+						},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Folded stacks, one per process",
+						},
+						"400": map[string]interface{}{
+							"description": "Invalid window",
+						},
+						"401": map[string]interface{}{
+							"description": "Unauthorized",
+						},
+						"404": map[string]interface{}{
+							"description": "The per-process collector is not enabled",
+						},
+					},
+				},
+			},
+			"/api/v1/buffer/history": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary":     "Buffer statistics history",
+					"description": "Return recent samples of the buffer's entry count and actual window, oldest first, to follow fill trends over time",
+					"security": []map[string]interface{}{
+						{"bearerAuth": []string{}},
+					},
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Sampled buffer statistics",
+							"content": map[string]interface{}{
+								"application/json": map[string]interface{}{
+									"schema": map[string]interface{}{"$ref": "#/components/schemas/BufferHistory"},
+								},
+							},
+						},
+						"401": map[string]interface{}{
+							"description": "Unauthorized",
+						},
+						"404": map[string]interface{}{
+							"description": "Buffer history is not enabled",
+						},
 					},
 				},
 			},
 			"/api/v1/health": map[string]interface{}{
 				"get": map[string]interface{}{
 					"summary":     "Health check",
-					"description": "Check if the BlackBox daemon is healthy",
+					"description": "Check if the BlackBox daemon is healthy; deep=true also runs the registered dependency checks, e.g. critical emitters",
+					"parameters": []map[string]interface{}{
+						{
+							"name":        "deep",
+							"in":          "query",
+							"description": "Run the dependency checks",
+							"schema":      map[string]interface{}{"type": "boolean"},
+						},
+					},
 					"responses": map[string]interface{}{
 						"200": map[string]interface{}{
 							"description": "Service is healthy",
 						},
+						"400": map[string]interface{}{
+							"description": "Invalid deep parameter",
+						},
+						"503": map[string]interface{}{
+							"description": "A dependency check failed",
+						},
+					},
+				},
+			},
+			"/api/v1/ready": map[string]interface{}{
+				"get": map[string]interface{}{
+					"summary":     "Readiness check",
+					"description": "Check if the BlackBox daemon is ready, e.g. the system collector finished its first collection cycle",
+					"responses": map[string]interface{}{
+						"200": map[string]interface{}{
+							"description": "Service is ready",
+						},
+						"503": map[string]interface{}{
+							"description": "A readiness gate is pending",
+						},
 					},
 				},
 			},
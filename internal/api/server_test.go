@@ -6,12 +6,25 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/incidentstore"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/metrics"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/recovery"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/ringbuffer"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/version"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/emitter"
 	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
 )
 
@@ -21,8 +34,9 @@ type mockTelemetryBuffer struct {
 }
 
 // Add records telemetry entries for test validation.
-func (m *mockTelemetryBuffer) Add(entry types.TelemetryEntry) {
+func (m *mockTelemetryBuffer) Add(entry types.TelemetryEntry) error {
 	m.entries = append(m.entries, entry)
+	return nil
 }
 
 // mockIncidentHandler implements IncidentHandler for testing.
@@ -35,6 +49,14 @@ func (m *mockIncidentHandler) HandleIncident(report types.IncidentReport) {
 	m.reports = append(m.reports, report)
 }
 
+// incidentHandlerFunc adapts a function to IncidentHandler for testing.
+type incidentHandlerFunc func(report types.IncidentReport)
+
+// HandleIncident calls the function with the report.
+func (f incidentHandlerFunc) HandleIncident(report types.IncidentReport) {
+	f(report)
+}
+
 // setupTestServer creates a test server with mock dependencies for testing API endpoints.
 func setupTestServer() (*Server, *mockTelemetryBuffer, *mockIncidentHandler) {
 	buffer := &mockTelemetryBuffer{}
@@ -65,6 +87,21 @@ func TestNewServer(t *testing.T) {
 	}
 }
 
+// TestBindAddress validates listening on a configured host or IP address.
+func TestBindAddress(t *testing.T) {
+	tests := map[string]string{
+		"127.0.0.1": "127.0.0.1:8080",
+		"::1":       "[::1]:8080",
+		"localhost": "localhost:8080",
+	}
+	for host, expected := range tests {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithBindAddress(host))
+		if server.httpServer.Addr != expected {
+			t.Errorf("Expected server address %q, got %q", expected, server.httpServer.Addr)
+		}
+	}
+}
+
 // TestAuthMiddleware validates authentication middleware functionality.
 func TestAuthMiddleware(t *testing.T) {
 	server, _, _ := setupTestServer()
@@ -136,6 +173,87 @@ func TestAuthMiddleware(t *testing.T) {
 	})
 }
 
+// TestNamespaceKeys validates that namespace-scoped API keys only submit data
+// for their namespace.
+func TestNamespaceKeys(t *testing.T) {
+	buffer := &mockTelemetryBuffer{}
+	incidents := &mockIncidentHandler{}
+	server := NewServer(8080, "test-api-key-123", buffer, incidents, false,
+		WithNamespaceKeys(map[string]string{"ns-a": "key-a", "ns-b": "key-b"}))
+
+	request := func(method, path, key string, body interface{}) *httptest.ResponseRecorder {
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest(method, path, bytes.NewReader(data))
+		req.Header.Set("Authorization", "Bearer "+key)
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+	telemetry := func(namespace string) types.SidecarTelemetry {
+		return types.SidecarTelemetry{
+			PodName:   "api-1",
+			Namespace: namespace,
+			Runtime:   "go",
+			Timestamp: time.Now(),
+			Data:      map[string]interface{}{"goroutines": 12},
+		}
+	}
+
+	t.Run("accepts telemetry for the key's namespace", func(t *testing.T) {
+		if w := request("POST", "/api/v1/telemetry", "key-a", telemetry("ns-a")); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("rejects telemetry for another namespace", func(t *testing.T) {
+		before := len(buffer.entries)
+		w := request("POST", "/api/v1/telemetry", "key-a", telemetry("ns-b"))
+		if w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", w.Code)
+		}
+		if len(buffer.entries) != before {
+			t.Error("Expected no telemetry buffered for another namespace")
+		}
+	})
+
+	t.Run("rejects batch elements for another namespace", func(t *testing.T) {
+		w := request("POST", "/api/v1/telemetry/batch", "key-a", []types.SidecarTelemetry{telemetry("ns-a"), telemetry("ns-b")})
+		var response map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&response)
+		if response["accepted"] != float64(1) || response["rejected"] != float64(1) {
+			t.Errorf("Expected 1 accepted and 1 rejected, got %v", response)
+		}
+	})
+
+	t.Run("rejects incidents for another namespace", func(t *testing.T) {
+		w := request("POST", "/api/v1/incident", "key-b", types.IncidentReport{PodName: "api-1", Namespace: "ns-a", Message: "stuck"})
+		if w.Code != http.StatusForbidden || len(incidents.reports) != 0 {
+			t.Errorf("Expected status 403 and no incident, got %d and %d incidents", w.Code, len(incidents.reports))
+		}
+		if w := request("POST", "/api/v1/incident", "key-b", types.IncidentReport{PodName: "api-1", Namespace: "ns-b", Message: "stuck"}); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("keeps the API key unrestricted", func(t *testing.T) {
+		if w := request("POST", "/api/v1/telemetry", "test-api-key-123", telemetry("ns-b")); w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+
+	t.Run("limits namespace keys to submissions", func(t *testing.T) {
+		if w := request("POST", "/api/v1/admin/flush", "key-a", nil); w.Code != http.StatusForbidden {
+			t.Errorf("Expected status 403, got %d", w.Code)
+		}
+	})
+
+	t.Run("rejects unknown keys", func(t *testing.T) {
+		if w := request("POST", "/api/v1/telemetry", "key-c", telemetry("ns-a")); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+}
+
 // TestHandleTelemetry validates telemetry endpoint functionality and processing.
 func TestHandleTelemetry(t *testing.T) {
 	server, buffer, _ := setupTestServer()
@@ -375,6 +493,19 @@ func TestHandleIncident(t *testing.T) {
 			t.Errorf("Expected status 400, got %d", w.Code)
 		}
 	})
+
+	t.Run("answers 503 without an incident handler", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, nil, false)
+		req := httptest.NewRequest("POST", "/api/v1/incident", strings.NewReader(`{"message":"manual"}`))
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "not configured") {
+			t.Errorf("Expected status 503 explaining the missing handler, got %d: %s", w.Code, w.Body.String())
+		}
+	})
 }
 
 // TestHandleHealth validates the health check endpoint.
@@ -417,6 +548,262 @@ func TestHandleHealth(t *testing.T) {
 	})
 }
 
+// failingEmitter is an emitter whose Emit result can be toggled.
+type failingEmitter struct {
+	mutex   sync.Mutex
+	failing bool
+}
+
+func (fe *failingEmitter) setFailing(failing bool) {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+	fe.failing = failing
+}
+
+func (fe *failingEmitter) Emit(data []byte) error {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+	if fe.failing {
+		return fmt.Errorf("connection refused")
+	}
+	return nil
+}
+
+func (fe *failingEmitter) Name() string { return "webhook" }
+func (fe *failingEmitter) Close() error { return nil }
+
+// TestDeepHealthCheck validates that the deep health check fails with a failing critical emitter.
+func TestDeepHealthCheck(t *testing.T) {
+	critical := &failingEmitter{}
+	optional := &failingEmitter{failing: true}
+	criticalMonitor := emitter.NewMonitoredEmitter(critical, true, 2)
+	optionalMonitor := emitter.NewMonitoredEmitter(optional, false, 2)
+	server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false,
+		WithHealthCheck("emitter_webhook", criticalMonitor), WithHealthCheck("emitter_file", optionalMonitor))
+
+	deepHealth := func() (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/api/v1/health?deep=true", nil)
+		w := httptest.NewRecorder()
+		server.handleHealth(w, req)
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse JSON response: %v", err)
+		}
+		return w.Code, response
+	}
+
+	for i := 0; i < 3; i++ {
+		optionalMonitor.Emit([]byte("incident"))
+	}
+
+	t.Run("healthy while the critical emitter works", func(t *testing.T) {
+		criticalMonitor.Emit([]byte("incident"))
+
+		code, response := deepHealth()
+		if code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", code)
+		}
+		checks, _ := response["checks"].(map[string]interface{})
+		if checks["emitter_webhook"] != "healthy" || checks["emitter_file"] != "healthy" {
+			t.Errorf("Expected both checks healthy, got %v", response["checks"])
+		}
+	})
+
+	t.Run("unhealthy once the critical emitter keeps failing", func(t *testing.T) {
+		critical.setFailing(true)
+		criticalMonitor.Emit([]byte("incident"))
+		criticalMonitor.Emit([]byte("incident"))
+
+		code, response := deepHealth()
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", code)
+		}
+		if response["status"] != "unhealthy" {
+			t.Errorf("Expected status 'unhealthy', got %v", response["status"])
+		}
+		checks, _ := response["checks"].(map[string]interface{})
+		if message, _ := checks["emitter_webhook"].(string); !strings.Contains(message, "connection refused") {
+			t.Errorf("Expected the emitter error, got %v", checks["emitter_webhook"])
+		}
+	})
+
+	t.Run("shallow check ignores dependencies", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/health", nil)
+		w := httptest.NewRecorder()
+		server.handleHealth(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		if strings.Contains(w.Body.String(), "checks") {
+			t.Errorf("Expected no checks in shallow response, got %s", w.Body.String())
+		}
+	})
+
+	t.Run("recovers after a successful emit", func(t *testing.T) {
+		critical.setFailing(false)
+		criticalMonitor.Emit([]byte("incident"))
+
+		if code, _ := deepHealth(); code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", code)
+		}
+	})
+
+	t.Run("rejects an invalid deep parameter", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/api/v1/health?deep=maybe", nil)
+		w := httptest.NewRecorder()
+		server.handleHealth(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
+
+// TestReadiness validates that the readiness endpoint reports not ready until
+// the system collector finished its first collection cycle.
+func TestReadiness(t *testing.T) {
+	collector := telemetry.NewSystemCollector(time.Hour, ringbuffer.New(time.Hour))
+	server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false,
+		WithReadinessGate("collector", collector))
+
+	ready := func() (int, map[string]interface{}) {
+		req := httptest.NewRequest("GET", "/api/v1/ready", nil)
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse JSON response: %v", err)
+		}
+		return w.Code, response
+	}
+
+	// start runs the collector until the returned function stops it
+	start := func() func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- collector.Start(ctx) }()
+		return func() {
+			cancel()
+			if err := <-done; err != context.Canceled {
+				t.Errorf("Expected context.Canceled, got %v", err)
+			}
+		}
+	}
+
+	t.Run("not ready during the startup delay", func(t *testing.T) {
+		collector.SetStartupDelay(time.Hour)
+		stop := start()
+		defer stop()
+
+		code, response := ready()
+		if code != http.StatusServiceUnavailable {
+			t.Errorf("Expected status 503, got %d", code)
+		}
+		gates, _ := response["gates"].(map[string]interface{})
+		if response["status"] != "not_ready" || gates["collector"] == nil {
+			t.Errorf("Expected the collector gate pending, got %v", response)
+		}
+	})
+
+	t.Run("ready after the first cycle without authentication", func(t *testing.T) {
+		collector.SetStartupDelay(0)
+		stop := start()
+		defer stop()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := collector.WaitReady(ctx); err != nil {
+			t.Fatalf("Expected the first cycle to finish, got %v", err)
+		}
+
+		code, response := ready()
+		if code != http.StatusOK || response["status"] != "ready" {
+			t.Errorf("Expected status 200 ready, got %d %v", code, response)
+		}
+	})
+}
+
+// TestDegradedMetrics validates that the daemon keeps ingesting telemetry with
+// the metrics subsystem degraded when the metrics port is taken.
+func TestDegradedMetrics(t *testing.T) {
+	taken, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer taken.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	collector := metrics.NewCollector(taken.Addr().(*net.TCPAddr).Port, "/metrics")
+	if err := collector.Run(ctx, false); err != nil {
+		t.Fatalf("Expected the metrics bind failure to be non-fatal, got %v", err)
+	}
+
+	// Listening before serving queues the requests below until Serve accepts them
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	buffer := &mockTelemetryBuffer{}
+	server := NewServer(0, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithDegradedCheck("metrics", collector))
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- server.Serve(ctx, listener)
+	}()
+	base := "http://" + listener.Addr().String()
+
+	health, err := http.Get(base + "/api/v1/health?deep=true")
+	if err != nil {
+		t.Fatalf("Expected the API server running, got %v", err)
+	}
+	var response map[string]interface{}
+	json.NewDecoder(health.Body).Decode(&response)
+	health.Body.Close()
+	if health.StatusCode != http.StatusOK || response["status"] != "degraded" {
+		t.Errorf("Expected 200 and status 'degraded', got %d and %v", health.StatusCode, response["status"])
+	}
+	checks, _ := response["checks"].(map[string]interface{})
+	if message, _ := checks["metrics"].(string); !strings.Contains(message, "address already in use") {
+		t.Errorf("Expected the bind error in the metrics check, got %v", checks["metrics"])
+	}
+
+	body, _ := json.Marshal(types.SidecarTelemetry{
+		PodName:   "api-1",
+		Namespace: "default",
+		Runtime:   "go",
+		Timestamp: time.Now(),
+		Data:      map[string]interface{}{"goroutines": 12},
+	})
+	req, _ := http.NewRequest("POST", base+"/api/v1/telemetry", bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer test-api-key-123")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("Expected telemetry accepted, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	cancel()
+	if err := <-errCh; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+
+	t.Run("API bind failure is fatal only when required", func(t *testing.T) {
+		server := NewServer(taken.Addr().(*net.TCPAddr).Port, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false)
+		if err := server.Run(context.Background(), false); err != nil {
+			t.Errorf("Expected an optional API server to continue, got %v", err)
+		}
+		if err := server.Run(context.Background(), true); err == nil {
+			t.Error("Expected a bind error for a required API server")
+		}
+	})
+}
+
 // TestInferTelemetryType validates telemetry type inference logic.
 func TestInferTelemetryType(t *testing.T) {
 	server, _, _ := setupTestServer()
@@ -490,23 +877,44 @@ func TestSwaggerEndpoints(t *testing.T) {
 			t.Errorf("Expected OpenAPI version 3.0.0, got %v", spec["openapi"])
 		}
 	})
+
+	t.Run("swagger UI sub-paths are public when enabled", func(t *testing.T) {
+		server := NewServer(8080, "test-key", &mockTelemetryBuffer{}, &mockIncidentHandler{}, true)
+
+		for _, path := range []string{"/swagger/", "/swagger/index.html", "/swagger/docs/oauth2-redirect"} {
+			w := httptest.NewRecorder()
+			server.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+			if w.Code != http.StatusOK {
+				t.Errorf("Expected status 200 for %s without a token, got %d", path, w.Code)
+			}
+		}
+	})
+
+	t.Run("swagger UI sub-paths require auth when disabled", func(t *testing.T) {
+		server, _, _ := setupTestServer()
+
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/swagger/index.html", nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("prefix exemption does not cover lookalike paths", func(t *testing.T) {
+		server := NewServer(8080, "test-key", &mockTelemetryBuffer{}, &mockIncidentHandler{}, true)
+
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/swagger-admin", nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
 }
 
 // TestServerIntegration validates end-to-end server functionality.
 func TestServerIntegration(t *testing.T) {
 	server, buffer, handler := setupTestServer()
 	
-	// Start server in background
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
-	
-	go func() {
-		server.Start(ctx)
-	}()
-	
-	// Give server time to start
-	time.Sleep(10 * time.Millisecond)
-	
 	t.Run("full telemetry submission workflow", func(t *testing.T) {
 		telemetryData := types.SidecarTelemetry{
 			PodName:   "integration-test-pod",
@@ -562,4 +970,1518 @@ func TestServerIntegration(t *testing.T) {
 			t.Error("Expected incident report to be processed")
 		}
 	})
-}
\ No newline at end of file
+}
+// TestServerClock validates that injected clocks drive generated timestamps and IDs.
+func TestServerClock(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	fakeClock := clock.NewFake(now)
+	buffer := &mockTelemetryBuffer{}
+	handler := &mockIncidentHandler{}
+	server := NewServer(8080, "test-api-key-123", buffer, handler, false, WithClock(fakeClock))
+
+	t.Run("stamps incidents with clock time", func(t *testing.T) {
+		jsonData, _ := json.Marshal(types.IncidentReport{PodName: "test-pod", Namespace: "default"})
+		req := httptest.NewRequest("POST", "/api/v1/incident", bytes.NewReader(jsonData))
+		w := httptest.NewRecorder()
+
+		server.handleIncident(w, req)
+
+		if len(handler.reports) != 1 {
+			t.Fatalf("Expected 1 incident report, got %d", len(handler.reports))
+		}
+		report := handler.reports[0]
+		if !report.Timestamp.Equal(now) {
+			t.Errorf("Expected timestamp %v, got %v", now, report.Timestamp)
+		}
+		if want := fmt.Sprintf("manual-%d", now.Unix()); report.ID != want {
+			t.Errorf("Expected ID %q, got %q", want, report.ID)
+		}
+	})
+
+	t.Run("stamps telemetry with clock time", func(t *testing.T) {
+		fakeClock.Advance(time.Minute)
+		jsonData, _ := json.Marshal(types.SidecarTelemetry{
+			PodName:   "test-pod",
+			Namespace: "default",
+			Runtime:   "go",
+			Data:      map[string]interface{}{"goroutines": 10},
+		})
+		req := httptest.NewRequest("POST", "/api/v1/telemetry", bytes.NewReader(jsonData))
+		w := httptest.NewRecorder()
+
+		server.handleTelemetry(w, req)
+
+		if len(buffer.entries) != 1 {
+			t.Fatalf("Expected 1 telemetry entry, got %d", len(buffer.entries))
+		}
+		if want := now.Add(time.Minute); !buffer.entries[0].Timestamp.Equal(want) {
+			t.Errorf("Expected timestamp %v, got %v", want, buffer.entries[0].Timestamp)
+		}
+	})
+}
+
+// mockBatchTelemetryBuffer implements BatchTelemetryBuffer and records batch calls.
+type mockBatchTelemetryBuffer struct {
+	mockTelemetryBuffer
+	batches int
+}
+
+// AddBatch records a batch insertion for test validation.
+func (m *mockBatchTelemetryBuffer) AddBatch(entries []types.TelemetryEntry) error {
+	m.batches++
+	m.entries = append(m.entries, entries...)
+	return nil
+}
+
+// TestTelemetryBatchInsert validates that sidecar submissions use a single batch insert when supported.
+func TestTelemetryBatchInsert(t *testing.T) {
+	buffer := &mockBatchTelemetryBuffer{}
+	server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false)
+
+	jsonData, _ := json.Marshal(types.SidecarTelemetry{
+		PodName:   "test-pod",
+		Namespace: "default",
+		Runtime:   "jvm",
+		Data: map[string]interface{}{
+			"heap_used":  1024,
+			"gc_count":   3,
+			"cpu_usage":  0.5,
+			"open_conns": 12,
+		},
+	})
+	req := httptest.NewRequest("POST", "/api/v1/telemetry", bytes.NewReader(jsonData))
+	w := httptest.NewRecorder()
+
+	server.handleTelemetry(w, req)
+
+	if buffer.batches != 1 {
+		t.Errorf("Expected 1 batch insert, got %d", buffer.batches)
+	}
+	if len(buffer.entries) != 4 {
+		t.Errorf("Expected 4 entries, got %d", len(buffer.entries))
+	}
+}
+
+// signalingTelemetryBuffer counts entries and signals when the first one arrives.
+type signalingTelemetryBuffer struct {
+	mutex sync.Mutex
+	count int
+	first chan struct{}
+}
+
+// Add counts the entry and signals on the first insertion.
+func (b *signalingTelemetryBuffer) Add(entry types.TelemetryEntry) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.count++
+	if b.count == 1 {
+		close(b.first)
+	}
+	return nil
+}
+
+// TestHandleTelemetryBatch validates streaming decode of batch telemetry uploads.
+func TestHandleTelemetryBatch(t *testing.T) {
+	element := func(i int) []byte {
+		data, _ := json.Marshal(types.SidecarTelemetry{
+			PodName:   fmt.Sprintf("pod-%d", i),
+			Namespace: "default",
+			Runtime:   "go",
+			Data:      map[string]interface{}{"goroutines": i},
+		})
+		return data
+	}
+
+	t.Run("buffers elements before the body is complete", func(t *testing.T) {
+		const total = 5000
+		buffer := &signalingTelemetryBuffer{first: make(chan struct{})}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false)
+
+		reader, writer := io.Pipe()
+		go func() {
+			writer.Write([]byte("["))
+			writer.Write(element(0))
+
+			// Only send the rest once the first element has been buffered
+			select {
+			case <-buffer.first:
+			case <-time.After(5 * time.Second):
+				writer.CloseWithError(fmt.Errorf("first element was not processed before the body completed"))
+				return
+			}
+
+			for i := 1; i < total; i++ {
+				writer.Write([]byte(","))
+				writer.Write(element(i))
+			}
+			writer.Write([]byte("]"))
+			writer.Close()
+		}()
+
+		req := httptest.NewRequest("POST", "/api/v1/telemetry/batch", reader)
+		w := httptest.NewRecorder()
+
+		server.handleTelemetryBatch(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if buffer.count != total {
+			t.Errorf("Expected %d entries, got %d", total, buffer.count)
+		}
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response["accepted"] != float64(total) {
+			t.Errorf("Expected %d accepted, got %v", total, response["accepted"])
+		}
+	})
+
+	t.Run("counts rejected elements", func(t *testing.T) {
+		server, buffer, _ := setupTestServer()
+
+		body := `[` + string(element(1)) + `,{"pod_name":"","namespace":"default","data":{"x":1}}]`
+		req := httptest.NewRequest("POST", "/api/v1/telemetry/batch", strings.NewReader(body))
+		w := httptest.NewRecorder()
+
+		server.handleTelemetryBatch(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response["accepted"] != float64(1) || response["rejected"] != float64(1) {
+			t.Errorf("Expected 1 accepted and 1 rejected, got %v and %v", response["accepted"], response["rejected"])
+		}
+		if len(buffer.entries) != 1 {
+			t.Errorf("Expected 1 entry, got %d", len(buffer.entries))
+		}
+	})
+
+	t.Run("rejects non-array body", func(t *testing.T) {
+		server, _, _ := setupTestServer()
+
+		req := httptest.NewRequest("POST", "/api/v1/telemetry/batch", bytes.NewReader(element(1)))
+		w := httptest.NewRecorder()
+
+		server.handleTelemetryBatch(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("enforces body size limit", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithMaxBatchBytes(1024))
+
+		var body bytes.Buffer
+		body.WriteString("[")
+		for i := 0; i < 100; i++ {
+			if i > 0 {
+				body.WriteString(",")
+			}
+			body.Write(element(i))
+		}
+		body.WriteString("]")
+
+		req := httptest.NewRequest("POST", "/api/v1/telemetry/batch", &body)
+		w := httptest.NewRecorder()
+
+		server.handleTelemetryBatch(w, req)
+
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected status 413, got %d", w.Code)
+		}
+	})
+
+	t.Run("rejects non-POST methods", func(t *testing.T) {
+		server, _, _ := setupTestServer()
+
+		req := httptest.NewRequest("GET", "/api/v1/telemetry/batch", nil)
+		w := httptest.NewRecorder()
+
+		server.handleTelemetryBatch(w, req)
+
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405, got %d", w.Code)
+		}
+	})
+}
+
+// TestBuildVersion validates that the injected build version is reported by health and incidents.
+func TestBuildVersion(t *testing.T) {
+	originalVersion, originalCommit := version.Version, version.Commit
+	defer func() { version.Version, version.Commit = originalVersion, originalCommit }()
+	version.Version, version.Commit = "v1.2.3", "abc1234"
+
+	t.Run("health returns injected version", func(t *testing.T) {
+		server, _, _ := setupTestServer()
+		req := httptest.NewRequest("GET", "/api/v1/health", nil)
+		w := httptest.NewRecorder()
+
+		server.handleHealth(w, req)
+
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse JSON response: %v", err)
+		}
+		if response["version"] != "v1.2.3" {
+			t.Errorf("Expected version 'v1.2.3', got %v", response["version"])
+		}
+		if response["commit"] != "abc1234" {
+			t.Errorf("Expected commit 'abc1234', got %v", response["commit"])
+		}
+	})
+
+	t.Run("incidents are tagged with version", func(t *testing.T) {
+		server, _, handler := setupTestServer()
+		req := httptest.NewRequest("POST", "/api/v1/incident", strings.NewReader(`{"message":"boom"}`))
+		w := httptest.NewRecorder()
+
+		server.handleIncident(w, req)
+
+		if len(handler.reports) != 1 {
+			t.Fatalf("Expected 1 incident, got %d", len(handler.reports))
+		}
+		if handler.reports[0].Context[version.ContextVersionKey] != "v1.2.3" {
+			t.Errorf("Expected blackbox_version 'v1.2.3', got %v", handler.reports[0].Context[version.ContextVersionKey])
+		}
+	})
+
+	t.Run("incidents carry the schema version", func(t *testing.T) {
+		server, _, handler := setupTestServer()
+		req := httptest.NewRequest("POST", "/api/v1/incident", strings.NewReader(`{"message":"boom","context":{"schema_version":0}}`))
+		w := httptest.NewRecorder()
+
+		server.handleIncident(w, req)
+
+		if version.SchemaVersion(handler.reports[0].Context) != version.IncidentSchemaVersion {
+			t.Errorf("Expected schema version %d, got %v", version.IncidentSchemaVersion, handler.reports[0].Context[version.ContextSchemaVersionKey])
+		}
+		var response map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse JSON response: %v", err)
+		}
+		if response["schema_version"] != float64(version.IncidentSchemaVersion) {
+			t.Errorf("Expected schema_version %d in the response, got %v", version.IncidentSchemaVersion, response["schema_version"])
+		}
+	})
+}
+
+// TestBasePath validates routing and auth exemptions under a base path prefix.
+func TestBasePath(t *testing.T) {
+	buffer := &mockTelemetryBuffer{}
+	handler := &mockIncidentHandler{}
+	server := NewServer(8080, "test-key", buffer, handler, true, WithBasePath("blackbox/"))
+
+	serve := func(method, path, body string, auth bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if auth {
+			req.Header.Set("Authorization", "Bearer test-key")
+		}
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("routes under prefix", func(t *testing.T) {
+		w := serve("POST", "/blackbox/api/v1/telemetry", `{"pod_name":"web-1","namespace":"default","data":{"heap_used":1}}`, true)
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+		if len(buffer.entries) != 1 {
+			t.Errorf("Expected 1 buffered entry, got %d", len(buffer.entries))
+		}
+	})
+
+	t.Run("does not serve unprefixed routes", func(t *testing.T) {
+		if w := serve("POST", "/api/v1/telemetry", `{}`, true); w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+
+	t.Run("exempts prefixed health and swagger from auth", func(t *testing.T) {
+		for _, path := range []string{"/blackbox/api/v1/health", "/blackbox/swagger.json", "/blackbox/swagger/"} {
+			if w := serve("GET", path, "", false); w.Code != http.StatusOK {
+				t.Errorf("Expected status 200 for %s, got %d", path, w.Code)
+			}
+		}
+	})
+
+	t.Run("requires auth for unprefixed health", func(t *testing.T) {
+		if w := serve("GET", "/api/v1/health", "", false); w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("reflects prefix in swagger", func(t *testing.T) {
+		w := serve("GET", "/blackbox/swagger.json", "", false)
+		var spec struct {
+			Servers []struct {
+				URL string `json:"url"`
+			} `json:"servers"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+			t.Fatalf("Failed to parse swagger spec: %v", err)
+		}
+		if len(spec.Servers) != 1 || spec.Servers[0].URL != "http://localhost:8080/blackbox" {
+			t.Errorf("Expected server URL with base path, got %+v", spec.Servers)
+		}
+
+		ui := serve("GET", "/blackbox/swagger/", "", false)
+		if !strings.Contains(ui.Body.String(), "url: '/blackbox/swagger.json'") {
+			t.Error("Expected Swagger UI to load the prefixed spec")
+		}
+	})
+
+	t.Run("normalizes base paths", func(t *testing.T) {
+		for input, expected := range map[string]string{"": "", "/": "", "blackbox": "/blackbox", "/a/b/": "/a/b"} {
+			if got := NormalizeBasePath(input); got != expected {
+				t.Errorf("Expected %q for %q, got %q", expected, input, got)
+			}
+		}
+	})
+}
+
+// panickingTelemetryBuffer panics on every Add to exercise panic recovery.
+type panickingTelemetryBuffer struct{}
+
+// Add panics unconditionally.
+func (panickingTelemetryBuffer) Add(entry types.TelemetryEntry) error {
+	panic("buffer corrupted")
+}
+
+// TestRecoverer validates that handler panics become self-incidents.
+func TestRecoverer(t *testing.T) {
+	handler := &mockIncidentHandler{}
+	recoverer := recovery.NewRecoverer(handler.HandleIncident)
+	server := NewServer(8080, "test-key", panickingTelemetryBuffer{}, handler, false, WithRecoverer(recoverer))
+
+	req := httptest.NewRequest("POST", "/api/v1/telemetry", strings.NewReader(`{"pod_name":"web-1","namespace":"default","data":{"heap_used":1}}`))
+	req.Header.Set("Authorization", "Bearer test-key")
+	w := httptest.NewRecorder()
+	server.httpServer.Handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status 500, got %d", w.Code)
+	}
+	if len(handler.reports) != 1 {
+		t.Fatalf("Expected 1 self-incident, got %d", len(handler.reports))
+	}
+	report := handler.reports[0]
+	if report.Type != recovery.IncidentSelfError || report.Context["component"] != "api" {
+		t.Errorf("Expected api self-incident, got %s %v", report.Type, report.Context["component"])
+	}
+	if stack, _ := report.Context["stack"].(string); !strings.Contains(stack, "processSidecarTelemetry") {
+		t.Errorf("Expected stack through the handler, got %q", stack)
+	}
+}
+
+// mockRequestRecorder counts recorded sidecar requests per pod.
+type mockRequestRecorder struct {
+	counts map[string]int
+}
+
+// RecordSidecarRequest counts a request under "namespace/pod/runtime".
+func (m *mockRequestRecorder) RecordSidecarRequest(pod, namespace, runtime string) {
+	m.counts[namespace+"/"+pod+"/"+runtime]++
+}
+
+// TestRequestRecorder validates per-sidecar request counting for single and batch submissions.
+func TestRequestRecorder(t *testing.T) {
+	recorder := &mockRequestRecorder{counts: make(map[string]int)}
+	server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithRequestRecorder(recorder))
+
+	single, _ := json.Marshal(types.SidecarTelemetry{PodName: "api-1", Namespace: "prod", Runtime: "jvm", Data: map[string]interface{}{"heap_used": 1}})
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		server.handleTelemetry(w, httptest.NewRequest("POST", "/api/v1/telemetry", bytes.NewReader(single)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+	}
+
+	batch := `[{"pod_name":"api-1","namespace":"prod","runtime":"jvm","data":{}},` +
+		`{"pod_name":"worker-1","namespace":"jobs","runtime":"go","data":{}},` +
+		`{"namespace":"jobs","runtime":"go","data":{}}]`
+	w := httptest.NewRecorder()
+	server.handleTelemetryBatch(w, httptest.NewRequest("POST", "/api/v1/telemetry/batch", strings.NewReader(batch)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	expected := map[string]int{"prod/api-1/jvm": 3, "jobs/worker-1/go": 1}
+	if len(recorder.counts) != len(expected) {
+		t.Errorf("Expected %d sidecars, got %v", len(expected), recorder.counts)
+	}
+	for sidecar, count := range expected {
+		if recorder.counts[sidecar] != count {
+			t.Errorf("Expected %d requests for %s, got %d", count, sidecar, recorder.counts[sidecar])
+		}
+	}
+}
+
+// asyncQueue is an asynchronous emitter queue delivering items on a worker goroutine.
+type asyncQueue struct {
+	items     chan string
+	pending   sync.WaitGroup
+	mutex     sync.Mutex
+	queued    int
+	delivered []string
+}
+
+// newAsyncQueue starts a queue whose worker delivers items on its own goroutine.
+func newAsyncQueue() *asyncQueue {
+	q := &asyncQueue{items: make(chan string, 100)}
+	go func() {
+		for item := range q.items {
+			q.mutex.Lock()
+			q.delivered = append(q.delivered, item)
+			q.queued--
+			q.mutex.Unlock()
+			q.pending.Done()
+		}
+	}()
+	return q
+}
+
+// enqueue queues an item for delivery.
+func (q *asyncQueue) enqueue(item string) {
+	q.mutex.Lock()
+	q.queued++
+	q.mutex.Unlock()
+	q.pending.Add(1)
+	q.items <- item
+}
+
+// Flush waits until every queued item has been delivered.
+func (q *asyncQueue) Flush(ctx context.Context) (int, error) {
+	q.mutex.Lock()
+	queued := q.queued
+	q.mutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return queued, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// failingFlusher always fails to flush.
+type failingFlusher struct{}
+
+// Flush returns an error.
+func (failingFlusher) Flush(ctx context.Context) (int, error) {
+	return 0, fmt.Errorf("snapshot directory is read-only")
+}
+
+// TestAdminFlush validates draining queues through the admin flush endpoint.
+func TestAdminFlush(t *testing.T) {
+	t.Run("drains the async queue", func(t *testing.T) {
+		queue := newAsyncQueue()
+		defer close(queue.items)
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithFlusher("emitter_queue", queue))
+
+		for i := 0; i < 20; i++ {
+			queue.enqueue(fmt.Sprintf("incident-%d", i))
+		}
+
+		req := httptest.NewRequest("POST", "/api/v1/admin/flush", nil)
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		queue.mutex.Lock()
+		delivered := len(queue.delivered)
+		queue.mutex.Unlock()
+		if delivered != 20 {
+			t.Errorf("Expected all 20 items delivered after flush, got %d", delivered)
+		}
+
+		var response struct {
+			Status  string         `json:"status"`
+			Flushed map[string]int `json:"flushed"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Status != "flushed" || response.Flushed["emitter_queue"] != 20 {
+			t.Errorf("Expected 20 flushed emitter_queue items, got %+v", response)
+		}
+	})
+
+	t.Run("requires authentication and POST", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false)
+
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/admin/flush", nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected status 401, got %d", w.Code)
+		}
+
+		req := httptest.NewRequest("GET", "/api/v1/admin/flush", nil)
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		w = httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected status 405, got %d", w.Code)
+		}
+	})
+
+	t.Run("reports flush failures", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithFlusher("buffer_snapshot", failingFlusher{}))
+
+		w := httptest.NewRecorder()
+		server.handleAdminFlush(w, httptest.NewRequest("POST", "/api/v1/admin/flush", nil))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500, got %d", w.Code)
+		}
+		if !strings.Contains(w.Body.String(), "snapshot directory is read-only") {
+			t.Errorf("Expected flush error in response, got %s", w.Body.String())
+		}
+	})
+}
+
+// mockSnapshotter returns fixed telemetry and records the requested window.
+type mockSnapshotter struct {
+	entries []types.TelemetryEntry
+	window  time.Duration
+}
+
+// Snapshot returns the fixed entries and records the window.
+func (m *mockSnapshotter) Snapshot(at time.Time, window time.Duration) []types.TelemetryEntry {
+	m.window = window
+	return m.entries
+}
+
+// TestExportFolded validates exporting per-process CPU telemetry as folded stacks.
+func TestExportFolded(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	sample := func(offset time.Duration, comm, pid string, seconds float64) types.TelemetryEntry {
+		return types.TelemetryEntry{
+			Timestamp: base.Add(offset),
+			Source:    types.SourceSystem,
+			Type:      types.TypeProcess,
+			Name:      "process_cpu_seconds_total",
+			Value:     seconds,
+			Tags:      map[string]string{"comm": comm, "pid": pid},
+		}
+	}
+
+	t.Run("exports folded stacks within the window", func(t *testing.T) {
+		source := &mockSnapshotter{entries: []types.TelemetryEntry{
+			sample(0, "envoy", "31", 2.0),
+			sample(time.Second, "envoy", "31", 2.4),
+			sample(0, "postgres", "1207", 50.0),
+			sample(time.Second, "postgres", "1207", 51.5),
+		}}
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithProcessProfile(source))
+
+		req := httptest.NewRequest("GET", "/api/v1/export/folded?window=5m", nil)
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if expected := "envoy;31 40\npostgres;1207 150\n"; w.Body.String() != expected {
+			t.Errorf("Expected %q, got %q", expected, w.Body.String())
+		}
+		if source.window != 5*time.Minute {
+			t.Errorf("Expected 5m window, got %v", source.window)
+		}
+		if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain") {
+			t.Errorf("Expected text/plain, got %s", contentType)
+		}
+	})
+
+	t.Run("rejects invalid window", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithProcessProfile(&mockSnapshotter{}))
+
+		w := httptest.NewRecorder()
+		server.handleExportFolded(w, httptest.NewRequest("GET", "/api/v1/export/folded?window=soon", nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("requires the per-process collector", func(t *testing.T) {
+		server, _, _ := setupTestServer()
+
+		w := httptest.NewRecorder()
+		server.handleExportFolded(w, httptest.NewRequest("GET", "/api/v1/export/folded", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+// limitRecorder records entry limit events in addition to sidecar requests.
+type limitRecorder struct {
+	mockRequestRecorder
+	actions map[string]int
+	dropped int
+}
+
+// RecordSidecarEntryLimit records the action and the dropped entries.
+func (m *limitRecorder) RecordSidecarEntryLimit(action string, dropped int) {
+	m.actions[action]++
+	m.dropped += dropped
+}
+
+// TestBufferFull validates answering 503 when the buffer drops telemetry.
+func TestBufferFull(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	newServer := func() *Server {
+		buffer := ringbuffer.New(time.Second, ringbuffer.WithClock(clock.NewFake(now)), ringbuffer.WithOverflowPolicy(ringbuffer.OverflowDropNewest))
+		filler := make([]types.TelemetryEntry, buffer.GetStats().BufferSize)
+		for i := range filler {
+			filler[i] = types.TelemetryEntry{Timestamp: now, Name: "filler", Value: i}
+		}
+		buffer.AddBatch(filler)
+		return NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithClock(clock.NewFake(now)))
+	}
+	post := func(server *Server, path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+	body := `{"pod_name": "api-1", "namespace": "prod", "runtime": "go", "data": {"goroutines": 42}}`
+
+	t.Run("single submission", func(t *testing.T) {
+		w := post(newServer(), "/api/v1/telemetry", body)
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+			t.Errorf("Expected 503 with Retry-After, got %d %q", w.Code, w.Header().Get("Retry-After"))
+		}
+	})
+
+	t.Run("batch submission", func(t *testing.T) {
+		w := post(newServer(), "/api/v1/telemetry/batch", "["+body+","+body+"]")
+		if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+			t.Fatalf("Expected 503 with Retry-After, got %d %q", w.Code, w.Header().Get("Retry-After"))
+		}
+		var response struct {
+			Status   string `json:"status"`
+			Rejected int    `json:"rejected"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if response.Status != "buffer_full" || response.Rejected != 2 {
+			t.Errorf("Expected 2 rejected with buffer_full, got %+v", response)
+		}
+	})
+}
+
+// TestTelemetryQuery validates filtering buffered telemetry by pod, source and tags.
+func TestTelemetryQuery(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	fakeClock := clock.NewFake(now)
+	buffer := ringbuffer.New(time.Hour, ringbuffer.WithClock(fakeClock))
+	add := func(offset time.Duration, source types.TelemetrySource, pod, name string, tags map[string]string) {
+		if pod != "" {
+			tags["pod_name"] = pod
+		}
+		buffer.Add(types.TelemetryEntry{
+			Timestamp: now.Add(offset),
+			Source:    source,
+			Type:      types.TypeNetwork,
+			Name:      name,
+			Value:     1,
+			Tags:      tags,
+		})
+	}
+	add(-10*time.Minute, types.SourceSystem, "", "network_rx_bytes", map[string]string{"interface": "eth0", "direction": "rx"})
+	add(-time.Minute, types.SourceSystem, "", "network_rx_bytes", map[string]string{"interface": "eth0", "direction": "rx"})
+	add(-time.Minute, types.SourceSystem, "", "network_tx_bytes", map[string]string{"interface": "eth0", "direction": "tx"})
+	add(-time.Minute, types.SourceSystem, "", "network_rx_bytes", map[string]string{"interface": "eth1", "direction": "rx"})
+	add(-time.Minute, types.SourceSidecar, "api-1", "http_rx_bytes", map[string]string{"interface": "eth0", "direction": "rx"})
+
+	server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithQuerier(buffer), WithClock(fakeClock))
+	query := func(t *testing.T, rawQuery string) (int, []types.TelemetryEntry) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/v1/telemetry/query?"+rawQuery, nil)
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+
+		var response struct {
+			Count   int                    `json:"count"`
+			Entries []types.TelemetryEntry `json:"entries"`
+		}
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+			if response.Count != len(response.Entries) {
+				t.Errorf("Expected count %d to match entries, got %d", len(response.Entries), response.Count)
+			}
+		}
+		return w.Code, response.Entries
+	}
+
+	t.Run("repeated tags must all match", func(t *testing.T) {
+		code, entries := query(t, "tag=interface:eth0&tag=direction:rx")
+		if code != http.StatusOK || len(entries) != 3 {
+			t.Fatalf("Expected 3 eth0 rx entries, got %d (status %d)", len(entries), code)
+		}
+		for _, entry := range entries {
+			if entry.Tags["interface"] != "eth0" || entry.Tags["direction"] != "rx" {
+				t.Errorf("Expected eth0 rx entry, got tags %v", entry.Tags)
+			}
+		}
+	})
+
+	t.Run("combines tags with source and window", func(t *testing.T) {
+		if _, entries := query(t, "tag=interface:eth0&source=system&window=5m"); len(entries) != 2 {
+			t.Errorf("Expected 2 recent system eth0 entries, got %d", len(entries))
+		}
+		_, entries := query(t, "tag=interface:eth0&source=sidecar&pod=api-1")
+		if len(entries) != 1 || entries[0].Name != "http_rx_bytes" {
+			t.Errorf("Expected the sidecar entry, got %+v", entries)
+		}
+	})
+
+	t.Run("returns an empty list without matches", func(t *testing.T) {
+		if code, entries := query(t, "tag=device:nvme0n1"); code != http.StatusOK || entries == nil || len(entries) != 0 {
+			t.Errorf("Expected an empty list, got %v (status %d)", entries, code)
+		}
+	})
+
+	t.Run("rejects malformed filters", func(t *testing.T) {
+		for _, rawQuery := range []string{"tag=interface", "tag=:eth0", "window=soon"} {
+			if code, _ := query(t, rawQuery); code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %q, got %d", rawQuery, code)
+			}
+		}
+	})
+
+	t.Run("is disabled without a querier", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false)
+		req := httptest.NewRequest("GET", "/api/v1/telemetry/query", nil)
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+// TestCompare validates comparing a metric across two time windows.
+func TestCompare(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	fakeClock := clock.NewFake(now)
+	buffer := ringbuffer.New(time.Hour, ringbuffer.WithClock(fakeClock))
+	add := func(offset time.Duration, name string, value interface{}, core string) {
+		buffer.Add(types.TelemetryEntry{
+			Timestamp: now.Add(offset),
+			Source:    types.SourceSystem,
+			Type:      types.TypeCPU,
+			Name:      name,
+			Value:     value,
+			Tags:      map[string]string{"core": core},
+		})
+	}
+	// Previous window (10m-5m ago): mean 40; current window (last 5m): mean 60
+	add(-9*time.Minute, "cpu_usage_percent", 30.0, "0")
+	add(-7*time.Minute, "cpu_usage_percent", 50.0, "0")
+	add(-4*time.Minute, "cpu_usage_percent", 50.0, "0")
+	add(-2*time.Minute, "cpu_usage_percent", 70.0, "0")
+	add(-2*time.Minute, "cpu_usage_percent", 99.0, "1")
+	add(-time.Minute, "memory_usage_percent", 10.0, "0")
+
+	server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithQuerier(buffer), WithClock(fakeClock))
+	compare := func(t *testing.T, rawQuery string) (int, WindowComparison) {
+		t.Helper()
+		req := httptest.NewRequest("GET", "/api/v1/compare?"+rawQuery, nil)
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+
+		var response WindowComparison
+		if w.Code == http.StatusOK {
+			if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return w.Code, response
+	}
+
+	t.Run("reports both windows and the delta", func(t *testing.T) {
+		code, response := compare(t, "name=cpu_usage_percent&window=5m&tag=core:0")
+		if code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", code)
+		}
+		if response.Offset != "5m0s" {
+			t.Errorf("Expected offset to default to the window, got %s", response.Offset)
+		}
+		if response.Current.Count != 2 || *response.Current.Mean != 60 || *response.Current.Last != 70 {
+			t.Errorf("Expected current mean 60 and last 70 over 2 values, got %+v", response.Current)
+		}
+		if response.Previous.Count != 2 || *response.Previous.Mean != 40 || *response.Previous.Min != 30 {
+			t.Errorf("Expected previous mean 40 and min 30 over 2 values, got %+v", response.Previous)
+		}
+		if *response.Delta.Mean != 20 || *response.Delta.MeanPercent != 50 {
+			t.Errorf("Expected mean up 20 (50%%), got %v (%v%%)", *response.Delta.Mean, *response.Delta.MeanPercent)
+		}
+		if !response.Current.End.Equal(now) || !response.Previous.End.Equal(now.Add(-5*time.Minute)) {
+			t.Errorf("Expected windows ending now and 5m ago, got %v and %v", response.Current.End, response.Previous.End)
+		}
+	})
+
+	t.Run("includes every series without tag filters", func(t *testing.T) {
+		_, response := compare(t, "name=cpu_usage_percent&window=5m")
+		if response.Current.Count != 3 || *response.Current.Max != 99 {
+			t.Errorf("Expected 3 current values with max 99, got %+v", response.Current)
+		}
+	})
+
+	t.Run("leaves values null for an empty window", func(t *testing.T) {
+		_, response := compare(t, "name=memory_usage_percent&window=5m")
+		if response.Previous.Count != 0 || response.Previous.Mean != nil {
+			t.Errorf("Expected an empty previous window, got %+v", response.Previous)
+		}
+		if response.Delta.Mean != nil || response.Delta.MeanPercent != nil {
+			t.Errorf("Expected no delta without a previous value, got %+v", response.Delta)
+		}
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		for _, rawQuery := range []string{"", "name=cpu_usage_percent&window=-5m", "name=cpu_usage_percent&offset=soon", "name=cpu_usage_percent&tag=core"} {
+			if code, _ := compare(t, rawQuery); code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %q, got %d", rawQuery, code)
+			}
+		}
+	})
+
+	t.Run("requires a querier", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false)
+		req := httptest.NewRequest("GET", "/api/v1/compare?name=cpu_usage_percent", nil)
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+// subscribedTailer signals subscriptions to the buffer it wraps, so tests
+// can add entries once a long-poll is waiting for them.
+type subscribedTailer struct {
+	*ringbuffer.RingBuffer
+	// subscribed, when set, receives a value after each subscription
+	subscribed chan struct{}
+}
+
+// Subscribe subscribes to the buffer and signals the subscription.
+func (st *subscribedTailer) Subscribe() (<-chan struct{}, func()) {
+	notify, unsubscribe := st.RingBuffer.Subscribe()
+	if st.subscribed != nil {
+		st.subscribed <- struct{}{}
+	}
+	return notify, unsubscribe
+}
+
+// TestTail validates long-polling for telemetry newer than a cursor.
+func TestTail(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	buffer := ringbuffer.New(time.Hour, ringbuffer.WithClock(clock.NewFake(now)))
+	add := func(value int) {
+		buffer.Add(types.TelemetryEntry{Timestamp: now, Source: types.SourceSystem, Type: types.TypeCPU, Name: "cpu_usage_percent", Value: value})
+	}
+	add(1)
+	add(2)
+
+	tailer := &subscribedTailer{RingBuffer: buffer}
+	server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false, WithTailer(tailer))
+	tail := func(rawQuery string) (int, []types.TelemetryEntry, string) {
+		req := httptest.NewRequest("GET", "/api/v1/tail?"+rawQuery, nil)
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+
+		var entries []types.TelemetryEntry
+		decoder := json.NewDecoder(w.Body)
+		for decoder.More() {
+			var entry types.TelemetryEntry
+			if err := decoder.Decode(&entry); err != nil {
+				break
+			}
+			entries = append(entries, entry)
+		}
+		return w.Code, entries, w.Header().Get(TailCursorHeader)
+	}
+
+	t.Run("returns buffered entries and advances the cursor", func(t *testing.T) {
+		code, entries, cursor := tail("since=0")
+		if code != http.StatusOK || len(entries) != 2 {
+			t.Fatalf("Expected 2 entries, got %d (status %d)", len(entries), code)
+		}
+		if cursor != "2" {
+			t.Errorf("Expected cursor 2, got %q", cursor)
+		}
+	})
+
+	t.Run("waits for new entries", func(t *testing.T) {
+		type result struct {
+			entries []types.TelemetryEntry
+			cursor  string
+		}
+		done := make(chan result, 1)
+		tailer.subscribed = make(chan struct{}, 1)
+		defer func() { tailer.subscribed = nil }()
+		go func() {
+			_, entries, cursor := tail("since=2&timeout=5s")
+			done <- result{entries, cursor}
+		}()
+
+		// Entries added after subscribing wake the poll
+		<-tailer.subscribed
+		add(3)
+
+		select {
+		case got := <-done:
+			if len(got.entries) != 1 || got.entries[0].Value != float64(3) {
+				t.Errorf("Expected only the new entry, got %v", got.entries)
+			}
+			if got.cursor != "3" {
+				t.Errorf("Expected cursor 3, got %q", got.cursor)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected the long-poll to return once an entry was added")
+		}
+	})
+
+	t.Run("returns empty after the timeout", func(t *testing.T) {
+		code, entries, cursor := tail("since=3&timeout=10ms")
+		if code != http.StatusOK || len(entries) != 0 || cursor != "3" {
+			t.Errorf("Expected an empty response with cursor 3, got %d entries, cursor %q (status %d)", len(entries), cursor, code)
+		}
+	})
+
+	t.Run("rejects invalid parameters", func(t *testing.T) {
+		for _, rawQuery := range []string{"since=next", "since=-1", "timeout=1h", "timeout=soon"} {
+			if code, _, _ := tail(rawQuery); code != http.StatusBadRequest {
+				t.Errorf("Expected status 400 for %q, got %d", rawQuery, code)
+			}
+		}
+	})
+
+	t.Run("requires a tailer", func(t *testing.T) {
+		server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false)
+		req := httptest.NewRequest("GET", "/api/v1/tail", nil)
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected status 404, got %d", w.Code)
+		}
+	})
+}
+
+// TestReadOnly validates that a read-only server rejects submissions and still serves queries.
+func TestReadOnly(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	fakeClock := clock.NewFake(now)
+	buffer := ringbuffer.New(time.Hour, ringbuffer.WithClock(fakeClock))
+	buffer.Add(types.TelemetryEntry{Timestamp: now, Source: types.SourceSystem, Type: types.TypeCPU, Name: "cpu_usage", Value: 42.0})
+	server := NewServer(8080, "test-api-key-123", buffer, nil, false, WithReadOnly(true), WithQuerier(buffer), WithClock(fakeClock))
+	serve := func(method, route, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, route, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("rejects submissions", func(t *testing.T) {
+		for route, body := range map[string]string{
+			"/api/v1/telemetry":       `{"pod_name":"web-1","namespace":"default","runtime":"go","data":{"heap":1}}`,
+			"/api/v1/telemetry/batch": `[]`,
+			"/api/v1/incident":        `{"pod_name":"web-1","namespace":"default","message":"crash"}`,
+		} {
+			if w := serve("POST", route, body); w.Code != http.StatusNotFound {
+				t.Errorf("Expected status 404 for %s, got %d", route, w.Code)
+			}
+		}
+		if stats := buffer.GetStats(); stats.TotalEntries != 1 {
+			t.Errorf("Expected nothing buffered, got %d entries", stats.TotalEntries)
+		}
+	})
+
+	t.Run("serves queries", func(t *testing.T) {
+		w := serve("GET", "/api/v1/telemetry/query", "")
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "cpu_usage") {
+			t.Errorf("Expected the buffered entry, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+}
+
+// TestCustomTelemetryTypes validates inferring and filtering by registered custom telemetry types.
+func TestCustomTelemetryTypes(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	fakeClock := clock.NewFake(now)
+	buffer := ringbuffer.New(time.Hour, ringbuffer.WithClock(fakeClock))
+	registry, err := telemetry.NewTypeRegistry([]telemetry.TypeDefinition{
+		{Name: "queue", Keywords: []string{"queue_depth", "backlog"}},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false,
+		WithQuerier(buffer), WithTelemetryTypes(registry), WithClock(fakeClock))
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		req.Header.Set("Authorization", "Bearer test-api-key-123")
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	body, _ := json.Marshal(types.SidecarTelemetry{
+		PodName:   "worker-1",
+		Namespace: "default",
+		Runtime:   "go",
+		Timestamp: now,
+		Data: map[string]interface{}{
+			"orders_queue_depth":     42,
+			"email_backlog":          7,
+			"heap_memory_used_bytes": 1024,
+		},
+	})
+	if w := serve(httptest.NewRequest("POST", "/api/v1/telemetry", bytes.NewReader(body))); w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	t.Run("infers the custom type from keywords", func(t *testing.T) {
+		if got := server.inferTelemetryType("payments_queue_depth", "go"); got != "queue" {
+			t.Errorf("Expected queue, got %s", got)
+		}
+		if got := server.inferTelemetryType("gc_count", "go"); got != types.TypeMemory {
+			t.Errorf("Expected built-in inference for other metrics, got %s", got)
+		}
+	})
+
+	t.Run("filters by the custom type", func(t *testing.T) {
+		w := serve(httptest.NewRequest("GET", "/api/v1/telemetry/query?type=queue", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+		var response struct {
+			Entries []types.TelemetryEntry `json:"entries"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		if len(response.Entries) != 2 {
+			t.Fatalf("Expected 2 queue entries, got %+v", response.Entries)
+		}
+		for _, entry := range response.Entries {
+			if entry.Type != "queue" {
+				t.Errorf("Expected type queue, got %s for %s", entry.Type, entry.Name)
+			}
+		}
+	})
+
+	t.Run("filters by built-in types", func(t *testing.T) {
+		w := serve(httptest.NewRequest("GET", "/api/v1/telemetry/query?type=memory", nil))
+		if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "heap_memory_used_bytes") {
+			t.Errorf("Expected the memory entry, got %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("rejects unknown types", func(t *testing.T) {
+		w := serve(httptest.NewRequest("GET", "/api/v1/telemetry/query?type=kpi", nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400, got %d", w.Code)
+		}
+	})
+}
+
+// TestReplayIncidents validates resubmitting stored incidents through the incident handler.
+func TestReplayIncidents(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	fakeClock := clock.NewFake(now)
+	store, err := incidentstore.Open(filepath.Join(t.TempDir(), "incidents.ndjson"), incidentstore.WithClock(fakeClock))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer store.Close()
+	store.Record(types.IncidentReport{ID: "old", Timestamp: now.Add(-3 * time.Hour), Type: types.IncidentCrash})
+	store.Record(types.IncidentReport{ID: "recent", Timestamp: now.Add(-time.Hour), Type: types.IncidentOOM})
+
+	// The handler stands in for the daemon's pipeline, which records every
+	// incident in the store before formatting and emitting it
+	emitted := &mockIncidentHandler{}
+	pipeline := incidentHandlerFunc(func(report types.IncidentReport) {
+		store.Record(report)
+		emitted.HandleIncident(report)
+	})
+	server := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, pipeline, false, WithIncidentHistory(store), WithClock(fakeClock))
+
+	replay := func(t *testing.T, method, rawQuery string) (int, map[string]interface{}) {
+		t.Helper()
+		w := httptest.NewRecorder()
+		server.handleReplayIncidents(w, httptest.NewRequest(method, "/api/v1/admin/replay-incidents?"+rawQuery, nil))
+		var response map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&response)
+		return w.Code, response
+	}
+
+	t.Run("dry run only counts incidents", func(t *testing.T) {
+		code, response := replay(t, "POST", "since=2h&dry_run=true")
+		if code != http.StatusOK || response["status"] != "dry_run" || response["incidents"] != 1.0 {
+			t.Errorf("Expected a dry run counting 1 incident, got %d %v", code, response)
+		}
+		if len(emitted.reports) != 0 {
+			t.Errorf("Expected nothing emitted in a dry run, got %d incidents", len(emitted.reports))
+		}
+	})
+
+	t.Run("re-emits stored incidents without storing them again", func(t *testing.T) {
+		code, response := replay(t, "POST", "since="+now.Add(-4*time.Hour).Format(time.RFC3339))
+		if code != http.StatusOK || response["status"] != "replayed" || response["incidents"] != 2.0 {
+			t.Fatalf("Expected 2 incidents replayed, got %d %v", code, response)
+		}
+		if len(emitted.reports) != 2 || emitted.reports[0].ID != "old" || emitted.reports[1].ID != "recent" {
+			t.Fatalf("Expected old and recent re-emitted in order, got %+v", emitted.reports)
+		}
+		if !incidentstore.IsReplayed(emitted.reports[0]) {
+			t.Errorf("Expected replayed incidents to be marked, got %v", emitted.reports[0].Context)
+		}
+		if history := store.Incidents(); len(history) != 2 {
+			t.Errorf("Expected the history unchanged by the replay, got %d incidents", len(history))
+		}
+	})
+
+	t.Run("rejects invalid requests", func(t *testing.T) {
+		if code, _ := replay(t, "GET", ""); code != http.StatusMethodNotAllowed {
+			t.Errorf("Expected 405 for GET, got %d", code)
+		}
+		if code, _ := replay(t, "POST", "since=yesterday"); code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for an invalid since, got %d", code)
+		}
+		if code, _ := replay(t, "POST", "dry_run=maybe"); code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for an invalid dry_run, got %d", code)
+		}
+	})
+
+	t.Run("not found without history", func(t *testing.T) {
+		plain := NewServer(8080, "test-api-key-123", &mockTelemetryBuffer{}, &mockIncidentHandler{}, false)
+		w := httptest.NewRecorder()
+		plain.handleReplayIncidents(w, httptest.NewRequest("POST", "/api/v1/admin/replay-incidents", nil))
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 without incident history, got %d", w.Code)
+		}
+	})
+
+	t.Run("requires authentication", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		server.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/admin/replay-incidents", nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 without the API key, got %d", w.Code)
+		}
+	})
+}
+
+// TestEntryLimit validates the per-request cap on telemetry entries.
+func TestEntryLimit(t *testing.T) {
+	oversized := make(map[string]interface{}, 50000)
+	for i := 0; i < 50000; i++ {
+		oversized[fmt.Sprintf("metric_%05d", i)] = i
+	}
+	body, _ := json.Marshal(types.SidecarTelemetry{PodName: "api-1", Namespace: "prod", Runtime: "go", Data: oversized})
+
+	t.Run("accepts any number of entries by default", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false)
+
+		w := httptest.NewRecorder()
+		server.handleTelemetry(w, httptest.NewRequest("POST", "/api/v1/telemetry", bytes.NewReader(body)))
+
+		if w.Code != http.StatusOK || len(buffer.entries) != 50000 {
+			t.Errorf("Expected all 50000 entries accepted, got status %d and %d entries", w.Code, len(buffer.entries))
+		}
+	})
+
+	t.Run("rejects oversized submissions", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		recorder := &limitRecorder{mockRequestRecorder: mockRequestRecorder{counts: make(map[string]int)}, actions: make(map[string]int)}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithRequestRecorder(recorder), WithEntryLimit(10000, false))
+
+		w := httptest.NewRecorder()
+		server.handleTelemetry(w, httptest.NewRequest("POST", "/api/v1/telemetry", bytes.NewReader(body)))
+
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "exceeds the limit of 10000") {
+			t.Errorf("Expected status 400 naming the limit, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(buffer.entries) != 0 || len(recorder.counts) != 0 {
+			t.Errorf("Expected nothing buffered or counted as accepted, got %d entries and %v", len(buffer.entries), recorder.counts)
+		}
+		if recorder.actions["rejected"] != 1 || recorder.dropped != 50000 {
+			t.Errorf("Expected 1 rejection dropping 50000 entries, got %v and %d", recorder.actions, recorder.dropped)
+		}
+	})
+
+	t.Run("truncates when configured", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		recorder := &limitRecorder{mockRequestRecorder: mockRequestRecorder{counts: make(map[string]int)}, actions: make(map[string]int)}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithRequestRecorder(recorder), WithEntryLimit(100, true))
+
+		w := httptest.NewRecorder()
+		server.handleTelemetry(w, httptest.NewRequest("POST", "/api/v1/telemetry", bytes.NewReader(body)))
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(buffer.entries) != 100 {
+			t.Errorf("Expected 100 entries buffered, got %d", len(buffer.entries))
+		}
+		for _, entry := range buffer.entries {
+			if entry.Name >= "metric_00100" {
+				t.Errorf("Expected the first 100 keys in sorted order, got %s", entry.Name)
+				break
+			}
+		}
+		if recorder.actions["truncated"] != 1 || recorder.dropped != 49900 {
+			t.Errorf("Expected 1 truncation dropping 49900 entries, got %v and %d", recorder.actions, recorder.dropped)
+		}
+	})
+
+	t.Run("counts oversized batch elements as rejected", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithEntryLimit(2, false))
+
+		batch := `[{"pod_name":"api-1","namespace":"prod","data":{"a":1,"b":2}},` +
+			`{"pod_name":"api-2","namespace":"prod","data":{"a":1,"b":2,"c":3}}]`
+		w := httptest.NewRecorder()
+		server.handleTelemetryBatch(w, httptest.NewRequest("POST", "/api/v1/telemetry/batch", strings.NewReader(batch)))
+
+		if !strings.Contains(w.Body.String(), `"accepted":1`) || !strings.Contains(w.Body.String(), `"rejected":1`) {
+			t.Errorf("Expected 1 accepted and 1 rejected, got %s", w.Body.String())
+		}
+		if len(buffer.entries) != 2 {
+			t.Errorf("Expected 2 entries buffered, got %d", len(buffer.entries))
+		}
+	})
+
+	t.Run("zero disables the cap", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithEntryLimit(0, false))
+
+		w := httptest.NewRecorder()
+		server.handleTelemetry(w, httptest.NewRequest("POST", "/api/v1/telemetry", bytes.NewReader(body)))
+
+		if w.Code != http.StatusOK || len(buffer.entries) != 50000 {
+			t.Errorf("Expected all 50000 entries accepted, got %d and %d", w.Code, len(buffer.entries))
+		}
+	})
+}
+
+// TestExactNumbers validates that large integer counters submitted by sidecars
+// keep their precision.
+func TestExactNumbers(t *testing.T) {
+	// 2^53 + 1 cannot be represented as a float64
+	const body = `{"pod_name":"api-1","namespace":"prod","runtime":"go","data":{"net_bytes_total":9007199254740993,"heap_ratio":0.25}}`
+
+	submit := func(t *testing.T, server *Server, path, body string) {
+		t.Helper()
+		req := httptest.NewRequest("POST", path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		if path == "/api/v1/telemetry" {
+			server.handleTelemetry(w, req)
+		} else {
+			server.handleTelemetryBatch(w, req)
+		}
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", w.Code)
+		}
+	}
+
+	values := func(buffer *mockTelemetryBuffer) map[string]interface{} {
+		result := make(map[string]interface{})
+		for _, entry := range buffer.entries {
+			result[entry.Name] = entry.Value
+		}
+		return result
+	}
+
+	t.Run("round-trips values above 2^53", func(t *testing.T) {
+		for _, path := range []string{"/api/v1/telemetry", "/api/v1/telemetry/batch"} {
+			server, buffer, _ := setupTestServer()
+			payload := body
+			if path == "/api/v1/telemetry/batch" {
+				payload = "[" + body + "]"
+			}
+			submit(t, server, path, payload)
+
+			got := values(buffer)
+			counter, ok := got["net_bytes_total"].(json.Number)
+			if !ok || counter.String() != "9007199254740993" {
+				t.Errorf("%s: expected exact json.Number 9007199254740993, got %#v", path, got["net_bytes_total"])
+			}
+			encoded, _ := json.Marshal(got["net_bytes_total"])
+			if string(encoded) != "9007199254740993" {
+				t.Errorf("%s: expected value re-encoded exactly, got %s", path, encoded)
+			}
+			if got["heap_ratio"] != json.Number("0.25") {
+				t.Errorf("%s: expected 0.25, got %#v", path, got["heap_ratio"])
+			}
+		}
+	})
+
+	t.Run("decodes floats when configured", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithExactNumbers(false))
+		submit(t, server, "/api/v1/telemetry", body)
+
+		if _, ok := values(buffer)["net_bytes_total"].(float64); !ok {
+			t.Errorf("Expected float64, got %#v", values(buffer)["net_bytes_total"])
+		}
+	})
+}
+
+// TestTransformer validates transforming sidecar telemetry before buffering.
+func TestTransformer(t *testing.T) {
+	transformer, err := telemetry.NewTransformer([]telemetry.TransformRule{
+		{Action: telemetry.TransformRename, Match: "heap_used_kb", Replacement: "heap_used_bytes"},
+		{Action: telemetry.TransformScale, Match: "heap_used_bytes", Factor: 1024},
+		{Action: telemetry.TransformDropTag, Tag: "container_id"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	buffer := &mockTelemetryBuffer{}
+	server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithTransformer(transformer))
+
+	body := `{"pod_name":"api-1","namespace":"prod","container_id":"containerd://abc","runtime":"jvm","data":{"heap_used_kb":2,"threads":40}}`
+	w := httptest.NewRecorder()
+	server.handleTelemetry(w, httptest.NewRequest("POST", "/api/v1/telemetry", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK || len(buffer.entries) != 2 {
+		t.Fatalf("Expected 2 entries accepted, got status %d and %d entries", w.Code, len(buffer.entries))
+	}
+	for _, entry := range buffer.entries {
+		if _, ok := entry.Tags["container_id"]; ok {
+			t.Errorf("Expected container_id dropped from %s, got %v", entry.Name, entry.Tags)
+		}
+		switch entry.Name {
+		case "heap_used_bytes":
+			if entry.Value != json.Number("2048") {
+				t.Errorf("Expected heap_used_bytes 2048, got %#v", entry.Value)
+			}
+		case "threads":
+			if entry.Value != json.Number("40") {
+				t.Errorf("Expected threads unchanged, got %#v", entry.Value)
+			}
+		default:
+			t.Errorf("Unexpected entry %s", entry.Name)
+		}
+	}
+}
+
+// histogramRecorder records sidecar histograms in addition to sidecar requests.
+type histogramRecorder struct {
+	mockRequestRecorder
+	histograms map[string]telemetry.Histogram
+}
+
+// RecordSidecarHistogram records the histogram under "namespace/pod/runtime/name".
+func (m *histogramRecorder) RecordSidecarHistogram(name, pod, namespace, runtime string, histogram telemetry.Histogram) {
+	m.histograms[namespace+"/"+pod+"/"+runtime+"/"+name] = histogram
+}
+
+// TestSidecarHistograms validates accepting histograms in sidecar telemetry.
+func TestSidecarHistograms(t *testing.T) {
+	body := `{"pod_name":"api-1","namespace":"prod","runtime":"go","data":{` +
+		`"request_latency_seconds":{"type":"histogram","buckets":[0.05,0.1,0.5],"counts":[120,30,8],"count":161,"sum":12.7},` +
+		`"goroutines":42}}`
+	newServer := func(enabled bool) (*Server, *mockTelemetryBuffer, *histogramRecorder) {
+		buffer := &mockTelemetryBuffer{}
+		recorder := &histogramRecorder{mockRequestRecorder: mockRequestRecorder{counts: make(map[string]int)}, histograms: make(map[string]telemetry.Histogram)}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithRequestRecorder(recorder), WithSidecarHistograms(enabled))
+		return server, buffer, recorder
+	}
+
+	t.Run("buffers and records histograms", func(t *testing.T) {
+		server, buffer, recorder := newServer(true)
+
+		w := httptest.NewRecorder()
+		server.handleTelemetry(w, httptest.NewRequest("POST", "/api/v1/telemetry", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		var buffered *types.TelemetryEntry
+		for i := range buffer.entries {
+			if buffer.entries[i].Name == "request_latency_seconds" {
+				buffered = &buffer.entries[i]
+			}
+		}
+		if buffered == nil || len(buffer.entries) != 2 {
+			t.Fatalf("Expected the histogram and gauge buffered, got %+v", buffer.entries)
+		}
+		histogram, ok := buffered.Value.(telemetry.Histogram)
+		if !ok || len(histogram.Counts) != 3 || histogram.Counts[1] != 30 || histogram.Count != 161 || histogram.Sum != 12.7 {
+			t.Errorf("Expected a structured histogram, got %#v", buffered.Value)
+		}
+		if buffered.Metadata[telemetry.ValueTypeMetadataKey] != telemetry.HistogramType {
+			t.Errorf("Expected value_type histogram, got %v", buffered.Metadata)
+		}
+
+		recorded, ok := recorder.histograms["prod/api-1/go/request_latency_seconds"]
+		if !ok || len(recorder.histograms) != 1 || recorded.Count != 161 {
+			t.Errorf("Expected the histogram recorded, got %v", recorder.histograms)
+		}
+	})
+
+	t.Run("rejects malformed histograms", func(t *testing.T) {
+		server, buffer, recorder := newServer(true)
+		malformed := `{"pod_name":"api-1","namespace":"prod","data":{"latency":{"type":"histogram","buckets":[0.5,0.1],"counts":[1,2]}}}`
+
+		w := httptest.NewRecorder()
+		server.handleTelemetry(w, httptest.NewRequest("POST", "/api/v1/telemetry", strings.NewReader(malformed)))
+
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "invalid histogram latency") {
+			t.Errorf("Expected status 400 naming the histogram, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(buffer.entries) != 0 || len(recorder.counts) != 0 || len(recorder.histograms) != 0 {
+			t.Errorf("Expected nothing buffered or recorded, got %d entries, %v and %v", len(buffer.entries), recorder.counts, recorder.histograms)
+		}
+	})
+
+	t.Run("buffers plain objects when disabled", func(t *testing.T) {
+		server, buffer, recorder := newServer(false)
+
+		w := httptest.NewRecorder()
+		server.handleTelemetry(w, httptest.NewRequest("POST", "/api/v1/telemetry", strings.NewReader(body)))
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+
+		for _, entry := range buffer.entries {
+			if _, ok := entry.Value.(telemetry.Histogram); ok {
+				t.Errorf("Expected no histogram values, got %#v", entry.Value)
+			}
+		}
+		if len(recorder.histograms) != 0 {
+			t.Errorf("Expected no histograms recorded, got %v", recorder.histograms)
+		}
+	})
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// SkewPolicy selects how the server treats sidecar timestamps that differ
+// from its own clock.
+type SkewPolicy string
+
+const (
+	// SkewAccept stores sidecar timestamps as sent
+	SkewAccept SkewPolicy = "accept"
+	// SkewReject rejects submissions whose timestamp is further from the
+	// server clock than the allowed skew
+	SkewReject SkewPolicy = "reject"
+	// SkewRebase replaces timestamps within the allowed skew with the receipt
+	// time, keeping the original in the entries' metadata; timestamps beyond
+	// it are treated as genuinely delayed and stored as sent
+	SkewRebase SkewPolicy = "rebase"
+)
+
+// DefaultMaxClockSkew is the allowed clock skew when none is configured.
+const DefaultMaxClockSkew = time.Minute
+
+// ParseSkewPolicy returns the skew policy with the given name. An empty name
+// is SkewAccept.
+func ParseSkewPolicy(name string) (SkewPolicy, error) {
+	switch policy := SkewPolicy(name); policy {
+	case "":
+		return SkewAccept, nil
+	case SkewAccept, SkewReject, SkewRebase:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown clock skew policy %q (expected accept, reject or rebase)", name)
+	}
+}
+
+// WithClockSkew sets how sidecar timestamps offset from the server clock by
+// more or less than maxSkew are handled, either way. Rejection and rebasing
+// are mutually exclusive. A non-positive maxSkew uses DefaultMaxClockSkew.
+// Defaults to SkewAccept.
+func WithClockSkew(policy SkewPolicy, maxSkew time.Duration) Option {
+	return func(s *Server) {
+		if maxSkew <= 0 {
+			maxSkew = DefaultMaxClockSkew
+		}
+		s.skewPolicy = policy
+		s.maxSkew = maxSkew
+	}
+}
+
+// rebasedTimestamp records the sidecar timestamp a submission was rebased from.
+type rebasedTimestamp struct {
+	// original is the timestamp the sidecar sent
+	original time.Time
+	// offset is the receipt time minus the original timestamp
+	offset time.Duration
+}
+
+// metadata adds the original timestamp and offset to an entry's metadata. A
+// nil receiver leaves the metadata unchanged.
+func (rt *rebasedTimestamp) metadata(metadata map[string]interface{}) map[string]interface{} {
+	if rt != nil {
+		metadata["sidecar_timestamp"] = rt.original
+		metadata["clock_offset"] = rt.offset.String()
+	}
+	return metadata
+}
+
+// applyClockSkew applies the skew policy to a submission's timestamp,
+// returning an error when the policy rejects it and the original timestamp
+// when it was rebased.
+func (s *Server) applyClockSkew(sidecar *types.SidecarTelemetry) (*rebasedTimestamp, error) {
+	if s.skewPolicy != SkewReject && s.skewPolicy != SkewRebase {
+		return nil, nil
+	}
+
+	receivedAt := s.clock.Now()
+	offset := receivedAt.Sub(sidecar.Timestamp)
+	within := offset.Abs() <= s.maxSkew
+
+	switch {
+	case s.skewPolicy == SkewReject && !within:
+		return nil, fmt.Errorf("timestamp is skewed by %v, exceeding the allowed %v", offset, s.maxSkew)
+	case s.skewPolicy == SkewRebase && within && offset != 0:
+		rebased := &rebasedTimestamp{original: sidecar.Timestamp, offset: offset}
+		sidecar.Timestamp = receivedAt
+		return rebased, nil
+	}
+	return nil, nil
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestClockSkew validates rejecting and rebasing skewed sidecar timestamps.
+func TestClockSkew(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	// submit posts one submission stamped at the given time, or without a
+	// timestamp when it is zero.
+	submit := func(t *testing.T, policy SkewPolicy, at time.Time) (*httptest.ResponseRecorder, *mockTelemetryBuffer) {
+		t.Helper()
+		buffer := &mockTelemetryBuffer{}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false,
+			WithClock(clock.NewFake(now)), WithClockSkew(policy, 30*time.Second))
+
+		jsonData, _ := json.Marshal(types.SidecarTelemetry{
+			PodName:   "api-1",
+			Namespace: "prod",
+			Runtime:   "go",
+			Timestamp: at,
+			Data:      map[string]interface{}{"goroutines": 10},
+		})
+		w := httptest.NewRecorder()
+		server.handleTelemetry(w, httptest.NewRequest("POST", "/api/v1/telemetry", strings.NewReader(string(jsonData))))
+		return w, buffer
+	}
+
+	t.Run("rebases timestamps within the bound", func(t *testing.T) {
+		sent := now.Add(-12 * time.Second)
+		w, buffer := submit(t, SkewRebase, sent)
+
+		if w.Code != http.StatusOK || len(buffer.entries) != 1 {
+			t.Fatalf("Expected 1 accepted entry, got status %d and %d entries", w.Code, len(buffer.entries))
+		}
+		entry := buffer.entries[0]
+		if !entry.Timestamp.Equal(now) {
+			t.Errorf("Expected timestamp rebased to %v, got %v", now, entry.Timestamp)
+		}
+		if original, ok := entry.Metadata["sidecar_timestamp"].(time.Time); !ok || !original.Equal(sent) {
+			t.Errorf("Expected original timestamp %v in metadata, got %v", sent, entry.Metadata["sidecar_timestamp"])
+		}
+		if entry.Metadata["clock_offset"] != "12s" {
+			t.Errorf("Expected clock offset 12s, got %v", entry.Metadata["clock_offset"])
+		}
+	})
+
+	t.Run("rebases sidecar clocks running ahead", func(t *testing.T) {
+		_, buffer := submit(t, SkewRebase, now.Add(5*time.Second))
+
+		if !buffer.entries[0].Timestamp.Equal(now) || buffer.entries[0].Metadata["clock_offset"] != "-5s" {
+			t.Errorf("Expected timestamp rebased from 5s ahead, got %v and %v", buffer.entries[0].Timestamp, buffer.entries[0].Metadata)
+		}
+	})
+
+	t.Run("keeps timestamps beyond the bound when rebasing", func(t *testing.T) {
+		sent := now.Add(-10 * time.Minute)
+		w, buffer := submit(t, SkewRebase, sent)
+
+		if w.Code != http.StatusOK || !buffer.entries[0].Timestamp.Equal(sent) {
+			t.Errorf("Expected delayed timestamp stored as sent, got status %d and %v", w.Code, buffer.entries[0].Timestamp)
+		}
+		if _, ok := buffer.entries[0].Metadata["sidecar_timestamp"]; ok {
+			t.Error("Expected no rebase metadata")
+		}
+	})
+
+	t.Run("does not rebase missing timestamps", func(t *testing.T) {
+		_, buffer := submit(t, SkewRebase, time.Time{})
+
+		if !buffer.entries[0].Timestamp.Equal(now) {
+			t.Errorf("Expected receipt time, got %v", buffer.entries[0].Timestamp)
+		}
+		if _, ok := buffer.entries[0].Metadata["sidecar_timestamp"]; ok {
+			t.Error("Expected no rebase metadata")
+		}
+	})
+
+	t.Run("rejects timestamps beyond the bound", func(t *testing.T) {
+		w, buffer := submit(t, SkewReject, now.Add(-10*time.Minute))
+
+		if w.Code != http.StatusBadRequest || len(buffer.entries) != 0 {
+			t.Errorf("Expected status 400 and nothing buffered, got %d and %d entries", w.Code, len(buffer.entries))
+		}
+		if !strings.Contains(w.Body.String(), "skewed by 10m0s") {
+			t.Errorf("Expected skew in error, got %q", w.Body.String())
+		}
+	})
+
+	t.Run("accepts timestamps within the bound when rejecting", func(t *testing.T) {
+		sent := now.Add(-12 * time.Second)
+		w, buffer := submit(t, SkewReject, sent)
+
+		if w.Code != http.StatusOK || !buffer.entries[0].Timestamp.Equal(sent) {
+			t.Errorf("Expected timestamp stored as sent, got status %d and %v", w.Code, buffer.entries[0].Timestamp)
+		}
+	})
+
+	t.Run("accepts any skew by default", func(t *testing.T) {
+		sent := now.Add(-24 * time.Hour)
+		w, buffer := submit(t, SkewAccept, sent)
+
+		if w.Code != http.StatusOK || !buffer.entries[0].Timestamp.Equal(sent) {
+			t.Errorf("Expected timestamp stored as sent, got status %d and %v", w.Code, buffer.entries[0].Timestamp)
+		}
+	})
+
+	t.Run("counts rejected batch elements", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false,
+			WithClock(clock.NewFake(now)), WithClockSkew(SkewReject, 0))
+
+		batch, _ := json.Marshal([]types.SidecarTelemetry{
+			{PodName: "api-1", Namespace: "prod", Timestamp: now.Add(-time.Hour), Data: map[string]interface{}{"a": 1}},
+			{PodName: "api-2", Namespace: "prod", Timestamp: now.Add(-time.Second), Data: map[string]interface{}{"a": 1}},
+		})
+		w := httptest.NewRecorder()
+		server.handleTelemetryBatch(w, httptest.NewRequest("POST", "/api/v1/telemetry/batch", strings.NewReader(string(batch))))
+
+		var response map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		if response["accepted"] != 1.0 || response["rejected"] != 1.0 {
+			t.Errorf("Expected 1 accepted and 1 rejected, got %v", response)
+		}
+	})
+}
+
+// TestParseSkewPolicy validates parsing clock skew policy names.
+func TestParseSkewPolicy(t *testing.T) {
+	for name, expected := range map[string]SkewPolicy{"": SkewAccept, "accept": SkewAccept, "reject": SkewReject, "rebase": SkewRebase} {
+		if policy, err := ParseSkewPolicy(name); err != nil || policy != expected {
+			t.Errorf("Expected %q for %q, got %q (%v)", expected, name, policy, err)
+		}
+	}
+	if _, err := ParseSkewPolicy("drop"); err == nil {
+		t.Error("Expected error for unknown policy")
+	}
+}
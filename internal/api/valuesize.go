@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+const (
+	// TruncatedValueMarker ends a sidecar value truncated by the value size limit
+	TruncatedValueMarker = "...[truncated]"
+	// ValueTruncatedMetadataKey is the metadata key of the serialized size, in
+	// bytes, of a value truncated by the value size limit
+	ValueTruncatedMetadataKey = "value_truncated_bytes"
+)
+
+// ValueSizeRecorder is implemented by RequestRecorders that also count sidecar
+// telemetry values exceeding the value size limit. The action is "rejected"
+// or "truncated".
+type ValueSizeRecorder interface {
+	RecordSidecarValueSizeLimit(action string)
+}
+
+// WithValueSizeLimit caps the serialized JSON size, in bytes, of each value in
+// a sidecar submission, so one huge string or nested object cannot consume
+// megabytes of the buffer. A submission with an oversized value is rejected
+// with 400, or, when truncate is set, the value is replaced by its text cut to
+// the limit and ending in TruncatedValueMarker, with the original size in the
+// entry's metadata. A limit of 0 disables the cap. Values are not capped by
+// default.
+func WithValueSizeLimit(limit int, truncate bool) Option {
+	return func(s *Server) {
+		s.maxValueBytes = limit
+		s.truncateValues = truncate
+	}
+}
+
+// limitValueSizes enforces the value size limit on the entries of data before
+// anything is buffered. It returns the original sizes of the truncated values
+// by key, or an error naming the first oversized key in sorted order when
+// truncation is disabled.
+func (s *Server) limitValueSizes(sidecar types.SidecarTelemetry) (map[string]int, error) {
+	if s.maxValueBytes <= 0 {
+		return nil, nil
+	}
+
+	sizes := make(map[string]int)
+	for key, value := range sidecar.Data {
+		if size := valueSize(value); size > s.maxValueBytes {
+			sizes[key] = size
+		}
+	}
+	if len(sizes) == 0 {
+		return nil, nil
+	}
+
+	if !s.truncateValues {
+		keys := make([]string, 0, len(sizes))
+		for key := range sizes {
+			keys = append(keys, key)
+			s.recordValueSizeLimit("rejected")
+		}
+		sort.Strings(keys)
+		return nil, fmt.Errorf("telemetry value %s is %d bytes, exceeding the limit of %d", keys[0], sizes[keys[0]], s.maxValueBytes)
+	}
+
+	for key, size := range sizes {
+		sidecar.Data[key] = truncateValue(sidecar.Data[key], s.maxValueBytes)
+		s.recordValueSizeLimit("truncated")
+		fmt.Printf("Warning: truncated telemetry value %s from %s/%s from %d to %d bytes\n", key, sidecar.Namespace, sidecar.PodName, size, s.maxValueBytes)
+	}
+	return sizes, nil
+}
+
+// recordValueSizeLimit counts a value exceeding the value size limit when the
+// request recorder supports it.
+func (s *Server) recordValueSizeLimit(action string) {
+	if recorder, ok := s.requests.(ValueSizeRecorder); ok {
+		recorder.RecordSidecarValueSizeLimit(action)
+	}
+}
+
+// valueSize returns the size of a value serialized as JSON.
+func valueSize(value interface{}) int {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// truncateValue returns the text of a value, its JSON for anything but a
+// string, cut on a character boundary so that with TruncatedValueMarker
+// appended it is at most limit bytes.
+func truncateValue(value interface{}, limit int) string {
+	text, ok := value.(string)
+	if !ok {
+		data, _ := json.Marshal(value)
+		text = string(data)
+	}
+
+	keep := limit - len(TruncatedValueMarker)
+	if keep < 0 {
+		keep = 0
+	}
+	if keep >= len(text) {
+		return text + TruncatedValueMarker
+	}
+	for keep > 0 && !utf8.RuneStart(text[keep]) {
+		keep--
+	}
+	return text[:keep] + TruncatedValueMarker
+}
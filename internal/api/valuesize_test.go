@@ -0,0 +1,113 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// valueSizeRecorder records value size limit events in addition to sidecar requests.
+type valueSizeRecorder struct {
+	mockRequestRecorder
+	actions map[string]int
+}
+
+// RecordSidecarValueSizeLimit records the action.
+func (m *valueSizeRecorder) RecordSidecarValueSizeLimit(action string) {
+	m.actions[action]++
+}
+
+// TestValueSizeLimit validates the per-entry cap on serialized value sizes.
+func TestValueSizeLimit(t *testing.T) {
+	huge := strings.Repeat("é", 1<<20)
+	body, _ := json.Marshal(types.SidecarTelemetry{PodName: "api-1", Namespace: "prod", Runtime: "go", Data: map[string]interface{}{
+		"last_error": huge,
+		"heap_used":  1024,
+	}})
+
+	// submit posts the body and returns the response.
+	submit := func(server *Server, body []byte) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		server.handleTelemetry(w, httptest.NewRequest("POST", "/api/v1/telemetry", bytes.NewReader(body)))
+		return w
+	}
+
+	t.Run("accepts any value size by default", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false)
+
+		if w := submit(server, body); w.Code != http.StatusOK || len(buffer.entries) != 2 {
+			t.Errorf("Expected both entries accepted, got status %d and %d entries", w.Code, len(buffer.entries))
+		}
+	})
+
+	t.Run("rejects oversized values", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		recorder := &valueSizeRecorder{mockRequestRecorder: mockRequestRecorder{counts: make(map[string]int)}, actions: make(map[string]int)}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithRequestRecorder(recorder), WithValueSizeLimit(4096, false))
+
+		w := submit(server, body)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "last_error") || !strings.Contains(w.Body.String(), "exceeding the limit of 4096") {
+			t.Errorf("Expected status 400 naming the value and limit, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(buffer.entries) != 0 || len(recorder.counts) != 0 {
+			t.Errorf("Expected nothing buffered or counted as accepted, got %d entries and %v", len(buffer.entries), recorder.counts)
+		}
+		if recorder.actions["rejected"] != 1 {
+			t.Errorf("Expected 1 rejection, got %v", recorder.actions)
+		}
+	})
+
+	t.Run("truncates with a marker when configured", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		recorder := &valueSizeRecorder{mockRequestRecorder: mockRequestRecorder{counts: make(map[string]int)}, actions: make(map[string]int)}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithRequestRecorder(recorder), WithValueSizeLimit(4097, true))
+
+		if w := submit(server, body); w.Code != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		if len(buffer.entries) != 2 {
+			t.Fatalf("Expected 2 entries buffered, got %d", len(buffer.entries))
+		}
+		for _, entry := range buffer.entries {
+			if entry.Name != "last_error" {
+				if _, ok := entry.Metadata[ValueTruncatedMetadataKey]; ok || entry.Value == nil {
+					t.Errorf("Expected %s unchanged, got %v and %v", entry.Name, entry.Value, entry.Metadata)
+				}
+				continue
+			}
+			value, _ := entry.Value.(string)
+			if len(value) > 4097 || !strings.HasSuffix(value, TruncatedValueMarker) || !strings.HasPrefix(value, "éé") {
+				t.Errorf("Expected at most 4097 bytes ending in the marker, got %d bytes", len(value))
+			}
+			if !utf8.ValidString(value) {
+				t.Error("Expected the value cut on a character boundary")
+			}
+			if entry.Metadata[ValueTruncatedMetadataKey] != len(huge)+2 {
+				t.Errorf("Expected the original size %d in metadata, got %v", len(huge)+2, entry.Metadata[ValueTruncatedMetadataKey])
+			}
+		}
+		if recorder.actions["truncated"] != 1 {
+			t.Errorf("Expected 1 truncation, got %v", recorder.actions)
+		}
+	})
+
+	t.Run("measures nested objects by their JSON", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		server := NewServer(8080, "test-api-key-123", buffer, &mockIncidentHandler{}, false, WithValueSizeLimit(32, true))
+
+		nested := `{"pod_name":"api-1","namespace":"prod","data":{"state":{"threads":[1,2,3,4,5,6,7,8,9,10,11,12]}}}`
+		if w := submit(server, []byte(nested)); w.Code != http.StatusOK || len(buffer.entries) != 1 {
+			t.Fatalf("Expected 1 entry accepted, got status %d and %d entries", w.Code, len(buffer.entries))
+		}
+		if value := buffer.entries[0].Value; value != `{"threads":[1,2,3,...[truncated]` {
+			t.Errorf("Expected the truncated JSON of the object, got %v", value)
+		}
+	})
+}
@@ -0,0 +1,57 @@
+// Package clock provides an injectable source of the current time so that
+// time-dependent behavior can be tested deterministically without sleeps.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock supplies the current time. Components accept a Clock instead of
+// calling time.Now directly so tests can control the passage of time.
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is a Clock backed by the system time.
+type Real struct{}
+
+// Now returns the current system time.
+func (Real) Now() time.Time {
+	return time.Now()
+}
+
+// Fake is a Clock whose time only changes when explicitly set or advanced.
+// It is safe for concurrent use.
+type Fake struct {
+	// mutex protects now
+	mutex sync.RWMutex
+	// now is the time returned by Now
+	now time.Time
+}
+
+// NewFake creates a fake clock frozen at the given time.
+func NewFake(now time.Time) *Fake {
+	return &Fake{now: now}
+}
+
+// Now returns the fake clock's current time.
+func (f *Fake) Now() time.Time {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	return f.now
+}
+
+// Advance moves the fake clock forward by the given duration.
+func (f *Fake) Advance(d time.Duration) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.now = f.now.Add(d)
+}
+
+// Set moves the fake clock to the given time.
+func (f *Fake) Set(now time.Time) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.now = now
+}
@@ -0,0 +1,38 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+// TestReal validates that the real clock tracks system time.
+func TestReal(t *testing.T) {
+	before := time.Now()
+	now := Real{}.Now()
+	after := time.Now()
+
+	if now.Before(before) || now.After(after) {
+		t.Errorf("Expected real clock time between %v and %v, got %v", before, after, now)
+	}
+}
+
+// TestFake validates that the fake clock only moves when told to.
+func TestFake(t *testing.T) {
+	start := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	fake := NewFake(start)
+
+	if !fake.Now().Equal(start) {
+		t.Errorf("Expected %v, got %v", start, fake.Now())
+	}
+
+	fake.Advance(90 * time.Second)
+	if want := start.Add(90 * time.Second); !fake.Now().Equal(want) {
+		t.Errorf("Expected %v after advance, got %v", want, fake.Now())
+	}
+
+	later := start.Add(time.Hour)
+	fake.Set(later)
+	if !fake.Now().Equal(later) {
+		t.Errorf("Expected %v after set, got %v", later, fake.Now())
+	}
+}
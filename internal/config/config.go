@@ -5,36 +5,280 @@ package config
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
+	"path"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/formatter"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/ringbuffer"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/shutdown"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
 	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/emitter"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/handler"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
 )
 
+// Daemon modes select which subsystems the daemon runs.
+const (
+	// ModeAgent collects node telemetry, watches pods, ingests sidecar
+	// telemetry and incidents, and serves the API
+	ModeAgent = "agent"
+	// ModeQuery only serves the read APIs (queries, exports and incident
+	// history) over a shared buffer or store, without collecting or ingesting
+	ModeQuery = "query"
+	// ModeAggregator ingests telemetry and incidents, e.g. forwarded by node
+	// daemons, and serves the API, without local collection or pod watching
+	ModeAggregator = "aggregator"
+)
+
+// Subsystems reports which daemon subsystems run. The API server runs in every mode.
+type Subsystems struct {
+	// Collect runs the system, process and Prometheus collectors
+	Collect bool
+	// WatchPods runs the Kubernetes pod watcher
+	WatchPods bool
+	// Ingest accepts telemetry submissions and incident reports over the API
+	Ingest bool
+	// Incidents processes incidents through the handler chain and emitters
+	Incidents bool
+}
+
 // Config holds all configuration parameters for the BlackBox daemon.
 // Configuration is loaded from environment variables with fallback to defaults.
 type Config struct {
+	// Mode selects the subsystems the daemon runs: agent (default, also when
+	// empty), query or aggregator
+	Mode string `json:"mode"`
+
 	// Buffer configuration - controls telemetry retention
 	// BufferWindowSize determines how long telemetry is kept in the ring buffer
 	BufferWindowSize time.Duration `json:"buffer_window_size"`
+	// BufferOverflowPolicy selects what happens when the buffer is full:
+	// overwrite-oldest (default), drop-newest or block
+	BufferOverflowPolicy string `json:"buffer_overflow_policy"`
+	// BufferBlockTimeout bounds how long the block overflow policy waits for
+	// space before dropping an entry (zero waits indefinitely)
+	BufferBlockTimeout time.Duration `json:"buffer_block_timeout"`
+	// BufferIntern interns the names and tags of buffered entries so repeated
+	// strings share storage
+	BufferIntern bool `json:"buffer_intern"`
+	// BufferImport is a glob of gzipped NDJSON telemetry dumps loaded into the
+	// buffer at startup, e.g. "/var/lib/blackbox/backfill/*.ndjson.gz" (empty disables it)
+	BufferImport string `json:"buffer_import"`
+	// BufferDedupMetrics lists glob patterns of metric names whose unchanged
+	// numeric values are collapsed instead of buffered, e.g. "memory_total_bytes"
+	// (empty disables deduplication)
+	BufferDedupMetrics []string `json:"buffer_dedup_metrics"`
+	// BufferDedupInterval bounds how long an unchanged value is collapsed before
+	// it is buffered again (zero uses the default of one minute)
+	BufferDedupInterval time.Duration `json:"buffer_dedup_interval"`
+	// BufferSpillDir is where entries overwritten before they expire are
+	// spilled, to be queried with the buffer (empty disables the disk tier)
+	BufferSpillDir string `json:"buffer_spill_dir"`
+	// BufferSpillMaxBytes bounds the disk used by spilled entries
+	BufferSpillMaxBytes int64 `json:"buffer_spill_max_bytes"`
+	// BufferReservations guarantees each listed source a number of retained
+	// entries that a flood from another source cannot overwrite
+	BufferReservations map[types.TelemetrySource]int `json:"buffer_reservations"`
+	// BufferPriorityRules assign retention priorities by entry name or type;
+	// overwritten high-priority entries are retained (empty disables priority retention)
+	BufferPriorityRules []ringbuffer.PriorityRule `json:"buffer_priority_rules"`
+	// BufferPriorityCapacity bounds the overwritten high-priority entries retained
+	BufferPriorityCapacity int `json:"buffer_priority_capacity"`
+	// BufferHistoryInterval is how often buffer statistics are sampled for
+	// GET /api/v1/buffer/history (zero disables the history)
+	BufferHistoryInterval time.Duration `json:"buffer_history_interval"`
+	// BufferHistoryLength is how many buffer statistics samples are kept
+	BufferHistoryLength int `json:"buffer_history_length"`
 	// CollectionInterval determines how frequently system metrics are collected
 	CollectionInterval time.Duration `json:"collection_interval"`
+	// CollectionStartupDelay postpones the first system collection after
+	// startup, e.g. so values read right after node boot are not buffered
+	CollectionStartupDelay time.Duration `json:"collection_startup_delay"`
+	// IncidentSnapshotWindow limits how much telemetry before an incident is captured
+	// in its report, independent of BufferWindowSize (zero or larger values use the full buffer window)
+	IncidentSnapshotWindow time.Duration `json:"incident_snapshot_window"`
+	// EmitOnInterval additionally emits a synthetic heartbeat incident with the
+	// current telemetry window through the outputs on this interval (zero emits
+	// only on real incidents)
+	EmitOnInterval time.Duration `json:"emit_on_interval"`
+	// IncidentWALPath is the write-ahead log that persists incident history across
+	// restarts (empty keeps incident history in memory only)
+	IncidentWALPath string `json:"incident_wal_path"`
+	// IncidentHistoryMax bounds the number of incidents kept in the history
+	IncidentHistoryMax int `json:"incident_history_max"`
+	// IncidentHistoryMaxAge drops incidents older than this from the history
+	IncidentHistoryMaxAge time.Duration `json:"incident_history_max_age"`
+	// TrackProcesses names processes whose RSS, CPU and open file descriptors are
+	// collected ("name", "comm:name" or "cmdline:<regexp>")
+	TrackProcesses []string `json:"track_processes"`
+	// MaxTrackedProcesses bounds how many matching processes are reported per collection
+	MaxTrackedProcesses int `json:"max_tracked_processes"`
+	// CollectFDLimits collects the soft and hard open file limits of the daemon and
+	// tracked processes, and their open descriptors as a percentage of the soft limit
+	CollectFDLimits bool `json:"collect_fd_limits"`
+	// CollectKernelRates collects the per-second rates of context switches,
+	// interrupts and softirqs from /proc/stat
+	CollectKernelRates bool `json:"collect_kernel_rates"`
+	// CollectSocketStats collects socket usage from /proc/net/sockstat and the
+	// per-second rates of TCP retransmissions and errors and UDP datagram errors
+	// from /proc/net/snmp
+	CollectSocketStats bool `json:"collect_socket_stats"`
+	// CollectZombies counts the processes in the zombie state on every collection
+	CollectZombies bool `json:"collect_zombies"`
+	// ZombieThreshold raises a zombie_accumulation incident once this many zombie
+	// processes exist (0 disables incidents)
+	ZombieThreshold int `json:"zombie_threshold"`
+	// FDUsageThreshold raises an fd_exhaustion incident once the daemon or a tracked
+	// process uses this percentage of its soft open file limit (0 disables incidents)
+	FDUsageThreshold float64 `json:"fd_usage_threshold"`
+	// DefaultTags are added to every telemetry entry at ingestion, e.g. cluster,
+	// region or environment; tags set on an entry take precedence
+	DefaultTags map[string]string `json:"default_tags"`
+	// TelemetryTransforms are rules applied, in order, to every system and sidecar
+	// telemetry entry before it is buffered: rename, drop_tag, add_tag or scale
+	TelemetryTransforms []telemetry.TransformRule `json:"telemetry_transforms"`
+	// TelemetryTypes registers custom telemetry types, beyond the built-in ones,
+	// inferred for sidecar metrics by keyword and accepted by telemetry queries
+	TelemetryTypes []telemetry.TypeDefinition `json:"telemetry_types"`
+	// DiskFullThresholds lists mounts checked for disk-full incidents as
+	// "mount=percent[:minFreeInodes]" pairs separated by commas (empty disables the check)
+	DiskFullThresholds string `json:"disk_full_thresholds"`
+	// MemoryLeakMetric is the telemetry metric watched for steady growth
+	MemoryLeakMetric string `json:"memory_leak_metric"`
+	// MemoryLeakWindow is the span of samples the metric's growth rate is fitted over
+	MemoryLeakWindow time.Duration `json:"memory_leak_window"`
+	// MemoryLeakSlope is the growth rate, in metric units per minute, above which the
+	// metric is considered leaking (zero disables leak detection)
+	MemoryLeakSlope float64 `json:"memory_leak_slope"`
+	// MemoryLeakSustain is how long the growth rate must exceed MemoryLeakSlope before
+	// an incident is raised
+	MemoryLeakSustain time.Duration `json:"memory_leak_sustain"`
+	// LowMemoryAvailableBytes raises a low_memory incident once the node's
+	// memory_available_bytes stays below this floor (0 disables the floor)
+	LowMemoryAvailableBytes uint64 `json:"low_memory_available_bytes"`
+	// LowMemoryUsedPercent raises a low_memory incident once the node's
+	// memory_usage_percent stays at or above this percentage (0 disables the ceiling)
+	LowMemoryUsedPercent float64 `json:"low_memory_used_percent"`
+	// LowMemorySustain is how long memory must stay low before an incident is raised
+	LowMemorySustain time.Duration `json:"low_memory_sustain"`
+	// LowMemoryTopProcesses is how many of the largest tracked processes are
+	// reported with low_memory incidents
+	LowMemoryTopProcesses int `json:"low_memory_top_processes"`
+	// NetworkInterfaces limits network telemetry to interfaces matching these glob
+	// patterns (empty collects every interface not excluded)
+	NetworkInterfaces []string `json:"network_interfaces"`
+	// NetworkInterfacesExclude lists glob patterns of interfaces left out of network
+	// telemetry; defaults to loopback and common CNI, veth and tunnel devices
+	NetworkInterfacesExclude []string `json:"network_interfaces_exclude"`
+	// CPUIOWaitBusy counts time spent waiting for I/O as busy in CPU usage
+	// percentages; when false it counts as idle
+	CPUIOWaitBusy bool `json:"cpu_iowait_busy"`
 
 	// API configuration - controls the REST API server for sidecars
 	// APIPort is the port number for the REST API server
 	APIPort int `json:"api_port"`
+	// BindAddress is the host or IP address the API and metrics servers listen
+	// on, e.g. "127.0.0.1" (empty listens on all interfaces)
+	BindAddress string `json:"bind_address"`
 	// APIKey is the authentication token required for sidecar requests
 	APIKey string `json:"api_key"`
+	// APINamespaceKeys maps namespaces to additional API keys that may only
+	// submit telemetry and incidents for that namespace
+	APINamespaceKeys map[string]string `json:"api_namespace_keys"`
 	// SwaggerEnable controls whether Swagger documentation is available
 	SwaggerEnable bool `json:"swagger_enable"`
+	// APIBasePath is prepended to all API and Swagger routes, e.g. "/blackbox"
+	// when an ingress forwards a path prefix (empty serves routes at the root)
+	APIBasePath string `json:"api_base_path"`
+	// EnablePprof serves the daemon's runtime profiles under /api/v1/debug/pprof/
+	// behind the API key
+	EnablePprof bool `json:"enable_pprof"`
+	// GRPCPort is the port number for the gRPC server hosting the health service (0 disables it)
+	GRPCPort int `json:"grpc_port"`
+	// GRPCQuery serves the read-only telemetry query service on the gRPC port,
+	// behind the API key
+	GRPCQuery bool `json:"grpc_query"`
+	// APIListenBacklog is the length of the API listener's pending connection queue
+	// (0 keeps the system default)
+	APIListenBacklog int `json:"api_listen_backlog"`
+	// APIReusePort sets SO_REUSEPORT on the API listener so several workers can share the port
+	APIReusePort bool `json:"api_reuse_port"`
+	// APIMaxEntriesPerRequest caps the telemetry entries accepted from one sidecar
+	// submission (0 disables the cap)
+	APIMaxEntriesPerRequest int `json:"api_max_entries_per_request"`
+	// APIEntryLimitAction is what happens to a submission over the cap: "reject"
+	// (or empty) answers 400, "truncate" keeps the first entries and logs a warning
+	APIEntryLimitAction string `json:"api_entry_limit_action"`
+	// APIMaxValueBytes caps the serialized size of each sidecar telemetry value
+	// (0 disables the cap)
+	APIMaxValueBytes int `json:"api_max_value_bytes"`
+	// APIValueLimitAction is what happens to a value over the cap: "reject" (or
+	// empty) answers 400, "truncate" cuts the value with a marker
+	APIValueLimitAction string `json:"api_value_limit_action"`
+	// APIExactNumbers keeps sidecar telemetry numbers exact as json.Number so
+	// integer counters above 2^53 keep their precision; when false they are float64
+	APIExactNumbers bool `json:"api_exact_numbers"`
+	// APISidecarHistograms recognizes sidecar telemetry values of type
+	// "histogram" and exports them as Prometheus histograms
+	APISidecarHistograms bool `json:"api_sidecar_histograms"`
+	// APIRequestTracing assigns each API request an ID, returned in the
+	// X-Request-ID header, logged and stamped into the entries it buffers
+	APIRequestTracing bool `json:"api_request_tracing"`
+	// APIClockSkewPolicy handles sidecar timestamps offset from the daemon's clock:
+	// "accept" (default) stores them as sent, "reject" rejects submissions skewed
+	// beyond APIMaxClockSkew and "rebase" replaces timestamps within it with the
+	// receipt time; rejecting and rebasing are mutually exclusive
+	APIClockSkewPolicy string `json:"api_clock_skew_policy"`
+	// APIMaxClockSkew is the largest clock offset the skew policy tolerates
+	APIMaxClockSkew time.Duration `json:"api_max_clock_skew"`
+	// APIStrictRuntimes rejects sidecar telemetry whose runtime is not jvm, go,
+	// nodejs, python, dotnet or one of APIExtraRuntimes
+	APIStrictRuntimes bool `json:"api_strict_runtimes"`
+	// APIExtraRuntimes are runtimes accepted in strict mode besides the built-in ones
+	APIExtraRuntimes []string `json:"api_extra_runtimes"`
+	// APIOptional keeps the daemon running when the API port cannot be bound,
+	// e.g. on nodes whose pods run no sidecars; by default it is fatal
+	APIOptional bool `json:"api_optional"`
 
 	// Prometheus configuration - controls metrics export
 	// MetricsPort is the port number for the Prometheus metrics server
 	MetricsPort int `json:"metrics_port"`
 	// MetricsPath is the HTTP path for metrics endpoint
 	MetricsPath string `json:"metrics_path"`
+	// MetricsRequired makes failing to bind MetricsPort fatal; by default the
+	// daemon continues without the metrics endpoint and reports it degraded
+	MetricsRequired bool `json:"metrics_required"`
+	// MetricsOpenMetrics serves the OpenMetrics format to scrapers that request it
+	MetricsOpenMetrics bool `json:"metrics_openmetrics"`
+	// MetricsCompression gzip-compresses scrape responses for scrapers that accept it
+	MetricsCompression bool `json:"metrics_compression"`
+	// MetricsStaleSeriesMisses is how many consecutive collection cycles an interface or
+	// device must be absent before its series is deleted; zero disables deletion
+	MetricsStaleSeriesMisses int `json:"metrics_stale_series_misses"`
+	// MetricsStaleSeriesWarmup is how many collection cycles after startup no series is deleted
+	MetricsStaleSeriesWarmup int `json:"metrics_stale_series_warmup"`
+	// MetricsSidecarSeriesLimit caps the distinct pods counted by the per-sidecar
+	// request counter; zero removes the cap
+	MetricsSidecarSeriesLimit int `json:"metrics_sidecar_series_limit"`
+	// MetricsLabelValueMaxLength caps the bytes of label values taken from sidecar
+	// submissions; longer values are truncated, and zero removes the cap
+	MetricsLabelValueMaxLength int `json:"metrics_label_value_max_length"`
+	// MetricsExportTypes selects the telemetry types (cpu, memory, network, disk,
+	// process) exported as Prometheus system gauges; empty exports all of them
+	MetricsExportTypes []string `json:"metrics_export_types"`
+	// MetricsBridgeLabels are the labels (source, pod, namespace) of the gauges
+	// bridged from telemetry entries; entries with the same name that differ in
+	// one of them are exported as separate series
+	MetricsBridgeLabels []string `json:"metrics_bridge_labels"`
+	// MetricsBridgeSeriesLimit caps the series of the gauges bridged from
+	// telemetry entries; zero removes the cap
+	MetricsBridgeSeriesLimit int `json:"metrics_bridge_series_limit"`
 
 	// Kubernetes configuration - controls cluster integration
 	// NodeName identifies which node this daemon is running on
@@ -43,36 +287,143 @@ type Config struct {
 	PodNamespace string `json:"pod_namespace"`
 	// KubeConfig is the path to kubeconfig file (optional, uses in-cluster config by default)
 	KubeConfig string `json:"kube_config"`
+	// WatchWorkloads restricts pod watching to pods owned by these workloads
+	// ("kind/name" or "namespace/kind/name"); empty watches all pods on the node
+	WatchWorkloads []string `json:"watch_workloads"`
+	// WatcherAuthRebuildThreshold is how many consecutive watches rejected with
+	// 401 or 403 rebuild the Kubernetes clientset from freshly loaded
+	// credentials; zero disables rebuilding
+	WatcherAuthRebuildThreshold int `json:"watcher_auth_rebuild_threshold"`
+	// WatcherAuthBackoffMax caps the delay between watches rejected with 401 or
+	// 403, which doubles from the retry interval on every rejection
+	WatcherAuthBackoffMax time.Duration `json:"watcher_auth_backoff_max"`
+	// NodeConditionsTTL is how long the node conditions attached to crash reports
+	// (Ready and active pressure conditions) are cached; zero disables the enrichment
+	NodeConditionsTTL time.Duration `json:"node_conditions_ttl"`
+	// CRISocket is the container runtime's CRI socket (a path or unix:// URL)
+	// queried for crashed containers' final stats; empty disables the enrichment
+	CRISocket string `json:"cri_socket"`
+	// CrashLogLines is how many of a crashed container's last log lines are
+	// attached to crash reports; zero disables the capture
+	CrashLogLines int `json:"crash_log_lines"`
+	// CrashLogParse selects how captured log lines are parsed: "raw", "json"
+	// or "auto" (JSON, logfmt and plain lines with a level)
+	CrashLogParse string `json:"crash_log_parse"`
+	// CrashLogConcurrency is how many crashed containers' logs are read at
+	// once (zero uses the default of 4); crashes beyond it are reported
+	// without logs
+	CrashLogConcurrency int `json:"crash_log_concurrency"`
+	// WatchConfigMap reloads the settings that are safe to change at runtime
+	// (see ReloadableKeys) from a ConfigMap whenever it changes
+	WatchConfigMap bool `json:"watch_configmap"`
+	// ConfigMapName is the name of the watched ConfigMap
+	ConfigMapName string `json:"configmap_name"`
+	// ConfigMapNamespace is the namespace of the watched ConfigMap (empty uses PodNamespace)
+	ConfigMapNamespace string `json:"configmap_namespace"`
 
 	// Output configuration - controls incident report formatting
 	// OutputFormatters is a list of formatters to use for incident reports
 	OutputFormatters []string `json:"output_formatters"`
 	// OutputPath is the directory or destination for incident reports
 	OutputPath string `json:"output_path"`
+	// OutputTimestampFormat is the Go time layout used by the default and CSV formatters
+	// (empty keeps each formatter's built-in layout)
+	OutputTimestampFormat string `json:"output_timestamp_format"`
+	// OutputTimezone is the IANA timezone timestamps are converted to before formatting
+	// (empty keeps the original timezone)
+	OutputTimezone string `json:"output_timezone"`
+	// OutputValuePrecision is the number of decimal places floating-point values are
+	// rendered with by the default and CSV formatters (zero keeps full precision)
+	OutputValuePrecision int `json:"output_value_precision"`
+	// OutputIncludeRaw keeps the raw telemetry listing after the summary in the
+	// default formatter (false reports only the header and summary)
+	OutputIncludeRaw bool `json:"output_include_raw"`
+	// OutputDeltaMetrics are glob patterns of counter names, e.g. "*_total", the JSON
+	// and CSV formatters emit as the difference from their previously emitted value
+	// (empty emits absolute values)
+	OutputDeltaMetrics []string `json:"output_delta_metrics"`
+	// OutputTemplateDir is the directory of *.tmpl files used by "template:<name>" formatters;
+	// templates are reloaded when they change
+	OutputTemplateDir string `json:"output_template_dir"`
 
 	// Emitter configuration - controls where formatted logs are emitted
 	// Emitters is a list of emitter configurations for sending formatted logs to various destinations
 	Emitters []emitter.EmitterConfig `json:"emitters"`
+	// EmitterFailureThreshold is how many consecutive failed emits make an
+	// emitter configured with "critical": true fail the deep health check
+	// (0 uses emitter.DefaultFailureThreshold)
+	EmitterFailureThreshold int `json:"emitter_failure_threshold"`
+	// EmitterProbeInterval is how often critical emitters that support it
+	// probe their destination's connectivity (0 uses emitter.DefaultProbeInterval)
+	EmitterProbeInterval time.Duration `json:"emitter_probe_interval"`
+
+	// Incident handler configuration - controls how incidents are processed
+	// IncidentHandlers is the ordered chain of registered incident handlers each incident passes through
+	IncidentHandlers []handler.HandlerConfig `json:"incident_handlers"`
 
 	// Logging configuration - controls daemon logging behavior
 	// LogLevel controls the verbosity of logging (debug, info, warn, error)
 	LogLevel string `json:"log_level"`
 	// LogJSON controls whether logs are formatted as JSON
 	LogJSON bool `json:"log_json"`
+	// SelfIncidentGoroutines includes a dump of all goroutines, not just the
+	// panicking one, in self-incidents raised for recovered panics
+	SelfIncidentGoroutines bool `json:"self_incident_goroutines"`
+
+	// Shutdown configuration - controls how the daemon stops on SIGTERM
+	// ShutdownGracePeriod bounds the whole shutdown sequence (0 uses shutdown.DefaultGracePeriod)
+	ShutdownGracePeriod time.Duration `json:"shutdown_grace_period"`
+	// ShutdownOrder is the order the subsystems stop in: api, collectors,
+	// watcher, snapshot, emitters, stores and metrics (empty uses
+	// shutdown.DefaultOrder)
+	ShutdownOrder []string `json:"shutdown_order"`
 }
 
 // DefaultConfig returns a configuration with sensible defaults for production use.
 // These defaults prioritize performance and security while providing comprehensive monitoring.
 func DefaultConfig() *Config {
 	return &Config{
-		BufferWindowSize:   60 * time.Second,
-		CollectionInterval: 1 * time.Second,
-		APIPort:            8080,
-		SwaggerEnable:      false,
-		MetricsPort:        9090,
-		MetricsPath:        "/metrics",
-		OutputFormatters:   []string{"default"},
-		OutputPath:         "/var/log/blackbox",
+		Mode:                       ModeAgent,
+		BufferWindowSize:           60 * time.Second,
+		BufferBlockTimeout:         ringbuffer.DefaultBlockTimeout,
+		BufferDedupInterval:        ringbuffer.DefaultDedupInterval,
+		BufferSpillMaxBytes:        ringbuffer.DefaultSpillMaxBytes,
+		BufferPriorityCapacity:     ringbuffer.DefaultPriorityCapacity,
+		BufferHistoryInterval:      ringbuffer.DefaultHistoryInterval,
+		BufferHistoryLength:        ringbuffer.DefaultHistoryLength,
+		CollectionInterval:         1 * time.Second,
+		IncidentSnapshotWindow:     60 * time.Second,
+		IncidentHistoryMax:         1000,
+		IncidentHistoryMaxAge:      7 * 24 * time.Hour,
+		MaxTrackedProcesses:        20,
+		MemoryLeakMetric:           telemetry.DefaultLeakMetric,
+		MemoryLeakWindow:           telemetry.DefaultLeakWindow,
+		MemoryLeakSustain:          telemetry.DefaultLeakSustain,
+		LowMemorySustain:           telemetry.DefaultLowMemorySustain,
+		LowMemoryTopProcesses:      telemetry.DefaultLowMemoryTopProcesses,
+		NetworkInterfacesExclude:   telemetry.DefaultExcludedInterfaces,
+		CPUIOWaitBusy:              true,
+		APIPort:                    8080,
+		APIEntryLimitAction:        "reject",
+		APIValueLimitAction:        "reject",
+		APIExactNumbers:            true,
+		APIClockSkewPolicy:         "accept",
+		APIMaxClockSkew:            time.Minute,
+		SwaggerEnable:              false,
+		EnablePprof:                false,
+		MetricsPort:                9090,
+		MetricsPath:                "/metrics",
+		MetricsOpenMetrics:         true,
+		MetricsCompression:         true,
+		MetricsStaleSeriesMisses:   3,
+		MetricsStaleSeriesWarmup:   2,
+		MetricsSidecarSeriesLimit:  1000,
+		MetricsLabelValueMaxLength: 256,
+		MetricsBridgeLabels:        []string{"source"},
+		MetricsBridgeSeriesLimit:   1000,
+		OutputFormatters:           []string{"default"},
+		OutputPath:                 "/var/log/blackbox",
+		OutputIncludeRaw:           true,
 		Emitters: []emitter.EmitterConfig{
 			{
 				Type: "file",
@@ -83,8 +434,23 @@ func DefaultConfig() *Config {
 				},
 			},
 		},
-		LogLevel: "info",
-		LogJSON:  true,
+		EmitterFailureThreshold: emitter.DefaultFailureThreshold,
+		EmitterProbeInterval:    emitter.DefaultProbeInterval,
+		IncidentHandlers: []handler.HandlerConfig{
+			{Type: "metrics"},
+			{Type: "ratelimit"},
+			{Type: "dedup"},
+			{Type: "output"},
+		},
+		WatcherAuthRebuildThreshold: 3,
+		WatcherAuthBackoffMax:       5 * time.Minute,
+		NodeConditionsTTL:           15 * time.Second,
+		CrashLogParse:               "auto",
+		CrashLogConcurrency:         4,
+		LogLevel:                    "info",
+		LogJSON:                     true,
+		ShutdownGracePeriod:         shutdown.DefaultGracePeriod,
+		ShutdownOrder:               append([]string(nil), shutdown.DefaultOrder...),
 	}
 }
 
@@ -93,10 +459,19 @@ func DefaultConfig() *Config {
 // This function reads all supported environment variables and validates their values.
 // Returns an error if any configuration value is invalid.
 func LoadFromEnv() (*Config, error) {
+	return load(os.Getenv)
+}
+
+// load creates a Config from the defaults and the variables returned by getenv.
+func load(getenv func(key string) string) (*Config, error) {
 	cfg := DefaultConfig()
 
+	if val := getenv("BLACKBOX_MODE"); val != "" {
+		cfg.Mode = strings.ToLower(strings.TrimSpace(val))
+	}
+
 	// Buffer configuration
-	if val := os.Getenv("BLACKBOX_BUFFER_WINDOW_SIZE"); val != "" {
+	if val := getenv("BLACKBOX_BUFFER_WINDOW_SIZE"); val != "" {
 		duration, err := time.ParseDuration(val)
 		if err != nil {
 			return nil, fmt.Errorf("invalid BLACKBOX_BUFFER_WINDOW_SIZE: %w", err)
@@ -104,7 +479,107 @@ func LoadFromEnv() (*Config, error) {
 		cfg.BufferWindowSize = duration
 	}
 
-	if val := os.Getenv("BLACKBOX_COLLECTION_INTERVAL"); val != "" {
+	if val := getenv("BLACKBOX_BUFFER_OVERFLOW_POLICY"); val != "" {
+		cfg.BufferOverflowPolicy = val
+	}
+
+	if val := getenv("BLACKBOX_BUFFER_BLOCK_TIMEOUT"); val != "" {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_BUFFER_BLOCK_TIMEOUT: %w", err)
+		}
+		cfg.BufferBlockTimeout = duration
+	}
+
+	if val := getenv("BLACKBOX_BUFFER_INTERN"); val != "" {
+		intern, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_BUFFER_INTERN: %w", err)
+		}
+		cfg.BufferIntern = intern
+	}
+
+	if val := getenv("BLACKBOX_BUFFER_IMPORT"); val != "" {
+		cfg.BufferImport = val
+	}
+
+	if val := getenv("BLACKBOX_BUFFER_DEDUP_METRICS"); val != "" {
+		cfg.BufferDedupMetrics = nil
+		for _, pattern := range strings.Split(val, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				cfg.BufferDedupMetrics = append(cfg.BufferDedupMetrics, pattern)
+			}
+		}
+	}
+
+	if val := getenv("BLACKBOX_BUFFER_DEDUP_INTERVAL"); val != "" {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_BUFFER_DEDUP_INTERVAL: %w", err)
+		}
+		cfg.BufferDedupInterval = duration
+	}
+
+	if val := getenv("BLACKBOX_BUFFER_SPILL_DIR"); val != "" {
+		cfg.BufferSpillDir = val
+	}
+
+	if val := getenv("BLACKBOX_BUFFER_SPILL_MAX_BYTES"); val != "" {
+		maxBytes, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_BUFFER_SPILL_MAX_BYTES: %w", err)
+		}
+		cfg.BufferSpillMaxBytes = maxBytes
+	}
+
+	if val := getenv("BLACKBOX_BUFFER_RESERVATIONS"); val != "" {
+		pairs, err := parseTags(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_BUFFER_RESERVATIONS: %w", err)
+		}
+		cfg.BufferReservations = make(map[types.TelemetrySource]int, len(pairs))
+		for source, count := range pairs {
+			reserved, err := strconv.Atoi(count)
+			if err != nil {
+				return nil, fmt.Errorf("invalid BLACKBOX_BUFFER_RESERVATIONS: %w", err)
+			}
+			cfg.BufferReservations[types.TelemetrySource(source)] = reserved
+		}
+	}
+
+	if val := getenv("BLACKBOX_BUFFER_PRIORITY_RULES"); val != "" {
+		rules, err := ringbuffer.ParsePriorityRules(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_BUFFER_PRIORITY_RULES: %w", err)
+		}
+		cfg.BufferPriorityRules = rules
+	}
+
+	if val := getenv("BLACKBOX_BUFFER_PRIORITY_CAPACITY"); val != "" {
+		capacity, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_BUFFER_PRIORITY_CAPACITY: %w", err)
+		}
+		cfg.BufferPriorityCapacity = capacity
+	}
+
+	if val := getenv("BLACKBOX_BUFFER_HISTORY_INTERVAL"); val != "" {
+		interval, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_BUFFER_HISTORY_INTERVAL: %w", err)
+		}
+		cfg.BufferHistoryInterval = interval
+	}
+
+	if val := getenv("BLACKBOX_BUFFER_HISTORY_LENGTH"); val != "" {
+		length, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_BUFFER_HISTORY_LENGTH: %w", err)
+		}
+		cfg.BufferHistoryLength = length
+	}
+
+	if val := getenv("BLACKBOX_COLLECTION_INTERVAL"); val != "" {
 		duration, err := time.ParseDuration(val)
 		if err != nil {
 			return nil, fmt.Errorf("invalid BLACKBOX_COLLECTION_INTERVAL: %w", err)
@@ -112,8 +587,227 @@ func LoadFromEnv() (*Config, error) {
 		cfg.CollectionInterval = duration
 	}
 
+	if val := getenv("BLACKBOX_COLLECTION_STARTUP_DELAY"); val != "" {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_COLLECTION_STARTUP_DELAY: %w", err)
+		}
+		cfg.CollectionStartupDelay = duration
+	}
+
+	if val := getenv("BLACKBOX_TRACK_PROCESSES"); val != "" {
+		cfg.TrackProcesses = strings.Split(val, ",")
+		for i, process := range cfg.TrackProcesses {
+			cfg.TrackProcesses[i] = strings.TrimSpace(process)
+		}
+	}
+
+	if val := getenv("BLACKBOX_TRACK_PROCESSES_MAX"); val != "" {
+		max, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_TRACK_PROCESSES_MAX: %w", err)
+		}
+		cfg.MaxTrackedProcesses = max
+	}
+
+	if val := getenv("BLACKBOX_COLLECT_FD_LIMITS"); val != "" {
+		collect, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_COLLECT_FD_LIMITS: %w", err)
+		}
+		cfg.CollectFDLimits = collect
+	}
+
+	if val := getenv("BLACKBOX_COLLECT_KERNEL_RATES"); val != "" {
+		collect, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_COLLECT_KERNEL_RATES: %w", err)
+		}
+		cfg.CollectKernelRates = collect
+	}
+
+	if val := getenv("BLACKBOX_COLLECT_SOCKET_STATS"); val != "" {
+		collect, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_COLLECT_SOCKET_STATS: %w", err)
+		}
+		cfg.CollectSocketStats = collect
+	}
+
+	if val := getenv("BLACKBOX_COLLECT_ZOMBIES"); val != "" {
+		collect, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_COLLECT_ZOMBIES: %w", err)
+		}
+		cfg.CollectZombies = collect
+	}
+
+	if val := getenv("BLACKBOX_ZOMBIE_THRESHOLD"); val != "" {
+		threshold, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_ZOMBIE_THRESHOLD: %w", err)
+		}
+		cfg.ZombieThreshold = threshold
+	}
+
+	if val := getenv("BLACKBOX_FD_USAGE_THRESHOLD"); val != "" {
+		threshold, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_FD_USAGE_THRESHOLD: %w", err)
+		}
+		cfg.FDUsageThreshold = threshold
+	}
+
+	if val := getenv("BLACKBOX_DEFAULT_TAGS"); val != "" {
+		tags, err := parseTags(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_DEFAULT_TAGS: %w", err)
+		}
+		cfg.DefaultTags = tags
+	}
+
+	if val := getenv("BLACKBOX_TELEMETRY_TRANSFORMS"); val != "" {
+		var rules []telemetry.TransformRule
+		if err := json.Unmarshal([]byte(val), &rules); err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_TELEMETRY_TRANSFORMS JSON: %w", err)
+		}
+		cfg.TelemetryTransforms = rules
+	}
+
+	if val := getenv("BLACKBOX_TELEMETRY_TYPES"); val != "" {
+		var definitions []telemetry.TypeDefinition
+		if err := json.Unmarshal([]byte(val), &definitions); err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_TELEMETRY_TYPES JSON: %w", err)
+		}
+		cfg.TelemetryTypes = definitions
+	}
+
+	if val := getenv("BLACKBOX_DISK_FULL_THRESHOLDS"); val != "" {
+		cfg.DiskFullThresholds = val
+	}
+
+	if val := getenv("BLACKBOX_MEMORY_LEAK_METRIC"); val != "" {
+		cfg.MemoryLeakMetric = val
+	}
+
+	if val := getenv("BLACKBOX_MEMORY_LEAK_WINDOW"); val != "" {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_MEMORY_LEAK_WINDOW: %w", err)
+		}
+		cfg.MemoryLeakWindow = duration
+	}
+
+	if val := getenv("BLACKBOX_MEMORY_LEAK_SLOPE"); val != "" {
+		slope, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_MEMORY_LEAK_SLOPE: %w", err)
+		}
+		cfg.MemoryLeakSlope = slope
+	}
+
+	if val := getenv("BLACKBOX_MEMORY_LEAK_SUSTAIN"); val != "" {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_MEMORY_LEAK_SUSTAIN: %w", err)
+		}
+		cfg.MemoryLeakSustain = duration
+	}
+
+	if val := getenv("BLACKBOX_LOW_MEMORY_AVAILABLE_BYTES"); val != "" {
+		floor, err := strconv.ParseUint(val, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_LOW_MEMORY_AVAILABLE_BYTES: %w", err)
+		}
+		cfg.LowMemoryAvailableBytes = floor
+	}
+
+	if val := getenv("BLACKBOX_LOW_MEMORY_USED_PERCENT"); val != "" {
+		percent, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_LOW_MEMORY_USED_PERCENT: %w", err)
+		}
+		cfg.LowMemoryUsedPercent = percent
+	}
+
+	if val := getenv("BLACKBOX_LOW_MEMORY_SUSTAIN"); val != "" {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_LOW_MEMORY_SUSTAIN: %w", err)
+		}
+		cfg.LowMemorySustain = duration
+	}
+
+	if val := getenv("BLACKBOX_LOW_MEMORY_TOP_PROCESSES"); val != "" {
+		count, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_LOW_MEMORY_TOP_PROCESSES: %w", err)
+		}
+		cfg.LowMemoryTopProcesses = count
+	}
+
+	if val := getenv("BLACKBOX_NETWORK_INTERFACES"); val != "" {
+		patterns, err := telemetry.ParseInterfacePatterns(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_NETWORK_INTERFACES: %w", err)
+		}
+		cfg.NetworkInterfaces = patterns
+	}
+
+	if val := getenv("BLACKBOX_NETWORK_INTERFACES_EXCLUDE"); val != "" {
+		patterns, err := telemetry.ParseInterfacePatterns(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_NETWORK_INTERFACES_EXCLUDE: %w", err)
+		}
+		cfg.NetworkInterfacesExclude = patterns
+	}
+
+	if val := getenv("BLACKBOX_CPU_IOWAIT_BUSY"); val != "" {
+		busy, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_CPU_IOWAIT_BUSY: %w", err)
+		}
+		cfg.CPUIOWaitBusy = busy
+	}
+
+	if val := getenv("BLACKBOX_INCIDENT_SNAPSHOT_WINDOW"); val != "" {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_INCIDENT_SNAPSHOT_WINDOW: %w", err)
+		}
+		cfg.IncidentSnapshotWindow = duration
+	}
+
+	if val := getenv("BLACKBOX_EMIT_ON_INTERVAL"); val != "" {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_EMIT_ON_INTERVAL: %w", err)
+		}
+		cfg.EmitOnInterval = duration
+	}
+
+	if val := getenv("BLACKBOX_INCIDENT_WAL_PATH"); val != "" {
+		cfg.IncidentWALPath = val
+	}
+
+	if val := getenv("BLACKBOX_INCIDENT_HISTORY_MAX"); val != "" {
+		max, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_INCIDENT_HISTORY_MAX: %w", err)
+		}
+		cfg.IncidentHistoryMax = max
+	}
+
+	if val := getenv("BLACKBOX_INCIDENT_HISTORY_MAX_AGE"); val != "" {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_INCIDENT_HISTORY_MAX_AGE: %w", err)
+		}
+		cfg.IncidentHistoryMaxAge = duration
+	}
+
 	// API configuration
-	if val := os.Getenv("BLACKBOX_API_PORT"); val != "" {
+	if val := getenv("BLACKBOX_API_PORT"); val != "" {
 		port, err := strconv.Atoi(val)
 		if err != nil {
 			return nil, fmt.Errorf("invalid BLACKBOX_API_PORT: %w", err)
@@ -121,11 +815,23 @@ func LoadFromEnv() (*Config, error) {
 		cfg.APIPort = port
 	}
 
-	if val := os.Getenv("BLACKBOX_API_KEY"); val != "" {
+	if val := getenv("BLACKBOX_BIND_ADDRESS"); val != "" {
+		cfg.BindAddress = strings.TrimSuffix(strings.TrimPrefix(val, "["), "]")
+	}
+
+	if val := getenv("BLACKBOX_API_KEY"); val != "" {
 		cfg.APIKey = val
 	}
 
-	if val := os.Getenv("BLACKBOX_SWAGGER_ENABLE"); val != "" {
+	if val := getenv("BLACKBOX_API_NAMESPACE_KEYS"); val != "" {
+		keys, err := parseTags(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_API_NAMESPACE_KEYS: %w", err)
+		}
+		cfg.APINamespaceKeys = keys
+	}
+
+	if val := getenv("BLACKBOX_SWAGGER_ENABLE"); val != "" {
 		enable, err := strconv.ParseBool(val)
 		if err != nil {
 			return nil, fmt.Errorf("invalid BLACKBOX_SWAGGER_ENABLE: %w", err)
@@ -133,105 +839,786 @@ func LoadFromEnv() (*Config, error) {
 		cfg.SwaggerEnable = enable
 	}
 
-	// Prometheus configuration
-	if val := os.Getenv("BLACKBOX_METRICS_PORT"); val != "" {
-		port, err := strconv.Atoi(val)
+	if val := getenv("BLACKBOX_PPROF_ENABLE"); val != "" {
+		enable, err := strconv.ParseBool(val)
 		if err != nil {
-			return nil, fmt.Errorf("invalid BLACKBOX_METRICS_PORT: %w", err)
+			return nil, fmt.Errorf("invalid BLACKBOX_PPROF_ENABLE: %w", err)
 		}
-		cfg.MetricsPort = port
-	}
-
-	if val := os.Getenv("BLACKBOX_METRICS_PATH"); val != "" {
-		cfg.MetricsPath = val
+		cfg.EnablePprof = enable
 	}
 
-	// Kubernetes configuration
-	if val := os.Getenv("NODE_NAME"); val != "" {
-		cfg.NodeName = val
+	if val := getenv("BLACKBOX_API_BASE_PATH"); val != "" {
+		cfg.APIBasePath = val
 	}
 
-	if val := os.Getenv("POD_NAMESPACE"); val != "" {
-		cfg.PodNamespace = val
+	if val := getenv("BLACKBOX_GRPC_PORT"); val != "" {
+		port, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_GRPC_PORT: %w", err)
+		}
+		cfg.GRPCPort = port
 	}
 
-	if val := os.Getenv("KUBECONFIG"); val != "" {
-		cfg.KubeConfig = val
+	if val := getenv("BLACKBOX_GRPC_QUERY"); val != "" {
+		enable, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_GRPC_QUERY: %w", err)
+		}
+		cfg.GRPCQuery = enable
 	}
 
-	// Output configuration
-	if val := os.Getenv("BLACKBOX_OUTPUT_FORMATTERS"); val != "" {
-		cfg.OutputFormatters = strings.Split(val, ",")
-		for i, formatter := range cfg.OutputFormatters {
-			cfg.OutputFormatters[i] = strings.TrimSpace(formatter)
+	if val := getenv("BLACKBOX_API_LISTEN_BACKLOG"); val != "" {
+		backlog, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_API_LISTEN_BACKLOG: %w", err)
 		}
+		cfg.APIListenBacklog = backlog
 	}
 
-	if val := os.Getenv("BLACKBOX_OUTPUT_PATH"); val != "" {
-		cfg.OutputPath = val
+	if val := getenv("BLACKBOX_API_REUSE_PORT"); val != "" {
+		enable, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_API_REUSE_PORT: %w", err)
+		}
+		cfg.APIReusePort = enable
 	}
 
-	// Emitter configuration
-	if val := os.Getenv("BLACKBOX_EMITTERS"); val != "" {
-		var emitterConfigs []emitter.EmitterConfig
-		if err := json.Unmarshal([]byte(val), &emitterConfigs); err != nil {
-			return nil, fmt.Errorf("invalid BLACKBOX_EMITTERS JSON: %w", err)
+	if val := getenv("BLACKBOX_API_MAX_ENTRIES_PER_REQUEST"); val != "" {
+		limit, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_API_MAX_ENTRIES_PER_REQUEST: %w", err)
 		}
-		cfg.Emitters = emitterConfigs
+		cfg.APIMaxEntriesPerRequest = limit
 	}
 
-	// Logging configuration
-	if val := os.Getenv("BLACKBOX_LOG_LEVEL"); val != "" {
-		cfg.LogLevel = val
+	if val := getenv("BLACKBOX_API_ENTRY_LIMIT_ACTION"); val != "" {
+		cfg.APIEntryLimitAction = val
 	}
 
-	if val := os.Getenv("BLACKBOX_LOG_JSON"); val != "" {
-		json, err := strconv.ParseBool(val)
+	if val := getenv("BLACKBOX_API_MAX_VALUE_BYTES"); val != "" {
+		limit, err := strconv.Atoi(val)
 		if err != nil {
-			return nil, fmt.Errorf("invalid BLACKBOX_LOG_JSON: %w", err)
+			return nil, fmt.Errorf("invalid BLACKBOX_API_MAX_VALUE_BYTES: %w", err)
 		}
-		cfg.LogJSON = json
+		cfg.APIMaxValueBytes = limit
 	}
 
-	return cfg, nil
-}
-
-// Validate checks if the configuration is valid and returns an error if not.
-// This performs comprehensive validation of all configuration parameters to ensure
-// the daemon can start successfully with the provided configuration.
-func (c *Config) Validate() error {
-	if c.BufferWindowSize <= 0 {
-		return fmt.Errorf("buffer window size must be positive")
+	if val := getenv("BLACKBOX_API_VALUE_LIMIT_ACTION"); val != "" {
+		cfg.APIValueLimitAction = val
 	}
 
-	if c.CollectionInterval <= 0 {
-		return fmt.Errorf("collection interval must be positive")
+	if val := getenv("BLACKBOX_API_EXACT_NUMBERS"); val != "" {
+		exact, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_API_EXACT_NUMBERS: %w", err)
+		}
+		cfg.APIExactNumbers = exact
 	}
 
-	if c.APIPort <= 0 || c.APIPort > 65535 {
-		return fmt.Errorf("API port must be between 1 and 65535")
+	if val := getenv("BLACKBOX_API_SIDECAR_HISTOGRAMS"); val != "" {
+		enabled, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_API_SIDECAR_HISTOGRAMS: %w", err)
+		}
+		cfg.APISidecarHistograms = enabled
 	}
 
-	if c.MetricsPort <= 0 || c.MetricsPort > 65535 {
-		return fmt.Errorf("metrics port must be between 1 and 65535")
+	if val := getenv("BLACKBOX_API_REQUEST_TRACING"); val != "" {
+		enabled, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_API_REQUEST_TRACING: %w", err)
+		}
+		cfg.APIRequestTracing = enabled
 	}
 
-	if c.APIKey == "" {
-		return fmt.Errorf("API key is required for sidecar authentication")
+	if val := getenv("BLACKBOX_API_CLOCK_SKEW_POLICY"); val != "" {
+		cfg.APIClockSkewPolicy = val
 	}
 
-	if len(c.OutputFormatters) == 0 {
-		return fmt.Errorf("at least one output formatter must be specified")
+	if val := getenv("BLACKBOX_API_MAX_CLOCK_SKEW"); val != "" {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_API_MAX_CLOCK_SKEW: %w", err)
+		}
+		cfg.APIMaxClockSkew = duration
 	}
 
-	validLogLevels := map[string]bool{
-		"debug": true,
-		"info":  true,
-		"warn":  true,
-		"error": true,
+	if val := getenv("BLACKBOX_API_STRICT_RUNTIMES"); val != "" {
+		strict, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_API_STRICT_RUNTIMES: %w", err)
+		}
+		cfg.APIStrictRuntimes = strict
 	}
-	if !validLogLevels[c.LogLevel] {
-		return fmt.Errorf("invalid log level: %s", c.LogLevel)
+
+	if val := getenv("BLACKBOX_API_EXTRA_RUNTIMES"); val != "" {
+		cfg.APIExtraRuntimes = strings.Split(val, ",")
+	}
+
+	if val := getenv("BLACKBOX_API_OPTIONAL"); val != "" {
+		optional, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_API_OPTIONAL: %w", err)
+		}
+		cfg.APIOptional = optional
+	}
+
+	// Prometheus configuration
+	if val := getenv("BLACKBOX_METRICS_PORT"); val != "" {
+		port, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_METRICS_PORT: %w", err)
+		}
+		cfg.MetricsPort = port
+	}
+
+	if val := getenv("BLACKBOX_METRICS_PATH"); val != "" {
+		cfg.MetricsPath = val
+	}
+
+	if val := getenv("BLACKBOX_METRICS_REQUIRED"); val != "" {
+		required, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_METRICS_REQUIRED: %w", err)
+		}
+		cfg.MetricsRequired = required
+	}
+
+	if val := getenv("BLACKBOX_METRICS_OPENMETRICS"); val != "" {
+		enable, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_METRICS_OPENMETRICS: %w", err)
+		}
+		cfg.MetricsOpenMetrics = enable
+	}
+
+	if val := getenv("BLACKBOX_METRICS_COMPRESSION"); val != "" {
+		enable, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_METRICS_COMPRESSION: %w", err)
+		}
+		cfg.MetricsCompression = enable
+	}
+
+	if val := getenv("BLACKBOX_METRICS_STALE_MISSES"); val != "" {
+		misses, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_METRICS_STALE_MISSES: %w", err)
+		}
+		cfg.MetricsStaleSeriesMisses = misses
+	}
+
+	if val := getenv("BLACKBOX_METRICS_STALE_WARMUP"); val != "" {
+		warmup, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_METRICS_STALE_WARMUP: %w", err)
+		}
+		cfg.MetricsStaleSeriesWarmup = warmup
+	}
+
+	if val := getenv("BLACKBOX_METRICS_SIDECAR_SERIES_LIMIT"); val != "" {
+		limit, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_METRICS_SIDECAR_SERIES_LIMIT: %w", err)
+		}
+		cfg.MetricsSidecarSeriesLimit = limit
+	}
+
+	if val := getenv("BLACKBOX_METRICS_LABEL_VALUE_MAX_LENGTH"); val != "" {
+		length, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_METRICS_LABEL_VALUE_MAX_LENGTH: %w", err)
+		}
+		cfg.MetricsLabelValueMaxLength = length
+	}
+
+	if val := getenv("BLACKBOX_METRICS_EXPORT_TYPES"); val != "" {
+		cfg.MetricsExportTypes = nil
+		for _, name := range strings.Split(val, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				cfg.MetricsExportTypes = append(cfg.MetricsExportTypes, name)
+			}
+		}
+	}
+
+	if val := getenv("BLACKBOX_METRICS_BRIDGE_LABELS"); val != "" {
+		cfg.MetricsBridgeLabels = nil
+		for _, name := range strings.Split(val, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				cfg.MetricsBridgeLabels = append(cfg.MetricsBridgeLabels, name)
+			}
+		}
+	}
+
+	if val := getenv("BLACKBOX_METRICS_BRIDGE_SERIES_LIMIT"); val != "" {
+		limit, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_METRICS_BRIDGE_SERIES_LIMIT: %w", err)
+		}
+		cfg.MetricsBridgeSeriesLimit = limit
+	}
+
+	// Kubernetes configuration
+	if val := getenv("NODE_NAME"); val != "" {
+		cfg.NodeName = val
+	}
+
+	if val := getenv("POD_NAMESPACE"); val != "" {
+		cfg.PodNamespace = val
+	}
+
+	if val := getenv("KUBECONFIG"); val != "" {
+		cfg.KubeConfig = val
+	}
+
+	if val := getenv("BLACKBOX_WATCH_WORKLOADS"); val != "" {
+		cfg.WatchWorkloads = strings.Split(val, ",")
+		for i, workload := range cfg.WatchWorkloads {
+			cfg.WatchWorkloads[i] = strings.TrimSpace(workload)
+		}
+	}
+
+	if val := getenv("BLACKBOX_WATCHER_AUTH_REBUILD_THRESHOLD"); val != "" {
+		threshold, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_WATCHER_AUTH_REBUILD_THRESHOLD: %w", err)
+		}
+		cfg.WatcherAuthRebuildThreshold = threshold
+	}
+
+	if val := getenv("BLACKBOX_WATCHER_AUTH_BACKOFF_MAX"); val != "" {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_WATCHER_AUTH_BACKOFF_MAX: %w", err)
+		}
+		cfg.WatcherAuthBackoffMax = duration
+	}
+
+	if val := getenv("BLACKBOX_NODE_CONDITIONS_TTL"); val != "" {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_NODE_CONDITIONS_TTL: %w", err)
+		}
+		cfg.NodeConditionsTTL = duration
+	}
+
+	if val := getenv("BLACKBOX_CRI_SOCKET"); val != "" {
+		cfg.CRISocket = val
+	}
+
+	if val := getenv("BLACKBOX_CRASH_LOG_LINES"); val != "" {
+		lines, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_CRASH_LOG_LINES: %w", err)
+		}
+		cfg.CrashLogLines = lines
+	}
+
+	if val := getenv("BLACKBOX_CRASH_LOG_PARSE"); val != "" {
+		cfg.CrashLogParse = val
+	}
+
+	if val := getenv("BLACKBOX_CRASH_LOG_CONCURRENCY"); val != "" {
+		concurrency, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_CRASH_LOG_CONCURRENCY: %w", err)
+		}
+		cfg.CrashLogConcurrency = concurrency
+	}
+
+	if val := getenv("BLACKBOX_WATCH_CONFIGMAP"); val != "" {
+		watch, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_WATCH_CONFIGMAP: %w", err)
+		}
+		cfg.WatchConfigMap = watch
+	}
+
+	if val := getenv("BLACKBOX_CONFIGMAP_NAME"); val != "" {
+		cfg.ConfigMapName = val
+	}
+
+	if val := getenv("BLACKBOX_CONFIGMAP_NAMESPACE"); val != "" {
+		cfg.ConfigMapNamespace = val
+	}
+
+	// Output configuration
+	if val := getenv("BLACKBOX_OUTPUT_FORMATTERS"); val != "" {
+		cfg.OutputFormatters = strings.Split(val, ",")
+		for i, formatter := range cfg.OutputFormatters {
+			cfg.OutputFormatters[i] = strings.TrimSpace(formatter)
+		}
+	}
+
+	if val := getenv("BLACKBOX_OUTPUT_PATH"); val != "" {
+		cfg.OutputPath = val
+	}
+
+	if val := getenv("BLACKBOX_OUTPUT_TIMESTAMP_FORMAT"); val != "" {
+		cfg.OutputTimestampFormat = val
+	}
+
+	if val := getenv("BLACKBOX_OUTPUT_TIMEZONE"); val != "" {
+		cfg.OutputTimezone = val
+	}
+
+	if val := getenv("BLACKBOX_OUTPUT_VALUE_PRECISION"); val != "" {
+		precision, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_OUTPUT_VALUE_PRECISION: %w", err)
+		}
+		cfg.OutputValuePrecision = precision
+	}
+
+	if val := getenv("BLACKBOX_OUTPUT_INCLUDE_RAW"); val != "" {
+		include, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_OUTPUT_INCLUDE_RAW: %w", err)
+		}
+		cfg.OutputIncludeRaw = include
+	}
+
+	if val := getenv("BLACKBOX_OUTPUT_DELTA_METRICS"); val != "" {
+		cfg.OutputDeltaMetrics = nil
+		for _, pattern := range strings.Split(val, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				cfg.OutputDeltaMetrics = append(cfg.OutputDeltaMetrics, pattern)
+			}
+		}
+	}
+
+	if val := getenv("BLACKBOX_OUTPUT_TEMPLATE_DIR"); val != "" {
+		cfg.OutputTemplateDir = val
+	}
+
+	// Incident handler configuration
+	if val := getenv("BLACKBOX_INCIDENT_HANDLERS"); val != "" {
+		var handlerConfigs []handler.HandlerConfig
+		if err := json.Unmarshal([]byte(val), &handlerConfigs); err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_INCIDENT_HANDLERS JSON: %w", err)
+		}
+		cfg.IncidentHandlers = handlerConfigs
+	}
+
+	// Emitter configuration
+	if val := getenv("BLACKBOX_EMITTERS"); val != "" {
+		var emitterConfigs []emitter.EmitterConfig
+		if err := json.Unmarshal([]byte(val), &emitterConfigs); err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_EMITTERS JSON: %w", err)
+		}
+		cfg.Emitters = emitterConfigs
+	}
+
+	if val := getenv("BLACKBOX_EMITTER_FAILURE_THRESHOLD"); val != "" {
+		threshold, err := strconv.Atoi(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_EMITTER_FAILURE_THRESHOLD: %w", err)
+		}
+		cfg.EmitterFailureThreshold = threshold
+	}
+
+	if val := getenv("BLACKBOX_EMITTER_PROBE_INTERVAL"); val != "" {
+		interval, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_EMITTER_PROBE_INTERVAL: %w", err)
+		}
+		cfg.EmitterProbeInterval = interval
+	}
+
+	// Logging configuration
+	if val := getenv("BLACKBOX_LOG_LEVEL"); val != "" {
+		cfg.LogLevel = val
+	}
+
+	if val := getenv("BLACKBOX_LOG_JSON"); val != "" {
+		json, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_LOG_JSON: %w", err)
+		}
+		cfg.LogJSON = json
+	}
+
+	if val := getenv("BLACKBOX_SELF_INCIDENT_GOROUTINES"); val != "" {
+		dump, err := strconv.ParseBool(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_SELF_INCIDENT_GOROUTINES: %w", err)
+		}
+		cfg.SelfIncidentGoroutines = dump
+	}
+
+	// Shutdown configuration
+	if val := getenv("BLACKBOX_SHUTDOWN_GRACE_PERIOD"); val != "" {
+		duration, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid BLACKBOX_SHUTDOWN_GRACE_PERIOD: %w", err)
+		}
+		cfg.ShutdownGracePeriod = duration
+	}
+
+	if val := getenv("BLACKBOX_SHUTDOWN_ORDER"); val != "" {
+		cfg.ShutdownOrder = nil
+		for _, name := range strings.Split(val, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				cfg.ShutdownOrder = append(cfg.ShutdownOrder, name)
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// validBindAddress reports whether host is an IP address or a hostname, such
+// as "127.0.0.1", "::1" or "localhost", rather than an address with a port.
+func validBindAddress(host string) bool {
+	if net.ParseIP(host) != nil {
+		return true
+	}
+	if len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if r != '-' && (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// parseTags parses comma-separated key=value pairs, e.g. "cluster=prod,region=us-east-1".
+func parseTags(spec string) (map[string]string, error) {
+	tags := make(map[string]string)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(pair, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", pair)
+		}
+		tags[key] = strings.TrimSpace(value)
+	}
+	return tags, nil
+}
+
+// Subsystems returns the subsystems the configured mode runs: agents run
+// everything, aggregators ingest and process incidents without collecting or
+// watching pods, and query instances only serve the read APIs.
+func (c *Config) Subsystems() Subsystems {
+	switch c.Mode {
+	case ModeQuery:
+		return Subsystems{}
+	case ModeAggregator:
+		return Subsystems{Ingest: true, Incidents: true}
+	default:
+		return Subsystems{Collect: true, WatchPods: true, Ingest: true, Incidents: true}
+	}
+}
+
+// Validate checks if the configuration is valid and returns an error if not.
+// This performs comprehensive validation of all configuration parameters to ensure
+// the daemon can start successfully with the provided configuration.
+func (c *Config) Validate() error {
+	switch c.Mode {
+	case "", ModeAgent, ModeQuery, ModeAggregator:
+	default:
+		return fmt.Errorf("mode must be agent, query or aggregator: %q", c.Mode)
+	}
+
+	if c.BufferWindowSize <= 0 {
+		return fmt.Errorf("buffer window size must be positive")
+	}
+
+	if _, err := ringbuffer.ParseOverflowPolicy(c.BufferOverflowPolicy); err != nil {
+		return fmt.Errorf("invalid buffer overflow policy: %w", err)
+	}
+
+	if _, err := filepath.Match(c.BufferImport, ""); err != nil {
+		return fmt.Errorf("buffer import pattern is malformed: %q", c.BufferImport)
+	}
+
+	if c.BufferBlockTimeout < 0 {
+		return fmt.Errorf("buffer block timeout must not be negative")
+	}
+
+	for _, pattern := range c.BufferDedupMetrics {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("buffer dedup pattern is malformed: %q", pattern)
+		}
+	}
+
+	if c.BufferDedupInterval < 0 {
+		return fmt.Errorf("buffer dedup interval must not be negative")
+	}
+
+	if c.BufferSpillMaxBytes < 0 {
+		return fmt.Errorf("buffer spill max bytes must not be negative")
+	}
+
+	for source, reserved := range c.BufferReservations {
+		if source != types.SourceSystem && source != types.SourceSidecar {
+			return fmt.Errorf("buffer reservation source must be system or sidecar, got %q", source)
+		}
+		if reserved <= 0 {
+			return fmt.Errorf("buffer reservation for %s must be positive", source)
+		}
+	}
+
+	for _, rule := range c.BufferPriorityRules {
+		if rule.Priority <= 0 {
+			return fmt.Errorf("buffer priority rule priorities must be positive")
+		}
+	}
+
+	if c.BufferPriorityCapacity < 0 {
+		return fmt.Errorf("buffer priority capacity must not be negative")
+	}
+
+	if c.BufferHistoryInterval < 0 {
+		return fmt.Errorf("buffer history interval must not be negative")
+	}
+
+	if c.BufferHistoryInterval > 0 && (c.BufferHistoryLength < 1 || c.BufferHistoryLength > ringbuffer.MaxHistoryLength) {
+		return fmt.Errorf("buffer history length must be between 1 and %d", ringbuffer.MaxHistoryLength)
+	}
+
+	if c.CollectionInterval <= 0 {
+		return fmt.Errorf("collection interval must be positive")
+	}
+
+	if c.CollectionStartupDelay < 0 {
+		return fmt.Errorf("collection startup delay must not be negative")
+	}
+
+	if c.IncidentSnapshotWindow < 0 {
+		return fmt.Errorf("incident snapshot window must not be negative")
+	}
+
+	if c.EmitOnInterval < 0 {
+		return fmt.Errorf("emit interval must not be negative")
+	}
+
+	if c.IncidentHistoryMax < 0 || c.IncidentHistoryMaxAge < 0 {
+		return fmt.Errorf("incident history limits must not be negative")
+	}
+
+	if _, err := telemetry.ParseProcessMatchers(c.TrackProcesses); err != nil {
+		return fmt.Errorf("invalid tracked processes: %w", err)
+	}
+
+	if c.MaxTrackedProcesses < 0 {
+		return fmt.Errorf("max tracked processes must not be negative")
+	}
+
+	if c.FDUsageThreshold < 0 || c.FDUsageThreshold > 100 {
+		return fmt.Errorf("fd usage threshold must be between 0 and 100")
+	}
+
+	if c.FDUsageThreshold > 0 && !c.CollectFDLimits {
+		return fmt.Errorf("fd usage threshold requires collecting fd limits")
+	}
+
+	if c.ZombieThreshold < 0 {
+		return fmt.Errorf("zombie threshold must not be negative")
+	}
+
+	if c.ZombieThreshold > 0 && !c.CollectZombies {
+		return fmt.Errorf("zombie threshold requires collecting zombies")
+	}
+
+	if _, err := telemetry.ParseDiskThresholds(c.DiskFullThresholds); err != nil {
+		return fmt.Errorf("invalid disk full thresholds: %w", err)
+	}
+
+	if c.MemoryLeakSlope < 0 {
+		return fmt.Errorf("memory leak slope must not be negative")
+	}
+
+	if c.MemoryLeakSlope > 0 && (c.MemoryLeakMetric == "" || c.MemoryLeakWindow <= 0 || c.MemoryLeakSustain < 0) {
+		return fmt.Errorf("memory leak detection requires a metric, a positive window and a non-negative sustain period")
+	}
+
+	if c.LowMemoryUsedPercent < 0 || c.LowMemoryUsedPercent > 100 {
+		return fmt.Errorf("low memory used percent must be between 0 and 100")
+	}
+
+	if c.LowMemorySustain < 0 {
+		return fmt.Errorf("low memory sustain must not be negative")
+	}
+
+	if c.LowMemoryTopProcesses < 0 {
+		return fmt.Errorf("low memory top processes must not be negative")
+	}
+
+	if err := telemetry.ValidateInterfacePatterns(c.NetworkInterfaces); err != nil {
+		return fmt.Errorf("invalid network interfaces: %w", err)
+	}
+
+	if err := telemetry.ValidateInterfacePatterns(c.NetworkInterfacesExclude); err != nil {
+		return fmt.Errorf("invalid network interfaces exclude: %w", err)
+	}
+
+	if c.APIPort <= 0 || c.APIPort > 65535 {
+		return fmt.Errorf("API port must be between 1 and 65535")
+	}
+
+	if c.MetricsPort <= 0 || c.MetricsPort > 65535 {
+		return fmt.Errorf("metrics port must be between 1 and 65535")
+	}
+
+	if c.BindAddress != "" && !validBindAddress(c.BindAddress) {
+		return fmt.Errorf("bind address must be an IP address or hostname without a port")
+	}
+
+	if c.MetricsStaleSeriesMisses < 0 || c.MetricsStaleSeriesWarmup < 0 {
+		return fmt.Errorf("metrics stale series misses and warmup must not be negative")
+	}
+
+	if c.MetricsSidecarSeriesLimit < 0 {
+		return fmt.Errorf("metrics sidecar series limit must not be negative")
+	}
+
+	if c.MetricsLabelValueMaxLength < 0 {
+		return fmt.Errorf("metrics label value max length must not be negative")
+	}
+
+	for _, name := range c.MetricsExportTypes {
+		switch types.TelemetryType(name) {
+		case types.TypeCPU, types.TypeMemory, types.TypeNetwork, types.TypeDisk, types.TypeProcess:
+		default:
+			return fmt.Errorf("metrics export type %q must be cpu, memory, network, disk or process", name)
+		}
+	}
+
+	bridgeLabels := make(map[string]bool, len(c.MetricsBridgeLabels))
+	for _, name := range c.MetricsBridgeLabels {
+		switch name {
+		case "source", "pod", "namespace":
+		default:
+			return fmt.Errorf("metrics bridge label %q must be source, pod or namespace", name)
+		}
+		if bridgeLabels[name] {
+			return fmt.Errorf("metrics bridge label %q is listed twice", name)
+		}
+		bridgeLabels[name] = true
+	}
+
+	if c.MetricsBridgeSeriesLimit < 0 {
+		return fmt.Errorf("metrics bridge series limit must not be negative")
+	}
+
+	if strings.ContainsAny(c.APIBasePath, "?# ") {
+		return fmt.Errorf("API base path must be a plain URL path: %q", c.APIBasePath)
+	}
+
+	if c.GRPCPort < 0 || c.GRPCPort > 65535 {
+		return fmt.Errorf("gRPC port must be between 0 (disabled) and 65535")
+	}
+
+	if c.GRPCQuery && c.GRPCPort == 0 {
+		return fmt.Errorf("gRPC query service requires a gRPC port")
+	}
+
+	if c.APIListenBacklog < 0 {
+		return fmt.Errorf("API listen backlog must not be negative")
+	}
+
+	if c.APIMaxEntriesPerRequest < 0 {
+		return fmt.Errorf("API max entries per request must not be negative")
+	}
+
+	if c.APIEntryLimitAction != "" && c.APIEntryLimitAction != "reject" && c.APIEntryLimitAction != "truncate" {
+		return fmt.Errorf("API entry limit action must be reject or truncate")
+	}
+
+	if c.APIMaxValueBytes < 0 {
+		return fmt.Errorf("API max value bytes must not be negative")
+	}
+
+	if c.APIValueLimitAction != "" && c.APIValueLimitAction != "reject" && c.APIValueLimitAction != "truncate" {
+		return fmt.Errorf("API value limit action must be reject or truncate")
+	}
+
+	switch c.APIClockSkewPolicy {
+	case "", "accept", "reject", "rebase":
+	default:
+		return fmt.Errorf("API clock skew policy must be accept, reject or rebase")
+	}
+
+	if c.APIMaxClockSkew < 0 {
+		return fmt.Errorf("API max clock skew must not be negative")
+	}
+
+	if c.APIKey == "" {
+		return fmt.Errorf("API key is required for sidecar authentication")
+	}
+
+	namespaces := make([]string, 0, len(c.APINamespaceKeys))
+	for namespace := range c.APINamespaceKeys {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	keyNamespaces := make(map[string]string, len(namespaces))
+	for _, namespace := range namespaces {
+		key := c.APINamespaceKeys[namespace]
+		if namespace == "" || key == "" {
+			return fmt.Errorf("namespace API keys need a namespace and a key: %q", namespace)
+		}
+		if key == c.APIKey {
+			return fmt.Errorf("API key for namespace %q must differ from the API key", namespace)
+		}
+		if other, ok := keyNamespaces[key]; ok {
+			return fmt.Errorf("namespaces %q and %q share an API key", other, namespace)
+		}
+		keyNamespaces[key] = namespace
+	}
+
+	if len(c.OutputFormatters) == 0 {
+		return fmt.Errorf("at least one output formatter must be specified")
+	}
+
+	if c.OutputTimestampFormat != "" {
+		if err := formatter.ValidateTimestampLayout(c.OutputTimestampFormat); err != nil {
+			return fmt.Errorf("invalid output timestamp format: %w", err)
+		}
+	}
+
+	if c.OutputTimezone != "" {
+		if _, err := time.LoadLocation(c.OutputTimezone); err != nil {
+			return fmt.Errorf("invalid output timezone: %w", err)
+		}
+	}
+
+	if c.OutputValuePrecision < 0 {
+		return fmt.Errorf("output value precision must not be negative")
+	}
+
+	for _, pattern := range c.OutputDeltaMetrics {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("output delta pattern is malformed: %q", pattern)
+		}
+	}
+
+	for _, formatter := range c.OutputFormatters {
+		if strings.HasPrefix(formatter, "template:") && c.OutputTemplateDir == "" {
+			return fmt.Errorf("formatter %s requires an output template directory", formatter)
+		}
+	}
+
+	validLogLevels := map[string]bool{
+		"debug": true,
+		"info":  true,
+		"warn":  true,
+		"error": true,
+	}
+	if !validLogLevels[c.LogLevel] {
+		return fmt.Errorf("invalid log level: %s", c.LogLevel)
 	}
 
 	// Validate emitter configurations
@@ -239,15 +1626,93 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("at least one emitter must be configured")
 	}
 	
+	if c.EmitterFailureThreshold < 0 {
+		return fmt.Errorf("emitter failure threshold cannot be negative")
+	}
+
+	if c.EmitterProbeInterval < 0 {
+		return fmt.Errorf("emitter probe interval cannot be negative")
+	}
+
 	for i, emitterConfig := range c.Emitters {
 		if emitterConfig.Type == "" {
 			return fmt.Errorf("emitter %d: type is required", i)
 		}
+		if critical, ok := emitterConfig.Config["critical"]; ok {
+			if _, isBool := critical.(bool); !isBool {
+				return fmt.Errorf("emitter %d (%s): critical must be a boolean", i, emitterConfig.Type)
+			}
+		}
 		// Validate that we can create the emitter (tests registry availability)
-		if _, err := emitter.CreateEmitter(emitterConfig); err != nil {
+		if _, err := emitter.NewFromConfig(emitterConfig); err != nil {
 			return fmt.Errorf("emitter %d (%s): %w", i, emitterConfig.Type, err)
 		}
 	}
 
+	// Validate incident handler configurations; handlers are constructed once
+	// their dependencies exist, so only check that each type is registered
+	for i, handlerConfig := range c.IncidentHandlers {
+		if !handler.Registered(handlerConfig.Type) {
+			return fmt.Errorf("incident handler %d: unknown type %q (registered: %s)", i, handlerConfig.Type, strings.Join(handler.RegisteredHandlers(), ", "))
+		}
+	}
+
+	if _, err := telemetry.NewTransformer(c.TelemetryTransforms); err != nil {
+		return fmt.Errorf("invalid telemetry transforms: %w", err)
+	}
+
+	if _, err := telemetry.NewTypeRegistry(c.TelemetryTypes); err != nil {
+		return fmt.Errorf("invalid telemetry types: %w", err)
+	}
+
+	if c.WatcherAuthRebuildThreshold < 0 {
+		return fmt.Errorf("watcher auth rebuild threshold must not be negative")
+	}
+
+	if c.WatcherAuthBackoffMax < 0 {
+		return fmt.Errorf("watcher auth backoff max must not be negative")
+	}
+
+	if c.NodeConditionsTTL < 0 {
+		return fmt.Errorf("node conditions TTL must not be negative")
+	}
+
+	if c.CRISocket != "" && !strings.HasPrefix(c.CRISocket, "/") && !strings.HasPrefix(c.CRISocket, "unix://") {
+		return fmt.Errorf("CRI socket must be an absolute path or a unix:// URL")
+	}
+
+	if c.CrashLogLines < 0 || c.CrashLogLines > 1000 {
+		return fmt.Errorf("crash log lines must be between 0 and 1000")
+	}
+
+	switch c.CrashLogParse {
+	case "", "raw", "json", "auto":
+	default:
+		return fmt.Errorf("crash log parse mode must be raw, json or auto")
+	}
+
+	if c.CrashLogConcurrency < 0 || c.CrashLogConcurrency > 64 {
+		return fmt.Errorf("crash log concurrency must be between 0 and 64")
+	}
+
+	if c.WatchConfigMap && c.ConfigMapName == "" {
+		return fmt.Errorf("watching a ConfigMap requires its name")
+	}
+
+	if c.ShutdownGracePeriod < 0 {
+		return fmt.Errorf("shutdown grace period must not be negative")
+	}
+
+	stages := make(map[string]bool, len(c.ShutdownOrder))
+	for _, name := range c.ShutdownOrder {
+		if !shutdown.IsStage(name) {
+			return fmt.Errorf("unknown shutdown stage %q (expected %s)", name, strings.Join(shutdown.DefaultOrder, ", "))
+		}
+		if stages[name] {
+			return fmt.Errorf("shutdown stage %q is listed twice", name)
+		}
+		stages[name] = true
+	}
+
 	return nil
 }
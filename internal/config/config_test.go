@@ -9,7 +9,10 @@ import (
 	"testing"
 	"time"
 
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
 	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/emitter"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/handler"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
 )
 
 // defaultTestEmitters returns the default emitter configuration for tests.
@@ -34,12 +37,16 @@ func TestLoadConfig(t *testing.T) {
 		"BLACKBOX_API_KEY",
 		"BLACKBOX_BUFFER_WINDOW_SIZE",
 		"BLACKBOX_COLLECTION_INTERVAL",
+		"BLACKBOX_BUFFER_BLOCK_TIMEOUT",
+		"BLACKBOX_BUFFER_INTERN",
+		"BLACKBOX_BUFFER_IMPORT",
 		"BLACKBOX_API_PORT",
 		"BLACKBOX_METRICS_PORT",
 		"BLACKBOX_OUTPUT_FORMATTERS",
 		"BLACKBOX_OUTPUT_PATH",
 		"BLACKBOX_LOG_LEVEL",
 		"BLACKBOX_SWAGGER_ENABLE",
+		"BLACKBOX_PPROF_ENABLE",
 		"BLACKBOX_LOG_JSON",
 		"NODE_NAME",
 		"POD_NAMESPACE",
@@ -150,12 +157,16 @@ func TestLoadConfig(t *testing.T) {
 		os.Setenv("BLACKBOX_API_KEY", "custom-key")
 		os.Setenv("BLACKBOX_BUFFER_WINDOW_SIZE", "5m")
 		os.Setenv("BLACKBOX_COLLECTION_INTERVAL", "10s")
+		os.Setenv("BLACKBOX_BUFFER_BLOCK_TIMEOUT", "2s")
+		os.Setenv("BLACKBOX_BUFFER_INTERN", "true")
+		os.Setenv("BLACKBOX_BUFFER_IMPORT", "/var/lib/blackbox/backfill/*.ndjson.gz")
 		os.Setenv("BLACKBOX_API_PORT", "9080")
 		os.Setenv("BLACKBOX_METRICS_PORT", "9091")
 		os.Setenv("BLACKBOX_OUTPUT_FORMATTERS", "json,csv")
 		os.Setenv("BLACKBOX_OUTPUT_PATH", "/tmp/logs")
 		os.Setenv("BLACKBOX_LOG_LEVEL", "debug")
 		os.Setenv("BLACKBOX_SWAGGER_ENABLE", "true")
+		os.Setenv("BLACKBOX_PPROF_ENABLE", "true")
 		os.Setenv("BLACKBOX_LOG_JSON", "false")
 		os.Setenv("NODE_NAME", "test-node")
 		os.Setenv("POD_NAMESPACE", "test-namespace")
@@ -172,68 +183,1422 @@ func TestLoadConfig(t *testing.T) {
 		if config.CollectionInterval != 10*time.Second {
 			t.Errorf("Expected CollectionInterval 10s, got %v", config.CollectionInterval)
 		}
+		if config.BufferBlockTimeout != 2*time.Second {
+			t.Errorf("Expected BufferBlockTimeout 2s, got %v", config.BufferBlockTimeout)
+		}
+		if !config.BufferIntern {
+			t.Error("Expected BufferIntern true")
+		}
+		if config.BufferImport != "/var/lib/blackbox/backfill/*.ndjson.gz" {
+			t.Errorf("Expected BufferImport glob, got %q", config.BufferImport)
+		}
 		if config.APIPort != 9080 {
 			t.Errorf("Expected APIPort 9080, got %v", config.APIPort)
 		}
-		if config.MetricsPort != 9091 {
-			t.Errorf("Expected MetricsPort 9091, got %v", config.MetricsPort)
+		if config.MetricsPort != 9091 {
+			t.Errorf("Expected MetricsPort 9091, got %v", config.MetricsPort)
+		}
+		if len(config.OutputFormatters) != 2 || config.OutputFormatters[0] != "json" || config.OutputFormatters[1] != "csv" {
+			t.Errorf("Expected OutputFormatters ['json','csv'], got %v", config.OutputFormatters)
+		}
+		if config.OutputPath != "/tmp/logs" {
+			t.Errorf("Expected OutputPath '/tmp/logs', got %v", config.OutputPath)
+		}
+		if config.LogLevel != "debug" {
+			t.Errorf("Expected LogLevel 'debug', got %v", config.LogLevel)
+		}
+		if config.SwaggerEnable != true {
+			t.Errorf("Expected SwaggerEnable true, got %v", config.SwaggerEnable)
+		}
+		if config.EnablePprof != true {
+			t.Errorf("Expected EnablePprof true, got %v", config.EnablePprof)
+		}
+		if config.LogJSON != false {
+			t.Errorf("Expected LogJSON false, got %v", config.LogJSON)
+		}
+		if config.NodeName != "test-node" {
+			t.Errorf("Expected NodeName 'test-node', got %v", config.NodeName)
+		}
+		if config.PodNamespace != "test-namespace" {
+			t.Errorf("Expected PodNamespace 'test-namespace', got %v", config.PodNamespace)
+		}
+	})
+
+	t.Run("handles invalid duration formats", func(t *testing.T) {
+		os.Setenv("BLACKBOX_API_KEY", "test-key")
+		os.Setenv("BLACKBOX_BUFFER_WINDOW_SIZE", "invalid")
+		
+		_, err := LoadFromEnv()
+		
+		if err == nil {
+			t.Fatal("Expected error for invalid duration")
+		}
+		if !strings.Contains(err.Error(), "invalid BLACKBOX_BUFFER_WINDOW_SIZE") {
+			t.Errorf("Expected duration parsing error, got %v", err)
+		}
+	})
+
+	t.Run("handles invalid port numbers", func(t *testing.T) {
+		os.Setenv("BLACKBOX_API_KEY", "test-key")
+		os.Setenv("BLACKBOX_API_PORT", "invalid")
+		
+		_, err := LoadFromEnv()
+		
+		if err == nil {
+			t.Fatal("Expected error for invalid port")
+		}
+	})
+
+	t.Run("handles invalid boolean values", func(t *testing.T) {
+		os.Setenv("BLACKBOX_API_KEY", "test-key")
+		os.Setenv("BLACKBOX_SWAGGER_ENABLE", "invalid")
+		
+		_, err := LoadFromEnv()
+		
+		if err == nil {
+			t.Fatal("Expected error for invalid boolean")
+		}
+	})
+}
+
+// TestStrictRuntimes validates loading the strict runtime settings.
+func TestStrictRuntimes(t *testing.T) {
+	t.Run("permissive by default", func(t *testing.T) {
+		if cfg := DefaultConfig(); cfg.APIStrictRuntimes || len(cfg.APIExtraRuntimes) != 0 {
+			t.Errorf("Expected any runtime accepted, got %v and %v", cfg.APIStrictRuntimes, cfg.APIExtraRuntimes)
+		}
+	})
+
+	t.Run("parses strict mode and additional runtimes", func(t *testing.T) {
+		t.Setenv("BLACKBOX_API_STRICT_RUNTIMES", "true")
+		t.Setenv("BLACKBOX_API_EXTRA_RUNTIMES", "ruby,rust")
+
+		cfg, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !cfg.APIStrictRuntimes || len(cfg.APIExtraRuntimes) != 2 || cfg.APIExtraRuntimes[1] != "rust" {
+			t.Errorf("Expected strict runtimes with ruby and rust, got %v and %v", cfg.APIStrictRuntimes, cfg.APIExtraRuntimes)
+		}
+	})
+
+	t.Run("rejects invalid strict flag", func(t *testing.T) {
+		t.Setenv("BLACKBOX_API_STRICT_RUNTIMES", "sometimes")
+
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "BLACKBOX_API_STRICT_RUNTIMES") {
+			t.Errorf("Expected strict runtimes parse error, got %v", err)
+		}
+	})
+}
+
+// TestMetricsExportTypes validates loading and validating the exported telemetry types.
+func TestMetricsExportTypes(t *testing.T) {
+	t.Run("exports every type by default", func(t *testing.T) {
+		if cfg := DefaultConfig(); len(cfg.MetricsExportTypes) != 0 {
+			t.Errorf("Expected no type selection, got %v", cfg.MetricsExportTypes)
+		}
+	})
+
+	t.Run("parses the selected types", func(t *testing.T) {
+		t.Setenv("BLACKBOX_METRICS_EXPORT_TYPES", "cpu, memory")
+
+		cfg, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(cfg.MetricsExportTypes) != 2 || cfg.MetricsExportTypes[0] != "cpu" || cfg.MetricsExportTypes[1] != "memory" {
+			t.Errorf("Expected cpu and memory, got %v", cfg.MetricsExportTypes)
+		}
+	})
+
+	t.Run("rejects unknown types", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.APIKey = "valid-key"
+		cfg.MetricsExportTypes = []string{"cpu", "gpu"}
+
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), `"gpu"`) {
+			t.Errorf("Expected unknown export type error, got %v", err)
+		}
+	})
+}
+
+// TestMode validates loading the daemon mode and the subsystems each mode runs.
+func TestMode(t *testing.T) {
+	t.Run("defaults to agent", func(t *testing.T) {
+		cfg := DefaultConfig()
+		if cfg.Mode != ModeAgent {
+			t.Errorf("Expected agent mode, got %q", cfg.Mode)
+		}
+	})
+
+	t.Run("parses the mode", func(t *testing.T) {
+		t.Setenv("BLACKBOX_MODE", " Query ")
+
+		cfg, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.Mode != ModeQuery {
+			t.Errorf("Expected query mode, got %q", cfg.Mode)
+		}
+	})
+
+	t.Run("selects subsystems per mode", func(t *testing.T) {
+		tests := []struct {
+			mode     string
+			expected Subsystems
+		}{
+			{ModeAgent, Subsystems{Collect: true, WatchPods: true, Ingest: true, Incidents: true}},
+			{"", Subsystems{Collect: true, WatchPods: true, Ingest: true, Incidents: true}},
+			{ModeAggregator, Subsystems{Ingest: true, Incidents: true}},
+			{ModeQuery, Subsystems{}},
+		}
+		for _, tt := range tests {
+			cfg := DefaultConfig()
+			cfg.Mode = tt.mode
+			if got := cfg.Subsystems(); got != tt.expected {
+				t.Errorf("Expected %+v for mode %q, got %+v", tt.expected, tt.mode, got)
+			}
+		}
+	})
+
+	t.Run("rejects unknown modes", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.APIKey = "valid-key"
+		cfg.Mode = "replica"
+
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), `"replica"`) {
+			t.Errorf("Expected mode error, got %v", err)
+		}
+	})
+}
+
+// TestTelemetryTypes validates loading and validating custom telemetry types.
+func TestTelemetryTypes(t *testing.T) {
+	t.Run("parses type definitions", func(t *testing.T) {
+		t.Setenv("BLACKBOX_TELEMETRY_TYPES", `[{"name":"queue","keywords":["queue_depth","backlog"]}]`)
+
+		cfg, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(cfg.TelemetryTypes) != 1 || cfg.TelemetryTypes[0].Name != "queue" || len(cfg.TelemetryTypes[0].Keywords) != 2 {
+			t.Errorf("Expected the queue type, got %+v", cfg.TelemetryTypes)
+		}
+	})
+
+	t.Run("rejects types shadowing built-in ones", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.APIKey = "valid-key"
+		cfg.TelemetryTypes = []telemetry.TypeDefinition{{Name: "cpu"}}
+
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "invalid telemetry types") {
+			t.Errorf("Expected telemetry types error, got %v", err)
+		}
+	})
+}
+
+// TestEmitterHealth validates loading and validating the critical emitter health settings.
+func TestEmitterHealth(t *testing.T) {
+	t.Run("parses the threshold and probe interval", func(t *testing.T) {
+		t.Setenv("BLACKBOX_EMITTER_FAILURE_THRESHOLD", "5")
+		t.Setenv("BLACKBOX_EMITTER_PROBE_INTERVAL", "10s")
+
+		cfg, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if cfg.EmitterFailureThreshold != 5 {
+			t.Errorf("Expected threshold 5, got %d", cfg.EmitterFailureThreshold)
+		}
+		if cfg.EmitterProbeInterval != 10*time.Second {
+			t.Errorf("Expected probe interval 10s, got %v", cfg.EmitterProbeInterval)
+		}
+	})
+
+	t.Run("rejects a negative threshold", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.APIKey = "valid-key"
+		cfg.EmitterFailureThreshold = -1
+
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "failure threshold") {
+			t.Errorf("Expected failure threshold error, got %v", err)
+		}
+	})
+
+	t.Run("rejects a non-boolean critical flag", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.APIKey = "valid-key"
+		cfg.Emitters[0].Config["critical"] = "yes"
+
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "critical must be a boolean") {
+			t.Errorf("Expected critical flag error, got %v", err)
+		}
+	})
+}
+
+// TestDefaultTags validates parsing default telemetry tags from the environment.
+func TestDefaultTags(t *testing.T) {
+	t.Run("parses key value pairs", func(t *testing.T) {
+		t.Setenv("BLACKBOX_DEFAULT_TAGS", "cluster=prod-east, region = us-east-1,environment=production")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		expected := map[string]string{"cluster": "prod-east", "region": "us-east-1", "environment": "production"}
+		if len(config.DefaultTags) != len(expected) {
+			t.Fatalf("Expected %d tags, got %v", len(expected), config.DefaultTags)
+		}
+		for key, value := range expected {
+			if config.DefaultTags[key] != value {
+				t.Errorf("Expected tag %s=%s, got %q", key, value, config.DefaultTags[key])
+			}
+		}
+	})
+
+	t.Run("rejects malformed pairs", func(t *testing.T) {
+		t.Setenv("BLACKBOX_DEFAULT_TAGS", "cluster=prod,region")
+
+		_, err := LoadFromEnv()
+		if err == nil || !strings.Contains(err.Error(), "invalid BLACKBOX_DEFAULT_TAGS") {
+			t.Errorf("Expected default tags error, got %v", err)
+		}
+	})
+}
+
+// TestTelemetryTransforms validates parsing and validating telemetry transform rules.
+func TestTelemetryTransforms(t *testing.T) {
+	t.Run("parses rules from JSON", func(t *testing.T) {
+		t.Setenv("BLACKBOX_API_KEY", "valid-key")
+		t.Setenv("BLACKBOX_TELEMETRY_TRANSFORMS", `[{"action":"rename","match":"jvm_(.*)","replacement":"java_$1"},{"action":"scale","match":".*_kb","factor":1024}]`)
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(config.TelemetryTransforms) != 2 || config.TelemetryTransforms[0].Replacement != "java_$1" || config.TelemetryTransforms[1].Factor != 1024 {
+			t.Errorf("Expected rename and scale rules, got %+v", config.TelemetryTransforms)
+		}
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected valid rules, got %v", err)
+		}
+	})
+
+	t.Run("rejects malformed JSON", func(t *testing.T) {
+		t.Setenv("BLACKBOX_TELEMETRY_TRANSFORMS", `{"action":"rename"}`)
+
+		_, err := LoadFromEnv()
+		if err == nil || !strings.Contains(err.Error(), "invalid BLACKBOX_TELEMETRY_TRANSFORMS") {
+			t.Errorf("Expected invalid BLACKBOX_TELEMETRY_TRANSFORMS error, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid rules", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.TelemetryTransforms = []telemetry.TransformRule{{Action: "scale"}}
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "invalid telemetry transforms") {
+			t.Errorf("Expected telemetry transforms error, got %v", err)
+		}
+	})
+}
+
+// TestCPUIOWaitBusy validates parsing whether iowait counts as busy CPU time.
+func TestCPUIOWaitBusy(t *testing.T) {
+	t.Run("counts iowait as idle", func(t *testing.T) {
+		t.Setenv("BLACKBOX_CPU_IOWAIT_BUSY", "false")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.CPUIOWaitBusy {
+			t.Error("Expected CPUIOWaitBusy false")
+		}
+	})
+
+	t.Run("rejects invalid value", func(t *testing.T) {
+		t.Setenv("BLACKBOX_CPU_IOWAIT_BUSY", "sometimes")
+
+		_, err := LoadFromEnv()
+		if err == nil || !strings.Contains(err.Error(), "invalid BLACKBOX_CPU_IOWAIT_BUSY") {
+			t.Errorf("Expected invalid BLACKBOX_CPU_IOWAIT_BUSY error, got %v", err)
+		}
+	})
+}
+
+// TestNetworkInterfaces validates parsing network interface patterns from the environment.
+func TestNetworkInterfaces(t *testing.T) {
+	t.Run("parses include and exclude patterns", func(t *testing.T) {
+		t.Setenv("BLACKBOX_NETWORK_INTERFACES", "eth*, ens*")
+		t.Setenv("BLACKBOX_NETWORK_INTERFACES_EXCLUDE", "lo")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(config.NetworkInterfaces) != 2 || config.NetworkInterfaces[1] != "ens*" {
+			t.Errorf("Expected [eth* ens*], got %v", config.NetworkInterfaces)
+		}
+		if len(config.NetworkInterfacesExclude) != 1 || config.NetworkInterfacesExclude[0] != "lo" {
+			t.Errorf("Expected exclusions replaced by [lo], got %v", config.NetworkInterfacesExclude)
+		}
+	})
+
+	t.Run("rejects malformed patterns", func(t *testing.T) {
+		t.Setenv("BLACKBOX_NETWORK_INTERFACES_EXCLUDE", "veth[")
+
+		_, err := LoadFromEnv()
+		if err == nil || !strings.Contains(err.Error(), "invalid BLACKBOX_NETWORK_INTERFACES_EXCLUDE") {
+			t.Errorf("Expected network interfaces exclude error, got %v", err)
+		}
+	})
+}
+
+// TestBufferDedup validates parsing the deduplicated metric patterns and interval.
+func TestBufferDedup(t *testing.T) {
+	t.Run("parses patterns and interval", func(t *testing.T) {
+		t.Setenv("BLACKBOX_BUFFER_DEDUP_METRICS", "memory_total_bytes, *_limit_bytes")
+		t.Setenv("BLACKBOX_BUFFER_DEDUP_INTERVAL", "5m")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(config.BufferDedupMetrics) != 2 || config.BufferDedupMetrics[1] != "*_limit_bytes" {
+			t.Errorf("Expected [memory_total_bytes *_limit_bytes], got %v", config.BufferDedupMetrics)
+		}
+		if config.BufferDedupInterval != 5*time.Minute {
+			t.Errorf("Expected BufferDedupInterval 5m, got %v", config.BufferDedupInterval)
+		}
+	})
+
+	t.Run("defaults to disabled", func(t *testing.T) {
+		config := DefaultConfig()
+		if len(config.BufferDedupMetrics) != 0 {
+			t.Errorf("Expected no deduplicated metrics, got %v", config.BufferDedupMetrics)
+		}
+		if config.BufferDedupInterval != time.Minute {
+			t.Errorf("Expected BufferDedupInterval 1m, got %v", config.BufferDedupInterval)
+		}
+	})
+
+	t.Run("rejects malformed patterns", func(t *testing.T) {
+		t.Setenv("BLACKBOX_BUFFER_DEDUP_METRICS", "memory_[")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "buffer dedup pattern is malformed") {
+			t.Errorf("Expected dedup pattern error, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid interval", func(t *testing.T) {
+		t.Setenv("BLACKBOX_BUFFER_DEDUP_INTERVAL", "-1m")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "buffer dedup interval must not be negative") {
+			t.Errorf("Expected dedup interval error, got %v", err)
+		}
+	})
+}
+
+// TestBufferSpill validates parsing the disk tier settings.
+func TestBufferSpill(t *testing.T) {
+	t.Run("parses directory and limit", func(t *testing.T) {
+		t.Setenv("BLACKBOX_BUFFER_SPILL_DIR", "/var/lib/blackbox/spill")
+		t.Setenv("BLACKBOX_BUFFER_SPILL_MAX_BYTES", "1073741824")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.BufferSpillDir != "/var/lib/blackbox/spill" {
+			t.Errorf("Expected BufferSpillDir, got %q", config.BufferSpillDir)
+		}
+		if config.BufferSpillMaxBytes != 1<<30 {
+			t.Errorf("Expected BufferSpillMaxBytes 1GiB, got %d", config.BufferSpillMaxBytes)
+		}
+	})
+
+	t.Run("defaults to disabled", func(t *testing.T) {
+		config := DefaultConfig()
+		if config.BufferSpillDir != "" {
+			t.Errorf("Expected no spill directory, got %q", config.BufferSpillDir)
+		}
+		if config.BufferSpillMaxBytes != 256<<20 {
+			t.Errorf("Expected BufferSpillMaxBytes 256MiB, got %d", config.BufferSpillMaxBytes)
+		}
+	})
+
+	t.Run("rejects invalid limits", func(t *testing.T) {
+		t.Setenv("BLACKBOX_BUFFER_SPILL_MAX_BYTES", "1GB")
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "invalid BLACKBOX_BUFFER_SPILL_MAX_BYTES") {
+			t.Errorf("Expected spill max bytes error, got %v", err)
+		}
+
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.BufferSpillMaxBytes = -1
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "buffer spill max bytes must not be negative") {
+			t.Errorf("Expected negative limit error, got %v", err)
+		}
+	})
+}
+
+// TestBufferReservations validates per-source reserved buffer capacity.
+func TestBufferReservations(t *testing.T) {
+	t.Run("parses reservations", func(t *testing.T) {
+		t.Setenv("BLACKBOX_BUFFER_RESERVATIONS", "system=5000, sidecar=2000")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.BufferReservations[types.SourceSystem] != 5000 || config.BufferReservations[types.SourceSidecar] != 2000 {
+			t.Errorf("Expected system 5000 and sidecar 2000, got %v", config.BufferReservations)
+		}
+	})
+
+	t.Run("defaults to none", func(t *testing.T) {
+		if config := DefaultConfig(); len(config.BufferReservations) != 0 {
+			t.Errorf("Expected no reservations, got %v", config.BufferReservations)
+		}
+	})
+
+	t.Run("rejects invalid reservations", func(t *testing.T) {
+		t.Setenv("BLACKBOX_BUFFER_RESERVATIONS", "sidecar=lots")
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "invalid BLACKBOX_BUFFER_RESERVATIONS") {
+			t.Errorf("Expected reservations error, got %v", err)
+		}
+
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.BufferReservations = map[types.TelemetrySource]int{"kernel": 100}
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "must be system or sidecar") {
+			t.Errorf("Expected unknown source error, got %v", err)
+		}
+
+		config.BufferReservations = map[types.TelemetrySource]int{types.SourceSidecar: 0}
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "must be positive") {
+			t.Errorf("Expected non-positive reservation error, got %v", err)
+		}
+	})
+}
+
+// TestBufferHistory validates configuring the buffer statistics history.
+func TestBufferHistory(t *testing.T) {
+	t.Run("samples every 10s by default", func(t *testing.T) {
+		config := DefaultConfig()
+		if config.BufferHistoryInterval != 10*time.Second || config.BufferHistoryLength != 360 {
+			t.Errorf("Expected 10s and 360 samples, got %v and %d", config.BufferHistoryInterval, config.BufferHistoryLength)
+		}
+	})
+
+	t.Run("parses interval and length", func(t *testing.T) {
+		t.Setenv("BLACKBOX_BUFFER_HISTORY_INTERVAL", "1m")
+		t.Setenv("BLACKBOX_BUFFER_HISTORY_LENGTH", "1440")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.BufferHistoryInterval != time.Minute || config.BufferHistoryLength != 1440 {
+			t.Errorf("Expected 1m and 1440 samples, got %v and %d", config.BufferHistoryInterval, config.BufferHistoryLength)
+		}
+	})
+
+	t.Run("rejects an unbounded length", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.BufferHistoryLength = 1000000
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "buffer history length must be between 1 and 100000") {
+			t.Errorf("Expected length error, got %v", err)
+		}
+
+		config.BufferHistoryInterval = 0
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected length ignored while disabled, got %v", err)
+		}
+	})
+
+	t.Run("rejects a negative interval", func(t *testing.T) {
+		t.Setenv("BLACKBOX_BUFFER_HISTORY_INTERVAL", "-1s")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		config.APIKey = "valid-key"
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "buffer history interval must not be negative") {
+			t.Errorf("Expected negative interval error, got %v", err)
+		}
+	})
+}
+
+// TestBufferPriority validates configuring priority retention.
+func TestBufferPriority(t *testing.T) {
+	t.Run("parses rules and capacity", func(t *testing.T) {
+		t.Setenv("BLACKBOX_BUFFER_PRIORITY_RULES", "*_errors_total=10,type:process=5")
+		t.Setenv("BLACKBOX_BUFFER_PRIORITY_CAPACITY", "5000")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(config.BufferPriorityRules) != 2 || config.BufferPriorityRules[1].Type != types.TypeProcess {
+			t.Errorf("Expected a name and a type rule, got %+v", config.BufferPriorityRules)
+		}
+		if config.BufferPriorityCapacity != 5000 {
+			t.Errorf("Expected BufferPriorityCapacity 5000, got %d", config.BufferPriorityCapacity)
+		}
+	})
+
+	t.Run("defaults to disabled", func(t *testing.T) {
+		config := DefaultConfig()
+		if len(config.BufferPriorityRules) != 0 {
+			t.Errorf("Expected no priority rules, got %+v", config.BufferPriorityRules)
+		}
+		if config.BufferPriorityCapacity != 1000 {
+			t.Errorf("Expected BufferPriorityCapacity 1000, got %d", config.BufferPriorityCapacity)
+		}
+	})
+
+	t.Run("rejects invalid settings", func(t *testing.T) {
+		t.Setenv("BLACKBOX_BUFFER_PRIORITY_RULES", "*_errors_total=high")
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "invalid BLACKBOX_BUFFER_PRIORITY_RULES") {
+			t.Errorf("Expected priority rules error, got %v", err)
+		}
+
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.BufferPriorityCapacity = -1
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "buffer priority capacity must not be negative") {
+			t.Errorf("Expected negative capacity error, got %v", err)
+		}
+	})
+}
+
+// TestBindFailures validates the flags deciding whether bind failures are fatal.
+func TestBindFailures(t *testing.T) {
+	t.Run("defaults to optional metrics and a required API", func(t *testing.T) {
+		config := DefaultConfig()
+		if config.MetricsRequired || config.APIOptional {
+			t.Errorf("Expected optional metrics and a required API, got %v and %v", config.MetricsRequired, config.APIOptional)
+		}
+	})
+
+	t.Run("parses both flags", func(t *testing.T) {
+		t.Setenv("BLACKBOX_METRICS_REQUIRED", "true")
+		t.Setenv("BLACKBOX_API_OPTIONAL", "true")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !config.MetricsRequired || !config.APIOptional {
+			t.Errorf("Expected required metrics and an optional API, got %v and %v", config.MetricsRequired, config.APIOptional)
+		}
+	})
+
+	t.Run("rejects invalid flags", func(t *testing.T) {
+		t.Setenv("BLACKBOX_METRICS_REQUIRED", "sometimes")
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "invalid BLACKBOX_METRICS_REQUIRED") {
+			t.Errorf("Expected metrics required error, got %v", err)
+		}
+	})
+}
+
+// TestNamespaceKeys validates parsing and validating namespace-scoped API keys.
+func TestNamespaceKeys(t *testing.T) {
+	t.Run("parses namespace keys", func(t *testing.T) {
+		t.Setenv("BLACKBOX_API_KEY", "cluster-key")
+		t.Setenv("BLACKBOX_API_NAMESPACE_KEYS", "ns-a=key-a, ns-b=key-b==")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.APINamespaceKeys["ns-a"] != "key-a" || config.APINamespaceKeys["ns-b"] != "key-b==" {
+			t.Errorf("Expected keys for ns-a and ns-b, got %v", config.APINamespaceKeys)
+		}
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected valid namespace keys, got %v", err)
+		}
+	})
+
+	t.Run("rejects malformed pairs", func(t *testing.T) {
+		t.Setenv("BLACKBOX_API_NAMESPACE_KEYS", "key-a")
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "invalid BLACKBOX_API_NAMESPACE_KEYS") {
+			t.Errorf("Expected namespace keys error, got %v", err)
+		}
+	})
+
+	t.Run("rejects unusable keys", func(t *testing.T) {
+		for keys, expected := range map[string]string{
+			"ns-a=":                   "need a namespace and a key",
+			"ns-a=cluster-key":        "must differ from the API key",
+			"ns-a=shared,ns-b=shared": "share an API key",
+		} {
+			t.Setenv("BLACKBOX_API_KEY", "cluster-key")
+			t.Setenv("BLACKBOX_API_NAMESPACE_KEYS", keys)
+			config, err := LoadFromEnv()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := config.Validate(); err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("Expected error containing %q for %q, got %v", expected, keys, err)
+			}
+		}
+	})
+}
+
+// TestShutdown validates the shutdown grace period and stage order.
+func TestShutdown(t *testing.T) {
+	t.Run("defaults to the default order", func(t *testing.T) {
+		config := DefaultConfig()
+		if config.ShutdownGracePeriod != 25*time.Second {
+			t.Errorf("Expected a 25s grace period, got %v", config.ShutdownGracePeriod)
+		}
+		if strings.Join(config.ShutdownOrder, ",") != "api,collectors,watcher,snapshot,emitters,stores,metrics" {
+			t.Errorf("Expected the default order, got %v", config.ShutdownOrder)
+		}
+	})
+
+	t.Run("parses grace period and order", func(t *testing.T) {
+		t.Setenv("BLACKBOX_SHUTDOWN_GRACE_PERIOD", "50s")
+		t.Setenv("BLACKBOX_SHUTDOWN_ORDER", "watcher, api,snapshot,emitters,metrics")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.ShutdownGracePeriod != 50*time.Second {
+			t.Errorf("Expected a 50s grace period, got %v", config.ShutdownGracePeriod)
+		}
+		if len(config.ShutdownOrder) != 5 || config.ShutdownOrder[0] != "watcher" || config.ShutdownOrder[1] != "api" {
+			t.Errorf("Expected the watcher stopped first, got %v", config.ShutdownOrder)
+		}
+	})
+
+	t.Run("rejects unknown and repeated stages", func(t *testing.T) {
+		for order, expected := range map[string]string{
+			"api,database": "unknown shutdown stage",
+			"api,api":      "listed twice",
+		} {
+			t.Setenv("BLACKBOX_API_KEY", "test-key")
+			t.Setenv("BLACKBOX_SHUTDOWN_ORDER", order)
+			config, err := LoadFromEnv()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := config.Validate(); err == nil || !strings.Contains(err.Error(), expected) {
+				t.Errorf("Expected error containing %q for %q, got %v", expected, order, err)
+			}
+		}
+	})
+}
+
+// TestOutputDeltaMetrics validates configuring counters emitted as deltas.
+func TestOutputDeltaMetrics(t *testing.T) {
+	t.Run("emits absolute values by default", func(t *testing.T) {
+		if config := DefaultConfig(); len(config.OutputDeltaMetrics) != 0 {
+			t.Errorf("Expected no delta metrics, got %v", config.OutputDeltaMetrics)
+		}
+	})
+
+	t.Run("parses patterns", func(t *testing.T) {
+		t.Setenv("BLACKBOX_OUTPUT_DELTA_METRICS", "*_total, network_*_bytes,")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(config.OutputDeltaMetrics) != 2 || config.OutputDeltaMetrics[1] != "network_*_bytes" {
+			t.Errorf("Expected 2 trimmed patterns, got %v", config.OutputDeltaMetrics)
+		}
+	})
+
+	t.Run("rejects malformed patterns", func(t *testing.T) {
+		t.Setenv("BLACKBOX_API_KEY", "test-key")
+		t.Setenv("BLACKBOX_OUTPUT_DELTA_METRICS", "requests_[total")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "output delta pattern is malformed") {
+			t.Errorf("Expected malformed pattern error, got %v", err)
+		}
+	})
+}
+
+// TestNodeConditionsTTL validates configuring node condition enrichment.
+func TestNodeConditionsTTL(t *testing.T) {
+	t.Run("caches for 15s by default", func(t *testing.T) {
+		if config := DefaultConfig(); config.NodeConditionsTTL != 15*time.Second {
+			t.Errorf("Expected 15s, got %v", config.NodeConditionsTTL)
+		}
+	})
+
+	t.Run("parses the TTL", func(t *testing.T) {
+		t.Setenv("BLACKBOX_NODE_CONDITIONS_TTL", "0s")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.NodeConditionsTTL != 0 {
+			t.Errorf("Expected enrichment disabled, got %v", config.NodeConditionsTTL)
+		}
+	})
+
+	t.Run("rejects a negative TTL", func(t *testing.T) {
+		t.Setenv("BLACKBOX_API_KEY", "test-key")
+		t.Setenv("BLACKBOX_NODE_CONDITIONS_TTL", "-1s")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "node conditions TTL must not be negative") {
+			t.Errorf("Expected negative TTL error, got %v", err)
+		}
+	})
+}
+
+// TestCrashLogs validates configuring crashed container log capture.
+func TestCrashLogs(t *testing.T) {
+	t.Run("is disabled by default", func(t *testing.T) {
+		config := DefaultConfig()
+		if config.CrashLogLines != 0 || config.CrashLogParse != "auto" {
+			t.Errorf("Expected capture disabled with auto parsing, got %d and %q", config.CrashLogLines, config.CrashLogParse)
+		}
+		if config.CrashLogConcurrency != 4 {
+			t.Errorf("Expected 4 concurrent log fetches, got %d", config.CrashLogConcurrency)
+		}
+	})
+
+	t.Run("parses lines and mode", func(t *testing.T) {
+		t.Setenv("BLACKBOX_CRASH_LOG_LINES", "100")
+		t.Setenv("BLACKBOX_CRASH_LOG_PARSE", "json")
+		t.Setenv("BLACKBOX_CRASH_LOG_CONCURRENCY", "8")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.CrashLogLines != 100 || config.CrashLogParse != "json" {
+			t.Errorf("Expected 100 lines parsed as JSON, got %d and %q", config.CrashLogLines, config.CrashLogParse)
+		}
+		if config.CrashLogConcurrency != 8 {
+			t.Errorf("Expected 8 concurrent log fetches, got %d", config.CrashLogConcurrency)
+		}
+	})
+
+	t.Run("rejects invalid settings", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.CrashLogLines = 5000
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "crash log lines must be between 0 and 1000") {
+			t.Errorf("Expected lines error, got %v", err)
+		}
+
+		config.CrashLogLines = 50
+		config.CrashLogParse = "xml"
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "crash log parse mode must be raw, json or auto") {
+			t.Errorf("Expected parse mode error, got %v", err)
+		}
+
+		config.CrashLogParse = "auto"
+		config.CrashLogConcurrency = 100
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "crash log concurrency must be between 0 and 64") {
+			t.Errorf("Expected concurrency error, got %v", err)
+		}
+	})
+}
+
+// TestCRISocket validates configuring container runtime enrichment.
+func TestCRISocket(t *testing.T) {
+	t.Run("is disabled by default", func(t *testing.T) {
+		if config := DefaultConfig(); config.CRISocket != "" {
+			t.Errorf("Expected no CRI socket, got %q", config.CRISocket)
+		}
+	})
+
+	t.Run("parses the socket", func(t *testing.T) {
+		t.Setenv("BLACKBOX_CRI_SOCKET", "unix:///run/containerd/containerd.sock")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.CRISocket != "unix:///run/containerd/containerd.sock" {
+			t.Errorf("Expected containerd socket, got %q", config.CRISocket)
+		}
+		config.APIKey = "valid-key"
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected valid config, got %v", err)
+		}
+	})
+
+	t.Run("rejects a relative path", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.CRISocket = "run/containerd/containerd.sock"
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "CRI socket must be an absolute path or a unix:// URL") {
+			t.Errorf("Expected relative path error, got %v", err)
+		}
+	})
+}
+
+// TestKernelRates validates configuring kernel activity rate collection.
+func TestKernelRates(t *testing.T) {
+	t.Run("is disabled by default", func(t *testing.T) {
+		if DefaultConfig().CollectKernelRates {
+			t.Error("Expected kernel rates disabled by default")
+		}
+	})
+
+	t.Run("parses collection", func(t *testing.T) {
+		t.Setenv("BLACKBOX_COLLECT_KERNEL_RATES", "true")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !config.CollectKernelRates {
+			t.Error("Expected kernel rates enabled")
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		t.Setenv("BLACKBOX_COLLECT_KERNEL_RATES", "sometimes")
+
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "BLACKBOX_COLLECT_KERNEL_RATES") {
+			t.Errorf("Expected invalid value error, got %v", err)
+		}
+	})
+}
+
+// TestSocketStats validates configuring socket statistics collection.
+func TestSocketStats(t *testing.T) {
+	t.Run("is disabled by default", func(t *testing.T) {
+		if DefaultConfig().CollectSocketStats {
+			t.Error("Expected socket statistics disabled by default")
+		}
+	})
+
+	t.Run("parses collection", func(t *testing.T) {
+		t.Setenv("BLACKBOX_COLLECT_SOCKET_STATS", "true")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !config.CollectSocketStats {
+			t.Error("Expected socket statistics enabled")
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		t.Setenv("BLACKBOX_COLLECT_SOCKET_STATS", "sometimes")
+
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "BLACKBOX_COLLECT_SOCKET_STATS") {
+			t.Errorf("Expected invalid value error, got %v", err)
+		}
+	})
+}
+
+// TestEmitOnInterval validates configuring periodic heartbeat emission.
+func TestEmitOnInterval(t *testing.T) {
+	t.Run("is disabled by default", func(t *testing.T) {
+		if DefaultConfig().EmitOnInterval != 0 {
+			t.Errorf("Expected incident-only emission by default, got %v", DefaultConfig().EmitOnInterval)
+		}
+	})
+
+	t.Run("parses the interval", func(t *testing.T) {
+		t.Setenv("BLACKBOX_EMIT_ON_INTERVAL", "5m")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.EmitOnInterval != 5*time.Minute {
+			t.Errorf("Expected 5m, got %v", config.EmitOnInterval)
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		t.Setenv("BLACKBOX_EMIT_ON_INTERVAL", "often")
+
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "BLACKBOX_EMIT_ON_INTERVAL") {
+			t.Errorf("Expected invalid value error, got %v", err)
+		}
+	})
+
+	t.Run("rejects negative intervals", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.EmitOnInterval = -time.Second
+
+		if err := config.Validate(); err == nil {
+			t.Error("Expected error for a negative emit interval")
+		}
+	})
+}
+
+// TestZombies validates configuring zombie process collection and incidents.
+func TestZombies(t *testing.T) {
+	t.Run("is disabled by default", func(t *testing.T) {
+		config := DefaultConfig()
+		if config.CollectZombies || config.ZombieThreshold != 0 {
+			t.Errorf("Expected zombie collection disabled by default, got %v and %d", config.CollectZombies, config.ZombieThreshold)
+		}
+	})
+
+	t.Run("parses collection and threshold", func(t *testing.T) {
+		t.Setenv("BLACKBOX_COLLECT_ZOMBIES", "true")
+		t.Setenv("BLACKBOX_ZOMBIE_THRESHOLD", "20")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !config.CollectZombies || config.ZombieThreshold != 20 {
+			t.Errorf("Expected zombies collected with threshold 20, got %v and %d", config.CollectZombies, config.ZombieThreshold)
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		t.Setenv("BLACKBOX_ZOMBIE_THRESHOLD", "many")
+
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "BLACKBOX_ZOMBIE_THRESHOLD") {
+			t.Errorf("Expected invalid value error, got %v", err)
+		}
+	})
+
+	t.Run("requires collection for a threshold", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.ZombieThreshold = 20
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "requires collecting zombies") {
+			t.Errorf("Expected collection required error, got %v", err)
+		}
+
+		config.CollectZombies = true
+		config.ZombieThreshold = -1
+		if err := config.Validate(); err == nil {
+			t.Error("Expected error for a negative threshold")
+		}
+	})
+}
+
+// TestMetricsLabelValueMaxLength validates configuring the label value length cap.
+func TestMetricsLabelValueMaxLength(t *testing.T) {
+	t.Run("parses the length", func(t *testing.T) {
+		t.Setenv("BLACKBOX_METRICS_LABEL_VALUE_MAX_LENGTH", "64")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.MetricsLabelValueMaxLength != 64 {
+			t.Errorf("Expected 64, got %d", config.MetricsLabelValueMaxLength)
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		t.Setenv("BLACKBOX_METRICS_LABEL_VALUE_MAX_LENGTH", "long")
+
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "BLACKBOX_METRICS_LABEL_VALUE_MAX_LENGTH") {
+			t.Errorf("Expected invalid value error, got %v", err)
+		}
+	})
+
+	t.Run("rejects negative lengths", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.MetricsLabelValueMaxLength = -1
+
+		if err := config.Validate(); err == nil {
+			t.Error("Expected error for a negative length")
+		}
+	})
+}
+
+// TestWatcherAuthRecovery validates configuring how the pod watcher recovers
+// from rejected watches.
+func TestWatcherAuthRecovery(t *testing.T) {
+	t.Run("rebuilds after 3 rejections by default", func(t *testing.T) {
+		config := DefaultConfig()
+		if config.WatcherAuthRebuildThreshold != 3 || config.WatcherAuthBackoffMax != 5*time.Minute {
+			t.Errorf("Expected threshold 3 and backoff 5m, got %d and %v", config.WatcherAuthRebuildThreshold, config.WatcherAuthBackoffMax)
+		}
+	})
+
+	t.Run("parses threshold and backoff", func(t *testing.T) {
+		t.Setenv("BLACKBOX_WATCHER_AUTH_REBUILD_THRESHOLD", "5")
+		t.Setenv("BLACKBOX_WATCHER_AUTH_BACKOFF_MAX", "1m")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.WatcherAuthRebuildThreshold != 5 || config.WatcherAuthBackoffMax != time.Minute {
+			t.Errorf("Expected threshold 5 and backoff 1m, got %d and %v", config.WatcherAuthRebuildThreshold, config.WatcherAuthBackoffMax)
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		for name, value := range map[string]string{
+			"BLACKBOX_WATCHER_AUTH_REBUILD_THRESHOLD": "often",
+			"BLACKBOX_WATCHER_AUTH_BACKOFF_MAX":       "5",
+		} {
+			t.Setenv(name, value)
+			if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), name) {
+				t.Errorf("Expected invalid %s error, got %v", name, err)
+			}
+			os.Unsetenv(name)
+		}
+
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.WatcherAuthRebuildThreshold = -1
+		if err := config.Validate(); err == nil {
+			t.Error("Expected error for a negative threshold")
+		}
+		config.WatcherAuthRebuildThreshold = 0
+		config.WatcherAuthBackoffMax = -time.Second
+		if err := config.Validate(); err == nil {
+			t.Error("Expected error for a negative backoff")
+		}
+	})
+}
+
+// TestCollectionStartupDelay validates configuring the delay before the first
+// system collection.
+func TestCollectionStartupDelay(t *testing.T) {
+	t.Run("collects immediately by default", func(t *testing.T) {
+		if delay := DefaultConfig().CollectionStartupDelay; delay != 0 {
+			t.Errorf("Expected no delay, got %v", delay)
+		}
+	})
+
+	t.Run("parses the delay", func(t *testing.T) {
+		t.Setenv("BLACKBOX_COLLECTION_STARTUP_DELAY", "30s")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.CollectionStartupDelay != 30*time.Second {
+			t.Errorf("Expected delay 30s, got %v", config.CollectionStartupDelay)
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		t.Setenv("BLACKBOX_COLLECTION_STARTUP_DELAY", "soon")
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "BLACKBOX_COLLECTION_STARTUP_DELAY") {
+			t.Errorf("Expected invalid BLACKBOX_COLLECTION_STARTUP_DELAY error, got %v", err)
+		}
+
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.CollectionStartupDelay = -time.Second
+		if err := config.Validate(); err == nil {
+			t.Error("Expected error for a negative delay")
+		}
+	})
+}
+
+// TestGRPCQuery validates configuring the gRPC telemetry query service.
+func TestGRPCQuery(t *testing.T) {
+	t.Run("is disabled by default", func(t *testing.T) {
+		if DefaultConfig().GRPCQuery {
+			t.Error("Expected the gRPC query service disabled by default")
+		}
+	})
+
+	t.Run("parses the flag", func(t *testing.T) {
+		t.Setenv("BLACKBOX_GRPC_PORT", "9095")
+		t.Setenv("BLACKBOX_GRPC_QUERY", "true")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !config.GRPCQuery {
+			t.Error("Expected the gRPC query service enabled")
 		}
-		if len(config.OutputFormatters) != 2 || config.OutputFormatters[0] != "json" || config.OutputFormatters[1] != "csv" {
-			t.Errorf("Expected OutputFormatters ['json','csv'], got %v", config.OutputFormatters)
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		t.Setenv("BLACKBOX_GRPC_QUERY", "maybe")
+
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "BLACKBOX_GRPC_QUERY") {
+			t.Errorf("Expected invalid value error, got %v", err)
 		}
-		if config.OutputPath != "/tmp/logs" {
-			t.Errorf("Expected OutputPath '/tmp/logs', got %v", config.OutputPath)
+	})
+
+	t.Run("requires a gRPC port", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.GRPCQuery = true
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "requires a gRPC port") {
+			t.Errorf("Expected gRPC port required error, got %v", err)
 		}
-		if config.LogLevel != "debug" {
-			t.Errorf("Expected LogLevel 'debug', got %v", config.LogLevel)
+
+		config.GRPCPort = 9095
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected no error, got %v", err)
 		}
-		if config.SwaggerEnable != true {
-			t.Errorf("Expected SwaggerEnable true, got %v", config.SwaggerEnable)
+	})
+}
+
+// TestMetricsBridge validates configuring the labels and series limit of the
+// gauges bridged from telemetry entries.
+func TestMetricsBridge(t *testing.T) {
+	t.Run("labels by source by default", func(t *testing.T) {
+		config := DefaultConfig()
+		if len(config.MetricsBridgeLabels) != 1 || config.MetricsBridgeLabels[0] != "source" || config.MetricsBridgeSeriesLimit != 1000 {
+			t.Errorf("Expected the source label and 1000 series, got %v and %d", config.MetricsBridgeLabels, config.MetricsBridgeSeriesLimit)
 		}
-		if config.LogJSON != false {
-			t.Errorf("Expected LogJSON false, got %v", config.LogJSON)
+	})
+
+	t.Run("parses labels and limit", func(t *testing.T) {
+		t.Setenv("BLACKBOX_METRICS_BRIDGE_LABELS", "source, pod,namespace")
+		t.Setenv("BLACKBOX_METRICS_BRIDGE_SERIES_LIMIT", "5000")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
 		}
-		if config.NodeName != "test-node" {
-			t.Errorf("Expected NodeName 'test-node', got %v", config.NodeName)
+		expected := []string{"source", "pod", "namespace"}
+		if strings.Join(config.MetricsBridgeLabels, ",") != strings.Join(expected, ",") || config.MetricsBridgeSeriesLimit != 5000 {
+			t.Errorf("Expected %v and 5000 series, got %v and %d", expected, config.MetricsBridgeLabels, config.MetricsBridgeSeriesLimit)
 		}
-		if config.PodNamespace != "test-namespace" {
-			t.Errorf("Expected PodNamespace 'test-namespace', got %v", config.PodNamespace)
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		t.Setenv("BLACKBOX_METRICS_BRIDGE_SERIES_LIMIT", "lots")
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "BLACKBOX_METRICS_BRIDGE_SERIES_LIMIT") {
+			t.Errorf("Expected invalid value error, got %v", err)
+		}
+
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		for _, labels := range [][]string{{"container"}, {"pod", "pod"}} {
+			config.MetricsBridgeLabels = labels
+			if err := config.Validate(); err == nil {
+				t.Errorf("Expected error for bridge labels %v", labels)
+			}
+		}
+		config.MetricsBridgeLabels = nil
+		config.MetricsBridgeSeriesLimit = -1
+		if err := config.Validate(); err == nil {
+			t.Error("Expected error for a negative series limit")
 		}
 	})
+}
 
-	t.Run("handles invalid duration formats", func(t *testing.T) {
-		os.Setenv("BLACKBOX_API_KEY", "test-key")
-		os.Setenv("BLACKBOX_BUFFER_WINDOW_SIZE", "invalid")
-		
-		_, err := LoadFromEnv()
-		
-		if err == nil {
-			t.Fatal("Expected error for invalid duration")
+// TestFDLimits validates configuring descriptor limit collection and incidents.
+func TestFDLimits(t *testing.T) {
+	t.Run("is disabled by default", func(t *testing.T) {
+		config := DefaultConfig()
+		if config.CollectFDLimits || config.FDUsageThreshold != 0 {
+			t.Errorf("Expected fd limits disabled, got %v and %v", config.CollectFDLimits, config.FDUsageThreshold)
 		}
-		if !strings.Contains(err.Error(), "invalid BLACKBOX_BUFFER_WINDOW_SIZE") {
-			t.Errorf("Expected duration parsing error, got %v", err)
+	})
+
+	t.Run("parses collection and threshold", func(t *testing.T) {
+		t.Setenv("BLACKBOX_COLLECT_FD_LIMITS", "true")
+		t.Setenv("BLACKBOX_FD_USAGE_THRESHOLD", "85.5")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !config.CollectFDLimits || config.FDUsageThreshold != 85.5 {
+			t.Errorf("Expected collection at 85.5%%, got %v and %v", config.CollectFDLimits, config.FDUsageThreshold)
 		}
 	})
 
-	t.Run("handles invalid port numbers", func(t *testing.T) {
-		os.Setenv("BLACKBOX_API_KEY", "test-key")
-		os.Setenv("BLACKBOX_API_PORT", "invalid")
-		
-		_, err := LoadFromEnv()
-		
-		if err == nil {
-			t.Fatal("Expected error for invalid port")
+	t.Run("rejects invalid thresholds", func(t *testing.T) {
+		for _, tc := range []struct {
+			collect, threshold, expected string
+		}{
+			{"true", "120", "must be between 0 and 100"},
+			{"false", "90", "requires collecting fd limits"},
+		} {
+			t.Setenv("BLACKBOX_API_KEY", "test-key")
+			t.Setenv("BLACKBOX_COLLECT_FD_LIMITS", tc.collect)
+			t.Setenv("BLACKBOX_FD_USAGE_THRESHOLD", tc.threshold)
+			config, err := LoadFromEnv()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := config.Validate(); err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("Expected error containing %q, got %v", tc.expected, err)
+			}
 		}
 	})
+}
 
-	t.Run("handles invalid boolean values", func(t *testing.T) {
-		os.Setenv("BLACKBOX_API_KEY", "test-key")
-		os.Setenv("BLACKBOX_SWAGGER_ENABLE", "invalid")
-		
-		_, err := LoadFromEnv()
-		
-		if err == nil {
-			t.Fatal("Expected error for invalid boolean")
+// TestAPISidecarHistograms validates configuring sidecar histogram support.
+func TestAPISidecarHistograms(t *testing.T) {
+	t.Run("is disabled by default", func(t *testing.T) {
+		if config := DefaultConfig(); config.APISidecarHistograms {
+			t.Error("Expected sidecar histograms disabled by default")
+		}
+	})
+
+	t.Run("parses the flag", func(t *testing.T) {
+		t.Setenv("BLACKBOX_API_SIDECAR_HISTOGRAMS", "true")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !config.APISidecarHistograms {
+			t.Error("Expected sidecar histograms enabled")
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		t.Setenv("BLACKBOX_API_SIDECAR_HISTOGRAMS", "sometimes")
+
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "BLACKBOX_API_SIDECAR_HISTOGRAMS") {
+			t.Errorf("Expected invalid flag error, got %v", err)
+		}
+	})
+}
+
+// TestAPIRequestTracing validates the request tracing flag.
+func TestAPIRequestTracing(t *testing.T) {
+	t.Run("is disabled by default", func(t *testing.T) {
+		if config := DefaultConfig(); config.APIRequestTracing {
+			t.Error("Expected request tracing disabled by default")
+		}
+	})
+
+	t.Run("parses the flag", func(t *testing.T) {
+		t.Setenv("BLACKBOX_API_REQUEST_TRACING", "true")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !config.APIRequestTracing {
+			t.Error("Expected request tracing enabled")
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		t.Setenv("BLACKBOX_API_REQUEST_TRACING", "verbose")
+
+		if _, err := LoadFromEnv(); err == nil || !strings.Contains(err.Error(), "BLACKBOX_API_REQUEST_TRACING") {
+			t.Errorf("Expected invalid flag error, got %v", err)
+		}
+	})
+}
+
+// TestLowMemory validates configuring low memory incidents.
+func TestLowMemory(t *testing.T) {
+	t.Run("is disabled by default", func(t *testing.T) {
+		config := DefaultConfig()
+		if config.LowMemoryAvailableBytes != 0 || config.LowMemoryUsedPercent != 0 {
+			t.Errorf("Expected no thresholds, got %d bytes and %v%%", config.LowMemoryAvailableBytes, config.LowMemoryUsedPercent)
+		}
+		if config.LowMemorySustain != time.Minute || config.LowMemoryTopProcesses != 5 {
+			t.Errorf("Expected 1m sustain and 5 top processes, got %v and %d", config.LowMemorySustain, config.LowMemoryTopProcesses)
+		}
+	})
+
+	t.Run("parses thresholds", func(t *testing.T) {
+		t.Setenv("BLACKBOX_LOW_MEMORY_AVAILABLE_BYTES", "536870912")
+		t.Setenv("BLACKBOX_LOW_MEMORY_USED_PERCENT", "95")
+		t.Setenv("BLACKBOX_LOW_MEMORY_SUSTAIN", "2m")
+		t.Setenv("BLACKBOX_LOW_MEMORY_TOP_PROCESSES", "3")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.LowMemoryAvailableBytes != 536870912 || config.LowMemoryUsedPercent != 95 || config.LowMemorySustain != 2*time.Minute || config.LowMemoryTopProcesses != 3 {
+			t.Errorf("Expected 512MiB, 95%%, 2m and 3, got %d, %v%%, %v and %d", config.LowMemoryAvailableBytes, config.LowMemoryUsedPercent, config.LowMemorySustain, config.LowMemoryTopProcesses)
+		}
+	})
+
+	t.Run("rejects a percentage above 100", func(t *testing.T) {
+		t.Setenv("BLACKBOX_API_KEY", "test-key")
+		t.Setenv("BLACKBOX_LOW_MEMORY_USED_PERCENT", "120")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "low memory used percent must be between 0 and 100") {
+			t.Errorf("Expected used percent error, got %v", err)
+		}
+	})
+}
+
+// TestBindAddress validates configuring the address the servers listen on.
+func TestBindAddress(t *testing.T) {
+	t.Run("listens on all interfaces by default", func(t *testing.T) {
+		if config := DefaultConfig(); config.BindAddress != "" {
+			t.Errorf("Expected no bind address, got %q", config.BindAddress)
+		}
+	})
+
+	t.Run("accepts hosts and IP addresses", func(t *testing.T) {
+		for _, address := range []string{"127.0.0.1", "::1", "[::1]", "localhost", "node-1.internal"} {
+			t.Setenv("BLACKBOX_API_KEY", "test-key")
+			t.Setenv("BLACKBOX_BIND_ADDRESS", address)
+
+			config, err := LoadFromEnv()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := config.Validate(); err != nil {
+				t.Errorf("Expected %q to be valid, got %v", address, err)
+			}
+		}
+	})
+
+	t.Run("strips IPv6 brackets", func(t *testing.T) {
+		t.Setenv("BLACKBOX_BIND_ADDRESS", "[::1]")
+
+		config, err := LoadFromEnv()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if config.BindAddress != "::1" {
+			t.Errorf("Expected '::1', got %q", config.BindAddress)
+		}
+	})
+
+	t.Run("rejects addresses with a port", func(t *testing.T) {
+		for _, address := range []string{"127.0.0.1:8080", "localhost:9090", "not a host", "-bad"} {
+			t.Setenv("BLACKBOX_API_KEY", "test-key")
+			t.Setenv("BLACKBOX_BIND_ADDRESS", address)
+
+			config, err := LoadFromEnv()
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "bind address must be an IP address or hostname") {
+				t.Errorf("Expected bind address error for %q, got %v", address, err)
+			}
 		}
 	})
 }
@@ -430,6 +1795,232 @@ func TestValidate(t *testing.T) {
 			t.Errorf("Expected formatter error, got %v", err)
 		}
 	})
+
+	t.Run("rejects invalid API base path", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.APIBasePath = "/blackbox?x=1"
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "API base path") {
+			t.Errorf("Expected base path error, got %v", err)
+		}
+
+		config.APIBasePath = "/blackbox"
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected valid base path, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid tracked processes", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.TrackProcesses = []string{"postgres", "cmdline:("}
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "invalid tracked processes") {
+			t.Errorf("Expected tracked process error, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid disk full thresholds", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.DiskFullThresholds = "/=150"
+
+		err := config.Validate()
+
+		if err == nil || !strings.Contains(err.Error(), "invalid disk full thresholds") {
+			t.Errorf("Expected disk threshold error, got %v", err)
+		}
+
+		config.DiskFullThresholds = "/=90,/var/lib=85:10000"
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected valid disk thresholds, got %v", err)
+		}
+	})
+
+	t.Run("rejects unregistered incident handler", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.IncidentHandlers = append(config.IncidentHandlers, handler.HandlerConfig{Type: "pagerduty"})
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), `incident handler 4: unknown type "pagerduty"`) {
+			t.Errorf("Expected unknown handler error, got %v", err)
+		}
+	})
+
+	t.Run("validates output timestamp format", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.OutputTimestampFormat = "not a layout"
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "output timestamp format") {
+			t.Errorf("Expected timestamp format error, got %v", err)
+		}
+
+		config.OutputTimestampFormat = "2006-01-02 15:04:05"
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected valid timestamp format, got %v", err)
+		}
+	})
+
+	t.Run("rejects negative output value precision", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.OutputValuePrecision = -1
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "output value precision") {
+			t.Errorf("Expected precision error, got %v", err)
+		}
+	})
+
+	t.Run("rejects malformed buffer import pattern", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.BufferImport = "/backfill/[*.gz"
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "buffer import pattern") {
+			t.Errorf("Expected import pattern error, got %v", err)
+		}
+	})
+
+	t.Run("rejects negative buffer block timeout", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.BufferBlockTimeout = -time.Second
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "buffer block timeout") {
+			t.Errorf("Expected block timeout error, got %v", err)
+		}
+
+		config.BufferBlockTimeout = 0
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected zero timeout to be valid, got %v", err)
+		}
+	})
+
+	t.Run("rejects negative stale series settings", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.MetricsStaleSeriesMisses = -1
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "stale series") {
+			t.Errorf("Expected stale series error, got %v", err)
+		}
+
+		config.MetricsStaleSeriesMisses = 0
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected zero misses to be valid, got %v", err)
+		}
+	})
+
+	t.Run("rejects negative API listen backlog", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.APIListenBacklog = -1
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "listen backlog") {
+			t.Errorf("Expected listen backlog error, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid API entry limit", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.APIMaxEntriesPerRequest = -1
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "max entries per request") {
+			t.Errorf("Expected max entries error, got %v", err)
+		}
+
+		config.APIMaxEntriesPerRequest = 0
+		config.APIEntryLimitAction = "drop"
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "entry limit action") {
+			t.Errorf("Expected entry limit action error, got %v", err)
+		}
+
+		config.APIEntryLimitAction = "truncate"
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected valid entry limit, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid API value size limit", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.APIMaxValueBytes = -1
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "max value bytes") {
+			t.Errorf("Expected max value bytes error, got %v", err)
+		}
+
+		config.APIMaxValueBytes = 65536
+		config.APIValueLimitAction = "drop"
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "value limit action") {
+			t.Errorf("Expected value limit action error, got %v", err)
+		}
+
+		config.APIValueLimitAction = "truncate"
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected valid value size limit, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid API clock skew", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.APIClockSkewPolicy = "ignore"
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "clock skew policy") {
+			t.Errorf("Expected clock skew policy error, got %v", err)
+		}
+
+		config.APIClockSkewPolicy = "rebase"
+		config.APIMaxClockSkew = -time.Second
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "max clock skew") {
+			t.Errorf("Expected max clock skew error, got %v", err)
+		}
+
+		config.APIMaxClockSkew = 30 * time.Second
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected valid clock skew settings, got %v", err)
+		}
+	})
+
+	t.Run("requires a ConfigMap name when watching", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.WatchConfigMap = true
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "ConfigMap") {
+			t.Errorf("Expected ConfigMap name error, got %v", err)
+		}
+
+		config.ConfigMapName = "blackbox-overrides"
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected valid ConfigMap watch, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid memory leak settings", func(t *testing.T) {
+		config := DefaultConfig()
+		config.APIKey = "valid-key"
+		config.MemoryLeakSlope = -1
+
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "memory leak slope") {
+			t.Errorf("Expected memory leak slope error, got %v", err)
+		}
+
+		config.MemoryLeakSlope = 0.5
+		config.MemoryLeakWindow = 0
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "memory leak detection") {
+			t.Errorf("Expected memory leak window error, got %v", err)
+		}
+
+		config.MemoryLeakWindow = 30 * time.Minute
+		if err := config.Validate(); err != nil {
+			t.Errorf("Expected valid memory leak settings, got %v", err)
+		}
+	})
 }
 
 // TestDefaultConfig tests the DefaultConfig function to ensure proper defaults are set.
@@ -443,6 +2034,58 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.CollectionInterval != 1*time.Second {
 		t.Errorf("Expected CollectionInterval 1s, got %v", cfg.CollectionInterval)
 	}
+	if cfg.BufferBlockTimeout != 5*time.Second {
+		t.Errorf("Expected BufferBlockTimeout 5s, got %v", cfg.BufferBlockTimeout)
+	}
+	if cfg.EnablePprof {
+		t.Error("Expected pprof disabled by default")
+	}
+	if cfg.BufferIntern {
+		t.Error("Expected interning disabled by default")
+	}
+
+	if !cfg.MetricsOpenMetrics || !cfg.MetricsCompression {
+		t.Errorf("Expected OpenMetrics and compression enabled, got %v and %v", cfg.MetricsOpenMetrics, cfg.MetricsCompression)
+	}
+	if cfg.IncidentWALPath != "" || cfg.IncidentHistoryMax != 1000 || cfg.IncidentHistoryMaxAge != 7*24*time.Hour {
+		t.Errorf("Expected in-memory incident history of 1000 incidents over 7 days, got %q, %d and %v", cfg.IncidentWALPath, cfg.IncidentHistoryMax, cfg.IncidentHistoryMaxAge)
+	}
+	if cfg.APIMaxEntriesPerRequest != 0 || cfg.APIEntryLimitAction != "reject" {
+		t.Errorf("Expected no entry cap, rejecting, got %d and %q", cfg.APIMaxEntriesPerRequest, cfg.APIEntryLimitAction)
+	}
+	if cfg.APIMaxValueBytes != 0 || cfg.APIValueLimitAction != "reject" {
+		t.Errorf("Expected no value size cap, rejecting, got %d and %q", cfg.APIMaxValueBytes, cfg.APIValueLimitAction)
+	}
+	if !cfg.OutputIncludeRaw {
+		t.Error("Expected OutputIncludeRaw to default to true")
+	}
+	if len(cfg.NetworkInterfaces) != 0 || len(cfg.NetworkInterfacesExclude) != len(telemetry.DefaultExcludedInterfaces) {
+		t.Errorf("Expected all interfaces except the default exclusions, got %v and %v", cfg.NetworkInterfaces, cfg.NetworkInterfacesExclude)
+	}
+	if cfg.APIClockSkewPolicy != "accept" || cfg.APIMaxClockSkew != time.Minute {
+		t.Errorf("Expected skewed timestamps accepted with a 1m bound, got %q and %v", cfg.APIClockSkewPolicy, cfg.APIMaxClockSkew)
+	}
+	if !cfg.APIExactNumbers {
+		t.Error("Expected APIExactNumbers to default to true")
+	}
+	if !cfg.CPUIOWaitBusy {
+		t.Error("Expected CPUIOWaitBusy to default to true")
+	}
+	if cfg.MetricsSidecarSeriesLimit != 1000 {
+		t.Errorf("Expected MetricsSidecarSeriesLimit 1000, got %d", cfg.MetricsSidecarSeriesLimit)
+	}
+	if cfg.MetricsLabelValueMaxLength != 256 {
+		t.Errorf("Expected MetricsLabelValueMaxLength 256, got %d", cfg.MetricsLabelValueMaxLength)
+	}
+	if cfg.MetricsStaleSeriesMisses != 3 || cfg.MetricsStaleSeriesWarmup != 2 {
+		t.Errorf("Expected stale series misses 3 and warmup 2, got %d and %d", cfg.MetricsStaleSeriesMisses, cfg.MetricsStaleSeriesWarmup)
+	}
+	if cfg.IncidentSnapshotWindow != 60*time.Second {
+		t.Errorf("Expected IncidentSnapshotWindow 60s, got %v", cfg.IncidentSnapshotWindow)
+	}
+	if cfg.MemoryLeakSlope != 0 || cfg.MemoryLeakMetric != "memory_usage_percent" || cfg.MemoryLeakWindow != 30*time.Minute || cfg.MemoryLeakSustain != 10*time.Minute {
+		t.Errorf("Expected leak detection disabled over memory_usage_percent, 30m window and 10m sustain, got %v, %q, %v and %v", cfg.MemoryLeakSlope, cfg.MemoryLeakMetric, cfg.MemoryLeakWindow, cfg.MemoryLeakSustain)
+	}
 	
 	if cfg.APIPort != 8080 {
 		t.Errorf("Expected APIPort 8080, got %d", cfg.APIPort)
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ReloadableKeys are the settings a running daemon can change without a
+// restart, e.g. when the watched ConfigMap is updated. Other settings size
+// buffers, open listeners or build components at startup and are ignored on
+// reload.
+var ReloadableKeys = []string{
+	"BLACKBOX_COLLECTION_INTERVAL",
+	"BLACKBOX_INCIDENT_SNAPSHOT_WINDOW",
+}
+
+// Reload returns a copy of the configuration with the reloadable settings in
+// data, a ConfigMap's key/value pairs using the environment variable names,
+// applied. Empty values are skipped. Keys that cannot be changed at runtime
+// are not parsed and are returned, sorted, so the caller can warn about them.
+// On a parse or validation error the current configuration should stay in
+// effect.
+func (c *Config) Reload(data map[string]string) (*Config, []string, error) {
+	parsed, err := load(func(key string) string {
+		for _, reloadable := range ReloadableKeys {
+			if key == reloadable {
+				return data[key]
+			}
+		}
+		return ""
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reloaded := *c
+	var ignored []string
+	for key, value := range data {
+		if value == "" {
+			continue
+		}
+		switch key {
+		case "BLACKBOX_COLLECTION_INTERVAL":
+			reloaded.CollectionInterval = parsed.CollectionInterval
+		case "BLACKBOX_INCIDENT_SNAPSHOT_WINDOW":
+			reloaded.IncidentSnapshotWindow = parsed.IncidentSnapshotWindow
+		default:
+			ignored = append(ignored, key)
+		}
+	}
+	sort.Strings(ignored)
+
+	if err := reloaded.Validate(); err != nil {
+		return nil, ignored, fmt.Errorf("invalid reloaded configuration: %w", err)
+	}
+	return &reloaded, ignored, nil
+}
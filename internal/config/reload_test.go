@@ -0,0 +1,58 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReload validates applying runtime-safe settings from ConfigMap data.
+func TestReload(t *testing.T) {
+	current := DefaultConfig()
+	current.APIKey = "valid-key"
+
+	t.Run("applies reloadable settings", func(t *testing.T) {
+		reloaded, ignored, err := current.Reload(map[string]string{
+			"BLACKBOX_COLLECTION_INTERVAL":      "5s",
+			"BLACKBOX_INCIDENT_SNAPSHOT_WINDOW": "30s",
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if reloaded.CollectionInterval != 5*time.Second || reloaded.IncidentSnapshotWindow != 30*time.Second {
+			t.Errorf("Expected 5s interval and 30s window, got %v and %v", reloaded.CollectionInterval, reloaded.IncidentSnapshotWindow)
+		}
+		if len(ignored) != 0 {
+			t.Errorf("Expected nothing ignored, got %v", ignored)
+		}
+		if current.CollectionInterval != time.Second {
+			t.Errorf("Expected current configuration unchanged, got %v", current.CollectionInterval)
+		}
+	})
+
+	t.Run("ignores settings that need a restart", func(t *testing.T) {
+		reloaded, ignored, err := current.Reload(map[string]string{
+			"BLACKBOX_COLLECTION_INTERVAL": "2s",
+			"BLACKBOX_API_PORT":            "9999",
+			"BLACKBOX_BUFFER_WINDOW_SIZE":  "5m",
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if reloaded.APIPort != 8080 || reloaded.BufferWindowSize != 60*time.Second {
+			t.Errorf("Expected port and buffer window unchanged, got %d and %v", reloaded.APIPort, reloaded.BufferWindowSize)
+		}
+		if len(ignored) != 2 || ignored[0] != "BLACKBOX_API_PORT" || ignored[1] != "BLACKBOX_BUFFER_WINDOW_SIZE" {
+			t.Errorf("Expected API port and buffer window ignored, got %v", ignored)
+		}
+	})
+
+	t.Run("rejects invalid values", func(t *testing.T) {
+		if _, _, err := current.Reload(map[string]string{"BLACKBOX_COLLECTION_INTERVAL": "often"}); err == nil || !strings.Contains(err.Error(), "BLACKBOX_COLLECTION_INTERVAL") {
+			t.Errorf("Expected collection interval parse error, got %v", err)
+		}
+		if _, _, err := current.Reload(map[string]string{"BLACKBOX_COLLECTION_INTERVAL": "-1s"}); err == nil || !strings.Contains(err.Error(), "collection interval") {
+			t.Errorf("Expected collection interval validation error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,204 @@
+// Package cri reads container details from the container runtime (containerd,
+// CRI-O) over the Kubernetes Container Runtime Interface. The Kubernetes API
+// only reports a crashed container's exit code and reason; the runtime also
+// knows its final resource usage and OOM score, which make crash reports
+// easier to diagnose.
+package cri
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// DefaultTimeout bounds the runtime calls made for one container.
+const DefaultTimeout = 2 * time.Second
+
+const (
+	// containerStatusMethod is the CRI method returning a container's status
+	containerStatusMethod = "/runtime.v1.RuntimeService/ContainerStatus"
+	// containerStatsMethod is the CRI method returning a container's resource usage
+	containerStatsMethod = "/runtime.v1.RuntimeService/ContainerStats"
+)
+
+// ContainerInfo is what the runtime reports about a container, attached to
+// crash reports under "runtime".
+type ContainerInfo struct {
+	// ID is the runtime's container ID
+	ID string `json:"id"`
+	// State is the container state: created, running, exited or unknown
+	State string `json:"state"`
+	// ExitCode is the exit code of an exited container
+	ExitCode int32 `json:"exit_code"`
+	// Reason is the runtime's brief explanation of the state, e.g. OOMKilled
+	Reason string `json:"reason,omitempty"`
+	// Message is the runtime's human-readable explanation of the state
+	Message string `json:"message,omitempty"`
+	// StartedAt is when the container started
+	StartedAt time.Time `json:"started_at"`
+	// FinishedAt is when the container exited
+	FinishedAt time.Time `json:"finished_at"`
+	// ImageRef is the digest of the image the container ran
+	ImageRef string `json:"image_ref,omitempty"`
+	// OOMScoreAdj is the container process's oom_score_adj, when the runtime
+	// reports its OCI spec
+	OOMScoreAdj *int `json:"oom_score_adj,omitempty"`
+	// Stats is the container's last resource usage, when the runtime still
+	// has it
+	Stats *ContainerStats `json:"stats,omitempty"`
+}
+
+// ContainerStats is the resource usage the runtime last sampled for a
+// container. Usage the runtime does not report is zero.
+type ContainerStats struct {
+	// Timestamp is when the memory usage was sampled
+	Timestamp time.Time `json:"timestamp"`
+	// CPUUsageCoreNanoseconds is the cumulative CPU time consumed
+	CPUUsageCoreNanoseconds uint64 `json:"cpu_usage_core_nanoseconds"`
+	// MemoryWorkingSetBytes is the working set, the memory the OOM killer acts on
+	MemoryWorkingSetBytes uint64 `json:"memory_working_set_bytes"`
+	// MemoryUsageBytes is the total memory in use, including file cache
+	MemoryUsageBytes uint64 `json:"memory_usage_bytes"`
+	// MemoryRSSBytes is the anonymous and swap cache memory
+	MemoryRSSBytes uint64 `json:"memory_rss_bytes"`
+	// MemoryAvailableBytes is the memory left before the limit
+	MemoryAvailableBytes uint64 `json:"memory_available_bytes"`
+	// PageFaults is the cumulative number of page faults
+	PageFaults uint64 `json:"page_faults"`
+	// MajorPageFaults is the cumulative number of major page faults
+	MajorPageFaults uint64 `json:"major_page_faults"`
+}
+
+// Client queries a container runtime's CRI RuntimeService.
+type Client struct {
+	// conn is the connection to the runtime socket
+	conn *grpc.ClientConn
+	// timeout bounds the calls made for one container
+	timeout time.Duration
+	// unavailable records that the runtime could not be reached, so failures
+	// are logged once until it answers again
+	unavailable atomic.Bool
+}
+
+// NewClient creates a client for the runtime listening on endpoint, a socket
+// path such as /run/containerd/containerd.sock or a unix:// URL. The
+// connection is made lazily, so a missing runtime surfaces as errors from
+// ContainerInfo rather than here.
+func NewClient(endpoint string) (*Client, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("CRI endpoint is empty")
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "unix://" + endpoint
+	}
+
+	conn, err := grpc.NewClient(endpoint,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.ForceCodec(codec{})),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create CRI client for %s: %w", endpoint, err)
+	}
+	return &Client{conn: conn, timeout: DefaultTimeout}, nil
+}
+
+// Close closes the connection to the runtime.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// ContainerInfo returns the runtime's status of the container and, when the
+// runtime still has it, its last resource usage. The ID may carry the
+// runtime prefix Kubernetes reports, e.g. containerd://<id>.
+func (c *Client) ContainerInfo(ctx context.Context, containerID string) (*ContainerInfo, error) {
+	if _, id, ok := strings.Cut(containerID, "://"); ok {
+		containerID = id
+	}
+	if containerID == "" {
+		return nil, fmt.Errorf("container ID is empty")
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	status := &containerStatusResponse{}
+	if err := c.conn.Invoke(ctx, containerStatusMethod, &containerStatusRequest{containerID: containerID, verbose: true}, status); err != nil {
+		if !c.unavailable.Swap(true) {
+			fmt.Printf("Warning: container runtime is unavailable; crash reports will not include runtime details: %v\n", err)
+		}
+		return nil, fmt.Errorf("failed to get status of container %s: %w", containerID, err)
+	}
+	c.unavailable.Store(false)
+
+	info := status.containerInfo()
+	// Runtimes often drop the stats of exited containers, which leaves the
+	// report with the status alone
+	stats := &containerStatsResponse{}
+	if err := c.conn.Invoke(ctx, containerStatsMethod, &containerStatsRequest{containerID: containerID}, stats); err == nil && stats.stats != nil {
+		info.Stats = stats.stats
+	}
+	return info, nil
+}
+
+// containerInfo converts the status response.
+func (csr *containerStatusResponse) containerInfo() *ContainerInfo {
+	info := &ContainerInfo{
+		ID:         csr.id,
+		State:      containerState(csr.state),
+		ExitCode:   csr.exitCode,
+		Reason:     csr.reason,
+		Message:    csr.message,
+		StartedAt:  unixNano(csr.startedAt),
+		FinishedAt: unixNano(csr.finishedAt),
+		ImageRef:   csr.imageRef,
+	}
+	info.OOMScoreAdj = oomScoreAdj(csr.info["info"])
+	return info
+}
+
+// containerState names a CRI ContainerState value.
+func containerState(state uint64) string {
+	switch state {
+	case 0:
+		return "created"
+	case 1:
+		return "running"
+	case 2:
+		return "exited"
+	default:
+		return "unknown"
+	}
+}
+
+// unixNano converts a CRI timestamp in nanoseconds, where zero means unset.
+func unixNano(nanoseconds int64) time.Time {
+	if nanoseconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanoseconds).UTC()
+}
+
+// oomScoreAdj extracts the process's oom_score_adj from the verbose status
+// info containerd and CRI-O report, a JSON document holding the container's
+// OCI runtime spec.
+func oomScoreAdj(verboseInfo string) *int {
+	if verboseInfo == "" {
+		return nil
+	}
+	var info struct {
+		RuntimeSpec struct {
+			Process struct {
+				OOMScoreAdj *int `json:"oomScoreAdj"`
+			} `json:"process"`
+		} `json:"runtimeSpec"`
+	}
+	if err := json.Unmarshal([]byte(verboseInfo), &info); err != nil {
+		return nil
+	}
+	return info.RuntimeSpec.Process.OOMScoreAdj
+}
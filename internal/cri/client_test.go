@@ -0,0 +1,241 @@
+package cri
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// rawCodec passes messages through as bytes, so the stub runtime encodes its
+// responses independently of the client's messages.
+type rawCodec struct{}
+
+// Marshal returns the encoded message.
+func (rawCodec) Marshal(v interface{}) ([]byte, error) { return *v.(*[]byte), nil }
+
+// Unmarshal keeps the encoded message.
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	*v.(*[]byte) = append([]byte(nil), data...)
+	return nil
+}
+
+// Name returns the protobuf content subtype.
+func (rawCodec) Name() string { return "proto" }
+
+// stubRuntime is a CRI RuntimeService returning synthetic container details.
+type stubRuntime struct {
+	// statuses maps container IDs to encoded ContainerStatusResponses
+	statuses map[string][]byte
+	// stats maps container IDs to encoded ContainerStatsResponses
+	stats map[string][]byte
+	// verbose records whether status requests asked for verbose info
+	verbose bool
+}
+
+// handle returns a unary handler answering from responses by container ID.
+func (sr *stubRuntime) handle(responses func() map[string][]byte) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(_ interface{}, _ context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+		var req []byte
+		if err := dec(&req); err != nil {
+			return nil, err
+		}
+		var id string
+		err := eachField(req, func(num protowire.Number, typ protowire.Type, value []byte) error {
+			switch {
+			case num == 1:
+				id = string(value)
+			case num == 2 && typ == protowire.VarintType:
+				sr.verbose = true
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		resp, ok := responses()[id]
+		if !ok {
+			return nil, status.Errorf(codes.NotFound, "container %q not found", id)
+		}
+		return &resp, nil
+	}
+}
+
+// serve starts the stub runtime on a unix socket and returns its path.
+func (sr *stubRuntime) serve(t *testing.T) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "cri.sock")
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	server := grpc.NewServer(grpc.ForceServerCodec(rawCodec{}))
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "runtime.v1.RuntimeService",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{
+			{MethodName: "ContainerStatus", Handler: sr.handle(func() map[string][]byte { return sr.statuses })},
+			{MethodName: "ContainerStats", Handler: sr.handle(func() map[string][]byte { return sr.stats })},
+		},
+	}, struct{}{})
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+	return socket
+}
+
+// message encodes length-delimited fields and varints into a message.
+func message(fields ...interface{}) []byte {
+	var data []byte
+	for i := 0; i < len(fields); i += 2 {
+		num := protowire.Number(fields[i].(int))
+		switch value := fields[i+1].(type) {
+		case string:
+			data = protowire.AppendTag(data, num, protowire.BytesType)
+			data = protowire.AppendString(data, value)
+		case []byte:
+			data = protowire.AppendTag(data, num, protowire.BytesType)
+			data = protowire.AppendBytes(data, value)
+		case int64:
+			data = protowire.AppendTag(data, num, protowire.VarintType)
+			data = protowire.AppendVarint(data, uint64(value))
+		default:
+			panic(fmt.Sprintf("unsupported field value %T", value))
+		}
+	}
+	return data
+}
+
+// TestContainerInfo validates reading a container's details from the runtime.
+func TestContainerInfo(t *testing.T) {
+	started := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	finished := started.Add(time.Hour)
+
+	exited := message(
+		1, message(
+			1, "abc123",
+			3, int64(2),
+			5, started.UnixNano(),
+			6, finished.UnixNano(),
+			7, int64(137),
+			9, "sha256:feed",
+			10, "OOMKilled",
+			11, "memory limit exceeded",
+		),
+		2, message(1, "info", 2, `{"pid":0,"runtimeSpec":{"process":{"oomScoreAdj":984}}}`),
+	)
+	usage := message(1, message(
+		2, message(2, message(1, int64(5_000_000_000))),
+		3, message(
+			1, finished.UnixNano(),
+			2, message(1, int64(268_435_456)),
+			4, message(1, int64(300_000_000)),
+			5, message(1, int64(250_000_000)),
+			7, message(1, int64(12)),
+		),
+	))
+
+	t.Run("returns status and stats", func(t *testing.T) {
+		runtime := &stubRuntime{statuses: map[string][]byte{"abc123": exited}, stats: map[string][]byte{"abc123": usage}}
+		client, err := NewClient(runtime.serve(t))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer client.Close()
+
+		info, err := client.ContainerInfo(context.Background(), "containerd://abc123")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !runtime.verbose {
+			t.Error("Expected verbose status request")
+		}
+		if info.ID != "abc123" || info.State != "exited" || info.ExitCode != 137 || info.Reason != "OOMKilled" || info.Message != "memory limit exceeded" {
+			t.Errorf("Expected exited container status, got %+v", info)
+		}
+		if !info.StartedAt.Equal(started) || !info.FinishedAt.Equal(finished) || info.ImageRef != "sha256:feed" {
+			t.Errorf("Expected container timestamps and image, got %+v", info)
+		}
+		if info.OOMScoreAdj == nil || *info.OOMScoreAdj != 984 {
+			t.Errorf("Expected oom_score_adj 984, got %v", info.OOMScoreAdj)
+		}
+		if info.Stats == nil {
+			t.Fatal("Expected stats")
+		}
+		expected := ContainerStats{
+			Timestamp:               finished,
+			CPUUsageCoreNanoseconds: 5_000_000_000,
+			MemoryWorkingSetBytes:   268_435_456,
+			MemoryUsageBytes:        300_000_000,
+			MemoryRSSBytes:          250_000_000,
+			MajorPageFaults:         12,
+		}
+		if *info.Stats != expected {
+			t.Errorf("Expected %+v, got %+v", expected, *info.Stats)
+		}
+	})
+
+	t.Run("returns status without stats the runtime dropped", func(t *testing.T) {
+		runtime := &stubRuntime{statuses: map[string][]byte{"abc123": exited}}
+		client, err := NewClient("unix://" + runtime.serve(t))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer client.Close()
+
+		info, err := client.ContainerInfo(context.Background(), "abc123")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if info.Stats != nil || info.ExitCode != 137 {
+			t.Errorf("Expected status without stats, got %+v", info)
+		}
+	})
+
+	t.Run("fails for unknown containers", func(t *testing.T) {
+		runtime := &stubRuntime{}
+		client, err := NewClient(runtime.serve(t))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer client.Close()
+
+		if _, err := client.ContainerInfo(context.Background(), "missing"); err == nil {
+			t.Error("Expected error for an unknown container")
+		}
+	})
+
+	t.Run("fails when the runtime is unavailable", func(t *testing.T) {
+		client, err := NewClient(filepath.Join(t.TempDir(), "missing.sock"))
+		if err != nil {
+			t.Fatalf("Expected lazy connection, got %v", err)
+		}
+		defer client.Close()
+		client.timeout = 100 * time.Millisecond
+
+		if _, err := client.ContainerInfo(context.Background(), "abc123"); err == nil {
+			t.Error("Expected error without a runtime")
+		}
+	})
+
+	t.Run("rejects empty endpoints and IDs", func(t *testing.T) {
+		if _, err := NewClient(""); err == nil {
+			t.Error("Expected error for an empty endpoint")
+		}
+		client, err := NewClient("/run/containerd/containerd.sock")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer client.Close()
+		if _, err := client.ContainerInfo(context.Background(), "containerd://"); err == nil {
+			t.Error("Expected error for an empty container ID")
+		}
+	})
+}
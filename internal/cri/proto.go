@@ -0,0 +1,277 @@
+package cri
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The CRI messages the client exchanges are encoded by hand with protowire,
+// following the runtime.v1 definitions in k8s.io/cri-api, so the daemon does
+// not depend on the generated API. Unknown fields are skipped.
+
+// request is a CRI request message the codec can encode.
+type request interface {
+	// marshal encodes the message
+	marshal() []byte
+}
+
+// response is a CRI response message the codec can decode.
+type response interface {
+	// unmarshal decodes the message
+	unmarshal(data []byte) error
+}
+
+// codec encodes the CRI messages as protocol buffers.
+type codec struct{}
+
+// Marshal encodes a CRI message.
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(request)
+	if !ok {
+		return nil, fmt.Errorf("cannot encode %T as a CRI request", v)
+	}
+	return msg.marshal(), nil
+}
+
+// Unmarshal decodes a CRI message.
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(response)
+	if !ok {
+		return fmt.Errorf("cannot decode %T as a CRI response", v)
+	}
+	return msg.unmarshal(data)
+}
+
+// Name returns the content subtype, so runtimes decode requests as protobuf.
+func (codec) Name() string {
+	return "proto"
+}
+
+// containerStatusRequest is a runtime.v1.ContainerStatusRequest.
+type containerStatusRequest struct {
+	// containerID is field 1
+	containerID string
+	// verbose is field 2, requesting the runtime's extra info
+	verbose bool
+}
+
+// marshal encodes the request.
+func (csr *containerStatusRequest) marshal() []byte {
+	var data []byte
+	data = protowire.AppendTag(data, 1, protowire.BytesType)
+	data = protowire.AppendString(data, csr.containerID)
+	if csr.verbose {
+		data = protowire.AppendTag(data, 2, protowire.VarintType)
+		data = protowire.AppendVarint(data, 1)
+	}
+	return data
+}
+
+// containerStatusResponse is a runtime.v1.ContainerStatusResponse with its
+// ContainerStatus (field 1) flattened.
+type containerStatusResponse struct {
+	// id is ContainerStatus field 1
+	id string
+	// state is ContainerStatus field 3
+	state uint64
+	// startedAt is ContainerStatus field 5, in nanoseconds
+	startedAt int64
+	// finishedAt is ContainerStatus field 6, in nanoseconds
+	finishedAt int64
+	// exitCode is ContainerStatus field 7
+	exitCode int32
+	// imageRef is ContainerStatus field 9
+	imageRef string
+	// reason is ContainerStatus field 10
+	reason string
+	// message is ContainerStatus field 11
+	message string
+	// info is field 2, the runtime's verbose info
+	info map[string]string
+}
+
+// unmarshal decodes the response.
+func (csr *containerStatusResponse) unmarshal(data []byte) error {
+	return eachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return csr.unmarshalStatus(value)
+		case num == 2 && typ == protowire.BytesType:
+			key, val, err := unmarshalMapEntry(value)
+			if err != nil {
+				return err
+			}
+			if csr.info == nil {
+				csr.info = make(map[string]string)
+			}
+			csr.info[key] = val
+		}
+		return nil
+	})
+}
+
+// unmarshalStatus decodes the ContainerStatus.
+func (csr *containerStatusResponse) unmarshalStatus(data []byte) error {
+	return eachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if typ == protowire.VarintType {
+			v, _ := protowire.ConsumeVarint(value)
+			switch num {
+			case 3:
+				csr.state = v
+			case 5:
+				csr.startedAt = int64(v)
+			case 6:
+				csr.finishedAt = int64(v)
+			case 7:
+				csr.exitCode = int32(v)
+			}
+			return nil
+		}
+		if typ == protowire.BytesType {
+			switch num {
+			case 1:
+				csr.id = string(value)
+			case 9:
+				csr.imageRef = string(value)
+			case 10:
+				csr.reason = string(value)
+			case 11:
+				csr.message = string(value)
+			}
+		}
+		return nil
+	})
+}
+
+// containerStatsRequest is a runtime.v1.ContainerStatsRequest.
+type containerStatsRequest struct {
+	// containerID is field 1
+	containerID string
+}
+
+// marshal encodes the request.
+func (csr *containerStatsRequest) marshal() []byte {
+	data := protowire.AppendTag(nil, 1, protowire.BytesType)
+	return protowire.AppendString(data, csr.containerID)
+}
+
+// containerStatsResponse is a runtime.v1.ContainerStatsResponse.
+type containerStatsResponse struct {
+	// stats is field 1 (nil when the runtime reports none)
+	stats *ContainerStats
+}
+
+// unmarshal decodes the response.
+func (csr *containerStatsResponse) unmarshal(data []byte) error {
+	return eachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num != 1 || typ != protowire.BytesType {
+			return nil
+		}
+		csr.stats = &ContainerStats{}
+		return eachField(value, func(num protowire.Number, typ protowire.Type, value []byte) error {
+			if typ != protowire.BytesType {
+				return nil
+			}
+			switch num {
+			case 2:
+				return csr.unmarshalCPU(value)
+			case 3:
+				return csr.unmarshalMemory(value)
+			}
+			return nil
+		})
+	})
+}
+
+// unmarshalCPU decodes a CpuUsage.
+func (csr *containerStatsResponse) unmarshalCPU(data []byte) error {
+	return eachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num == 2 && typ == protowire.BytesType {
+			return unmarshalUInt64Value(value, &csr.stats.CPUUsageCoreNanoseconds)
+		}
+		return nil
+	})
+}
+
+// unmarshalMemory decodes a MemoryUsage.
+func (csr *containerStatsResponse) unmarshalMemory(data []byte) error {
+	fields := map[protowire.Number]*uint64{
+		2: &csr.stats.MemoryWorkingSetBytes,
+		3: &csr.stats.MemoryAvailableBytes,
+		4: &csr.stats.MemoryUsageBytes,
+		5: &csr.stats.MemoryRSSBytes,
+		6: &csr.stats.PageFaults,
+		7: &csr.stats.MajorPageFaults,
+	}
+	return eachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num == 1 && typ == protowire.VarintType {
+			timestamp, _ := protowire.ConsumeVarint(value)
+			csr.stats.Timestamp = unixNano(int64(timestamp))
+			return nil
+		}
+		if field, ok := fields[num]; ok && typ == protowire.BytesType {
+			return unmarshalUInt64Value(value, field)
+		}
+		return nil
+	})
+}
+
+// unmarshalUInt64Value decodes a runtime.v1.UInt64Value into v.
+func unmarshalUInt64Value(data []byte, v *uint64) error {
+	return eachField(data, func(num protowire.Number, typ protowire.Type, value []byte) error {
+		if num == 1 && typ == protowire.VarintType {
+			*v, _ = protowire.ConsumeVarint(value)
+		}
+		return nil
+	})
+}
+
+// unmarshalMapEntry decodes an entry of a map<string, string> field.
+func unmarshalMapEntry(data []byte) (key, value string, err error) {
+	err = eachField(data, func(num protowire.Number, typ protowire.Type, field []byte) error {
+		if typ != protowire.BytesType {
+			return nil
+		}
+		switch num {
+		case 1:
+			key = string(field)
+		case 2:
+			value = string(field)
+		}
+		return nil
+	})
+	return key, value, err
+}
+
+// eachField calls f with each field of an encoded message. Varint fields are
+// passed still encoded, and length-delimited fields as their contents.
+func eachField(data []byte, f func(num protowire.Number, typ protowire.Type, value []byte) error) error {
+	for len(data) > 0 {
+		num, typ, n := protowire.ConsumeTag(data)
+		if n < 0 {
+			return fmt.Errorf("malformed CRI message: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		var value []byte
+		switch typ {
+		case protowire.BytesType:
+			value, n = protowire.ConsumeBytes(data)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, data)
+			if n >= 0 {
+				value = data[:n]
+			}
+		}
+		if n < 0 {
+			return fmt.Errorf("malformed CRI message: %w", protowire.ParseError(n))
+		}
+		data = data[n:]
+
+		if err := f(num, typ, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
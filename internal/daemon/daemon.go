@@ -0,0 +1,225 @@
+// Package daemon runs the daemon's subsystems for the configured mode. The
+// entrypoint constructs the subsystems and hands them to a Daemon, which
+// starts those the mode runs, e.g. no collectors or pod watcher in query mode,
+// and stops them stage by stage on termination.
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/config"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/shutdown"
+)
+
+// Subsystem names, as reported by Running. They are also their shutdown
+// stage names.
+const (
+	// SubsystemAPI is the REST API server, run in every mode
+	SubsystemAPI = shutdown.StageAPI
+	// SubsystemCollectors are the system, process and Prometheus collectors
+	SubsystemCollectors = shutdown.StageCollectors
+	// SubsystemWatcher is the Kubernetes pod watcher
+	SubsystemWatcher = shutdown.StageWatcher
+	// SubsystemMetrics is the Prometheus metrics server, run in every mode
+	SubsystemMetrics = shutdown.StageMetrics
+)
+
+// Runner is a subsystem that runs until its context is cancelled, e.g.
+// telemetry.SystemCollector, k8s.PodWatcher, api.Server or metrics.Collector.
+type Runner interface {
+	Start(ctx context.Context) error
+}
+
+// Components are the constructed subsystems. Nil components are not started.
+type Components struct {
+	// API serves the REST API; in query mode it should be built with
+	// api.WithReadOnly(true)
+	API Runner
+	// Collectors collect node telemetry into the buffer
+	Collectors []Runner
+	// Watcher watches the node's pods for crashes
+	Watcher Runner
+	// Metrics serves the Prometheus metrics
+	Metrics Runner
+
+	// Snapshot writes the final buffer snapshot once intake stopped
+	Snapshot func(ctx context.Context) error
+	// Emitters drains and closes the emitters, e.g. formatter.FormatterChain
+	Emitters io.Closer
+	// Stores are closed after the emitters, e.g. incidentstore.Store and
+	// ringbuffer.DiskSpill
+	Stores []io.Closer
+}
+
+// Daemon starts the subsystems the configured mode runs.
+type Daemon struct {
+	// config selects the mode
+	config *config.Config
+	// components are the constructed subsystems
+	components Components
+
+	// mutex protects running and started
+	mutex sync.Mutex
+	// running holds the started subsystems by name
+	running map[string]*subsystem
+	// started lists the started subsystems in start order
+	started []string
+}
+
+// subsystem is a started subsystem's runners.
+type subsystem struct {
+	// cancel stops the runners
+	cancel context.CancelFunc
+	// done receives each runner's result once it returns
+	done chan error
+	// runners is the number of runners started
+	runners int
+}
+
+// New creates a daemon running the components in the configured mode.
+func New(cfg *config.Config, components Components) *Daemon {
+	return &Daemon{config: cfg, components: components, running: make(map[string]*subsystem)}
+}
+
+// Start starts the subsystems the mode runs, each in its own goroutine until
+// ctx is cancelled or Shutdown stops it. The API and metrics servers run in every mode; collectors
+// and the pod watcher only when config.Subsystems enables them. A subsystem
+// that fails is logged and the others keep running.
+func (d *Daemon) Start(ctx context.Context) {
+	subsystems := d.config.Subsystems()
+
+	d.start(ctx, SubsystemAPI, d.components.API)
+	if subsystems.Collect {
+		d.start(ctx, SubsystemCollectors, d.components.Collectors...)
+	}
+	if subsystems.WatchPods {
+		d.start(ctx, SubsystemWatcher, d.components.Watcher)
+	}
+	d.start(ctx, SubsystemMetrics, d.components.Metrics)
+}
+
+// Running returns the names of the started subsystems in start order.
+func (d *Daemon) Running() []string {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return append([]string(nil), d.started...)
+}
+
+// start runs the non-nil runners under name.
+func (d *Daemon) start(ctx context.Context, name string, runners ...Runner) {
+	var active []Runner
+	for _, runner := range runners {
+		if runner != nil {
+			active = append(active, runner)
+		}
+	}
+	if len(active) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	sub := &subsystem{cancel: cancel, done: make(chan error, len(active)), runners: len(active)}
+	d.mutex.Lock()
+	d.running[name] = sub
+	d.started = append(d.started, name)
+	d.mutex.Unlock()
+
+	fmt.Printf("Starting %s (mode %s)\n", name, d.mode())
+	for _, runner := range active {
+		go func(runner Runner) {
+			err := runner.Start(ctx)
+			if err != nil && !errors.Is(err, context.Canceled) {
+				fmt.Printf("Error: %s stopped: %v\n", name, err)
+			}
+			sub.done <- err
+		}(runner)
+	}
+}
+
+// Shutdown stops the started subsystems and the components holding state in
+// the configured order (by default stop intake, write the buffer snapshot,
+// drain the emitters, close the stores, then stop the metrics server) within
+// the configured grace period, and returns how each stage stopped.
+func (d *Daemon) Shutdown(ctx context.Context) []shutdown.Result {
+	return shutdown.NewSequence(d.config.ShutdownGracePeriod, d.Stages()...).Run(ctx)
+}
+
+// Stages returns the shutdown stages in the configured order. Subsystems
+// that were not started and components left nil have no stage.
+func (d *Daemon) Stages() []shutdown.Stage {
+	var stages []shutdown.Stage
+	d.mutex.Lock()
+	for _, name := range d.started {
+		stages = append(stages, shutdown.Stage{Name: name, Stop: d.running[name].stop})
+	}
+	d.mutex.Unlock()
+
+	if d.components.Snapshot != nil {
+		stages = append(stages, shutdown.Stage{Name: shutdown.StageSnapshot, Stop: d.components.Snapshot})
+	}
+	if d.components.Emitters != nil {
+		stages = append(stages, closeStage(shutdown.StageEmitters, d.components.Emitters))
+	}
+	if len(d.components.Stores) > 0 {
+		stages = append(stages, closeStage(shutdown.StageStores, d.components.Stores...))
+	}
+
+	order := d.config.ShutdownOrder
+	if len(order) == 0 {
+		order = shutdown.DefaultOrder
+	}
+	return shutdown.Ordered(order, stages...)
+}
+
+// stop cancels the subsystem's runners and waits for them to return.
+func (s *subsystem) stop(ctx context.Context) error {
+	s.cancel()
+
+	var errs []string
+	for i := 0; i < s.runners; i++ {
+		select {
+		case err := <-s.done:
+			if err != nil && !errors.Is(err, context.Canceled) {
+				errs = append(errs, err.Error())
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("errors stopping: %s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// closeStage returns a stage closing the closers, skipping nil ones.
+func closeStage(name string, closers ...io.Closer) shutdown.Stage {
+	return shutdown.Stage{Name: name, Stop: func(ctx context.Context) error {
+		var errs []string
+		for _, closer := range closers {
+			if closer == nil {
+				continue
+			}
+			if err := closer.Close(); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("errors closing: %s", strings.Join(errs, "; "))
+		}
+		return nil
+	}}
+}
+
+// mode returns the configured mode, defaulting to agent.
+func (d *Daemon) mode() string {
+	if d.config.Mode == "" {
+		return config.ModeAgent
+	}
+	return d.config.Mode
+}
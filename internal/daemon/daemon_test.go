@@ -0,0 +1,287 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/api"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/config"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/formatter"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/incidentstore"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/k8s"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/metrics"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/ringbuffer"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/shutdown"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
+)
+
+// The daemon's real subsystems are runners and its stateful components closers.
+var (
+	_ Runner    = (*api.Server)(nil)
+	_ Runner    = (*telemetry.SystemCollector)(nil)
+	_ Runner    = (*k8s.PodWatcher)(nil)
+	_ Runner    = (*metrics.Collector)(nil)
+	_ io.Closer = (*formatter.FormatterChain)(nil)
+	_ io.Closer = (*incidentstore.Store)(nil)
+	_ io.Closer = (*ringbuffer.DiskSpill)(nil)
+)
+
+// stopLog records the order components stop in.
+type stopLog struct {
+	mutex   sync.Mutex
+	stopped []string
+}
+
+// record records that the named component stopped.
+func (l *stopLog) record(name string) {
+	l.mutex.Lock()
+	l.stopped = append(l.stopped, name)
+	l.mutex.Unlock()
+}
+
+// order returns the names of the components stopped so far.
+func (l *stopLog) order() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]string(nil), l.stopped...)
+}
+
+// fakeRunner signals when it starts and runs until its context is cancelled.
+type fakeRunner struct {
+	started chan struct{}
+	// name is recorded in log when the runner stops
+	name string
+	log  *stopLog
+	// hang keeps the runner running after cancellation until closed
+	hang chan struct{}
+}
+
+// newFakeRunner creates a fake runner.
+func newFakeRunner() *fakeRunner {
+	return &fakeRunner{started: make(chan struct{}, 1)}
+}
+
+// Start signals the start and blocks until ctx is cancelled.
+func (f *fakeRunner) Start(ctx context.Context) error {
+	f.started <- struct{}{}
+	<-ctx.Done()
+	if f.hang != nil {
+		<-f.hang
+	}
+	if f.log != nil {
+		f.log.record(f.name)
+	}
+	return ctx.Err()
+}
+
+// fakeCloser records when it is closed.
+type fakeCloser struct {
+	name string
+	log  *stopLog
+}
+
+// Close records the close.
+func (f *fakeCloser) Close() error {
+	f.log.record(f.name)
+	return nil
+}
+
+// waitStarted reports whether the runner started within a second.
+func (f *fakeRunner) waitStarted() bool {
+	select {
+	case <-f.started:
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+// TestStartModes validates that each mode starts only its subsystems.
+func TestStartModes(t *testing.T) {
+	tests := []struct {
+		mode     string
+		expected []string
+	}{
+		{"", []string{SubsystemAPI, SubsystemCollectors, SubsystemWatcher, SubsystemMetrics}},
+		{config.ModeAgent, []string{SubsystemAPI, SubsystemCollectors, SubsystemWatcher, SubsystemMetrics}},
+		{config.ModeAggregator, []string{SubsystemAPI, SubsystemMetrics}},
+		{config.ModeQuery, []string{SubsystemAPI, SubsystemMetrics}},
+	}
+
+	for _, tt := range tests {
+		t.Run("mode "+tt.mode, func(t *testing.T) {
+			cfg := config.DefaultConfig()
+			cfg.Mode = tt.mode
+			runners := map[string]*fakeRunner{
+				SubsystemAPI:        newFakeRunner(),
+				SubsystemCollectors: newFakeRunner(),
+				SubsystemWatcher:    newFakeRunner(),
+				SubsystemMetrics:    newFakeRunner(),
+			}
+			d := New(cfg, Components{
+				API:        runners[SubsystemAPI],
+				Collectors: []Runner{runners[SubsystemCollectors]},
+				Watcher:    runners[SubsystemWatcher],
+				Metrics:    runners[SubsystemMetrics],
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			d.Start(ctx)
+
+			if running := strings.Join(d.Running(), ","); running != strings.Join(tt.expected, ",") {
+				t.Fatalf("Expected %v running, got %v", tt.expected, d.Running())
+			}
+			enabled := make(map[string]bool)
+			for _, name := range tt.expected {
+				enabled[name] = true
+				if !runners[name].waitStarted() {
+					t.Errorf("Expected %s to start", name)
+				}
+			}
+			for name, runner := range runners {
+				if enabled[name] {
+					continue
+				}
+				select {
+				case <-runner.started:
+					t.Errorf("Expected %s not to start in mode %q", name, tt.mode)
+				default:
+				}
+			}
+		})
+	}
+}
+
+// TestStartSkipsMissingComponents validates that components left nil, e.g.
+// the watcher outside Kubernetes, are not started.
+func TestStartSkipsMissingComponents(t *testing.T) {
+	server := newFakeRunner()
+	d := New(config.DefaultConfig(), Components{API: server, Collectors: []Runner{nil}})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d.Start(ctx)
+
+	if running := d.Running(); len(running) != 1 || running[0] != SubsystemAPI {
+		t.Errorf("Expected only the API running, got %v", running)
+	}
+	if !server.waitStarted() {
+		t.Error("Expected the API to start")
+	}
+}
+
+// TestShutdown validates stopping the daemon's stages in order within their
+// budgets.
+func TestShutdown(t *testing.T) {
+	// newDaemon returns a daemon whose components record their stop in log.
+	newDaemon := func(cfg *config.Config, log *stopLog) (*Daemon, []*fakeRunner) {
+		runner := func(name string) *fakeRunner {
+			return &fakeRunner{started: make(chan struct{}, 1), name: name, log: log}
+		}
+		system, process := runner(SubsystemCollectors), runner(SubsystemCollectors)
+		apiServer, watcher, metricsServer := runner(SubsystemAPI), runner(SubsystemWatcher), runner(SubsystemMetrics)
+		d := New(cfg, Components{
+			API:        apiServer,
+			Collectors: []Runner{system, process},
+			Watcher:    watcher,
+			Metrics:    metricsServer,
+			Snapshot: func(ctx context.Context) error {
+				log.record(shutdown.StageSnapshot)
+				return nil
+			},
+			Emitters: &fakeCloser{name: shutdown.StageEmitters, log: log},
+			Stores: []io.Closer{
+				&fakeCloser{name: "incident store", log: log},
+				&fakeCloser{name: "disk spill", log: log},
+			},
+		})
+		return d, []*fakeRunner{apiServer, system, process, watcher, metricsServer}
+	}
+
+	t.Run("stops intake, flushes the buffer, drains the emitters, then closes the stores", func(t *testing.T) {
+		log := &stopLog{}
+		d, runners := newDaemon(config.DefaultConfig(), log)
+		d.Start(context.Background())
+		for _, runner := range runners {
+			if !runner.waitStarted() {
+				t.Fatalf("Expected %s to start", runner.name)
+			}
+		}
+
+		results := d.Shutdown(context.Background())
+
+		expected := []string{SubsystemAPI, SubsystemCollectors, SubsystemCollectors, SubsystemWatcher, shutdown.StageSnapshot, shutdown.StageEmitters, "incident store", "disk spill", SubsystemMetrics}
+		if order := log.order(); strings.Join(order, ",") != strings.Join(expected, ",") {
+			t.Errorf("Expected components stopped in order %v, got %v", expected, order)
+		}
+		stages := []string{shutdown.StageAPI, shutdown.StageCollectors, shutdown.StageWatcher, shutdown.StageSnapshot, shutdown.StageEmitters, shutdown.StageStores, shutdown.StageMetrics}
+		if len(results) != len(stages) {
+			t.Fatalf("Expected %d stages, got %v", len(stages), results)
+		}
+		for i, name := range stages {
+			if results[i].Name != name || results[i].Err != nil {
+				t.Errorf("Expected stage %d to be %s stopped cleanly, got %s with %v", i, name, results[i].Name, results[i].Err)
+			}
+		}
+	})
+
+	t.Run("skips subsystems the mode does not run", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.Mode = config.ModeQuery
+		d, _ := newDaemon(cfg, &stopLog{})
+		d.Start(context.Background())
+
+		var names []string
+		for _, stage := range d.Stages() {
+			names = append(names, stage.Name)
+		}
+		expected := []string{shutdown.StageAPI, shutdown.StageSnapshot, shutdown.StageEmitters, shutdown.StageStores, shutdown.StageMetrics}
+		if strings.Join(names, ",") != strings.Join(expected, ",") {
+			t.Errorf("Expected stages %v, got %v", expected, names)
+		}
+		d.Shutdown(context.Background())
+	})
+
+	t.Run("abandons a stage overrunning its budget", func(t *testing.T) {
+		cfg := config.DefaultConfig()
+		cfg.ShutdownGracePeriod = 700 * time.Millisecond
+		log := &stopLog{}
+		d, runners := newDaemon(cfg, log)
+		// The API ignores cancellation, e.g. a request that never finishes
+		hung := runners[0]
+		hung.hang = make(chan struct{})
+		defer close(hung.hang)
+		d.Start(context.Background())
+		for _, runner := range runners {
+			runner.waitStarted()
+		}
+
+		start := time.Now()
+		results := d.Shutdown(context.Background())
+		elapsed := time.Since(start)
+
+		if len(results) != 7 || results[0].Name != shutdown.StageAPI || !results[0].TimedOut {
+			t.Fatalf("Expected the API stage to time out, got %+v", results)
+		}
+		// Seven stages share the grace period, so the API gets a seventh of it
+		if results[0].Duration >= cfg.ShutdownGracePeriod/2 {
+			t.Errorf("Expected the API stage cut off at its share of the grace period, ran %v", results[0].Duration)
+		}
+		for _, result := range results[1:] {
+			if result.TimedOut || result.Err != nil {
+				t.Errorf("Expected %s to stop after the abandoned stage, got %v", result.Name, result.Err)
+			}
+		}
+		if elapsed >= cfg.ShutdownGracePeriod {
+			t.Errorf("Expected shutdown within the %v grace period, took %v", cfg.ShutdownGracePeriod, elapsed)
+		}
+		if order := log.order(); len(order) == 0 || order[len(order)-1] != SubsystemMetrics {
+			t.Errorf("Expected the later stages to still run, got %v", order)
+		}
+	})
+}
@@ -5,9 +5,14 @@ package formatter
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/ringbuffer"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/version"
 	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/emitter"
 	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
 )
@@ -23,6 +28,14 @@ type Formatter interface {
 // telemetry data to be simultaneously output in different formats to different locations.
 type FormatterChain struct {
 	formatters []FormatterConfig
+	// recorder observes each emit (nil records nothing)
+	recorder EmitRecorder
+}
+
+// EmitRecorder records the outcome and duration of each emit, e.g. as
+// Prometheus metrics per emitter. The result is "success" or "failure".
+type EmitRecorder interface {
+	RecordEmit(emitter, result string, duration time.Duration)
 }
 
 // FormatterConfig combines a formatter with its emitters, defining how
@@ -48,6 +61,12 @@ func (fc *FormatterChain) AddFormatter(formatter Formatter, emitters ...emitter.
 	})
 }
 
+// SetEmitRecorder records every emit of the chain with recorder, so operators
+// can alert on emitters failing to deliver.
+func (fc *FormatterChain) SetEmitRecorder(recorder EmitRecorder) {
+	fc.recorder = recorder
+}
+
 // Process runs all formatters in the chain for the given incident, formatting the data
 // with each formatter and emitting to their respective destinations.
 func (fc *FormatterChain) Process(entries []types.TelemetryEntry, incident types.IncidentReport) error {
@@ -58,7 +77,7 @@ func (fc *FormatterChain) Process(entries []types.TelemetryEntry, incident types
 		}
 
 		for _, emit := range config.Emitters {
-			if err := emit.Emit(data); err != nil {
+			if err := fc.emit(emit, data); err != nil {
 				return fmt.Errorf("failed to emit to %s: %w", emit.Name(), err)
 			}
 		}
@@ -66,6 +85,22 @@ func (fc *FormatterChain) Process(entries []types.TelemetryEntry, incident types
 	return nil
 }
 
+// emit emits data and records the outcome with the chain's recorder.
+func (fc *FormatterChain) emit(emit emitter.Emitter, data []byte) error {
+	if fc.recorder == nil {
+		return emit.Emit(data)
+	}
+
+	start := time.Now()
+	err := emit.Emit(data)
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	fc.recorder.RecordEmit(emit.Name(), result, time.Since(start))
+	return err
+}
+
 // Close closes all emitters in the chain, ensuring resources are properly cleaned up.
 func (fc *FormatterChain) Close() error {
 	var errors []string
@@ -82,29 +117,236 @@ func (fc *FormatterChain) Close() error {
 	return nil
 }
 
+// options holds the presentation settings applied by Option.
+type options struct {
+	// timestampLayout is the Go time layout used when rendering timestamps
+	// (empty keeps the formatter's built-in layout)
+	timestampLayout string
+	// timezone is the IANA location timestamps are converted to (empty leaves
+	// timestamps in the location they were recorded in)
+	timezone string
+	// clock supplies generation timestamps
+	clock clock.Clock
+	// templates provides the named templates used by "template:<name>" formatters
+	templates *TemplateSet
+	// valuePrecision is the number of decimal places for floating-point values
+	// (zero keeps full precision)
+	valuePrecision int
+	// excludeRaw drops the raw telemetry listing from the default formatter
+	excludeRaw bool
+	// deltaMetrics are the glob patterns of counters the JSON and CSV
+	// formatters emit as deltas (empty emits absolute values)
+	deltaMetrics []string
+}
+
+// Option configures presentation details shared by the formatters. Without
+// options each formatter keeps its built-in behavior.
+type Option func(*options)
+
+// WithTimestampLayout sets the Go time layout used when rendering timestamps.
+// An empty layout keeps the formatter's built-in layout.
+func WithTimestampLayout(layout string) Option {
+	return func(opts *options) {
+		opts.timestampLayout = layout
+	}
+}
+
+// WithTimezone sets the IANA location name (e.g. "UTC", "Local",
+// "Europe/Berlin") timestamps are converted to before formatting. An empty
+// value leaves timestamps in the location they were recorded in.
+func WithTimezone(name string) Option {
+	return func(opts *options) {
+		opts.timezone = name
+	}
+}
+
+// WithClock sets the clock supplying generation timestamps. Defaults to the system clock.
+func WithClock(c clock.Clock) Option {
+	return func(opts *options) {
+		opts.clock = c
+	}
+}
+
+// WithTemplates provides the named templates used by "template:<name>" formatters.
+func WithTemplates(templates *TemplateSet) Option {
+	return func(opts *options) {
+		opts.templates = templates
+	}
+}
+
+// WithValuePrecision sets the number of decimal places floating-point
+// telemetry values are rendered with by the default and CSV formatters. Zero
+// keeps full precision. Integers are never affected, and JSON output always
+// keeps full precision.
+func WithValuePrecision(precision int) Option {
+	return func(opts *options) {
+		opts.valuePrecision = precision
+	}
+}
+
+// WithExcludeRaw drops the raw telemetry listing from the default formatter,
+// leaving the incident header and telemetry summary (include_raw: false).
+func WithExcludeRaw(exclude bool) Option {
+	return func(opts *options) {
+		opts.excludeRaw = exclude
+	}
+}
+
+// WithDeltaMetrics makes the JSON and CSV formatters emit counters whose name
+// matches one of the glob patterns, e.g. "*_total", as the difference from
+// the value they previously emitted for the same name and tags, marked with
+// "delta" in the entry metadata (JSON) or the delta column (CSV). Each
+// formatter tracks its own previous values. Absolute values are the default.
+func WithDeltaMetrics(patterns []string) Option {
+	return func(opts *options) {
+		opts.deltaMetrics = patterns
+	}
+}
+
+// deltaEncoder returns an encoder for the configured delta metrics, or nil
+// when values are emitted absolute.
+func (o options) deltaEncoder() *telemetry.DeltaEncoder {
+	if len(o.deltaMetrics) == 0 {
+		return nil
+	}
+	return telemetry.NewDeltaEncoder(o.deltaMetrics)
+}
+
+// newOptions applies the options over the defaults.
+func newOptions(opts []Option) options {
+	o := options{clock: clock.Real{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// summaryTopMetrics is the number of metrics listed by last value in the
+// default formatter's telemetry summary.
+const summaryTopMetrics = 5
+
+// formatValue renders a telemetry value, rounding floating-point values to the
+// given number of decimal places when precision is positive.
+func formatValue(value interface{}, precision int) string {
+	if precision > 0 {
+		switch v := value.(type) {
+		case float64:
+			return strconv.FormatFloat(v, 'f', precision, 64)
+		case float32:
+			return strconv.FormatFloat(float64(v), 'f', precision, 32)
+		case json.Number:
+			// Integers stay exact; only fractional numbers are rounded
+			if strings.ContainsAny(v.String(), ".eE") {
+				if f, err := v.Float64(); err == nil {
+					return strconv.FormatFloat(f, 'f', precision, 64)
+				}
+			}
+		}
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// timestampFormat renders timestamps using an optional layout and location,
+// falling back to the formatter's built-in layout when none is configured.
+type timestampFormat struct {
+	layout   string
+	location *time.Location
+}
+
+// newTimestampFormat validates the layout and timezone from the options and
+// returns the resulting timestamp format.
+func newTimestampFormat(opts options) (timestampFormat, error) {
+	var tf timestampFormat
+
+	if opts.timestampLayout != "" {
+		if err := ValidateTimestampLayout(opts.timestampLayout); err != nil {
+			return tf, err
+		}
+		tf.layout = opts.timestampLayout
+	}
+
+	if opts.timezone != "" {
+		location, err := time.LoadLocation(opts.timezone)
+		if err != nil {
+			return tf, fmt.Errorf("invalid timezone %q: %w", opts.timezone, err)
+		}
+		tf.location = location
+	}
+
+	return tf, nil
+}
+
+// format renders the timestamp with the configured layout, or the fallback
+// layout when no layout has been configured.
+func (tf timestampFormat) format(t time.Time, fallback string) string {
+	if tf.location != nil {
+		t = t.In(tf.location)
+	}
+	if tf.layout != "" {
+		return t.Format(tf.layout)
+	}
+	return t.Format(fallback)
+}
+
+// ValidateTimestampLayout checks that a Go time layout contains at least one
+// time element and produces output that can be parsed back with the same layout.
+func ValidateTimestampLayout(layout string) error {
+	// Every element renders differently at this time than it reads in a
+	// layout, so output equal to the layout means it is only literal text
+	sample := time.Date(2019, time.November, 28, 21, 13, 59, 987654321, time.UTC)
+	formatted := sample.Format(layout)
+	if formatted == layout {
+		return fmt.Errorf("invalid timestamp layout %q: no time elements", layout)
+	}
+	if _, err := time.Parse(layout, formatted); err != nil {
+		return fmt.Errorf("invalid timestamp layout %q: %w", layout, err)
+	}
+	return nil
+}
+
 // DefaultFormatter implements the default "DATE : TIME | TELEMETRY ITEM NAME | VALUE" format
 // with a human-readable incident report header.
-type DefaultFormatter struct{}
+type DefaultFormatter struct {
+	timestamps timestampFormat
+	precision  int
+	excludeRaw bool
+}
 
 // NewDefaultFormatter creates a new default formatter instance.
 func NewDefaultFormatter() *DefaultFormatter {
 	return &DefaultFormatter{}
 }
 
+// NewDefaultFormatterWithOptions creates a default formatter using the timestamp
+// layout, timezone, value precision and raw listing options. Returns an error if the
+// layout or timezone is invalid.
+func NewDefaultFormatterWithOptions(opts ...Option) (*DefaultFormatter, error) {
+	o := newOptions(opts)
+	timestamps, err := newTimestampFormat(o)
+	if err != nil {
+		return nil, err
+	}
+	return &DefaultFormatter{timestamps: timestamps, precision: o.valuePrecision, excludeRaw: o.excludeRaw}, nil
+}
+
 // Name returns the formatter name for identification and logging.
 func (df *DefaultFormatter) Name() string {
 	return "default"
 }
 
 // Format formats telemetry entries using the default human-readable format with
-// an incident report header followed by timestamped telemetry entries.
+// an incident report header, a telemetry summary and, unless excluded, the
+// timestamped telemetry entries.
 func (df *DefaultFormatter) Format(entries []types.TelemetryEntry, incident types.IncidentReport) ([]byte, error) {
 	var output strings.Builder
 
 	// Write incident header
 	output.WriteString(fmt.Sprintf("=== INCIDENT REPORT ===\n"))
 	output.WriteString(fmt.Sprintf("ID: %s\n", incident.ID))
-	output.WriteString(fmt.Sprintf("TIMESTAMP: %s\n", incident.Timestamp.Format("2006-01-02 15:04:05.000")))
+	if schemaVersion := version.SchemaVersion(incident.Context); schemaVersion > 0 {
+		output.WriteString(fmt.Sprintf("SCHEMA VERSION: %d\n", schemaVersion))
+	}
+	output.WriteString(fmt.Sprintf("TIMESTAMP: %s\n", df.timestamps.format(incident.Timestamp, "2006-01-02 15:04:05.000")))
 	output.WriteString(fmt.Sprintf("SEVERITY: %s\n", incident.Severity))
 	output.WriteString(fmt.Sprintf("TYPE: %s\n", incident.Type))
 	output.WriteString(fmt.Sprintf("MESSAGE: %s\n", incident.Message))
@@ -113,23 +355,60 @@ func (df *DefaultFormatter) Format(entries []types.TelemetryEntry, incident type
 	}
 	output.WriteString("\n")
 
+	df.writeSummary(&output, ringbuffer.Aggregate(entries))
+	if df.excludeRaw {
+		return []byte(output.String()), nil
+	}
+
 	// Write telemetry data
 	output.WriteString("=== TELEMETRY DATA ===\n")
 	for _, entry := range entries {
-		dateTime := entry.Timestamp.Format("2006-01-02 : 15:04:05.000")
-		output.WriteString(fmt.Sprintf("%s | %s | %v\n", dateTime, entry.Name, entry.Value))
+		dateTime := df.timestamps.format(entry.Timestamp, "2006-01-02 : 15:04:05.000")
+		output.WriteString(fmt.Sprintf("%s | %s | %s\n", dateTime, entry.Name, formatValue(entry.Value, df.precision)))
 	}
 
 	return []byte(output.String()), nil
 }
 
+// writeSummary writes the entry count, distinct metrics, time span and the
+// metrics with the highest last values.
+func (df *DefaultFormatter) writeSummary(output *strings.Builder, summary ringbuffer.Summary) {
+	output.WriteString("=== TELEMETRY SUMMARY ===\n")
+	output.WriteString(fmt.Sprintf("ENTRIES: %d\n", summary.Count))
+	output.WriteString(fmt.Sprintf("METRICS: %d\n", len(summary.Metrics)))
+	if summary.Count > 0 {
+		output.WriteString(fmt.Sprintf("SPAN: %s - %s (%s)\n",
+			df.timestamps.format(summary.Start, "2006-01-02 15:04:05.000"),
+			df.timestamps.format(summary.End, "2006-01-02 15:04:05.000"),
+			summary.Span()))
+	}
+	if top := summary.TopByLast(summaryTopMetrics); len(top) > 0 {
+		output.WriteString("TOP METRICS BY LAST VALUE:\n")
+		for _, metric := range top {
+			output.WriteString(fmt.Sprintf("  %s: %s\n", metric.Name, formatValue(metric.Last, df.precision)))
+		}
+	}
+	output.WriteString("\n")
+}
+
 // JSONFormatter formats output as structured JSON for machine consumption
 // and integration with logging systems.
-type JSONFormatter struct{}
+type JSONFormatter struct {
+	clock clock.Clock
+	// deltas encodes counters as deltas (nil emits absolute values)
+	deltas *telemetry.DeltaEncoder
+}
 
 // NewJSONFormatter creates a new JSON formatter instance.
 func NewJSONFormatter() *JSONFormatter {
-	return &JSONFormatter{}
+	return &JSONFormatter{clock: clock.Real{}}
+}
+
+// NewJSONFormatterWithOptions creates a JSON formatter using the clock and
+// delta metrics options.
+func NewJSONFormatterWithOptions(opts ...Option) *JSONFormatter {
+	o := newOptions(opts)
+	return &JSONFormatter{clock: o.clock, deltas: o.deltaEncoder()}
 }
 
 // Name returns the formatter name for identification and logging.
@@ -140,35 +419,62 @@ func (jf *JSONFormatter) Name() string {
 // Format formats the incident and telemetry data as structured JSON with
 // a generation timestamp for audit purposes.
 func (jf *JSONFormatter) Format(entries []types.TelemetryEntry, incident types.IncidentReport) ([]byte, error) {
+	if jf.deltas != nil {
+		entries = jf.deltas.Encode(entries)
+	}
 	output := map[string]interface{}{
-		"incident":     incident,
-		"telemetry":    entries,
-		"generated_at": time.Now(),
+		"schema_version": version.SchemaVersion(incident.Context),
+		"incident":       incident,
+		"telemetry":      entries,
+		"generated_at":   jf.clock.Now(),
 	}
 
 	return json.MarshalIndent(output, "", "  ")
 }
 
 // CSVFormatter formats telemetry as CSV for data analysis and spreadsheet import.
-type CSVFormatter struct{}
+type CSVFormatter struct {
+	timestamps timestampFormat
+	precision  int
+	// deltas encodes counters as deltas and adds the delta column (nil emits absolute values)
+	deltas *telemetry.DeltaEncoder
+}
 
 // NewCSVFormatter creates a new CSV formatter instance.
 func NewCSVFormatter() *CSVFormatter {
 	return &CSVFormatter{}
 }
 
+// NewCSVFormatterWithOptions creates a CSV formatter using the timestamp layout,
+// timezone, value precision and delta metrics from the options. Returns an error if the layout
+// or timezone is invalid.
+func NewCSVFormatterWithOptions(opts ...Option) (*CSVFormatter, error) {
+	o := newOptions(opts)
+	timestamps, err := newTimestampFormat(o)
+	if err != nil {
+		return nil, err
+	}
+	return &CSVFormatter{timestamps: timestamps, precision: o.valuePrecision, deltas: o.deltaEncoder()}, nil
+}
+
 // Name returns the formatter name for identification and logging.
 func (cf *CSVFormatter) Name() string {
 	return "csv"
 }
 
 // Format formats telemetry entries as CSV with headers and properly escaped values,
-// including tags as semicolon-separated key=value pairs.
+// including tags as semicolon-separated key=value pairs. With delta metrics a
+// trailing delta column reports whether each value is a delta.
 func (cf *CSVFormatter) Format(entries []types.TelemetryEntry, incident types.IncidentReport) ([]byte, error) {
 	var output strings.Builder
 
 	// CSV header
-	output.WriteString("timestamp,source,type,name,value,tags,incident_id\n")
+	if cf.deltas != nil {
+		entries = cf.deltas.Encode(entries)
+		output.WriteString("timestamp,source,type,name,value,tags,incident_id,delta\n")
+	} else {
+		output.WriteString("timestamp,source,type,name,value,tags,incident_id\n")
+	}
 
 	// CSV data
 	for _, entry := range entries {
@@ -181,15 +487,19 @@ func (cf *CSVFormatter) Format(entries []types.TelemetryEntry, incident types.In
 			tags = strings.Join(tagPairs, ";")
 		}
 
-		output.WriteString(fmt.Sprintf("%s,%s,%s,%s,%v,\"%s\",%s\n",
-			entry.Timestamp.Format("2006-01-02T15:04:05.000Z"),
+		output.WriteString(fmt.Sprintf("%s,%s,%s,%s,%s,\"%s\",%s",
+			cf.timestamps.format(entry.Timestamp, "2006-01-02T15:04:05.000Z"),
 			entry.Source,
 			entry.Type,
 			entry.Name,
-			entry.Value,
+			formatValue(entry.Value, cf.precision),
 			tags,
 			incident.ID,
 		))
+		if cf.deltas != nil {
+			output.WriteString("," + strconv.FormatBool(telemetry.IsDelta(entry)))
+		}
+		output.WriteString("\n")
 	}
 
 	return []byte(output.String()), nil
@@ -199,12 +509,18 @@ func (cf *CSVFormatter) Format(entries []types.TelemetryEntry, incident types.In
 
 // CreateFormatterChain creates a formatter chain from configuration strings and emitter configs
 func CreateFormatterChain(formatters []string, emitterConfigs []emitter.EmitterConfig) (*FormatterChain, error) {
+	return CreateFormatterChainWithOptions(formatters, emitterConfigs)
+}
+
+// CreateFormatterChainWithOptions creates a formatter chain like CreateFormatterChain,
+// applying the given options to the formatters that support them.
+func CreateFormatterChainWithOptions(formatters []string, emitterConfigs []emitter.EmitterConfig, opts ...Option) (*FormatterChain, error) {
 	chain := NewFormatterChain()
 	
 	// Create emitters from configuration
 	var emitters []emitter.Emitter
 	for _, config := range emitterConfigs {
-		emit, err := emitter.CreateEmitter(config)
+		emit, err := emitter.NewFromConfig(config)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create emitter: %w", err)
 		}
@@ -213,17 +529,25 @@ func CreateFormatterChain(formatters []string, emitterConfigs []emitter.EmitterC
 
 	for _, formatterName := range formatters {
 		var formatter Formatter
+		var err error
 
 		// Create formatter
 		switch strings.ToLower(formatterName) {
 		case "default":
-			formatter = NewDefaultFormatter()
+			formatter, err = NewDefaultFormatterWithOptions(opts...)
 		case "json":
-			formatter = NewJSONFormatter()
+			formatter = NewJSONFormatterWithOptions(opts...)
 		case "csv":
-			formatter = NewCSVFormatter()
+			formatter, err = NewCSVFormatterWithOptions(opts...)
 		default:
-			return nil, fmt.Errorf("unknown formatter: %s", formatterName)
+			name, ok := strings.CutPrefix(formatterName, TemplateFormatterPrefix)
+			if !ok {
+				return nil, fmt.Errorf("unknown formatter: %s", formatterName)
+			}
+			formatter, err = NewTemplateFormatter(newOptions(opts).templates, name, opts...)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("formatter %s: %w", formatterName, err)
 		}
 
 		// Add formatter with all configured emitters
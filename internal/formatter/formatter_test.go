@@ -1,8 +1,17 @@
 package formatter
 
 import (
+"encoding/json"
+"errors"
+"fmt"
+"strings"
 "testing"
+"time"
+
+"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/version"
 "github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/emitter"
+"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
 )
 
 func TestNewFormatterChain(t *testing.T) {
@@ -57,3 +66,406 @@ if len(chain.formatters) != 1 {
 t.Errorf("Expected 1 formatter, got %d", len(chain.formatters))
 }
 }
+
+// testIncidentAndEntries returns a fixed incident and telemetry entry for formatter output tests.
+func testIncidentAndEntries() (types.IncidentReport, []types.TelemetryEntry) {
+	timestamp := time.Date(2024, time.November, 2, 15, 4, 5, 123000000, time.UTC)
+	incident := types.IncidentReport{
+		ID:        "incident-1",
+		Timestamp: timestamp,
+		Severity:  types.SeverityHigh,
+		Type:      types.IncidentCrash,
+		Message:   "test incident",
+	}
+	entries := []types.TelemetryEntry{
+		{
+			Timestamp: timestamp,
+			Source:    types.SourceSystem,
+			Type:      types.TypeCPU,
+			Name:      "cpu_usage_percent",
+			Value:     42,
+		},
+	}
+	return incident, entries
+}
+
+// TestFormatterTimestampLayout validates configurable timestamp layouts and timezones.
+func TestFormatterTimestampLayout(t *testing.T) {
+	incident, entries := testIncidentAndEntries()
+
+	t.Run("default formatter keeps built-in layout", func(t *testing.T) {
+		output, err := NewDefaultFormatter().Format(entries, incident)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !strings.Contains(string(output), "2024-11-02 : 15:04:05.123 | cpu_usage_percent | 42") {
+			t.Errorf("Expected built-in layout in output, got:\n%s", output)
+		}
+	})
+
+	t.Run("default formatter uses RFC3339", func(t *testing.T) {
+		formatter, err := NewDefaultFormatterWithOptions(WithTimestampLayout(time.RFC3339))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		output, err := formatter.Format(entries, incident)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !strings.Contains(string(output), "TIMESTAMP: 2024-11-02T15:04:05Z\n") {
+			t.Errorf("Expected RFC3339 incident timestamp, got:\n%s", output)
+		}
+		if !strings.Contains(string(output), "2024-11-02T15:04:05Z | cpu_usage_percent | 42") {
+			t.Errorf("Expected RFC3339 telemetry timestamp, got:\n%s", output)
+		}
+	})
+
+	t.Run("csv formatter uses custom layout and timezone", func(t *testing.T) {
+		formatter, err := NewCSVFormatterWithOptions(
+			WithTimestampLayout("02/01/2006 15:04 MST"),
+			WithTimezone("UTC"),
+		)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		output, err := formatter.Format(entries, incident)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !strings.Contains(string(output), "\n02/11/2024 15:04 UTC,system,cpu,cpu_usage_percent,42,") {
+			t.Errorf("Expected custom layout in CSV output, got:\n%s", output)
+		}
+	})
+
+	t.Run("converts to configured timezone", func(t *testing.T) {
+		formatter, err := NewCSVFormatterWithOptions(
+			WithTimestampLayout(time.RFC3339),
+			WithTimezone("UTC"),
+		)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		local := incident.Timestamp.In(time.FixedZone("UTC+2", 2*60*60))
+		shifted := []types.TelemetryEntry{entries[0]}
+		shifted[0].Timestamp = local
+		output, err := formatter.Format(shifted, incident)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if !strings.Contains(string(output), "2024-11-02T15:04:05Z,") {
+			t.Errorf("Expected timestamp converted to UTC, got:\n%s", output)
+		}
+	})
+
+	t.Run("rejects layout without time elements", func(t *testing.T) {
+		if _, err := NewDefaultFormatterWithOptions(WithTimestampLayout("not a layout")); err == nil {
+			t.Error("Expected error for layout without time elements")
+		}
+	})
+
+	t.Run("rejects unknown timezone", func(t *testing.T) {
+		if _, err := NewCSVFormatterWithOptions(WithTimezone("Not/AZone")); err == nil {
+			t.Error("Expected error for unknown timezone")
+		}
+	})
+
+	t.Run("chain rejects invalid layout", func(t *testing.T) {
+		_, err := CreateFormatterChainWithOptions([]string{"default"}, nil, WithTimestampLayout("nope"))
+		if err == nil {
+			t.Error("Expected error creating chain with invalid layout")
+		}
+	})
+}
+
+// TestValidateTimestampLayout validates detecting layouts without time elements.
+func TestValidateTimestampLayout(t *testing.T) {
+	tests := []struct {
+		layout string
+		valid  bool
+	}{
+		{"2006-01-02 : 15:04:05.000", true},
+		{"2006-01-02T15:04:05.000Z", true},
+		{"2006-01-02 15:04:05", true},
+		{"15:04:05", true},
+		{"2006", true},
+		{time.RFC3339, true},
+		{"02/01/2006 15:04 MST", true},
+		{"Mon Jan 2", true},
+		{"not a layout", false},
+		{"1999-12-31", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			err := ValidateTimestampLayout(tt.layout)
+			if tt.valid && err != nil {
+				t.Errorf("Expected %q to be valid, got %v", tt.layout, err)
+			}
+			if !tt.valid && err == nil {
+				t.Errorf("Expected %q to be rejected", tt.layout)
+			}
+		})
+	}
+}
+
+// TestJSONFormatterClock validates that the generation timestamp comes from the injected clock.
+func TestJSONFormatterClock(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	incident, entries := testIncidentAndEntries()
+
+	output, err := NewJSONFormatterWithOptions(WithClock(clock.NewFake(now))).Format(entries, incident)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var decoded struct {
+		GeneratedAt time.Time `json:"generated_at"`
+	}
+	if err := json.Unmarshal(output, &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got %v", err)
+	}
+	if !decoded.GeneratedAt.Equal(now) {
+		t.Errorf("Expected generated_at %v, got %v", now, decoded.GeneratedAt)
+	}
+}
+
+// TestFormatterSchemaVersion validates reporting the incident schema version.
+func TestFormatterSchemaVersion(t *testing.T) {
+	incident, entries := testIncidentAndEntries()
+	incident.Context = version.Tag(nil)
+
+	t.Run("json formatter", func(t *testing.T) {
+		output, _ := NewJSONFormatter().Format(entries, incident)
+
+		var decoded struct {
+			SchemaVersion int                  `json:"schema_version"`
+			Incident      types.IncidentReport `json:"incident"`
+		}
+		if err := json.Unmarshal(output, &decoded); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		if decoded.SchemaVersion != version.IncidentSchemaVersion || version.SchemaVersion(decoded.Incident.Context) != version.IncidentSchemaVersion {
+			t.Errorf("Expected schema version %d, got %d", version.IncidentSchemaVersion, decoded.SchemaVersion)
+		}
+	})
+
+	t.Run("default formatter", func(t *testing.T) {
+		output, _ := NewDefaultFormatter().Format(entries, incident)
+		if !strings.Contains(string(output), fmt.Sprintf("SCHEMA VERSION: %d\n", version.IncidentSchemaVersion)) {
+			t.Errorf("Expected the schema version in the header, got:\n%s", output)
+		}
+	})
+}
+
+// TestFormatterValuePrecision validates rounding of floating-point telemetry values.
+func TestFormatterValuePrecision(t *testing.T) {
+	incident, _ := testIncidentAndEntries()
+	at := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	entries := []types.TelemetryEntry{
+		{Timestamp: at, Source: types.SourceSystem, Type: types.TypeCPU, Name: "cpu_usage_percent", Value: 75.5},
+		{Timestamp: at, Source: types.SourceSystem, Type: types.TypeMemory, Name: "memory_used_percent", Value: 75.49999999999},
+		{Timestamp: at, Source: types.SourceSystem, Type: types.TypeNetwork, Name: "network_rx_bytes_eth0", Value: uint64(1234567)},
+		{Timestamp: at, Source: types.SourceSystem, Type: types.TypeProcess, Name: "process_open_fds", Value: 42},
+	}
+	precision := WithValuePrecision(2)
+
+	t.Run("default formatter rounds floats", func(t *testing.T) {
+		formatter, _ := NewDefaultFormatterWithOptions(precision)
+		output, _ := formatter.Format(entries, incident)
+
+		for _, expected := range []string{"| cpu_usage_percent | 75.50\n", "| memory_used_percent | 75.50\n", "| network_rx_bytes_eth0 | 1234567\n", "| process_open_fds | 42\n"} {
+			if !strings.Contains(string(output), expected) {
+				t.Errorf("Expected %q in output, got:\n%s", expected, output)
+			}
+		}
+	})
+
+	t.Run("csv formatter rounds floats", func(t *testing.T) {
+		formatter, _ := NewCSVFormatterWithOptions(precision)
+		output, _ := formatter.Format(entries, incident)
+
+		for _, expected := range []string{",cpu_usage_percent,75.50,", ",network_rx_bytes_eth0,1234567,", ",process_open_fds,42,"} {
+			if !strings.Contains(string(output), expected) {
+				t.Errorf("Expected %q in output, got:\n%s", expected, output)
+			}
+		}
+	})
+
+	t.Run("keeps full precision by default and in JSON", func(t *testing.T) {
+		output, _ := NewDefaultFormatter().Format(entries, incident)
+		if !strings.Contains(string(output), "| memory_used_percent | 75.49999999999\n") {
+			t.Errorf("Expected full precision without configuration, got:\n%s", output)
+		}
+
+		output, _ = NewJSONFormatterWithOptions(precision).Format(entries, incident)
+		if !strings.Contains(string(output), "75.49999999999") {
+			t.Errorf("Expected full precision in JSON, got:\n%s", output)
+		}
+	})
+
+	t.Run("rounds fractional sidecar numbers and keeps integers exact", func(t *testing.T) {
+		sidecar := []types.TelemetryEntry{
+			{Timestamp: at, Source: types.SourceSidecar, Type: types.TypeCustom, Name: "heap_ratio", Value: json.Number("0.256")},
+			{Timestamp: at, Source: types.SourceSidecar, Type: types.TypeCustom, Name: "net_bytes_total", Value: json.Number("9007199254740993")},
+		}
+		formatter, _ := NewCSVFormatterWithOptions(precision)
+		output, _ := formatter.Format(sidecar, incident)
+
+		for _, expected := range []string{",heap_ratio,0.26,", ",net_bytes_total,9007199254740993,"} {
+			if !strings.Contains(string(output), expected) {
+				t.Errorf("Expected %q in output, got:\n%s", expected, output)
+			}
+		}
+	})
+
+	t.Run("chain passes precision to formatters", func(t *testing.T) {
+		chain, err := CreateFormatterChainWithOptions([]string{"csv"}, nil, precision)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		output, _ := chain.formatters[0].Formatter.Format(entries[:1], incident)
+		if !strings.Contains(string(output), ",75.50,") {
+			t.Errorf("Expected chained formatter to round, got:\n%s", output)
+		}
+	})
+}
+
+// TestFormatterDeltaMetrics validates emitting counters as deltas between outputs.
+func TestFormatterDeltaMetrics(t *testing.T) {
+	incident, _ := testIncidentAndEntries()
+	at := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	entries := []types.TelemetryEntry{
+		{Timestamp: at, Source: types.SourceSystem, Type: types.TypeNetwork, Name: "network_rx_bytes_total", Value: 1000},
+		{Timestamp: at, Source: types.SourceSystem, Type: types.TypeCPU, Name: "cpu_usage_percent", Value: 75.5},
+		{Timestamp: at.Add(time.Second), Source: types.SourceSystem, Type: types.TypeNetwork, Name: "network_rx_bytes_total", Value: 1600},
+	}
+	deltas := WithDeltaMetrics([]string{"*_total"})
+
+	t.Run("csv formatter adds a delta column", func(t *testing.T) {
+		formatter, _ := NewCSVFormatterWithOptions(deltas)
+		output, _ := formatter.Format(entries, incident)
+
+		for _, expected := range []string{"incident_id,delta\n", ",network_rx_bytes_total,1000,\"\",incident-1,false\n", ",cpu_usage_percent,75.5,\"\",incident-1,false\n", ",network_rx_bytes_total,600,\"\",incident-1,true\n"} {
+			if !strings.Contains(string(output), expected) {
+				t.Errorf("Expected %q in output, got:\n%s", expected, output)
+			}
+		}
+
+		next := []types.TelemetryEntry{{Timestamp: at.Add(2 * time.Second), Source: types.SourceSystem, Type: types.TypeNetwork, Name: "network_rx_bytes_total", Value: 1750}}
+		output, _ = formatter.Format(next, incident)
+		if !strings.Contains(string(output), ",network_rx_bytes_total,150,") {
+			t.Errorf("Expected the delta from the previous output, got:\n%s", output)
+		}
+	})
+
+	t.Run("json formatter marks deltas in metadata", func(t *testing.T) {
+		output, _ := NewJSONFormatterWithOptions(deltas).Format(entries, incident)
+
+		var decoded struct {
+			Telemetry []types.TelemetryEntry `json:"telemetry"`
+		}
+		if err := json.Unmarshal(output, &decoded); err != nil {
+			t.Fatalf("Expected valid JSON, got %v", err)
+		}
+		last := decoded.Telemetry[2]
+		if last.Value != 600.0 || last.Metadata["delta"] != true {
+			t.Errorf("Expected a delta of 600 marked in metadata, got %+v", last)
+		}
+		if decoded.Telemetry[0].Metadata != nil {
+			t.Errorf("Expected the first value absolute, got %+v", decoded.Telemetry[0])
+		}
+	})
+
+	t.Run("keeps absolute values by default", func(t *testing.T) {
+		output, _ := NewCSVFormatter().Format(entries, incident)
+		if strings.Contains(string(output), "delta") || !strings.Contains(string(output), ",network_rx_bytes_total,1600,") {
+			t.Errorf("Expected absolute values without a delta column, got:\n%s", output)
+		}
+	})
+}
+
+// TestDefaultFormatterSummary validates the telemetry summary of the default formatter.
+func TestDefaultFormatterSummary(t *testing.T) {
+	incident, _ := testIncidentAndEntries()
+	at := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	entries := []types.TelemetryEntry{
+		{Timestamp: at, Source: types.SourceSystem, Type: types.TypeCPU, Name: "cpu_usage_percent", Value: 42.0},
+		{Timestamp: at.Add(30 * time.Second), Source: types.SourceSystem, Type: types.TypeCPU, Name: "cpu_usage_percent", Value: 97.5},
+		{Timestamp: at.Add(45 * time.Second), Source: types.SourceSystem, Type: types.TypeProcess, Name: "process_open_fds", Value: 42},
+	}
+
+	t.Run("summarizes entries before the raw listing", func(t *testing.T) {
+		output, _ := NewDefaultFormatter().Format(entries, incident)
+		text := string(output)
+
+		for _, expected := range []string{
+			"ENTRIES: 3\n",
+			"METRICS: 2\n",
+			"SPAN: 2024-11-02 15:04:05.000 - 2024-11-02 15:04:50.000 (45s)\n",
+			"TOP METRICS BY LAST VALUE:\n  cpu_usage_percent: 97.5\n  process_open_fds: 42\n",
+		} {
+			if !strings.Contains(text, expected) {
+				t.Errorf("Expected %q in output, got:\n%s", expected, text)
+			}
+		}
+		if strings.Index(text, "=== TELEMETRY SUMMARY ===") > strings.Index(text, "=== TELEMETRY DATA ===") {
+			t.Errorf("Expected summary before raw telemetry, got:\n%s", text)
+		}
+	})
+
+	t.Run("omits raw listing when excluded", func(t *testing.T) {
+		formatter, _ := NewDefaultFormatterWithOptions(WithExcludeRaw(true))
+		output, _ := formatter.Format(entries, incident)
+
+		if !strings.Contains(string(output), "ENTRIES: 3\n") {
+			t.Errorf("Expected summary, got:\n%s", output)
+		}
+		if strings.Contains(string(output), "=== TELEMETRY DATA ===") {
+			t.Errorf("Expected no raw telemetry, got:\n%s", output)
+		}
+	})
+}
+
+// failingEmitter is an emitter whose Emit always fails.
+type failingEmitter struct{}
+
+func (failingEmitter) Emit(data []byte) error { return errors.New("connection refused") }
+func (failingEmitter) Name() string           { return "webhook" }
+func (failingEmitter) Close() error           { return nil }
+
+// acceptingEmitter is an emitter whose Emit always succeeds.
+type acceptingEmitter struct{}
+
+func (acceptingEmitter) Emit(data []byte) error { return nil }
+func (acceptingEmitter) Name() string           { return "stdout" }
+func (acceptingEmitter) Close() error           { return nil }
+
+// countingRecorder counts recorded emits by emitter and result.
+type countingRecorder map[string]int
+
+func (cr countingRecorder) RecordEmit(emitter, result string, duration time.Duration) {
+	cr[emitter+"/"+result]++
+}
+
+// TestEmitRecorder validates recording the outcome of each emit.
+func TestEmitRecorder(t *testing.T) {
+	incident, entries := testIncidentAndEntries()
+	recorder := countingRecorder{}
+	chain := NewFormatterChain()
+	chain.AddFormatter(NewJSONFormatter(), acceptingEmitter{}, failingEmitter{})
+	chain.SetEmitRecorder(recorder)
+
+	for i := 0; i < 2; i++ {
+		if err := chain.Process(entries, incident); err == nil || !strings.Contains(err.Error(), "webhook") {
+			t.Errorf("Expected the emit failure to be returned, got %v", err)
+		}
+	}
+
+	if recorder["webhook/failure"] != 2 {
+		t.Errorf("Expected 2 failures for webhook, got %d", recorder["webhook/failure"])
+	}
+	if recorder["stdout/success"] != 2 {
+		t.Errorf("Expected 2 successes for stdout, got %d", recorder["stdout/success"])
+	}
+}
@@ -0,0 +1,210 @@
+package formatter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/version"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TemplateExtension is the file extension of templates loaded from a template
+// directory. The template name is the file name without the extension.
+const TemplateExtension = ".tmpl"
+
+// TemplateFormatterPrefix selects a named template in formatter configuration,
+// e.g. "template:slack" uses slack.tmpl from the template directory.
+const TemplateFormatterPrefix = "template:"
+
+// TemplateData is the value templates are executed against.
+type TemplateData struct {
+	// Incident is the incident being reported
+	Incident types.IncidentReport
+	// SchemaVersion is the incident's schema version (0 for untagged incidents)
+	SchemaVersion int
+	// Telemetry is the telemetry captured for the incident
+	Telemetry []types.TelemetryEntry
+	// Timestamp is when the output was generated
+	Timestamp time.Time
+}
+
+// templateFile records the state of a template file when it was last loaded.
+type templateFile struct {
+	modTime time.Time
+	size    int64
+}
+
+// TemplateSet holds named templates loaded from a directory and reloads them
+// when the files change. A template that fails to parse on reload keeps its
+// last good version, so a bad edit never breaks incident output.
+type TemplateSet struct {
+	// dir is the directory templates are loaded from
+	dir string
+	// mutex protects templates and files
+	mutex sync.RWMutex
+	// templates maps template names to their parsed templates
+	templates map[string]*template.Template
+	// files tracks the state of each file as of the last reload
+	files map[string]templateFile
+}
+
+// LoadTemplateDir parses every template in dir. Unlike a reload, any parse
+// error fails the initial load since there is no last good version to keep.
+func LoadTemplateDir(dir string) (*TemplateSet, error) {
+	ts := &TemplateSet{
+		dir:       dir,
+		templates: make(map[string]*template.Template),
+		files:     make(map[string]templateFile),
+	}
+	if err := ts.Reload(); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// Lookup returns the current version of the named template.
+func (ts *TemplateSet) Lookup(name string) (*template.Template, bool) {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	tmpl, ok := ts.templates[name]
+	return tmpl, ok
+}
+
+// Names returns the names of all loaded templates in sorted order.
+func (ts *TemplateSet) Names() []string {
+	ts.mutex.RLock()
+	defer ts.mutex.RUnlock()
+	names := make([]string, 0, len(ts.templates))
+	for name := range ts.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Reload rescans the template directory, parsing new and modified templates and
+// dropping templates whose files were removed. The new set replaces the old one
+// atomically. Templates that fail to parse keep their previous version and are
+// reported in the returned error.
+func (ts *TemplateSet) Reload() error {
+	paths, err := filepath.Glob(filepath.Join(ts.dir, "*"+TemplateExtension))
+	if err != nil {
+		return fmt.Errorf("failed to list templates in %s: %w", ts.dir, err)
+	}
+
+	ts.mutex.RLock()
+	templates := make(map[string]*template.Template, len(paths))
+	files := make(map[string]templateFile, len(paths))
+	var failures []string
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), TemplateExtension)
+		info, err := os.Stat(path)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+
+		state := templateFile{modTime: info.ModTime(), size: info.Size()}
+		files[name] = state
+
+		if current, ok := ts.templates[name]; ok && ts.files[name] == state {
+			templates[name] = current
+			continue
+		}
+
+		tmpl, err := template.New(filepath.Base(path)).ParseFiles(path)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			if current, ok := ts.templates[name]; ok {
+				templates[name] = current
+			}
+			continue
+		}
+		templates[name] = tmpl
+	}
+	ts.mutex.RUnlock()
+
+	ts.mutex.Lock()
+	ts.templates = templates
+	ts.files = files
+	ts.mutex.Unlock()
+
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to load templates: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// Watch reloads templates every interval until the context is cancelled.
+// Reload failures are logged and the last good templates stay in use.
+func (ts *TemplateSet) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := ts.Reload(); err != nil {
+				fmt.Printf("Template reload error (keeping last good templates): %v\n", err)
+			}
+		}
+	}
+}
+
+// TemplateFormatter formats incidents with a named template from a TemplateSet.
+// The template is looked up on every call, so reloaded templates take effect
+// for the next incident.
+type TemplateFormatter struct {
+	templates *TemplateSet
+	name      string
+	clock     clock.Clock
+}
+
+// NewTemplateFormatter creates a formatter using the named template. Returns an
+// error if no template set is configured or the template does not exist.
+func NewTemplateFormatter(templates *TemplateSet, name string, opts ...Option) (*TemplateFormatter, error) {
+	if templates == nil {
+		return nil, fmt.Errorf("no template directory configured")
+	}
+	if _, ok := templates.Lookup(name); !ok {
+		return nil, fmt.Errorf("template %q not found in %s", name, templates.dir)
+	}
+
+	return &TemplateFormatter{templates: templates, name: name, clock: newOptions(opts).clock}, nil
+}
+
+// Name returns the formatter name for identification and logging.
+func (tf *TemplateFormatter) Name() string {
+	return TemplateFormatterPrefix + tf.name
+}
+
+// Format executes the current version of the template against the incident and telemetry.
+func (tf *TemplateFormatter) Format(entries []types.TelemetryEntry, incident types.IncidentReport) ([]byte, error) {
+	tmpl, ok := tf.templates.Lookup(tf.name)
+	if !ok {
+		return nil, fmt.Errorf("template %q is no longer available", tf.name)
+	}
+
+	var buf bytes.Buffer
+	data := TemplateData{
+		Incident:      incident,
+		SchemaVersion: version.SchemaVersion(incident.Context),
+		Telemetry:     entries,
+		Timestamp:     tf.clock.Now(),
+	}
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template %q: %w", tf.name, err)
+	}
+	return buf.Bytes(), nil
+}
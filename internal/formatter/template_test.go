@@ -0,0 +1,174 @@
+package formatter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+)
+
+// writeTemplate writes a template file and bumps its modification time so that
+// reloads detect the change even within the filesystem's timestamp granularity.
+func writeTemplate(t *testing.T, dir, name, content string, modTime time.Time) {
+	t.Helper()
+	path := filepath.Join(dir, name+TemplateExtension)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set template time: %v", err)
+	}
+}
+
+// TestTemplateSet validates loading and hot reloading of template directories.
+func TestTemplateSet(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	incident, entries := testIncidentAndEntries()
+
+	t.Run("loads named templates", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplate(t, dir, "summary", "{{.Incident.ID}}: {{.Incident.Message}}", base)
+		writeTemplate(t, dir, "count", "{{len .Telemetry}} entries", base)
+		os.WriteFile(filepath.Join(dir, "README.md"), []byte("ignored"), 0644)
+
+		templates, err := LoadTemplateDir(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if names := strings.Join(templates.Names(), ","); names != "count,summary" {
+			t.Errorf("Expected templates 'count,summary', got %q", names)
+		}
+
+		formatter, err := NewTemplateFormatter(templates, "summary")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		output, err := formatter.Format(entries, incident)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if string(output) != "incident-1: test incident" {
+			t.Errorf("Expected rendered template, got %q", output)
+		}
+		if formatter.Name() != "template:summary" {
+			t.Errorf("Expected formatter name 'template:summary', got %q", formatter.Name())
+		}
+	})
+
+	t.Run("rejects broken templates on initial load", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplate(t, dir, "broken", "{{.Incident.ID", base)
+
+		if _, err := LoadTemplateDir(dir); err == nil {
+			t.Error("Expected error loading broken template")
+		}
+	})
+
+	t.Run("reloads modified templates", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplate(t, dir, "summary", "v1 {{.Incident.ID}}", base)
+		templates, err := LoadTemplateDir(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		formatter, _ := NewTemplateFormatter(templates, "summary")
+
+		writeTemplate(t, dir, "summary", "v2 {{.Incident.ID}}", base.Add(time.Second))
+		writeTemplate(t, dir, "added", "new", base.Add(time.Second))
+		if err := templates.Reload(); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		output, _ := formatter.Format(entries, incident)
+		if string(output) != "v2 incident-1" {
+			t.Errorf("Expected reloaded template output, got %q", output)
+		}
+		if _, ok := templates.Lookup("added"); !ok {
+			t.Error("Expected new template to be loaded")
+		}
+	})
+
+	t.Run("keeps last good template when reload fails", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplate(t, dir, "summary", "good {{.Incident.ID}}", base)
+		templates, err := LoadTemplateDir(dir)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		formatter, _ := NewTemplateFormatter(templates, "summary")
+
+		writeTemplate(t, dir, "summary", "bad {{.Incident.ID", base.Add(time.Second))
+		if err := templates.Reload(); err == nil {
+			t.Error("Expected reload error for broken template")
+		}
+
+		output, err := formatter.Format(entries, incident)
+		if err != nil {
+			t.Fatalf("Expected last good template to keep working, got %v", err)
+		}
+		if string(output) != "good incident-1" {
+			t.Errorf("Expected last good template output, got %q", output)
+		}
+
+		writeTemplate(t, dir, "summary", "fixed {{.Incident.ID}}", base.Add(2*time.Second))
+		if err := templates.Reload(); err != nil {
+			t.Fatalf("Expected fixed template to reload, got %v", err)
+		}
+		if output, _ := formatter.Format(entries, incident); string(output) != "fixed incident-1" {
+			t.Errorf("Expected fixed template output, got %q", output)
+		}
+	})
+
+	t.Run("drops removed templates", func(t *testing.T) {
+		dir := t.TempDir()
+		writeTemplate(t, dir, "summary", "{{.Incident.ID}}", base)
+		templates, _ := LoadTemplateDir(dir)
+		formatter, _ := NewTemplateFormatter(templates, "summary")
+
+		os.Remove(filepath.Join(dir, "summary"+TemplateExtension))
+		templates.Reload()
+
+		if _, err := formatter.Format(entries, incident); err == nil {
+			t.Error("Expected error formatting with removed template")
+		}
+	})
+}
+
+// TestTemplateFormatterChain validates selecting templates in formatter configuration.
+func TestTemplateFormatterChain(t *testing.T) {
+	dir := t.TempDir()
+	writeTemplate(t, dir, "generated", "{{.Timestamp.Format \"2006-01-02\"}}", time.Now())
+	templates, err := LoadTemplateDir(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	t.Run("creates template formatter by name", func(t *testing.T) {
+		fake := clock.NewFake(time.Date(2024, time.November, 2, 0, 0, 0, 0, time.UTC))
+		chain, err := CreateFormatterChainWithOptions([]string{"template:generated"}, nil, WithTemplates(templates), WithClock(fake))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		incident, entries := testIncidentAndEntries()
+		output, err := chain.formatters[0].Formatter.Format(entries, incident)
+		if err != nil || string(output) != "2024-11-02" {
+			t.Errorf("Expected '2024-11-02', got %q (err=%v)", output, err)
+		}
+	})
+
+	t.Run("rejects unknown template", func(t *testing.T) {
+		if _, err := CreateFormatterChainWithOptions([]string{"template:missing"}, nil, WithTemplates(templates)); err == nil {
+			t.Error("Expected error for unknown template")
+		}
+	})
+
+	t.Run("requires template directory", func(t *testing.T) {
+		if _, err := CreateFormatterChainWithOptions([]string{"template:generated"}, nil); err == nil {
+			t.Error("Expected error without template directory")
+		}
+	})
+}
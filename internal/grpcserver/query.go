@@ -0,0 +1,190 @@
+package grpcserver
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/ringbuffer"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// QueryServiceName is the full name of the telemetry query service.
+const QueryServiceName = "blackbox.telemetry.v1.TelemetryQuery"
+
+// Full method names of the telemetry query service.
+const (
+	// QueryMethod streams the buffered telemetry matching a filter
+	QueryMethod = "/" + QueryServiceName + "/Query"
+	// GetStatsMethod returns the buffer statistics
+	GetStatsMethod = "/" + QueryServiceName + "/GetStats"
+)
+
+// TelemetryQuerier returns buffered telemetry and buffer statistics, e.g. the
+// ring buffer. Filter returns the entries within the buffer window before
+// from that match every predicate.
+type TelemetryQuerier interface {
+	Filter(from time.Time, predicates ...ringbuffer.Predicate) []types.TelemetryEntry
+	GetStats() ringbuffer.BufferStats
+}
+
+// queryHandler is implemented by the telemetry query service. It types the
+// handlers of queryServiceDesc, which is written by hand so the service needs
+// no generated code.
+type queryHandler interface {
+	query(filter *structpb.Struct, stream grpc.ServerStream) error
+	getStats() (*structpb.Struct, error)
+}
+
+// queryServiceDesc describes the telemetry query service:
+//
+//	service TelemetryQuery {
+//	  rpc Query(google.protobuf.Struct) returns (stream google.protobuf.Struct);
+//	  rpc GetStats(google.protobuf.Empty) returns (google.protobuf.Struct);
+//	}
+//
+// The messages are well-known types, so clients in any language can call it
+// without the daemon's protos. Entries have the fields of the JSON the REST
+// API returns for GET /api/v1/telemetry/query, and the statistics those of
+// ringbuffer.BufferStats encoded as JSON.
+var queryServiceDesc = grpc.ServiceDesc{
+	ServiceName: QueryServiceName,
+	HandlerType: (*queryHandler)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "GetStats", Handler: getStatsHandler},
+	},
+	Streams: []grpc.StreamDesc{
+		{StreamName: "Query", Handler: queryStreamHandler, ServerStreams: true},
+	},
+	Metadata: "blackbox/telemetry/v1/query.proto",
+}
+
+// queryStreamHandler receives the filter of a Query call and streams the results.
+func queryStreamHandler(srv interface{}, stream grpc.ServerStream) error {
+	filter := new(structpb.Struct)
+	if err := stream.RecvMsg(filter); err != nil {
+		return err
+	}
+	return srv.(queryHandler).query(filter, stream)
+}
+
+// getStatsHandler decodes a GetStats call and passes it through the interceptor.
+func getStatsHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	if err := dec(new(emptypb.Empty)); err != nil {
+		return nil, err
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(queryHandler).getStats()
+	}
+	if interceptor == nil {
+		return handler(ctx, nil)
+	}
+	return interceptor(ctx, new(emptypb.Empty), &grpc.UnaryServerInfo{Server: srv, FullMethod: GetStatsMethod}, handler)
+}
+
+// queryService serves telemetry queries from the buffer.
+type queryService struct {
+	// querier supplies the buffered telemetry
+	querier TelemetryQuerier
+}
+
+// query streams the entries matching the filter, oldest first, one message
+// per entry. The filter's optional fields are window (a duration, defaulting
+// to the whole buffer), pod, source and type, which select entries exactly,
+// and tags, an object of tag values that must all match.
+func (qs *queryService) query(filter *structpb.Struct, stream grpc.ServerStream) error {
+	now := time.Now()
+	predicates, err := queryPredicates(filter, now)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	for _, entry := range qs.querier.Filter(now, predicates...) {
+		message, err := toStruct(entry)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to encode entry %s: %v", entry.Name, err)
+		}
+		if err := stream.SendMsg(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getStats returns the buffer statistics.
+func (qs *queryService) getStats() (*structpb.Struct, error) {
+	message, err := toStruct(qs.querier.GetStats())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode buffer stats: %v", err)
+	}
+	return message, nil
+}
+
+// queryPredicates builds the buffer predicates of a Query filter.
+func queryPredicates(filter *structpb.Struct, now time.Time) ([]ringbuffer.Predicate, error) {
+	var predicates []ringbuffer.Predicate
+	for name, value := range filter.GetFields() {
+		switch name {
+		case "window", "pod", "source", "type":
+			text, ok := value.GetKind().(*structpb.Value_StringValue)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a string", name)
+			}
+			switch name {
+			case "window":
+				window, err := time.ParseDuration(text.StringValue)
+				if err != nil || window < 0 {
+					return nil, fmt.Errorf("invalid window %q", text.StringValue)
+				}
+				cutoff := now.Add(-window)
+				predicates = append(predicates, func(entry types.TelemetryEntry) bool {
+					return entry.Timestamp.After(cutoff)
+				})
+			case "pod":
+				predicates = append(predicates, ringbuffer.MatchPod(text.StringValue))
+			case "source":
+				predicates = append(predicates, ringbuffer.MatchSource(types.TelemetrySource(text.StringValue)))
+			case "type":
+				predicates = append(predicates, ringbuffer.MatchType(types.TelemetryType(text.StringValue)))
+			}
+		case "tags":
+			object := value.GetStructValue()
+			if object == nil {
+				return nil, fmt.Errorf("tags must be an object")
+			}
+			tags := make(map[string]string, len(object.Fields))
+			for key, tag := range object.Fields {
+				text, ok := tag.GetKind().(*structpb.Value_StringValue)
+				if !ok {
+					return nil, fmt.Errorf("tag %s must be a string", key)
+				}
+				tags[key] = text.StringValue
+			}
+			predicates = append(predicates, ringbuffer.MatchTags(tags))
+		default:
+			return nil, fmt.Errorf("unknown filter field %q", name)
+		}
+	}
+	return predicates, nil
+}
+
+// toStruct converts a value to a Struct through its JSON encoding, so gRPC
+// clients see the same fields as REST clients.
+func toStruct(value interface{}) (*structpb.Struct, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	message := new(structpb.Struct)
+	if err := protojson.Unmarshal(data, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
@@ -0,0 +1,165 @@
+package grpcserver
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/ringbuffer"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// queryTelemetry calls Query with the filter and returns the streamed entries.
+func queryTelemetry(ctx context.Context, conn *grpc.ClientConn, filter map[string]interface{}) ([]*structpb.Struct, error) {
+	request, err := structpb.NewStruct(filter)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := conn.NewStream(ctx, &queryServiceDesc.Streams[0], QueryMethod)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(request); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+
+	var entries []*structpb.Struct
+	for {
+		entry := new(structpb.Struct)
+		if err := stream.RecvMsg(entry); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// TestQueryService validates the telemetry query service against an
+// in-process server backed by a populated ring buffer.
+func TestQueryService(t *testing.T) {
+	now := time.Now()
+
+	// populatedBuffer returns a buffer with system CPU telemetry and the
+	// telemetry of two pods.
+	populatedBuffer := func() *ringbuffer.RingBuffer {
+		buffer := ringbuffer.New(time.Hour)
+		buffer.Add(types.TelemetryEntry{Timestamp: now.Add(-30 * time.Minute), Source: types.SourceSystem, Type: types.TypeCPU, Name: "cpu_usage_percent", Value: 42.5, Tags: map[string]string{"core": "cpu0"}})
+		buffer.Add(types.TelemetryEntry{Timestamp: now.Add(-20 * time.Minute), Source: types.SourceSidecar, Type: types.TypeMemory, Name: "heap_used", Value: 100.0, Tags: map[string]string{"pod_name": "api-1"}})
+		buffer.Add(types.TelemetryEntry{Timestamp: now.Add(-2 * time.Minute), Source: types.SourceSidecar, Type: types.TypeMemory, Name: "heap_used", Value: 200.0, Tags: map[string]string{"pod_name": "api-1"}})
+		buffer.Add(types.TelemetryEntry{Timestamp: now.Add(-time.Minute), Source: types.SourceSidecar, Type: types.TypeMemory, Name: "heap_used", Value: 300.0, Tags: map[string]string{"pod_name": "web-1"}})
+		return buffer
+	}
+
+	t.Run("streams the entries matching the filter", func(t *testing.T) {
+		conn := dialTestServer(t, NewServer(0, nil, WithQueryService(populatedBuffer())))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		entries, err := queryTelemetry(ctx, conn, map[string]interface{}{"pod": "api-1", "type": "memory"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 entries of api-1, got %d", len(entries))
+		}
+		for i, value := range []float64{100, 200} {
+			fields := entries[i].Fields
+			if fields["name"].GetStringValue() != "heap_used" || fields["value"].GetNumberValue() != value {
+				t.Errorf("Expected entry %d heap_used %v, got %v", i, value, entries[i])
+			}
+			if pod := fields["tags"].GetStructValue().Fields["pod_name"].GetStringValue(); pod != "api-1" {
+				t.Errorf("Expected entry %d of api-1, got %s", i, pod)
+			}
+		}
+
+		entries, err = queryTelemetry(ctx, conn, map[string]interface{}{"window": "5m", "source": "sidecar"})
+		if err != nil || len(entries) != 2 {
+			t.Fatalf("Expected 2 sidecar entries within 5m, got %d (err=%v)", len(entries), err)
+		}
+
+		entries, err = queryTelemetry(ctx, conn, map[string]interface{}{"tags": map[string]interface{}{"core": "cpu0"}})
+		if err != nil || len(entries) != 1 || entries[0].Fields["source"].GetStringValue() != "system" {
+			t.Errorf("Expected the system entry of cpu0, got %v (err=%v)", entries, err)
+		}
+	})
+
+	t.Run("rejects invalid filters", func(t *testing.T) {
+		conn := dialTestServer(t, NewServer(0, nil, WithQueryService(populatedBuffer())))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		for _, filter := range []map[string]interface{}{
+			{"window": "soon"},
+			{"pod": 3},
+			{"tags": "core=cpu0"},
+			{"namespace": "default"},
+		} {
+			if _, err := queryTelemetry(ctx, conn, filter); status.Code(err) != codes.InvalidArgument {
+				t.Errorf("Expected InvalidArgument for %v, got %v", filter, err)
+			}
+		}
+	})
+
+	t.Run("returns buffer stats", func(t *testing.T) {
+		conn := dialTestServer(t, NewServer(0, nil, WithQueryService(populatedBuffer())))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		stats := new(structpb.Struct)
+		if err := conn.Invoke(ctx, GetStatsMethod, new(emptypb.Empty), stats); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if total := stats.Fields["total_entries"].GetNumberValue(); total != 4 {
+			t.Errorf("Expected 4 entries, got %v", total)
+		}
+	})
+
+	t.Run("requires the API key", func(t *testing.T) {
+		conn := dialTestServer(t, NewServer(0, nil, WithAPIKey("secret"), WithQueryService(populatedBuffer())))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if _, err := queryTelemetry(ctx, conn, nil); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Expected Unauthenticated for Query without a key, got %v", err)
+		}
+		if err := conn.Invoke(ctx, GetStatsMethod, new(emptypb.Empty), new(structpb.Struct)); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Expected Unauthenticated for GetStats without a key, got %v", err)
+		}
+		wrong := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer wrong")
+		if _, err := queryTelemetry(wrong, conn, nil); status.Code(err) != codes.Unauthenticated {
+			t.Errorf("Expected Unauthenticated for a wrong key, got %v", err)
+		}
+
+		authorized := metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer secret")
+		if entries, err := queryTelemetry(authorized, conn, nil); err != nil || len(entries) != 4 {
+			t.Errorf("Expected all 4 entries with the key, got %d (err=%v)", len(entries), err)
+		}
+
+		// Probes need no credentials
+		if response, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil || response.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Expected health checks without a key, got %v (err=%v)", response.GetStatus(), err)
+		}
+	})
+
+	t.Run("is not registered by default", func(t *testing.T) {
+		conn := dialTestServer(t, NewServer(0, nil))
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := conn.Invoke(ctx, GetStatsMethod, new(emptypb.Empty), new(structpb.Struct)); status.Code(err) != codes.Unimplemented {
+			t.Errorf("Expected Unimplemented, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,177 @@
+// Package grpcserver provides the gRPC server for BlackBox-Daemon. It hosts the
+// standard grpc.health.v1.Health service so Kubernetes gRPC probes and service
+// meshes can check the daemon without going through the REST API, and
+// optionally a read-only telemetry query service.
+package grpcserver
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultHealthInterval is how often the health status is refreshed from the daemon.
+const DefaultHealthInterval = 5 * time.Second
+
+// HealthFunc reports whether the daemon's subsystems (buffer and collectors) are healthy.
+type HealthFunc func() bool
+
+// Server is the daemon's gRPC server. The health status reported to clients is
+// refreshed periodically from a HealthFunc: SERVING while it reports healthy and
+// NOT_SERVING otherwise.
+type Server struct {
+	// port is the TCP port the server listens on
+	port int
+	// grpcServer is the underlying gRPC server
+	grpcServer *grpc.Server
+	// health implements grpc.health.v1.Health
+	health *health.Server
+	// check reports the daemon's overall health
+	check HealthFunc
+	// interval is how often health is refreshed
+	interval time.Duration
+	// apiKey is the bearer token required for every service but health (empty
+	// disables authentication)
+	apiKey string
+	// querier backs the telemetry query service (nil leaves it unregistered)
+	querier TelemetryQuerier
+}
+
+// Option configures optional features of a Server.
+type Option func(*Server)
+
+// WithAPIKey requires the API key as a bearer token in the "authorization"
+// metadata of every call except health checks, the same token the REST API
+// expects in its Authorization header.
+func WithAPIKey(apiKey string) Option {
+	return func(s *Server) {
+		s.apiKey = apiKey
+	}
+}
+
+// WithQueryService registers the read-only telemetry query service, backed
+// by the querier, e.g. the ring buffer.
+func WithQueryService(querier TelemetryQuerier) Option {
+	return func(s *Server) {
+		s.querier = querier
+	}
+}
+
+// NewServer creates a gRPC server on the given port with the health service
+// registered. A nil check always reports SERVING.
+func NewServer(port int, check HealthFunc, opts ...Option) *Server {
+	if check == nil {
+		check = func() bool { return true }
+	}
+
+	s := &Server{
+		port:     port,
+		check:    check,
+		interval: DefaultHealthInterval,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.grpcServer = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.unaryAuthInterceptor),
+		grpc.ChainStreamInterceptor(s.streamAuthInterceptor),
+	)
+	s.health = health.NewServer()
+	healthpb.RegisterHealthServer(s.grpcServer, s.health)
+	if s.querier != nil {
+		s.grpcServer.RegisterService(&queryServiceDesc, &queryService{querier: s.querier})
+	}
+
+	s.UpdateHealth()
+	return s
+}
+
+// authorize checks the bearer token of a call. Health checks are always
+// allowed so probes need no credentials.
+func (s *Server) authorize(ctx context.Context, fullMethod string) error {
+	if s.apiKey == "" || strings.HasPrefix(fullMethod, "/"+healthpb.Health_ServiceDesc.ServiceName+"/") {
+		return nil
+	}
+
+	// Use constant-time comparison to prevent timing attacks on API key validation
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		if subtle.ConstantTimeCompare([]byte(value), []byte("Bearer "+s.apiKey)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid API key")
+}
+
+// unaryAuthInterceptor rejects unary calls without the API key.
+func (s *Server) unaryAuthInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// streamAuthInterceptor rejects streaming calls without the API key.
+func (s *Server) streamAuthInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
+
+// UpdateHealth refreshes the overall serving status from the health check.
+func (s *Server) UpdateHealth() {
+	status := healthpb.HealthCheckResponse_NOT_SERVING
+	if s.check() {
+		status = healthpb.HealthCheckResponse_SERVING
+	}
+	s.health.SetServingStatus("", status)
+}
+
+// Start listens on the configured port and serves until the context is cancelled.
+func (s *Server) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
+	if err != nil {
+		return fmt.Errorf("failed to listen on port %d: %w", s.port, err)
+	}
+
+	fmt.Printf("Starting gRPC server on %s\n", listener.Addr())
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves gRPC requests on the listener until the context is cancelled,
+// refreshing health status in the background. On shutdown all services are
+// reported NOT_SERVING before in-flight calls are drained.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	go func() {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				s.health.Shutdown()
+				s.grpcServer.GracefulStop()
+				return
+			case <-ticker.C:
+				s.UpdateHealth()
+			}
+		}
+	}()
+
+	if err := s.grpcServer.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+		return err
+	}
+	return nil
+}
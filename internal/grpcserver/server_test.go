@@ -0,0 +1,120 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// startTestServer serves the server over an in-process listener and returns a
+// connected health client.
+func startTestServer(t *testing.T, server *Server) healthpb.HealthClient {
+	t.Helper()
+	return healthpb.NewHealthClient(dialTestServer(t, server))
+}
+
+// dialTestServer serves the server over an in-process listener and returns a
+// client connection to it.
+func dialTestServer(t *testing.T, server *Server) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, listener) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+	})
+	return conn
+}
+
+// checkStatus performs a health check for the overall server status.
+func checkStatus(t *testing.T, client healthpb.HealthClient) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	response, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatalf("Health check failed: %v", err)
+	}
+	return response.Status
+}
+
+// TestHealthService validates the gRPC health service against an in-process server.
+func TestHealthService(t *testing.T) {
+	t.Run("reports serving when healthy", func(t *testing.T) {
+		client := startTestServer(t, NewServer(0, func() bool { return true }))
+
+		if status := checkStatus(t, client); status != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Expected SERVING, got %v", status)
+		}
+	})
+
+	t.Run("reports not serving when unhealthy", func(t *testing.T) {
+		client := startTestServer(t, NewServer(0, func() bool { return false }))
+
+		if status := checkStatus(t, client); status != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Errorf("Expected NOT_SERVING, got %v", status)
+		}
+	})
+
+	t.Run("follows health changes", func(t *testing.T) {
+		var healthy atomic.Bool
+		healthy.Store(true)
+		server := NewServer(0, healthy.Load)
+		server.interval = 10 * time.Millisecond
+		client := startTestServer(t, server)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("Failed to watch health: %v", err)
+		}
+		if response, err := stream.Recv(); err != nil || response.Status != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("Expected SERVING, got %v (err=%v)", response.GetStatus(), err)
+		}
+
+		// The next update comes from the background refresh
+		healthy.Store(false)
+		if response, err := stream.Recv(); err != nil || response.Status != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Fatalf("Expected status to change to NOT_SERVING, got %v (err=%v)", response.GetStatus(), err)
+		}
+
+		healthy.Store(true)
+		server.UpdateHealth()
+		if status := checkStatus(t, client); status != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Expected SERVING after recovery, got %v", status)
+		}
+	})
+
+	t.Run("nil check reports serving", func(t *testing.T) {
+		client := startTestServer(t, NewServer(0, nil))
+
+		if status := checkStatus(t, client); status != healthpb.HealthCheckResponse_SERVING {
+			t.Errorf("Expected SERVING, got %v", status)
+		}
+	})
+}
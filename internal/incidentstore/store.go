@@ -0,0 +1,368 @@
+// Package incidentstore keeps a bounded history of recent incidents that
+// survives daemon restarts. Every incident is appended to a write-ahead log
+// (WAL) of newline-delimited JSON before it is processed, and the log is
+// replayed on startup to rebuild the history.
+package incidentstore
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+const (
+	// DefaultMaxIncidents bounds the number of incidents kept in the history
+	DefaultMaxIncidents = 1000
+	// DefaultMaxAge bounds how long incidents are kept in the history
+	DefaultMaxAge = 7 * 24 * time.Hour
+	// DefaultMaxWALBytes is the WAL size at which it is compacted on append
+	DefaultMaxWALBytes = 16 << 20
+	// DefaultCompactInterval is how often Start compacts the WAL
+	DefaultCompactInterval = time.Hour
+	// ReplayedAtKey is the context key marking an incident resubmitted from
+	// the history, holding the RFC 3339 time it was replayed
+	ReplayedAtKey = "replayed_at"
+)
+
+// Store is a bounded, persistent history of recent incidents. It is safe for
+// concurrent use.
+type Store struct {
+	// mutex protects all store operations
+	mutex sync.Mutex
+	// path is the WAL file location
+	path string
+	// file is the WAL opened for appending
+	file *os.File
+	// size is the current WAL size in bytes
+	size int64
+	// incidents holds the retained history in the order it was recorded
+	incidents []types.IncidentReport
+	// maxIncidents bounds the history length (zero or less is unbounded)
+	maxIncidents int
+	// maxAge drops incidents older than this (zero or less keeps them regardless of age)
+	maxAge time.Duration
+	// maxBytes triggers compaction when an append grows the WAL beyond it
+	maxBytes int64
+	// compactInterval is how often Start compacts the WAL
+	compactInterval time.Duration
+	// clock supplies the current time for age-based retention
+	clock clock.Clock
+}
+
+// Option configures optional store behavior at construction time.
+type Option func(*Store)
+
+// WithMaxIncidents bounds the number of incidents kept. Defaults to DefaultMaxIncidents.
+func WithMaxIncidents(max int) Option {
+	return func(s *Store) {
+		s.maxIncidents = max
+	}
+}
+
+// WithMaxAge drops incidents older than the given age. Defaults to DefaultMaxAge.
+func WithMaxAge(age time.Duration) Option {
+	return func(s *Store) {
+		s.maxAge = age
+	}
+}
+
+// WithMaxWALBytes sets the WAL size at which it is compacted on append.
+// Defaults to DefaultMaxWALBytes.
+func WithMaxWALBytes(max int64) Option {
+	return func(s *Store) {
+		s.maxBytes = max
+	}
+}
+
+// WithCompactInterval sets how often Start compacts the WAL. Defaults to
+// DefaultCompactInterval.
+func WithCompactInterval(interval time.Duration) Option {
+	return func(s *Store) {
+		s.compactInterval = interval
+	}
+}
+
+// WithClock sets the clock used for age-based retention. Defaults to the system clock.
+func WithClock(c clock.Clock) Option {
+	return func(s *Store) {
+		s.clock = c
+	}
+}
+
+// Open opens the store backed by the WAL at path, creating it if needed, and
+// replays it to rebuild the incident history. Lines that cannot be decoded,
+// such as a record torn by a crash mid-write, are skipped. The WAL is then
+// compacted to the retained incidents.
+func Open(path string, opts ...Option) (*Store, error) {
+	s := &Store{
+		path:            path,
+		maxIncidents:    DefaultMaxIncidents,
+		maxAge:          DefaultMaxAge,
+		maxBytes:        DefaultMaxWALBytes,
+		compactInterval: DefaultCompactInterval,
+		clock:           clock.Real{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create incident WAL directory: %w", err)
+	}
+
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.pruneLocked()
+	if err := s.compactLocked(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// replay reads the WAL into the incident history.
+func (s *Store) replay() error {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open incident WAL: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	skipped := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var report types.IncidentReport
+			if jsonErr := json.Unmarshal(line, &report); jsonErr != nil {
+				skipped++
+			} else {
+				s.incidents = append(s.incidents, report)
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read incident WAL: %w", err)
+		}
+	}
+
+	if skipped > 0 {
+		fmt.Printf("Skipped %d unreadable records replaying incident WAL %s\n", skipped, s.path)
+	}
+	return nil
+}
+
+// Record appends the incident to the WAL and syncs it to disk before adding it
+// to the history, so an incident is durable before it is processed. The WAL is
+// compacted when the append grows it beyond the configured size. Replayed
+// incidents are already in the history and are not recorded again.
+func (s *Store) Record(report types.IncidentReport) error {
+	if IsReplayed(report) {
+		return nil
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to encode incident: %w", err)
+	}
+	data = append(data, '\n')
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return fmt.Errorf("incident store is closed")
+	}
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	if err != nil {
+		return fmt.Errorf("failed to append incident to WAL: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync incident WAL: %w", err)
+	}
+
+	s.incidents = append(s.incidents, report)
+	s.pruneLocked()
+
+	if s.maxBytes > 0 && s.size > s.maxBytes {
+		return s.compactLocked()
+	}
+	return nil
+}
+
+// Incidents returns a copy of the retained incidents in the order they were recorded.
+func (s *Store) Incidents() []types.IncidentReport {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pruneLocked()
+	incidents := make([]types.IncidentReport, len(s.incidents))
+	copy(incidents, s.incidents)
+	return incidents
+}
+
+// IncidentsSince returns a copy of the retained incidents with a timestamp at
+// or after since, in the order they were recorded. A zero since returns the
+// whole history.
+func (s *Store) IncidentsSince(since time.Time) []types.IncidentReport {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pruneLocked()
+	var incidents []types.IncidentReport
+	for _, report := range s.incidents {
+		if !report.Timestamp.Before(since) {
+			incidents = append(incidents, report)
+		}
+	}
+	return incidents
+}
+
+// MarkReplayed returns the incident with ReplayedAtKey set in a copy of its
+// context, so resubmitting it through the incident pipeline does not record it
+// in the history again.
+func MarkReplayed(report types.IncidentReport, at time.Time) types.IncidentReport {
+	context := make(map[string]interface{}, len(report.Context)+1)
+	for key, value := range report.Context {
+		context[key] = value
+	}
+	context[ReplayedAtKey] = at.Format(time.RFC3339)
+	report.Context = context
+	return report
+}
+
+// IsReplayed reports whether the incident was marked by MarkReplayed.
+func IsReplayed(report types.IncidentReport) bool {
+	_, ok := report.Context[ReplayedAtKey]
+	return ok
+}
+
+// Compact rewrites the WAL to contain only the retained incidents, dropping
+// those evicted by the count and age limits.
+func (s *Store) Compact() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.pruneLocked()
+	return s.compactLocked()
+}
+
+// Start compacts the WAL periodically until the context is cancelled.
+func (s *Store) Start(ctx context.Context) error {
+	ticker := time.NewTicker(s.compactInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := s.Compact(); err != nil {
+				fmt.Printf("Error compacting incident WAL: %v\n", err)
+			}
+		}
+	}
+}
+
+// Close syncs and closes the WAL. Record fails after Close.
+func (s *Store) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// pruneLocked drops incidents beyond the count limit or older than the age limit.
+func (s *Store) pruneLocked() {
+	if s.maxAge > 0 {
+		cutoff := s.clock.Now().Add(-s.maxAge)
+		kept := s.incidents[:0]
+		for _, report := range s.incidents {
+			if !report.Timestamp.Before(cutoff) {
+				kept = append(kept, report)
+			}
+		}
+		s.incidents = kept
+	}
+
+	if s.maxIncidents > 0 && len(s.incidents) > s.maxIncidents {
+		s.incidents = append(s.incidents[:0], s.incidents[len(s.incidents)-s.maxIncidents:]...)
+	}
+}
+
+// compactLocked atomically replaces the WAL with the retained incidents and
+// reopens it for appending.
+func (s *Store) compactLocked() error {
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to create compacted incident WAL: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, report := range s.incidents {
+		if err := encoder.Encode(report); err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to write compacted incident WAL: %w", err)
+		}
+	}
+	if err := writer.Flush(); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write compacted incident WAL: %w", err)
+	}
+
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to replace incident WAL: %w", err)
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open incident WAL: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat incident WAL: %w", err)
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
@@ -0,0 +1,206 @@
+package incidentstore
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// incident builds an incident report with the given ID recorded at the given time.
+func incident(id string, at time.Time) types.IncidentReport {
+	return types.IncidentReport{
+		ID:        id,
+		Timestamp: at,
+		PodName:   "api-1",
+		Namespace: "prod",
+		Severity:  types.SeverityCritical,
+		Type:      types.IncidentCrash,
+		Message:   "crash " + id,
+	}
+}
+
+// incidentIDs returns the IDs of the incidents in order.
+func incidentIDs(incidents []types.IncidentReport) []string {
+	ids := make([]string, len(incidents))
+	for i, report := range incidents {
+		ids[i] = report.ID
+	}
+	return ids
+}
+
+// walLines returns the non-empty lines of the WAL file.
+func walLines(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read WAL: %v", err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+// TestStore validates appending, replaying and rotating the incident WAL.
+func TestStore(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("appends incidents to the WAL", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "wal", "incidents.ndjson")
+		store, err := Open(path, WithClock(clock.NewFake(base)))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer store.Close()
+
+		for i := 0; i < 3; i++ {
+			if err := store.Record(incident(fmt.Sprintf("incident-%d", i), base)); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		if lines := walLines(t, path); len(lines) != 3 || !strings.Contains(lines[2], `"id":"incident-2"`) {
+			t.Errorf("Expected 3 NDJSON records, got %v", lines)
+		}
+		if ids := incidentIDs(store.Incidents()); len(ids) != 3 || ids[0] != "incident-0" {
+			t.Errorf("Expected incidents in record order, got %v", ids)
+		}
+	})
+
+	t.Run("replays the WAL on open", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "incidents.ndjson")
+		store, _ := Open(path, WithClock(clock.NewFake(base)))
+		store.Record(incident("incident-0", base))
+		store.Record(incident("incident-1", base.Add(time.Minute)))
+		store.Close()
+
+		// Simulate a record torn by a crash mid-write
+		file, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		file.WriteString(`{"id":"incident-2","time`)
+		file.Close()
+
+		reopened, err := Open(path, WithClock(clock.NewFake(base.Add(time.Hour))))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer reopened.Close()
+
+		incidents := reopened.Incidents()
+		if ids := incidentIDs(incidents); len(ids) != 2 || ids[0] != "incident-0" || ids[1] != "incident-1" {
+			t.Fatalf("Expected replayed incidents, got %v", ids)
+		}
+		if !incidents[1].Timestamp.Equal(base.Add(time.Minute)) || incidents[1].Message != "crash incident-1" {
+			t.Errorf("Expected incident details to survive replay, got %+v", incidents[1])
+		}
+		if lines := walLines(t, path); len(lines) != 2 {
+			t.Errorf("Expected torn record to be dropped from the WAL, got %v", lines)
+		}
+
+		reopened.Record(incident("incident-3", base.Add(time.Hour)))
+		if lines := walLines(t, path); len(lines) != 3 {
+			t.Errorf("Expected appends after replay, got %v", lines)
+		}
+	})
+
+	t.Run("bounds replayed history by count and age", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "incidents.ndjson")
+		store, _ := Open(path, WithClock(clock.NewFake(base)), WithMaxIncidents(0), WithMaxAge(0))
+		for i := 0; i < 5; i++ {
+			store.Record(incident(fmt.Sprintf("incident-%d", i), base.Add(time.Duration(i)*time.Hour)))
+		}
+		store.Close()
+
+		now := base.Add(5 * time.Hour)
+		reopened, _ := Open(path, WithClock(clock.NewFake(now)), WithMaxIncidents(3), WithMaxAge(150*time.Minute))
+		defer reopened.Close()
+
+		if ids := incidentIDs(reopened.Incidents()); len(ids) != 2 || ids[0] != "incident-3" || ids[1] != "incident-4" {
+			t.Errorf("Expected incidents within 150m, got %v", ids)
+		}
+	})
+
+	t.Run("rotates the WAL when it grows too large", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "incidents.ndjson")
+		store, _ := Open(path, WithClock(clock.NewFake(base)), WithMaxIncidents(2), WithMaxWALBytes(1024))
+		defer store.Close()
+
+		for i := 0; i < 20; i++ {
+			if err := store.Record(incident(fmt.Sprintf("incident-%d", i), base)); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		info, _ := os.Stat(path)
+		if info.Size() > 1024 {
+			t.Errorf("Expected WAL to stay under 1024 bytes, got %d", info.Size())
+		}
+		lines := walLines(t, path)
+		if len(lines) >= 20 || !strings.Contains(lines[len(lines)-1], `"id":"incident-19"`) {
+			t.Errorf("Expected rotated WAL to end with the latest incident, got %v", lines)
+		}
+		if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+			t.Errorf("Expected no leftover temporary WAL, got %v", err)
+		}
+	})
+
+	t.Run("compacts expired incidents", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "incidents.ndjson")
+		fake := clock.NewFake(base)
+		store, _ := Open(path, WithClock(fake), WithMaxAge(time.Hour))
+		defer store.Close()
+
+		store.Record(incident("old", base))
+		fake.Advance(2 * time.Hour)
+		store.Record(incident("new", base.Add(2*time.Hour)))
+
+		if err := store.Compact(); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if lines := walLines(t, path); len(lines) != 1 || !strings.Contains(lines[0], `"id":"new"`) {
+			t.Errorf("Expected only the recent incident after compaction, got %v", lines)
+		}
+	})
+
+	t.Run("selects incidents since a time and skips replayed ones", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "incidents.ndjson")
+		store, err := Open(path, WithClock(clock.NewFake(base)))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer store.Close()
+
+		store.Record(incident("old", base.Add(-time.Hour)))
+		store.Record(incident("new", base))
+
+		recent := store.IncidentsSince(base.Add(-time.Minute))
+		if ids := incidentIDs(recent); len(ids) != 1 || ids[0] != "new" {
+			t.Fatalf("Expected only the new incident, got %v", ids)
+		}
+		if all := store.IncidentsSince(time.Time{}); len(all) != 2 {
+			t.Errorf("Expected the whole history for a zero time, got %d incidents", len(all))
+		}
+
+		replayed := MarkReplayed(recent[0], base)
+		if !IsReplayed(replayed) || IsReplayed(recent[0]) {
+			t.Errorf("Expected only the copy marked replayed, got %v and %v", replayed.Context, recent[0].Context)
+		}
+		if err := store.Record(replayed); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(store.Incidents()) != 2 || len(walLines(t, path)) != 2 {
+			t.Errorf("Expected the replayed incident not stored again, got %d incidents", len(store.Incidents()))
+		}
+	})
+
+	t.Run("rejects records after close", func(t *testing.T) {
+		store, _ := Open(filepath.Join(t.TempDir(), "incidents.ndjson"))
+		store.Close()
+
+		if err := store.Record(incident("late", base)); err == nil {
+			t.Error("Expected error recording to a closed store")
+		}
+	})
+}
@@ -0,0 +1,122 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ConfigMapWatcher watches a single ConfigMap and passes its data to a
+// callback whenever it is created or changed, so settings can be tuned
+// without restarting the DaemonSet.
+type ConfigMapWatcher struct {
+	// clientset returns the current Kubernetes client, normally the
+	// PodWatcher's, so a client rebuilt after auth failures is picked up
+	clientset func() kubernetes.Interface
+	// namespace and name identify the watched ConfigMap
+	namespace string
+	name      string
+	// onChange receives the ConfigMap's data on every change
+	onChange func(data map[string]string)
+	// retryInterval is the delay between watch attempts (0 uses defaultRetryInterval)
+	retryInterval time.Duration
+}
+
+// NewConfigMapWatcher creates a watcher calling onChange with the data of the
+// ConfigMap namespace/name when it is created or updated. The clientset
+// function is called each time the watch is established; pass
+// PodWatcher.Clientset to share the pod watcher's client, including the fresh
+// one it builds when credentials stop working.
+func NewConfigMapWatcher(clientset func() kubernetes.Interface, namespace, name string, onChange func(data map[string]string)) *ConfigMapWatcher {
+	return &ConfigMapWatcher{
+		clientset: clientset,
+		namespace: namespace,
+		name:      name,
+		onChange:  onChange,
+	}
+}
+
+// Start watches the ConfigMap until the context is cancelled, re-establishing
+// the watch after errors. The current data is delivered when the watch is
+// first established, as the API server reports existing objects as added.
+func (cw *ConfigMapWatcher) Start(ctx context.Context) error {
+	fieldSelector := fields.OneTermEqualSelector("metadata.name", cw.name).String()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			if err := cw.watch(ctx, fieldSelector); err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				fmt.Printf("ConfigMap watcher error (retrying): %v\n", err)
+				time.Sleep(cw.retryDelay())
+			}
+		}
+	}
+}
+
+// watch runs a single watch of the ConfigMap until it fails or the context is cancelled.
+func (cw *ConfigMapWatcher) watch(ctx context.Context, fieldSelector string) error {
+	watcher, err := cw.clientset().CoreV1().ConfigMaps(cw.namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector: fieldSelector,
+		Watch:         true,
+	})
+	if err != nil {
+		return err
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return fmt.Errorf("watch channel closed")
+			}
+			if event.Type == watch.Error {
+				return apierrors.FromObject(event.Object)
+			}
+
+			cw.handleEvent(event)
+		}
+	}
+}
+
+// handleEvent passes the data of an added or modified ConfigMap to onChange.
+// Deleting the ConfigMap keeps the current settings.
+func (cw *ConfigMapWatcher) handleEvent(event watch.Event) {
+	configMap, ok := event.Object.(*corev1.ConfigMap)
+	if !ok || configMap.Name != cw.name {
+		return
+	}
+
+	switch event.Type {
+	case watch.Added, watch.Modified:
+		data := make(map[string]string, len(configMap.Data))
+		for key, value := range configMap.Data {
+			data[key] = value
+		}
+		cw.onChange(data)
+	case watch.Deleted:
+		fmt.Printf("ConfigMap %s/%s deleted, keeping current settings\n", cw.namespace, cw.name)
+	}
+}
+
+// retryDelay returns the delay before re-establishing a failed watch.
+func (cw *ConfigMapWatcher) retryDelay() time.Duration {
+	if cw.retryInterval > 0 {
+		return cw.retryInterval
+	}
+	return defaultRetryInterval
+}
@@ -0,0 +1,154 @@
+package k8s
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/config"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+// TestConfigMapWatcher validates reloading settings from ConfigMap updates.
+func TestConfigMapWatcher(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	watching := make(chan struct{}, 1)
+	clientset.PrependWatchReactor("configmaps", func(action ktesting.Action) (bool, watch.Interface, error) {
+		watcher, err := clientset.Tracker().Watch(corev1.SchemeGroupVersion.WithResource("configmaps"), action.GetNamespace())
+		watching <- struct{}{}
+		return true, watcher, err
+	})
+
+	current := config.DefaultConfig()
+	current.APIKey = "valid-key"
+	intervals := make(chan time.Duration, 10)
+	ignored := make(chan []string, 10)
+	watcher := NewConfigMapWatcher(func() kubernetes.Interface { return clientset }, "monitoring", "blackbox-overrides", func(data map[string]string) {
+		reloaded, unsafe, err := current.Reload(data)
+		if err != nil {
+			t.Errorf("Expected valid reload, got %v", err)
+			return
+		}
+		intervals <- reloaded.CollectionInterval
+		ignored <- unsafe
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- watcher.Start(ctx) }()
+
+	select {
+	case <-watching:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the ConfigMap watch to be established")
+	}
+
+	configMaps := clientset.CoreV1().ConfigMaps("monitoring")
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "blackbox-overrides", Namespace: "monitoring"},
+		Data:       map[string]string{"BLACKBOX_COLLECTION_INTERVAL": "5s"},
+	}
+	if _, err := configMaps.Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create ConfigMap: %v", err)
+	}
+	other := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "monitoring"},
+		Data:       map[string]string{"BLACKBOX_COLLECTION_INTERVAL": "1m"},
+	}
+	if _, err := configMaps.Create(ctx, other, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create ConfigMap: %v", err)
+	}
+	configMap.Data = map[string]string{"BLACKBOX_COLLECTION_INTERVAL": "250ms", "BLACKBOX_API_PORT": "9999"}
+	if _, err := configMaps.Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("Failed to update ConfigMap: %v", err)
+	}
+
+	for _, expected := range []time.Duration{5 * time.Second, 250 * time.Millisecond} {
+		select {
+		case interval := <-intervals:
+			if interval != expected {
+				t.Errorf("Expected collection interval %v, got %v", expected, interval)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected a reload to %v", expected)
+		}
+	}
+	<-ignored
+	if unsafe := <-ignored; len(unsafe) != 1 || unsafe[0] != "BLACKBOX_API_PORT" {
+		t.Errorf("Expected BLACKBOX_API_PORT ignored, got %v", unsafe)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	select {
+	case interval := <-intervals:
+		t.Errorf("Expected no reload from the unrelated ConfigMap, got %v", interval)
+	default:
+	}
+}
+
+// TestConfigMapWatcherSharesClientset validates that the ConfigMap watch picks
+// up the clientset the pod watcher rebuilds after persistent auth failures.
+func TestConfigMapWatcherSharesClientset(t *testing.T) {
+	unauthorized := apierrors.NewUnauthorized("token expired")
+	stale := fake.NewSimpleClientset()
+	stale.PrependWatchReactor("configmaps", func(action ktesting.Action) (bool, watch.Interface, error) {
+		return true, nil, unauthorized
+	})
+	fresh := fake.NewSimpleClientset()
+	watching := make(chan struct{}, 10)
+	fresh.PrependWatchReactor("configmaps", func(action ktesting.Action) (bool, watch.Interface, error) {
+		watcher, err := fresh.Tracker().Watch(corev1.SchemeGroupVersion.WithResource("configmaps"), action.GetNamespace())
+		watching <- struct{}{}
+		return true, watcher, err
+	})
+
+	podWatcher := &PodWatcher{
+		clientset:    stale,
+		newClientset: func() (kubernetes.Interface, error) { return fresh, nil },
+	}
+	received := make(chan map[string]string, 1)
+	watcher := NewConfigMapWatcher(podWatcher.Clientset, "monitoring", "blackbox-overrides", func(data map[string]string) {
+		received <- data
+	})
+	watcher.retryInterval = time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watcher.Start(ctx)
+
+	for i := 0; i < defaultAuthRebuildThreshold; i++ {
+		podWatcher.handleWatchError(unauthorized)
+	}
+
+	select {
+	case <-watching:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the ConfigMap watch to use the rebuilt clientset")
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "blackbox-overrides", Namespace: "monitoring"},
+		Data:       map[string]string{"BLACKBOX_COLLECTION_INTERVAL": "5s"},
+	}
+	if _, err := fresh.CoreV1().ConfigMaps("monitoring").Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("Failed to create ConfigMap: %v", err)
+	}
+
+	select {
+	case data := <-received:
+		if data["BLACKBOX_COLLECTION_INTERVAL"] != "5s" {
+			t.Errorf("Expected the ConfigMap data from the rebuilt clientset, got %v", data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a reload from the rebuilt clientset")
+	}
+}
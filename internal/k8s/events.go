@@ -0,0 +1,106 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultPodEventLimit is the number of recent pod events attached to crash reports.
+const DefaultPodEventLimit = 10
+
+// eventLookupTimeout bounds the API call listing a pod's events.
+const eventLookupTimeout = 5 * time.Second
+
+// PodEvent is a Kubernetes event about a pod, such as a failed probe or a
+// back-off restart, attached to crash reports under "k8s_events".
+type PodEvent struct {
+	Type     string    `json:"type"`
+	Reason   string    `json:"reason"`
+	Message  string    `json:"message"`
+	Count    int32     `json:"count"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// eventFetcher lists recent events for crashed pods.
+type eventFetcher struct {
+	// forbidden records that listing events was denied, so the missing
+	// permission is only logged once
+	forbidden atomic.Bool
+}
+
+// recent returns up to limit of the pod's most recent events, oldest first.
+func (ef *eventFetcher) recent(clientset kubernetes.Interface, pod *corev1.Pod, limit int) ([]PodEvent, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), eventLookupTimeout)
+	defer cancel()
+
+	selector := fields.Set{
+		"involvedObject.kind":      "Pod",
+		"involvedObject.name":      pod.Name,
+		"involvedObject.namespace": pod.Namespace,
+	}
+	if pod.UID != "" {
+		selector["involvedObject.uid"] = string(pod.UID)
+	}
+
+	list, err := clientset.CoreV1().Events(pod.Namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: selector.AsSelector().String(),
+	})
+	if err != nil {
+		if !apierrors.IsForbidden(err) {
+			fmt.Printf("Error listing events for pod %s/%s: %v\n", pod.Namespace, pod.Name, err)
+		} else if !ef.forbidden.Swap(true) {
+			fmt.Printf("Listing pod events is forbidden; crash reports will not include k8s_events: %v\n", err)
+		}
+		return nil, err
+	}
+
+	events := make([]PodEvent, 0, len(list.Items))
+	for _, event := range list.Items {
+		// Not every API server (or fake client) applies the field selector
+		if event.InvolvedObject.Name != pod.Name ||
+			(pod.UID != "" && event.InvolvedObject.UID != "" && event.InvolvedObject.UID != pod.UID) {
+			continue
+		}
+		events = append(events, PodEvent{
+			Type:     event.Type,
+			Reason:   event.Reason,
+			Message:  event.Message,
+			Count:    event.Count,
+			LastSeen: eventTime(event),
+		})
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].LastSeen.Before(events[j].LastSeen)
+	})
+	if len(events) > limit {
+		events = events[len(events)-limit:]
+	}
+	return events, nil
+}
+
+// eventTime returns when the event was last observed, falling back through the
+// fields populated by the different event APIs.
+func eventTime(event corev1.Event) time.Time {
+	switch {
+	case !event.LastTimestamp.IsZero():
+		return event.LastTimestamp.Time
+	case event.Series != nil && !event.Series.LastObservedTime.IsZero():
+		return event.Series.LastObservedTime.Time
+	case !event.EventTime.IsZero():
+		return event.EventTime.Time
+	case !event.FirstTimestamp.IsZero():
+		return event.FirstTimestamp.Time
+	default:
+		return event.CreationTimestamp.Time
+	}
+}
@@ -0,0 +1,129 @@
+package k8s
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+// podEvent builds an event about the named pod last seen at the given time.
+func podEvent(pod, reason string, lastSeen time.Time) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%s.%d", pod, reason, lastSeen.Unix()),
+			Namespace: "default",
+		},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: pod, Namespace: "default"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         reason,
+		Message:        reason + " for " + pod,
+		Count:          1,
+		LastTimestamp:  metav1.NewTime(lastSeen),
+	}
+}
+
+// failedPod returns a failed bare pod in the default namespace.
+func failedPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+	}
+}
+
+// TestCrashReportEvents validates attaching recent pod events to crash reports.
+func TestCrashReportEvents(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("attaches recent events oldest first", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset(
+			podEvent("web-1", "BackOff", base.Add(2*time.Minute)),
+			podEvent("web-1", "FailedScheduling", base),
+			podEvent("web-1", "Unhealthy", base.Add(time.Minute)),
+			podEvent("other", "Unhealthy", base.Add(3*time.Minute)),
+		)
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(clientset, "", handler)
+
+		watcher.handlePodEvent(failedPod("web-1"))
+
+		reports := handler.getCrashReports()
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 crash report, got %d", len(reports))
+		}
+		events, ok := reports[0].Context["k8s_events"].([]PodEvent)
+		if !ok {
+			t.Fatalf("Expected k8s_events in context, got %v", reports[0].Context["k8s_events"])
+		}
+		if len(events) != 3 {
+			t.Fatalf("Expected 3 events for the pod, got %d", len(events))
+		}
+		for i, reason := range []string{"FailedScheduling", "Unhealthy", "BackOff"} {
+			if events[i].Reason != reason {
+				t.Errorf("Expected event %d to be %s, got %s", i, reason, events[i].Reason)
+			}
+		}
+		if events[2].Message != "BackOff for web-1" || !events[2].LastSeen.Equal(base.Add(2*time.Minute)) {
+			t.Errorf("Expected BackOff details, got %+v", events[2])
+		}
+	})
+
+	t.Run("bounds the number of events", func(t *testing.T) {
+		var objects []runtime.Object
+		for i := 0; i < 5; i++ {
+			objects = append(objects, podEvent("web-1", fmt.Sprintf("Reason%d", i), base.Add(time.Duration(i)*time.Minute)))
+		}
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(fake.NewSimpleClientset(objects...), "", handler)
+		watcher.SetPodEventLimit(2)
+
+		watcher.handlePodEvent(failedPod("web-1"))
+
+		events := handler.getCrashReports()[0].Context["k8s_events"].([]PodEvent)
+		if len(events) != 2 || events[0].Reason != "Reason3" || events[1].Reason != "Reason4" {
+			t.Errorf("Expected the 2 most recent events, got %+v", events)
+		}
+	})
+
+	t.Run("can be disabled", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(fake.NewSimpleClientset(podEvent("web-1", "BackOff", base)), "", handler)
+		watcher.SetPodEventLimit(0)
+
+		watcher.handlePodEvent(failedPod("web-1"))
+
+		if _, ok := handler.getCrashReports()[0].Context["k8s_events"]; ok {
+			t.Error("Expected no k8s_events when disabled")
+		}
+	})
+
+	t.Run("reports crash when listing events is forbidden", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset()
+		clientset.PrependReactor("list", "events", func(action ktesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "events"}, "", fmt.Errorf("RBAC denied"))
+		})
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(clientset, "", handler)
+
+		watcher.handlePodEvent(failedPod("web-1"))
+		watcher.handlePodEvent(failedPod("web-1"))
+
+		reports := handler.getCrashReports()
+		if len(reports) != 2 {
+			t.Fatalf("Expected 2 crash reports, got %d", len(reports))
+		}
+		if _, ok := reports[0].Context["k8s_events"]; ok {
+			t.Error("Expected no k8s_events when forbidden")
+		}
+		if !watcher.events.forbidden.Load() {
+			t.Error("Expected forbidden listing to be recorded")
+		}
+	})
+}
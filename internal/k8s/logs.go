@@ -0,0 +1,390 @@
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// logLookupTimeout bounds the API call reading a container's logs
+	logLookupTimeout = 5 * time.Second
+	// maxLogBytes bounds the log bytes read per crash, so containers logging
+	// huge lines cannot bloat reports
+	maxLogBytes = 256 << 10
+)
+
+// DefaultLogFetchConcurrency is the number of crashed containers' logs read at
+// once.
+const DefaultLogFetchConcurrency = 4
+
+// LogParseMode selects how captured log lines are parsed.
+type LogParseMode string
+
+const (
+	// LogParseRaw keeps every line as its message, without levels
+	LogParseRaw LogParseMode = "raw"
+	// LogParseJSON parses JSON log lines and keeps other lines raw
+	LogParseJSON LogParseMode = "json"
+	// LogParseAuto parses JSON, logfmt and plain lines with a level, e.g.
+	// "2024-11-02 15:04:05 ERROR message" or "[WARN] message"
+	LogParseAuto LogParseMode = "auto"
+)
+
+// LogEntry is a line of a crashed container's log, attached to crash reports
+// under "last_log_entries". Lines that could not be parsed carry only their
+// message, the raw line; indented continuation lines such as stack frames are
+// appended to the message of the entry they follow.
+type LogEntry struct {
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+	Level     string     `json:"level,omitempty"`
+	Message   string     `json:"message"`
+}
+
+// logFetcher reads the last lines of crashed containers' logs.
+type logFetcher struct {
+	// forbidden records that reading logs was denied, so the missing
+	// permission is only logged once
+	forbidden atomic.Bool
+	// limit is the number of fetches allowed in flight (0 uses
+	// DefaultLogFetchConcurrency)
+	limit int32
+	// inFlight counts the fetches in progress
+	inFlight atomic.Int32
+	// skipped counts the fetches skipped because the limit was reached
+	skipped atomic.Uint64
+}
+
+// SetLogCapture sets how many of a crashed container's last log lines are
+// attached to crash reports and how they are parsed. The last ERROR or FATAL
+// entry is also attached as "last_error_log", the likely cause. Zero or fewer
+// lines disables the capture, which is disabled by default.
+func (pw *PodWatcher) SetLogCapture(lines int, mode LogParseMode) {
+	pw.logLines = lines
+	pw.logParseMode = mode
+}
+
+// SetLogFetchConcurrency sets how many crashed containers' logs are read at
+// once, so a crash storm cannot flood the kubelets and the API server with log
+// requests. Reports of crashes beyond the limit are sent without logs and
+// counted by LogFetchesSkipped. A limit of zero or less uses
+// DefaultLogFetchConcurrency.
+func (pw *PodWatcher) SetLogFetchConcurrency(limit int) {
+	if limit <= 0 {
+		limit = DefaultLogFetchConcurrency
+	}
+	pw.logs.limit = int32(limit)
+}
+
+// LogFetchesSkipped returns how many crash reports were sent without logs
+// because the log fetch concurrency limit was reached.
+func (pw *PodWatcher) LogFetchesSkipped() uint64 {
+	return pw.logs.skipped.Load()
+}
+
+// attachLogs adds the crashed container's last log lines to the report context.
+// A restarted container's previous instance is read. Failures, including
+// missing RBAC permission to read pod logs, leave the report without logs
+// rather than delaying or dropping it.
+func (pw *PodWatcher) attachLogs(pod *corev1.Pod, context map[string]interface{}) {
+	container, _ := context["container_name"].(string)
+	clientset := pw.Clientset()
+	if pw.logLines <= 0 || container == "" || clientset == nil {
+		return
+	}
+	// Only the restart branch reports a restart count; its crashed instance
+	// is the previous one
+	_, restarted := context["restart_count"]
+
+	if !pw.logs.acquire() {
+		fmt.Printf("Skipping logs of %s in pod %s/%s: %d log fetches already in progress\n", container, pod.Namespace, pod.Name, pw.logs.maxInFlight())
+		return
+	}
+	data, err := pw.logs.tail(clientset, pod, container, restarted, pw.logLines)
+	pw.logs.release()
+	if err != nil || len(data) == 0 {
+		return
+	}
+	entries := parseLogLines(data, pw.logParseMode)
+	if len(entries) == 0 {
+		return
+	}
+	context["last_log_entries"] = entries
+	if entry, ok := lastErrorEntry(entries); ok {
+		context["last_error_log"] = entry
+	}
+}
+
+// acquire reserves a fetch slot, reporting false and counting the fetch as
+// skipped when the limit is reached. Crashes are reported from the watch loop,
+// so waiting for a slot would delay every later event.
+func (lf *logFetcher) acquire() bool {
+	if lf.inFlight.Add(1) > lf.maxInFlight() {
+		lf.inFlight.Add(-1)
+		lf.skipped.Add(1)
+		return false
+	}
+	return true
+}
+
+// release frees a slot reserved by acquire.
+func (lf *logFetcher) release() {
+	lf.inFlight.Add(-1)
+}
+
+// maxInFlight returns the number of fetches allowed in flight.
+func (lf *logFetcher) maxInFlight() int32 {
+	if lf.limit <= 0 {
+		return DefaultLogFetchConcurrency
+	}
+	return lf.limit
+}
+
+// tail returns the last lines of the container's log.
+func (lf *logFetcher) tail(clientset kubernetes.Interface, pod *corev1.Pod, container string, previous bool, lines int) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), logLookupTimeout)
+	defer cancel()
+
+	tailLines, limitBytes := int64(lines), int64(maxLogBytes)
+	data, err := clientset.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		TailLines:  &tailLines,
+		LimitBytes: &limitBytes,
+	}).DoRaw(ctx)
+	if err != nil {
+		if !apierrors.IsForbidden(err) {
+			fmt.Printf("Error reading logs of %s in pod %s/%s: %v\n", container, pod.Namespace, pod.Name, err)
+		} else if !lf.forbidden.Swap(true) {
+			fmt.Printf("Reading pod logs is forbidden; crash reports will not include last_log_entries: %v\n", err)
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// parseLogLines parses log output into entries according to the mode. Empty
+// lines are skipped.
+func parseLogLines(data []byte, mode LogParseMode) []LogEntry {
+	var entries []LogEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLogBytes)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if mode == LogParseRaw || mode == "" {
+			entries = append(entries, LogEntry{Message: line})
+			continue
+		}
+
+		if entry, ok := parseLogLine(line, mode); ok {
+			entries = append(entries, entry)
+			continue
+		}
+		if n := len(entries); n > 0 && entries[n-1].Level != "" && isContinuation(line) {
+			entries[n-1].Message += "\n" + line
+			continue
+		}
+		entries = append(entries, LogEntry{Message: line})
+	}
+	return entries
+}
+
+// exceptionLine matches the first line of a stack trace, e.g.
+// "java.lang.IllegalStateException: pool exhausted" or "Traceback (most recent
+// call last):".
+var exceptionLine = regexp.MustCompile(`^(?:[\w$.]+(?:Exception|Error|Throwable)(?::|$)|Traceback \(|Caused by:|goroutine \d+ \[)`)
+
+// isContinuation reports whether an unparsed line continues the previous
+// entry, e.g. an indented stack frame, an exception or a Java "Caused by:" line.
+func isContinuation(line string) bool {
+	return line[0] == ' ' || line[0] == '\t' || exceptionLine.MatchString(line)
+}
+
+// parseLogLine parses a JSON line, and in auto mode a logfmt or plain line,
+// reporting whether the line was recognized.
+func parseLogLine(line string, mode LogParseMode) (LogEntry, bool) {
+	if strings.HasPrefix(line, "{") {
+		if entry, ok := parseJSONLogLine(line); ok {
+			return entry, true
+		}
+	}
+	if mode != LogParseAuto {
+		return LogEntry{}, false
+	}
+	if entry, ok := parseLogfmtLine(line); ok {
+		return entry, true
+	}
+	return parsePlainLogLine(line)
+}
+
+// Keys commonly holding the level, message and timestamp of structured logs.
+var (
+	logLevelKeys     = []string{"level", "lvl", "severity", "levelname", "log.level"}
+	logMessageKeys   = []string{"msg", "message", "log", "error"}
+	logTimestampKeys = []string{"time", "ts", "timestamp", "@timestamp", "asctime"}
+)
+
+// parseJSONLogLine parses a JSON object line, e.g. from zap, logrus, pino or
+// structlog.
+func parseJSONLogLine(line string) (LogEntry, bool) {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &fields); err != nil {
+		return LogEntry{}, false
+	}
+	return structuredLogEntry(fields, line), true
+}
+
+// logfmtPair matches a key=value pair of a logfmt line, with an optionally
+// quoted value.
+var logfmtPair = regexp.MustCompile(`([\w.@-]+)=("(?:[^"\\]|\\.)*"|\S*)`)
+
+// parseLogfmtLine parses a logfmt line such as
+// `time=2024-11-02T15:04:05Z level=error msg="connection refused"`, which
+// must carry a level.
+func parseLogfmtLine(line string) (LogEntry, bool) {
+	fields := make(map[string]interface{})
+	for _, match := range logfmtPair.FindAllStringSubmatch(line, -1) {
+		value := match[2]
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		fields[match[1]] = value
+	}
+
+	entry := structuredLogEntry(fields, line)
+	return entry, entry.Level != ""
+}
+
+// structuredLogEntry returns the entry of a structured line's fields, with
+// the line as its message when no message field is present.
+func structuredLogEntry(fields map[string]interface{}, line string) LogEntry {
+	entry := LogEntry{Message: line}
+	for _, key := range logLevelKeys {
+		if value, ok := fields[key]; ok {
+			entry.Level = normalizeLevel(value)
+			break
+		}
+	}
+	for _, key := range logMessageKeys {
+		if value, ok := fields[key].(string); ok {
+			entry.Message = value
+			break
+		}
+	}
+	for _, key := range logTimestampKeys {
+		if value, ok := fields[key]; ok {
+			entry.Timestamp = parseLogTimestamp(value)
+			break
+		}
+	}
+	return entry
+}
+
+// plainLogLine matches a line with an optional leading timestamp followed by
+// a level, optionally bracketed or followed by a colon, and the message.
+var plainLogLine = regexp.MustCompile(`^(?:(\d{4}[-/]\d{2}[-/]\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)\s+)?\[?(?i:(trace|debug|info|warn|warning|error|err|fatal|panic|critical|severe))\]?:?\s+(.*)$`)
+
+// parsePlainLogLine parses a plain text line with a level, e.g.
+// "2024-11-02 15:04:05,123 ERROR [main] Failed to start" or "[WARN] retrying".
+func parsePlainLogLine(line string) (LogEntry, bool) {
+	match := plainLogLine.FindStringSubmatch(line)
+	if match == nil {
+		return LogEntry{}, false
+	}
+	entry := LogEntry{Level: normalizeLevel(match[2]), Message: match[3]}
+	if match[1] != "" {
+		entry.Timestamp = parseLogTimestamp(match[1])
+	}
+	return entry, true
+}
+
+// logTimestampLayouts are the timestamp formats recognized in log lines.
+var logTimestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05,999999999",
+	"2006/01/02 15:04:05.999999999",
+}
+
+// parseLogTimestamp parses a timestamp string in one of the recognized layouts
+// or a Unix time in seconds or, e.g. from pino, milliseconds. It returns nil
+// when the value is not a recognized timestamp.
+func parseLogTimestamp(value interface{}) *time.Time {
+	switch v := value.(type) {
+	case string:
+		for _, layout := range logTimestampLayouts {
+			if t, err := time.Parse(layout, v); err == nil {
+				t = t.UTC()
+				return &t
+			}
+		}
+	case float64:
+		seconds := v
+		// Millisecond timestamps are past the year 33658 in seconds
+		if seconds > 1e12 {
+			seconds /= 1000
+		}
+		t := time.Unix(0, int64(seconds*float64(time.Second))).UTC()
+		return &t
+	}
+	return nil
+}
+
+// normalizeLevel returns the upper-case level of a level name, or of a
+// numeric bunyan or pino level, folding aliases such as "warning" and "err".
+func normalizeLevel(value interface{}) string {
+	if number, ok := value.(float64); ok {
+		switch {
+		case number >= 60:
+			return "FATAL"
+		case number >= 50:
+			return "ERROR"
+		case number >= 40:
+			return "WARN"
+		case number >= 30:
+			return "INFO"
+		case number >= 20:
+			return "DEBUG"
+		default:
+			return "TRACE"
+		}
+	}
+
+	level, _ := value.(string)
+	switch level = strings.ToUpper(strings.TrimSpace(level)); level {
+	case "WARNING":
+		return "WARN"
+	case "ERR":
+		return "ERROR"
+	case "CRITICAL", "CRIT", "SEVERE", "PANIC", "DPANIC", "EMERG", "ALERT":
+		return "FATAL"
+	default:
+		return level
+	}
+}
+
+// lastErrorEntry returns the last ERROR or FATAL entry.
+func lastErrorEntry(entries []LogEntry) (LogEntry, bool) {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Level == "ERROR" || entries[i].Level == "FATAL" {
+			return entries[i], true
+		}
+	}
+	return LogEntry{}, false
+}
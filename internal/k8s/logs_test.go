@@ -0,0 +1,315 @@
+package k8s
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	ktesting "k8s.io/client-go/testing"
+)
+
+// TestParseLogLines validates parsing captured container logs.
+func TestParseLogLines(t *testing.T) {
+	t.Run("parses JSON lines", func(t *testing.T) {
+		logs := `{"level":"info","ts":1730559845.5,"msg":"starting server"}
+{"level":"warning","time":"2024-11-02T15:04:06Z","message":"slow query"}
+{"level":50,"time":1730559847000,"msg":"connection refused"}
+{"severity":"INFO","msg":"shutting down"}
+`
+		entries := parseLogLines([]byte(logs), LogParseJSON)
+		if len(entries) != 4 {
+			t.Fatalf("Expected 4 entries, got %d", len(entries))
+		}
+		expected := []struct {
+			level, message string
+			at             time.Time
+		}{
+			{"INFO", "starting server", time.Date(2024, time.November, 2, 15, 4, 5, 500000000, time.UTC)},
+			{"WARN", "slow query", time.Date(2024, time.November, 2, 15, 4, 6, 0, time.UTC)},
+			{"ERROR", "connection refused", time.Date(2024, time.November, 2, 15, 4, 7, 0, time.UTC)},
+			{"INFO", "shutting down", time.Time{}},
+		}
+		for i, want := range expected {
+			entry := entries[i]
+			if entry.Level != want.level || entry.Message != want.message {
+				t.Errorf("Expected entry %d %s %q, got %s %q", i, want.level, want.message, entry.Level, entry.Message)
+			}
+			if want.at.IsZero() != (entry.Timestamp == nil) || (entry.Timestamp != nil && !entry.Timestamp.Equal(want.at)) {
+				t.Errorf("Expected entry %d at %v, got %v", i, want.at, entry.Timestamp)
+			}
+		}
+		if entry, ok := lastErrorEntry(entries); !ok || entry.Message != "connection refused" {
+			t.Errorf("Expected the last error line, got %+v", entry)
+		}
+	})
+
+	t.Run("parses plain and logfmt lines", func(t *testing.T) {
+		logs := `2024-11-02 15:04:05,123 INFO [main] Starting application
+time=2024-11-02T15:04:06Z level=warn msg="cache miss rate high" rate=0.4
+[ERROR] Failed to connect to database
+2024-11-02T15:04:08Z FATAL Unable to recover
+java.lang.IllegalStateException: pool exhausted
+	at com.example.Pool.acquire(Pool.java:42)
+Caused by: java.net.ConnectException: refused
+`
+		entries := parseLogLines([]byte(logs), LogParseAuto)
+		if len(entries) != 4 {
+			t.Fatalf("Expected 4 entries, got %d: %+v", len(entries), entries)
+		}
+		for i, level := range []string{"INFO", "WARN", "ERROR", "FATAL"} {
+			if entries[i].Level != level {
+				t.Errorf("Expected entry %d at %s, got %s", i, level, entries[i].Level)
+			}
+		}
+		if entries[0].Message != "[main] Starting application" || entries[0].Timestamp == nil ||
+			!entries[0].Timestamp.Equal(time.Date(2024, time.November, 2, 15, 4, 5, 123000000, time.UTC)) {
+			t.Errorf("Expected the timestamped message, got %+v", entries[0])
+		}
+		if entries[1].Message != "cache miss rate high" {
+			t.Errorf("Expected the logfmt message, got %q", entries[1].Message)
+		}
+		expected := "Unable to recover\njava.lang.IllegalStateException: pool exhausted\n\tat com.example.Pool.acquire(Pool.java:42)\nCaused by: java.net.ConnectException: refused"
+		if entries[3].Message != expected {
+			t.Errorf("Expected the stack trace appended to the fatal message, got %q", entries[3].Message)
+		}
+	})
+
+	t.Run("appends continuation lines to the previous entry", func(t *testing.T) {
+		logs := "ERROR Unhandled exception\n\tat main.go:10\nCaused by: timeout\nINFO done\n"
+		entries := parseLogLines([]byte(logs), LogParseAuto)
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 entries, got %d: %+v", len(entries), entries)
+		}
+		if entries[0].Message != "Unhandled exception\n\tat main.go:10\nCaused by: timeout" {
+			t.Errorf("Expected the stack appended, got %q", entries[0].Message)
+		}
+		if entry, ok := lastErrorEntry(entries); !ok || entry.Level != "ERROR" {
+			t.Errorf("Expected the error entry, got %+v", entry)
+		}
+	})
+
+	t.Run("falls back to raw lines", func(t *testing.T) {
+		logs := "plain output without a level\n{not json\n\n"
+		entries := parseLogLines([]byte(logs), LogParseAuto)
+		if len(entries) != 2 || entries[0].Message != "plain output without a level" || entries[1].Message != "{not json" {
+			t.Errorf("Expected 2 raw entries, got %+v", entries)
+		}
+		if entries[0].Level != "" || entries[0].Timestamp != nil {
+			t.Errorf("Expected no level or timestamp, got %+v", entries[0])
+		}
+		if _, ok := lastErrorEntry(entries); ok {
+			t.Error("Expected no error entry")
+		}
+	})
+
+	t.Run("keeps lines raw in raw mode", func(t *testing.T) {
+		logs := `{"level":"error","msg":"boom"}` + "\nERROR boom\n"
+		entries := parseLogLines([]byte(logs), LogParseRaw)
+		if len(entries) != 2 || entries[0].Message != `{"level":"error","msg":"boom"}` || entries[1].Level != "" {
+			t.Errorf("Expected raw entries, got %+v", entries)
+		}
+	})
+
+	t.Run("JSON mode keeps plain lines raw", func(t *testing.T) {
+		entries := parseLogLines([]byte("ERROR boom\n"), LogParseJSON)
+		if len(entries) != 1 || entries[0].Level != "" || entries[0].Message != "ERROR boom" {
+			t.Errorf("Expected a raw entry, got %+v", entries)
+		}
+	})
+}
+
+// TestCrashReportLogs validates attaching crashed containers' logs to crash reports.
+func TestCrashReportLogs(t *testing.T) {
+	// logOptions returns the options of the log requests made through the clientset.
+	logOptions := func(clientset *fake.Clientset) []*corev1.PodLogOptions {
+		var options []*corev1.PodLogOptions
+		for _, action := range clientset.Actions() {
+			if action.GetSubresource() == "log" {
+				options = append(options, action.(ktesting.GenericAction).GetValue().(*corev1.PodLogOptions))
+			}
+		}
+		return options
+	}
+
+	t.Run("attaches the previous container's last lines", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset()
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(clientset, "", handler)
+		watcher.SetLogCapture(20, LogParseAuto)
+
+		watcher.handlePodEvent(restartedPod("web-1"))
+
+		reports := handler.getCrashReports()
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 crash report, got %d", len(reports))
+		}
+		entries, ok := reports[0].Context["last_log_entries"].([]LogEntry)
+		if !ok || len(entries) != 1 || entries[0].Message != "fake logs" {
+			t.Fatalf("Expected the fake client's log line, got %v", reports[0].Context["last_log_entries"])
+		}
+		options := logOptions(clientset)
+		if len(options) != 1 {
+			t.Fatalf("Expected 1 log request, got %d", len(options))
+		}
+		if options[0].Container != "app" || !options[0].Previous || options[0].TailLines == nil || *options[0].TailLines != 20 {
+			t.Errorf("Expected the previous app container's last 20 lines, got %+v", options[0])
+		}
+	})
+
+	t.Run("is disabled by default", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset()
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(clientset, "", handler)
+
+		watcher.handlePodEvent(restartedPod("web-1"))
+
+		reports := handler.getCrashReports()
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 crash report, got %d", len(reports))
+		}
+		if _, ok := reports[0].Context["last_log_entries"]; ok || len(logOptions(clientset)) != 0 {
+			t.Errorf("Expected no log capture, got %v", reports[0].Context["last_log_entries"])
+		}
+	})
+
+	t.Run("skips reports without a container", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset()
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(clientset, "", handler)
+		watcher.SetLogCapture(50, LogParseAuto)
+
+		watcher.handlePodEvent(failedPod("web-1"))
+
+		if len(handler.getCrashReports()) != 1 || len(logOptions(clientset)) != 0 {
+			t.Errorf("Expected a report without a log request, got %v", logOptions(clientset))
+		}
+	})
+}
+
+// TestLogFetchConcurrency validates bounding the crashed containers' logs read
+// at once.
+func TestLogFetchConcurrency(t *testing.T) {
+	t.Run("skips fetches beyond the limit during a crash storm", func(t *testing.T) {
+		const limit, crashes = 2, 10
+
+		// The fake clientset serializes its reactors, so the log requests are
+		// served by an API server that holds them until released
+		var inFlight, maxInFlight atomic.Int32
+		started := make(chan struct{}, crashes)
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.HasSuffix(r.URL.Path, "/log") {
+				http.NotFound(w, r)
+				return
+			}
+			n := inFlight.Add(1)
+			for {
+				peak := maxInFlight.Load()
+				if n <= peak || maxInFlight.CompareAndSwap(peak, n) {
+					break
+				}
+			}
+			started <- struct{}{}
+			<-release
+			inFlight.Add(-1)
+			w.Write([]byte("fake logs"))
+		}))
+		defer server.Close()
+		clientset, err := kubernetes.NewForConfig(&rest.Config{Host: server.URL})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(clientset, "", handler)
+		watcher.SetLogCapture(20, LogParseAuto)
+		watcher.SetLogFetchConcurrency(limit)
+
+		finished := make(chan struct{}, crashes)
+		for i := 0; i < crashes; i++ {
+			go func(i int) {
+				watcher.handlePodEvent(restartedPod(fmt.Sprintf("web-%d", i)))
+				finished <- struct{}{}
+			}(i)
+		}
+
+		// The fetches holding a slot block until released, so every other
+		// crash is reported once both have started
+		timeout := time.After(5 * time.Second)
+		for fetching, done := 0, 0; fetching < limit || done < crashes-limit; {
+			select {
+			case <-started:
+				fetching++
+			case <-finished:
+				done++
+			case <-timeout:
+				t.Fatalf("Expected %d fetches and %d skipped reports, got %d and %d", limit, crashes-limit, fetching, done)
+			}
+		}
+		close(release)
+		for i := 0; i < limit; i++ {
+			<-finished
+		}
+
+		if peak := maxInFlight.Load(); peak > limit {
+			t.Errorf("Expected at most %d concurrent log fetches, got %d", limit, peak)
+		}
+		if skipped := watcher.LogFetchesSkipped(); skipped != crashes-limit {
+			t.Errorf("Expected %d skipped fetches, got %d", crashes-limit, skipped)
+		}
+
+		reports := handler.getCrashReports()
+		if len(reports) != crashes {
+			t.Fatalf("Expected %d crash reports, got %d", crashes, len(reports))
+		}
+		withLogs := 0
+		for _, report := range reports {
+			if _, ok := report.Context["last_log_entries"]; ok {
+				withLogs++
+			}
+		}
+		if withLogs != limit {
+			t.Errorf("Expected %d reports with logs, got %d", limit, withLogs)
+		}
+	})
+
+	t.Run("releases slots after each fetch", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(fake.NewSimpleClientset(), "", handler)
+		watcher.SetLogCapture(20, LogParseAuto)
+		watcher.SetLogFetchConcurrency(1)
+
+		for i := 0; i < 3; i++ {
+			watcher.handlePodEvent(restartedPod(fmt.Sprintf("web-%d", i)))
+		}
+
+		if skipped := watcher.LogFetchesSkipped(); skipped != 0 {
+			t.Errorf("Expected no skipped fetches, got %d", skipped)
+		}
+		for _, report := range handler.getCrashReports() {
+			if _, ok := report.Context["last_log_entries"]; !ok {
+				t.Errorf("Expected logs on every sequential report, got %v", report.Context)
+			}
+		}
+	})
+
+	t.Run("uses the default limit", func(t *testing.T) {
+		watcher := newPodWatcher(fake.NewSimpleClientset(), "", &mockEventHandler{})
+		if limit := watcher.logs.maxInFlight(); limit != DefaultLogFetchConcurrency {
+			t.Errorf("Expected %d, got %d", DefaultLogFetchConcurrency, limit)
+		}
+
+		watcher.SetLogFetchConcurrency(-1)
+		if limit := watcher.logs.maxInFlight(); limit != DefaultLogFetchConcurrency {
+			t.Errorf("Expected %d, got %d", DefaultLogFetchConcurrency, limit)
+		}
+	})
+}
@@ -0,0 +1,154 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultNodeConditionsTTL is how long a node's conditions are cached, so a
+// storm of crashes on one node costs a single API call per interval.
+const DefaultNodeConditionsTTL = 15 * time.Second
+
+// nodeLookupTimeout bounds the API call getting a node.
+const nodeLookupTimeout = 5 * time.Second
+
+// pressureConditions are the node conditions that explain crashes when true.
+var pressureConditions = map[corev1.NodeConditionType]bool{
+	corev1.NodeMemoryPressure:     true,
+	corev1.NodeDiskPressure:       true,
+	corev1.NodePIDPressure:        true,
+	corev1.NodeNetworkUnavailable: true,
+}
+
+// NodeCondition is a condition of the node a crashed pod ran on, attached to
+// crash reports under "node_conditions".
+type NodeCondition struct {
+	Type               string    `json:"type"`
+	Status             string    `json:"status"`
+	Reason             string    `json:"reason,omitempty"`
+	Message            string    `json:"message,omitempty"`
+	LastTransitionTime time.Time `json:"last_transition_time"`
+}
+
+// nodeConditionCache caches the active conditions of the nodes pods crash on.
+// Failed lookups are cached too, so a missing node or RBAC permission is not
+// retried on every crash.
+type nodeConditionCache struct {
+	mutex sync.Mutex
+	// nodes caches node names to their conditions
+	nodes map[string]nodeConditionEntry
+	// forbidden records that getting nodes was denied, so the missing
+	// permission is only logged once
+	forbidden atomic.Bool
+}
+
+// nodeConditionEntry is a cached node lookup.
+type nodeConditionEntry struct {
+	// conditions are the node's active conditions
+	conditions []NodeCondition
+	// err is the error of a failed lookup
+	err error
+	// expires is when the node is looked up again
+	expires time.Time
+}
+
+// get returns the active conditions of the node: Ready in any status and the
+// pressure conditions that are true. Lookups are cached for ttl.
+func (nc *nodeConditionCache) get(clientset kubernetes.Interface, nodeName string, now time.Time, ttl time.Duration) ([]NodeCondition, error) {
+	nc.mutex.Lock()
+	entry, ok := nc.nodes[nodeName]
+	nc.mutex.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.conditions, entry.err
+	}
+
+	entry = nodeConditionEntry{expires: now.Add(ttl)}
+	entry.conditions, entry.err = nc.lookup(clientset, nodeName)
+
+	nc.mutex.Lock()
+	if nc.nodes == nil {
+		nc.nodes = make(map[string]nodeConditionEntry)
+	}
+	// Only the watched node, and nodes of pods being rescheduled, are looked
+	// up, so expired entries are pruned here rather than bounded by size
+	for name, cached := range nc.nodes {
+		if !now.Before(cached.expires) {
+			delete(nc.nodes, name)
+		}
+	}
+	nc.nodes[nodeName] = entry
+	nc.mutex.Unlock()
+	return entry.conditions, entry.err
+}
+
+// lookup gets the node and returns its active conditions.
+func (nc *nodeConditionCache) lookup(clientset kubernetes.Interface, nodeName string) ([]NodeCondition, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), nodeLookupTimeout)
+	defer cancel()
+
+	node, err := clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsForbidden(err) {
+			fmt.Printf("Error getting node %s: %v\n", nodeName, err)
+		} else if !nc.forbidden.Swap(true) {
+			fmt.Printf("Getting nodes is forbidden; crash reports will not include node_conditions: %v\n", err)
+		}
+		return nil, err
+	}
+
+	var conditions []NodeCondition
+	for _, condition := range node.Status.Conditions {
+		if condition.Type != corev1.NodeReady && !(pressureConditions[condition.Type] && condition.Status == corev1.ConditionTrue) {
+			continue
+		}
+		conditions = append(conditions, NodeCondition{
+			Type:               string(condition.Type),
+			Status:             string(condition.Status),
+			Reason:             condition.Reason,
+			Message:            condition.Message,
+			LastTransitionTime: condition.LastTransitionTime.Time,
+		})
+	}
+	return conditions, nil
+}
+
+// SetNodeConditionsTTL sets how long node conditions attached to crash
+// reports are cached. A TTL of zero or less disables node condition enrichment.
+func (pw *PodWatcher) SetNodeConditionsTTL(ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = -1
+	}
+	pw.nodeConditionsTTL = ttl
+}
+
+// attachNodeConditions adds the active conditions of the pod's node to the
+// report context. Failures, including missing RBAC permission to get nodes,
+// leave the report without node conditions rather than delaying or dropping it.
+func (pw *PodWatcher) attachNodeConditions(pod *corev1.Pod, context map[string]interface{}) {
+	ttl := pw.nodeConditionsTTL
+	if ttl == 0 {
+		ttl = DefaultNodeConditionsTTL
+	}
+	nodeName := pod.Spec.NodeName
+	if nodeName == "" {
+		nodeName = pw.nodeName
+	}
+	clientset := pw.Clientset()
+	if ttl < 0 || nodeName == "" || clientset == nil {
+		return
+	}
+
+	conditions, err := pw.nodeConditions.get(clientset, nodeName, pw.clock.Now(), ttl)
+	if err != nil || len(conditions) == 0 {
+		return
+	}
+	context["node_conditions"] = conditions
+}
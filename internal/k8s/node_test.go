@@ -0,0 +1,122 @@
+package k8s
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	ktesting "k8s.io/client-go/testing"
+)
+
+// pressuredNode returns a node that is ready but under memory pressure.
+func pressuredNode(name string, since time.Time) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue, Reason: "KubeletReady"},
+			{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionTrue, Reason: "KubeletHasInsufficientMemory", Message: "kubelet has insufficient memory available", LastTransitionTime: metav1.NewTime(since)},
+			{Type: corev1.NodeDiskPressure, Status: corev1.ConditionFalse, Reason: "KubeletHasNoDiskPressure"},
+		}},
+	}
+}
+
+// TestCrashReportNodeConditions validates attaching node conditions to crash reports.
+func TestCrashReportNodeConditions(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("attaches active pressure and ready conditions", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(fake.NewSimpleClientset(pressuredNode("node-1", base)), "node-1", handler)
+
+		watcher.handlePodEvent(failedPod("web-1"))
+
+		reports := handler.getCrashReports()
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 crash report, got %d", len(reports))
+		}
+		conditions, ok := reports[0].Context["node_conditions"].([]NodeCondition)
+		if !ok {
+			t.Fatalf("Expected node_conditions in context, got %v", reports[0].Context["node_conditions"])
+		}
+		if len(conditions) != 2 || conditions[0].Type != "Ready" || conditions[1].Type != "MemoryPressure" {
+			t.Fatalf("Expected Ready and MemoryPressure, got %+v", conditions)
+		}
+		if conditions[1].Status != "True" || conditions[1].Reason != "KubeletHasInsufficientMemory" || !conditions[1].LastTransitionTime.Equal(base) {
+			t.Errorf("Expected MemoryPressure details, got %+v", conditions[1])
+		}
+	})
+
+	t.Run("prefers the pod's node", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(fake.NewSimpleClientset(pressuredNode("node-2", base)), "node-1", handler)
+		pod := failedPod("web-1")
+		pod.Spec.NodeName = "node-2"
+
+		watcher.handlePodEvent(pod)
+
+		if _, ok := handler.getCrashReports()[0].Context["node_conditions"]; !ok {
+			t.Error("Expected the conditions of the pod's node")
+		}
+	})
+
+	t.Run("caches conditions during crash storms", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset(pressuredNode("node-1", base))
+		fakeClock := clock.NewFake(base)
+		watcher := newPodWatcher(clientset, "node-1", &mockEventHandler{}, WithClock(fakeClock))
+		watcher.SetPodEventLimit(0)
+
+		for i := 0; i < 5; i++ {
+			watcher.handlePodEvent(failedPod(fmt.Sprintf("web-%d", i)))
+		}
+		if len(clientset.Actions()) != 1 {
+			t.Errorf("Expected one node lookup, got %d API calls", len(clientset.Actions()))
+		}
+
+		fakeClock.Advance(DefaultNodeConditionsTTL)
+		watcher.handlePodEvent(failedPod("web-5"))
+		if len(clientset.Actions()) != 2 {
+			t.Errorf("Expected the node looked up again after %v, got %d API calls", DefaultNodeConditionsTTL, len(clientset.Actions()))
+		}
+	})
+
+	t.Run("can be disabled", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(fake.NewSimpleClientset(pressuredNode("node-1", base)), "node-1", handler)
+		watcher.SetNodeConditionsTTL(0)
+
+		watcher.handlePodEvent(failedPod("web-1"))
+
+		if _, ok := handler.getCrashReports()[0].Context["node_conditions"]; ok {
+			t.Error("Expected no node_conditions when disabled")
+		}
+	})
+
+	t.Run("reports crash when getting nodes is forbidden", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset()
+		clientset.PrependReactor("get", "nodes", func(action ktesting.Action) (bool, runtime.Object, error) {
+			return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "nodes"}, "node-1", fmt.Errorf("RBAC denied"))
+		})
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(clientset, "node-1", handler)
+
+		watcher.handlePodEvent(failedPod("web-1"))
+
+		reports := handler.getCrashReports()
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 crash report, got %d", len(reports))
+		}
+		if _, ok := reports[0].Context["node_conditions"]; ok {
+			t.Error("Expected no node_conditions when forbidden")
+		}
+		if !watcher.nodeConditions.forbidden.Load() {
+			t.Error("Expected forbidden lookup to be recorded")
+		}
+	})
+}
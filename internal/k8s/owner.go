@@ -0,0 +1,271 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ownerLookupTimeout bounds each API call made while resolving a pod's workload
+	ownerLookupTimeout = 5 * time.Second
+	// ownerCacheSize bounds the intermediate owners kept by the resolver
+	ownerCacheSize = 1024
+	// ownerCacheTTL is how long a resolved intermediate owner is kept, so owners
+	// deleted by rollouts or finished jobs age out
+	ownerCacheTTL = 10 * time.Minute
+	// ownerErrorTTL is how long a failed lookup is remembered before retrying,
+	// so a missing owner or RBAC permission costs one API call per interval
+	ownerErrorTTL = 30 * time.Second
+)
+
+// Workload identifies the top-level controller that owns a pod, such as the
+// Deployment behind a ReplicaSet or the CronJob behind a Job.
+type Workload struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+}
+
+// String returns the workload in kubectl-style "kind/name" form, e.g. "deployment/foo".
+func (w Workload) String() string {
+	return strings.ToLower(w.Kind) + "/" + w.Name
+}
+
+// ownerResolver walks pod owner references up to the top-level workload.
+// Intermediate owners (ReplicaSets and Jobs) are cached since their controller
+// never changes once set. The cache holds at most ownerCacheSize entries, each
+// expiring so deleted owners do not accumulate, and failed lookups are cached
+// for ownerErrorTTL.
+type ownerResolver struct {
+	mutex sync.Mutex
+	// parents caches "kind/namespace/name" of intermediate owners to their controller
+	parents map[string]ownerEntry
+}
+
+// ownerEntry is a cached intermediate owner lookup.
+type ownerEntry struct {
+	// parent is the controller of the intermediate owner (nil when it has none)
+	parent *metav1.OwnerReference
+	// err is the error of a failed lookup
+	err error
+	// expires is when the entry is looked up again
+	expires time.Time
+}
+
+// errNoClientset reports that an intermediate owner could not be looked up
+// because the watcher has no clientset.
+var errNoClientset = fmt.Errorf("no kubernetes clientset")
+
+// resolve returns the top-level workload owning the pod. Bare pods without a
+// controller return false. When the clientset is nil or a lookup fails, the
+// nearest known owner is returned with the error instead of walking further.
+func (or *ownerResolver) resolve(clientset kubernetes.Interface, pod *corev1.Pod, now time.Time) (Workload, bool, error) {
+	owner := metav1.GetControllerOf(pod)
+	if owner == nil {
+		return Workload{}, false, nil
+	}
+
+	workload := Workload{Kind: owner.Kind, Name: owner.Name, Namespace: pod.Namespace}
+	if owner.Kind != "ReplicaSet" && owner.Kind != "Job" {
+		return workload, true, nil
+	}
+
+	if clientset == nil {
+		return workload, true, errNoClientset
+	}
+
+	parent, err := or.parentOf(clientset, owner.Kind, pod.Namespace, owner.Name, now)
+	if err != nil {
+		return workload, true, err
+	}
+	if parent == nil {
+		return workload, true, nil
+	}
+
+	return Workload{Kind: parent.Kind, Name: parent.Name, Namespace: pod.Namespace}, true, nil
+}
+
+// inferParent guesses the workload behind an intermediate owner whose lookup
+// failed from the names its controller generates: a Deployment names its
+// ReplicaSets "<name>-<pod-template-hash>" and a CronJob its Jobs
+// "<name>-<scheduled minute>". It returns false when the name does not fit.
+func inferParent(owner Workload, pod *corev1.Pod) (Workload, bool) {
+	dash := strings.LastIndex(owner.Name, "-")
+	if dash <= 0 {
+		return Workload{}, false
+	}
+	name, suffix := owner.Name[:dash], owner.Name[dash+1:]
+
+	switch owner.Kind {
+	case "ReplicaSet":
+		if hash := pod.Labels["pod-template-hash"]; hash != "" && hash != suffix {
+			return Workload{}, false
+		}
+		return Workload{Kind: "Deployment", Name: name, Namespace: owner.Namespace}, true
+	case "Job":
+		if _, err := strconv.ParseUint(suffix, 10, 64); err != nil {
+			return Workload{}, false
+		}
+		return Workload{Kind: "CronJob", Name: name, Namespace: owner.Namespace}, true
+	default:
+		return Workload{}, false
+	}
+}
+
+// parentOf returns the controller of an intermediate owner, consulting the cache first.
+func (or *ownerResolver) parentOf(clientset kubernetes.Interface, kind, namespace, name string, now time.Time) (*metav1.OwnerReference, error) {
+	key := kind + "/" + namespace + "/" + name
+
+	or.mutex.Lock()
+	if entry, ok := or.parents[key]; ok && now.Before(entry.expires) {
+		or.mutex.Unlock()
+		return entry.parent, entry.err
+	}
+	or.mutex.Unlock()
+
+	parent, err := lookupParent(clientset, kind, namespace, name)
+	entry := ownerEntry{parent: parent, err: err, expires: now.Add(ownerCacheTTL)}
+	if err != nil {
+		entry.expires = now.Add(ownerErrorTTL)
+		fmt.Printf("Error resolving the owner of %s %s/%s (workload filters match it by name): %v\n", kind, namespace, name, err)
+	}
+
+	or.mutex.Lock()
+	or.store(key, entry, now)
+	or.mutex.Unlock()
+
+	return parent, err
+}
+
+// store caches an entry, evicting expired entries and then the entry closest
+// to expiry when the cache is full. The caller must hold the mutex.
+func (or *ownerResolver) store(key string, entry ownerEntry, now time.Time) {
+	if or.parents == nil {
+		or.parents = make(map[string]ownerEntry)
+	}
+
+	if _, ok := or.parents[key]; !ok && len(or.parents) >= ownerCacheSize {
+		oldest := ""
+		for k, e := range or.parents {
+			if !now.Before(e.expires) {
+				delete(or.parents, k)
+				continue
+			}
+			if oldest == "" || e.expires.Before(or.parents[oldest].expires) {
+				oldest = k
+			}
+		}
+		if len(or.parents) >= ownerCacheSize {
+			delete(or.parents, oldest)
+		}
+	}
+	or.parents[key] = entry
+}
+
+// lookupParent fetches an intermediate owner from the API and returns its controller.
+func lookupParent(clientset kubernetes.Interface, kind, namespace, name string) (*metav1.OwnerReference, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), ownerLookupTimeout)
+	defer cancel()
+
+	switch kind {
+	case "ReplicaSet":
+		rs, err := clientset.AppsV1().ReplicaSets(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return metav1.GetControllerOf(rs), nil
+	case "Job":
+		job, err := clientset.BatchV1().Jobs(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		return metav1.GetControllerOf(job), nil
+	default:
+		return nil, fmt.Errorf("unsupported owner kind: %s", kind)
+	}
+}
+
+// podOwner resolves the workload owning a pod at most once while one pod
+// event is handled, and only if the filter or a crash report needs it.
+type podOwner struct {
+	pw  *PodWatcher
+	pod *corev1.Pod
+	// resolved reports whether workload, ok and err hold the resolution
+	resolved bool
+	workload Workload
+	ok       bool
+	// err is the error that stopped resolution at an intermediate owner
+	err error
+}
+
+// ownerOf returns the lazily resolved owner of the pod for one event.
+func (pw *PodWatcher) ownerOf(pod *corev1.Pod) *podOwner {
+	return &podOwner{pw: pw, pod: pod}
+}
+
+// get returns the workload owning the pod, resolving it on first use.
+func (po *podOwner) get() (Workload, bool) {
+	if !po.resolved {
+		po.workload, po.ok, po.err = po.pw.owners.resolve(po.pw.Clientset(), po.pod, po.pw.clock.Now())
+		po.resolved = true
+	}
+	return po.workload, po.ok
+}
+
+// matches reports whether the pod's workload matches the filter. When
+// resolution stopped at an intermediate owner because of a failed lookup, the
+// workload inferred from the owner's name is matched too, so crashes of
+// filtered workloads are not dropped while the API is unreachable or RBAC
+// forbids reading ReplicaSets or Jobs.
+func (po *podOwner) matches(filter map[string]bool) bool {
+	workload, ok := po.get()
+	if !ok {
+		return false
+	}
+	if matchesWorkloadFilter(filter, workload) {
+		return true
+	}
+	if po.err == nil {
+		return false
+	}
+	inferred, ok := inferParent(workload, po.pod)
+	return ok && matchesWorkloadFilter(filter, inferred)
+}
+
+// parseWorkloadFilter parses workload filter entries of the form "kind/name" or
+// "namespace/kind/name" into a set of normalized keys.
+func parseWorkloadFilter(workloads []string) (map[string]bool, error) {
+	filter := make(map[string]bool, len(workloads))
+	for _, workload := range workloads {
+		parts := strings.Split(strings.TrimSpace(workload), "/")
+		for _, part := range parts {
+			if part == "" {
+				return nil, fmt.Errorf("invalid workload %q: expected kind/name or namespace/kind/name", workload)
+			}
+		}
+
+		switch len(parts) {
+		case 2:
+			filter[strings.ToLower(parts[0])+"/"+parts[1]] = true
+		case 3:
+			filter[parts[0]+"/"+strings.ToLower(parts[1])+"/"+parts[2]] = true
+		default:
+			return nil, fmt.Errorf("invalid workload %q: expected kind/name or namespace/kind/name", workload)
+		}
+	}
+	return filter, nil
+}
+
+// matchesWorkloadFilter reports whether the workload matches a filter entry,
+// either by kind/name in any namespace or by its fully qualified name.
+func matchesWorkloadFilter(filter map[string]bool, workload Workload) bool {
+	return filter[workload.String()] || filter[workload.Namespace+"/"+workload.String()]
+}
@@ -0,0 +1,355 @@
+package k8s
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// controllerRef builds a controller owner reference for test objects.
+func controllerRef(apiVersion, kind, name string) metav1.OwnerReference {
+	isController := true
+	return metav1.OwnerReference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Name:       name,
+		Controller: &isController,
+	}
+}
+
+// deploymentChain returns a fake clientset holding a ReplicaSet owned by the
+// named Deployment, and a failed pod owned by that ReplicaSet.
+func deploymentChain(namespace, deployment string) (*fake.Clientset, *corev1.Pod) {
+	replicaSet := &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            deployment + "-7d4b9c",
+			Namespace:       namespace,
+			OwnerReferences: []metav1.OwnerReference{controllerRef("apps/v1", "Deployment", deployment)},
+		},
+	}
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            deployment + "-7d4b9c-x2x9q",
+			Namespace:       namespace,
+			OwnerReferences: []metav1.OwnerReference{controllerRef("apps/v1", "ReplicaSet", replicaSet.Name)},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodFailed,
+		},
+	}
+
+	return fake.NewSimpleClientset(replicaSet), pod
+}
+
+// TestWorkloadResolution validates owner-chain resolution to the top-level workload.
+func TestWorkloadResolution(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("resolves pod through replicaset to deployment", func(t *testing.T) {
+		clientset, pod := deploymentChain("default", "checkout")
+		resolver := &ownerResolver{}
+
+		workload, ok, _ := resolver.resolve(clientset, pod, now)
+		if !ok {
+			t.Fatal("Expected workload to be resolved")
+		}
+		if workload.Kind != "Deployment" || workload.Name != "checkout" {
+			t.Errorf("Expected Deployment checkout, got %s %s", workload.Kind, workload.Name)
+		}
+		if workload.String() != "deployment/checkout" {
+			t.Errorf("Expected 'deployment/checkout', got %q", workload.String())
+		}
+	})
+
+	t.Run("caches intermediate owners", func(t *testing.T) {
+		clientset, pod := deploymentChain("default", "checkout")
+		resolver := &ownerResolver{}
+
+		resolver.resolve(clientset, pod, now)
+		actions := len(clientset.Actions())
+		resolver.resolve(clientset, pod, now)
+
+		if len(clientset.Actions()) != actions {
+			t.Errorf("Expected cached lookup, got %d additional API calls", len(clientset.Actions())-actions)
+		}
+	})
+
+	t.Run("expires cached owners", func(t *testing.T) {
+		clientset, pod := deploymentChain("default", "checkout")
+		resolver := &ownerResolver{}
+
+		resolver.resolve(clientset, pod, now)
+		actions := len(clientset.Actions())
+		resolver.resolve(clientset, pod, now.Add(ownerCacheTTL))
+
+		if len(clientset.Actions()) != actions+1 {
+			t.Errorf("Expected expired owner to be looked up again, got %d additional API calls", len(clientset.Actions())-actions)
+		}
+	})
+
+	t.Run("caches failed lookups", func(t *testing.T) {
+		_, pod := deploymentChain("default", "checkout")
+		clientset := fake.NewSimpleClientset()
+		resolver := &ownerResolver{}
+
+		resolver.resolve(clientset, pod, now)
+		resolver.resolve(clientset, pod, now.Add(ownerErrorTTL-time.Second))
+		if len(clientset.Actions()) != 1 {
+			t.Errorf("Expected failed lookup to be cached, got %d API calls", len(clientset.Actions()))
+		}
+
+		resolver.resolve(clientset, pod, now.Add(ownerErrorTTL))
+		if len(clientset.Actions()) != 2 {
+			t.Errorf("Expected retry after %v, got %d API calls", ownerErrorTTL, len(clientset.Actions()))
+		}
+	})
+
+	t.Run("bounds the cache", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset()
+		resolver := &ownerResolver{}
+
+		for i := 0; i < ownerCacheSize+10; i++ {
+			_, pod := deploymentChain("default", fmt.Sprintf("app-%d", i))
+			resolver.resolve(clientset, pod, now.Add(time.Duration(i)*time.Millisecond))
+		}
+
+		if len(resolver.parents) != ownerCacheSize {
+			t.Errorf("Expected %d cached owners, got %d", ownerCacheSize, len(resolver.parents))
+		}
+		if _, ok := resolver.parents["ReplicaSet/default/app-0-7d4b9c"]; ok {
+			t.Error("Expected the oldest owner to be evicted")
+		}
+	})
+
+	t.Run("resolves statefulset directly", func(t *testing.T) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "ledger-0",
+				Namespace:       "payments",
+				OwnerReferences: []metav1.OwnerReference{controllerRef("apps/v1", "StatefulSet", "ledger")},
+			},
+		}
+		resolver := &ownerResolver{}
+
+		workload, ok, _ := resolver.resolve(fake.NewSimpleClientset(), pod, now)
+		if !ok || workload.String() != "statefulset/ledger" {
+			t.Errorf("Expected statefulset/ledger, got %q (ok=%v)", workload.String(), ok)
+		}
+	})
+
+	t.Run("falls back to replicaset when lookup fails", func(t *testing.T) {
+		_, pod := deploymentChain("default", "checkout")
+		resolver := &ownerResolver{}
+
+		workload, ok, err := resolver.resolve(fake.NewSimpleClientset(), pod, now)
+		if !ok || workload.Kind != "ReplicaSet" || err == nil {
+			t.Errorf("Expected ReplicaSet fallback with the lookup error, got %q (ok=%v, err=%v)", workload.String(), ok, err)
+		}
+	})
+
+	t.Run("handles bare pods", func(t *testing.T) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "bare", Namespace: "default"},
+		}
+		resolver := &ownerResolver{}
+
+		if _, ok, _ := resolver.resolve(fake.NewSimpleClientset(), pod, now); ok {
+			t.Error("Expected bare pod to have no workload")
+		}
+	})
+}
+
+// TestCrashReportWorkloadContext validates that crash incidents carry the owning workload.
+func TestCrashReportWorkloadContext(t *testing.T) {
+	t.Run("attributes crash to deployment", func(t *testing.T) {
+		clientset, pod := deploymentChain("default", "checkout")
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(clientset, "", handler)
+
+		watcher.handlePodEvent(pod)
+
+		reports := handler.getCrashReports()
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 crash report, got %d", len(reports))
+		}
+		if reports[0].Context["workload"] != "deployment/checkout" {
+			t.Errorf("Expected workload 'deployment/checkout', got %v", reports[0].Context["workload"])
+		}
+		if reports[0].Context["workload_kind"] != "Deployment" {
+			t.Errorf("Expected workload kind 'Deployment', got %v", reports[0].Context["workload_kind"])
+		}
+	})
+
+	t.Run("resolves the owner once per event", func(t *testing.T) {
+		_, pod := deploymentChain("default", "checkout")
+		clientset := fake.NewSimpleClientset()
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(clientset, "", handler)
+		watcher.SetPodEventLimit(0)
+		if err := watcher.SetWorkloadFilter([]string{"replicaset/checkout-7d4b9c"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		watcher.handlePodEvent(pod)
+
+		if len(handler.getCrashReports()) != 1 {
+			t.Fatalf("Expected 1 crash report, got %d", len(handler.getCrashReports()))
+		}
+		if len(clientset.Actions()) != 1 {
+			t.Errorf("Expected 1 owner lookup, got %d API calls", len(clientset.Actions()))
+		}
+	})
+
+	t.Run("omits workload for bare pods", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(fake.NewSimpleClientset(), "", handler)
+
+		watcher.handlePodEvent(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "bare", Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+		})
+
+		reports := handler.getCrashReports()
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 crash report, got %d", len(reports))
+		}
+		if _, ok := reports[0].Context["workload"]; ok {
+			t.Errorf("Expected no workload for bare pod, got %v", reports[0].Context["workload"])
+		}
+	})
+}
+
+// TestWorkloadFilter validates restricting the watcher to configured workloads.
+func TestWorkloadFilter(t *testing.T) {
+	t.Run("processes pods of watched workloads", func(t *testing.T) {
+		clientset, pod := deploymentChain("default", "checkout")
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(clientset, "", handler)
+		if err := watcher.SetWorkloadFilter([]string{"deployment/checkout"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		watcher.handlePodEvent(pod)
+
+		if len(handler.getCrashReports()) != 1 {
+			t.Errorf("Expected crash report for watched workload, got %d", len(handler.getCrashReports()))
+		}
+	})
+
+	t.Run("matches namespace-qualified entries", func(t *testing.T) {
+		clientset, pod := deploymentChain("shop", "checkout")
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(clientset, "", handler)
+		if err := watcher.SetWorkloadFilter([]string{"default/deployment/checkout"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		watcher.handlePodEvent(pod)
+
+		if len(handler.getCrashReports()) != 0 {
+			t.Errorf("Expected pod in other namespace to be ignored, got %d reports", len(handler.getCrashReports()))
+		}
+	})
+
+	t.Run("ignores other workloads and bare pods", func(t *testing.T) {
+		clientset, pod := deploymentChain("default", "checkout")
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(clientset, "", handler)
+		if err := watcher.SetWorkloadFilter([]string{"Deployment/cart"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		watcher.handlePodEvent(pod)
+		watcher.handlePodEvent(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "bare", Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+		})
+
+		if reports := handler.getCrashReports(); len(reports) != 0 {
+			t.Errorf("Expected no crash reports, got %d", len(reports))
+		}
+	})
+
+	t.Run("matches owners by name when the lookup fails", func(t *testing.T) {
+		jobPod := func(job string) *corev1.Pod {
+			return &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:            job + "-abcde",
+					Namespace:       "default",
+					OwnerReferences: []metav1.OwnerReference{controllerRef("batch/v1", "Job", job)},
+				},
+				Status: corev1.PodStatus{Phase: corev1.PodFailed},
+			}
+		}
+		_, deploymentPod := deploymentChain("default", "checkout")
+		deploymentPod.Labels = map[string]string{"pod-template-hash": "7d4b9c"}
+		_, otherPod := deploymentChain("default", "cart")
+
+		handler := &mockEventHandler{}
+		// The ReplicaSets and Jobs are missing, so every lookup fails
+		watcher := newPodWatcher(fake.NewSimpleClientset(), "", handler)
+		if err := watcher.SetWorkloadFilter([]string{"deployment/checkout", "cronjob/backup"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		watcher.handlePodEvent(deploymentPod)
+		watcher.handlePodEvent(jobPod("backup-28374650"))
+		watcher.handlePodEvent(otherPod)
+		watcher.handlePodEvent(jobPod("backup-manual"))
+
+		reports := handler.getCrashReports()
+		if len(reports) != 2 {
+			t.Fatalf("Expected crash reports of the two filtered workloads, got %d", len(reports))
+		}
+		if reports[0].Context["workload"] != "replicaset/checkout-7d4b9c" || reports[1].Context["workload"] != "job/backup-28374650" {
+			t.Errorf("Expected the nearest known owners in the reports, got %v and %v", reports[0].Context["workload"], reports[1].Context["workload"])
+		}
+	})
+
+	t.Run("does not infer owners of resolved replicasets", func(t *testing.T) {
+		replicaSet := &appsv1.ReplicaSet{ObjectMeta: metav1.ObjectMeta{Name: "checkout-7d4b9c", Namespace: "default"}}
+		_, pod := deploymentChain("default", "checkout")
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(fake.NewSimpleClientset(replicaSet), "", handler)
+		if err := watcher.SetWorkloadFilter([]string{"deployment/checkout"}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		watcher.handlePodEvent(pod)
+
+		if reports := handler.getCrashReports(); len(reports) != 0 {
+			t.Errorf("Expected the bare ReplicaSet's pod ignored, got %d reports", len(reports))
+		}
+	})
+
+	t.Run("rejects malformed entries", func(t *testing.T) {
+		watcher := &PodWatcher{}
+		for _, entry := range []string{"checkout", "a/b/c/d", "deployment/"} {
+			if err := watcher.SetWorkloadFilter([]string{entry}); err == nil {
+				t.Errorf("Expected error for workload %q", entry)
+			}
+		}
+	})
+
+	t.Run("empty filter watches all pods", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(nil, "", handler)
+		watcher.SetWorkloadFilter(nil)
+
+		watcher.handlePodEvent(&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "bare", Namespace: "default"},
+			Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+		})
+
+		if reports := handler.getCrashReports(); len(reports) != 1 || reports[0].Type != types.IncidentCrash {
+			t.Errorf("Expected 1 crash report, got %d", len(reports))
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package k8s
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// IncidentProbeFailure is the incident type for containers that stop passing
+// their readiness, liveness or startup probes.
+const IncidentProbeFailure types.IncidentType = "probe_failure"
+
+// DefaultProbeDebounce is how long further probe failures of a container are
+// folded into the next report instead of being reported individually.
+const DefaultProbeDebounce = 5 * time.Minute
+
+// probeTracker remembers container readiness between pod updates so that
+// probe failures are reported on Ready true→false transitions, debounced per
+// container.
+type probeTracker struct {
+	// mutex protects the maps below
+	mutex sync.Mutex
+	// ready holds the last observed Ready state per container
+	ready map[string]bool
+	// lastReported holds when a probe failure was last reported per container
+	lastReported map[string]time.Time
+	// suppressed counts failures within the debounce window since the last report
+	suppressed map[string]int
+}
+
+// transition records a container's readiness and returns whether a probe
+// failure should be reported, along with the failures since the last report.
+func (pt *probeTracker) transition(key string, ready bool, now time.Time, debounce time.Duration) (bool, int) {
+	pt.mutex.Lock()
+	defer pt.mutex.Unlock()
+
+	if pt.ready == nil {
+		pt.ready = make(map[string]bool)
+		pt.lastReported = make(map[string]time.Time)
+		pt.suppressed = make(map[string]int)
+	}
+
+	wasReady, known := pt.ready[key]
+	pt.ready[key] = ready
+	// Containers that have never been ready are still starting up
+	if !known || !wasReady || ready {
+		return false, 0
+	}
+
+	failures := pt.suppressed[key] + 1
+	if last, ok := pt.lastReported[key]; ok && now.Sub(last) < debounce {
+		pt.suppressed[key] = failures
+		return false, 0
+	}
+	pt.lastReported[key] = now
+	delete(pt.suppressed, key)
+	return true, failures
+}
+
+// forget drops the state of a deleted pod's containers.
+func (pt *probeTracker) forget(pod *corev1.Pod) {
+	pt.mutex.Lock()
+	defer pt.mutex.Unlock()
+
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		key := probeKey(pod, containerStatus.Name)
+		delete(pt.ready, key)
+		delete(pt.lastReported, key)
+		delete(pt.suppressed, key)
+	}
+}
+
+// probeKey identifies a container across pod updates.
+func probeKey(pod *corev1.Pod, container string) string {
+	return fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.Name, container)
+}
+
+// SetProbeDebounce sets how long repeated probe failures of a container are
+// suppressed after one is reported. Zero uses DefaultProbeDebounce.
+func (pw *PodWatcher) SetProbeDebounce(debounce time.Duration) {
+	pw.probeDebounce = debounce
+}
+
+// checkProbeFailures reports running containers whose Ready state flipped to
+// false. A container that failed again while debounced is reported with
+// medium rather than low severity once the window has passed.
+func (pw *PodWatcher) checkProbeFailures(owner *podOwner) {
+	pod := owner.pod
+	debounce := pw.probeDebounce
+	if debounce <= 0 {
+		debounce = DefaultProbeDebounce
+	}
+
+	now := pw.clock.Now()
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		// Stopped containers are reported as crashes
+		if containerStatus.State.Running == nil {
+			continue
+		}
+
+		report, failures := pw.probes.transition(probeKey(pod, containerStatus.Name), containerStatus.Ready, now, debounce)
+		if !report {
+			continue
+		}
+
+		severity := types.SeverityLow
+		if failures > 1 {
+			severity = types.SeverityMedium
+		}
+		probe := probeType(pod, containerStatus)
+		reason, message := readinessCondition(pod)
+
+		pw.reportCrash(owner, types.IncidentReport{
+			ID:          fmt.Sprintf("probe-failure-%s-%s-%d", pod.Name, containerStatus.Name, now.Unix()),
+			Timestamp:   now,
+			PodName:     pod.Name,
+			Namespace:   pod.Namespace,
+			ContainerID: containerStatus.ContainerID,
+			Severity:    severity,
+			Type:        IncidentProbeFailure,
+			Message:     fmt.Sprintf("Container %s in pod %s/%s is failing its %s probe", containerStatus.Name, pod.Namespace, pod.Name, probe),
+			Context: map[string]interface{}{
+				"container_name": containerStatus.Name,
+				"probe":          probe,
+				"failures":       failures,
+				"restart_count":  containerStatus.RestartCount,
+				"reason":         reason,
+				"message":        message,
+			},
+		})
+	}
+}
+
+// probeType names the probe that most likely marked the container unready,
+// based on the probes defined in the pod spec.
+func probeType(pod *corev1.Pod, status corev1.ContainerStatus) string {
+	for _, container := range pod.Spec.Containers {
+		if container.Name != status.Name {
+			continue
+		}
+		switch {
+		case container.StartupProbe != nil && status.Started != nil && !*status.Started:
+			return "startup"
+		case container.ReadinessProbe != nil:
+			return "readiness"
+		case container.LivenessProbe != nil:
+			return "liveness"
+		}
+	}
+	return "readiness"
+}
+
+// readinessCondition returns the reason and message of the pod's failing
+// ContainersReady (or Ready) condition, e.g. "ContainersNotReady" and
+// "containers with unready status: [app]".
+func readinessCondition(pod *corev1.Pod) (string, string) {
+	var reason, message string
+	for _, condition := range pod.Status.Conditions {
+		if condition.Status != corev1.ConditionFalse {
+			continue
+		}
+		switch condition.Type {
+		case corev1.ContainersReady:
+			return condition.Reason, condition.Message
+		case corev1.PodReady:
+			reason, message = condition.Reason, condition.Message
+		}
+	}
+	return reason, message
+}
@@ -0,0 +1,158 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// probePod builds a running pod whose app container has a readiness probe and
+// the given Ready state.
+func probePod(ready bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "prod"},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:           "app",
+				ReadinessProbe: &corev1.Probe{},
+			}},
+		},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:        "app",
+				ContainerID: "containerd://app",
+				Ready:       ready,
+				State:       corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}},
+		},
+	}
+	if !ready {
+		pod.Status.Conditions = []corev1.PodCondition{{
+			Type:    corev1.ContainersReady,
+			Status:  corev1.ConditionFalse,
+			Reason:  "ContainersNotReady",
+			Message: "containers with unready status: [app]",
+		}}
+	}
+	return pod
+}
+
+// probeReports returns the probe failure incidents among the handler's reports.
+func probeReports(handler *mockEventHandler) []types.IncidentReport {
+	var reports []types.IncidentReport
+	for _, report := range handler.getCrashReports() {
+		if report.Type == IncidentProbeFailure {
+			reports = append(reports, report)
+		}
+	}
+	return reports
+}
+
+// TestProbeFailures validates reporting containers whose Ready condition flips to false.
+func TestProbeFailures(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("reports ready to unready transition", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(nil, "", handler, WithClock(clock.NewFake(base)))
+
+		watcher.handlePodEvent(probePod(true))
+		watcher.handlePodEvent(probePod(false))
+
+		reports := probeReports(handler)
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 probe failure, got %d", len(reports))
+		}
+		report := reports[0]
+		if report.Severity != types.SeverityLow {
+			t.Errorf("Expected severity low, got %s", report.Severity)
+		}
+		if report.ContainerID != "containerd://app" {
+			t.Errorf("Expected container ID containerd://app, got %s", report.ContainerID)
+		}
+		if report.Context["probe"] != "readiness" {
+			t.Errorf("Expected probe readiness, got %v", report.Context["probe"])
+		}
+		if report.Context["message"] != "containers with unready status: [app]" {
+			t.Errorf("Expected condition message, got %v", report.Context["message"])
+		}
+	})
+
+	t.Run("ignores containers that were never ready", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(nil, "", handler)
+
+		watcher.handlePodEvent(probePod(false))
+		watcher.handlePodEvent(probePod(false))
+
+		if reports := probeReports(handler); len(reports) != 0 {
+			t.Errorf("Expected no probe failures while starting up, got %d", len(reports))
+		}
+	})
+
+	t.Run("debounces flapping probes", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		fake := clock.NewFake(base)
+		watcher := newPodWatcher(nil, "", handler, WithClock(fake))
+		watcher.SetProbeDebounce(time.Minute)
+
+		for i := 0; i < 3; i++ {
+			watcher.handlePodEvent(probePod(true))
+			watcher.handlePodEvent(probePod(false))
+			fake.Advance(10 * time.Second)
+		}
+		if reports := probeReports(handler); len(reports) != 1 {
+			t.Fatalf("Expected 1 probe failure within the debounce window, got %d", len(reports))
+		}
+
+		fake.Advance(time.Minute)
+		watcher.handlePodEvent(probePod(true))
+		watcher.handlePodEvent(probePod(false))
+
+		reports := probeReports(handler)
+		if len(reports) != 2 {
+			t.Fatalf("Expected 2 probe failures after the debounce window, got %d", len(reports))
+		}
+		if reports[1].Severity != types.SeverityMedium {
+			t.Errorf("Expected repeated failures to be medium severity, got %s", reports[1].Severity)
+		}
+		if reports[1].Context["failures"] != 3 {
+			t.Errorf("Expected 3 failures since the last report, got %v", reports[1].Context["failures"])
+		}
+	})
+
+	t.Run("names liveness and startup probes", func(t *testing.T) {
+		pod := probePod(false)
+		pod.Spec.Containers[0].ReadinessProbe = nil
+		pod.Spec.Containers[0].LivenessProbe = &corev1.Probe{}
+		if probe := probeType(pod, pod.Status.ContainerStatuses[0]); probe != "liveness" {
+			t.Errorf("Expected liveness, got %s", probe)
+		}
+
+		started := false
+		pod.Spec.Containers[0].StartupProbe = &corev1.Probe{}
+		pod.Status.ContainerStatuses[0].Started = &started
+		if probe := probeType(pod, pod.Status.ContainerStatuses[0]); probe != "startup" {
+			t.Errorf("Expected startup, got %s", probe)
+		}
+	})
+
+	t.Run("forgets deleted pods", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(nil, "", handler)
+
+		watcher.handlePodEvent(probePod(true))
+		watcher.handleWatchEvent(watch.Event{Type: watch.Deleted, Object: probePod(true)})
+		watcher.handlePodEvent(probePod(false))
+
+		if reports := probeReports(handler); len(reports) != 0 {
+			t.Errorf("Expected no probe failure for a recreated pod, got %d", len(reports))
+		}
+	})
+}
@@ -0,0 +1,62 @@
+package k8s
+
+import (
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ContainerImage is the image a container of a crashed pod runs, attached to
+// crash reports under "container_images" so a crash can be correlated with
+// the rollout that introduced it.
+type ContainerImage struct {
+	// Container is the container name
+	Container string `json:"container"`
+	// Image is the image reference the container runs, e.g. "registry/api:v2"
+	Image string `json:"image"`
+	// ImageID is the image the kubelet actually pulled, as reported in the
+	// container status (empty before the image is pulled)
+	ImageID string `json:"image_id,omitempty"`
+	// Digest is the content digest taken from ImageID, e.g. "sha256:..."
+	Digest string `json:"digest,omitempty"`
+}
+
+// attachRevision adds the images of the pod's containers and the revision
+// hash labels set by its controller to the report context. Deployments label
+// pods with "pod-template-hash", StatefulSets and DaemonSets with
+// "controller-revision-hash"; both change whenever the pod template does.
+func attachRevision(pod *corev1.Pod, context map[string]interface{}) {
+	if len(pod.Status.ContainerStatuses) > 0 {
+		images := make([]ContainerImage, 0, len(pod.Status.ContainerStatuses))
+		for _, status := range pod.Status.ContainerStatuses {
+			images = append(images, ContainerImage{
+				Container: status.Name,
+				Image:     status.Image,
+				ImageID:   status.ImageID,
+				Digest:    imageDigest(status.ImageID),
+			})
+		}
+		context["container_images"] = images
+	}
+
+	if hash := pod.Labels[appsv1.DefaultDeploymentUniqueLabelKey]; hash != "" {
+		context["pod_template_hash"] = hash
+	}
+	if hash := pod.Labels[appsv1.ControllerRevisionHashLabelKey]; hash != "" {
+		context["controller_revision_hash"] = hash
+	}
+}
+
+// imageDigest extracts the digest from a container status image ID, which
+// runtimes report as "docker-pullable://repo@sha256:...", "repo@sha256:..." or
+// a bare "sha256:..." image ID.
+func imageDigest(imageID string) string {
+	if i := strings.LastIndex(imageID, "@"); i >= 0 {
+		return imageID[i+1:]
+	}
+	if strings.HasPrefix(imageID, "sha256:") {
+		return imageID
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package k8s
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestCrashReportRevision validates attaching container images and revision
+// hashes to crash reports.
+func TestCrashReportRevision(t *testing.T) {
+	t.Run("attaches image digests and revision hashes", func(t *testing.T) {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "api-7d9f8b6c5-x2k4p",
+				Namespace: "default",
+				Labels:    map[string]string{"app": "api", "pod-template-hash": "7d9f8b6c5"},
+			},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{
+					{
+						Name:         "app",
+						Image:        "registry.example.com/api:v2.3.1",
+						ImageID:      "docker-pullable://registry.example.com/api@sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945",
+						ContainerID:  "containerd://abc123",
+						RestartCount: 1,
+						State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+						LastTerminationState: corev1.ContainerState{
+							Terminated: &corev1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"},
+						},
+					},
+					{
+						Name:    "proxy",
+						Image:   "envoyproxy/envoy:v1.30",
+						ImageID: "sha256:9b1c4e0f7a55e1a3b4c8d2e6f0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3",
+						State:   corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+					},
+				},
+			},
+		}
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(fake.NewSimpleClientset(), "", handler)
+
+		watcher.handlePodEvent(pod)
+
+		reports := handler.getCrashReports()
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 crash report, got %d", len(reports))
+		}
+		images, ok := reports[0].Context["container_images"].([]ContainerImage)
+		if !ok || len(images) != 2 {
+			t.Fatalf("Expected 2 container images in context, got %v", reports[0].Context["container_images"])
+		}
+		if images[0].Container != "app" || images[0].Image != "registry.example.com/api:v2.3.1" {
+			t.Errorf("Expected app image, got %+v", images[0])
+		}
+		if images[0].Digest != "sha256:4f53cda18c2baa0c0354bb5f9a3ecbe5ed12ab4d8e11ba873c2f11161202b945" {
+			t.Errorf("Expected app digest, got %q", images[0].Digest)
+		}
+		if images[1].Digest != "sha256:9b1c4e0f7a55e1a3b4c8d2e6f0a1b2c3d4e5f60718293a4b5c6d7e8f90a1b2c3" {
+			t.Errorf("Expected bare image ID as proxy digest, got %q", images[1].Digest)
+		}
+		if reports[0].Context["pod_template_hash"] != "7d9f8b6c5" {
+			t.Errorf("Expected pod template hash, got %v", reports[0].Context["pod_template_hash"])
+		}
+		if _, ok := reports[0].Context["controller_revision_hash"]; ok {
+			t.Error("Expected no controller revision hash for a Deployment pod")
+		}
+	})
+
+	t.Run("attaches the controller revision hash", func(t *testing.T) {
+		pod := failedPod("ledger-0")
+		pod.Labels = map[string]string{"controller-revision-hash": "ledger-5b7c9d"}
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(fake.NewSimpleClientset(), "", handler)
+
+		watcher.handlePodEvent(pod)
+
+		context := handler.getCrashReports()[0].Context
+		if context["controller_revision_hash"] != "ledger-5b7c9d" {
+			t.Errorf("Expected controller revision hash, got %v", context["controller_revision_hash"])
+		}
+		if _, ok := context["container_images"]; ok {
+			t.Error("Expected no container images without container statuses")
+		}
+	})
+}
+
+// TestImageDigest validates extracting digests from container status image IDs.
+func TestImageDigest(t *testing.T) {
+	tests := map[string]string{
+		"docker-pullable://nginx@sha256:abc": "sha256:abc",
+		"docker.io/library/nginx@sha256:abc": "sha256:abc",
+		"sha256:abc":                         "sha256:abc",
+		"nginx:1.25":                         "",
+		"":                                   "",
+	}
+	for imageID, expected := range tests {
+		if digest := imageDigest(imageID); digest != expected {
+			t.Errorf("Expected digest %q for %q, got %q", expected, imageID, digest)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package k8s
+
+import (
+	"context"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/cri"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// ContainerRuntime reads a container's details from the container runtime,
+// e.g. a cri.Client.
+type ContainerRuntime interface {
+	ContainerInfo(ctx context.Context, containerID string) (*cri.ContainerInfo, error)
+}
+
+// SetContainerRuntime enables attaching the container runtime's details of a
+// crashed container, such as its final memory usage and OOM score, to crash
+// reports. A nil runtime disables the enrichment.
+func (pw *PodWatcher) SetContainerRuntime(runtime ContainerRuntime) {
+	pw.runtime = runtime
+}
+
+// attachRuntime adds the runtime's details of the crashed container to the
+// report context under "runtime". For a restarted container the previous,
+// crashed, container is looked up. Failures, including an unreachable
+// runtime, leave the report without runtime details rather than delaying or
+// dropping it.
+func (pw *PodWatcher) attachRuntime(report types.IncidentReport) {
+	containerID, _ := report.Context["last_container_id"].(string)
+	if containerID == "" {
+		containerID = report.ContainerID
+	}
+	if pw.runtime == nil || containerID == "" {
+		return
+	}
+
+	info, err := pw.runtime.ContainerInfo(context.Background(), containerID)
+	if err != nil {
+		return
+	}
+	report.Context["runtime"] = info
+}
@@ -0,0 +1,113 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/cri"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeRuntime returns canned container details and records the IDs asked for.
+type fakeRuntime struct {
+	// containers maps container IDs to their details
+	containers map[string]*cri.ContainerInfo
+	// requested records the container IDs looked up
+	requested []string
+}
+
+// ContainerInfo returns the container's details or an error when unknown.
+func (fr *fakeRuntime) ContainerInfo(ctx context.Context, containerID string) (*cri.ContainerInfo, error) {
+	fr.requested = append(fr.requested, containerID)
+	info, ok := fr.containers[containerID]
+	if !ok {
+		return nil, fmt.Errorf("container %s not found", containerID)
+	}
+	return info, nil
+}
+
+// restartedPod returns a running pod whose container was OOM killed and
+// restarted.
+func restartedPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:         "app",
+				ContainerID:  "containerd://new",
+				RestartCount: 1,
+				State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(time.Now())}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					ExitCode:    137,
+					Reason:      "OOMKilled",
+					ContainerID: "containerd://old",
+				}},
+			}},
+		},
+	}
+}
+
+// TestCrashReportRuntime validates attaching container runtime details to crash reports.
+func TestCrashReportRuntime(t *testing.T) {
+	oomScore := 984
+	crashed := &cri.ContainerInfo{ID: "old", State: "exited", ExitCode: 137, Reason: "OOMKilled", OOMScoreAdj: &oomScore,
+		Stats: &cri.ContainerStats{MemoryWorkingSetBytes: 268_435_456}}
+
+	t.Run("attaches the crashed container's details", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		runtime := &fakeRuntime{containers: map[string]*cri.ContainerInfo{"containerd://old": crashed}}
+		watcher := newPodWatcher(fake.NewSimpleClientset(), "", handler)
+		watcher.SetContainerRuntime(runtime)
+
+		watcher.handlePodEvent(restartedPod("web-1"))
+
+		reports := handler.getCrashReports()
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 crash report, got %d", len(reports))
+		}
+		if len(runtime.requested) != 1 || runtime.requested[0] != "containerd://old" {
+			t.Errorf("Expected the crashed container looked up, got %v", runtime.requested)
+		}
+		if reports[0].Context["last_container_id"] != "containerd://old" {
+			t.Errorf("Expected last_container_id, got %v", reports[0].Context["last_container_id"])
+		}
+		info, ok := reports[0].Context["runtime"].(*cri.ContainerInfo)
+		if !ok || info != crashed {
+			t.Fatalf("Expected runtime details in context, got %v", reports[0].Context["runtime"])
+		}
+	})
+
+	t.Run("reports without runtime details when the lookup fails", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		watcher := newPodWatcher(fake.NewSimpleClientset(), "", handler)
+		watcher.SetContainerRuntime(&fakeRuntime{})
+
+		watcher.handlePodEvent(restartedPod("web-1"))
+
+		reports := handler.getCrashReports()
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 crash report, got %d", len(reports))
+		}
+		if _, ok := reports[0].Context["runtime"]; ok {
+			t.Errorf("Expected no runtime details, got %v", reports[0].Context["runtime"])
+		}
+	})
+
+	t.Run("skips reports without a container", func(t *testing.T) {
+		handler := &mockEventHandler{}
+		runtime := &fakeRuntime{}
+		watcher := newPodWatcher(fake.NewSimpleClientset(), "", handler)
+		watcher.SetContainerRuntime(runtime)
+
+		watcher.handlePodEvent(failedPod("web-1"))
+
+		if len(handler.getCrashReports()) != 1 || len(runtime.requested) != 0 {
+			t.Errorf("Expected a report without a runtime lookup, got %v", runtime.requested)
+		}
+	})
+}
@@ -5,10 +5,15 @@ package k8s
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/recovery"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/version"
 	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/watch"
@@ -17,12 +22,93 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 )
 
+const (
+	// defaultAuthRebuildThreshold is the number of consecutive 401 or 403
+	// responses after which the clientset is rebuilt from freshly loaded credentials
+	defaultAuthRebuildThreshold = 3
+	// defaultRetryInterval is the delay before re-establishing a failed watch
+	defaultRetryInterval = 5 * time.Second
+	// defaultAuthBackoffMax caps the delay between watch attempts rejected by
+	// the API server
+	defaultAuthBackoffMax = 5 * time.Minute
+)
+
+// Reasons a failed pod watch is re-established, the values of the "reason"
+// label of blackbox_watcher_reconnects_total.
+const (
+	// ReconnectTransient retries with the same clientset after an error such
+	// as a closed watch or an unreachable API server
+	ReconnectTransient = "transient"
+	// ReconnectAuth retries with the same clientset, after a backoff, once the
+	// API server rejected the watch (401 or 403)
+	ReconnectAuth = "auth"
+	// ReconnectRebuild retries with a clientset rebuilt from freshly loaded
+	// configuration and credentials after persistent rejections
+	ReconnectRebuild = "rebuild"
+)
+
+// ReconnectRecorder counts re-established pod watches by reason.
+type ReconnectRecorder interface {
+	RecordWatcherReconnect(reason string)
+}
+
 // PodWatcher monitors pods on the current node and detects crashes by watching
 // Kubernetes pod events and analyzing container exit codes and restart patterns.
 type PodWatcher struct {
-	clientset    kubernetes.Interface
-	nodeName     string
-	eventHandler EventHandler
+	// clientsetMutex protects clientset, which is rebuilt on persistent auth
+	// failures while other watchers may be reading it
+	clientsetMutex sync.RWMutex
+	clientset      kubernetes.Interface
+	nodeName       string
+	eventHandler   EventHandler
+	// owners resolves pods to the workload (Deployment, StatefulSet, ...) that owns them
+	owners ownerResolver
+	// workloadFilter restricts watching to pods owned by these workloads (nil watches all pods)
+	workloadFilter map[string]bool
+	// clock supplies incident timestamps
+	clock clock.Clock
+	// events fetches recent pod events for crash reports
+	events eventFetcher
+	// podEventLimit caps the events attached to crash reports (0 uses
+	// DefaultPodEventLimit, negative disables enrichment)
+	podEventLimit int
+	// nodeConditions caches the conditions of nodes for crash reports
+	nodeConditions nodeConditionCache
+	// nodeConditionsTTL is how long node conditions are cached (0 uses
+	// DefaultNodeConditionsTTL, negative disables enrichment)
+	nodeConditionsTTL time.Duration
+	// recoverer turns panics while handling a pod event into self-incidents
+	recoverer *recovery.Recoverer
+	// newClientset reloads the client configuration and builds a fresh clientset
+	// (nil disables rebuilding on persistent auth failures)
+	newClientset func() (kubernetes.Interface, error)
+	// authStreak counts consecutive 401 and 403 watch errors, driving the
+	// backoff and the clientset rebuilds
+	authStreak int
+	// authRebuildThreshold is the number of consecutive 401 or 403 errors that
+	// rebuilds the clientset (0 uses defaultAuthRebuildThreshold, negative
+	// disables rebuilding)
+	authRebuildThreshold int
+	// authBackoffMax caps the backoff after 401 or 403 errors (0 uses defaultAuthBackoffMax)
+	authBackoffMax time.Duration
+	// reconnects counts re-established watches (nil disables counting)
+	reconnects ReconnectRecorder
+	// retryInterval is the delay between watch attempts (0 uses defaultRetryInterval)
+	retryInterval time.Duration
+	// probes tracks container readiness to detect probe failures
+	probes probeTracker
+	// probeDebounce suppresses repeated probe failures (0 uses DefaultProbeDebounce)
+	probeDebounce time.Duration
+	// runtime reads crashed containers' details from the container runtime
+	// (nil disables the enrichment)
+	runtime ContainerRuntime
+	// logs reads the last log lines of crashed containers
+	logs logFetcher
+	// logLines is how many log lines are attached to crash reports (zero or
+	// less disables the capture)
+	logLines int
+	// logParseMode selects how captured log lines are parsed
+	logParseMode LogParseMode
 }
 
 // EventHandler defines the interface for handling pod events and lifecycle changes.
@@ -34,30 +120,178 @@ type EventHandler interface {
 
 // NewPodWatcher creates a new Kubernetes pod watcher that monitors pods on the specified node.
 // It supports both in-cluster configuration and external kubeconfig files.
-func NewPodWatcher(kubeConfig, nodeName string, eventHandler EventHandler) (*PodWatcher, error) {
-	var config *rest.Config
-	var err error
+//
+// Both configurations read the bearer token from a file (BearerTokenFile) that
+// client-go re-reads periodically, so rotated service account tokens are picked
+// up without a restart. If the API server still rejects requests persistently,
+// the watcher reloads the configuration and rebuilds its clientset.
+func NewPodWatcher(kubeConfig, nodeName string, eventHandler EventHandler, opts ...Option) (*PodWatcher, error) {
+	newClientset := func() (kubernetes.Interface, error) {
+		var config *rest.Config
+		var err error
+
+		if kubeConfig != "" {
+			config, err = clientcmd.BuildConfigFromFlags("", kubeConfig)
+		} else {
+			config, err = rest.InClusterConfig()
+		}
 
-	if kubeConfig != "" {
-		config, err = clientcmd.BuildConfigFromFlags("", kubeConfig)
-	} else {
-		config, err = rest.InClusterConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes config: %w", err)
+		}
+
+		clientset, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
+		}
+		return clientset, nil
 	}
 
+	clientset, err := newClientset()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create kubernetes config: %w", err)
+		return nil, err
 	}
 
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
+	pw := newPodWatcher(clientset, nodeName, eventHandler, opts...)
+	pw.newClientset = newClientset
+	return pw, nil
+}
+
+// Option configures a PodWatcher.
+type Option func(*PodWatcher)
+
+// WithClock sets the clock used to timestamp incident reports. Defaults to the system clock.
+func WithClock(c clock.Clock) Option {
+	return func(pw *PodWatcher) {
+		pw.clock = c
 	}
+}
 
-	return &PodWatcher{
+// newPodWatcher creates a watcher using the given clientset, without
+// rebuilding it on auth failures.
+func newPodWatcher(clientset kubernetes.Interface, nodeName string, eventHandler EventHandler, opts ...Option) *PodWatcher {
+	pw := &PodWatcher{
 		clientset:    clientset,
 		nodeName:     nodeName,
 		eventHandler: eventHandler,
-	}, nil
+		clock:        clock.Real{},
+	}
+	for _, opt := range opts {
+		opt(pw)
+	}
+	return pw
+}
+
+// Clientset returns the watcher's current Kubernetes client, so other watchers
+// such as a ConfigMapWatcher can share it.
+func (pw *PodWatcher) Clientset() kubernetes.Interface {
+	pw.clientsetMutex.RLock()
+	defer pw.clientsetMutex.RUnlock()
+	return pw.clientset
+}
+
+// SetRecoverer guards pod event handling so that a panic while processing one
+// event is reported as a self-incident instead of stopping the watcher.
+func (pw *PodWatcher) SetRecoverer(recoverer *recovery.Recoverer) {
+	pw.recoverer = recoverer
+}
+
+// SetAuthRecovery sets how the watcher recovers from the API server rejecting
+// its watch with 401 Unauthorized or 403 Forbidden, e.g. after credentials
+// expired or its RBAC permissions changed. Rejected watches are retried with a
+// delay doubling from the retry interval up to maxBackoff, and the clientset
+// is rebuilt from freshly loaded configuration after threshold consecutive
+// rejections. A threshold of zero uses the default of 3 and a negative one
+// disables rebuilding; a maxBackoff of zero uses the default of 5 minutes.
+func (pw *PodWatcher) SetAuthRecovery(threshold int, maxBackoff time.Duration) {
+	pw.authRebuildThreshold = threshold
+	pw.authBackoffMax = maxBackoff
+}
+
+// SetReconnectRecorder counts every re-established pod watch by reason
+// (ReconnectTransient, ReconnectAuth or ReconnectRebuild).
+func (pw *PodWatcher) SetReconnectRecorder(recorder ReconnectRecorder) {
+	pw.reconnects = recorder
+}
+
+// SetPodEventLimit sets how many recent pod events are attached to crash
+// reports. A limit of zero or less disables event enrichment.
+func (pw *PodWatcher) SetPodEventLimit(limit int) {
+	if limit <= 0 {
+		limit = -1
+	}
+	pw.podEventLimit = limit
+}
+
+// SetWorkloadFilter restricts the watcher to pods owned by the given workloads.
+// Entries take the form "kind/name" (any namespace) or "namespace/kind/name",
+// e.g. "deployment/api" or "payments/statefulset/ledger". Bare pods without an
+// owner are ignored while a filter is set. An empty list watches all pods.
+func (pw *PodWatcher) SetWorkloadFilter(workloads []string) error {
+	if len(workloads) == 0 {
+		pw.workloadFilter = nil
+		return nil
+	}
+
+	filter, err := parseWorkloadFilter(workloads)
+	if err != nil {
+		return err
+	}
+	pw.workloadFilter = filter
+	return nil
+}
+
+// shouldWatch reports whether events for the pod should be processed given the
+// configured workload filter.
+func (pw *PodWatcher) shouldWatch(owner *podOwner) bool {
+	if pw.workloadFilter == nil {
+		return true
+	}
+
+	return owner.matches(pw.workloadFilter)
+}
+
+// reportCrash enriches a crash report with pod-level context and forwards it
+// to the event handler.
+func (pw *PodWatcher) reportCrash(owner *podOwner, report types.IncidentReport) {
+	pod := owner.pod
+	if report.Context == nil {
+		report.Context = make(map[string]interface{})
+	}
+
+	if workload, ok := owner.get(); ok {
+		report.Context["workload"] = workload.String()
+		report.Context["workload_kind"] = workload.Kind
+		report.Context["workload_name"] = workload.Name
+	}
+	attachRevision(pod, report.Context)
+	pw.attachEvents(pod, report.Context)
+	pw.attachLogs(pod, report.Context)
+	pw.attachNodeConditions(pod, report.Context)
+	pw.attachRuntime(report)
+	version.Tag(report.Context)
+
+	pw.eventHandler.OnPodCrash(report)
+}
+
+// attachEvents adds the pod's recent Kubernetes events to the report context.
+// Failures, including missing RBAC permission to list events, leave the
+// report without events rather than delaying or dropping it.
+func (pw *PodWatcher) attachEvents(pod *corev1.Pod, context map[string]interface{}) {
+	limit := pw.podEventLimit
+	if limit == 0 {
+		limit = DefaultPodEventLimit
+	}
+	clientset := pw.Clientset()
+	if limit < 0 || clientset == nil {
+		return
+	}
+
+	events, err := pw.events.recent(clientset, pod, limit)
+	if err != nil || len(events) == 0 {
+		return
+	}
+	context["k8s_events"] = events
 }
 
 // Start begins monitoring pods on the node, synchronizing initial state and watching
@@ -72,17 +306,116 @@ func (pw *PodWatcher) Start(ctx context.Context) error {
 	fieldSelector := fields.OneTermEqualSelector("spec.nodeName", pw.nodeName).String()
 
 	for {
+		err := pw.watchPods(ctx, fieldSelector)
+		// A watch ended by the cancellation is not a failure to retry
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		// The auth backoff can reach minutes, so shutdown must not wait it out
+		timer := time.NewTimer(pw.handleWatchError(err))
 		select {
 		case <-ctx.Done():
+			timer.Stop()
 			return ctx.Err()
-		default:
-			if err := pw.watchPods(ctx, fieldSelector); err != nil {
-				fmt.Printf("Pod watcher error (retrying): %v\n", err)
-				time.Sleep(5 * time.Second)
-				continue
-			}
+		case <-timer.C:
+		}
+	}
+}
+
+// retryDelay returns the delay before re-establishing a failed watch.
+func (pw *PodWatcher) retryDelay() time.Duration {
+	if pw.retryInterval <= 0 {
+		return defaultRetryInterval
+	}
+	return pw.retryInterval
+}
+
+// isAuthError reports whether the API server rejected a request for its
+// credentials (401) or permissions (403), which retrying with the same
+// clientset may never fix.
+func isAuthError(err error) bool {
+	return apierrors.IsUnauthorized(err) || apierrors.IsForbidden(err)
+}
+
+// handleWatchError logs a failed watch, rebuilds the clientset once 401 or
+// 403 errors persist, and returns the delay before the next attempt.
+// Transient errors are retried after the retry interval and reset the
+// streak. Rejections are retried with a backoff and logged once per streak
+// rather than on every attempt; every threshold rejections in a row rebuild
+// the clientset, so a rebuilt clientset that is rejected too, or a failed
+// rebuild, is rebuilt again after as many more. The rebuild recovers from
+// credentials that client-go's token file reload cannot fix, e.g. a replaced
+// kubeconfig or CA bundle.
+func (pw *PodWatcher) handleWatchError(err error) time.Duration {
+	if !isAuthError(err) {
+		pw.authStreak = 0
+		fmt.Printf("Pod watcher error (retrying): %v\n", err)
+		pw.recordReconnect(ReconnectTransient)
+		return pw.retryDelay()
+	}
+
+	pw.authStreak++
+	if pw.authStreak == 1 {
+		fmt.Printf("Pod watcher rejected by the API server (retrying with backoff): %v\n", err)
+	}
+
+	reason := ReconnectAuth
+	if threshold := pw.rebuildThreshold(); threshold > 0 && pw.authStreak%threshold == 0 && pw.newClientset != nil {
+		if pw.rebuildClientset() {
+			reason = ReconnectRebuild
 		}
 	}
+	pw.recordReconnect(reason)
+	return pw.authBackoff()
+}
+
+// rebuildClientset replaces the clientset with one built from freshly loaded
+// configuration, reporting whether it succeeded.
+func (pw *PodWatcher) rebuildClientset() bool {
+	clientset, err := pw.newClientset()
+	if err != nil {
+		fmt.Printf("Failed to rebuild kubernetes clientset after %d rejected watches: %v\n", pw.authStreak, err)
+		return false
+	}
+	fmt.Printf("Rebuilt kubernetes clientset after %d rejected watches\n", pw.authStreak)
+	pw.clientsetMutex.Lock()
+	pw.clientset = clientset
+	pw.clientsetMutex.Unlock()
+	return true
+}
+
+// rebuildThreshold returns the consecutive rejections that rebuild the
+// clientset, or zero or less when rebuilding is disabled.
+func (pw *PodWatcher) rebuildThreshold() int {
+	if pw.authRebuildThreshold == 0 {
+		return defaultAuthRebuildThreshold
+	}
+	return pw.authRebuildThreshold
+}
+
+// authBackoff returns the delay after the current streak of rejections: the
+// retry interval doubled for each consecutive rejection, capped at the maximum.
+func (pw *PodWatcher) authBackoff() time.Duration {
+	max := pw.authBackoffMax
+	if max <= 0 {
+		max = defaultAuthBackoffMax
+	}
+	delay := pw.retryDelay()
+	for i := 1; i < pw.authStreak && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// recordReconnect counts a re-established watch when a recorder is set.
+func (pw *PodWatcher) recordReconnect(reason string) {
+	if pw.reconnects != nil {
+		pw.reconnects.RecordWatcherReconnect(reason)
+	}
 }
 
 // syncInitialPods gets the current state of pods on this node and notifies the
@@ -90,7 +423,7 @@ func (pw *PodWatcher) Start(ctx context.Context) error {
 func (pw *PodWatcher) syncInitialPods(ctx context.Context) error {
 	fieldSelector := fields.OneTermEqualSelector("spec.nodeName", pw.nodeName).String()
 
-	pods, err := pw.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+	pods, err := pw.Clientset().CoreV1().Pods("").List(ctx, metav1.ListOptions{
 		FieldSelector: fieldSelector,
 	})
 	if err != nil {
@@ -98,7 +431,7 @@ func (pw *PodWatcher) syncInitialPods(ctx context.Context) error {
 	}
 
 	for _, pod := range pods.Items {
-		if pod.Status.Phase == corev1.PodRunning {
+		if pod.Status.Phase == corev1.PodRunning && pw.shouldWatch(pw.ownerOf(&pod)) {
 			pw.eventHandler.OnPodStart(&pod)
 		}
 	}
@@ -109,7 +442,7 @@ func (pw *PodWatcher) syncInitialPods(ctx context.Context) error {
 // watchPods watches for pod events on this node using the Kubernetes watch API
 // and processes add, modify, and delete events.
 func (pw *PodWatcher) watchPods(ctx context.Context, fieldSelector string) error {
-	watcher, err := pw.clientset.CoreV1().Pods("").Watch(ctx, metav1.ListOptions{
+	watcher, err := pw.Clientset().CoreV1().Pods("").Watch(ctx, metav1.ListOptions{
 		FieldSelector: fieldSelector,
 		Watch:         true,
 	})
@@ -126,18 +459,34 @@ func (pw *PodWatcher) watchPods(ctx context.Context, fieldSelector string) error
 			if !ok {
 				return fmt.Errorf("watch channel closed")
 			}
-
-			pod, ok := event.Object.(*corev1.Pod)
-			if !ok {
-				continue
+			if event.Type == watch.Error {
+				return apierrors.FromObject(event.Object)
 			}
 
-			switch event.Type {
-			case watch.Added, watch.Modified:
-				pw.handlePodEvent(pod)
-			case watch.Deleted:
-				pw.eventHandler.OnPodStop(pod)
-			}
+			pw.handleWatchEvent(event)
+		}
+	}
+}
+
+// handleWatchEvent dispatches a single watch event, recovering panics when a
+// recoverer is set.
+func (pw *PodWatcher) handleWatchEvent(event watch.Event) {
+	if pw.recoverer != nil {
+		defer pw.recoverer.Recover("pod-watcher")
+	}
+
+	pod, ok := event.Object.(*corev1.Pod)
+	if !ok {
+		return
+	}
+
+	switch event.Type {
+	case watch.Added, watch.Modified:
+		pw.handlePodEvent(pod)
+	case watch.Deleted:
+		pw.probes.forget(pod)
+		if pw.shouldWatch(pw.ownerOf(pod)) {
+			pw.eventHandler.OnPodStop(pod)
 		}
 	}
 }
@@ -149,16 +498,20 @@ func (pw *PodWatcher) handlePodEvent(pod *corev1.Pod) {
 	if pod == nil || pw.eventHandler == nil {
 		return
 	}
-	
-	switch pod.Status.Phase {
-	case corev1.PodRunning:
-		pw.eventHandler.OnPodStart(pod)
 
+	owner := pw.ownerOf(pod)
+	if !pw.shouldWatch(owner) {
+		return
+	}
+
+	now := pw.clock.Now()
+
+	switch pod.Status.Phase {
 	case corev1.PodFailed:
 		// Pod has failed - create incident report
 		report := types.IncidentReport{
-			ID:        fmt.Sprintf("pod-crash-%s-%d", pod.Name, time.Now().Unix()),
-			Timestamp: time.Now(),
+			ID:        fmt.Sprintf("pod-crash-%s-%d", pod.Name, now.Unix()),
+			Timestamp: now,
 			PodName:   pod.Name,
 			Namespace: pod.Namespace,
 			Severity:  types.SeverityCritical,
@@ -170,7 +523,7 @@ func (pw *PodWatcher) handlePodEvent(pod *corev1.Pod) {
 				"phase":   string(pod.Status.Phase),
 			},
 		}
-		pw.eventHandler.OnPodCrash(report)
+		pw.reportCrash(owner, report)
 
 	case corev1.PodSucceeded:
 		// Pod completed successfully
@@ -178,26 +531,38 @@ func (pw *PodWatcher) handlePodEvent(pod *corev1.Pod) {
 	}
 
 	// Check container statuses for crashes
-	pw.checkContainerStatuses(pod)
+	pw.checkContainerStatuses(owner)
+	pw.checkProbeFailures(owner)
+
+	// Notify running pods after their crash reports, so handlers tracking
+	// recovery see a restarted container's crash before its new run
+	if pod.Status.Phase == corev1.PodRunning {
+		pw.eventHandler.OnPodStart(pod)
+	}
 }
 
 // checkContainerStatuses examines individual container statuses for crashes
-func (pw *PodWatcher) checkContainerStatuses(pod *corev1.Pod) {
+func (pw *PodWatcher) checkContainerStatuses(owner *podOwner) {
+	pod := owner.pod
 	// Validate pod is not nil
 	if pod == nil {
 		return
 	}
-	
+
+	now := pw.clock.Now()
 	for _, containerStatus := range pod.Status.ContainerStatuses {
 		// Check for restarts indicating crashes
 		if containerStatus.RestartCount > 0 && containerStatus.State.Running != nil {
 			// Container has been restarted
-			var reason, message string
+			var reason, message, lastContainerID string
 			var exitCode int32
+			var finishedAt metav1.Time
 			if containerStatus.LastTerminationState.Terminated != nil {
 				reason = containerStatus.LastTerminationState.Terminated.Reason
 				message = containerStatus.LastTerminationState.Terminated.Message
 				exitCode = containerStatus.LastTerminationState.Terminated.ExitCode
+				finishedAt = containerStatus.LastTerminationState.Terminated.FinishedAt
+				lastContainerID = containerStatus.LastTerminationState.Terminated.ContainerID
 			}
 
 			var incidentType types.IncidentType = types.IncidentCrash
@@ -210,8 +575,8 @@ func (pw *PodWatcher) checkContainerStatuses(pod *corev1.Pod) {
 			}
 
 			report := types.IncidentReport{
-				ID:          fmt.Sprintf("container-restart-%s-%s-%d", pod.Name, containerStatus.Name, time.Now().Unix()),
-				Timestamp:   time.Now(),
+				ID:          fmt.Sprintf("container-restart-%s-%s-%d", pod.Name, containerStatus.Name, now.Unix()),
+				Timestamp:   now,
 				PodName:     pod.Name,
 				Namespace:   pod.Namespace,
 				ContainerID: containerStatus.ContainerID,
@@ -225,10 +590,16 @@ func (pw *PodWatcher) checkContainerStatuses(pod *corev1.Pod) {
 					"reason":         reason,
 					"message":        message,
 					"started_at":     containerStatus.State.Running.StartedAt,
+					"finished_at":    finishedAt,
 				},
 			}
+			// The report's container is the restarted one; the runtime is
+			// asked about the one that crashed
+			if lastContainerID != "" {
+				report.Context["last_container_id"] = lastContainerID
+			}
 
-			pw.eventHandler.OnPodCrash(report)
+			pw.reportCrash(owner, report)
 		}
 
 		// Check for currently failed containers
@@ -242,8 +613,8 @@ func (pw *PodWatcher) checkContainerStatuses(pod *corev1.Pod) {
 			}
 
 			report := types.IncidentReport{
-				ID:          fmt.Sprintf("container-failed-%s-%s-%d", pod.Name, containerStatus.Name, time.Now().Unix()),
-				Timestamp:   time.Now(),
+				ID:          fmt.Sprintf("container-failed-%s-%s-%d", pod.Name, containerStatus.Name, now.Unix()),
+				Timestamp:   now,
 				PodName:     pod.Name,
 				Namespace:   pod.Namespace,
 				ContainerID: containerStatus.ContainerID,
@@ -259,7 +630,7 @@ func (pw *PodWatcher) checkContainerStatuses(pod *corev1.Pod) {
 				},
 			}
 
-			pw.eventHandler.OnPodCrash(report)
+			pw.reportCrash(owner, report)
 		}
 	}
 }
@@ -268,7 +639,7 @@ func (pw *PodWatcher) checkContainerStatuses(pod *corev1.Pod) {
 func (pw *PodWatcher) GetPodsOnNode(ctx context.Context) ([]*corev1.Pod, error) {
 	fieldSelector := fields.OneTermEqualSelector("spec.nodeName", pw.nodeName).String()
 
-	pods, err := pw.clientset.CoreV1().Pods("").List(ctx, metav1.ListOptions{
+	pods, err := pw.Clientset().CoreV1().Pods("").List(ctx, metav1.ListOptions{
 		FieldSelector: fieldSelector,
 	})
 	if err != nil {
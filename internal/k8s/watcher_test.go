@@ -5,14 +5,24 @@ package k8s
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/recovery"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/version"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/handler"
 	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/fake"
 	ktesting "k8s.io/client-go/testing"
 )
@@ -108,11 +118,7 @@ func TestNewPodWatcher(t *testing.T) {
 func TestPodWatcherCreation(t *testing.T) {
 	handler := &mockEventHandler{}
 
-	watcher := &PodWatcher{
-		clientset:    fake.NewSimpleClientset(),
-		nodeName:     "test-node",
-		eventHandler: handler,
-	}
+	watcher := newPodWatcher(fake.NewSimpleClientset(), "test-node", handler)
 
 	if watcher.clientset == nil {
 		t.Error("Expected clientset to be initialized")
@@ -128,9 +134,7 @@ func TestPodWatcherCreation(t *testing.T) {
 // TestHandlePodEventFailed validates incident report generation when pods fail.
 func TestHandlePodEventFailed(t *testing.T) {
 	handler := &mockEventHandler{}
-	watcher := &PodWatcher{
-		eventHandler: handler,
-	}
+	watcher := newPodWatcher(nil, "", handler)
 
 	failedPod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -166,9 +170,7 @@ func TestHandlePodEventFailed(t *testing.T) {
 // TestHandlePodEventRunning validates running pod start notifications.
 func TestHandlePodEventRunning(t *testing.T) {
 	handler := &mockEventHandler{}
-	watcher := &PodWatcher{
-		eventHandler: handler,
-	}
+	watcher := newPodWatcher(nil, "", handler)
 
 	runningPod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -194,9 +196,7 @@ func TestHandlePodEventRunning(t *testing.T) {
 // TestHandlePodEventSucceeded validates successful pod completion handling.
 func TestHandlePodEventSucceeded(t *testing.T) {
 	handler := &mockEventHandler{}
-	watcher := &PodWatcher{
-		eventHandler: handler,
-	}
+	watcher := newPodWatcher(nil, "", handler)
 
 	succeededPod := &corev1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -224,9 +224,7 @@ func TestHandlePodEventSucceeded(t *testing.T) {
 // TestContainerStatusCrashDetection validates container crash detection logic.
 func TestContainerStatusCrashDetection(t *testing.T) {
 	handler := &mockEventHandler{}
-	watcher := &PodWatcher{
-		eventHandler: handler,
-	}
+	watcher := newPodWatcher(nil, "", handler)
 
 	t.Run("detects container restart", func(t *testing.T) {
 		handler = &mockEventHandler{} // Reset handler
@@ -390,11 +388,7 @@ func TestSyncInitialPods(t *testing.T) {
 	
 	clientset.CoreV1().Pods("").Create(context.Background(), runningPod, metav1.CreateOptions{})
 
-	watcher := &PodWatcher{
-		clientset:    clientset,
-		nodeName:     "test-node",
-		eventHandler: handler,
-	}
+	watcher := newPodWatcher(clientset, "test-node", handler)
 
 	err := watcher.syncInitialPods(context.Background())
 	if err != nil {
@@ -434,10 +428,7 @@ func TestGetPodsOnNode(t *testing.T) {
 		clientset.CoreV1().Pods("").Create(context.Background(), pod, metav1.CreateOptions{})
 	}
 
-	watcher := &PodWatcher{
-		clientset: clientset,
-		nodeName:  "test-node",
-	}
+	watcher := newPodWatcher(clientset, "test-node", nil)
 
 	nodePods, err := watcher.GetPodsOnNode(context.Background())
 	if err != nil {
@@ -467,18 +458,15 @@ func TestWatchPodsIntegration(t *testing.T) {
 		return true, watcher, nil
 	})
 
-	podWatcher := &PodWatcher{
-		clientset:    clientset,
-		nodeName:     "test-node",
-		eventHandler: handler,
-	}
+	podWatcher := newPodWatcher(clientset, "test-node", handler)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Start watching in background
+	done := make(chan error, 1)
 	go func() {
-		_ = podWatcher.watchPods(ctx, "spec.nodeName=test-node")
+		done <- podWatcher.watchPods(ctx, "spec.nodeName=test-node")
 	}()
 
 	// Simulate pod events
@@ -499,8 +487,13 @@ func TestWatchPodsIntegration(t *testing.T) {
 	watcher.Modify(testPod)
 	watcher.Delete(testPod)
 
-	// Give some time for events to be processed
-	time.Sleep(50 * time.Millisecond)
+	// Closing the watch ends watchPods once every event has been processed
+	watcher.Stop()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected watch to end after the watch channel closed")
+	}
 
 	startedPods := handler.getStartedPods()
 	stoppedPods := handler.getStoppedPods()
@@ -519,9 +512,7 @@ func TestWatchPodsIntegration(t *testing.T) {
 // TestErrorHandling validates error scenarios and edge cases.
 func TestErrorHandling(t *testing.T) {
 	t.Run("handles nil event handler gracefully", func(t *testing.T) {
-		watcher := &PodWatcher{
-			eventHandler: nil,
-		}
+		watcher := newPodWatcher(nil, "", nil)
 
 		pod := &corev1.Pod{
 			ObjectMeta: metav1.ObjectMeta{Name: "test-pod"},
@@ -540,9 +531,7 @@ func TestErrorHandling(t *testing.T) {
 
 	t.Run("handles nil pod gracefully", func(t *testing.T) {
 		handler := &mockEventHandler{}
-		watcher := &PodWatcher{
-			eventHandler: handler,
-		}
+		watcher := newPodWatcher(nil, "", handler)
 
 		// Should not panic with nil pod
 		defer func() {
@@ -556,9 +545,7 @@ func TestErrorHandling(t *testing.T) {
 
 	t.Run("handles container status with nil terminated state", func(t *testing.T) {
 		handler := &mockEventHandler{}
-		watcher := &PodWatcher{
-			eventHandler: handler,
-		}
+		watcher := newPodWatcher(nil, "", handler)
 
 		podWithNilTerminated := &corev1.Pod{
 			ObjectMeta: metav1.ObjectMeta{
@@ -596,9 +583,7 @@ func TestErrorHandling(t *testing.T) {
 // TestConcurrentAccess validates thread safety of the event handler.
 func TestConcurrentAccess(t *testing.T) {
 	handler := &mockEventHandler{}
-	watcher := &PodWatcher{
-		eventHandler: handler,
-	}
+	watcher := newPodWatcher(nil, "", handler)
 
 	const numGoroutines = 10
 	const eventsPerGoroutine = 5
@@ -636,3 +621,381 @@ func TestConcurrentAccess(t *testing.T) {
 		t.Errorf("Expected %d started pod events, got %d", expectedEvents, len(startedPods))
 	}
 }
+
+// TestWatcherClock validates that injected clocks drive incident timestamps and IDs.
+func TestWatcherClock(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	handler := &mockEventHandler{}
+	watcher := newPodWatcher(nil, "", handler, WithClock(clock.NewFake(now)))
+
+	watcher.handlePodEvent(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "failed-pod", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+	})
+
+	reports := handler.getCrashReports()
+	if len(reports) != 1 {
+		t.Fatalf("Expected 1 crash report, got %d", len(reports))
+	}
+	if !reports[0].Timestamp.Equal(now) {
+		t.Errorf("Expected timestamp %v, got %v", now, reports[0].Timestamp)
+	}
+	if want := fmt.Sprintf("pod-crash-failed-pod-%d", now.Unix()); reports[0].ID != want {
+		t.Errorf("Expected ID %q, got %q", want, reports[0].ID)
+	}
+}
+
+// chainEventHandler passes the watcher's events to an incident handler chain,
+// as the daemon does.
+type chainEventHandler struct {
+	chain *handler.MultiHandler
+}
+
+// OnPodCrash passes the report down the chain.
+func (c *chainEventHandler) OnPodCrash(report types.IncidentReport) { c.chain.HandleIncident(report) }
+
+// OnPodStart notifies the chain's pod observers.
+func (c *chainEventHandler) OnPodStart(pod *corev1.Pod) { c.chain.OnPodStart(pod) }
+
+// OnPodStop notifies the chain's pod observers.
+func (c *chainEventHandler) OnPodStop(pod *corev1.Pod) { c.chain.OnPodStop(pod) }
+
+// TestWatcherCrashResolution validates that a restarted container running past
+// the resolution handler's quiet period resolves its crash incident.
+func TestWatcherCrashResolution(t *testing.T) {
+	crashed := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	restarted := crashed.Add(45 * time.Second)
+
+	var received []types.IncidentReport
+	resolution, err := handler.CreateHandler(handler.HandlerConfig{Type: "resolution", Config: map[string]interface{}{"quiet_period": "1m"}}, handler.Dependencies{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	chain := handler.NewMultiHandler(resolution, handler.HandlerFunc(func(report types.IncidentReport) {
+		received = append(received, report)
+	}))
+	watcher := newPodWatcher(nil, "", &chainEventHandler{chain: chain}, WithClock(clock.NewFake(restarted.Add(5*time.Minute))))
+	watcher.SetPodEventLimit(0)
+
+	// The restart is reported and recovery observed from a single update
+	watcher.handlePodEvent(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "prod"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{{
+				Name:         "app",
+				ContainerID:  "containerd://new",
+				RestartCount: 1,
+				State:        corev1.ContainerState{Running: &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(restarted)}},
+				LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+					ExitCode:   1,
+					Reason:     "Error",
+					FinishedAt: metav1.NewTime(crashed),
+				}},
+			}},
+		},
+	})
+
+	if len(received) != 2 || received[0].Type != types.IncidentCrash || received[1].Type != handler.IncidentResolved {
+		t.Fatalf("Expected the crash followed by its resolution, got %+v", received)
+	}
+	if received[1].Context["downtime"] != "45s" || received[1].Context["resolved_incident_id"] != received[0].ID {
+		t.Errorf("Expected 45s downtime resolving %s, got %v", received[0].ID, received[1].Context)
+	}
+}
+
+// panickingEventHandler panics on crash reports to exercise the watcher guard.
+type panickingEventHandler struct {
+	mockEventHandler
+}
+
+// OnPodCrash panics unconditionally.
+func (p *panickingEventHandler) OnPodCrash(report types.IncidentReport) {
+	panic("handler failed")
+}
+
+// TestWatcherRecoverer validates that panics while handling pod events become self-incidents.
+func TestWatcherRecoverer(t *testing.T) {
+	var selfIncidents []types.IncidentReport
+	watcher := newPodWatcher(fake.NewSimpleClientset(), "", &panickingEventHandler{})
+	watcher.SetRecoverer(recovery.NewRecoverer(func(report types.IncidentReport) {
+		selfIncidents = append(selfIncidents, report)
+	}))
+
+	failed := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "default"},
+		Status:     corev1.PodStatus{Phase: corev1.PodFailed},
+	}
+	watcher.handleWatchEvent(watch.Event{Type: watch.Modified, Object: failed})
+	watcher.handleWatchEvent(watch.Event{Type: watch.Modified, Object: failed})
+
+	if len(selfIncidents) != 2 {
+		t.Fatalf("Expected 2 self-incidents, got %d", len(selfIncidents))
+	}
+	if selfIncidents[0].Type != recovery.IncidentSelfError || selfIncidents[0].Context["component"] != "pod-watcher" {
+		t.Errorf("Expected pod-watcher self-incident, got %s %v", selfIncidents[0].Type, selfIncidents[0].Context["component"])
+	}
+	if stack, _ := selfIncidents[0].Context["stack"].(string); !strings.Contains(stack, "reportCrash") {
+		t.Errorf("Expected stack through reportCrash, got %q", stack)
+	}
+}
+
+// mockReconnectRecorder records watcher reconnect reasons.
+type mockReconnectRecorder struct {
+	reasons []string
+}
+
+// RecordWatcherReconnect records the reason.
+func (m *mockReconnectRecorder) RecordWatcherReconnect(reason string) {
+	m.reasons = append(m.reasons, reason)
+}
+
+// TestWatcherAuthRebuild validates rebuilding the clientset after persistent unauthorized or forbidden errors.
+func TestWatcherAuthRebuild(t *testing.T) {
+	unauthorized := apierrors.NewUnauthorized("token expired")
+	forbidden := apierrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "", fmt.Errorf("RBAC: access denied"))
+
+	t.Run("rebuilds clientset after persistent 401", func(t *testing.T) {
+		expired := fake.NewSimpleClientset()
+		watchAttempts := 0
+		expired.PrependWatchReactor("pods", func(action ktesting.Action) (bool, watch.Interface, error) {
+			watchAttempts++
+			return true, nil, unauthorized
+		})
+		refreshed := fake.NewSimpleClientset()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		rebuilds := 0
+		watcher := &PodWatcher{
+			clientset:     expired,
+			nodeName:      "test-node",
+			eventHandler:  &mockEventHandler{},
+			retryInterval: time.Millisecond,
+			newClientset: func() (kubernetes.Interface, error) {
+				rebuilds++
+				cancel()
+				return refreshed, nil
+			},
+		}
+
+		if err := watcher.Start(ctx); err != context.Canceled {
+			t.Fatalf("Expected context canceled, got %v", err)
+		}
+		if rebuilds != 1 {
+			t.Errorf("Expected 1 rebuild, got %d", rebuilds)
+		}
+		if watchAttempts != defaultAuthRebuildThreshold {
+			t.Errorf("Expected %d watch attempts before rebuild, got %d", defaultAuthRebuildThreshold, watchAttempts)
+		}
+		if watcher.clientset != refreshed {
+			t.Error("Expected watcher to use the rebuilt clientset")
+		}
+	})
+
+	t.Run("rebuilds clientset after persistent 403", func(t *testing.T) {
+		revoked := fake.NewSimpleClientset()
+		revoked.PrependWatchReactor("pods", func(action ktesting.Action) (bool, watch.Interface, error) {
+			return true, nil, forbidden
+		})
+		refreshed := fake.NewSimpleClientset()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		recorder := &mockReconnectRecorder{}
+		watcher := &PodWatcher{
+			clientset:     revoked,
+			nodeName:      "test-node",
+			eventHandler:  &mockEventHandler{},
+			retryInterval: time.Millisecond,
+			newClientset: func() (kubernetes.Interface, error) {
+				cancel()
+				return refreshed, nil
+			},
+		}
+		watcher.SetAuthRecovery(2, 10*time.Millisecond)
+		watcher.SetReconnectRecorder(recorder)
+
+		if err := watcher.Start(ctx); err != context.Canceled {
+			t.Fatalf("Expected context canceled, got %v", err)
+		}
+		if watcher.clientset != refreshed {
+			t.Error("Expected watcher to use the rebuilt clientset")
+		}
+		expected := []string{ReconnectAuth, ReconnectRebuild}
+		if !reflect.DeepEqual(recorder.reasons, expected) {
+			t.Errorf("Expected reconnects %v, got %v", expected, recorder.reasons)
+		}
+	})
+
+	t.Run("backs off rejected watches", func(t *testing.T) {
+		watcher := &PodWatcher{retryInterval: time.Second}
+		watcher.SetAuthRecovery(-1, 5*time.Second)
+
+		var delays []time.Duration
+		for i := 0; i < 5; i++ {
+			delays = append(delays, watcher.handleWatchError(forbidden))
+		}
+		expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+		if !reflect.DeepEqual(delays, expected) {
+			t.Errorf("Expected delays %v, got %v", expected, delays)
+		}
+
+		if delay := watcher.handleWatchError(fmt.Errorf("watch channel closed")); delay != time.Second {
+			t.Errorf("Expected the retry interval for transient errors, got %v", delay)
+		}
+		if delay := watcher.handleWatchError(forbidden); delay != time.Second {
+			t.Errorf("Expected the backoff to restart after a transient error, got %v", delay)
+		}
+	})
+
+	t.Run("retries transient errors without rebuilding", func(t *testing.T) {
+		rebuilds := 0
+		recorder := &mockReconnectRecorder{}
+		watcher := &PodWatcher{newClientset: func() (kubernetes.Interface, error) {
+			rebuilds++
+			return fake.NewSimpleClientset(), nil
+		}}
+		watcher.SetReconnectRecorder(recorder)
+
+		for i := 0; i < defaultAuthRebuildThreshold+1; i++ {
+			watcher.handleWatchError(fmt.Errorf("connection refused"))
+		}
+
+		if rebuilds != 0 {
+			t.Errorf("Expected no rebuild for transient errors, got %d", rebuilds)
+		}
+		if len(recorder.reasons) != defaultAuthRebuildThreshold+1 || recorder.reasons[0] != ReconnectTransient {
+			t.Errorf("Expected transient reconnects, got %v", recorder.reasons)
+		}
+	})
+
+	t.Run("ignores transient 401", func(t *testing.T) {
+		rebuilds := 0
+		watcher := &PodWatcher{newClientset: func() (kubernetes.Interface, error) {
+			rebuilds++
+			return fake.NewSimpleClientset(), nil
+		}}
+
+		watcher.handleWatchError(unauthorized)
+		watcher.handleWatchError(fmt.Errorf("watch channel closed"))
+		watcher.handleWatchError(unauthorized)
+		watcher.handleWatchError(unauthorized)
+
+		if rebuilds != 0 {
+			t.Errorf("Expected no rebuild for non-consecutive 401s, got %d", rebuilds)
+		}
+	})
+
+	t.Run("surfaces 401 delivered as watch error event", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset()
+		fakeWatch := watch.NewFake()
+		clientset.PrependWatchReactor("pods", func(action ktesting.Action) (bool, watch.Interface, error) {
+			return true, fakeWatch, nil
+		})
+		watcher := newPodWatcher(clientset, "", &mockEventHandler{})
+
+		go fakeWatch.Error(&unauthorized.ErrStatus)
+		err := watcher.watchPods(context.Background(), "spec.nodeName=test-node")
+
+		if !apierrors.IsUnauthorized(err) {
+			t.Errorf("Expected unauthorized error, got %v", err)
+		}
+	})
+}
+
+// TestWatcherStartCancel validates that Start stops promptly on cancellation
+// without treating the cancelled watch as a failure.
+func TestWatcherStartCancel(t *testing.T) {
+	t.Run("returns during backoff", func(t *testing.T) {
+		clientset := fake.NewSimpleClientset()
+		rejected := make(chan struct{}, 1)
+		clientset.PrependWatchReactor("pods", func(action ktesting.Action) (bool, watch.Interface, error) {
+			select {
+			case rejected <- struct{}{}:
+			default:
+			}
+			return true, nil, apierrors.NewUnauthorized("token expired")
+		})
+		watcher := &PodWatcher{
+			clientset:     clientset,
+			nodeName:      "test-node",
+			eventHandler:  &mockEventHandler{},
+			retryInterval: time.Hour,
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() { done <- watcher.Start(ctx) }()
+
+		<-rejected
+		cancel()
+
+		select {
+		case err := <-done:
+			if err != context.Canceled {
+				t.Errorf("Expected context canceled, got %v", err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected Start to return without waiting out the backoff")
+		}
+	})
+
+	t.Run("does not record cancelled watch", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		clientset := fake.NewSimpleClientset()
+		clientset.PrependWatchReactor("pods", func(action ktesting.Action) (bool, watch.Interface, error) {
+			cancel()
+			return true, nil, context.Canceled
+		})
+		recorder := &mockReconnectRecorder{}
+		watcher := &PodWatcher{
+			clientset:     clientset,
+			nodeName:      "test-node",
+			eventHandler:  &mockEventHandler{},
+			retryInterval: time.Hour,
+		}
+		watcher.SetReconnectRecorder(recorder)
+
+		if err := watcher.Start(ctx); err != context.Canceled {
+			t.Fatalf("Expected context canceled, got %v", err)
+		}
+		if len(recorder.reasons) != 0 {
+			t.Errorf("Expected no reconnects for a cancelled watch, got %v", recorder.reasons)
+		}
+	})
+}
+
+// TestCrashReportSchemaVersion validates that every kind of crash report carries
+// the current incident schema version.
+func TestCrashReportSchemaVersion(t *testing.T) {
+	handler := &mockEventHandler{}
+	watcher := newPodWatcher(nil, "", handler)
+	terminated := &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}
+
+	watcher.handlePodEvent(failedPod("failed-pod"))
+	watcher.handlePodEvent(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "crashing-pod", Namespace: "default"},
+		Status: corev1.PodStatus{
+			Phase: corev1.PodRunning,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "restarted", RestartCount: 2, State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}, LastTerminationState: corev1.ContainerState{Terminated: terminated}},
+				{Name: "terminated", State: corev1.ContainerState{Terminated: terminated}},
+			},
+		},
+	})
+
+	reports := handler.getCrashReports()
+	if len(reports) != 3 {
+		t.Fatalf("Expected 3 crash reports, got %d", len(reports))
+	}
+	for _, report := range reports {
+		if version.SchemaVersion(report.Context) != version.IncidentSchemaVersion {
+			t.Errorf("Expected %s to carry schema version %d, got %v", report.ID, version.IncidentSchemaVersion, report.Context[version.ContextSchemaVersionKey])
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// telemetryGaugePrefix prefixes the names of gauges bridged from telemetry entries
+	telemetryGaugePrefix = "blackbox_telemetry_"
+	// DefaultBridgeSeriesLimit caps the series of gauges bridged from telemetry entries
+	DefaultBridgeSeriesLimit = 1000
+)
+
+// Labels that can be added to bridged telemetry gauges.
+const (
+	// BridgeLabelSource is the entry's source, e.g. "system" or "sidecar"
+	BridgeLabelSource = "source"
+	// BridgeLabelPod is the entry's pod_name tag
+	BridgeLabelPod = "pod"
+	// BridgeLabelNamespace is the entry's namespace tag
+	BridgeLabelNamespace = "namespace"
+)
+
+// DefaultBridgeLabels keeps same-named telemetry from different sources on
+// separate series without a series per pod.
+var DefaultBridgeLabels = []string{BridgeLabelSource}
+
+// telemetryGauge is the last value of one bridged series.
+type telemetryGauge struct {
+	// desc describes the series' metric
+	desc *prometheus.Desc
+	// labels are the values of the configured labels
+	labels []string
+	// pod and namespace identify the series to release when the pod is deleted
+	pod, namespace string
+	// value is the last recorded value
+	value float64
+}
+
+// telemetryGauges exposes the last value of each telemetry entry name as a
+// gauge. The names are only known once entries are recorded, so it is
+// registered unchecked and creates a constant gauge per series on every scrape.
+type telemetryGauges struct {
+	mutex sync.Mutex
+	// labelNames are the configured labels, in order
+	labelNames []string
+	// descs maps entry names to their gauge description
+	descs map[string]*prometheus.Desc
+	// series maps the series key to its gauge
+	series map[string]*telemetryGauge
+	// limiter caps the number of series
+	limiter *seriesLimiter
+}
+
+// newTelemetryGauges creates a collector labelling gauges with labelNames
+// (unknown names are ignored) and admitting up to limit series.
+func newTelemetryGauges(labelNames []string, limit int) *telemetryGauges {
+	var known []string
+	for _, name := range labelNames {
+		switch name {
+		case BridgeLabelSource, BridgeLabelPod, BridgeLabelNamespace:
+			known = append(known, name)
+		}
+	}
+	return &telemetryGauges{
+		labelNames: known,
+		descs:      make(map[string]*prometheus.Desc),
+		series:     make(map[string]*telemetryGauge),
+		limiter:    newSeriesLimiter(limit),
+	}
+}
+
+// Describe sends nothing, registering the collector unchecked.
+func (tg *telemetryGauges) Describe(chan<- *prometheus.Desc) {}
+
+// Collect sends a constant gauge for each series.
+func (tg *telemetryGauges) Collect(ch chan<- prometheus.Metric) {
+	tg.mutex.Lock()
+	defer tg.mutex.Unlock()
+
+	for _, series := range tg.series {
+		ch <- prometheus.MustNewConstMetric(series.desc, prometheus.GaugeValue, series.value, series.labels...)
+	}
+}
+
+// set records the value of an entry. Labels that are not configured are left
+// out of the series, so entries differing only in them share it. Values of
+// new series beyond the limit are dropped.
+func (tg *telemetryGauges) set(name, source, pod, namespace string, value float64) {
+	name = sanitizeMetricName(name)
+	labels := make([]string, len(tg.labelNames))
+	seriesPod, seriesNamespace := "", ""
+	for i, label := range tg.labelNames {
+		switch label {
+		case BridgeLabelSource:
+			labels[i] = source
+		case BridgeLabelPod:
+			labels[i], seriesPod = pod, pod
+		case BridgeLabelNamespace:
+			labels[i], seriesNamespace = namespace, namespace
+		}
+	}
+
+	// Keyed by pod and namespace first so release can free a pod's slots
+	keyValues := append([]string{seriesPod, seriesNamespace, name}, labels...)
+	if !tg.limiter.admit(keyValues...) {
+		return
+	}
+	key := strings.Join(keyValues, "\xff")
+
+	tg.mutex.Lock()
+	defer tg.mutex.Unlock()
+
+	series, ok := tg.series[key]
+	if !ok {
+		desc, ok := tg.descs[name]
+		if !ok {
+			desc = prometheus.NewDesc(
+				telemetryGaugePrefix+sanitizeMetricName(name),
+				"Last value of the "+name+" telemetry entries",
+				tg.labelNames, nil,
+			)
+			tg.descs[name] = desc
+		}
+		series = &telemetryGauge{desc: desc, labels: labels, pod: seriesPod, namespace: seriesNamespace}
+		tg.series[key] = series
+	}
+	series.value = value
+}
+
+// release deletes the series of a pod and frees their slots. Without the pod
+// label, series are shared by pods and are kept.
+func (tg *telemetryGauges) release(pod, namespace string) {
+	byPod, byNamespace := false, false
+	for _, label := range tg.labelNames {
+		byPod = byPod || label == BridgeLabelPod
+		byNamespace = byNamespace || label == BridgeLabelNamespace
+	}
+	if !byPod {
+		return
+	}
+	if !byNamespace {
+		namespace = ""
+	}
+
+	tg.mutex.Lock()
+	for key, series := range tg.series {
+		if series.pod == pod && series.namespace == namespace {
+			delete(tg.series, key)
+		}
+	}
+	tg.mutex.Unlock()
+
+	tg.limiter.release(pod, namespace)
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestRecordTelemetry validates bridging telemetry entries to Prometheus gauges.
+func TestRecordTelemetry(t *testing.T) {
+	// sidecarEntry returns a cpu_usage entry submitted by the sidecar of a pod.
+	sidecarEntry := func(pod string, value float64) types.TelemetryEntry {
+		return types.TelemetryEntry{
+			Source: types.SourceSidecar,
+			Type:   types.TypeCPU,
+			Name:   "cpu_usage",
+			Value:  value,
+			Tags:   map[string]string{"pod_name": pod, "namespace": "prod"},
+		}
+	}
+	systemEntry := types.TelemetryEntry{Source: types.SourceSystem, Type: types.TypeCPU, Name: "cpu_usage", Value: 35.0}
+
+	t.Run("keeps same-named entries from different sources apart", func(t *testing.T) {
+		collector := NewCollector(9099, "/metrics")
+
+		collector.RecordTelemetry(systemEntry)
+		collector.RecordTelemetry(sidecarEntry("api-1", 80))
+
+		expected := `
+# HELP blackbox_telemetry_cpu_usage Last value of the cpu_usage telemetry entries
+# TYPE blackbox_telemetry_cpu_usage gauge
+blackbox_telemetry_cpu_usage{source="sidecar"} 80
+blackbox_telemetry_cpu_usage{source="system"} 35
+`
+		if err := testutil.CollectAndCompare(collector.telemetryGauges, strings.NewReader(expected)); err != nil {
+			t.Errorf("Expected a series per source: %v", err)
+		}
+	})
+
+	t.Run("adds the configured pod and namespace labels", func(t *testing.T) {
+		collector := NewCollector(9099, "/metrics", WithBridgeLabels(BridgeLabelSource, BridgeLabelPod, BridgeLabelNamespace))
+
+		collector.RecordTelemetry(systemEntry)
+		collector.RecordTelemetry(sidecarEntry("api-1", 80))
+		collector.RecordTelemetry(sidecarEntry("api-2", 60))
+
+		expected := `
+# HELP blackbox_telemetry_cpu_usage Last value of the cpu_usage telemetry entries
+# TYPE blackbox_telemetry_cpu_usage gauge
+blackbox_telemetry_cpu_usage{namespace="",pod="",source="system"} 35
+blackbox_telemetry_cpu_usage{namespace="prod",pod="api-1",source="sidecar"} 80
+blackbox_telemetry_cpu_usage{namespace="prod",pod="api-2",source="sidecar"} 60
+`
+		if err := testutil.CollectAndCompare(collector.telemetryGauges, strings.NewReader(expected)); err != nil {
+			t.Errorf("Expected a series per pod: %v", err)
+		}
+
+		collector.ReleaseSidecar("api-1", "prod")
+		if count := testutil.CollectAndCount(collector.telemetryGauges); count != 2 {
+			t.Errorf("Expected the released pod's series deleted, got %d series", count)
+		}
+	})
+
+	t.Run("shares series without the pod label", func(t *testing.T) {
+		collector := NewCollector(9099, "/metrics")
+
+		collector.RecordTelemetry(sidecarEntry("api-1", 80))
+		collector.RecordTelemetry(sidecarEntry("api-2", 60))
+		collector.ReleaseSidecar("api-2", "prod")
+
+		if count := testutil.CollectAndCount(collector.telemetryGauges); count != 1 {
+			t.Errorf("Expected a single shared series, got %d", count)
+		}
+	})
+
+	t.Run("drops series beyond the limit", func(t *testing.T) {
+		collector := NewCollector(9099, "/metrics", WithBridgeLabels(BridgeLabelSource, BridgeLabelPod), WithBridgeSeriesLimit(2))
+
+		collector.RecordTelemetry(sidecarEntry("api-1", 80))
+		collector.RecordTelemetry(sidecarEntry("api-2", 60))
+		collector.RecordTelemetry(sidecarEntry("api-3", 40))
+		collector.RecordTelemetry(sidecarEntry("api-1", 90))
+
+		expected := `
+# HELP blackbox_telemetry_cpu_usage Last value of the cpu_usage telemetry entries
+# TYPE blackbox_telemetry_cpu_usage gauge
+blackbox_telemetry_cpu_usage{pod="api-1",source="sidecar"} 90
+blackbox_telemetry_cpu_usage{pod="api-2",source="sidecar"} 60
+`
+		if err := testutil.CollectAndCompare(collector.telemetryGauges, strings.NewReader(expected)); err != nil {
+			t.Errorf("Expected the third pod dropped: %v", err)
+		}
+	})
+
+	t.Run("skips non-numeric and unexported entries", func(t *testing.T) {
+		collector := NewCollector(9099, "/metrics", WithExportedTypes(types.TypeMemory))
+
+		collector.RecordTelemetry(systemEntry)
+		collector.RecordTelemetry(types.TelemetryEntry{Source: types.SourceSidecar, Type: types.TypeMemory, Name: "gc_mode", Value: "concurrent"})
+
+		if count := testutil.CollectAndCount(collector.telemetryGauges); count != 0 {
+			t.Errorf("Expected no series, got %d", count)
+		}
+	})
+}
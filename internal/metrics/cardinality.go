@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+)
+
+const (
+	// DefaultSidecarSeriesLimit caps the distinct pod label sets of per-sidecar metrics
+	DefaultSidecarSeriesLimit = 1000
+	// overflowLabel replaces label values once a series limit is reached
+	overflowLabel = "_other"
+)
+
+// seriesLimiter caps the number of distinct label sets a metric may create.
+// Label sets seen before the cap is reached keep reporting; new ones beyond it
+// are folded into a single overflow series so a flood of short-lived pods
+// cannot grow the registry without bound. Released label sets free their slot
+// for new ones.
+type seriesLimiter struct {
+	mutex sync.Mutex
+	// limit is the maximum number of distinct label sets (zero or less is unlimited)
+	limit int
+	// seen holds the admitted label sets
+	seen map[string]struct{}
+}
+
+// newSeriesLimiter creates a limiter admitting up to limit label sets.
+func newSeriesLimiter(limit int) *seriesLimiter {
+	return &seriesLimiter{
+		limit: limit,
+		seen:  make(map[string]struct{}),
+	}
+}
+
+// labels returns the label values to record: the given values when the label
+// set is already admitted or there is room for it, and overflow values otherwise.
+func (sl *seriesLimiter) labels(values ...string) []string {
+	if sl.admit(values...) {
+		return values
+	}
+
+	overflow := make([]string, len(values))
+	for i := range overflow {
+		overflow[i] = overflowLabel
+	}
+	return overflow
+}
+
+// admit reports whether the label set is already admitted or there is room
+// for it, admitting it in the latter case.
+func (sl *seriesLimiter) admit(values ...string) bool {
+	if sl.limit <= 0 {
+		return true
+	}
+
+	key := strings.Join(values, "\xff")
+
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+
+	if _, ok := sl.seen[key]; ok {
+		return true
+	}
+	if len(sl.seen) < sl.limit {
+		sl.seen[key] = struct{}{}
+		return true
+	}
+	return false
+}
+
+// release frees the slots of the admitted label sets starting with the given
+// values, e.g. every runtime of a pod and namespace.
+func (sl *seriesLimiter) release(prefix ...string) {
+	key := strings.Join(prefix, "\xff") + "\xff"
+
+	sl.mutex.Lock()
+	defer sl.mutex.Unlock()
+
+	for seen := range sl.seen {
+		if strings.HasPrefix(seen, key) {
+			delete(sl.seen, seen)
+		}
+	}
+}
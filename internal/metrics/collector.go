@@ -5,11 +5,17 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/version"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
 )
 
 // Collector provides an extensible framework for Prometheus metrics collection and export.
@@ -19,6 +25,11 @@ type Collector struct {
 	registry   *prometheus.Registry
 	httpServer *http.Server
 
+	// degradedMutex protects degraded
+	degradedMutex sync.Mutex
+	// degraded is why the metrics server is not serving (nil while it serves)
+	degraded error
+
 	// System telemetry metrics
 	cpuUsageGauge     *prometheus.GaugeVec
 	memoryUsageGauge  *prometheus.GaugeVec
@@ -30,17 +41,194 @@ type Collector struct {
 
 	// BlackBox operational metrics
 	sidecarRequestsCounter prometheus.Counter
+	sidecarPodCounter      *prometheus.CounterVec
+	sidecarLimitCounter    *prometheus.CounterVec
+	sidecarDroppedCounter  prometheus.Counter
+	sidecarValueCounter    *prometheus.CounterVec
+	sidecarLabelCounter    *prometheus.CounterVec
 	incidentCounter        *prometheus.CounterVec
+	incidentShedCounter    *prometheus.CounterVec
 	bufferSizeGauge        prometheus.Gauge
 	bufferEntriesGauge     prometheus.Gauge
+	buildInfoGauge         *prometheus.GaugeVec
+	watcherReconnects      *prometheus.CounterVec
+
+	// Emitter delivery metrics
+	emitCounter  *prometheus.CounterVec
+	emitDuration *prometheus.HistogramVec
+
+	// Rule engine metrics
+	ruleActiveGauge  *prometheus.GaugeVec
+	ruleFiredCounter *prometheus.CounterVec
+
+	// sidecarSeries caps the pod label sets of sidecarPodCounter
+	sidecarSeries *seriesLimiter
+	// sidecarHistograms exposes histograms submitted by sidecars
+	sidecarHistograms *sidecarHistograms
+	// sidecarLabels sanitizes the label values taken from sidecar submissions
+	sidecarLabels *labelSanitizer
+	// telemetryGauges exposes telemetry entries bridged by RecordTelemetry
+	telemetryGauges *telemetryGauges
+
+	// Stale-series tracking for per-interface and per-device gauges
+	networkSeries *seriesTracker
+	diskSeries    *seriesTracker
+
+	// exported selects the telemetry types recorded as system gauges (nil exports all)
+	exported map[types.TelemetryType]bool
 
 	// Custom metrics registry for extensions
 	customMetrics map[string]prometheus.Collector
 }
 
+// options holds the settings applied by Option.
+type options struct {
+	// handler configures how the metrics endpoint serves scrapes
+	handler promhttp.HandlerOpts
+	// staleMisses is the number of consecutive missed cycles before a series is deleted
+	staleMisses int
+	// staleWarmup is the number of cycles during which no series is deleted
+	staleWarmup int
+	// sidecarSeriesLimit caps the pod label sets of the per-sidecar request counter
+	sidecarSeriesLimit int
+	// labelValueMaxLength caps the bytes of label values taken from sidecar submissions
+	labelValueMaxLength int
+	// exported selects the telemetry types recorded as system gauges (nil exports all)
+	exported map[types.TelemetryType]bool
+	// bridgeLabels are the labels of gauges bridged from telemetry entries
+	bridgeLabels []string
+	// bridgeSeriesLimit caps the series of gauges bridged from telemetry entries
+	bridgeSeriesLimit int
+	// bindAddress is the host or IP the server listens on (empty listens on all interfaces)
+	bindAddress string
+}
+
+// Option configures the collector and how the metrics endpoint serves scrapes.
+type Option func(*options)
+
+// WithOpenMetrics controls whether scrapers sending an OpenMetrics Accept header
+// receive the OpenMetrics text format. When disabled, every scrape is answered
+// in the classic Prometheus text format. Defaults to enabled.
+func WithOpenMetrics(enabled bool) Option {
+	return func(opts *options) {
+		opts.handler.EnableOpenMetrics = enabled
+	}
+}
+
+// WithCompression controls whether responses are gzip-compressed for scrapers
+// that accept it. Defaults to enabled.
+func WithCompression(enabled bool) Option {
+	return func(opts *options) {
+		opts.handler.DisableCompression = !enabled
+	}
+}
+
+// WithStaleSeriesMisses sets how many consecutive collection cycles an interface
+// or device label set must be absent before SweepStaleSeries deletes it. Zero or
+// less disables deletion. Defaults to DefaultStaleSeriesMisses.
+func WithStaleSeriesMisses(misses int) Option {
+	return func(opts *options) {
+		opts.staleMisses = misses
+	}
+}
+
+// WithStaleSeriesWarmup sets how many collection cycles must complete before
+// SweepStaleSeries deletes anything, so devices that are slow to appear after
+// startup do not lose their series. Defaults to DefaultStaleSeriesWarmup.
+func WithStaleSeriesWarmup(cycles int) Option {
+	return func(opts *options) {
+		opts.staleWarmup = cycles
+	}
+}
+
+// WithSidecarSeriesLimit caps how many distinct pod/namespace/runtime label sets
+// blackbox_sidecar_pod_requests_total may create. Requests from further sidecars
+// are counted under the "_other" label values. Zero or less removes the cap.
+// Defaults to DefaultSidecarSeriesLimit.
+func WithSidecarSeriesLimit(limit int) Option {
+	return func(opts *options) {
+		opts.sidecarSeriesLimit = limit
+	}
+}
+
+// WithLabelValueMaxLength caps the bytes of the pod, namespace and runtime
+// label values taken from sidecar submissions. Longer values are cut at a
+// character boundary and counted in blackbox_sidecar_label_sanitized_total.
+// Zero or less removes the cap. Defaults to DefaultLabelValueMaxLength.
+func WithLabelValueMaxLength(length int) Option {
+	return func(opts *options) {
+		opts.labelValueMaxLength = length
+	}
+}
+
+// WithExportedTypes limits the system gauges to the given telemetry types, e.g.
+// only CPU and memory, keeping the rest of the telemetry in the buffer and
+// emitters. Gauges of other types are neither registered nor recorded, which
+// shrinks scrapes on busy nodes. Load averages, process counts and open files
+// belong to types.TypeProcess. With no types, every system gauge is exported,
+// which is the default.
+func WithExportedTypes(exported ...types.TelemetryType) Option {
+	return func(opts *options) {
+		opts.exported = nil
+		if len(exported) == 0 {
+			return
+		}
+		opts.exported = make(map[types.TelemetryType]bool, len(exported))
+		for _, telemetryType := range exported {
+			opts.exported[telemetryType] = true
+		}
+	}
+}
+
+// WithBridgeLabels sets the labels of the gauges RecordTelemetry bridges from
+// telemetry entries: any of BridgeLabelSource, BridgeLabelPod and
+// BridgeLabelNamespace. Entries with the same name that differ in a configured
+// label get separate series, e.g. cpu_usage from the system and from a
+// sidecar; those differing only in other labels share one. Pod and namespace
+// labels add a series per pod, so they are bounded by WithBridgeSeriesLimit.
+// Defaults to DefaultBridgeLabels.
+func WithBridgeLabels(labels ...string) Option {
+	return func(opts *options) {
+		opts.bridgeLabels = labels
+	}
+}
+
+// WithBridgeSeriesLimit caps how many series the gauges bridged from telemetry
+// entries may create. Values of further series are dropped. Zero or less
+// removes the cap. Defaults to DefaultBridgeSeriesLimit.
+func WithBridgeSeriesLimit(limit int) Option {
+	return func(opts *options) {
+		opts.bridgeSeriesLimit = limit
+	}
+}
+
+// WithBindAddress listens on the given host or IP address, e.g. "127.0.0.1"
+// to only serve scrapes from the node, instead of every interface. Defaults to
+// all interfaces.
+func WithBindAddress(host string) Option {
+	return func(opts *options) {
+		opts.bindAddress = host
+	}
+}
+
 // NewCollector creates a new Prometheus metrics collector with HTTP server on the specified port.
 // It initializes all system and operational metrics and prepares them for registration.
-func NewCollector(port int, metricsPath string) *Collector {
+// The endpoint negotiates the exposition format from the scraper's Accept header.
+func NewCollector(port int, metricsPath string, opts ...Option) *Collector {
+	o := options{
+		handler:     promhttp.HandlerOpts{EnableOpenMetrics: true},
+		staleMisses: DefaultStaleSeriesMisses,
+		staleWarmup: DefaultStaleSeriesWarmup,
+
+		sidecarSeriesLimit:  DefaultSidecarSeriesLimit,
+		labelValueMaxLength: DefaultLabelValueMaxLength,
+		bridgeLabels:        DefaultBridgeLabels,
+		bridgeSeriesLimit:   DefaultBridgeSeriesLimit,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	registry := prometheus.NewRegistry()
 
 	// System telemetry metrics
@@ -106,6 +294,53 @@ func NewCollector(port int, metricsPath string) *Collector {
 		},
 	)
 
+	sidecarPodCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blackbox_sidecar_pod_requests_total",
+			Help: "Total number of telemetry submissions received per sidecar",
+		},
+		[]string{"pod", "namespace", "runtime"},
+	)
+
+	sidecarLimitCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blackbox_sidecar_entry_limit_total",
+			Help: "Total number of sidecar submissions exceeding the per-request entry limit",
+		},
+		[]string{"action"}, // rejected or truncated
+	)
+
+	sidecarValueCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blackbox_sidecar_value_size_limit_total",
+			Help: "Total number of sidecar telemetry values exceeding the value size limit",
+		},
+		[]string{"action"}, // rejected or truncated
+	)
+
+	sidecarLabelCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blackbox_sidecar_label_sanitized_total",
+			Help: "Total number of label values from sidecar submissions sanitized before export",
+		},
+		[]string{"reason"}, // truncated or invalid_utf8
+	)
+
+	watcherReconnects := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blackbox_watcher_reconnects_total",
+			Help: "Total number of pod watches re-established after an error",
+		},
+		[]string{"reason"}, // transient, auth or rebuild
+	)
+
+	sidecarDroppedCounter := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "blackbox_sidecar_entries_dropped_total",
+			Help: "Total number of sidecar telemetry entries discarded by the per-request entry limit",
+		},
+	)
+
 	incidentCounter := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "blackbox_incidents_total",
@@ -114,6 +349,14 @@ func NewCollector(port int, metricsPath string) *Collector {
 		[]string{"type", "severity"}, // type: crash, oom, timeout, etc.
 	)
 
+	incidentShedCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blackbox_incidents_shed_total",
+			Help: "Total number of incidents dropped by the global incident rate limit",
+		},
+		[]string{"type"},
+	)
+
 	bufferSizeGauge := prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "blackbox_buffer_size_bytes",
@@ -128,23 +371,99 @@ func NewCollector(port int, metricsPath string) *Collector {
 		},
 	)
 
-	// Register all metrics
+	buildInfoGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blackbox_build_info",
+			Help: "Build information of the running daemon, always 1",
+		},
+		[]string{"version", "commit"},
+	)
+	buildInfoGauge.WithLabelValues(version.Version, version.Commit).Set(1)
+
+	// Emitter delivery metrics
+	emitCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blackbox_emitter_emits_total",
+			Help: "Total number of formatted incidents passed to each emitter",
+		},
+		[]string{"emitter", "result"}, // result: success or failure
+	)
+
+	emitDuration := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "blackbox_emitter_duration_seconds",
+			Help:    "Time taken by each emitter to emit a formatted incident",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"emitter"},
+	)
+
+	// Rule engine metrics
+	ruleActiveGauge := prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "blackbox_rule_active",
+			Help: "Whether a rule's condition is currently sustained (1) or not (0)",
+		},
+		[]string{"rule"},
+	)
+
+	ruleFiredCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "blackbox_rule_fired_total",
+			Help: "Total number of times a rule has fired",
+		},
+		[]string{"rule"},
+	)
+
+	// Register the system gauges of the exported telemetry types
+	systemGauges := []struct {
+		telemetryType types.TelemetryType
+		gauges        []prometheus.Collector
+	}{
+		{types.TypeCPU, []prometheus.Collector{cpuUsageGauge}},
+		{types.TypeMemory, []prometheus.Collector{memoryUsageGauge}},
+		{types.TypeNetwork, []prometheus.Collector{networkBytesGauge}},
+		{types.TypeDisk, []prometheus.Collector{diskIOGauge}},
+		{types.TypeProcess, []prometheus.Collector{processCountGauge, openFilesGauge, loadAvgGauge}},
+	}
+	for _, system := range systemGauges {
+		if o.exported == nil || o.exported[system.telemetryType] {
+			registry.MustRegister(system.gauges...)
+		}
+	}
+
+	// Register the operational metrics
 	registry.MustRegister(
-		cpuUsageGauge,
-		memoryUsageGauge,
-		networkBytesGauge,
-		diskIOGauge,
-		processCountGauge,
-		openFilesGauge,
-		loadAvgGauge,
 		sidecarRequestsCounter,
+		sidecarPodCounter,
+		sidecarLimitCounter,
+		sidecarDroppedCounter,
+		sidecarValueCounter,
+		sidecarLabelCounter,
 		incidentCounter,
+		incidentShedCounter,
 		bufferSizeGauge,
 		bufferEntriesGauge,
+		buildInfoGauge,
+		watcherReconnects,
+		emitCounter,
+		emitDuration,
+		ruleActiveGauge,
+		ruleFiredCounter,
 	)
 
+	// Sidecar histograms are named by the sidecars, so they are registered
+	// unchecked and share the sidecar series limit
+	sidecarHistograms := newSidecarHistograms(o.sidecarSeriesLimit)
+	registry.MustRegister(sidecarHistograms)
+
+	// Bridged telemetry gauges are named after the entries, so they are
+	// registered unchecked too
+	telemetryGauges := newTelemetryGauges(o.bridgeLabels, o.bridgeSeriesLimit)
+	registry.MustRegister(telemetryGauges)
+
 	mux := http.NewServeMux()
-	mux.Handle(metricsPath, promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	mux.Handle(metricsPath, promhttp.HandlerFor(registry, o.handler))
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(`<html>
 <head><title>BlackBox Daemon Metrics</title></head>
@@ -156,7 +475,7 @@ func NewCollector(port int, metricsPath string) *Collector {
 	})
 
 	httpServer := &http.Server{
-		Addr:    fmt.Sprintf(":%d", port),
+		Addr:    net.JoinHostPort(o.bindAddress, strconv.Itoa(port)),
 		Handler: mux,
 	}
 
@@ -171,9 +490,28 @@ func NewCollector(port int, metricsPath string) *Collector {
 		openFilesGauge:         openFilesGauge,
 		loadAvgGauge:           loadAvgGauge,
 		sidecarRequestsCounter: sidecarRequestsCounter,
+		sidecarPodCounter:      sidecarPodCounter,
+		sidecarLimitCounter:    sidecarLimitCounter,
+		sidecarDroppedCounter:  sidecarDroppedCounter,
+		sidecarValueCounter:    sidecarValueCounter,
+		sidecarLabelCounter:    sidecarLabelCounter,
 		incidentCounter:        incidentCounter,
+		incidentShedCounter:    incidentShedCounter,
 		bufferSizeGauge:        bufferSizeGauge,
 		bufferEntriesGauge:     bufferEntriesGauge,
+		buildInfoGauge:         buildInfoGauge,
+		watcherReconnects:      watcherReconnects,
+		emitCounter:            emitCounter,
+		emitDuration:           emitDuration,
+		ruleActiveGauge:        ruleActiveGauge,
+		ruleFiredCounter:       ruleFiredCounter,
+		sidecarSeries:          newSeriesLimiter(o.sidecarSeriesLimit),
+		sidecarHistograms:      sidecarHistograms,
+		sidecarLabels:          &labelSanitizer{maxLength: o.labelValueMaxLength, counter: sidecarLabelCounter},
+		telemetryGauges:        telemetryGauges,
+		networkSeries:          newSeriesTracker(networkBytesGauge, o.staleMisses, o.staleWarmup),
+		diskSeries:             newSeriesTracker(diskIOGauge, o.staleMisses, o.staleWarmup),
+		exported:               o.exported,
 		customMetrics:          make(map[string]prometheus.Collector),
 	}
 }
@@ -181,6 +519,52 @@ func NewCollector(port int, metricsPath string) *Collector {
 // Start starts the Prometheus HTTP server and handles graceful shutdown when context is cancelled.
 // The server exposes metrics on the configured port and path.
 func (c *Collector) Start(ctx context.Context) error {
+	listener, err := net.Listen("tcp", c.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", c.httpServer.Addr, err)
+	}
+
+	fmt.Printf("Starting Prometheus metrics server on %s\n", c.httpServer.Addr)
+	return c.Serve(ctx, listener)
+}
+
+// Run starts the metrics server like Start. When the port cannot be bound and
+// required is false, Run logs a warning, marks the collector degraded and
+// returns nil, so the daemon keeps ingesting telemetry without a metrics
+// endpoint; metrics are still recorded in the registry. Check reports the
+// failure to health checks.
+func (c *Collector) Run(ctx context.Context, required bool) error {
+	listener, err := net.Listen("tcp", c.httpServer.Addr)
+	if err != nil {
+		err = fmt.Errorf("failed to listen on %s: %w", c.httpServer.Addr, err)
+		if required {
+			return err
+		}
+		fmt.Printf("Warning: metrics server unavailable, continuing without it: %v\n", err)
+		c.degradedMutex.Lock()
+		c.degraded = err
+		c.degradedMutex.Unlock()
+		return nil
+	}
+
+	fmt.Printf("Starting Prometheus metrics server on %s\n", c.httpServer.Addr)
+	return c.Serve(ctx, listener)
+}
+
+// Check reports whether the metrics server is serving; it fails after Run
+// could not bind the port.
+func (c *Collector) Check() error {
+	c.degradedMutex.Lock()
+	defer c.degradedMutex.Unlock()
+	if c.degraded != nil {
+		return fmt.Errorf("metrics server degraded: %w", c.degraded)
+	}
+	return nil
+}
+
+// Serve serves metrics on the listener until the context is cancelled, then
+// shuts the server down gracefully.
+func (c *Collector) Serve(ctx context.Context, listener net.Listener) error {
 	go func() {
 		<-ctx.Done()
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -188,8 +572,7 @@ func (c *Collector) Start(ctx context.Context) error {
 		c.httpServer.Shutdown(shutdownCtx)
 	}()
 
-	fmt.Printf("Starting Prometheus metrics server on %s\n", c.httpServer.Addr)
-	if err := c.httpServer.ListenAndServe(); err != http.ErrServerClosed {
+	if err := c.httpServer.Serve(listener); err != http.ErrServerClosed {
 		return err
 	}
 	return nil
@@ -197,38 +580,75 @@ func (c *Collector) Start(ctx context.Context) error {
 
 // System telemetry recording methods
 
+// exports reports whether gauges of the telemetry type are exported.
+func (c *Collector) exports(telemetryType types.TelemetryType) bool {
+	return c.exported == nil || c.exported[telemetryType]
+}
+
 // RecordCPUUsage records CPU usage percentage for a specific CPU core.
 func (c *Collector) RecordCPUUsage(core string, usage float64) {
+	if !c.exports(types.TypeCPU) {
+		return
+	}
 	c.cpuUsageGauge.WithLabelValues(core).Set(usage)
 }
 
 // RecordMemoryUsage records memory usage metrics for different memory types (total, free, available, etc.).
 func (c *Collector) RecordMemoryUsage(memoryType string, bytes uint64) {
+	if !c.exports(types.TypeMemory) {
+		return
+	}
 	c.memoryUsageGauge.WithLabelValues(memoryType).Set(float64(bytes))
 }
 
 // RecordNetworkBytes records network bytes transmitted or received for a specific interface.
 func (c *Collector) RecordNetworkBytes(iface, direction string, bytes uint64) {
+	if !c.exports(types.TypeNetwork) {
+		return
+	}
 	c.networkBytesGauge.WithLabelValues(iface, direction).Set(float64(bytes))
+	c.networkSeries.seen(iface, direction)
 }
 
 // RecordDiskIO records disk I/O bytes for read or write operations on a specific device.
 func (c *Collector) RecordDiskIO(device, direction string, bytes uint64) {
+	if !c.exports(types.TypeDisk) {
+		return
+	}
 	c.diskIOGauge.WithLabelValues(device, direction).Set(float64(bytes))
+	c.diskSeries.seen(device, direction)
+}
+
+// SweepStaleSeries ends a collection cycle: interface and device series that were
+// not recorded since the previous sweep count a miss, and series missed for the
+// configured number of consecutive cycles are deleted once the warmup has passed.
+// Call it after each round of RecordNetworkBytes and RecordDiskIO calls.
+func (c *Collector) SweepStaleSeries() {
+	c.networkSeries.sweep()
+	c.diskSeries.sweep()
 }
 
 // RecordProcessCount records the total number of running processes on the system.
 func (c *Collector) RecordProcessCount(count int) {
+	if !c.exports(types.TypeProcess) {
+		return
+	}
 	c.processCountGauge.Set(float64(count))
 }
 
 // RecordOpenFiles records the total number of open file descriptors system-wide.
 func (c *Collector) RecordOpenFiles(count int) {
+	if !c.exports(types.TypeProcess) {
+		return
+	}
 	c.openFilesGauge.Set(float64(count))
 }
 
 // RecordLoadAverage records system load average for different time periods (1min, 5min, 15min).
 func (c *Collector) RecordLoadAverage(period string, load float64) {
+	if !c.exports(types.TypeProcess) {
+		return
+	}
 	c.loadAvgGauge.WithLabelValues(period).Set(load)
 }
 
@@ -239,11 +659,80 @@ func (c *Collector) IncrementSidecarRequests() {
 	c.sidecarRequestsCounter.Inc()
 }
 
+// RecordSidecarRequest counts a telemetry submission from a sidecar, both in the
+// overall request counter and per pod, namespace and runtime. Per-pod series are
+// capped by WithSidecarSeriesLimit, and the label values are sanitized.
+func (c *Collector) RecordSidecarRequest(pod, namespace, runtime string) {
+	c.sidecarRequestsCounter.Inc()
+	labels := c.sidecarLabels.sanitize(pod, namespace, runtime)
+	c.sidecarPodCounter.WithLabelValues(c.sidecarSeries.labels(labels...)...).Inc()
+}
+
+// RecordTelemetry bridges a telemetry entry to the gauge
+// blackbox_telemetry_<name>, set to the entry's last value and labelled as
+// configured by WithBridgeLabels with its source and its pod_name and
+// namespace tags. Entries with non-numeric values or of a telemetry type not
+// exported by WithExportedTypes are skipped. Series are capped by
+// WithBridgeSeriesLimit, and the label values are sanitized.
+func (c *Collector) RecordTelemetry(entry types.TelemetryEntry) {
+	if !c.exports(entry.Type) {
+		return
+	}
+	value, ok := telemetry.Float64Value(entry.Value)
+	if !ok {
+		return
+	}
+	labels := c.sidecarLabels.sanitize(string(entry.Source), entry.Tags["pod_name"], entry.Tags["namespace"])
+	c.telemetryGauges.set(entry.Name, labels[0], labels[1], labels[2], value)
+}
+
+// ReleaseSidecar deletes the per-pod request, histogram and bridged series of a pod
+// that was deleted and frees its slots under the sidecar series limit, so pods
+// replaced by rollouts do not push newer pods into the overflow series. Call it
+// when the pod watcher reports the pod stopped.
+func (c *Collector) ReleaseSidecar(pod, namespace string) {
+	labels := c.sidecarLabels.sanitize(pod, namespace)
+	pod, namespace = labels[0], labels[1]
+	c.sidecarPodCounter.DeletePartialMatch(prometheus.Labels{"pod": pod, "namespace": namespace})
+	c.sidecarSeries.release(pod, namespace)
+	c.sidecarHistograms.release(pod, namespace)
+	c.telemetryGauges.release(pod, namespace)
+}
+
+// RecordSidecarHistogram records a histogram submitted by a sidecar as the
+// Prometheus histogram blackbox_sidecar_histogram_<name> labelled by pod,
+// namespace and runtime. Interval histograms accumulate; cumulative ones
+// replace the series. Series beyond the sidecar series limit are dropped, and
+// the label values are sanitized.
+func (c *Collector) RecordSidecarHistogram(name, pod, namespace, runtime string, histogram telemetry.Histogram) {
+	labels := c.sidecarLabels.sanitize(pod, namespace, runtime)
+	c.sidecarHistograms.observe(name, labels[0], labels[1], labels[2], histogram)
+}
+
+// RecordSidecarEntryLimit counts a sidecar submission exceeding the per-request
+// entry limit by action ("rejected" or "truncated") and the entries it lost.
+func (c *Collector) RecordSidecarEntryLimit(action string, dropped int) {
+	c.sidecarLimitCounter.WithLabelValues(action).Inc()
+	c.sidecarDroppedCounter.Add(float64(dropped))
+}
+
+// RecordSidecarValueSizeLimit counts a sidecar telemetry value exceeding the
+// value size limit by action ("rejected" or "truncated").
+func (c *Collector) RecordSidecarValueSizeLimit(action string) {
+	c.sidecarValueCounter.WithLabelValues(action).Inc()
+}
+
 // IncrementIncidents increments the counter for detected incidents with type and severity labels.
 func (c *Collector) IncrementIncidents(incidentType, severity string) {
 	c.incidentCounter.WithLabelValues(incidentType, severity).Inc()
 }
 
+// IncrementIncidentsShed counts an incident dropped by the global incident
+// rate limit, by type.
+func (c *Collector) IncrementIncidentsShed(incidentType string) {
+	c.incidentShedCounter.WithLabelValues(incidentType).Inc()
+}
+
 // RecordBufferSize records the current ring buffer size in bytes.
 func (c *Collector) RecordBufferSize(sizeBytes int) {
 	c.bufferSizeGauge.Set(float64(sizeBytes))
@@ -254,6 +743,35 @@ func (c *Collector) RecordBufferEntries(count int) {
 	c.bufferEntriesGauge.Set(float64(count))
 }
 
+// RecordWatcherReconnect counts a pod watch re-established after an error by
+// reason ("transient", "auth" or "rebuild").
+func (c *Collector) RecordWatcherReconnect(reason string) {
+	c.watcherReconnects.WithLabelValues(reason).Inc()
+}
+
+// RecordEmit counts an emit by emitter and result ("success" or "failure")
+// and observes how long it took.
+func (c *Collector) RecordEmit(emitter, result string, duration time.Duration) {
+	c.emitCounter.WithLabelValues(emitter, result).Inc()
+	c.emitDuration.WithLabelValues(emitter).Observe(duration.Seconds())
+}
+
+// Rule engine metrics
+
+// SetRuleActive records whether a rule's condition is currently sustained.
+func (c *Collector) SetRuleActive(rule string, active bool) {
+	value := 0.0
+	if active {
+		value = 1
+	}
+	c.ruleActiveGauge.WithLabelValues(rule).Set(value)
+}
+
+// IncrementRuleFired increments the counter for a rule that has fired.
+func (c *Collector) IncrementRuleFired(rule string) {
+	c.ruleFiredCounter.WithLabelValues(rule).Inc()
+}
+
 // Custom metrics management
 
 // RegisterCustomMetric registers a custom Prometheus metric
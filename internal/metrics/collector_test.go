@@ -7,12 +7,16 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/version"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
 )
 
 // TestNewCollector validates collector creation and configuration.
@@ -81,32 +85,40 @@ func TestNewCollector(t *testing.T) {
 			t.Error("Expected custom metrics map to be initialized")
 		}
 	})
+
+	t.Run("listens on the bind address", func(t *testing.T) {
+		collector := NewCollector(9090, "/metrics", WithBindAddress("127.0.0.1"))
+
+		if collector.httpServer.Addr != "127.0.0.1:9090" {
+			t.Errorf("Expected server address '127.0.0.1:9090', got %q", collector.httpServer.Addr)
+		}
+	})
 }
 
 // TestStart validates HTTP server startup and shutdown behavior.
 func TestStart(t *testing.T) {
 	t.Run("starts and stops HTTP server", func(t *testing.T) {
 		collector := NewCollector(19090, "/metrics") // Use different port to avoid conflicts
+		listener := listen(t)
 		
-		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 		
-		// Start in goroutine
+		// Serve in goroutine; the listener already accepts connections
 		errCh := make(chan error, 1)
 		go func() {
-			errCh <- collector.Start(ctx)
+			errCh <- collector.Serve(ctx, listener)
 		}()
 		
-		// Give server time to start
-		time.Sleep(50 * time.Millisecond)
-		
-		// Try to connect to verify server is running
-		resp, err := http.Get("http://localhost:19090/")
-		if err == nil {
-			resp.Body.Close()
+		// Connect to verify server is running
+		resp, err := http.Get("http://" + listener.Addr().String() + "/")
+		if err != nil {
+			t.Fatalf("Expected server to be running, got %v", err)
 		}
+		resp.Body.Close()
 		
-		// Wait for context cancellation and server shutdown
+		// Cancel and wait for server shutdown
+		cancel()
 		select {
 		case err := <-errCh:
 			// Should be nil (clean shutdown) or http.ErrServerClosed
@@ -132,6 +144,80 @@ func TestStart(t *testing.T) {
 	})
 }
 
+// listen returns a listener on a free loopback port, closed when the test ends.
+func listen(t *testing.T) net.Listener {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	return listener
+}
+
+// TestRun validates that a metrics port conflict degrades the collector
+// instead of failing unless the metrics server is required.
+func TestRun(t *testing.T) {
+	// occupy binds a port on all interfaces, as the metrics server does
+	occupy := func(t *testing.T) int {
+		t.Helper()
+		listener, err := net.Listen("tcp", ":0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		t.Cleanup(func() { listener.Close() })
+		return listener.Addr().(*net.TCPAddr).Port
+	}
+
+	t.Run("continues degraded when the port is taken", func(t *testing.T) {
+		collector := NewCollector(occupy(t), "/metrics")
+
+		if err := collector.Run(context.Background(), false); err != nil {
+			t.Fatalf("Expected the daemon to continue, got %v", err)
+		}
+		if err := collector.Check(); err == nil {
+			t.Error("Expected the collector reported degraded")
+		}
+
+		// Metrics are still recorded for when the endpoint is available
+		collector.IncrementIncidents("crash", "high")
+		if value := testutil.ToFloat64(collector.incidentCounter.WithLabelValues("crash", "high")); value != 1 {
+			t.Errorf("Expected incidents still counted, got %v", value)
+		}
+	})
+
+	t.Run("fails when metrics are required", func(t *testing.T) {
+		collector := NewCollector(occupy(t), "/metrics")
+
+		if err := collector.Run(context.Background(), true); err == nil {
+			t.Fatal("Expected a bind error when metrics are required")
+		}
+		if err := collector.Check(); err != nil {
+			t.Errorf("Expected no degraded state when the failure is fatal, got %v", err)
+		}
+	})
+
+	t.Run("reports healthy after binding the port", func(t *testing.T) {
+		listener, err := net.Listen("tcp", ":0")
+		if err != nil {
+			t.Fatalf("Failed to listen: %v", err)
+		}
+		port := listener.Addr().(*net.TCPAddr).Port
+		listener.Close()
+		collector := NewCollector(port, "/metrics")
+
+		// Run binds the port before it serves and notices the cancellation
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := collector.Run(ctx, false); err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+		if err := collector.Check(); err != nil {
+			t.Errorf("Expected a collector that bound its port healthy, got %v", err)
+		}
+	})
+}
+
 // TestRecordCPUUsage validates CPU metric recording.
 func TestRecordCPUUsage(t *testing.T) {
 	collector := NewCollector(9092, "/metrics")
@@ -330,6 +416,155 @@ func TestIncrementSidecarRequests(t *testing.T) {
 	})
 }
 
+// TestRecordSidecarRequest validates per-sidecar request counting and its series cap.
+func TestRecordSidecarRequest(t *testing.T) {
+	t.Run("counts requests per pod", func(t *testing.T) {
+		collector := NewCollector(9099, "/metrics")
+
+		collector.RecordSidecarRequest("api-1", "prod", "jvm")
+		collector.RecordSidecarRequest("api-1", "prod", "jvm")
+		collector.RecordSidecarRequest("worker-1", "jobs", "go")
+
+		if value := testutil.ToFloat64(collector.sidecarPodCounter.WithLabelValues("api-1", "prod", "jvm")); value != 2 {
+			t.Errorf("Expected 2 requests for api-1, got %v", value)
+		}
+		if value := testutil.ToFloat64(collector.sidecarPodCounter.WithLabelValues("worker-1", "jobs", "go")); value != 1 {
+			t.Errorf("Expected 1 request for worker-1, got %v", value)
+		}
+		if value := testutil.ToFloat64(collector.sidecarRequestsCounter); value != 3 {
+			t.Errorf("Expected 3 total requests, got %v", value)
+		}
+	})
+
+	t.Run("folds sidecars beyond the limit into overflow series", func(t *testing.T) {
+		collector := NewCollector(9099, "/metrics", WithSidecarSeriesLimit(2))
+
+		for i := 0; i < 5; i++ {
+			collector.RecordSidecarRequest(fmt.Sprintf("pod-%d", i), "default", "go")
+		}
+		collector.RecordSidecarRequest("pod-0", "default", "go")
+
+		if count := testutil.CollectAndCount(collector.sidecarPodCounter); count != 3 {
+			t.Errorf("Expected 2 pod series plus overflow, got %d", count)
+		}
+		if value := testutil.ToFloat64(collector.sidecarPodCounter.WithLabelValues("pod-0", "default", "go")); value != 2 {
+			t.Errorf("Expected admitted pod to keep counting, got %v", value)
+		}
+		if value := testutil.ToFloat64(collector.sidecarPodCounter.WithLabelValues(overflowLabel, overflowLabel, overflowLabel)); value != 3 {
+			t.Errorf("Expected 3 overflow requests, got %v", value)
+		}
+	})
+
+	t.Run("releases the series of deleted pods", func(t *testing.T) {
+		collector := NewCollector(9099, "/metrics", WithSidecarSeriesLimit(3))
+		collector.RecordSidecarRequest("pod-0", "default", "go")
+		collector.RecordSidecarRequest("pod-0", "default", "jvm")
+		collector.RecordSidecarRequest("pod-0", "staging", "go")
+
+		collector.ReleaseSidecar("pod-0", "default")
+		collector.RecordSidecarRequest("pod-1", "default", "go")
+		collector.RecordSidecarRequest("pod-2", "default", "go")
+
+		if count := testutil.CollectAndCount(collector.sidecarPodCounter); count != 3 {
+			t.Errorf("Expected 3 pod series without overflow, got %d", count)
+		}
+		for _, pod := range []string{"pod-1", "pod-2"} {
+			if value := testutil.ToFloat64(collector.sidecarPodCounter.WithLabelValues(pod, "default", "go")); value != 1 {
+				t.Errorf("Expected %s admitted into a released slot, got %v", pod, value)
+			}
+		}
+		if value := testutil.ToFloat64(collector.sidecarPodCounter.WithLabelValues("pod-0", "staging", "go")); value != 1 {
+			t.Errorf("Expected the pod in another namespace to keep its series, got %v", value)
+		}
+	})
+}
+
+// TestRecordSidecarEntryLimit validates counting submissions over the entry limit.
+func TestRecordSidecarEntryLimit(t *testing.T) {
+	collector := NewCollector(9099, "/metrics")
+
+	collector.RecordSidecarEntryLimit("rejected", 50000)
+	collector.RecordSidecarEntryLimit("truncated", 120)
+	collector.RecordSidecarEntryLimit("truncated", 30)
+
+	if value := testutil.ToFloat64(collector.sidecarLimitCounter.WithLabelValues("truncated")); value != 2 {
+		t.Errorf("Expected 2 truncations, got %v", value)
+	}
+	if value := testutil.ToFloat64(collector.sidecarLimitCounter.WithLabelValues("rejected")); value != 1 {
+		t.Errorf("Expected 1 rejection, got %v", value)
+	}
+	if value := testutil.ToFloat64(collector.sidecarDroppedCounter); value != 50150 {
+		t.Errorf("Expected 50150 dropped entries, got %v", value)
+	}
+}
+
+// TestRecordSidecarValueSizeLimit validates counting values over the value size limit.
+func TestRecordSidecarValueSizeLimit(t *testing.T) {
+	collector := NewCollector(9099, "/metrics")
+
+	collector.RecordSidecarValueSizeLimit("truncated")
+	collector.RecordSidecarValueSizeLimit("truncated")
+	collector.RecordSidecarValueSizeLimit("rejected")
+
+	if value := testutil.ToFloat64(collector.sidecarValueCounter.WithLabelValues("truncated")); value != 2 {
+		t.Errorf("Expected 2 truncations, got %v", value)
+	}
+	if value := testutil.ToFloat64(collector.sidecarValueCounter.WithLabelValues("rejected")); value != 1 {
+		t.Errorf("Expected 1 rejection, got %v", value)
+	}
+}
+
+// TestRecordWatcherReconnect validates counting re-established pod watches by reason.
+func TestRecordWatcherReconnect(t *testing.T) {
+	collector := NewCollector(9099, "/metrics")
+
+	collector.RecordWatcherReconnect("auth")
+	collector.RecordWatcherReconnect("auth")
+	collector.RecordWatcherReconnect("rebuild")
+
+	if value := testutil.ToFloat64(collector.watcherReconnects.WithLabelValues("auth")); value != 2 {
+		t.Errorf("Expected 2 auth reconnects, got %v", value)
+	}
+	if value := testutil.ToFloat64(collector.watcherReconnects.WithLabelValues("rebuild")); value != 1 {
+		t.Errorf("Expected 1 rebuild, got %v", value)
+	}
+}
+
+// TestRecordEmit validates counting emits by result and observing their duration.
+func TestRecordEmit(t *testing.T) {
+	collector := NewCollector(9099, "/metrics")
+
+	collector.RecordEmit("webhook", "failure", 2*time.Second)
+	collector.RecordEmit("webhook", "failure", time.Second)
+	collector.RecordEmit("webhook", "success", 100*time.Millisecond)
+
+	if value := testutil.ToFloat64(collector.emitCounter.WithLabelValues("webhook", "failure")); value != 2 {
+		t.Errorf("Expected 2 failures, got %v", value)
+	}
+	if value := testutil.ToFloat64(collector.emitCounter.WithLabelValues("webhook", "success")); value != 1 {
+		t.Errorf("Expected 1 success, got %v", value)
+	}
+	if count := testutil.CollectAndCount(collector.emitDuration, "blackbox_emitter_duration_seconds"); count != 1 {
+		t.Errorf("Expected 1 duration series, got %d", count)
+	}
+}
+
+// TestIncrementIncidentsShed validates the shed incident counter.
+func TestIncrementIncidentsShed(t *testing.T) {
+	collector := NewCollector(9099, "/metrics")
+
+	collector.IncrementIncidentsShed("crash")
+	collector.IncrementIncidentsShed("crash")
+	collector.IncrementIncidentsShed("oom")
+
+	if value := testutil.ToFloat64(collector.incidentShedCounter.WithLabelValues("crash")); value != 2 {
+		t.Errorf("Expected 2 shed crash incidents, got %v", value)
+	}
+	if value := testutil.ToFloat64(collector.incidentShedCounter.WithLabelValues("oom")); value != 1 {
+		t.Errorf("Expected 1 shed oom incident, got %v", value)
+	}
+}
+
 // TestIncrementIncidents validates incident counter.
 func TestIncrementIncidents(t *testing.T) {
 	collector := NewCollector(9100, "/metrics")
@@ -385,6 +620,56 @@ func TestRecordBufferMetrics(t *testing.T) {
 	})
 }
 
+// TestRuleMetrics validates rule firing state transitions.
+func TestRuleMetrics(t *testing.T) {
+	collector := NewCollector(9105, "/metrics")
+
+	t.Run("tracks rule active state transitions", func(t *testing.T) {
+		rule := "high_cpu"
+
+		collector.SetRuleActive(rule, true)
+		collector.IncrementRuleFired(rule)
+		if value := testutil.ToFloat64(collector.ruleActiveGauge.WithLabelValues(rule)); value != 1 {
+			t.Errorf("Expected rule to be active (1), got %v", value)
+		}
+
+		collector.SetRuleActive(rule, false)
+		if value := testutil.ToFloat64(collector.ruleActiveGauge.WithLabelValues(rule)); value != 0 {
+			t.Errorf("Expected rule to be inactive (0), got %v", value)
+		}
+
+		collector.SetRuleActive(rule, true)
+		collector.IncrementRuleFired(rule)
+		if value := testutil.ToFloat64(collector.ruleActiveGauge.WithLabelValues(rule)); value != 1 {
+			t.Errorf("Expected rule to be active again (1), got %v", value)
+		}
+		if value := testutil.ToFloat64(collector.ruleFiredCounter.WithLabelValues(rule)); value != 2 {
+			t.Errorf("Expected rule to have fired 2 times, got %v", value)
+		}
+	})
+
+	t.Run("keeps rules independent", func(t *testing.T) {
+		collector.SetRuleActive("disk_full", true)
+		collector.SetRuleActive("memory_pressure", false)
+
+		if value := testutil.ToFloat64(collector.ruleActiveGauge.WithLabelValues("disk_full")); value != 1 {
+			t.Errorf("Expected disk_full to be active, got %v", value)
+		}
+		if value := testutil.ToFloat64(collector.ruleActiveGauge.WithLabelValues("memory_pressure")); value != 0 {
+			t.Errorf("Expected memory_pressure to be inactive, got %v", value)
+		}
+	})
+}
+
+// TestBuildInfo validates that the build info metric carries the daemon version.
+func TestBuildInfo(t *testing.T) {
+	collector := NewCollector(9106, "/metrics")
+
+	if value := testutil.ToFloat64(collector.buildInfoGauge.WithLabelValues(version.Version, version.Commit)); value != 1 {
+		t.Errorf("Expected build info to be 1, got %v", value)
+	}
+}
+
 // TestCustomMetrics validates custom metric management.
 func TestCustomMetrics(t *testing.T) {
 	collector := NewCollector(9102, "/metrics")
@@ -551,24 +836,22 @@ func TestMetricsHTTPEndpoint(t *testing.T) {
 	collector := NewCollector(19103, "/metrics")
 	
 	t.Run("serves metrics endpoint", func(t *testing.T) {
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 		
-		// Start server in background
+		// Serve in background; the listener already accepts connections
+		listener := listen(t)
 		go func() {
-			collector.Start(ctx)
+			collector.Serve(ctx, listener)
 		}()
 		
-		// Give server time to start
-		time.Sleep(100 * time.Millisecond)
-		
 		// Record some test metrics
 		collector.RecordCPUUsage("cpu0", 50.0)
 		collector.IncrementSidecarRequests()
 		collector.RecordBufferEntries(100)
 		
 		// Make HTTP request to metrics endpoint
-		resp, err := http.Get("http://localhost:19103/metrics")
+		resp, err := http.Get("http://" + listener.Addr().String() + "/metrics")
 		if err != nil {
 			t.Fatalf("Failed to get metrics: %v", err)
 		}
@@ -602,19 +885,17 @@ func TestMetricsHTTPEndpoint(t *testing.T) {
 	
 	t.Run("serves root endpoint", func(t *testing.T) {
 		collector2 := NewCollector(19104, "/metrics") // Use different port
-		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		ctx, cancel := context.WithCancel(context.Background())
 		defer cancel()
 		
-		// Start server in background
+		// Serve in background; the listener already accepts connections
+		listener := listen(t)
 		go func() {
-			collector2.Start(ctx)
+			collector2.Serve(ctx, listener)
 		}()
 		
-		// Give server time to start
-		time.Sleep(100 * time.Millisecond)
-		
 		// Make HTTP request to root endpoint
-		resp, err := http.Get("http://localhost:19104/")
+		resp, err := http.Get("http://" + listener.Addr().String() + "/")
 		if err != nil {
 			t.Fatalf("Failed to get root endpoint: %v", err)
 		}
@@ -639,4 +920,122 @@ func TestMetricsHTTPEndpoint(t *testing.T) {
 			t.Error("Expected root page to contain metrics link")
 		}
 	})
-}
\ No newline at end of file
+}
+
+// TestMetricsContentNegotiation validates exposition format and compression negotiation.
+func TestMetricsContentNegotiation(t *testing.T) {
+	scrape := func(collector *Collector, accept, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/metrics", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		w := httptest.NewRecorder()
+		collector.httpServer.Handler.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("serves OpenMetrics when requested", func(t *testing.T) {
+		collector := NewCollector(9107, "/metrics")
+		collector.IncrementSidecarRequests()
+
+		w := scrape(collector, "application/openmetrics-text; version=1.0.0", "")
+
+		if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "application/openmetrics-text") {
+			t.Errorf("Expected OpenMetrics content type, got %q", contentType)
+		}
+		if !strings.HasSuffix(w.Body.String(), "# EOF\n") {
+			t.Error("Expected OpenMetrics body to end with # EOF")
+		}
+	})
+
+	t.Run("serves Prometheus text format by default", func(t *testing.T) {
+		collector := NewCollector(9107, "/metrics")
+
+		for _, accept := range []string{"", "text/plain;version=0.0.4;q=1,*/*;q=0.1"} {
+			w := scrape(collector, accept, "")
+			if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+				t.Errorf("Expected Prometheus text content type for Accept %q, got %q", accept, contentType)
+			}
+		}
+	})
+
+	t.Run("falls back to Prometheus text when OpenMetrics is disabled", func(t *testing.T) {
+		collector := NewCollector(9107, "/metrics", WithOpenMetrics(false))
+
+		w := scrape(collector, "application/openmetrics-text; version=1.0.0", "")
+
+		if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, "text/plain; version=0.0.4") {
+			t.Errorf("Expected Prometheus text content type, got %q", contentType)
+		}
+	})
+
+	t.Run("compresses unless disabled", func(t *testing.T) {
+		if w := scrape(NewCollector(9107, "/metrics"), "", "gzip"); w.Header().Get("Content-Encoding") != "gzip" {
+			t.Errorf("Expected gzip encoding, got %q", w.Header().Get("Content-Encoding"))
+		}
+		if w := scrape(NewCollector(9107, "/metrics", WithCompression(false)), "", "gzip"); w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("Expected no encoding, got %q", w.Header().Get("Content-Encoding"))
+		}
+	})
+}
+
+// TestExportedTypes validates limiting the system gauges to selected telemetry types.
+func TestExportedTypes(t *testing.T) {
+	// record sets every system gauge and returns the scraped exposition.
+	record := func(collector *Collector) string {
+		collector.RecordCPUUsage("cpu0", 42)
+		collector.RecordMemoryUsage("used", 1024)
+		collector.RecordNetworkBytes("eth0", "rx", 2048)
+		collector.RecordDiskIO("sda", "read", 4096)
+		collector.RecordProcessCount(120)
+		collector.RecordOpenFiles(900)
+		collector.RecordLoadAverage("1m", 0.5)
+
+		w := httptest.NewRecorder()
+		collector.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		return w.Body.String()
+	}
+	systemMetrics := []string{
+		"blackbox_cpu_usage_percent",
+		"blackbox_memory_bytes",
+		"blackbox_network_bytes_total",
+		"blackbox_disk_io_bytes_total",
+		"blackbox_processes_total",
+		"blackbox_open_files_total",
+		"blackbox_load_average",
+	}
+
+	t.Run("exports every type by default", func(t *testing.T) {
+		body := record(NewCollector(9108, "/metrics"))
+		for _, name := range systemMetrics {
+			if !strings.Contains(body, name) {
+				t.Errorf("Expected %s to be exported", name)
+			}
+		}
+	})
+
+	t.Run("exports only the selected types", func(t *testing.T) {
+		collector := NewCollector(9108, "/metrics", WithExportedTypes(types.TypeCPU, types.TypeMemory))
+		body := record(collector)
+
+		for _, name := range systemMetrics[:2] {
+			if !strings.Contains(body, name) {
+				t.Errorf("Expected %s to be exported", name)
+			}
+		}
+		for _, name := range systemMetrics[2:] {
+			if strings.Contains(body, name) {
+				t.Errorf("Expected %s to be absent, got it in the scrape", name)
+			}
+		}
+		if testutil.ToFloat64(collector.networkBytesGauge.WithLabelValues("eth0", "rx")) != 0 {
+			t.Error("Expected network bytes not to be recorded")
+		}
+		if !strings.Contains(body, "blackbox_build_info") {
+			t.Error("Expected operational metrics to be exported regardless of the selection")
+		}
+	})
+}
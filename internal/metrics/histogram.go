@@ -0,0 +1,141 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
+)
+
+// sidecarHistogramPrefix prefixes the names of histograms submitted by sidecars.
+const sidecarHistogramPrefix = "blackbox_sidecar_histogram_"
+
+// sidecarHistogram is the accumulated state of one histogram series.
+type sidecarHistogram struct {
+	// desc describes the series' metric
+	desc *prometheus.Desc
+	// labels are the pod, namespace and runtime label values
+	labels []string
+	// buckets are the upper bounds of the buckets
+	buckets []float64
+	// counts are the observations in each bucket, not accumulated across buckets
+	counts []uint64
+	// count is the number of observations, including those above the last bound
+	count uint64
+	// sum is the sum of the observed values
+	sum float64
+}
+
+// sidecarHistograms exposes histograms submitted by sidecars as Prometheus
+// histograms. Their names are only known once sidecars submit them, so it is
+// registered unchecked and creates a constant histogram per series on every
+// scrape.
+type sidecarHistograms struct {
+	mutex sync.Mutex
+	// series maps name, pod, namespace and runtime to their histogram
+	series map[string]*sidecarHistogram
+	// limiter caps the number of series
+	limiter *seriesLimiter
+}
+
+// newSidecarHistograms creates a collector admitting up to limit series.
+func newSidecarHistograms(limit int) *sidecarHistograms {
+	return &sidecarHistograms{
+		series:  make(map[string]*sidecarHistogram),
+		limiter: newSeriesLimiter(limit),
+	}
+}
+
+// Describe sends nothing, registering the collector unchecked.
+func (sh *sidecarHistograms) Describe(chan<- *prometheus.Desc) {}
+
+// Collect sends a constant histogram for each series.
+func (sh *sidecarHistograms) Collect(ch chan<- prometheus.Metric) {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+
+	for _, series := range sh.series {
+		buckets := make(map[float64]uint64, len(series.buckets))
+		var cumulative uint64
+		for i, bound := range series.buckets {
+			cumulative += series.counts[i]
+			buckets[bound] = cumulative
+		}
+		ch <- prometheus.MustNewConstHistogram(series.desc, series.count, series.sum, buckets, series.labels...)
+	}
+}
+
+// observe records a submitted histogram. Interval histograms are added to the
+// series; cumulative ones replace it. A changed bucket layout restarts the
+// series. Submissions of new series beyond the limit are dropped.
+func (sh *sidecarHistograms) observe(name, pod, namespace, runtime string, histogram telemetry.Histogram) {
+	if !sh.limiter.admit(pod, namespace, runtime, name) {
+		return
+	}
+	key := strings.Join([]string{pod, namespace, runtime, name}, "\xff")
+
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+
+	series, ok := sh.series[key]
+	if !ok || histogram.Cumulative || !sameBuckets(series.buckets, histogram.Buckets) {
+		series = &sidecarHistogram{
+			desc: prometheus.NewDesc(
+				sidecarHistogramPrefix+sanitizeMetricName(name),
+				"Histogram "+name+" submitted by sidecars",
+				[]string{"pod", "namespace", "runtime"}, nil,
+			),
+			labels:  []string{pod, namespace, runtime},
+			buckets: histogram.Buckets,
+			counts:  make([]uint64, len(histogram.Counts)),
+		}
+		sh.series[key] = series
+	}
+
+	for i, count := range histogram.Counts {
+		series.counts[i] += count
+	}
+	series.count += histogram.Count
+	series.sum += histogram.Sum
+}
+
+// release deletes the series of a pod and frees their slots.
+func (sh *sidecarHistograms) release(pod, namespace string) {
+	prefix := pod + "\xff" + namespace + "\xff"
+
+	sh.mutex.Lock()
+	for key := range sh.series {
+		if strings.HasPrefix(key, prefix) {
+			delete(sh.series, key)
+		}
+	}
+	sh.mutex.Unlock()
+
+	sh.limiter.release(pod, namespace)
+}
+
+// sameBuckets reports whether two bucket layouts are identical.
+func sameBuckets(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// sanitizeMetricName replaces the characters not allowed in Prometheus metric
+// names with underscores, e.g. "http.request-latency" becomes
+// "http_request_latency".
+func sanitizeMetricName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r == ':' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
+)
+
+// latencyExposition is the exposition of blackbox_sidecar_histogram_request_latency_seconds
+// for api-1 with the given bucket, count and sum lines.
+func latencyExposition(samples string) string {
+	return `
+# HELP blackbox_sidecar_histogram_request_latency_seconds Histogram request.latency_seconds submitted by sidecars
+# TYPE blackbox_sidecar_histogram_request_latency_seconds histogram
+` + samples
+}
+
+// TestRecordSidecarHistogram validates exporting sidecar histograms as Prometheus histograms.
+func TestRecordSidecarHistogram(t *testing.T) {
+	latency := func(counts []uint64, count uint64, sum float64, cumulative bool) telemetry.Histogram {
+		return telemetry.Histogram{Type: telemetry.HistogramType, Buckets: []float64{0.1, 0.5}, Counts: counts, Count: count, Sum: sum, Cumulative: cumulative}
+	}
+
+	t.Run("accumulates interval histograms", func(t *testing.T) {
+		collector := NewCollector(9095, "/metrics")
+
+		collector.RecordSidecarHistogram("request.latency_seconds", "api-1", "prod", "go", latency([]uint64{10, 5}, 16, 3.5, false))
+		collector.RecordSidecarHistogram("request.latency_seconds", "api-1", "prod", "go", latency([]uint64{2, 1}, 3, 0.5, false))
+
+		expected := latencyExposition(`blackbox_sidecar_histogram_request_latency_seconds_bucket{namespace="prod",pod="api-1",runtime="go",le="0.1"} 12
+blackbox_sidecar_histogram_request_latency_seconds_bucket{namespace="prod",pod="api-1",runtime="go",le="0.5"} 18
+blackbox_sidecar_histogram_request_latency_seconds_bucket{namespace="prod",pod="api-1",runtime="go",le="+Inf"} 19
+blackbox_sidecar_histogram_request_latency_seconds_sum{namespace="prod",pod="api-1",runtime="go"} 4
+blackbox_sidecar_histogram_request_latency_seconds_count{namespace="prod",pod="api-1",runtime="go"} 19
+`)
+		if err := testutil.GatherAndCompare(collector.registry, strings.NewReader(expected), "blackbox_sidecar_histogram_request_latency_seconds"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("replaces cumulative histograms", func(t *testing.T) {
+		collector := NewCollector(9095, "/metrics")
+
+		collector.RecordSidecarHistogram("request.latency_seconds", "api-1", "prod", "go", latency([]uint64{10, 5}, 15, 3.5, true))
+		collector.RecordSidecarHistogram("request.latency_seconds", "api-1", "prod", "go", latency([]uint64{12, 6}, 18, 4, true))
+
+		expected := latencyExposition(`blackbox_sidecar_histogram_request_latency_seconds_bucket{namespace="prod",pod="api-1",runtime="go",le="0.1"} 12
+blackbox_sidecar_histogram_request_latency_seconds_bucket{namespace="prod",pod="api-1",runtime="go",le="0.5"} 18
+blackbox_sidecar_histogram_request_latency_seconds_bucket{namespace="prod",pod="api-1",runtime="go",le="+Inf"} 18
+blackbox_sidecar_histogram_request_latency_seconds_sum{namespace="prod",pod="api-1",runtime="go"} 4
+blackbox_sidecar_histogram_request_latency_seconds_count{namespace="prod",pod="api-1",runtime="go"} 18
+`)
+		if err := testutil.GatherAndCompare(collector.registry, strings.NewReader(expected), "blackbox_sidecar_histogram_request_latency_seconds"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("restarts series when the buckets change", func(t *testing.T) {
+		collector := NewCollector(9095, "/metrics")
+
+		collector.RecordSidecarHistogram("request.latency_seconds", "api-1", "prod", "go", latency([]uint64{10, 5}, 15, 3.5, false))
+		collector.RecordSidecarHistogram("request.latency_seconds", "api-1", "prod", "go", telemetry.Histogram{Buckets: []float64{1}, Counts: []uint64{4}, Count: 4, Sum: 2})
+
+		expected := latencyExposition(`blackbox_sidecar_histogram_request_latency_seconds_bucket{namespace="prod",pod="api-1",runtime="go",le="1"} 4
+blackbox_sidecar_histogram_request_latency_seconds_bucket{namespace="prod",pod="api-1",runtime="go",le="+Inf"} 4
+blackbox_sidecar_histogram_request_latency_seconds_sum{namespace="prod",pod="api-1",runtime="go"} 2
+blackbox_sidecar_histogram_request_latency_seconds_count{namespace="prod",pod="api-1",runtime="go"} 4
+`)
+		if err := testutil.GatherAndCompare(collector.registry, strings.NewReader(expected), "blackbox_sidecar_histogram_request_latency_seconds"); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("caps and releases series", func(t *testing.T) {
+		collector := NewCollector(9095, "/metrics", WithSidecarSeriesLimit(1))
+
+		collector.RecordSidecarHistogram("request.latency_seconds", "api-1", "prod", "go", latency([]uint64{1, 0}, 1, 0.05, false))
+		collector.RecordSidecarHistogram("request.latency_seconds", "api-2", "prod", "go", latency([]uint64{1, 0}, 1, 0.05, false))
+		if count := testutil.CollectAndCount(collector.sidecarHistograms); count != 1 {
+			t.Errorf("Expected 1 series under the limit, got %d", count)
+		}
+
+		collector.ReleaseSidecar("api-1", "prod")
+		collector.RecordSidecarHistogram("request.latency_seconds", "api-2", "prod", "go", latency([]uint64{1, 0}, 1, 0.05, false))
+		if count := testutil.CollectAndCount(collector.sidecarHistograms); count != 1 {
+			t.Errorf("Expected the released slot taken by api-2, got %d series", count)
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultLabelValueMaxLength caps the bytes of label values taken from sidecar
+// submissions.
+const DefaultLabelValueMaxLength = 256
+
+// Reasons a label value is sanitized, the values of the "reason" label of
+// blackbox_sidecar_label_sanitized_total.
+const (
+	// labelTruncated marks a value cut to the maximum length
+	labelTruncated = "truncated"
+	// labelInvalidUTF8 marks a value with invalid UTF-8 sequences replaced
+	labelInvalidUTF8 = "invalid_utf8"
+)
+
+// labelSanitizer makes label values taken from sidecar submissions safe to
+// expose. Prometheus rejects label values that are not valid UTF-8, which
+// fails the whole scrape, and very long values bloat every scrape and the
+// scraper's index, so a single malformed sidecar tag could break the endpoint.
+type labelSanitizer struct {
+	// maxLength is the maximum bytes of a value (zero or less is unlimited)
+	maxLength int
+	// counter counts sanitized values by reason
+	counter *prometheus.CounterVec
+}
+
+// sanitize returns the values with invalid UTF-8 sequences replaced by U+FFFD
+// and values longer than maxLength cut at a character boundary. Values that
+// need no change are returned as they are.
+func (ls *labelSanitizer) sanitize(values ...string) []string {
+	sanitized, copied := values, false
+	for i, value := range values {
+		clean := value
+		if !utf8.ValidString(clean) {
+			clean = strings.ToValidUTF8(clean, "\uFFFD")
+			ls.counter.WithLabelValues(labelInvalidUTF8).Inc()
+		}
+		if ls.maxLength > 0 && len(clean) > ls.maxLength {
+			clean = truncateUTF8(clean, ls.maxLength)
+			ls.counter.WithLabelValues(labelTruncated).Inc()
+		}
+		if clean == value {
+			continue
+		}
+		// Copy before the first change so the caller's slice is left untouched
+		if !copied {
+			sanitized, copied = append([]string(nil), values...), true
+		}
+		sanitized[i] = clean
+	}
+	return sanitized
+}
+
+// truncateUTF8 cuts a valid UTF-8 string to at most maxBytes without
+// splitting a multi-byte character.
+func truncateUTF8(value string, maxBytes int) string {
+	end := maxBytes
+	for end > 0 && !utf8.RuneStart(value[end]) {
+		end--
+	}
+	return value[:end]
+}
@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
+)
+
+// TestSidecarLabelSanitization validates sanitizing label values taken from
+// sidecar submissions before they are exported.
+func TestSidecarLabelSanitization(t *testing.T) {
+	// scrape returns the exposition of the collector's metrics endpoint.
+	scrape := func(t *testing.T, collector *Collector) string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		collector.httpServer.Handler.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+		if w.Code != 200 {
+			t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+
+	t.Run("sanitizes over-long and invalid UTF-8 values", func(t *testing.T) {
+		collector := NewCollector(9099, "/metrics", WithLabelValueMaxLength(16))
+		longPod := "checkout-" + strings.Repeat("x", 40)
+		invalidNamespace := "prod\xff\xfeteam"
+
+		collector.RecordSidecarRequest(longPod, invalidNamespace, "jvm")
+		collector.RecordSidecarHistogram("latency_seconds", longPod, invalidNamespace, "jvm", telemetry.Histogram{
+			Buckets: []float64{0.1, 1},
+			Counts:  []uint64{3, 1, 0},
+			Count:   4,
+			Sum:     1.5,
+		})
+
+		body := scrape(t, collector)
+		expectedLabels := "namespace=\"prod\uFFFDteam\",pod=\"checkout-xxxxxxx\",runtime=\"jvm\""
+		if !strings.Contains(body, "blackbox_sidecar_pod_requests_total{"+expectedLabels+"} 1") {
+			t.Errorf("Expected the sanitized request series, got:\n%s", body)
+		}
+		if !strings.Contains(body, "blackbox_sidecar_histogram_latency_seconds_count{"+expectedLabels+"} 4") {
+			t.Errorf("Expected the sanitized histogram series, got:\n%s", body)
+		}
+		if strings.Contains(body, longPod) {
+			t.Error("Expected the over-long pod name to be truncated")
+		}
+
+		if value := testutil.ToFloat64(collector.sidecarLabelCounter.WithLabelValues(labelTruncated)); value != 2 {
+			t.Errorf("Expected 2 truncations, got %v", value)
+		}
+		if value := testutil.ToFloat64(collector.sidecarLabelCounter.WithLabelValues(labelInvalidUTF8)); value != 2 {
+			t.Errorf("Expected 2 invalid UTF-8 replacements, got %v", value)
+		}
+	})
+
+	t.Run("keeps valid values and multi-byte characters intact", func(t *testing.T) {
+		collector := NewCollector(9099, "/metrics", WithLabelValueMaxLength(5))
+
+		collector.RecordSidecarRequest("api-1", "prod", "jvm")
+		collector.RecordSidecarRequest("añañaña", "prod", "go")
+
+		if value := testutil.ToFloat64(collector.sidecarPodCounter.WithLabelValues("api-1", "prod", "jvm")); value != 1 {
+			t.Errorf("Expected the valid series unchanged, got %v", value)
+		}
+		// The fifth byte starts the two-byte "ñ", which is dropped rather than split
+		if value := testutil.ToFloat64(collector.sidecarPodCounter.WithLabelValues("aña", "prod", "go")); value != 1 {
+			t.Errorf("Expected the value cut at a character boundary, got %v", value)
+		}
+	})
+
+	t.Run("releases sanitized series", func(t *testing.T) {
+		collector := NewCollector(9099, "/metrics", WithLabelValueMaxLength(8))
+		collector.RecordSidecarRequest("web-1234567890", "default", "go")
+
+		collector.ReleaseSidecar("web-1234567890", "default")
+
+		if count := testutil.CollectAndCount(collector.sidecarPodCounter); count != 0 {
+			t.Errorf("Expected the sanitized series released, got %d series", count)
+		}
+	})
+
+	t.Run("removes the length cap", func(t *testing.T) {
+		collector := NewCollector(9099, "/metrics", WithLabelValueMaxLength(0))
+		pod := strings.Repeat("p", 1000)
+
+		collector.RecordSidecarRequest(pod, "default", "go")
+
+		if value := testutil.ToFloat64(collector.sidecarPodCounter.WithLabelValues(pod, "default", "go")); value != 1 {
+			t.Errorf("Expected the long value kept, got %v", value)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultStaleSeriesMisses is the number of consecutive missed cycles before a series is deleted
+	DefaultStaleSeriesMisses = 3
+	// DefaultStaleSeriesWarmup is the number of cycles after startup during which no series is deleted
+	DefaultStaleSeriesWarmup = 2
+)
+
+// trackedSeries is a label set recorded on a gauge and its consecutive misses.
+type trackedSeries struct {
+	labels []string
+	misses int
+	seen   bool
+}
+
+// seriesTracker deletes label sets from a gauge once they stop being recorded.
+// A series is only deleted after it has been absent for a number of consecutive
+// cycles, and never during the warmup cycles, so a device that is briefly absent
+// or not yet discovered keeps its series instead of flapping.
+type seriesTracker struct {
+	mutex sync.Mutex
+	// gauge holds the tracked series
+	gauge *prometheus.GaugeVec
+	// series maps joined label values to their tracking state
+	series map[string]*trackedSeries
+	// misses is the number of consecutive misses before deletion; zero or less disables deletion
+	misses int
+	// warmup is the number of sweeps that must complete before anything is deleted
+	warmup int
+	// cycles counts completed sweeps
+	cycles int
+}
+
+// newSeriesTracker creates a tracker for the series of gauge.
+func newSeriesTracker(gauge *prometheus.GaugeVec, misses, warmup int) *seriesTracker {
+	return &seriesTracker{
+		gauge:  gauge,
+		series: make(map[string]*trackedSeries),
+		misses: misses,
+		warmup: warmup,
+	}
+}
+
+// seen marks a label set as recorded in the current cycle.
+func (st *seriesTracker) seen(labels ...string) {
+	key := strings.Join(labels, "\xff")
+
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	if s, ok := st.series[key]; ok {
+		s.seen = true
+		s.misses = 0
+		return
+	}
+	st.series[key] = &trackedSeries{labels: labels, seen: true}
+}
+
+// sweep ends a cycle, counting a miss for every label set not seen since the
+// previous sweep and deleting those that reached the miss threshold.
+func (st *seriesTracker) sweep() {
+	st.mutex.Lock()
+	defer st.mutex.Unlock()
+
+	st.cycles++
+	for key, s := range st.series {
+		if s.seen {
+			s.seen = false
+			continue
+		}
+		s.misses++
+		if st.misses <= 0 || st.cycles <= st.warmup || s.misses < st.misses {
+			continue
+		}
+		st.gauge.DeleteLabelValues(s.labels...)
+		delete(st.series, key)
+	}
+}
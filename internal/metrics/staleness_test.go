@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// TestSweepStaleSeries validates warmup and consecutive-miss deletion of interface and device series.
+func TestSweepStaleSeries(t *testing.T) {
+	t.Run("keeps interface absent for one cycle", func(t *testing.T) {
+		collector := NewCollector(9094, "/metrics", WithStaleSeriesMisses(2), WithStaleSeriesWarmup(0))
+
+		collector.RecordNetworkBytes("eth0", "rx", 100)
+		collector.RecordNetworkBytes("eth1", "rx", 200)
+		collector.SweepStaleSeries()
+
+		// eth1 misses a single cycle
+		collector.RecordNetworkBytes("eth0", "rx", 110)
+		collector.SweepStaleSeries()
+		if count := testutil.CollectAndCount(collector.networkBytesGauge); count != 2 {
+			t.Fatalf("Expected 2 series after one miss, got %d", count)
+		}
+
+		collector.RecordNetworkBytes("eth0", "rx", 120)
+		collector.RecordNetworkBytes("eth1", "rx", 250)
+		collector.SweepStaleSeries()
+		collector.RecordNetworkBytes("eth0", "rx", 130)
+		collector.SweepStaleSeries()
+
+		if count := testutil.CollectAndCount(collector.networkBytesGauge); count != 2 {
+			t.Errorf("Expected returning interface to reset its misses, got %d series", count)
+		}
+		if value := testutil.ToFloat64(collector.networkBytesGauge.WithLabelValues("eth1", "rx")); value != 250 {
+			t.Errorf("Expected eth1 value 250, got %v", value)
+		}
+	})
+
+	t.Run("deletes series after consecutive misses", func(t *testing.T) {
+		collector := NewCollector(9094, "/metrics", WithStaleSeriesMisses(2), WithStaleSeriesWarmup(0))
+
+		collector.RecordDiskIO("sda", "read", 100)
+		collector.RecordDiskIO("sdb", "read", 200)
+		collector.SweepStaleSeries()
+
+		for i := 0; i < 2; i++ {
+			collector.RecordDiskIO("sda", "read", 100)
+			collector.SweepStaleSeries()
+		}
+
+		if count := testutil.CollectAndCount(collector.diskIOGauge); count != 1 {
+			t.Errorf("Expected removed device to be deleted, got %d series", count)
+		}
+	})
+
+	t.Run("does not delete during warmup", func(t *testing.T) {
+		collector := NewCollector(9094, "/metrics", WithStaleSeriesMisses(1), WithStaleSeriesWarmup(3))
+
+		collector.RecordNetworkBytes("eth0", "tx", 100)
+		collector.SweepStaleSeries()
+		collector.SweepStaleSeries()
+		collector.SweepStaleSeries()
+		if count := testutil.CollectAndCount(collector.networkBytesGauge); count != 1 {
+			t.Fatalf("Expected series to survive warmup, got %d", count)
+		}
+
+		collector.SweepStaleSeries()
+		if count := testutil.CollectAndCount(collector.networkBytesGauge); count != 0 {
+			t.Errorf("Expected series to be deleted after warmup, got %d", count)
+		}
+	})
+
+	t.Run("can be disabled", func(t *testing.T) {
+		collector := NewCollector(9094, "/metrics", WithStaleSeriesMisses(0))
+
+		collector.RecordNetworkBytes("eth0", "rx", 100)
+		for i := 0; i < 10; i++ {
+			collector.SweepStaleSeries()
+		}
+
+		if count := testutil.CollectAndCount(collector.networkBytesGauge); count != 1 {
+			t.Errorf("Expected no deletion when disabled, got %d series", count)
+		}
+	})
+}
@@ -0,0 +1,133 @@
+// Package recovery turns panics inside the daemon into self-incidents. A
+// recovered panic is reported as an incident carrying the stack trace, so the
+// daemon's own failures reach the same outputs as the incidents it detects.
+package recovery
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/version"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// IncidentSelfError identifies incidents raised for failures of the daemon itself.
+const IncidentSelfError types.IncidentType = "self_error"
+
+// DefaultMaxStackBytes bounds the stack trace attached to self-incidents.
+const DefaultMaxStackBytes = 64 << 10
+
+// Recoverer recovers panics and reports them as self-incidents.
+type Recoverer struct {
+	// report receives self-incidents, typically the daemon's incident handler
+	report func(types.IncidentReport)
+	// allGoroutines dumps every goroutine instead of only the panicking one
+	allGoroutines bool
+	// maxStackBytes bounds the captured stack
+	maxStackBytes int
+	// clock supplies incident timestamps
+	clock clock.Clock
+}
+
+// Option configures optional recoverer behavior at construction time.
+type Option func(*Recoverer)
+
+// WithAllGoroutines includes a dump of all goroutines instead of only the stack
+// of the panicking goroutine.
+func WithAllGoroutines(enabled bool) Option {
+	return func(r *Recoverer) {
+		r.allGoroutines = enabled
+	}
+}
+
+// WithMaxStackBytes bounds the captured stack trace. Defaults to DefaultMaxStackBytes.
+func WithMaxStackBytes(limit int) Option {
+	return func(r *Recoverer) {
+		r.maxStackBytes = limit
+	}
+}
+
+// WithClock sets the clock used for incident timestamps. Defaults to the system clock.
+func WithClock(c clock.Clock) Option {
+	return func(r *Recoverer) {
+		r.clock = c
+	}
+}
+
+// NewRecoverer creates a recoverer that passes self-incidents to report.
+func NewRecoverer(report func(types.IncidentReport), opts ...Option) *Recoverer {
+	r := &Recoverer{
+		report:        report,
+		maxStackBytes: DefaultMaxStackBytes,
+		clock:         clock.Real{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Recover stops a panic in progress and reports it as a self-incident for the
+// named component. It must be deferred directly:
+//
+//	defer recoverer.Recover("pod-watcher")
+func (r *Recoverer) Recover(component string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	r.reportPanic(component, recovered)
+}
+
+// Middleware recovers panics in HTTP handlers, responding with 500 Internal
+// Server Error and reporting a self-incident for the component.
+func (r *Recoverer) Middleware(component string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			recovered := recover()
+			if recovered == nil {
+				return
+			}
+			// Let net/http abort the response as it would without the middleware
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			r.reportPanic(component, recovered)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}()
+		next.ServeHTTP(w, req)
+	})
+}
+
+// reportPanic captures the stack and reports the self-incident. It runs in the
+// deferred call of the panicking goroutine so the stack still shows the panic site.
+func (r *Recoverer) reportPanic(component string, recovered interface{}) {
+	stack, truncated := r.captureStack()
+	fmt.Printf("Recovered panic in %s: %v\n", component, recovered)
+
+	now := r.clock.Now()
+	r.report(types.IncidentReport{
+		ID:        fmt.Sprintf("self-%s-%d", component, now.UnixNano()),
+		Timestamp: now,
+		Severity:  types.SeverityHigh,
+		Type:      IncidentSelfError,
+		Message:   fmt.Sprintf("BlackBox daemon recovered from a panic in %s: %v", component, recovered),
+		Context: version.Tag(map[string]interface{}{
+			"component":       component,
+			"panic":           fmt.Sprint(recovered),
+			"stack":           stack,
+			"stack_truncated": truncated,
+			"all_goroutines":  r.allGoroutines,
+		}),
+	})
+}
+
+// captureStack returns the current stack, or all goroutines when configured,
+// bounded by maxStackBytes.
+func (r *Recoverer) captureStack() (string, bool) {
+	buf := make([]byte, r.maxStackBytes)
+	n := runtime.Stack(buf, r.allGoroutines)
+	return string(buf[:n]), n == len(buf)
+}
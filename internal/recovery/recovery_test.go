@@ -0,0 +1,121 @@
+package recovery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/version"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// recordingRecoverer returns a recoverer that records self-incidents.
+func recordingRecoverer(opts ...Option) (*Recoverer, *[]types.IncidentReport) {
+	var reports []types.IncidentReport
+	opts = append([]Option{WithClock(clock.NewFake(time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)))}, opts...)
+	return NewRecoverer(func(report types.IncidentReport) {
+		reports = append(reports, report)
+	}, opts...), &reports
+}
+
+// panickingHelper panics from a named frame so it can be found in the stack.
+func panickingHelper() {
+	panic("nil map write")
+}
+
+// TestRecover validates reporting recovered panics as self-incidents.
+func TestRecover(t *testing.T) {
+	t.Run("reports panic with stack", func(t *testing.T) {
+		recoverer, reports := recordingRecoverer()
+
+		func() {
+			defer recoverer.Recover("pod-watcher")
+			panickingHelper()
+		}()
+
+		if len(*reports) != 1 {
+			t.Fatalf("Expected 1 self-incident, got %d", len(*reports))
+		}
+		report := (*reports)[0]
+		if report.Type != IncidentSelfError {
+			t.Errorf("Expected type %s, got %s", IncidentSelfError, report.Type)
+		}
+		if report.Context["component"] != "pod-watcher" || report.Context["panic"] != "nil map write" {
+			t.Errorf("Expected component and panic value, got %v", report.Context)
+		}
+		stack, _ := report.Context["stack"].(string)
+		if !strings.Contains(stack, "panickingHelper") {
+			t.Errorf("Expected stack to include the panic site, got %q", stack)
+		}
+		if report.Context["blackbox_version"] == nil {
+			t.Error("Expected self-incident to be tagged with the daemon version")
+		}
+		if version.SchemaVersion(report.Context) != version.IncidentSchemaVersion {
+			t.Errorf("Expected schema version %d, got %v", version.IncidentSchemaVersion, report.Context["schema_version"])
+		}
+	})
+
+	t.Run("ignores normal return", func(t *testing.T) {
+		recoverer, reports := recordingRecoverer()
+
+		func() {
+			defer recoverer.Recover("pod-watcher")
+		}()
+
+		if len(*reports) != 0 {
+			t.Errorf("Expected no self-incidents, got %d", len(*reports))
+		}
+	})
+
+	t.Run("bounds stack size", func(t *testing.T) {
+		recoverer, reports := recordingRecoverer(WithMaxStackBytes(128), WithAllGoroutines(true))
+
+		func() {
+			defer recoverer.Recover("collector")
+			panickingHelper()
+		}()
+
+		report := (*reports)[0]
+		if stack := report.Context["stack"].(string); len(stack) != 128 {
+			t.Errorf("Expected stack bounded to 128 bytes, got %d", len(stack))
+		}
+		if report.Context["stack_truncated"] != true || report.Context["all_goroutines"] != true {
+			t.Errorf("Expected truncated goroutine dump, got %v", report.Context)
+		}
+	})
+}
+
+// TestMiddleware validates recovering panics in HTTP handlers.
+func TestMiddleware(t *testing.T) {
+	recoverer, reports := recordingRecoverer()
+	handler := recoverer.Middleware("api", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/panic" {
+			panickingHelper()
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	t.Run("responds 500 and reports panic", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+
+		if w.Code != http.StatusInternalServerError {
+			t.Errorf("Expected status 500, got %d", w.Code)
+		}
+		if len(*reports) != 1 || (*reports)[0].Context["component"] != "api" {
+			t.Errorf("Expected api self-incident, got %v", *reports)
+		}
+	})
+
+	t.Run("passes through normal requests", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "/ok", nil))
+
+		if w.Code != http.StatusOK {
+			t.Errorf("Expected status 200, got %d", w.Code)
+		}
+	})
+}
@@ -0,0 +1,113 @@
+package ringbuffer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// Summary aggregates a set of telemetry entries, such as an incident snapshot.
+type Summary struct {
+	// Count is the number of entries
+	Count int
+	// Start is the timestamp of the earliest entry
+	Start time.Time
+	// End is the timestamp of the latest entry
+	End time.Time
+	// Metrics summarizes each distinct metric name, sorted by name
+	Metrics []MetricSummary
+}
+
+// Span returns the time covered by the entries.
+func (s Summary) Span() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+// MetricSummary aggregates the entries of one metric name.
+type MetricSummary struct {
+	// Name is the metric name
+	Name string
+	// Count is the number of entries with this name
+	Count int
+	// Last is the value of the latest entry
+	Last interface{}
+	// LastAt is the timestamp of the latest entry
+	LastAt time.Time
+	// Numeric reports whether Last is a number, in which case LastNumber holds it
+	Numeric bool
+	// LastNumber is Last converted to float64 when Numeric
+	LastNumber float64
+	// NumericCount is the number of entries with a numeric value
+	NumericCount int
+	// Min, Max and Mean aggregate the numeric values (zero when NumericCount is zero)
+	Min  float64
+	Max  float64
+	Mean float64
+}
+
+// Aggregate summarizes entries by count, time span and per-metric latest value.
+// Entries may be in any order.
+func Aggregate(entries []types.TelemetryEntry) Summary {
+	summary := Summary{Count: len(entries)}
+	metrics := make(map[string]*MetricSummary)
+
+	for i, entry := range entries {
+		if i == 0 || entry.Timestamp.Before(summary.Start) {
+			summary.Start = entry.Timestamp
+		}
+		if i == 0 || entry.Timestamp.After(summary.End) {
+			summary.End = entry.Timestamp
+		}
+
+		metric, ok := metrics[entry.Name]
+		if !ok {
+			metric = &MetricSummary{Name: entry.Name}
+			metrics[entry.Name] = metric
+		}
+		metric.Count++
+		if metric.Count == 1 || !entry.Timestamp.Before(metric.LastAt) {
+			metric.Last = entry.Value
+			metric.LastAt = entry.Timestamp
+			metric.LastNumber, metric.Numeric = telemetry.Float64Value(entry.Value)
+		}
+		if value, ok := telemetry.Float64Value(entry.Value); ok {
+			metric.NumericCount++
+			if metric.NumericCount == 1 || value < metric.Min {
+				metric.Min = value
+			}
+			if metric.NumericCount == 1 || value > metric.Max {
+				metric.Max = value
+			}
+			// Running mean, avoiding overflow of a sum of large counters
+			metric.Mean += (value - metric.Mean) / float64(metric.NumericCount)
+		}
+	}
+
+	summary.Metrics = make([]MetricSummary, 0, len(metrics))
+	for _, metric := range metrics {
+		summary.Metrics = append(summary.Metrics, *metric)
+	}
+	sort.Slice(summary.Metrics, func(i, j int) bool {
+		return summary.Metrics[i].Name < summary.Metrics[j].Name
+	})
+	return summary
+}
+
+// TopByLast returns up to n numeric metrics with the highest latest values.
+func (s Summary) TopByLast(n int) []MetricSummary {
+	top := make([]MetricSummary, 0, len(s.Metrics))
+	for _, metric := range s.Metrics {
+		if metric.Numeric {
+			top = append(top, metric)
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool {
+		return top[i].LastNumber > top[j].LastNumber
+	})
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
@@ -0,0 +1,59 @@
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestAggregate validates summarizing telemetry entries.
+func TestAggregate(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	entries := []types.TelemetryEntry{
+		{Timestamp: base.Add(2 * time.Second), Name: "cpu_usage", Value: 91.5},
+		{Timestamp: base, Name: "cpu_usage", Value: 40.0},
+		{Timestamp: base.Add(time.Second), Name: "open_files", Value: uint64(512)},
+		{Timestamp: base.Add(30 * time.Second), Name: "gc_phase", Value: "mark"},
+		{Timestamp: base.Add(3 * time.Second), Name: "heap_used", Value: 2048},
+	}
+
+	t.Run("summarizes count, span and metrics", func(t *testing.T) {
+		summary := Aggregate(entries)
+
+		if summary.Count != 5 {
+			t.Errorf("Expected 5 entries, got %d", summary.Count)
+		}
+		if !summary.Start.Equal(base) || summary.Span() != 30*time.Second {
+			t.Errorf("Expected span of 30s from %v, got %v from %v", base, summary.Span(), summary.Start)
+		}
+		if len(summary.Metrics) != 4 || summary.Metrics[0].Name != "cpu_usage" {
+			t.Fatalf("Expected 4 metrics sorted by name, got %+v", summary.Metrics)
+		}
+		if cpu := summary.Metrics[0]; cpu.Count != 2 || cpu.Last != 91.5 {
+			t.Errorf("Expected 2 cpu_usage entries with last value 91.5, got %+v", cpu)
+		}
+		if cpu := summary.Metrics[0]; cpu.NumericCount != 2 || cpu.Min != 40 || cpu.Max != 91.5 || cpu.Mean != 65.75 {
+			t.Errorf("Expected cpu_usage min 40, max 91.5 and mean 65.75, got %+v", cpu)
+		}
+		if phase := summary.Metrics[1]; phase.Name != "gc_phase" || phase.NumericCount != 0 {
+			t.Errorf("Expected no numeric values for gc_phase, got %+v", phase)
+		}
+	})
+
+	t.Run("ranks numeric metrics by last value", func(t *testing.T) {
+		top := Aggregate(entries).TopByLast(2)
+
+		if len(top) != 2 || top[0].Name != "heap_used" || top[1].Name != "open_files" {
+			t.Errorf("Expected heap_used and open_files, got %+v", top)
+		}
+	})
+
+	t.Run("handles no entries", func(t *testing.T) {
+		summary := Aggregate(nil)
+
+		if summary.Count != 0 || len(summary.Metrics) != 0 || summary.Span() != 0 {
+			t.Errorf("Expected empty summary, got %+v", summary)
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package ringbuffer
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// DefaultDedupInterval is how long an unchanged value is collapsed before it is
+// stored again, so a deduplicated series still appears in every snapshot.
+const DefaultDedupInterval = time.Minute
+
+// dedupSeries is the state of one deduplicated name and tag set.
+type dedupSeries struct {
+	// value is the last stored value
+	value float64
+	// stored is the timestamp of the last stored entry
+	stored time.Time
+	// lastSeen is the timestamp of the latest entry, stored or collapsed
+	lastSeen time.Time
+}
+
+// deduplicator collapses numeric entries of slow-changing metrics whose value
+// equals the last stored one.
+type deduplicator struct {
+	// patterns are the glob patterns, as matched by path.Match, of the metric
+	// names that are deduplicated
+	patterns []string
+	// interval bounds how long an unchanged value is collapsed
+	interval time.Duration
+	// series tracks each deduplicated name and tag set
+	series map[string]*dedupSeries
+	// collapsed counts the entries that were not stored
+	collapsed uint64
+}
+
+// WithDedupUnchanged collapses numeric entries whose name matches one of the
+// glob patterns, e.g. "memory_total_bytes" or "*_limit_bytes", when their value
+// equals the previous entry with the same name and tags. A collapsed entry is
+// not stored; only the series' last-seen time (see LastSeen) is updated. The
+// value is stored again once interval has passed since it was last stored, so
+// gauges that rarely change keep a sample in every window. An interval of zero
+// or less uses DefaultDedupInterval. Deduplication is lossy and disabled by
+// default; non-numeric entries are never collapsed.
+func WithDedupUnchanged(patterns []string, interval time.Duration) Option {
+	return func(rb *RingBuffer) {
+		if len(patterns) == 0 {
+			rb.dedup = nil
+			return
+		}
+		if interval <= 0 {
+			interval = DefaultDedupInterval
+		}
+		rb.dedup = &deduplicator{
+			patterns: patterns,
+			interval: interval,
+			series:   make(map[string]*dedupSeries),
+		}
+	}
+}
+
+// collapse reports whether the entry repeats the last stored value of its
+// series within the interval and should not be stored, recording it either way.
+func (d *deduplicator) collapse(entry types.TelemetryEntry) bool {
+	if !d.matches(entry.Name) {
+		return false
+	}
+	value, ok := telemetry.Float64Value(entry.Value)
+	if !ok {
+		return false
+	}
+
+	key := seriesKey(entry.Name, entry.Tags)
+	series, ok := d.series[key]
+	if !ok {
+		d.series[key] = &dedupSeries{value: value, stored: entry.Timestamp, lastSeen: entry.Timestamp}
+		return false
+	}
+	if entry.Timestamp.After(series.lastSeen) {
+		series.lastSeen = entry.Timestamp
+	}
+
+	if elapsed := entry.Timestamp.Sub(series.stored); value == series.value && elapsed >= 0 && elapsed < d.interval {
+		d.collapsed++
+		return true
+	}
+	series.value = value
+	series.stored = entry.Timestamp
+	return false
+}
+
+// matches reports whether the metric name is deduplicated.
+func (d *deduplicator) matches(name string) bool {
+	for _, pattern := range d.patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// expire forgets the series not seen since the cutoff.
+func (d *deduplicator) expire(cutoff time.Time) {
+	for key, series := range d.series {
+		if series.lastSeen.Before(cutoff) {
+			delete(d.series, key)
+		}
+	}
+}
+
+// seriesKey returns a stable key for a metric name and tag set.
+func seriesKey(name string, tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return name + "{" + strings.Join(pairs, ",") + "}"
+}
+
+// LastSeen returns the timestamp of the latest entry added for a deduplicated
+// metric name and tag set, including entries collapsed by WithDedupUnchanged.
+// The tags must include any default tags. It returns false for series that
+// are not deduplicated or were not seen within the window.
+func (rb *RingBuffer) LastSeen(name string, tags map[string]string) (time.Time, bool) {
+	rb.mutex.RLock()
+	defer rb.mutex.RUnlock()
+
+	if rb.dedup == nil {
+		return time.Time{}, false
+	}
+	series, ok := rb.dedup.series[seriesKey(name, tags)]
+	if !ok {
+		return time.Time{}, false
+	}
+	return series.lastSeen, true
+}
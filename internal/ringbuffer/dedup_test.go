@@ -0,0 +1,128 @@
+package ringbuffer
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestDedupUnchanged validates collapsing unchanged values of deduplicated metrics.
+func TestDedupUnchanged(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	entry := func(offset time.Duration, name string, value interface{}, tags map[string]string) types.TelemetryEntry {
+		return types.TelemetryEntry{
+			Timestamp: now.Add(offset),
+			Source:    types.SourceSystem,
+			Type:      types.TypeMemory,
+			Name:      name,
+			Value:     value,
+			Tags:      tags,
+		}
+	}
+
+	t.Run("collapses unchanged values", func(t *testing.T) {
+		rb := New(5*time.Minute, WithDedupUnchanged([]string{"memory_total_bytes"}, time.Minute))
+		for i := 0; i < 10; i++ {
+			rb.Add(entry(time.Duration(i)*time.Second, "memory_total_bytes", int64(8<<30), nil))
+		}
+
+		entries := rb.GetAll()
+		if len(entries) != 1 {
+			t.Fatalf("Expected unchanged values collapsed into 1 entry, got %d", len(entries))
+		}
+		if !entries[0].Timestamp.Equal(now) {
+			t.Errorf("Expected the first entry kept, got %v", entries[0].Timestamp)
+		}
+		lastSeen, ok := rb.LastSeen("memory_total_bytes", nil)
+		if !ok || !lastSeen.Equal(now.Add(9*time.Second)) {
+			t.Errorf("Expected last seen %v, got %v (%v)", now.Add(9*time.Second), lastSeen, ok)
+		}
+		if collapsed := rb.GetStats().CollapsedEntries; collapsed != 9 {
+			t.Errorf("Expected 9 collapsed entries, got %d", collapsed)
+		}
+	})
+
+	t.Run("stores changed values", func(t *testing.T) {
+		rb := New(5*time.Minute, WithDedupUnchanged([]string{"memory_*"}, time.Minute))
+		rb.Add(entry(0, "memory_total_bytes", 100, nil))
+		rb.Add(entry(time.Second, "memory_total_bytes", json.Number("100"), nil))
+		rb.Add(entry(2*time.Second, "memory_total_bytes", 200, nil))
+		rb.Add(entry(3*time.Second, "memory_total_bytes", 100, nil))
+
+		if entries := rb.GetAll(); len(entries) != 3 {
+			t.Errorf("Expected each change stored, got %d entries", len(entries))
+		}
+	})
+
+	t.Run("stores unchanged values again after the interval", func(t *testing.T) {
+		rb := New(5*time.Minute, WithDedupUnchanged([]string{"memory_total_bytes"}, time.Minute))
+		for i := 0; i <= 120; i++ {
+			rb.Add(entry(time.Duration(i)*time.Second, "memory_total_bytes", 100, nil))
+		}
+
+		if entries := rb.GetAll(); len(entries) != 3 {
+			t.Errorf("Expected one entry per interval, got %d", len(entries))
+		}
+	})
+
+	t.Run("keeps series with different tags apart", func(t *testing.T) {
+		rb := New(5*time.Minute, WithDedupUnchanged([]string{"memory_total_bytes"}, time.Minute))
+		rb.Add(entry(0, "memory_total_bytes", 100, map[string]string{"pod_name": "api-1"}))
+		rb.Add(entry(time.Second, "memory_total_bytes", 100, map[string]string{"pod_name": "api-2"}))
+		rb.Add(entry(2*time.Second, "memory_total_bytes", 100, map[string]string{"pod_name": "api-1"}))
+
+		if entries := rb.GetAll(); len(entries) != 2 {
+			t.Errorf("Expected one entry per tag set, got %d", len(entries))
+		}
+	})
+
+	t.Run("leaves other metrics alone", func(t *testing.T) {
+		rb := New(5*time.Minute, WithDedupUnchanged([]string{"memory_total_bytes"}, time.Minute))
+		rb.Add(entry(0, "memory_used_bytes", 100, nil))
+		rb.Add(entry(time.Second, "memory_used_bytes", 100, nil))
+		rb.Add(entry(0, "memory_total_bytes", "8Gi", nil))
+		rb.Add(entry(time.Second, "memory_total_bytes", "8Gi", nil))
+
+		if entries := rb.GetAll(); len(entries) != 4 {
+			t.Errorf("Expected unmatched and non-numeric entries stored, got %d", len(entries))
+		}
+		if _, ok := rb.LastSeen("memory_used_bytes", nil); ok {
+			t.Error("Expected no last seen time for a metric that is not deduplicated")
+		}
+	})
+
+	t.Run("collapses within batches", func(t *testing.T) {
+		rb := New(5*time.Minute, WithDedupUnchanged([]string{"memory_total_bytes"}, time.Minute))
+		rb.AddBatch([]types.TelemetryEntry{
+			entry(0, "memory_total_bytes", 100, nil),
+			entry(0, "memory_used_bytes", 50, nil),
+			entry(time.Second, "memory_total_bytes", 100, nil),
+			entry(time.Second, "memory_used_bytes", 60, nil),
+		})
+
+		if entries := rb.GetAll(); len(entries) != 3 {
+			t.Errorf("Expected 3 entries, got %d", len(entries))
+		}
+	})
+
+	t.Run("cleanup forgets expired series", func(t *testing.T) {
+		fakeClock := clock.NewFake(now)
+		rb := New(30*time.Second, WithClock(fakeClock), WithDedupUnchanged([]string{"memory_total_bytes"}, time.Minute))
+		rb.Add(entry(0, "memory_total_bytes", 100, nil))
+
+		fakeClock.Advance(time.Minute)
+		rb.Cleanup()
+		if _, ok := rb.LastSeen("memory_total_bytes", nil); ok {
+			t.Error("Expected the expired series to be forgotten")
+		}
+
+		rb.Add(entry(time.Minute, "memory_total_bytes", 100, nil))
+		if entries := rb.GetAll(); len(entries) != 1 {
+			t.Errorf("Expected the value stored again after expiry, got %d entries", len(entries))
+		}
+	})
+}
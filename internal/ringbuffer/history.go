@@ -0,0 +1,130 @@
+package ringbuffer
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultHistoryInterval is how often buffer statistics are sampled
+	DefaultHistoryInterval = 10 * time.Second
+	// DefaultHistoryLength is how many samples are kept, an hour at the
+	// default interval
+	DefaultHistoryLength = 360
+	// MaxHistoryLength bounds how many samples can be kept
+	MaxHistoryLength = 100000
+)
+
+// StatsSample is the buffer occupancy at one point in time.
+type StatsSample struct {
+	// Timestamp is when the sample was taken
+	Timestamp time.Time `json:"timestamp"`
+	// TotalEntries is the number of entries in the buffer
+	TotalEntries int `json:"total_entries"`
+	// ActualWindow is the time span of the entries in the buffer
+	ActualWindow time.Duration `json:"actual_window"`
+	// EffectiveWindow is the history the buffer could guarantee
+	EffectiveWindow time.Duration `json:"effective_window"`
+	// DroppedEntries is the cumulative number of entries rejected
+	DroppedEntries uint64 `json:"dropped_entries"`
+}
+
+// StatsHistory keeps the most recent samples of a buffer's statistics, so fill
+// trends can be followed over time, e.g. to see the actual window shrink below
+// the configured one as the ingest rate grows.
+type StatsHistory struct {
+	// mutex protects samples, head and count
+	mutex sync.Mutex
+	// buffer is the sampled buffer
+	buffer *RingBuffer
+	// interval is how often Start samples the buffer
+	interval time.Duration
+	// samples is the circular array of samples
+	samples []StatsSample
+	// head is the next write position
+	head int
+	// count is the number of samples held
+	count int
+}
+
+// NewStatsHistory creates a history sampling the buffer every interval and
+// keeping the last length samples, at most MaxHistoryLength. Zero or less
+// uses DefaultHistoryInterval and DefaultHistoryLength.
+func NewStatsHistory(buffer *RingBuffer, interval time.Duration, length int) *StatsHistory {
+	if interval <= 0 {
+		interval = DefaultHistoryInterval
+	}
+	if length <= 0 {
+		length = DefaultHistoryLength
+	}
+	if length > MaxHistoryLength {
+		length = MaxHistoryLength
+	}
+	return &StatsHistory{
+		buffer:   buffer,
+		interval: interval,
+		samples:  make([]StatsSample, length),
+	}
+}
+
+// Interval returns how often the buffer is sampled.
+func (sh *StatsHistory) Interval() time.Duration {
+	return sh.interval
+}
+
+// Capacity returns how many samples are kept.
+func (sh *StatsHistory) Capacity() int {
+	return len(sh.samples)
+}
+
+// Sample records the buffer's current statistics, replacing the oldest sample
+// once the history is full.
+func (sh *StatsHistory) Sample() {
+	stats := sh.buffer.GetStats()
+	sample := StatsSample{
+		Timestamp:       sh.buffer.clock.Now(),
+		TotalEntries:    stats.TotalEntries,
+		ActualWindow:    stats.ActualWindow,
+		EffectiveWindow: stats.EffectiveWindow,
+		DroppedEntries:  stats.DroppedEntries,
+	}
+
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+	sh.samples[sh.head] = sample
+	sh.head = (sh.head + 1) % len(sh.samples)
+	if sh.count < len(sh.samples) {
+		sh.count++
+	}
+}
+
+// Samples returns the samples held, oldest first.
+func (sh *StatsHistory) Samples() []StatsSample {
+	sh.mutex.Lock()
+	defer sh.mutex.Unlock()
+
+	samples := make([]StatsSample, sh.count)
+	start := (sh.head - sh.count + len(sh.samples)) % len(sh.samples)
+	for i := range samples {
+		samples[i] = sh.samples[(start+i)%len(sh.samples)]
+	}
+	return samples
+}
+
+// Start samples the buffer immediately and then every interval until the
+// context is cancelled.
+func (sh *StatsHistory) Start(ctx context.Context) error {
+	sh.Sample()
+	ticker := time.NewTicker(sh.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			sh.Sample()
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package ringbuffer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestStatsHistory validates sampling buffer statistics over time.
+func TestStatsHistory(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("accumulates samples over time", func(t *testing.T) {
+		fake := clock.NewFake(now)
+		rb := New(time.Minute, WithClock(fake))
+		history := NewStatsHistory(rb, 10*time.Second, 0)
+
+		for i := 0; i < 3; i++ {
+			rb.Add(types.TelemetryEntry{Timestamp: fake.Now(), Source: types.SourceSystem, Name: "cpu_usage_percent", Value: i})
+			history.Sample()
+			fake.Advance(10 * time.Second)
+		}
+
+		samples := history.Samples()
+		if len(samples) != 3 {
+			t.Fatalf("Expected 3 samples, got %d", len(samples))
+		}
+		for i, sample := range samples {
+			if !sample.Timestamp.Equal(now.Add(time.Duration(i) * 10 * time.Second)) {
+				t.Errorf("Expected sample %d at %v, got %v", i, now.Add(time.Duration(i)*10*time.Second), sample.Timestamp)
+			}
+			if sample.TotalEntries != i+1 {
+				t.Errorf("Expected %d entries in sample %d, got %d", i+1, i, sample.TotalEntries)
+			}
+			if sample.ActualWindow != time.Duration(i)*10*time.Second {
+				t.Errorf("Expected actual window %v in sample %d, got %v", time.Duration(i)*10*time.Second, i, sample.ActualWindow)
+			}
+			if sample.EffectiveWindow != time.Minute {
+				t.Errorf("Expected effective window 1m, got %v", sample.EffectiveWindow)
+			}
+		}
+	})
+
+	t.Run("bounds the history", func(t *testing.T) {
+		fake := clock.NewFake(now)
+		history := NewStatsHistory(New(time.Minute, WithClock(fake)), time.Second, 4)
+
+		for i := 0; i < 10; i++ {
+			history.Sample()
+			fake.Advance(time.Second)
+		}
+
+		samples := history.Samples()
+		if len(samples) != 4 || history.Capacity() != 4 {
+			t.Fatalf("Expected 4 samples, got %d", len(samples))
+		}
+		if !samples[0].Timestamp.Equal(now.Add(6*time.Second)) || !samples[3].Timestamp.Equal(now.Add(9*time.Second)) {
+			t.Errorf("Expected the 4 most recent samples oldest first, got %v to %v", samples[0].Timestamp, samples[3].Timestamp)
+		}
+	})
+
+	t.Run("samples when started", func(t *testing.T) {
+		history := NewStatsHistory(New(time.Minute), time.Hour, 0)
+		if history.Interval() != time.Hour || history.Capacity() != DefaultHistoryLength {
+			t.Errorf("Expected 1h interval and default length, got %v and %d", history.Interval(), history.Capacity())
+		}
+
+		// Start samples before it notices the cancellation
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := history.Start(ctx); err != context.Canceled {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+		if len(history.Samples()) != 1 {
+			t.Errorf("Expected an initial sample, got %d", len(history.Samples()))
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package ringbuffer
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+)
+
+// writeGzip writes the lines to a gzipped file in dir.
+func writeGzip(t *testing.T, dir, name string, lines ...string) {
+	t.Helper()
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("Failed to create %s: %v", name, err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	gz.Write([]byte(strings.Join(lines, "\n") + "\n"))
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+}
+
+// TestImport validates backfilling the buffer from gzipped NDJSON dumps.
+func TestImport(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("imports entries within the window in order", func(t *testing.T) {
+		dir := t.TempDir()
+		writeGzip(t, dir, "node-b.ndjson.gz",
+			`{"timestamp":"2024-11-02T15:03:30Z","source":"system","type":"memory","name":"memory_used","value":2048}`,
+			`{"timestamp":"2024-11-02T14:00:00Z","source":"system","type":"cpu","name":"cpu_usage","value":10}`,
+		)
+		writeGzip(t, dir, "node-a.ndjson.gz",
+			`{"timestamp":"2024-11-02T15:03:10Z","source":"system","type":"cpu","name":"cpu_usage","value":42.5,"tags":{"cpu":"cpu0"}}`,
+			`not json`,
+			`{"timestamp":"2024-11-02T15:03:20Z","source":"system","name":""}`,
+		)
+		writeGzip(t, dir, "ignored.txt")
+
+		rb := New(time.Minute, WithClock(clock.NewFake(now)))
+		result, err := Import(rb, filepath.Join(dir, "*.ndjson.gz"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if result != (ImportResult{Files: 2, Imported: 2, Expired: 1, Invalid: 2}) {
+			t.Errorf("Expected 2 files, 2 imported, 1 expired and 2 invalid, got %+v", result)
+		}
+
+		entries := rb.GetAll()
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 buffered entries, got %d", len(entries))
+		}
+		if entries[0].Name != "cpu_usage" || entries[0].Tags["cpu"] != "cpu0" || entries[0].Value != json.Number("42.5") {
+			t.Errorf("Expected cpu0 usage 42.5 first, got %+v", entries[0])
+		}
+		if entries[1].Name != "memory_used" || entries[1].Value != json.Number("2048") {
+			t.Errorf("Expected memory_used 2048 second, got %+v", entries[1])
+		}
+	})
+
+	t.Run("fails on files that are not gzipped", func(t *testing.T) {
+		dir := t.TempDir()
+		os.WriteFile(filepath.Join(dir, "plain.ndjson.gz"), []byte(`{"name":"x"}`), 0644)
+
+		rb := New(time.Minute, WithClock(clock.NewFake(now)))
+		if _, err := Import(rb, filepath.Join(dir, "*.gz")); err == nil {
+			t.Error("Expected error importing a file that is not gzipped")
+		}
+		if len(rb.GetAll()) != 0 {
+			t.Error("Expected nothing buffered after a failed import")
+		}
+	})
+
+	t.Run("rejects malformed patterns", func(t *testing.T) {
+		if _, err := Import(New(time.Minute), "["); err == nil {
+			t.Error("Expected error for a malformed pattern")
+		}
+	})
+}
@@ -0,0 +1,243 @@
+package ringbuffer
+
+import (
+	"container/heap"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// PriorityMetadataKey is the metadata key of an entry's retention priority.
+// Entries without one have priority zero.
+const PriorityMetadataKey = "priority"
+
+// DefaultPriorityCapacity is how many overwritten high-priority entries are
+// retained when no capacity is given.
+const DefaultPriorityCapacity = 1000
+
+// PriorityRule assigns a retention priority to the entries matching its type
+// and name pattern.
+type PriorityRule struct {
+	// Type matches the entry type (empty matches every type)
+	Type types.TelemetryType `json:"type,omitempty"`
+	// Name is a glob pattern, as matched by path.Match, of entry names (empty
+	// matches every name)
+	Name string `json:"name,omitempty"`
+	// Priority is assigned to matching entries; higher is retained longer
+	Priority int `json:"priority"`
+}
+
+// matches reports whether the rule applies to the entry.
+func (pr PriorityRule) matches(entry types.TelemetryEntry) bool {
+	if pr.Type != "" && pr.Type != entry.Type {
+		return false
+	}
+	if pr.Name == "" {
+		return true
+	}
+	matched, _ := path.Match(pr.Name, entry.Name)
+	return matched
+}
+
+// ParsePriorityRules parses comma-separated pattern=priority pairs, e.g.
+// "*_errors_total=10,type:process=5". A pattern of the form type:<type>
+// matches a telemetry type, and any other pattern matches entry names.
+func ParsePriorityRules(spec string) ([]PriorityRule, error) {
+	var rules []PriorityRule
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		pattern, value, ok := strings.Cut(pair, "=")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("expected pattern=priority, got %q", pair)
+		}
+		priority, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || priority <= 0 {
+			return nil, fmt.Errorf("priority of %q must be a positive integer", pattern)
+		}
+
+		rule := PriorityRule{Priority: priority}
+		if telemetryType, ok := strings.CutPrefix(pattern, "type:"); ok {
+			rule.Type = types.TelemetryType(telemetryType)
+		} else if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("malformed name pattern %q", pattern)
+		} else {
+			rule.Name = pattern
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// EntryPriority returns the retention priority in the entry's metadata, or
+// zero when it has none.
+func EntryPriority(entry types.TelemetryEntry) int {
+	priority, ok := telemetry.Float64Value(entry.Metadata[PriorityMetadataKey])
+	if !ok {
+		return 0
+	}
+	return int(priority)
+}
+
+// PriorityStats describes the retention of overwritten high-priority entries.
+type PriorityStats struct {
+	// Capacity is how many overwritten high-priority entries can be retained
+	Capacity int `json:"capacity"`
+	// RetainedEntries is the number of overwritten entries retained
+	RetainedEntries int `json:"retained_entries"`
+	// EvictedEntries is the number of retained entries evicted for higher
+	// priority or newer ones
+	EvictedEntries uint64 `json:"evicted_entries"`
+}
+
+// priorityRetention keeps the highest-priority entries the buffer overwrote.
+type priorityRetention struct {
+	// rules assign priorities to added entries
+	rules []PriorityRule
+	// retained holds the kept entries, lowest priority and oldest first
+	retained priorityHeap
+	// capacity bounds the retained entries
+	capacity int
+	// evicted counts the retained entries evicted
+	evicted uint64
+}
+
+// WithPriorityRetention makes the buffer prefer evicting low-priority entries
+// during overflow. Added entries are assigned the highest priority of the
+// rules they match, unless they already carry one in their metadata. When the
+// buffer overwrites an entry with a positive priority that is still inside the
+// retention window, the entry is retained, up to capacity entries held in
+// addition to the buffer's capacity. Once they are all held, the lowest
+// priority, and among those the oldest, entry is evicted, and an overwritten
+// entry of lower priority than every retained one is lost. A capacity of zero
+// or less uses DefaultPriorityCapacity. Disabled by default.
+func WithPriorityRetention(rules []PriorityRule, capacity int) Option {
+	return func(rb *RingBuffer) {
+		if capacity <= 0 {
+			capacity = DefaultPriorityCapacity
+		}
+		rb.priority = &priorityRetention{rules: rules, capacity: capacity}
+	}
+}
+
+// assign returns the entry with the priority of the rules it matches in a copy
+// of its metadata. Entries that match no rule, or carry a priority, are
+// returned unchanged.
+func (pr *priorityRetention) assign(entry types.TelemetryEntry) types.TelemetryEntry {
+	if _, ok := entry.Metadata[PriorityMetadataKey]; ok {
+		return entry
+	}
+	priority := 0
+	for _, rule := range pr.rules {
+		if rule.Priority > priority && rule.matches(entry) {
+			priority = rule.Priority
+		}
+	}
+	if priority == 0 {
+		return entry
+	}
+
+	metadata := make(map[string]interface{}, len(entry.Metadata)+1)
+	for key, value := range entry.Metadata {
+		metadata[key] = value
+	}
+	metadata[PriorityMetadataKey] = priority
+	entry.Metadata = metadata
+	return entry
+}
+
+// retain keeps an overwritten entry, returning the entry that is lost
+// instead: the entry itself when it has no priority or a lower one than every
+// retained entry, or the retained entry it evicted.
+func (pr *priorityRetention) retain(lost reservedEntry) (reservedEntry, bool) {
+	priority := EntryPriority(lost.entry)
+	if priority <= 0 {
+		return lost, true
+	}
+
+	held := prioritizedEntry{reservedEntry: lost, priority: priority}
+	if len(pr.retained) < pr.capacity {
+		heap.Push(&pr.retained, held)
+		return reservedEntry{}, false
+	}
+	if pr.retained[0].priority > priority {
+		return lost, true
+	}
+	evicted := pr.retained[0].reservedEntry
+	pr.retained[0] = held
+	heap.Fix(&pr.retained, 0)
+	pr.evicted++
+	return evicted, true
+}
+
+// expire removes the retained entries older than cutoff.
+func (pr *priorityRetention) expire(cutoff time.Time) {
+	kept := pr.retained[:0]
+	for _, held := range pr.retained {
+		if !held.entry.Timestamp.Before(cutoff) {
+			kept = append(kept, held)
+		}
+	}
+	for i := len(kept); i < len(pr.retained); i++ {
+		pr.retained[i] = prioritizedEntry{}
+	}
+	pr.retained = kept
+	heap.Init(&pr.retained)
+}
+
+// priorityLocked retains an overwritten live entry by priority when priority
+// retention is enabled. It returns the entry that is lost instead. The caller
+// must hold the write lock.
+func (rb *RingBuffer) priorityLocked(lost reservedEntry) (reservedEntry, bool) {
+	if rb.priority == nil {
+		return lost, true
+	}
+	if lost.entry.Timestamp.Before(rb.clock.Now().Add(-rb.windowSize)) {
+		return reservedEntry{}, false
+	}
+	return rb.priority.retain(lost)
+}
+
+// prioritizedEntry is a retained entry with its priority.
+type prioritizedEntry struct {
+	reservedEntry
+	priority int
+}
+
+// priorityHeap orders retained entries by priority, then age, so the first
+// entry is the next to evict.
+type priorityHeap []prioritizedEntry
+
+// Len returns the number of retained entries.
+func (h priorityHeap) Len() int { return len(h) }
+
+// Less orders lower priorities, then older entries, first.
+func (h priorityHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority < h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+// Swap swaps two entries.
+func (h priorityHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+// Push appends an entry.
+func (h *priorityHeap) Push(x interface{}) { *h = append(*h, x.(prioritizedEntry)) }
+
+// Pop removes the last entry.
+func (h *priorityHeap) Pop() interface{} {
+	old := *h
+	last := old[len(old)-1]
+	old[len(old)-1] = prioritizedEntry{}
+	*h = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,157 @@
+package ringbuffer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestPriorityRetention validates that high-priority entries survive an
+// overflow that evicts low-priority ones.
+func TestPriorityRetention(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	rules := []PriorityRule{
+		{Name: "*_errors_total", Priority: 10},
+		{Type: types.TypeProcess, Priority: 5},
+	}
+
+	// fill adds n entries a microsecond apart inside the window, every
+	// errorEvery'th of them an error count and the rest CPU samples.
+	fill := func(rb *RingBuffer, n, errorEvery int) {
+		for i := 0; i < n; i++ {
+			entry := types.TelemetryEntry{
+				Timestamp: now.Add(-30*time.Second + time.Duration(i)*time.Microsecond),
+				Source:    types.SourceSystem,
+				Type:      types.TypeCPU,
+				Name:      "cpu_usage_percent",
+				Value:     i,
+			}
+			if i%errorEvery == 0 {
+				entry.Source, entry.Type, entry.Name = types.SourceSidecar, types.TypeApplication, "http_errors_total"
+			}
+			rb.Add(entry)
+		}
+	}
+	errors := func(entries []types.TelemetryEntry) int {
+		count := 0
+		for _, entry := range entries {
+			if entry.Name == "http_errors_total" {
+				count++
+			}
+		}
+		return count
+	}
+
+	t.Run("keeps high-priority entries through an overflow", func(t *testing.T) {
+		rb := New(time.Minute, WithClock(clock.NewFake(now)), WithPriorityRetention(rules, 2000))
+		size := rb.GetStats().BufferSize
+		fill(rb, 3*size, 100)
+
+		entries := rb.GetWindow(now)
+		if got, want := errors(entries), 3*size/100; got != want {
+			t.Errorf("Expected all %d error entries retained, got %d", want, got)
+		}
+		if len(entries) != size+2*size/100 {
+			t.Errorf("Expected the buffer plus %d retained entries, got %d", 2*size/100, len(entries))
+		}
+		for i := 1; i < len(entries); i++ {
+			if entries[i].Timestamp.Before(entries[i-1].Timestamp) {
+				t.Fatalf("Expected chronological order, entry %d precedes %d", i, i-1)
+			}
+		}
+		if EntryPriority(entries[0]) != 10 {
+			t.Errorf("Expected the error priority in metadata, got %v", entries[0].Metadata)
+		}
+
+		stats := rb.GetStats().Priority
+		if stats == nil || stats.RetainedEntries != 2*size/100 || stats.Capacity != 2000 || stats.EvictedEntries != 0 {
+			t.Errorf("Expected %d retained entries, got %+v", 2*size/100, stats)
+		}
+	})
+
+	t.Run("loses them without priority retention", func(t *testing.T) {
+		rb := New(time.Minute, WithClock(clock.NewFake(now)))
+		size := rb.GetStats().BufferSize
+		fill(rb, 3*size, 100)
+
+		if got := errors(rb.GetWindow(now)); got != size/100 {
+			t.Errorf("Expected only the %d errors in the buffer, got %d", size/100, got)
+		}
+	})
+
+	t.Run("evicts the lowest priority first", func(t *testing.T) {
+		rb := New(time.Minute, WithClock(clock.NewFake(now)), WithPriorityRetention(rules, 2))
+		size := rb.GetStats().BufferSize
+		add := func(name string, entryType types.TelemetryType, i int) {
+			rb.Add(types.TelemetryEntry{Timestamp: now.Add(time.Duration(i-size) * time.Millisecond), Type: entryType, Name: name, Value: i})
+		}
+		add("process_rss_bytes", types.TypeProcess, 0)
+		add("disk_errors_total", types.TypeDisk, 1)
+		add("process_rss_bytes", types.TypeProcess, 2)
+		add("http_errors_total", types.TypeApplication, 3)
+		for i := 4; i < size+4; i++ {
+			add("cpu_usage_percent", types.TypeCPU, i)
+		}
+
+		retained := rb.GetAll()[:2]
+		if retained[0].Name != "disk_errors_total" || retained[1].Name != "http_errors_total" {
+			t.Errorf("Expected both errors retained over process samples, got %s and %s", retained[0].Name, retained[1].Name)
+		}
+		if stats := rb.GetStats().Priority; stats.EvictedEntries != 2 {
+			t.Errorf("Expected both process samples evicted, got %+v", stats)
+		}
+	})
+
+	t.Run("honors priorities set by producers", func(t *testing.T) {
+		rb := New(time.Minute, WithClock(clock.NewFake(now)), WithPriorityRetention(rules, 10))
+		rb.Add(types.TelemetryEntry{Timestamp: now, Name: "http_errors_total", Metadata: map[string]interface{}{PriorityMetadataKey: 1}})
+
+		if got := EntryPriority(rb.GetAll()[0]); got != 1 {
+			t.Errorf("Expected the producer's priority 1, got %d", got)
+		}
+	})
+
+	t.Run("expires retained entries on cleanup", func(t *testing.T) {
+		fakeClock := clock.NewFake(now)
+		rb := New(time.Minute, WithClock(fakeClock), WithPriorityRetention(rules, 100))
+		size := rb.GetStats().BufferSize
+		fill(rb, 2*size, 100)
+
+		fakeClock.Advance(45 * time.Second)
+		rb.Cleanup()
+
+		if stats := rb.GetStats().Priority; stats.RetainedEntries != 0 {
+			t.Errorf("Expected retained entries to expire, got %+v", stats)
+		}
+	})
+}
+
+// TestParsePriorityRules validates parsing priority rules from configuration.
+func TestParsePriorityRules(t *testing.T) {
+	t.Run("parses name and type patterns", func(t *testing.T) {
+		rules, err := ParsePriorityRules("*_errors_total=10, type:process=5")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(rules) != 2 || rules[0] != (PriorityRule{Name: "*_errors_total", Priority: 10}) || rules[1] != (PriorityRule{Type: types.TypeProcess, Priority: 5}) {
+			t.Errorf("Expected a name and a type rule, got %+v", rules)
+		}
+	})
+
+	t.Run("rejects malformed rules", func(t *testing.T) {
+		for spec, message := range map[string]string{
+			"errors":      "expected pattern=priority",
+			"errors=high": "must be a positive integer",
+			"errors=0":    "must be a positive integer",
+			"[errors=5":   "malformed name pattern",
+			"=5":          "expected pattern=priority",
+		} {
+			if _, err := ParsePriorityRules(spec); err == nil || !strings.Contains(err.Error(), message) {
+				t.Errorf("Expected %q for %q, got %v", message, spec, err)
+			}
+		}
+	})
+}
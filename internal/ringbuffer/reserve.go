@@ -0,0 +1,146 @@
+package ringbuffer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// sourceReserve holds the most recent entries of one source that the shared
+// buffer overwrote before they expired, up to the source's reserved capacity.
+type sourceReserve struct {
+	// entries is the circular array of reserved entries
+	entries []reservedEntry
+	// head is the next write position
+	head int
+	// count is the number of entries held
+	count int
+}
+
+// reservedEntry is an entry kept by a reservation with the write sequence it
+// had in the shared buffer, which orders reserved entries across sources.
+type reservedEntry struct {
+	seq   uint64
+	entry types.TelemetryEntry
+}
+
+// SourceStats describes the buffer occupancy of one telemetry source.
+type SourceStats struct {
+	// Entries is the number of the source's entries in the shared buffer
+	Entries int `json:"entries"`
+	// Reserved is the number of overwritten entries the source is guaranteed to keep
+	Reserved int `json:"reserved"`
+	// ReservedEntries is the number of overwritten entries its reservation holds
+	ReservedEntries int `json:"reserved_entries"`
+}
+
+// WithSourceReservations guarantees each listed source a minimum number of
+// retained entries, so a flood from one source cannot evict all telemetry of
+// another. When the shared buffer overwrites an entry that is still inside the
+// retention window, the most recent such entries of its source are kept in a
+// reservation of the given size, held in addition to the buffer's capacity.
+// Queries return reserved entries before those in the shared buffer. Sources
+// with zero or less are not reserved.
+func WithSourceReservations(reservations map[types.TelemetrySource]int) Option {
+	return func(rb *RingBuffer) {
+		for source, size := range reservations {
+			if size <= 0 {
+				continue
+			}
+			if rb.reserves == nil {
+				rb.reserves = make(map[types.TelemetrySource]*sourceReserve)
+			}
+			rb.reserves[source] = &sourceReserve{entries: make([]reservedEntry, size)}
+		}
+	}
+}
+
+// push stores an entry, returning the oldest reserved entry it displaced, if any.
+func (sr *sourceReserve) push(entry reservedEntry) (reservedEntry, bool) {
+	displaced, full := sr.entries[sr.head], sr.count == len(sr.entries)
+	sr.entries[sr.head] = entry
+	sr.head = (sr.head + 1) % len(sr.entries)
+	if !full {
+		sr.count++
+	}
+	return displaced, full
+}
+
+// expire removes the reserved entries older than cutoff.
+func (sr *sourceReserve) expire(cutoff time.Time) {
+	start := (sr.head - sr.count + len(sr.entries)) % len(sr.entries)
+	for sr.count > 0 && sr.entries[start].entry.Timestamp.Before(cutoff) {
+		sr.entries[start] = reservedEntry{}
+		start = (start + 1) % len(sr.entries)
+		sr.count--
+	}
+}
+
+// reserveLocked keeps an overwritten entry in its source's reservation when it
+// is still inside the retention window. It returns the entry that is lost
+// instead: the overwritten entry when its source has no reservation, or the
+// reserved entry it displaced. The caller must hold the write lock.
+func (rb *RingBuffer) reserveLocked(lost reservedEntry) (reservedEntry, bool) {
+	reserve := rb.reserves[lost.entry.Source]
+	if reserve == nil {
+		return lost, true
+	}
+	if lost.entry.Timestamp.Before(rb.clock.Now().Add(-rb.windowSize)) {
+		return reservedEntry{}, false
+	}
+	return reserve.push(lost)
+}
+
+// reservedLocked returns the reserved and priority-retained entries accepted
+// by include, ordered by when they were written. The caller must hold the lock.
+func (rb *RingBuffer) reservedLocked(include func(entry types.TelemetryEntry) bool) []types.TelemetryEntry {
+	var reserved []reservedEntry
+	for _, reserve := range rb.reserves {
+		start := (reserve.head - reserve.count + len(reserve.entries)) % len(reserve.entries)
+		for i := 0; i < reserve.count; i++ {
+			if held := reserve.entries[(start+i)%len(reserve.entries)]; include(held.entry) {
+				reserved = append(reserved, held)
+			}
+		}
+	}
+	if rb.priority != nil {
+		for _, held := range rb.priority.retained {
+			if include(held.entry) {
+				reserved = append(reserved, held.reservedEntry)
+			}
+		}
+	}
+	if len(reserved) == 0 {
+		return nil
+	}
+
+	sort.Slice(reserved, func(i, j int) bool {
+		return reserved[i].seq < reserved[j].seq
+	})
+	entries := make([]types.TelemetryEntry, len(reserved))
+	for i, held := range reserved {
+		entries[i] = held.entry
+	}
+	return entries
+}
+
+// sourceStatsLocked counts the entries of each source in the shared buffer
+// and its reservation. The caller must hold the lock.
+func (rb *RingBuffer) sourceStatsLocked() map[types.TelemetrySource]SourceStats {
+	sources := make(map[types.TelemetrySource]SourceStats)
+	start := (rb.head - rb.count + rb.size) % rb.size
+	for i := 0; i < rb.count; i++ {
+		source := rb.entries[(start+i)%rb.size].Source
+		stats := sources[source]
+		stats.Entries++
+		sources[source] = stats
+	}
+	for source, reserve := range rb.reserves {
+		stats := sources[source]
+		stats.Reserved = len(reserve.entries)
+		stats.ReservedEntries = reserve.count
+		sources[source] = stats
+	}
+	return sources
+}
@@ -0,0 +1,116 @@
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestSourceReservations validates that a flood from one source cannot evict
+// the reserved entries of another.
+func TestSourceReservations(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	entry := func(source types.TelemetrySource, name string, i int, at time.Time) types.TelemetryEntry {
+		return types.TelemetryEntry{Timestamp: at, Source: source, Type: types.TypeCustom, Name: name, Value: i}
+	}
+	// floodAfterSidecar adds n sidecar entries followed by a flood of system entries
+	// filling the whole buffer, all inside the window.
+	floodAfterSidecar := func(rb *RingBuffer, n int, batch bool) {
+		size := rb.GetStats().BufferSize
+		for i := 0; i < n; i++ {
+			rb.Add(entry(types.SourceSidecar, "request_latency_ms", i, now.Add(-30*time.Second+time.Duration(i)*time.Millisecond)))
+		}
+		flood := make([]types.TelemetryEntry, size)
+		for i := range flood {
+			flood[i] = entry(types.SourceSystem, "cpu_usage_percent", i, now.Add(-10*time.Second+time.Duration(i)*time.Microsecond))
+		}
+		if batch {
+			rb.AddBatch(flood)
+			return
+		}
+		for _, e := range flood {
+			rb.Add(e)
+		}
+	}
+	reservations := map[types.TelemetrySource]int{types.SourceSidecar: 50}
+
+	t.Run("keeps reserved entries through a flood", func(t *testing.T) {
+		for _, batch := range []bool{false, true} {
+			rb := New(time.Minute, WithClock(clock.NewFake(now)), WithSourceReservations(reservations))
+			size := rb.GetStats().BufferSize
+			floodAfterSidecar(rb, 80, batch)
+
+			sidecar := rb.FilterBySource(types.SourceSidecar, now)
+			if len(sidecar) != 50 {
+				t.Fatalf("Expected 50 reserved sidecar entries (batch %v), got %d", batch, len(sidecar))
+			}
+			if sidecar[0].Value != 30 || sidecar[49].Value != 79 {
+				t.Errorf("Expected the 50 most recent sidecar entries, got %v to %v", sidecar[0].Value, sidecar[49].Value)
+			}
+
+			all := rb.GetAll()
+			if len(all) != size+50 {
+				t.Errorf("Expected %d entries including reserved ones, got %d", size+50, len(all))
+			}
+			window := rb.GetWindow(now)
+			for i := 1; i < len(window); i++ {
+				if window[i].Timestamp.Before(window[i-1].Timestamp) {
+					t.Fatalf("Expected chronological order, entry %d precedes %d", i, i-1)
+				}
+			}
+		}
+	})
+
+	t.Run("loses unreserved sources", func(t *testing.T) {
+		rb := New(time.Minute, WithClock(clock.NewFake(now)))
+		floodAfterSidecar(rb, 80, false)
+
+		if sidecar := rb.FilterBySource(types.SourceSidecar, now); len(sidecar) != 0 {
+			t.Errorf("Expected the flood to evict every sidecar entry, got %d", len(sidecar))
+		}
+	})
+
+	t.Run("reports per-source occupancy", func(t *testing.T) {
+		rb := New(time.Minute, WithClock(clock.NewFake(now)), WithSourceReservations(reservations))
+		size := rb.GetStats().BufferSize
+		floodAfterSidecar(rb, 80, false)
+
+		stats := rb.GetStats()
+		if sidecar := stats.Sources[types.SourceSidecar]; sidecar != (SourceStats{Reserved: 50, ReservedEntries: 50}) {
+			t.Errorf("Expected 50 of 50 reserved sidecar entries, got %+v", sidecar)
+		}
+		if system := stats.Sources[types.SourceSystem]; system != (SourceStats{Entries: size}) {
+			t.Errorf("Expected %d system entries, got %+v", size, system)
+		}
+	})
+
+	t.Run("does not reserve expired entries", func(t *testing.T) {
+		fakeClock := clock.NewFake(now)
+		rb := New(time.Minute, WithClock(fakeClock), WithSourceReservations(reservations))
+		size := rb.GetStats().BufferSize
+		rb.Add(entry(types.SourceSidecar, "request_latency_ms", 0, now.Add(-2*time.Minute)))
+		for i := 0; i < size; i++ {
+			rb.Add(entry(types.SourceSystem, "cpu_usage_percent", i, now))
+		}
+
+		if stats := rb.GetStats().Sources[types.SourceSidecar]; stats.ReservedEntries != 0 {
+			t.Errorf("Expected no reserved entries, got %+v", stats)
+		}
+	})
+
+	t.Run("expires reserved entries on cleanup", func(t *testing.T) {
+		fakeClock := clock.NewFake(now)
+		rb := New(time.Minute, WithClock(fakeClock), WithSourceReservations(reservations))
+		floodAfterSidecar(rb, 80, false)
+
+		fakeClock.Advance(45 * time.Second)
+		rb.Cleanup()
+
+		if stats := rb.GetStats().Sources[types.SourceSidecar]; stats.ReservedEntries != 0 {
+			t.Errorf("Expected reserved entries to expire, got %+v", stats)
+		}
+	})
+}
@@ -5,9 +5,13 @@
 package ringbuffer
 
 import (
+	"errors"
+	"fmt"
 	"sync"
 	"time"
+	"unique"
 
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
 	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
 )
 
@@ -27,12 +31,125 @@ type RingBuffer struct {
 	count int
 	// windowSize is the time duration for which entries should be retained
 	windowSize time.Duration
+	// clock supplies the current time for expiration
+	clock clock.Clock
+	// overflow controls what happens when an entry is added to a full buffer
+	overflow OverflowPolicy
+	// notFull is signalled when space frees up for producers blocked by OverflowBlock
+	notFull *sync.Cond
+	// blockTimeout bounds how long OverflowBlock waits for space (zero waits indefinitely)
+	blockTimeout time.Duration
+	// dropped counts entries rejected by OverflowDropNewest or a timed out OverflowBlock
+	dropped uint64
+	// defaultTags are merged into the tags of every added entry
+	defaultTags map[string]string
+	// truncationWarned records that the capacity shortfall has been logged
+	truncationWarned bool
+	// intern makes identical names and tags of stored entries share their storage
+	intern bool
+	// dedup collapses unchanged values of slow metrics (nil disables it)
+	dedup *deduplicator
+	// spill receives live entries the buffer overwrites (nil discards them)
+	spill *DiskSpill
+	// reserves keep overwritten live entries of sources with reserved capacity
+	reserves map[types.TelemetrySource]*sourceReserve
+	// priority assigns priorities and keeps overwritten high-priority entries
+	// (nil disables priority retention)
+	priority *priorityRetention
+	// written counts the entries ever stored; it is the cursor of the next entry
+	written uint64
+	// subscribers are notified whenever entries are stored
+	subscribers map[chan struct{}]struct{}
+}
+
+// OverflowPolicy controls what Add does when the buffer is full of entries that
+// are still within the retention window.
+type OverflowPolicy string
+
+const (
+	// OverflowOverwriteOldest replaces the oldest entry, favoring recent telemetry (default)
+	OverflowOverwriteOldest OverflowPolicy = "overwrite-oldest"
+	// OverflowDropNewest rejects new entries, preserving the data already buffered
+	OverflowDropNewest OverflowPolicy = "drop-newest"
+	// OverflowBlock makes Add wait until entries expire, for bounded producers
+	OverflowBlock OverflowPolicy = "block"
+)
+
+// DefaultBlockTimeout bounds how long OverflowBlock waits for space before the
+// entry is dropped.
+const DefaultBlockTimeout = 5 * time.Second
+
+// ErrBufferFull is returned when an entry is dropped because the buffer is
+// full, either under OverflowDropNewest or after OverflowBlock timed out.
+var ErrBufferFull = errors.New("ring buffer is full")
+
+// ParseOverflowPolicy converts a policy name to an OverflowPolicy.
+// An empty name selects OverflowOverwriteOldest.
+func ParseOverflowPolicy(name string) (OverflowPolicy, error) {
+	switch policy := OverflowPolicy(name); policy {
+	case "":
+		return OverflowOverwriteOldest, nil
+	case OverflowOverwriteOldest, OverflowDropNewest, OverflowBlock:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown overflow policy %q (expected overwrite-oldest, drop-newest or block)", name)
+	}
+}
+
+// minBlockWait bounds how often blocked producers re-check for expired entries.
+const minBlockWait = 10 * time.Millisecond
+
+// Option configures optional ring buffer behavior at construction time.
+type Option func(*RingBuffer)
+
+// WithClock sets the clock used to determine the current time during cleanup.
+// Defaults to the system clock.
+func WithClock(c clock.Clock) Option {
+	return func(rb *RingBuffer) {
+		rb.clock = c
+	}
+}
+
+// WithOverflowPolicy sets the behavior of Add when the buffer is full.
+// Defaults to OverflowOverwriteOldest.
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(rb *RingBuffer) {
+		rb.overflow = policy
+	}
+}
+
+// WithBlockTimeout sets how long Add waits for space under OverflowBlock before
+// dropping the entry with ErrBufferFull. Zero waits indefinitely. Defaults to
+// DefaultBlockTimeout.
+func WithBlockTimeout(timeout time.Duration) Option {
+	return func(rb *RingBuffer) {
+		rb.blockTimeout = timeout
+	}
+}
+
+// WithDefaultTags sets tags, such as cluster, region or environment, merged into
+// every entry added to the buffer. Tags already set on an entry take precedence.
+func WithDefaultTags(tags map[string]string) Option {
+	return func(rb *RingBuffer) {
+		rb.defaultTags = tags
+	}
+}
+
+// WithInterning makes the buffer intern the names, tag keys and tag values of
+// added entries, so identical strings repeated across entries share one copy.
+// It cuts the memory of large buffers whose producers allocate the same names
+// and tags for every sample, e.g. decoded sidecar JSON, at the cost of a
+// lookup per string and a copied tag map per Add. Disabled by default.
+func WithInterning(enabled bool) Option {
+	return func(rb *RingBuffer) {
+		rb.intern = enabled
+	}
 }
 
 // New creates a new ring buffer with the specified window size.
 // The buffer size is automatically calculated based on the window size and
 // expected telemetry throughput (~1000 entries per second).
-func New(windowSize time.Duration) *RingBuffer {
+func New(windowSize time.Duration, opts ...Option) *RingBuffer {
 	// Estimate buffer size based on window size and expected entry rate
 	// Assuming ~1000 entries per second across all sources
 	estimatedSize := int(windowSize.Seconds() * 1000)
@@ -40,19 +157,124 @@ func New(windowSize time.Duration) *RingBuffer {
 		estimatedSize = 1000 // Minimum buffer size
 	}
 
-	return &RingBuffer{
+	rb := &RingBuffer{
 		entries:    make([]types.TelemetryEntry, estimatedSize),
 		size:       estimatedSize,
 		windowSize: windowSize,
+		clock:      clock.Real{},
+		overflow:   OverflowOverwriteOldest,
+
+		blockTimeout: DefaultBlockTimeout,
+	}
+	rb.notFull = sync.NewCond(&rb.mutex)
+
+	for _, opt := range opts {
+		opt(rb)
 	}
+
+	return rb
 }
 
 // Add inserts a new telemetry entry into the ring buffer.
-// This operation is thread-safe and will overwrite the oldest entry if the buffer is full.
-func (rb *RingBuffer) Add(entry types.TelemetryEntry) {
+// This operation is thread-safe. When the buffer is full, the overflow policy
+// decides whether the oldest entry is overwritten (the default), the new entry
+// is dropped, or the call blocks until entries expire. A dropped entry, including
+// one whose block timed out, returns ErrBufferFull.
+func (rb *RingBuffer) Add(entry types.TelemetryEntry) error {
+	entry = rb.prepare(entry)
+
 	rb.mutex.Lock()
 	defer rb.mutex.Unlock()
 
+	if rb.dedup != nil && rb.dedup.collapse(entry) {
+		return nil
+	}
+	return rb.addLocked(entry)
+}
+
+// prepare returns the entry as it is stored: with the default tags merged in,
+// its priority assigned and, when interning is enabled, its strings interned.
+func (rb *RingBuffer) prepare(entry types.TelemetryEntry) types.TelemetryEntry {
+	entry = rb.withDefaultTags(entry)
+	if rb.priority != nil {
+		entry = rb.priority.assign(entry)
+	}
+	if rb.intern {
+		entry = internEntry(entry)
+	}
+	return entry
+}
+
+// internEntry returns the entry with its name, tag keys and tag values replaced
+// by their canonical copies. The tags are copied into a new map, leaving the
+// caller's map untouched.
+func internEntry(entry types.TelemetryEntry) types.TelemetryEntry {
+	entry.Name = unique.Make(entry.Name).Value()
+	if len(entry.Tags) == 0 {
+		return entry
+	}
+
+	tags := make(map[string]string, len(entry.Tags))
+	for key, value := range entry.Tags {
+		tags[unique.Make(key).Value()] = unique.Make(value).Value()
+	}
+	entry.Tags = tags
+	return entry
+}
+
+// withDefaultTags returns the entry with the default tags merged into a copy of
+// its tags, leaving the caller's map untouched.
+func (rb *RingBuffer) withDefaultTags(entry types.TelemetryEntry) types.TelemetryEntry {
+	if len(rb.defaultTags) == 0 {
+		return entry
+	}
+
+	tags := make(map[string]string, len(rb.defaultTags)+len(entry.Tags))
+	for key, value := range rb.defaultTags {
+		tags[key] = value
+	}
+	for key, value := range entry.Tags {
+		tags[key] = value
+	}
+	entry.Tags = tags
+	return entry
+}
+
+// addLocked applies the overflow policy and stores the entry. The caller must hold the write lock.
+func (rb *RingBuffer) addLocked(entry types.TelemetryEntry) error {
+	switch rb.overflow {
+	case OverflowDropNewest:
+		if rb.fullLocked() {
+			rb.dropped++
+			return ErrBufferFull
+		}
+	case OverflowBlock:
+		var deadline time.Time
+		if rb.blockTimeout > 0 {
+			deadline = time.Now().Add(rb.blockTimeout)
+		}
+		for rb.fullLocked() {
+			if !deadline.IsZero() && !time.Now().Before(deadline) {
+				rb.dropped++
+				return ErrBufferFull
+			}
+			rb.waitForSpaceLocked(deadline)
+		}
+	}
+
+	if rb.count == rb.size {
+		rb.checkTruncationLocked(rb.entries[rb.head])
+		// An overwritten entry moves to its source's reservation, then to
+		// priority retention; the entry lost from both is spilled
+		lost, ok := rb.reserveLocked(reservedEntry{seq: rb.written - uint64(rb.size), entry: rb.entries[rb.head]})
+		if ok {
+			lost, ok = rb.priorityLocked(lost)
+		}
+		if ok {
+			rb.spillLocked(lost.entry)
+		}
+	}
+
 	// Store the entry at the current head position
 	rb.entries[rb.head] = entry
 	// Advance head position, wrapping around if necessary (circular buffer)
@@ -62,22 +284,194 @@ func (rb *RingBuffer) Add(entry types.TelemetryEntry) {
 	if rb.count < rb.size {
 		rb.count++
 	}
+	rb.written++
+	rb.notifyLocked()
+	return nil
+}
+
+// checkTruncationLocked logs a warning, once, when an entry still inside the
+// retention window is lost because the buffer is full. This means sustained
+// throughput exceeds what the capacity can hold for the configured window, so
+// incidents see less history than configured. The caller must hold the write lock.
+func (rb *RingBuffer) checkTruncationLocked(lost types.TelemetryEntry) {
+	if rb.truncationWarned || lost.Timestamp.Before(rb.clock.Now().Add(-rb.windowSize)) {
+		return
+	}
+	rb.truncationWarned = true
+	fmt.Printf("Warning: ring buffer capacity of %d entries cannot hold the configured %v window; "+
+		"entries are being overwritten before they expire (see EffectiveWindow in buffer stats)\n", rb.size, rb.windowSize)
+}
+
+// spillLocked writes an overwritten entry to the disk tier when one is
+// configured and the entry is still inside the retention window. The caller
+// must hold the write lock.
+func (rb *RingBuffer) spillLocked(lost types.TelemetryEntry) {
+	if rb.spill == nil || lost.Timestamp.Before(rb.clock.Now().Add(-rb.windowSize)) {
+		return
+	}
+	if err := rb.spill.Write(lost); err != nil {
+		fmt.Printf("Warning: failed to spill overwritten telemetry to disk: %v\n", err)
+	}
+}
+
+// fullLocked reports whether the buffer is at capacity with its oldest entry
+// still inside the retention window. An expired oldest entry may always be
+// overwritten. The caller must hold the lock.
+func (rb *RingBuffer) fullLocked() bool {
+	if rb.count < rb.size {
+		return false
+	}
+	oldest := rb.entries[rb.head]
+	return !oldest.Timestamp.Before(rb.clock.Now().Add(-rb.windowSize))
+}
+
+// waitForSpaceLocked blocks until Cleanup frees space, the oldest entry is due
+// to expire or the deadline (if set) passes. The caller must hold the write
+// lock, which is released while waiting.
+func (rb *RingBuffer) waitForSpaceLocked(deadline time.Time) {
+	wait := rb.entries[rb.head].Timestamp.Add(rb.windowSize).Sub(rb.clock.Now())
+	if wait < minBlockWait {
+		wait = minBlockWait
+	}
+	if !deadline.IsZero() {
+		if remaining := time.Until(deadline); remaining < wait {
+			wait = remaining
+		}
+	}
+	timer := time.AfterFunc(wait, func() {
+		rb.mutex.Lock()
+		rb.notFull.Broadcast()
+		rb.mutex.Unlock()
+	})
+	rb.notFull.Wait()
+	timer.Stop()
+}
+
+// AddBatch inserts multiple telemetry entries while acquiring the lock only once,
+// which is considerably cheaper than calling Add per entry under heavy ingestion.
+// Entries are stored in order; if the batch is larger than the buffer, only the
+// last size entries survive, exactly as if they had been added one at a time.
+// When the overflow policy drops any entry, the rest are still stored and
+// ErrBufferFull is returned.
+func (rb *RingBuffer) AddBatch(entries []types.TelemetryEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	if len(rb.defaultTags) > 0 || rb.intern || rb.priority != nil {
+		prepared := make([]types.TelemetryEntry, len(entries))
+		for i, entry := range entries {
+			prepared[i] = rb.prepare(entry)
+		}
+		entries = prepared
+	}
+
+	rb.mutex.Lock()
+	defer rb.mutex.Unlock()
+
+	if rb.dedup != nil {
+		kept := make([]types.TelemetryEntry, 0, len(entries))
+		for _, entry := range entries {
+			if !rb.dedup.collapse(entry) {
+				kept = append(kept, entry)
+			}
+		}
+		if entries = kept; len(entries) == 0 {
+			return nil
+		}
+	}
+
+	// Policies that may reject or wait, spilling, reservations and priority
+	// retention apply per entry
+	if rb.overflow != OverflowOverwriteOldest || rb.spill != nil || rb.reserves != nil || rb.priority != nil {
+		var err error
+		for _, entry := range entries {
+			if addErr := rb.addLocked(entry); addErr != nil {
+				err = addErr
+			}
+		}
+		return err
+	}
+
+	// Entries skipped below are counted as stored and immediately overwritten
+	rb.written += uint64(len(entries))
+	rb.notifyLocked()
+
+	// Entries that would be overwritten within the same batch are skipped entirely
+	if len(entries) > rb.size {
+		rb.checkTruncationLocked(entries[len(entries)-rb.size-1])
+		entries = entries[len(entries)-rb.size:]
+	}
+
+	// The newest stored entry the batch overwrites decides whether the window is truncated
+	if overwritten := rb.count + len(entries) - rb.size; overwritten > 0 {
+		if overwritten > rb.count {
+			overwritten = rb.count
+		}
+		start := (rb.head - rb.count + rb.size) % rb.size
+		rb.checkTruncationLocked(rb.entries[(start+overwritten-1)%rb.size])
+	}
+
+	// Copy in at most two segments: up to the end of the array, then wrapped to the start
+	copied := copy(rb.entries[rb.head:], entries)
+	copy(rb.entries, entries[copied:])
+	rb.head = (rb.head + len(entries)) % rb.size
+
+	rb.count += len(entries)
+	if rb.count > rb.size {
+		rb.count = rb.size
+	}
+	return nil
 }
 
 // GetWindow returns all entries within the specified time window from the given timestamp.
 // The time window extends backwards from the 'from' timestamp by the buffer's window size.
 // This is the primary method used during incident analysis to gather relevant telemetry.
 func (rb *RingBuffer) GetWindow(from time.Time) []types.TelemetryEntry {
+	// Calculate the cutoff time - only entries after this time are included
+	cutoff := from.Add(-rb.windowSize)
+
+	return rb.collect(func(entry types.TelemetryEntry) bool {
+		return entry.Timestamp.After(cutoff)
+	})
+}
+
+// GetWindowBetween returns all entries with timestamps after start and up to and
+// including end, in chronological order. Unlike GetWindow, the range is independent
+// of the buffer's window size.
+func (rb *RingBuffer) GetWindowBetween(start, end time.Time) []types.TelemetryEntry {
+	return rb.collect(func(entry types.TelemetryEntry) bool {
+		return entry.Timestamp.After(start) && !entry.Timestamp.After(end)
+	})
+}
+
+// Snapshot returns the telemetry leading up to an incident at the given time,
+// limited to the given window. The window is capped at the buffer's window size,
+// so a short snapshot window keeps incident reports focused on the most recent
+// data regardless of total retention.
+func (rb *RingBuffer) Snapshot(at time.Time, window time.Duration) []types.TelemetryEntry {
+	if window <= 0 || window > rb.windowSize {
+		window = rb.windowSize
+	}
+	return rb.GetWindowBetween(at.Add(-window), at)
+}
+
+// collect returns the entries accepted by include in chronological order.
+func (rb *RingBuffer) collect(include func(entry types.TelemetryEntry) bool) []types.TelemetryEntry {
 	rb.mutex.RLock()
 	defer rb.mutex.RUnlock()
 
-	if rb.count == 0 {
-		return []types.TelemetryEntry{}
+	// Spilled entries are older than every entry still in memory
+	var result []types.TelemetryEntry
+	if rb.spill != nil {
+		result = rb.spill.Query(include)
 	}
+	// Reserved entries were overwritten, so they precede the shared buffer
+	result = append(result, rb.reservedLocked(include)...)
 
-	var result []types.TelemetryEntry
-	// Calculate the cutoff time - only entries after this time are included
-	cutoff := from.Add(-rb.windowSize)
+	if rb.count == 0 && result == nil {
+		return []types.TelemetryEntry{}
+	}
 
 	// Calculate the starting position of the oldest entry in the circular buffer
 	// If head-count is negative, we need to wrap around to the end of the buffer
@@ -92,8 +486,7 @@ func (rb *RingBuffer) GetWindow(from time.Time) []types.TelemetryEntry {
 		idx := (start + i) % rb.size
 		entry := rb.entries[idx]
 
-		// Only include entries within the specified time window
-		if entry.Timestamp.After(cutoff) {
+		if include(entry) {
 			result = append(result, entry)
 		}
 	}
@@ -101,17 +494,20 @@ func (rb *RingBuffer) GetWindow(from time.Time) []types.TelemetryEntry {
 	return result
 }
 
-// GetAll returns all entries currently in the buffer in chronological order.
+// GetAll returns all entries currently in the buffer, including reserved
+// entries, in chronological order.
 // This method is primarily used for debugging and administrative purposes.
 func (rb *RingBuffer) GetAll() []types.TelemetryEntry {
 	rb.mutex.RLock()
 	defer rb.mutex.RUnlock()
 
-	if rb.count == 0 {
+	reserved := rb.reservedLocked(func(types.TelemetryEntry) bool { return true })
+	if rb.count == 0 && len(reserved) == 0 {
 		return []types.TelemetryEntry{}
 	}
 
-	result := make([]types.TelemetryEntry, rb.count)
+	result := make([]types.TelemetryEntry, len(reserved)+rb.count)
+	copy(result, reserved)
 
 	start := rb.head - rb.count
 	if start < 0 {
@@ -120,7 +516,7 @@ func (rb *RingBuffer) GetAll() []types.TelemetryEntry {
 
 	for i := 0; i < rb.count; i++ {
 		idx := (start + i) % rb.size
-		result[i] = rb.entries[idx]
+		result[len(reserved)+i] = rb.entries[idx]
 	}
 
 	return result
@@ -133,9 +529,26 @@ func (rb *RingBuffer) GetStats() BufferStats {
 	defer rb.mutex.RUnlock()
 
 	stats := BufferStats{
-		TotalEntries: rb.count,
-		BufferSize:   rb.size,
-		WindowSize:   rb.windowSize,
+		TotalEntries:   rb.count,
+		BufferSize:     rb.size,
+		WindowSize:     rb.windowSize,
+		OverflowPolicy: rb.overflow,
+		DroppedEntries: rb.dropped,
+	}
+	if rb.dedup != nil {
+		stats.CollapsedEntries = rb.dedup.collapsed
+	}
+	if rb.spill != nil {
+		spill := rb.spill.Stats()
+		stats.Spill = &spill
+	}
+	stats.Sources = rb.sourceStatsLocked()
+	if rb.priority != nil {
+		stats.Priority = &PriorityStats{
+			Capacity:        rb.priority.capacity,
+			RetainedEntries: len(rb.priority.retained),
+			EvictedEntries:  rb.priority.evicted,
+		}
 	}
 
 	if rb.count > 0 {
@@ -153,17 +566,26 @@ func (rb *RingBuffer) GetStats() BufferStats {
 		stats.ActualWindow = newest.Timestamp.Sub(oldest.Timestamp)
 	}
 
+	// A full buffer only guarantees the span it currently holds
+	stats.EffectiveWindow = rb.windowSize
+	if rb.count == rb.size && stats.ActualWindow < rb.windowSize {
+		stats.EffectiveWindow = stats.ActualWindow
+	}
+
 	return stats
 }
 
-// FilterBySource returns entries from the buffer filtered by source within the time window.
-// This is useful for getting only system telemetry or only sidecar telemetry during analysis.
-func (rb *RingBuffer) FilterBySource(source types.TelemetrySource, from time.Time) []types.TelemetryEntry {
+// Predicate reports whether a telemetry entry should be included in a filtered result.
+type Predicate func(entry types.TelemetryEntry) bool
+
+// Filter returns entries within the time window that satisfy every predicate.
+// Predicates are ANDed together; with no predicates all entries in the window match.
+func (rb *RingBuffer) Filter(from time.Time, predicates ...Predicate) []types.TelemetryEntry {
 	entries := rb.GetWindow(from)
 	var filtered []types.TelemetryEntry
 
 	for _, entry := range entries {
-		if entry.Source == source {
+		if matchesAll(entry, predicates) {
 			filtered = append(filtered, entry)
 		}
 	}
@@ -171,27 +593,74 @@ func (rb *RingBuffer) FilterBySource(source types.TelemetrySource, from time.Tim
 	return filtered
 }
 
-// FilterByPod returns entries from the buffer filtered by pod name within the time window.
-// If podName is empty, returns all system telemetry. Otherwise, returns telemetry
-// specifically associated with the named pod.
-func (rb *RingBuffer) FilterByPod(podName string, from time.Time) []types.TelemetryEntry {
-	entries := rb.GetWindow(from)
-	var filtered []types.TelemetryEntry
+// matchesAll reports whether the entry satisfies every predicate.
+func matchesAll(entry types.TelemetryEntry, predicates []Predicate) bool {
+	for _, predicate := range predicates {
+		if !predicate(entry) {
+			return false
+		}
+	}
+	return true
+}
 
-	for _, entry := range entries {
+// MatchSource returns a predicate matching entries from the given source.
+func MatchSource(source types.TelemetrySource) Predicate {
+	return func(entry types.TelemetryEntry) bool {
+		return entry.Source == source
+	}
+}
+
+// MatchType returns a predicate matching entries of the given telemetry type,
+// built-in or custom.
+func MatchType(telemetryType types.TelemetryType) Predicate {
+	return func(entry types.TelemetryEntry) bool {
+		return entry.Type == telemetryType
+	}
+}
+
+// MatchPod returns a predicate matching entries associated with the named pod.
+// If podName is empty, it matches system telemetry instead.
+func MatchPod(podName string) Predicate {
+	return func(entry types.TelemetryEntry) bool {
 		if podName == "" {
 			// Include system telemetry when no specific pod is requested
-			if entry.Source == types.SourceSystem {
-				filtered = append(filtered, entry)
-			}
-		} else if entry.Tags != nil {
-			if entryPod, ok := entry.Tags["pod_name"]; ok && entryPod == podName {
-				filtered = append(filtered, entry)
+			return entry.Source == types.SourceSystem
+		}
+		entryPod, ok := entry.Tags["pod_name"]
+		return ok && entryPod == podName
+	}
+}
+
+// MatchTags returns a predicate matching entries that carry every given tag
+// key with the given value. An empty tag set matches all entries.
+func MatchTags(tags map[string]string) Predicate {
+	return func(entry types.TelemetryEntry) bool {
+		for key, value := range tags {
+			if entryValue, ok := entry.Tags[key]; !ok || entryValue != value {
+				return false
 			}
 		}
+		return true
 	}
+}
 
-	return filtered
+// FilterBySource returns entries from the buffer filtered by source within the time window.
+// This is useful for getting only system telemetry or only sidecar telemetry during analysis.
+func (rb *RingBuffer) FilterBySource(source types.TelemetrySource, from time.Time) []types.TelemetryEntry {
+	return rb.Filter(from, MatchSource(source))
+}
+
+// FilterByPod returns entries from the buffer filtered by pod name within the time window.
+// If podName is empty, returns all system telemetry. Otherwise, returns telemetry
+// specifically associated with the named pod.
+func (rb *RingBuffer) FilterByPod(podName string, from time.Time) []types.TelemetryEntry {
+	return rb.Filter(from, MatchPod(podName))
+}
+
+// FilterByTags returns entries within the time window that carry all of the given
+// tags, e.g. {"interface": "eth0"} or {"device": "nvme0n1"}.
+func (rb *RingBuffer) FilterByTags(tags map[string]string, from time.Time) []types.TelemetryEntry {
+	return rb.Filter(from, MatchTags(tags))
 }
 
 // BufferStats contains statistics about the ring buffer for monitoring and analysis.
@@ -204,10 +673,26 @@ type BufferStats struct {
 	WindowSize time.Duration `json:"window_size"`
 	// ActualWindow is the actual time span of data currently in the buffer
 	ActualWindow time.Duration `json:"actual_window"`
+	// EffectiveWindow is the history the buffer can actually guarantee: the
+	// configured window, or less once capacity forces entries out before they expire
+	EffectiveWindow time.Duration `json:"effective_window"`
 	// OldestEntry is the timestamp of the oldest entry in the buffer
 	OldestEntry time.Time `json:"oldest_entry"`
 	// NewestEntry is the timestamp of the newest entry in the buffer
 	NewestEntry time.Time `json:"newest_entry"`
+	// OverflowPolicy is the configured behavior when the buffer is full
+	OverflowPolicy OverflowPolicy `json:"overflow_policy"`
+	// DroppedEntries is the number of entries rejected by the drop-newest policy
+	DroppedEntries uint64 `json:"dropped_entries"`
+	// CollapsedEntries is the number of unchanged values not stored because of
+	// WithDedupUnchanged
+	CollapsedEntries uint64 `json:"collapsed_entries"`
+	// Spill describes the disk tier (nil when WithSpill is not used)
+	Spill *SpillStats `json:"spill,omitempty"`
+	// Sources describes the occupancy of each source, including reservations
+	Sources map[types.TelemetrySource]SourceStats `json:"sources"`
+	// Priority describes priority retention (nil when WithPriorityRetention is not used)
+	Priority *PriorityStats `json:"priority,omitempty"`
 }
 
 // Cleanup removes entries older than the window size to free memory and prevent
@@ -216,11 +701,24 @@ func (rb *RingBuffer) Cleanup() {
 	rb.mutex.Lock()
 	defer rb.mutex.Unlock()
 
+	if rb.dedup != nil {
+		rb.dedup.expire(rb.clock.Now().Add(-rb.windowSize))
+	}
+	if rb.spill != nil {
+		rb.spill.Expire(rb.clock.Now().Add(-rb.windowSize))
+	}
+	for _, reserve := range rb.reserves {
+		reserve.expire(rb.clock.Now().Add(-rb.windowSize))
+	}
+	if rb.priority != nil {
+		rb.priority.expire(rb.clock.Now().Add(-rb.windowSize))
+	}
+
 	if rb.count == 0 {
 		return
 	}
 
-	now := time.Now()
+	now := rb.clock.Now()
 	cutoff := now.Add(-rb.windowSize)
 
 	// Count how many entries to remove
@@ -247,5 +745,7 @@ func (rb *RingBuffer) Cleanup() {
 			idx := (start + i) % rb.size
 			rb.entries[idx] = types.TelemetryEntry{}
 		}
+		// Wake producers blocked on a full buffer
+		rb.notFull.Broadcast()
 	}
 }
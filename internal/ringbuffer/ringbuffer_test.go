@@ -4,10 +4,17 @@
 package ringbuffer
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
 	"sync"
 	"testing"
 	"time"
+	"unsafe"
 
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
 	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
 )
 
@@ -425,9 +432,10 @@ func TestGetStats(t *testing.T) {
 // TestCleanup validates automatic cleanup of old entries.
 func TestCleanup(t *testing.T) {
 	t.Run("removes expired entries", func(t *testing.T) {
-		rb := New(30 * time.Second) // 30 second window
+		fakeClock := clock.NewFake(time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC))
+		rb := New(30*time.Second, WithClock(fakeClock)) // 30 second window
 		
-		baseTime := time.Now().Add(-60 * time.Second) // Start 60 seconds ago
+		baseTime := fakeClock.Now().Add(-60 * time.Second) // Start 60 seconds ago
 		
 		// Add entries spanning 50 seconds (some should be expired)
 		for i := 0; i < 50; i++ {
@@ -449,15 +457,15 @@ func TestCleanup(t *testing.T) {
 		
 		finalStats := rb.GetStats()
 		
-		// Should have fewer entries after cleanup
-		if finalStats.TotalEntries >= initialCount {
-			t.Errorf("Expected cleanup to reduce entries from %d, got %d", 
+		// Entries older than 30 seconds (the first 30 of 50) should be removed
+		if initialCount != 50 || finalStats.TotalEntries != 20 {
+			t.Errorf("Expected cleanup to reduce entries from 50 to 20, got %d to %d", 
 				initialCount, finalStats.TotalEntries)
 		}
 		
 		// Remaining entries should all be within window
 		entries := rb.GetAll()
-		cutoff := time.Now().Add(-30 * time.Second)
+		cutoff := fakeClock.Now().Add(-30 * time.Second)
 		for _, entry := range entries {
 			if entry.Timestamp.Before(cutoff) {
 				t.Errorf("Found expired entry after cleanup: %v", entry.Timestamp)
@@ -465,6 +473,31 @@ func TestCleanup(t *testing.T) {
 		}
 	})
 	
+	t.Run("expires entries as the clock advances", func(t *testing.T) {
+		fakeClock := clock.NewFake(time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC))
+		rb := New(30*time.Second, WithClock(fakeClock))
+		
+		rb.Add(types.TelemetryEntry{
+			Timestamp: fakeClock.Now(),
+			Source:    types.SourceSystem,
+			Type:      types.TypeCPU,
+			Name:      "test_metric",
+			Value:     1.0,
+		})
+		
+		fakeClock.Advance(29 * time.Second)
+		rb.Cleanup()
+		if stats := rb.GetStats(); stats.TotalEntries != 1 {
+			t.Errorf("Expected entry to survive within window, got %d entries", stats.TotalEntries)
+		}
+		
+		fakeClock.Advance(2 * time.Second)
+		rb.Cleanup()
+		if stats := rb.GetStats(); stats.TotalEntries != 0 {
+			t.Errorf("Expected entry to expire after window, got %d entries", stats.TotalEntries)
+		}
+	})
+	
 	t.Run("handles empty buffer cleanup", func(t *testing.T) {
 		rb := New(60 * time.Second)
 		
@@ -481,7 +514,8 @@ func TestCleanup(t *testing.T) {
 // TestThreadSafety validates concurrent access to the ring buffer.
 func TestThreadSafety(t *testing.T) {
 	t.Run("concurrent adds and reads", func(t *testing.T) {
-		rb := New(60 * time.Second)
+		fakeClock := clock.NewFake(time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC))
+		rb := New(60*time.Second, WithClock(fakeClock))
 		
 		var wg sync.WaitGroup
 		numWorkers := 10
@@ -495,7 +529,7 @@ func TestThreadSafety(t *testing.T) {
 				
 				for j := 0; j < entriesPerWorker; j++ {
 					entry := types.TelemetryEntry{
-						Timestamp: time.Now(),
+						Timestamp: fakeClock.Now(),
 						Source:    types.SourceSystem,
 						Type:      types.TypeCPU,
 						Name:      "concurrent_test",
@@ -514,9 +548,9 @@ func TestThreadSafety(t *testing.T) {
 				
 				for j := 0; j < entriesPerWorker/10; j++ {
 					rb.GetAll()
-					rb.GetWindow(time.Now())
+					rb.GetWindow(fakeClock.Now())
 					rb.GetStats()
-					time.Sleep(time.Millisecond)
+					fakeClock.Advance(time.Millisecond)
 				}
 			}()
 		}
@@ -586,4 +620,630 @@ func TestEdgeCases(t *testing.T) {
 			}
 		}
 	})
-}
\ No newline at end of file
+}
+// sequentialEntries returns n entries one second apart with Value set to their index.
+func sequentialEntries(n int, base time.Time) []types.TelemetryEntry {
+	entries := make([]types.TelemetryEntry, n)
+	for i := range entries {
+		entries[i] = types.TelemetryEntry{
+			Timestamp: base.Add(time.Duration(i) * time.Millisecond),
+			Source:    types.SourceSystem,
+			Type:      types.TypeCPU,
+			Name:      "test_metric",
+			Value:     i,
+		}
+	}
+	return entries
+}
+
+// TestAddBatch validates batch insertion and its equivalence to looped Add.
+func TestAddBatch(t *testing.T) {
+	t.Run("adds entries in order", func(t *testing.T) {
+		rb := New(time.Second)
+		rb.AddBatch(sequentialEntries(10, time.Now()))
+
+		all := rb.GetAll()
+		if len(all) != 10 {
+			t.Fatalf("Expected 10 entries, got %d", len(all))
+		}
+		for i, entry := range all {
+			if entry.Value != i {
+				t.Errorf("Expected entry %d to have value %d, got %v", i, i, entry.Value)
+			}
+		}
+	})
+
+	t.Run("ignores empty batch", func(t *testing.T) {
+		rb := New(time.Second)
+		rb.AddBatch(nil)
+
+		if stats := rb.GetStats(); stats.TotalEntries != 0 {
+			t.Errorf("Expected empty buffer, got %d entries", stats.TotalEntries)
+		}
+	})
+
+	t.Run("wraps around the end of the buffer", func(t *testing.T) {
+		rb := New(time.Second)
+		size := rb.GetStats().BufferSize
+		entries := sequentialEntries(size+250, time.Now())
+
+		rb.AddBatch(entries[:size-100])
+		rb.AddBatch(entries[size-100:])
+
+		all := rb.GetAll()
+		if len(all) != size {
+			t.Fatalf("Expected %d entries, got %d", size, len(all))
+		}
+		if all[0].Value != 250 || all[size-1].Value != size+249 {
+			t.Errorf("Expected values 250..%d, got %v..%v", size+249, all[0].Value, all[size-1].Value)
+		}
+	})
+
+	t.Run("keeps only the last size entries of an oversized batch", func(t *testing.T) {
+		rb := New(time.Second)
+		size := rb.GetStats().BufferSize
+		rb.Add(sequentialEntries(1, time.Now())[0])
+
+		rb.AddBatch(sequentialEntries(size*2+7, time.Now()))
+
+		all := rb.GetAll()
+		if len(all) != size {
+			t.Fatalf("Expected %d entries, got %d", size, len(all))
+		}
+		if all[0].Value != size+7 || all[size-1].Value != size*2+6 {
+			t.Errorf("Expected values %d..%d, got %v..%v", size+7, size*2+6, all[0].Value, all[size-1].Value)
+		}
+	})
+
+	t.Run("matches looped Add", func(t *testing.T) {
+		batched := New(time.Second)
+		looped := New(time.Second)
+		entries := sequentialEntries(2500, time.Now())
+
+		batched.AddBatch(entries[:700])
+		batched.AddBatch(entries[700:])
+		for _, entry := range entries {
+			looped.Add(entry)
+		}
+
+		batchedAll, loopedAll := batched.GetAll(), looped.GetAll()
+		if len(batchedAll) != len(loopedAll) {
+			t.Fatalf("Expected %d entries, got %d", len(loopedAll), len(batchedAll))
+		}
+		for i := range loopedAll {
+			if batchedAll[i].Value != loopedAll[i].Value {
+				t.Fatalf("Entry %d differs: batched %v, looped %v", i, batchedAll[i].Value, loopedAll[i].Value)
+			}
+		}
+	})
+}
+
+// BenchmarkAdd measures inserting a batch of entries with one Add call per entry.
+func BenchmarkAdd(b *testing.B) {
+	rb := New(60 * time.Second)
+	entries := sequentialEntries(100, time.Now())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, entry := range entries {
+			rb.Add(entry)
+		}
+	}
+}
+
+// BenchmarkAddBatch measures inserting the same batch of entries with a single AddBatch call.
+func BenchmarkAddBatch(b *testing.B) {
+	rb := New(60 * time.Second)
+	entries := sequentialEntries(100, time.Now())
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rb.AddBatch(entries)
+	}
+}
+
+// TestFilterByTags validates tag-based filtering and combining it with other predicates.
+func TestFilterByTags(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	rb := New(60*time.Second, WithClock(clock.NewFake(now)))
+
+	rb.AddBatch([]types.TelemetryEntry{
+		{Timestamp: now, Source: types.SourceSystem, Type: types.TypeNetwork, Name: "rx_bytes", Value: 1, Tags: map[string]string{"interface": "eth0", "direction": "rx"}},
+		{Timestamp: now, Source: types.SourceSystem, Type: types.TypeNetwork, Name: "tx_bytes", Value: 2, Tags: map[string]string{"interface": "eth0", "direction": "tx"}},
+		{Timestamp: now, Source: types.SourceSystem, Type: types.TypeNetwork, Name: "rx_bytes", Value: 3, Tags: map[string]string{"interface": "eth1", "direction": "rx"}},
+		{Timestamp: now, Source: types.SourceSystem, Type: types.TypeDisk, Name: "read_bytes", Value: 4, Tags: map[string]string{"device": "nvme0n1"}},
+		{Timestamp: now, Source: types.SourceSidecar, Type: types.TypeNetwork, Name: "rx_bytes", Value: 5, Tags: map[string]string{"interface": "eth0", "pod_name": "web-1"}},
+		{Timestamp: now, Source: types.SourceSystem, Type: types.TypeCPU, Name: "cpu_usage", Value: 6},
+	})
+
+	t.Run("matches single tag", func(t *testing.T) {
+		entries := rb.FilterByTags(map[string]string{"device": "nvme0n1"}, now)
+		if len(entries) != 1 || entries[0].Value != 4 {
+			t.Errorf("Expected only the nvme0n1 entry, got %v", entries)
+		}
+	})
+
+	t.Run("ANDs multiple tags", func(t *testing.T) {
+		entries := rb.FilterByTags(map[string]string{"interface": "eth0", "direction": "rx"}, now)
+		if len(entries) != 1 || entries[0].Value != 1 {
+			t.Errorf("Expected only the eth0 rx entry, got %v", entries)
+		}
+	})
+
+	t.Run("empty tags match all entries", func(t *testing.T) {
+		if entries := rb.FilterByTags(nil, now); len(entries) != 6 {
+			t.Errorf("Expected 6 entries, got %d", len(entries))
+		}
+	})
+
+	t.Run("combines tag and source predicates", func(t *testing.T) {
+		entries := rb.Filter(now, MatchTags(map[string]string{"interface": "eth0"}), MatchSource(types.SourceSidecar))
+		if len(entries) != 1 || entries[0].Value != 5 {
+			t.Errorf("Expected only the sidecar eth0 entry, got %v", entries)
+		}
+
+		entries = rb.Filter(now, MatchTags(map[string]string{"interface": "eth0"}), MatchSource(types.SourceSystem))
+		if len(entries) != 2 {
+			t.Errorf("Expected 2 system eth0 entries, got %d", len(entries))
+		}
+	})
+
+	t.Run("matches built-in and custom types", func(t *testing.T) {
+		rb.Add(types.TelemetryEntry{Timestamp: now, Source: types.SourceSidecar, Type: "queue", Name: "jobs_queue_depth", Value: 7})
+
+		if entries := rb.Filter(now, MatchType(types.TypeNetwork)); len(entries) != 4 {
+			t.Errorf("Expected 4 network entries, got %d", len(entries))
+		}
+		entries := rb.Filter(now, MatchType("queue"))
+		if len(entries) != 1 || entries[0].Name != "jobs_queue_depth" {
+			t.Errorf("Expected only the queue entry, got %v", entries)
+		}
+	})
+
+	t.Run("respects time window", func(t *testing.T) {
+		if entries := rb.FilterByTags(map[string]string{"interface": "eth0"}, now.Add(2*time.Minute)); len(entries) != 0 {
+			t.Errorf("Expected no entries outside the window, got %d", len(entries))
+		}
+	})
+}
+
+// TestSnapshot validates incident snapshots bounded independently of the buffer window.
+func TestSnapshot(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	rb := New(10*time.Minute, WithClock(clock.NewFake(now)))
+
+	// One entry every 10 seconds over the full 10 minute window
+	for i := 0; i < 60; i++ {
+		rb.Add(types.TelemetryEntry{
+			Timestamp: now.Add(-time.Duration(59-i) * 10 * time.Second),
+			Source:    types.SourceSystem,
+			Type:      types.TypeCPU,
+			Name:      "cpu_usage",
+			Value:     i,
+		})
+	}
+
+	t.Run("respects narrower snapshot window", func(t *testing.T) {
+		entries := rb.Snapshot(now, 60*time.Second)
+		if len(entries) != 6 {
+			t.Fatalf("Expected 6 entries in a 60s snapshot, got %d", len(entries))
+		}
+		for _, entry := range entries {
+			if !entry.Timestamp.After(now.Add(-60 * time.Second)) {
+				t.Errorf("Expected entry within 60s of incident, got %v", entry.Timestamp)
+			}
+		}
+		if total := len(rb.GetWindow(now)); total != 60 {
+			t.Errorf("Expected buffer to retain all 60 entries, got %d", total)
+		}
+	})
+
+	t.Run("caps snapshot at buffer window", func(t *testing.T) {
+		if entries := rb.Snapshot(now, time.Hour); len(entries) != 60 {
+			t.Errorf("Expected 60 entries, got %d", len(entries))
+		}
+		if entries := rb.Snapshot(now, 0); len(entries) != 60 {
+			t.Errorf("Expected zero window to use the buffer window, got %d", len(entries))
+		}
+	})
+
+	t.Run("excludes entries after the incident", func(t *testing.T) {
+		entries := rb.GetWindowBetween(now.Add(-60*time.Second), now.Add(-30*time.Second))
+		if len(entries) != 3 {
+			t.Fatalf("Expected 3 entries, got %d", len(entries))
+		}
+		if last := entries[len(entries)-1].Timestamp; !last.Equal(now.Add(-30 * time.Second)) {
+			t.Errorf("Expected end to be inclusive, got last entry at %v", last)
+		}
+	})
+}
+
+// TestOverflowPolicy validates each overflow policy's behavior at capacity.
+func TestOverflowPolicy(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	// fill adds size entries with sequential values, all within the window
+	fill := func(rb *RingBuffer) {
+		rb.AddBatch(sequentialEntries(rb.size, now))
+	}
+
+	t.Run("overwrite-oldest replaces oldest entry", func(t *testing.T) {
+		rb := New(time.Second, WithClock(clock.NewFake(now)))
+		fill(rb)
+
+		rb.Add(types.TelemetryEntry{Timestamp: now, Name: "new", Value: -1})
+
+		entries := rb.GetAll()
+		if len(entries) != rb.size || entries[len(entries)-1].Value != -1 {
+			t.Errorf("Expected newest entry to be stored, got last %v", entries[len(entries)-1].Value)
+		}
+		if entries[0].Value != 1 {
+			t.Errorf("Expected oldest entry to be overwritten, got first %v", entries[0].Value)
+		}
+	})
+
+	t.Run("drop-newest rejects entries when full", func(t *testing.T) {
+		rb := New(time.Second, WithClock(clock.NewFake(now)), WithOverflowPolicy(OverflowDropNewest))
+		fill(rb)
+
+		if err := rb.Add(types.TelemetryEntry{Timestamp: now, Name: "new", Value: -1}); !errors.Is(err, ErrBufferFull) {
+			t.Errorf("Expected ErrBufferFull from Add, got %v", err)
+		}
+		if err := rb.AddBatch([]types.TelemetryEntry{{Timestamp: now, Value: -2}, {Timestamp: now, Value: -3}}); !errors.Is(err, ErrBufferFull) {
+			t.Errorf("Expected ErrBufferFull from AddBatch, got %v", err)
+		}
+
+		entries := rb.GetAll()
+		if entries[0].Value != 0 || entries[len(entries)-1].Value == -1 {
+			t.Errorf("Expected buffered entries to be preserved, got first %v last %v", entries[0].Value, entries[len(entries)-1].Value)
+		}
+		if stats := rb.GetStats(); stats.DroppedEntries != 3 {
+			t.Errorf("Expected 3 dropped entries, got %d", stats.DroppedEntries)
+		}
+	})
+
+	t.Run("drop-newest overwrites expired entries", func(t *testing.T) {
+		fake := clock.NewFake(now)
+		rb := New(time.Second, WithClock(fake), WithOverflowPolicy(OverflowDropNewest))
+		fill(rb)
+
+		fake.Advance(2 * time.Second)
+		rb.Add(types.TelemetryEntry{Timestamp: fake.Now(), Name: "new", Value: -1})
+
+		entries := rb.GetAll()
+		if entries[len(entries)-1].Value != -1 {
+			t.Errorf("Expected entry to replace expired data, got last %v", entries[len(entries)-1].Value)
+		}
+		if stats := rb.GetStats(); stats.DroppedEntries != 0 {
+			t.Errorf("Expected no dropped entries, got %d", stats.DroppedEntries)
+		}
+	})
+
+	t.Run("block waits for cleanup to free space", func(t *testing.T) {
+		fake := clock.NewFake(now)
+		rb := New(time.Second, WithClock(fake), WithOverflowPolicy(OverflowBlock))
+		fill(rb)
+
+		added := make(chan struct{})
+		go func() {
+			rb.Add(types.TelemetryEntry{Timestamp: now.Add(2 * time.Second), Name: "new", Value: -1})
+			close(added)
+		}()
+
+		select {
+		case <-added:
+			t.Fatal("Expected Add to block while the buffer is full")
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		fake.Advance(2 * time.Second)
+		rb.Cleanup()
+
+		select {
+		case <-added:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected Add to complete after cleanup freed space")
+		}
+		if stats := rb.GetStats(); stats.TotalEntries != 1 {
+			t.Errorf("Expected only the new entry after cleanup, got %d", stats.TotalEntries)
+		}
+	})
+
+	t.Run("block resumes when oldest entry expires", func(t *testing.T) {
+		fake := clock.NewFake(now)
+		rb := New(time.Second, WithClock(fake), WithOverflowPolicy(OverflowBlock))
+		fill(rb)
+
+		added := make(chan struct{})
+		go func() {
+			rb.Add(types.TelemetryEntry{Timestamp: now, Name: "new", Value: -1})
+			close(added)
+		}()
+
+		// Expire entries without calling Cleanup; the blocked producer re-checks on its own
+		fake.Advance(2 * time.Second)
+
+		select {
+		case <-added:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected Add to complete once the oldest entry expired")
+		}
+	})
+
+	t.Run("block drops the entry after the timeout", func(t *testing.T) {
+		rb := New(time.Second, WithClock(clock.NewFake(now)), WithOverflowPolicy(OverflowBlock), WithBlockTimeout(20*time.Millisecond))
+		fill(rb)
+
+		if err := rb.Add(types.TelemetryEntry{Timestamp: now, Name: "new", Value: -1}); !errors.Is(err, ErrBufferFull) {
+			t.Errorf("Expected ErrBufferFull after the block timeout, got %v", err)
+		}
+		if stats := rb.GetStats(); stats.DroppedEntries != 1 {
+			t.Errorf("Expected 1 dropped entry, got %d", stats.DroppedEntries)
+		}
+	})
+
+	t.Run("accepted entries return no error", func(t *testing.T) {
+		rb := New(time.Second, WithClock(clock.NewFake(now)), WithOverflowPolicy(OverflowDropNewest))
+		if err := rb.Add(types.TelemetryEntry{Timestamp: now, Name: "new", Value: 1}); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		if err := rb.AddBatch(sequentialEntries(3, now)); err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+	})
+
+	t.Run("parses policy names", func(t *testing.T) {
+		for name, want := range map[string]OverflowPolicy{
+			"":                 OverflowOverwriteOldest,
+			"overwrite-oldest": OverflowOverwriteOldest,
+			"drop-newest":      OverflowDropNewest,
+			"block":            OverflowBlock,
+		} {
+			if policy, err := ParseOverflowPolicy(name); err != nil || policy != want {
+				t.Errorf("Expected %q to parse as %q, got %q (err=%v)", name, want, policy, err)
+			}
+		}
+		if _, err := ParseOverflowPolicy("drop-oldest"); err == nil {
+			t.Error("Expected error for unknown policy")
+		}
+	})
+}
+
+// TestDefaultTags validates merging default tags into added entries.
+func TestDefaultTags(t *testing.T) {
+	now := time.Now()
+	defaults := map[string]string{"cluster": "prod-east", "environment": "production"}
+
+	t.Run("applies default tags to entries", func(t *testing.T) {
+		rb := New(time.Minute, WithDefaultTags(defaults))
+		rb.Add(types.TelemetryEntry{Timestamp: now, Source: types.SourceSystem, Name: "cpu_usage"})
+
+		entry := rb.GetAll()[0]
+		if entry.Tags["cluster"] != "prod-east" || entry.Tags["environment"] != "production" {
+			t.Errorf("Expected default tags, got %v", entry.Tags)
+		}
+	})
+
+	t.Run("entry tags take precedence", func(t *testing.T) {
+		rb := New(time.Minute, WithDefaultTags(defaults))
+		tags := map[string]string{"environment": "canary", "pod": "web-1"}
+		rb.Add(types.TelemetryEntry{Timestamp: now, Source: types.SourceSidecar, Name: "heap_used", Tags: tags})
+
+		entry := rb.GetAll()[0]
+		if entry.Tags["environment"] != "canary" {
+			t.Errorf("Expected entry tag 'canary' to win, got %q", entry.Tags["environment"])
+		}
+		if entry.Tags["cluster"] != "prod-east" || entry.Tags["pod"] != "web-1" {
+			t.Errorf("Expected merged tags, got %v", entry.Tags)
+		}
+		if len(tags) != 2 {
+			t.Errorf("Expected caller's tags to be left untouched, got %v", tags)
+		}
+	})
+
+	t.Run("applies default tags to batches", func(t *testing.T) {
+		rb := New(time.Minute, WithDefaultTags(defaults))
+		rb.AddBatch([]types.TelemetryEntry{
+			{Timestamp: now, Name: "a"},
+			{Timestamp: now, Name: "b", Tags: map[string]string{"cluster": "dev"}},
+		})
+
+		entries := rb.GetAll()
+		if entries[0].Tags["cluster"] != "prod-east" || entries[1].Tags["cluster"] != "dev" {
+			t.Errorf("Expected default and overriding cluster tags, got %v and %v", entries[0].Tags, entries[1].Tags)
+		}
+		if len(rb.FilterByTags(map[string]string{"environment": "production"}, now.Add(time.Second))) != 2 {
+			t.Error("Expected both entries to match default tag filter")
+		}
+	})
+
+	t.Run("leaves entries unchanged without defaults", func(t *testing.T) {
+		rb := New(time.Minute)
+		rb.Add(types.TelemetryEntry{Timestamp: now, Name: "a"})
+
+		if tags := rb.GetAll()[0].Tags; tags != nil {
+			t.Errorf("Expected nil tags, got %v", tags)
+		}
+	})
+}
+
+// TestEffectiveWindow validates reporting of window truncation when capacity runs out.
+func TestEffectiveWindow(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("reports configured window when capacity suffices", func(t *testing.T) {
+		fake := clock.NewFake(base)
+		rb := New(time.Second, WithClock(fake))
+		for i := 0; i < 500; i++ {
+			rb.Add(types.TelemetryEntry{Timestamp: base.Add(time.Duration(i) * time.Millisecond)})
+		}
+		fake.Set(base.Add(500 * time.Millisecond))
+
+		stats := rb.GetStats()
+		if stats.EffectiveWindow != time.Second {
+			t.Errorf("Expected effective window 1s, got %v", stats.EffectiveWindow)
+		}
+		if rb.truncationWarned {
+			t.Error("Expected no truncation warning")
+		}
+	})
+
+	t.Run("reports truncated window when buffer wraps within window", func(t *testing.T) {
+		fake := clock.NewFake(base)
+		rb := New(time.Second, WithClock(fake))
+		// 1500 entries half a millisecond apart wrap the 1000 entry buffer in 750ms
+		for i := 0; i < 1500; i++ {
+			now := base.Add(time.Duration(i) * 500 * time.Microsecond)
+			fake.Set(now)
+			rb.Add(types.TelemetryEntry{Timestamp: now})
+		}
+
+		stats := rb.GetStats()
+		expected := 999 * 500 * time.Microsecond
+		if stats.EffectiveWindow != expected {
+			t.Errorf("Expected effective window %v, got %v", expected, stats.EffectiveWindow)
+		}
+		if !rb.truncationWarned {
+			t.Error("Expected truncation warning")
+		}
+	})
+
+	t.Run("detects truncation in batches", func(t *testing.T) {
+		fake := clock.NewFake(base)
+		rb := New(time.Second, WithClock(fake))
+		batch := make([]types.TelemetryEntry, 1200)
+		for i := range batch {
+			batch[i] = types.TelemetryEntry{Timestamp: base.Add(time.Duration(i) * 100 * time.Microsecond)}
+		}
+		fake.Set(base.Add(120 * time.Millisecond))
+		rb.AddBatch(batch)
+
+		if !rb.truncationWarned {
+			t.Error("Expected truncation warning for oversized batch")
+		}
+		if stats := rb.GetStats(); stats.EffectiveWindow >= time.Second {
+			t.Errorf("Expected truncated effective window, got %v", stats.EffectiveWindow)
+		}
+	})
+
+	t.Run("ignores overwriting expired entries", func(t *testing.T) {
+		fake := clock.NewFake(base)
+		rb := New(time.Second, WithClock(fake))
+		// One entry every 2ms spans 2s across the 1000 entries, twice the window
+		for i := 0; i < 1500; i++ {
+			now := base.Add(time.Duration(i) * 2 * time.Millisecond)
+			fake.Set(now)
+			rb.AddBatch([]types.TelemetryEntry{{Timestamp: now}})
+		}
+
+		if rb.truncationWarned {
+			t.Error("Expected no truncation warning when overwritten entries have expired")
+		}
+		if stats := rb.GetStats(); stats.EffectiveWindow != time.Second {
+			t.Errorf("Expected effective window 1s, got %v", stats.EffectiveWindow)
+		}
+	})
+}
+
+// TestInterning validates that interned entries keep their contents and share string storage.
+func TestInterning(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	// decoded returns an entry whose strings are freshly allocated, as if
+	// decoded from a sidecar submission.
+	decoded := func(value int) types.TelemetryEntry {
+		return types.TelemetryEntry{
+			Timestamp: now,
+			Source:    types.SourceSidecar,
+			Name:      strings.Clone("jvm_heap_used_bytes"),
+			Value:     value,
+			Tags:      map[string]string{strings.Clone("pod_name"): strings.Clone("api-1"), strings.Clone("namespace"): strings.Clone("prod")},
+		}
+	}
+
+	t.Run("interned entries compare equal", func(t *testing.T) {
+		rb := New(time.Minute, WithInterning(true), WithDefaultTags(map[string]string{"cluster": "prod-east"}))
+		original := decoded(1)
+		rb.Add(original)
+		rb.AddBatch([]types.TelemetryEntry{decoded(2)})
+
+		entries := rb.GetAll()
+		if len(entries) != 2 {
+			t.Fatalf("Expected 2 entries, got %d", len(entries))
+		}
+		expected := decoded(1)
+		expected.Tags["cluster"] = "prod-east"
+		if !reflect.DeepEqual(entries[0], expected) {
+			t.Errorf("Expected interned entry %+v, got %+v", expected, entries[0])
+		}
+		if len(original.Tags) != 2 {
+			t.Errorf("Expected caller's tags to be left untouched, got %v", original.Tags)
+		}
+		if len(rb.FilterByTags(map[string]string{"pod_name": "api-1"}, now)) != 2 {
+			t.Error("Expected both interned entries to match a tag filter")
+		}
+	})
+
+	t.Run("identical strings share storage", func(t *testing.T) {
+		rb := New(time.Minute, WithInterning(true))
+		rb.Add(decoded(1))
+		rb.Add(decoded(2))
+
+		entries := rb.GetAll()
+		if unsafe.StringData(entries[0].Name) != unsafe.StringData(entries[1].Name) {
+			t.Error("Expected interned names to share storage")
+		}
+		if unsafe.StringData(entries[0].Tags["pod_name"]) != unsafe.StringData(entries[1].Tags["pod_name"]) {
+			t.Error("Expected interned tag values to share storage")
+		}
+	})
+
+	t.Run("stores entries as given when disabled", func(t *testing.T) {
+		rb := New(time.Minute)
+		rb.Add(decoded(1))
+		rb.Add(decoded(2))
+
+		entries := rb.GetAll()
+		if unsafe.StringData(entries[0].Name) == unsafe.StringData(entries[1].Name) {
+			t.Error("Expected names to keep their own storage without interning")
+		}
+	})
+}
+
+// BenchmarkInterning measures the heap retained by a full buffer of entries
+// with freshly allocated names and tags, with and without interning.
+func BenchmarkInterning(b *testing.B) {
+	now := time.Now()
+	for _, intern := range []bool{false, true} {
+		b.Run(fmt.Sprintf("intern=%v", intern), func(b *testing.B) {
+			var retained, entries uint64
+			for i := 0; i < b.N; i++ {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+
+				rb := New(10*time.Second, WithInterning(intern))
+				for j := 0; j < rb.size; j++ {
+					rb.Add(types.TelemetryEntry{
+						Timestamp: now,
+						Source:    types.SourceSidecar,
+						Name:      fmt.Sprintf("jvm_gc_%s_seconds", []string{"young", "old", "full"}[j%3]),
+						Value:     j,
+						Tags: map[string]string{
+							strings.Clone("pod_name"):  fmt.Sprintf("api-%d", j%8),
+							strings.Clone("namespace"): strings.Clone("production"),
+							strings.Clone("container"): strings.Clone("application"),
+						},
+					})
+				}
+
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+				retained += after.HeapAlloc - before.HeapAlloc
+				entries += uint64(rb.size)
+				runtime.KeepAlive(rb)
+			}
+			b.ReportMetric(float64(retained)/float64(entries), "retained-B/entry")
+		})
+	}
+}
@@ -0,0 +1,278 @@
+package ringbuffer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// DefaultSpillMaxBytes bounds the disk used by a DiskSpill when no limit is given.
+const DefaultSpillMaxBytes = 256 << 20
+
+// spillSegments is how many segments the disk budget is divided into. Whole
+// segments are evicted, so the tier holds between 7/8 and all of its budget
+// once full.
+const spillSegments = 8
+
+// spillPattern matches the segment files of a spill directory.
+const spillPattern = "spill-*.ndjson"
+
+// DiskSpill is a bounded, disk-backed second tier for a RingBuffer. Entries
+// the buffer overwrites while they are still inside the retention window are
+// appended to NDJSON segment files instead of being lost, and queries read
+// them back, more slowly, before the entries still in memory. Segments are
+// removed once every entry in them has expired, and the oldest segments are
+// evicted when the disk budget is exceeded.
+type DiskSpill struct {
+	// mutex protects all fields below
+	mutex sync.Mutex
+	// dir holds the segment files
+	dir string
+	// maxBytes bounds the total size of the segments
+	maxBytes int64
+	// segmentSize is the size at which the current segment is rotated
+	segmentSize int64
+	// segments are the segment files, oldest first; the last one is written to
+	segments []*spillSegment
+	// file and writer append to the last segment (nil before the first write)
+	file   *os.File
+	writer *bufio.Writer
+	// seq numbers the segment files
+	seq int
+	// bytes is the total size of the segments
+	bytes int64
+	// spilled counts the entries written
+	spilled uint64
+	// evicted counts the entries removed to stay within maxBytes
+	evicted uint64
+	// writeErrors counts entries that could not be written
+	writeErrors uint64
+}
+
+// spillSegment describes one segment file.
+type spillSegment struct {
+	path    string
+	size    int64
+	entries int
+	// newest is the latest timestamp in the segment
+	newest time.Time
+}
+
+// SpillStats describes the disk tier of a buffer.
+type SpillStats struct {
+	// Segments is the number of segment files
+	Segments int `json:"segments"`
+	// Bytes is the disk used by the segments
+	Bytes int64 `json:"bytes"`
+	// MaxBytes is the disk budget
+	MaxBytes int64 `json:"max_bytes"`
+	// SpilledEntries is the number of overwritten entries written to disk
+	SpilledEntries uint64 `json:"spilled_entries"`
+	// EvictedEntries is the number of spilled entries removed to stay within the budget
+	EvictedEntries uint64 `json:"evicted_entries"`
+	// WriteErrors is the number of entries that could not be written
+	WriteErrors uint64 `json:"write_errors"`
+}
+
+// NewDiskSpill creates a disk tier storing segments in dir, created if
+// missing, using at most maxBytes (zero or less uses DefaultSpillMaxBytes).
+// Segments left over from a previous run are removed.
+func NewDiskSpill(dir string, maxBytes int64) (*DiskSpill, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultSpillMaxBytes
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create spill directory: %w", err)
+	}
+	stale, err := filepath.Glob(filepath.Join(dir, spillPattern))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list spill segments: %w", err)
+	}
+	for _, path := range stale {
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale spill segment: %w", err)
+		}
+	}
+
+	segmentSize := maxBytes / spillSegments
+	if segmentSize < 1 {
+		segmentSize = 1
+	}
+	return &DiskSpill{dir: dir, maxBytes: maxBytes, segmentSize: segmentSize}, nil
+}
+
+// WithSpill tees entries overwritten before they expire into the disk tier,
+// which GetWindow, GetWindowBetween, Snapshot and the filters query along with
+// the buffer. Only the overwrite-oldest policy overwrites live entries, and
+// AddBatch then stores entries one at a time. Disabled by default.
+func WithSpill(spill *DiskSpill) Option {
+	return func(rb *RingBuffer) {
+		rb.spill = spill
+	}
+}
+
+// Write appends the entry to the current segment, rotating it when full and
+// evicting the oldest segments when the disk budget is exceeded.
+func (ds *DiskSpill) Write(entry types.TelemetryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode spilled entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.writer == nil || ds.current().size+int64(len(data)) > ds.segmentSize {
+		if err := ds.rotateLocked(); err != nil {
+			ds.writeErrors++
+			return err
+		}
+	}
+	if _, err := ds.writer.Write(data); err != nil {
+		ds.writeErrors++
+		return fmt.Errorf("failed to write spill segment: %w", err)
+	}
+
+	segment := ds.current()
+	segment.size += int64(len(data))
+	segment.entries++
+	if entry.Timestamp.After(segment.newest) {
+		segment.newest = entry.Timestamp
+	}
+	ds.bytes += int64(len(data))
+	ds.spilled++
+
+	for ds.bytes > ds.maxBytes && len(ds.segments) > 1 {
+		ds.evicted += uint64(ds.segments[0].entries)
+		ds.removeOldestLocked()
+	}
+	return nil
+}
+
+// current returns the segment being written. The caller must hold the lock.
+func (ds *DiskSpill) current() *spillSegment {
+	return ds.segments[len(ds.segments)-1]
+}
+
+// rotateLocked closes the current segment and starts a new one. The caller
+// must hold the lock.
+func (ds *DiskSpill) rotateLocked() error {
+	if err := ds.closeLocked(); err != nil {
+		return err
+	}
+	ds.seq++
+	path := filepath.Join(ds.dir, fmt.Sprintf("spill-%06d.ndjson", ds.seq))
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to create spill segment: %w", err)
+	}
+	ds.file = file
+	ds.writer = bufio.NewWriterSize(file, 64<<10)
+	ds.segments = append(ds.segments, &spillSegment{path: path})
+	return nil
+}
+
+// closeLocked flushes and closes the current segment, if any. The caller must
+// hold the lock.
+func (ds *DiskSpill) closeLocked() error {
+	if ds.file == nil {
+		return nil
+	}
+	flushErr := ds.writer.Flush()
+	closeErr := ds.file.Close()
+	ds.file, ds.writer = nil, nil
+	if flushErr != nil {
+		return fmt.Errorf("failed to flush spill segment: %w", flushErr)
+	}
+	if closeErr != nil {
+		return fmt.Errorf("failed to close spill segment: %w", closeErr)
+	}
+	return nil
+}
+
+// removeOldestLocked deletes the oldest segment, closing it first if it is
+// being written. The caller must hold the lock.
+func (ds *DiskSpill) removeOldestLocked() {
+	oldest := ds.segments[0]
+	if len(ds.segments) == 1 {
+		ds.closeLocked()
+	}
+	if err := os.Remove(oldest.path); err != nil && !os.IsNotExist(err) {
+		fmt.Printf("Warning: failed to remove spill segment %s: %v\n", oldest.path, err)
+	}
+	ds.bytes -= oldest.size
+	ds.segments = ds.segments[1:]
+}
+
+// Expire removes the segments whose entries are all older than cutoff.
+func (ds *DiskSpill) Expire(cutoff time.Time) {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	for len(ds.segments) > 0 && ds.segments[0].newest.Before(cutoff) {
+		ds.removeOldestLocked()
+	}
+}
+
+// Query returns the spilled entries accepted by include, oldest first.
+// Segments that cannot be read are skipped with a warning.
+func (ds *DiskSpill) Query(include func(entry types.TelemetryEntry) bool) []types.TelemetryEntry {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	if ds.writer != nil {
+		if err := ds.writer.Flush(); err != nil {
+			fmt.Printf("Warning: failed to flush spill segment: %v\n", err)
+		}
+	}
+
+	var result []types.TelemetryEntry
+	for _, segment := range ds.segments {
+		file, err := os.Open(segment.path)
+		if err != nil {
+			fmt.Printf("Warning: failed to open spill segment %s: %v\n", segment.path, err)
+			continue
+		}
+		entries, _, err := readNDJSON(file)
+		file.Close()
+		if err != nil {
+			fmt.Printf("Warning: failed to read spill segment %s: %v\n", segment.path, err)
+		}
+		for _, entry := range entries {
+			if include(entry) {
+				result = append(result, entry)
+			}
+		}
+	}
+	return result
+}
+
+// Stats returns the disk tier's usage.
+func (ds *DiskSpill) Stats() SpillStats {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+
+	return SpillStats{
+		Segments:       len(ds.segments),
+		Bytes:          ds.bytes,
+		MaxBytes:       ds.maxBytes,
+		SpilledEntries: ds.spilled,
+		EvictedEntries: ds.evicted,
+		WriteErrors:    ds.writeErrors,
+	}
+}
+
+// Close flushes and closes the current segment. The segments stay on disk
+// until the next NewDiskSpill for the directory.
+func (ds *DiskSpill) Close() error {
+	ds.mutex.Lock()
+	defer ds.mutex.Unlock()
+	return ds.closeLocked()
+}
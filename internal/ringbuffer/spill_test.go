@@ -0,0 +1,164 @@
+package ringbuffer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestDiskSpill validates spilling overwritten live entries to disk and
+// querying them with the buffer.
+func TestDiskSpill(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	// fill adds n entries a microsecond apart, ending at the current time.
+	fill := func(rb *RingBuffer, n int) {
+		for i := 0; i < n; i++ {
+			rb.Add(types.TelemetryEntry{
+				Timestamp: now.Add(time.Duration(i-n+1) * time.Microsecond),
+				Source:    types.SourceSystem,
+				Type:      types.TypeCPU,
+				Name:      "cpu_usage_percent",
+				Value:     i,
+				Tags:      map[string]string{"core": "0"},
+			})
+		}
+	}
+
+	newBuffer := func(t *testing.T, maxBytes int64) (*RingBuffer, *DiskSpill, *clock.Fake) {
+		t.Helper()
+		spill, err := NewDiskSpill(t.TempDir(), maxBytes)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		t.Cleanup(func() { spill.Close() })
+		fakeClock := clock.NewFake(now)
+		return New(time.Minute, WithClock(fakeClock), WithSpill(spill)), spill, fakeClock
+	}
+
+	t.Run("spills overwritten live entries and queries them", func(t *testing.T) {
+		rb, spill, _ := newBuffer(t, 0)
+		size := rb.GetStats().BufferSize
+		fill(rb, size+500)
+
+		if stats := spill.Stats(); stats.SpilledEntries != 500 || stats.Segments != 1 {
+			t.Errorf("Expected 500 spilled entries in 1 segment, got %+v", stats)
+		}
+		if len(rb.GetAll()) != size {
+			t.Errorf("Expected the memory buffer to hold %d entries, got %d", size, len(rb.GetAll()))
+		}
+
+		entries := rb.GetWindow(now)
+		if len(entries) != size+500 {
+			t.Fatalf("Expected every entry from disk and memory, got %d", len(entries))
+		}
+		for i := 1; i < len(entries); i++ {
+			if entries[i].Timestamp.Before(entries[i-1].Timestamp) {
+				t.Fatalf("Expected chronological order, entry %d is older than entry %d", i, i-1)
+			}
+		}
+		if entries[0].Value != json.Number("0") || entries[0].Tags["core"] != "0" {
+			t.Errorf("Expected the first spilled entry decoded, got %+v", entries[0])
+		}
+
+		snapshot := rb.Snapshot(now.Add(-time.Duration(size)*time.Microsecond), time.Minute)
+		if len(snapshot) != 500 {
+			t.Errorf("Expected a snapshot before the memory window served from disk, got %d entries", len(snapshot))
+		}
+		if filtered := rb.FilterByTags(map[string]string{"core": "1"}, now); len(filtered) != 0 {
+			t.Errorf("Expected filters applied to spilled entries, got %d", len(filtered))
+		}
+
+		stats := rb.GetStats()
+		if stats.Spill == nil || stats.Spill.SpilledEntries != 500 || stats.Spill.Bytes == 0 {
+			t.Errorf("Expected spill stats in buffer stats, got %+v", stats.Spill)
+		}
+	})
+
+	t.Run("does not spill expired entries", func(t *testing.T) {
+		rb, spill, fakeClock := newBuffer(t, 0)
+		size := rb.GetStats().BufferSize
+		fill(rb, size)
+
+		fakeClock.Advance(2 * time.Minute)
+		rb.Add(types.TelemetryEntry{Timestamp: fakeClock.Now(), Source: types.SourceSystem, Name: "cpu_usage_percent", Value: 1})
+
+		if spilled := spill.Stats().SpilledEntries; spilled != 0 {
+			t.Errorf("Expected no expired entries spilled, got %d", spilled)
+		}
+	})
+
+	t.Run("bounds disk usage", func(t *testing.T) {
+		rb, spill, _ := newBuffer(t, 16<<10)
+		size := rb.GetStats().BufferSize
+		fill(rb, size+5000)
+
+		stats := spill.Stats()
+		if stats.Bytes > stats.MaxBytes {
+			t.Errorf("Expected at most %d bytes on disk, got %d", stats.MaxBytes, stats.Bytes)
+		}
+		if stats.EvictedEntries == 0 || stats.Segments > spillSegments+1 {
+			t.Errorf("Expected old segments evicted, got %+v", stats)
+		}
+
+		// Querying flushes the segment being written
+		spill.Query(func(types.TelemetryEntry) bool { return false })
+		var onDisk int64
+		paths, _ := filepath.Glob(filepath.Join(spill.dir, spillPattern))
+		for _, path := range paths {
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			onDisk += info.Size()
+		}
+		if onDisk > stats.MaxBytes {
+			t.Errorf("Expected segment files within the budget, got %d bytes", onDisk)
+		}
+
+		entries := rb.GetWindow(now)
+		if uint64(len(entries)) != uint64(size)+stats.SpilledEntries-stats.EvictedEntries {
+			t.Errorf("Expected evicted entries missing from queries, got %d entries", len(entries))
+		}
+	})
+
+	t.Run("cleanup expires spilled segments", func(t *testing.T) {
+		rb, spill, fakeClock := newBuffer(t, 0)
+		fill(rb, rb.GetStats().BufferSize+10)
+
+		fakeClock.Advance(2 * time.Minute)
+		rb.Cleanup()
+
+		if stats := spill.Stats(); stats.Segments != 0 || stats.Bytes != 0 {
+			t.Errorf("Expected expired segments removed, got %+v", stats)
+		}
+		if entries := rb.GetWindow(fakeClock.Now()); len(entries) != 0 {
+			t.Errorf("Expected no entries after expiry, got %d", len(entries))
+		}
+
+		fill(rb, 1)
+		if entries := rb.GetWindowBetween(now.Add(-time.Second), now); len(entries) != 1 {
+			t.Errorf("Expected the buffer usable after expiry, got %d entries", len(entries))
+		}
+	})
+
+	t.Run("removes stale segments", func(t *testing.T) {
+		dir := t.TempDir()
+		stale := filepath.Join(dir, "spill-000001.ndjson")
+		if err := os.WriteFile(stale, []byte("{}\n"), 0o640); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if _, err := NewDiskSpill(dir, 0); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err := os.Stat(stale); !os.IsNotExist(err) {
+			t.Errorf("Expected stale segment removed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,78 @@
+package ringbuffer
+
+import (
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// Since returns up to limit entries stored at or after the cursor, oldest
+// first, and the cursor to pass next time. Cursors count the entries ever
+// stored, so a zero cursor starts at the oldest entry still buffered, and a
+// cursor whose entries were overwritten or expired resumes there as well. A
+// limit of zero or less returns every newer entry. Spilled entries are not
+// returned.
+func (rb *RingBuffer) Since(cursor uint64, limit int) ([]types.TelemetryEntry, uint64) {
+	rb.mutex.RLock()
+	defer rb.mutex.RUnlock()
+
+	oldest := rb.written - uint64(rb.count)
+	if cursor < oldest {
+		cursor = oldest
+	}
+	if cursor >= rb.written {
+		return []types.TelemetryEntry{}, rb.written
+	}
+
+	n := int(rb.written - cursor)
+	if limit > 0 && n > limit {
+		n = limit
+	}
+	result := make([]types.TelemetryEntry, n)
+	start := rb.head - int(rb.written-cursor)
+	if start < 0 {
+		start += rb.size
+	}
+	for i := range result {
+		result[i] = rb.entries[(start+i)%rb.size]
+	}
+	return result, cursor + uint64(n)
+}
+
+// Cursor returns the cursor of the next entry to be stored, so Since with it
+// returns only entries added from now on.
+func (rb *RingBuffer) Cursor() uint64 {
+	rb.mutex.RLock()
+	defer rb.mutex.RUnlock()
+	return rb.written
+}
+
+// Subscribe returns a channel that receives a value whenever entries are
+// stored, for waiting on new telemetry, and a function that unsubscribes.
+// Notifications coalesce: a subscriber that is not receiving gets one pending
+// value, so it should read everything new with Since after each wakeup.
+func (rb *RingBuffer) Subscribe() (<-chan struct{}, func()) {
+	notify := make(chan struct{}, 1)
+
+	rb.mutex.Lock()
+	if rb.subscribers == nil {
+		rb.subscribers = make(map[chan struct{}]struct{})
+	}
+	rb.subscribers[notify] = struct{}{}
+	rb.mutex.Unlock()
+
+	return notify, func() {
+		rb.mutex.Lock()
+		delete(rb.subscribers, notify)
+		rb.mutex.Unlock()
+	}
+}
+
+// notifyLocked wakes the subscribers without blocking. The caller must hold
+// the write lock.
+func (rb *RingBuffer) notifyLocked() {
+	for notify := range rb.subscribers {
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestTail validates reading entries since a cursor and waiting for new ones.
+func TestTail(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	entry := func(value int) types.TelemetryEntry {
+		return types.TelemetryEntry{Timestamp: now, Source: types.SourceSystem, Name: "cpu_usage_percent", Value: value}
+	}
+
+	t.Run("returns entries since the cursor", func(t *testing.T) {
+		rb := New(time.Minute, WithClock(clock.NewFake(now)))
+		rb.Add(entry(0))
+		rb.AddBatch([]types.TelemetryEntry{entry(1), entry(2)})
+
+		entries, cursor := rb.Since(0, 0)
+		if len(entries) != 3 || cursor != 3 || entries[2].Value != 2 {
+			t.Fatalf("Expected 3 entries and cursor 3, got %d and %d", len(entries), cursor)
+		}
+
+		rb.Add(entry(3))
+		entries, cursor = rb.Since(cursor, 0)
+		if len(entries) != 1 || entries[0].Value != 3 || cursor != 4 {
+			t.Errorf("Expected only the new entry and cursor 4, got %v and %d", entries, cursor)
+		}
+		if entries, next := rb.Since(cursor, 0); len(entries) != 0 || next != cursor {
+			t.Errorf("Expected nothing new, got %d entries and cursor %d", len(entries), next)
+		}
+	})
+
+	t.Run("limits the entries returned", func(t *testing.T) {
+		rb := New(time.Minute, WithClock(clock.NewFake(now)))
+		for i := 0; i < 5; i++ {
+			rb.Add(entry(i))
+		}
+
+		entries, cursor := rb.Since(1, 2)
+		if len(entries) != 2 || entries[0].Value != 1 || cursor != 3 {
+			t.Errorf("Expected entries 1 and 2 and cursor 3, got %v and %d", entries, cursor)
+		}
+	})
+
+	t.Run("resumes at the oldest entry after overwrites", func(t *testing.T) {
+		rb := New(time.Minute, WithClock(clock.NewFake(now)))
+		size := rb.GetStats().BufferSize
+		for i := 0; i < size+10; i++ {
+			rb.Add(entry(i))
+		}
+
+		entries, cursor := rb.Since(5, 0)
+		if len(entries) != size || entries[0].Value != 10 || cursor != uint64(size+10) {
+			t.Errorf("Expected the %d buffered entries from 10, got %d from %v", size, len(entries), entries[0].Value)
+		}
+		if rb.Cursor() != cursor {
+			t.Errorf("Expected cursor %d, got %d", cursor, rb.Cursor())
+		}
+	})
+
+	t.Run("notifies subscribers of new entries", func(t *testing.T) {
+		rb := New(time.Minute, WithClock(clock.NewFake(now)))
+		notify, unsubscribe := rb.Subscribe()
+
+		rb.Add(entry(0))
+		rb.Add(entry(1))
+		select {
+		case <-notify:
+		default:
+			t.Fatal("Expected a notification")
+		}
+		select {
+		case <-notify:
+			t.Error("Expected notifications coalesced")
+		default:
+		}
+
+		unsubscribe()
+		rb.Add(entry(2))
+		select {
+		case <-notify:
+			t.Error("Expected no notification after unsubscribing")
+		default:
+		}
+	})
+}
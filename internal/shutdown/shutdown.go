@@ -0,0 +1,163 @@
+// Package shutdown stops the daemon's subsystems in order on termination.
+// Cancelling a shared context stops everything at once, so an incident raised
+// while the API drains could reach emitters that already stopped. A Sequence
+// instead stops one stage at a time, each within its share of the grace period.
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Stage names of the daemon's subsystems, in the order they stop by default.
+const (
+	// StageAPI stops accepting sidecar telemetry and incidents
+	StageAPI = "api"
+	// StageCollectors stops collecting node telemetry into the buffer
+	StageCollectors = "collectors"
+	// StageWatcher stops watching pods, so no new crash incidents are raised
+	StageWatcher = "watcher"
+	// StageSnapshot writes the final buffer snapshot
+	StageSnapshot = "snapshot"
+	// StageEmitters drains the asynchronous emitter queues
+	StageEmitters = "emitters"
+	// StageStores closes the incident store and the buffer's disk tier
+	StageStores = "stores"
+	// StageMetrics stops the metrics server, last so shutdown stays observable
+	StageMetrics = "metrics"
+)
+
+// DefaultOrder is the order the daemon's stages stop in: inputs first, then
+// the buffered state, then outputs and the stores they write to.
+var DefaultOrder = []string{StageAPI, StageCollectors, StageWatcher, StageSnapshot, StageEmitters, StageStores, StageMetrics}
+
+// DefaultGracePeriod bounds the whole sequence, leaving room within the
+// default Kubernetes termination grace period of 30 seconds.
+const DefaultGracePeriod = 25 * time.Second
+
+// IsStage reports whether name is one of the daemon's stage names.
+func IsStage(name string) bool {
+	for _, stage := range DefaultOrder {
+		if stage == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Stage is one step of the shutdown sequence.
+type Stage struct {
+	// Name identifies the stage in logs and results
+	Name string
+	// Timeout bounds the stage; zero gives it an equal share of the grace
+	// period left for the remaining stages
+	Timeout time.Duration
+	// Stop stops the subsystem and should return once its context is done
+	Stop func(ctx context.Context) error
+}
+
+// Result describes how a stage stopped.
+type Result struct {
+	// Name is the stage name
+	Name string
+	// Duration is how long the stage ran
+	Duration time.Duration
+	// Err is the error Stop returned, or the context error when it timed out
+	Err error
+	// TimedOut reports that the stage was abandoned at its deadline
+	TimedOut bool
+}
+
+// Sequence stops stages one after another within a grace period.
+type Sequence struct {
+	// grace bounds the whole sequence
+	grace time.Duration
+	// stages are stopped in order
+	stages []Stage
+}
+
+// NewSequence creates a sequence stopping the stages in the given order
+// within grace (zero or less uses DefaultGracePeriod).
+func NewSequence(grace time.Duration, stages ...Stage) *Sequence {
+	if grace <= 0 {
+		grace = DefaultGracePeriod
+	}
+	return &Sequence{grace: grace, stages: stages}
+}
+
+// Ordered returns the stages sorted by the configured order of names. Names
+// without a stage are skipped, e.g. the watcher in query mode, and stages not
+// named in the order stop last in their original order.
+func Ordered(order []string, stages ...Stage) []Stage {
+	byName := make(map[string]Stage, len(stages))
+	for _, stage := range stages {
+		byName[stage.Name] = stage
+	}
+
+	result := make([]Stage, 0, len(stages))
+	for _, name := range order {
+		if stage, ok := byName[name]; ok {
+			result = append(result, stage)
+			delete(byName, name)
+		}
+	}
+	for _, stage := range stages {
+		if _, ok := byName[stage.Name]; ok {
+			result = append(result, stage)
+		}
+	}
+	return result
+}
+
+// Run stops every stage in order and returns how each one stopped. A stage
+// that fails or overruns its budget is logged and abandoned, and the next one
+// starts, so a hung stage cannot keep later stages from running. Time a stage
+// does not use is shared by the stages after it. Cancelling ctx cuts the
+// remaining stages short.
+func (s *Sequence) Run(ctx context.Context) []Result {
+	ctx, cancel := context.WithTimeout(ctx, s.grace)
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+
+	results := make([]Result, 0, len(s.stages))
+	for i, stage := range s.stages {
+		budget := stage.Timeout
+		if budget <= 0 {
+			budget = time.Until(deadline) / time.Duration(len(s.stages)-i)
+		}
+		fmt.Printf("Shutdown: stopping %s (budget %v)\n", stage.Name, budget.Round(time.Millisecond))
+
+		result := runStage(ctx, stage, budget)
+		switch {
+		case result.TimedOut:
+			fmt.Printf("Warning: shutdown stage %s did not finish within %v, continuing\n", stage.Name, budget.Round(time.Millisecond))
+		case result.Err != nil:
+			fmt.Printf("Warning: shutdown stage %s failed: %v\n", stage.Name, result.Err)
+		default:
+			fmt.Printf("Shutdown: stopped %s in %v\n", stage.Name, result.Duration.Round(time.Millisecond))
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// runStage runs one stage's Stop with its budget, abandoning it when it does
+// not return in time.
+func runStage(ctx context.Context, stage Stage, budget time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, budget)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		done <- stage.Stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return Result{Name: stage.Name, Duration: time.Since(start), Err: err}
+	case <-ctx.Done():
+		return Result{Name: stage.Name, Duration: time.Since(start), Err: ctx.Err(), TimedOut: true}
+	}
+}
@@ -0,0 +1,157 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorder records the order stages stop in.
+type recorder struct {
+	mutex   sync.Mutex
+	stopped []string
+}
+
+// stage returns a stage that records its name and runs stop.
+func (r *recorder) stage(name string, stop func(ctx context.Context) error) Stage {
+	return Stage{Name: name, Stop: func(ctx context.Context) error {
+		r.mutex.Lock()
+		r.stopped = append(r.stopped, name)
+		r.mutex.Unlock()
+		if stop == nil {
+			return nil
+		}
+		return stop(ctx)
+	}}
+}
+
+// order returns the names of the stages stopped so far.
+func (r *recorder) order() []string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]string(nil), r.stopped...)
+}
+
+// TestSequence validates stopping stages in order within the grace period.
+func TestSequence(t *testing.T) {
+	t.Run("stops stages in the configured order", func(t *testing.T) {
+		rec := &recorder{}
+		stages := Ordered(DefaultOrder,
+			rec.stage(StageMetrics, nil),
+			rec.stage(StageStores, nil),
+			rec.stage(StageEmitters, nil),
+			rec.stage(StageAPI, nil),
+			rec.stage(StageSnapshot, nil),
+			rec.stage(StageWatcher, nil),
+			rec.stage(StageCollectors, nil),
+		)
+
+		results := NewSequence(time.Second, stages...).Run(context.Background())
+
+		expected := []string{StageAPI, StageCollectors, StageWatcher, StageSnapshot, StageEmitters, StageStores, StageMetrics}
+		order := rec.order()
+		if len(order) != len(expected) || len(results) != len(expected) {
+			t.Fatalf("Expected %d stages, got %v", len(expected), order)
+		}
+		for i, name := range expected {
+			if order[i] != name || results[i].Name != name {
+				t.Errorf("Expected stage %d to be %s, got %s", i, name, order[i])
+			}
+			if results[i].Err != nil {
+				t.Errorf("Expected stage %s to succeed, got %v", name, results[i].Err)
+			}
+		}
+	})
+
+	t.Run("skips absent stages and runs unnamed ones last", func(t *testing.T) {
+		rec := &recorder{}
+		stages := Ordered([]string{StageEmitters, StageAPI},
+			rec.stage("tracing", nil),
+			rec.stage(StageAPI, nil),
+			rec.stage(StageEmitters, nil),
+		)
+
+		NewSequence(time.Second, stages...).Run(context.Background())
+
+		if order := rec.order(); len(order) != 3 || order[0] != StageEmitters || order[1] != StageAPI || order[2] != "tracing" {
+			t.Errorf("Expected emitters, api, tracing, got %v", order)
+		}
+	})
+
+	t.Run("abandons a hung stage and continues within the grace period", func(t *testing.T) {
+		rec := &recorder{}
+		hung := make(chan struct{})
+		defer close(hung)
+		stages := []Stage{
+			rec.stage(StageAPI, nil),
+			rec.stage(StageSnapshot, func(ctx context.Context) error {
+				<-hung // ignores its context
+				return nil
+			}),
+			rec.stage(StageEmitters, func(ctx context.Context) error {
+				return errors.New("queue closed")
+			}),
+			rec.stage(StageMetrics, nil),
+		}
+
+		grace := 200 * time.Millisecond
+		start := time.Now()
+		results := NewSequence(grace, stages...).Run(context.Background())
+		elapsed := time.Since(start)
+
+		if elapsed > grace+50*time.Millisecond {
+			t.Errorf("Expected the sequence within the %v grace period, took %v", grace, elapsed)
+		}
+		if order := rec.order(); len(order) != 4 || order[3] != StageMetrics {
+			t.Fatalf("Expected every stage to run, got %v", order)
+		}
+		if !results[1].TimedOut || !errors.Is(results[1].Err, context.DeadlineExceeded) {
+			t.Errorf("Expected the snapshot stage timed out, got %+v", results[1])
+		}
+		if results[2].Err == nil || results[2].TimedOut {
+			t.Errorf("Expected the emitter error reported, got %+v", results[2])
+		}
+		if results[3].Err != nil {
+			t.Errorf("Expected metrics stopped after the failures, got %v", results[3].Err)
+		}
+	})
+
+	t.Run("shares unused time with later stages", func(t *testing.T) {
+		var budgets []time.Duration
+		stage := func(name string) Stage {
+			return Stage{Name: name, Stop: func(ctx context.Context) error {
+				deadline, _ := ctx.Deadline()
+				budgets = append(budgets, time.Until(deadline))
+				return nil
+			}}
+		}
+
+		NewSequence(time.Second, stage("a"), stage("b")).Run(context.Background())
+
+		if budgets[0] > 510*time.Millisecond || budgets[1] < 900*time.Millisecond {
+			t.Errorf("Expected half the grace period, then what the first stage left, got %v", budgets)
+		}
+	})
+
+	t.Run("honors explicit stage timeouts", func(t *testing.T) {
+		stage := Stage{Name: StageSnapshot, Timeout: 20 * time.Millisecond, Stop: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}}
+
+		results := NewSequence(time.Second, stage).Run(context.Background())
+
+		if !results[0].TimedOut || results[0].Duration > 200*time.Millisecond {
+			t.Errorf("Expected the stage cut off after 20ms, got %+v", results[0])
+		}
+	})
+}
+
+// TestIsStage validates recognizing stage names.
+func TestIsStage(t *testing.T) {
+	if !IsStage(StageEmitters) || IsStage("database") {
+		t.Error("Expected only the daemon's stage names recognized")
+	}
+}
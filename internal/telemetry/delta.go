@@ -0,0 +1,132 @@
+package telemetry
+
+import (
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// DeltaMetadataKey marks an entry whose value is the difference from the
+// previously emitted value of its series instead of the absolute value.
+const DeltaMetadataKey = "delta"
+
+// CounterResetMetadataKey marks a delta entry whose counter decreased, e.g.
+// after a process restart; its value is the absolute value counted since the reset.
+const CounterResetMetadataKey = "counter_reset"
+
+// deltaSeries is the last emitted value of one name and tag set.
+type deltaSeries struct {
+	value     float64
+	timestamp time.Time
+}
+
+// DeltaEncoder rewrites cumulative counters as the difference from the value
+// previously emitted for the same name and tags, which keeps exports and
+// forwarded telemetry small when counters are shipped repeatedly. It is safe
+// for concurrent use and keeps its state across calls, so each output carries
+// only what changed since the last one.
+type DeltaEncoder struct {
+	// mutex protects series
+	mutex sync.Mutex
+	// patterns are the glob patterns, as matched by path.Match, of the
+	// counter names that are encoded
+	patterns []string
+	// series holds the last emitted value of each encoded series
+	series map[string]deltaSeries
+}
+
+// NewDeltaEncoder creates an encoder for numeric entries whose name matches
+// one of the glob patterns, e.g. "*_total".
+func NewDeltaEncoder(patterns []string) *DeltaEncoder {
+	return &DeltaEncoder{patterns: patterns, series: make(map[string]deltaSeries)}
+}
+
+// Encode returns the entries with the values of matching counters replaced by
+// their delta, marked with DeltaMetadataKey in a copy of their metadata. The
+// first entry of a series, and entries not newer than the series' last emitted
+// one, e.g. an overlapping snapshot window, keep their absolute value and are
+// not marked. When a counter decreases, the entry is marked with
+// CounterResetMetadataKey and carries its absolute value, the count since the
+// reset. Other entries are returned unchanged; the input is not modified.
+func (de *DeltaEncoder) Encode(entries []types.TelemetryEntry) []types.TelemetryEntry {
+	de.mutex.Lock()
+	defer de.mutex.Unlock()
+
+	result := make([]types.TelemetryEntry, len(entries))
+	for i, entry := range entries {
+		result[i] = entry
+		if !de.matches(entry.Name) {
+			continue
+		}
+		value, ok := Float64Value(entry.Value)
+		if !ok {
+			continue
+		}
+
+		key := deltaKey(entry.Name, entry.Tags)
+		previous, seen := de.series[key]
+		if seen && !entry.Timestamp.After(previous.timestamp) {
+			continue
+		}
+		de.series[key] = deltaSeries{value: value, timestamp: entry.Timestamp}
+		if !seen {
+			continue
+		}
+
+		metadata := make(map[string]interface{}, len(entry.Metadata)+2)
+		for k, v := range entry.Metadata {
+			metadata[k] = v
+		}
+		metadata[DeltaMetadataKey] = true
+		if value < previous.value {
+			metadata[CounterResetMetadataKey] = true
+			result[i].Value = value
+		} else {
+			result[i].Value = value - previous.value
+		}
+		result[i].Metadata = metadata
+	}
+	return result
+}
+
+// matches reports whether the name matches one of the encoder's patterns.
+func (de *DeltaEncoder) matches(name string) bool {
+	for _, pattern := range de.patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDelta reports whether the entry's value was encoded as a delta.
+func IsDelta(entry types.TelemetryEntry) bool {
+	delta, _ := entry.Metadata[DeltaMetadataKey].(bool)
+	return delta
+}
+
+// deltaKey identifies a series by its name and sorted tags.
+func deltaKey(name string, tags map[string]string) string {
+	if len(tags) == 0 {
+		return name
+	}
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, key := range keys {
+		b.WriteByte(0)
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(tags[key])
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestDeltaEncoder validates encoding counters as deltas between emits.
+func TestDeltaEncoder(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	counter := func(offset int, name string, value interface{}, tags map[string]string) types.TelemetryEntry {
+		return types.TelemetryEntry{
+			Timestamp: base.Add(time.Duration(offset) * time.Second),
+			Source:    types.SourceSystem,
+			Type:      types.TypeNetwork,
+			Name:      name,
+			Value:     value,
+			Tags:      tags,
+		}
+	}
+
+	t.Run("computes deltas per series", func(t *testing.T) {
+		encoder := NewDeltaEncoder([]string{"*_total"})
+		eth0 := map[string]string{"interface": "eth0"}
+		eth1 := map[string]string{"interface": "eth1"}
+
+		entries := encoder.Encode([]types.TelemetryEntry{
+			counter(0, "network_rx_bytes_total", 1000, eth0),
+			counter(0, "network_rx_bytes_total", 5000, eth1),
+			counter(1, "network_rx_bytes_total", 1250, eth0),
+			counter(1, "network_rx_bytes_total", 5100, eth1),
+			counter(1, "cpu_usage_percent", 42.5, nil),
+		})
+
+		if entries[0].Value != 1000 || IsDelta(entries[0]) {
+			t.Errorf("Expected the first value kept absolute, got %v (delta %v)", entries[0].Value, IsDelta(entries[0]))
+		}
+		if entries[2].Value != 250.0 || !IsDelta(entries[2]) {
+			t.Errorf("Expected eth0 delta 250, got %v (delta %v)", entries[2].Value, IsDelta(entries[2]))
+		}
+		if entries[3].Value != 100.0 || !IsDelta(entries[3]) {
+			t.Errorf("Expected eth1 delta 100, got %v (delta %v)", entries[3].Value, IsDelta(entries[3]))
+		}
+		if entries[4].Value != 42.5 || entries[4].Metadata != nil {
+			t.Errorf("Expected gauges unchanged, got %+v", entries[4])
+		}
+	})
+
+	t.Run("continues from the previous emit", func(t *testing.T) {
+		encoder := NewDeltaEncoder([]string{"*_total"})
+		encoder.Encode([]types.TelemetryEntry{counter(0, "requests_total", 10, nil), counter(1, "requests_total", 15, nil)})
+
+		entries := encoder.Encode([]types.TelemetryEntry{
+			counter(1, "requests_total", 15, nil),
+			counter(2, "requests_total", 22, nil),
+		})
+
+		if entries[0].Value != 15 || IsDelta(entries[0]) {
+			t.Errorf("Expected an already emitted entry kept absolute, got %v (delta %v)", entries[0].Value, IsDelta(entries[0]))
+		}
+		if entries[1].Value != 7.0 || !IsDelta(entries[1]) {
+			t.Errorf("Expected delta 7 from the previous emit, got %v", entries[1].Value)
+		}
+	})
+
+	t.Run("handles counter resets", func(t *testing.T) {
+		encoder := NewDeltaEncoder([]string{"*_total"})
+
+		entries := encoder.Encode([]types.TelemetryEntry{
+			counter(0, "requests_total", 900, nil),
+			counter(1, "requests_total", 30, nil),
+			counter(2, "requests_total", 45, nil),
+		})
+
+		if entries[1].Value != 30.0 || !IsDelta(entries[1]) || entries[1].Metadata[CounterResetMetadataKey] != true {
+			t.Errorf("Expected the count since the reset marked as a reset, got %v and %v", entries[1].Value, entries[1].Metadata)
+		}
+		if entries[2].Value != 15.0 || entries[2].Metadata[CounterResetMetadataKey] != nil {
+			t.Errorf("Expected delta 15 after the reset, got %v and %v", entries[2].Value, entries[2].Metadata)
+		}
+	})
+
+	t.Run("does not modify the input", func(t *testing.T) {
+		encoder := NewDeltaEncoder([]string{"*_total"})
+		input := []types.TelemetryEntry{
+			counter(0, "requests_total", 1, nil),
+			counter(1, "requests_total", 3, nil),
+		}
+		input[1].Metadata = map[string]interface{}{"clock_offset": "2s"}
+
+		entries := encoder.Encode(input)
+
+		if input[1].Value != 3 || len(input[1].Metadata) != 1 {
+			t.Errorf("Expected the input untouched, got %+v", input[1])
+		}
+		if entries[1].Metadata["clock_offset"] != "2s" {
+			t.Errorf("Expected existing metadata kept, got %v", entries[1].Metadata)
+		}
+	})
+}
@@ -0,0 +1,256 @@
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// IncidentDiskFull identifies incidents raised when a filesystem runs out of
+// space or inodes, a leading cause of pod crashes.
+const IncidentDiskFull types.IncidentType = "disk_full"
+
+// FilesystemStats describes the capacity of a mounted filesystem.
+type FilesystemStats struct {
+	// TotalBytes is the size of the filesystem
+	TotalBytes uint64
+	// FreeBytes is the space not used by any file
+	FreeBytes uint64
+	// AvailableBytes is the space available to unprivileged users
+	AvailableBytes uint64
+	// TotalInodes is the number of inodes on the filesystem
+	TotalInodes uint64
+	// FreeInodes is the number of unused inodes
+	FreeInodes uint64
+}
+
+// UsedPercent returns the used space as a percentage, computed like df: used
+// blocks relative to the space usable by unprivileged users.
+func (fs FilesystemStats) UsedPercent() float64 {
+	used := fs.TotalBytes - fs.FreeBytes
+	if used+fs.AvailableBytes == 0 {
+		return 0
+	}
+	return float64(used) / float64(used+fs.AvailableBytes) * 100
+}
+
+// StatfsFunc returns the capacity of the filesystem mounted at the given path.
+type StatfsFunc func(mount string) (FilesystemStats, error)
+
+// Statfs reads filesystem capacity with the statfs system call.
+func Statfs(mount string) (FilesystemStats, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(mount, &st); err != nil {
+		return FilesystemStats{}, err
+	}
+	blockSize := uint64(st.Bsize)
+	return FilesystemStats{
+		TotalBytes:     st.Blocks * blockSize,
+		FreeBytes:      st.Bfree * blockSize,
+		AvailableBytes: st.Bavail * blockSize,
+		TotalInodes:    st.Files,
+		FreeInodes:     st.Ffree,
+	}, nil
+}
+
+// DiskThreshold configures when a mount is considered full.
+type DiskThreshold struct {
+	// UsedPercent raises an incident once used space reaches this percentage (0 disables)
+	UsedPercent float64
+	// MinFreeInodes raises an incident once free inodes drop below this count (0 disables)
+	MinFreeInodes uint64
+}
+
+// ParseDiskThresholds parses per-mount thresholds of the form
+// "mount=percent[:minFreeInodes]" separated by commas, e.g. "/=90,/var/lib=85:10000".
+func ParseDiskThresholds(spec string) (map[string]DiskThreshold, error) {
+	thresholds := make(map[string]DiskThreshold)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		mount, limits, ok := strings.Cut(item, "=")
+		if !ok || mount == "" {
+			return nil, fmt.Errorf("invalid disk threshold %q: expected mount=percent[:minFreeInodes]", item)
+		}
+
+		var threshold DiskThreshold
+		percent, inodes, hasInodes := strings.Cut(limits, ":")
+		if percent != "" {
+			value, err := strconv.ParseFloat(percent, 64)
+			if err != nil || value < 0 || value > 100 {
+				return nil, fmt.Errorf("invalid used percent for %s: %q", mount, percent)
+			}
+			threshold.UsedPercent = value
+		}
+		if hasInodes {
+			value, err := strconv.ParseUint(inodes, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid free inode floor for %s: %w", mount, err)
+			}
+			threshold.MinFreeInodes = value
+		}
+		if threshold.UsedPercent == 0 && threshold.MinFreeInodes == 0 {
+			return nil, fmt.Errorf("disk threshold for %s sets no limit", mount)
+		}
+
+		thresholds[mount] = threshold
+	}
+	return thresholds, nil
+}
+
+// SpaceConsumer is a path and the space it uses, reported with disk-full incidents.
+type SpaceConsumer struct {
+	Path  string `json:"path"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// DiskFullChecker raises disk-full incidents when a monitored mount crosses its
+// used-space threshold or free-inode floor. Incidents are debounced: a mount
+// reports once when it becomes full and again only after it has recovered.
+type DiskFullChecker struct {
+	// mutex protects breached
+	mutex sync.Mutex
+	// thresholds maps mount points to their limits
+	thresholds map[string]DiskThreshold
+	// report receives raised incidents
+	report func(types.IncidentReport)
+	// breached tracks mounts that have already raised an incident
+	breached map[string]bool
+	// statfs reads filesystem capacity; replaceable for tests
+	statfs StatfsFunc
+	// topConsumers optionally lists the largest space consumers of a mount
+	topConsumers func(mount string) []SpaceConsumer
+	// clock supplies incident timestamps
+	clock clock.Clock
+}
+
+// NewDiskFullChecker creates a checker for the given per-mount thresholds that
+// passes incidents to report.
+func NewDiskFullChecker(thresholds map[string]DiskThreshold, report func(types.IncidentReport)) *DiskFullChecker {
+	return &DiskFullChecker{
+		thresholds: thresholds,
+		report:     report,
+		breached:   make(map[string]bool),
+		statfs:     Statfs,
+		clock:      clock.Real{},
+	}
+}
+
+// SetTopConsumers sets a function listing the largest space consumers of a
+// mount, included in incident context when available.
+func (dc *DiskFullChecker) SetTopConsumers(topConsumers func(mount string) []SpaceConsumer) {
+	dc.topConsumers = topConsumers
+}
+
+// Start checks all mounts on the given interval until the context is cancelled.
+func (dc *DiskFullChecker) Start(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	dc.Check()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			dc.Check()
+		}
+	}
+}
+
+// Check evaluates every monitored mount once, raising incidents for mounts that
+// have newly become full. Mounts that cannot be read are skipped.
+func (dc *DiskFullChecker) Check() {
+	mounts := make([]string, 0, len(dc.thresholds))
+	for mount := range dc.thresholds {
+		mounts = append(mounts, mount)
+	}
+	sort.Strings(mounts)
+
+	for _, mount := range mounts {
+		stats, err := dc.statfs(mount)
+		if err != nil {
+			fmt.Printf("Error reading filesystem stats for %s: %v\n", mount, err)
+			continue
+		}
+
+		threshold := dc.thresholds[mount]
+		reasons := breaches(stats, threshold)
+
+		dc.mutex.Lock()
+		alreadyBreached := dc.breached[mount]
+		dc.breached[mount] = len(reasons) > 0
+		dc.mutex.Unlock()
+
+		if len(reasons) > 0 && !alreadyBreached {
+			dc.report(dc.buildIncident(mount, stats, threshold, reasons))
+		}
+	}
+}
+
+// breaches returns a description of each limit the filesystem violates.
+func breaches(stats FilesystemStats, threshold DiskThreshold) []string {
+	var reasons []string
+	if threshold.UsedPercent > 0 && stats.UsedPercent() >= threshold.UsedPercent {
+		reasons = append(reasons, fmt.Sprintf("%.1f%% used (threshold %.1f%%)", stats.UsedPercent(), threshold.UsedPercent))
+	}
+	if threshold.MinFreeInodes > 0 && stats.TotalInodes > 0 && stats.FreeInodes < threshold.MinFreeInodes {
+		reasons = append(reasons, fmt.Sprintf("%d free inodes (floor %d)", stats.FreeInodes, threshold.MinFreeInodes))
+	}
+	return reasons
+}
+
+// buildIncident creates the incident report for a full mount.
+func (dc *DiskFullChecker) buildIncident(mount string, stats FilesystemStats, threshold DiskThreshold, reasons []string) types.IncidentReport {
+	now := dc.clock.Now()
+
+	severity := types.SeverityHigh
+	if stats.AvailableBytes == 0 || (stats.TotalInodes > 0 && stats.FreeInodes == 0) {
+		severity = types.SeverityCritical
+	}
+
+	context := map[string]interface{}{
+		"mount":           mount,
+		"used_percent":    stats.UsedPercent(),
+		"total_bytes":     stats.TotalBytes,
+		"available_bytes": stats.AvailableBytes,
+		"total_inodes":    stats.TotalInodes,
+		"free_inodes":     stats.FreeInodes,
+	}
+	if threshold.UsedPercent > 0 {
+		context["threshold_percent"] = threshold.UsedPercent
+	}
+	if threshold.MinFreeInodes > 0 {
+		context["min_free_inodes"] = threshold.MinFreeInodes
+	}
+	if dc.topConsumers != nil {
+		if consumers := dc.topConsumers(mount); len(consumers) > 0 {
+			context["top_consumers"] = consumers
+		}
+	}
+
+	name := strings.Trim(strings.ReplaceAll(mount, "/", "-"), "-")
+	if name == "" {
+		name = "root"
+	}
+
+	return types.IncidentReport{
+		ID:        fmt.Sprintf("disk-full-%s-%d", name, now.Unix()),
+		Timestamp: now,
+		Severity:  severity,
+		Type:      IncidentDiskFull,
+		Message:   fmt.Sprintf("Filesystem %s is full: %s", mount, strings.Join(reasons, ", ")),
+		Context:   context,
+	}
+}
@@ -0,0 +1,184 @@
+package telemetry
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// fakeStatfs returns synthetic filesystem stats per mount.
+type fakeStatfs map[string]FilesystemStats
+
+// statfs implements StatfsFunc over the synthetic stats.
+func (f fakeStatfs) statfs(mount string) (FilesystemStats, error) {
+	stats, ok := f[mount]
+	if !ok {
+		return FilesystemStats{}, fmt.Errorf("no such mount: %s", mount)
+	}
+	return stats, nil
+}
+
+// usage builds stats for a 100 GiB filesystem with the given used percentage and free inodes.
+func usage(usedPercent float64, freeInodes uint64) FilesystemStats {
+	const total = 100 << 30
+	free := uint64(float64(total) * (100 - usedPercent) / 100)
+	return FilesystemStats{
+		TotalBytes:     total,
+		FreeBytes:      free,
+		AvailableBytes: free,
+		TotalInodes:    1000000,
+		FreeInodes:     freeInodes,
+	}
+}
+
+// newTestDiskFullChecker returns a checker over synthetic stats that records incidents.
+func newTestDiskFullChecker(thresholds map[string]DiskThreshold, stats fakeStatfs) (*DiskFullChecker, *[]types.IncidentReport) {
+	var reports []types.IncidentReport
+	checker := NewDiskFullChecker(thresholds, func(report types.IncidentReport) {
+		reports = append(reports, report)
+	})
+	checker.statfs = stats.statfs
+	checker.clock = clock.NewFake(time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC))
+	return checker, &reports
+}
+
+// TestDiskFullChecker validates disk-full incidents from synthetic statfs results.
+func TestDiskFullChecker(t *testing.T) {
+	t.Run("raises incident over used threshold", func(t *testing.T) {
+		stats := fakeStatfs{"/": usage(95, 500000)}
+		checker, reports := newTestDiskFullChecker(map[string]DiskThreshold{"/": {UsedPercent: 90}}, stats)
+		checker.SetTopConsumers(func(mount string) []SpaceConsumer {
+			return []SpaceConsumer{{Path: "/var/log", Bytes: 40 << 30}}
+		})
+
+		checker.Check()
+
+		if len(*reports) != 1 {
+			t.Fatalf("Expected 1 incident, got %d", len(*reports))
+		}
+		report := (*reports)[0]
+		if report.Type != IncidentDiskFull || report.Severity != types.SeverityHigh {
+			t.Errorf("Expected high disk_full incident, got %s %s", report.Severity, report.Type)
+		}
+		if report.Context["mount"] != "/" {
+			t.Errorf("Expected mount '/', got %v", report.Context["mount"])
+		}
+		if used := report.Context["used_percent"].(float64); used < 94.9 || used > 95.1 {
+			t.Errorf("Expected used percent ~95, got %v", used)
+		}
+		if consumers, ok := report.Context["top_consumers"].([]SpaceConsumer); !ok || consumers[0].Path != "/var/log" {
+			t.Errorf("Expected top consumers in context, got %v", report.Context["top_consumers"])
+		}
+		if report.ID != "disk-full-root-1730559845" {
+			t.Errorf("Expected ID 'disk-full-root-1730559845', got %q", report.ID)
+		}
+	})
+
+	t.Run("raises incident under free inode floor", func(t *testing.T) {
+		stats := fakeStatfs{"/var/lib": usage(10, 50)}
+		checker, reports := newTestDiskFullChecker(map[string]DiskThreshold{"/var/lib": {UsedPercent: 90, MinFreeInodes: 1000}}, stats)
+
+		checker.Check()
+
+		if len(*reports) != 1 {
+			t.Fatalf("Expected 1 incident, got %d", len(*reports))
+		}
+		if (*reports)[0].Context["free_inodes"] != uint64(50) {
+			t.Errorf("Expected 50 free inodes, got %v", (*reports)[0].Context["free_inodes"])
+		}
+	})
+
+	t.Run("escalates to critical when exhausted", func(t *testing.T) {
+		stats := fakeStatfs{"/": usage(100, 500000)}
+		checker, reports := newTestDiskFullChecker(map[string]DiskThreshold{"/": {UsedPercent: 90}}, stats)
+
+		checker.Check()
+
+		if len(*reports) != 1 || (*reports)[0].Severity != types.SeverityCritical {
+			t.Errorf("Expected critical incident, got %v", *reports)
+		}
+	})
+
+	t.Run("debounces until recovery", func(t *testing.T) {
+		stats := fakeStatfs{"/": usage(95, 500000)}
+		checker, reports := newTestDiskFullChecker(map[string]DiskThreshold{"/": {UsedPercent: 90}}, stats)
+
+		checker.Check()
+		checker.Check()
+		if len(*reports) != 1 {
+			t.Fatalf("Expected 1 incident while still full, got %d", len(*reports))
+		}
+
+		stats["/"] = usage(50, 500000)
+		checker.Check()
+		stats["/"] = usage(92, 500000)
+		checker.Check()
+		if len(*reports) != 2 {
+			t.Errorf("Expected a new incident after recovery, got %d", len(*reports))
+		}
+	})
+
+	t.Run("applies thresholds per mount", func(t *testing.T) {
+		stats := fakeStatfs{"/": usage(85, 500000), "/data": usage(85, 500000)}
+		checker, reports := newTestDiskFullChecker(map[string]DiskThreshold{
+			"/":     {UsedPercent: 90},
+			"/data": {UsedPercent: 80},
+		}, stats)
+
+		checker.Check()
+
+		if len(*reports) != 1 || (*reports)[0].Context["mount"] != "/data" {
+			t.Errorf("Expected only /data to be reported, got %v", *reports)
+		}
+	})
+
+	t.Run("skips unreadable mounts", func(t *testing.T) {
+		checker, reports := newTestDiskFullChecker(map[string]DiskThreshold{"/missing": {UsedPercent: 90}}, fakeStatfs{})
+
+		checker.Check()
+
+		if len(*reports) != 0 {
+			t.Errorf("Expected no incidents, got %d", len(*reports))
+		}
+	})
+}
+
+// TestParseDiskThresholds validates per-mount threshold configuration parsing.
+func TestParseDiskThresholds(t *testing.T) {
+	t.Run("parses percent and inode floors", func(t *testing.T) {
+		thresholds, err := ParseDiskThresholds("/=90, /var/lib=85:10000, /tmp=:500")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if thresholds["/"].UsedPercent != 90 {
+			t.Errorf("Expected / at 90%%, got %v", thresholds["/"])
+		}
+		if thresholds["/var/lib"] != (DiskThreshold{UsedPercent: 85, MinFreeInodes: 10000}) {
+			t.Errorf("Expected /var/lib at 85%% and 10000 inodes, got %v", thresholds["/var/lib"])
+		}
+		if thresholds["/tmp"] != (DiskThreshold{MinFreeInodes: 500}) {
+			t.Errorf("Expected /tmp inode floor only, got %v", thresholds["/tmp"])
+		}
+	})
+
+	t.Run("rejects malformed entries", func(t *testing.T) {
+		for _, spec := range []string{"/", "=90", "/=abc", "/=120", "/=90:x", "/=:"} {
+			if _, err := ParseDiskThresholds(spec); err == nil {
+				t.Errorf("Expected error for %q", spec)
+			}
+		}
+	})
+
+	t.Run("reads real filesystem", func(t *testing.T) {
+		stats, err := Statfs("/")
+		if err != nil {
+			t.Skipf("statfs unavailable: %v", err)
+		}
+		if stats.TotalBytes == 0 || stats.UsedPercent() < 0 || stats.UsedPercent() > 100 {
+			t.Errorf("Expected plausible stats for /, got %+v", stats)
+		}
+	})
+}
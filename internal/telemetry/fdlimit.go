@@ -0,0 +1,280 @@
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// IncidentFDExhaustion identifies incidents raised when a process nears its
+// open file descriptor limit, after which it fails in confusing ways such as
+// refused connections or unreadable config files.
+const IncidentFDExhaustion types.IncidentType = "fd_exhaustion"
+
+// Names of the file descriptor metrics. The daemon's own metrics are
+// unprefixed; those of tracked processes carry the "process_" prefix and the
+// process tags, next to process_open_fds.
+const (
+	// FDUsageMetric is the daemon's open descriptors as a percentage of its soft limit
+	FDUsageMetric = "fd_usage_percent"
+	// ProcessFDUsageMetric is a tracked process's open descriptors as a percentage of its soft limit
+	ProcessFDUsageMetric = "process_fd_usage_percent"
+)
+
+// FDUsage is a process's open file descriptors and its limits.
+type FDUsage struct {
+	// Open is the number of open file descriptors
+	Open int
+	// SoftLimit is the enforced limit (0 when unlimited)
+	SoftLimit uint64
+	// HardLimit is the ceiling the soft limit may be raised to (0 when unlimited)
+	HardLimit uint64
+}
+
+// UsedPercent returns the open descriptors as a percentage of the soft limit,
+// or false when the soft limit is unlimited.
+func (u FDUsage) UsedPercent() (float64, bool) {
+	if u.SoftLimit == 0 {
+		return 0, false
+	}
+	return float64(u.Open) / float64(u.SoftLimit) * 100, true
+}
+
+// ReadFDUsage reads the open descriptors (<procRoot>/<pid>/fd) and limits
+// (<procRoot>/<pid>/limits) of a process, where pid may be "self".
+func ReadFDUsage(procRoot, pid string) (FDUsage, error) {
+	data, err := os.ReadFile(filepath.Join(procRoot, pid, "limits"))
+	if err != nil {
+		return FDUsage{}, err
+	}
+	usage, err := parseFDLimits(string(data))
+	if err != nil {
+		return FDUsage{}, fmt.Errorf("%s/limits: %w", pid, err)
+	}
+
+	fds, err := os.ReadDir(filepath.Join(procRoot, pid, "fd"))
+	if err != nil {
+		return FDUsage{}, err
+	}
+	usage.Open = len(fds)
+	return usage, nil
+}
+
+// parseFDLimits returns the limits from the "Max open files" line of
+// /proc/<pid>/limits, e.g. "Max open files  1024  1048576  files".
+func parseFDLimits(limits string) (FDUsage, error) {
+	for _, line := range strings.Split(limits, "\n") {
+		rest, ok := strings.CutPrefix(line, "Max open files")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rest)
+		if len(fields) < 2 {
+			return FDUsage{}, fmt.Errorf("invalid open files limit %q", line)
+		}
+		soft, err := parseLimit(fields[0])
+		if err != nil {
+			return FDUsage{}, err
+		}
+		hard, err := parseLimit(fields[1])
+		if err != nil {
+			return FDUsage{}, err
+		}
+		return FDUsage{SoftLimit: soft, HardLimit: hard}, nil
+	}
+	return FDUsage{}, fmt.Errorf("no open files limit")
+}
+
+// parseLimit parses a resource limit, returning 0 for "unlimited".
+func parseLimit(value string) (uint64, error) {
+	if value == "unlimited" {
+		return 0, nil
+	}
+	limit, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid limit %q", value)
+	}
+	return limit, nil
+}
+
+// fdEntries returns the limit and usage entries of a process with the given
+// metric name prefix. Open descriptors are reported by the caller, and
+// unlimited limits are left out.
+func fdEntries(prefix string, usage FDUsage, timestamp time.Time, tags map[string]string) []types.TelemetryEntry {
+	entry := func(name string, value interface{}) types.TelemetryEntry {
+		return types.TelemetryEntry{
+			Timestamp: timestamp,
+			Source:    types.SourceSystem,
+			Type:      types.TypeProcess,
+			Name:      prefix + name,
+			Value:     value,
+			Tags:      tags,
+		}
+	}
+
+	var entries []types.TelemetryEntry
+	if usage.SoftLimit > 0 {
+		entries = append(entries, entry("fd_soft_limit", usage.SoftLimit))
+	}
+	if usage.HardLimit > 0 {
+		entries = append(entries, entry("fd_hard_limit", usage.HardLimit))
+	}
+	if percent, ok := usage.UsedPercent(); ok {
+		entries = append(entries, entry("fd_usage_percent", percent))
+	}
+	return entries
+}
+
+// fdSeries tracks the descriptor usage of the daemon or one tracked process.
+type fdSeries struct {
+	// softLimit is the last soft limit seen (nil until reported)
+	softLimit interface{}
+	// open is the last open descriptor count seen (nil until reported)
+	open interface{}
+	// reported records that the current breach has already raised an incident
+	reported bool
+	// seen is when the series was last sampled
+	seen time.Time
+}
+
+// FDUsageDetector raises fd_exhaustion incidents when the daemon or a tracked
+// process uses more than a percentage of its soft descriptor limit. It sits
+// in front of the telemetry buffer, forwarding every entry, and watches the
+// fd_usage_percent metrics. Incidents are debounced: a process reports once
+// when it crosses the threshold and again only after dropping below it.
+type FDUsageDetector struct {
+	// mutex protects series
+	mutex sync.Mutex
+	// threshold is the usage percentage that raises an incident
+	threshold float64
+	// next receives every entry passed to Add (nil discards them)
+	next TelemetryBuffer
+	// report receives raised incidents
+	report func(types.IncidentReport)
+	// series maps the daemon and tracked processes to their usage
+	series map[string]*fdSeries
+}
+
+// fdSeriesExpiry is how long an unsampled series is kept, so exited processes
+// do not accumulate.
+const fdSeriesExpiry = 10 * time.Minute
+
+// NewFDUsageDetector creates a detector raising incidents at threshold percent
+// of the soft limit that forwards entries to next and passes incidents to report.
+func NewFDUsageDetector(threshold float64, next TelemetryBuffer, report func(types.IncidentReport)) *FDUsageDetector {
+	return &FDUsageDetector{
+		threshold: threshold,
+		next:      next,
+		report:    report,
+		series:    make(map[string]*fdSeries),
+	}
+}
+
+// Add forwards the entry and checks descriptor usage against the threshold.
+// It returns the error of the next buffer, if any.
+func (fd *FDUsageDetector) Add(entry types.TelemetryEntry) error {
+	var err error
+	if fd.next != nil {
+		err = fd.next.Add(entry)
+	}
+
+	var prefix string
+	switch entry.Name {
+	case FDUsageMetric, "fd_soft_limit", "fd_open":
+	case ProcessFDUsageMetric, "process_fd_soft_limit", "process_open_fds":
+		prefix = "process_"
+	default:
+		return err
+	}
+	if report, raised := fd.observe(prefix, entry); raised {
+		fd.report(report)
+	}
+	return err
+}
+
+// observe records an entry of a series and returns an incident when its usage
+// newly crosses the threshold.
+func (fd *FDUsageDetector) observe(prefix string, entry types.TelemetryEntry) (types.IncidentReport, bool) {
+	fd.mutex.Lock()
+	defer fd.mutex.Unlock()
+
+	key := prefix + tagKey(entry.Tags)
+	series, ok := fd.series[key]
+	if !ok {
+		for k, s := range fd.series {
+			if entry.Timestamp.Sub(s.seen) > fdSeriesExpiry {
+				delete(fd.series, k)
+			}
+		}
+		series = &fdSeries{}
+		fd.series[key] = series
+	}
+	series.seen = entry.Timestamp
+
+	switch strings.TrimPrefix(entry.Name, prefix) {
+	case "fd_soft_limit":
+		series.softLimit = entry.Value
+		return types.IncidentReport{}, false
+	case "fd_open", "open_fds":
+		series.open = entry.Value
+		return types.IncidentReport{}, false
+	}
+
+	percent, ok := Float64Value(entry.Value)
+	if !ok {
+		return types.IncidentReport{}, false
+	}
+	if percent < fd.threshold {
+		series.reported = false
+		return types.IncidentReport{}, false
+	}
+	if series.reported {
+		return types.IncidentReport{}, false
+	}
+	series.reported = true
+	return fd.buildIncident(series, entry, percent), true
+}
+
+// buildIncident creates the fd_exhaustion report for a series over the threshold.
+func (fd *FDUsageDetector) buildIncident(series *fdSeries, entry types.TelemetryEntry, percent float64) types.IncidentReport {
+	process, pid := "blackbox-daemon", strconv.Itoa(os.Getpid())
+	if entry.Name == ProcessFDUsageMetric {
+		process, pid = entry.Tags["comm"], entry.Tags["pid"]
+	}
+
+	context := map[string]interface{}{
+		"process":           process,
+		"pid":               pid,
+		"usage_percent":     percent,
+		"threshold_percent": fd.threshold,
+	}
+	if series.open != nil {
+		context["open_fds"] = series.open
+	}
+	if series.softLimit != nil {
+		context["soft_limit"] = series.softLimit
+	}
+	if len(entry.Tags) > 0 {
+		context["tags"] = entry.Tags
+	}
+
+	severity := types.SeverityHigh
+	if percent >= 100 {
+		severity = types.SeverityCritical
+	}
+
+	return types.IncidentReport{
+		ID:        fmt.Sprintf("fd-exhaustion-%s-%s-%d", process, pid, entry.Timestamp.Unix()),
+		Timestamp: entry.Timestamp,
+		Severity:  severity,
+		Type:      IncidentFDExhaustion,
+		Message:   fmt.Sprintf("Process %s (pid %s) uses %.1f%% of its open file limit (threshold %.1f%%)", process, pid, percent, fd.threshold),
+		Context:   context,
+	}
+}
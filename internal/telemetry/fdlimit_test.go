@@ -0,0 +1,210 @@
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// limitsFile is /proc/<pid>/limits with the given open files limits.
+func limitsFile(soft, hard string) string {
+	return "Limit                     Soft Limit           Hard Limit           Units     \n" +
+		"Max cpu time              unlimited            unlimited            seconds   \n" +
+		"Max processes             63432                63432                processes \n" +
+		"Max open files            " + soft + "                 " + hard + "              files     \n" +
+		"Max locked memory         8388608              8388608              bytes     \n"
+}
+
+// writeFDFixture creates <root>/<pid>/limits and open descriptors under <root>/<pid>/fd.
+func writeFDFixture(t *testing.T, root, pid, limits string, open int) {
+	t.Helper()
+	dir := filepath.Join(root, pid)
+	if err := os.MkdirAll(filepath.Join(dir, "fd"), 0755); err != nil {
+		t.Fatalf("Failed to create process dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "limits"), []byte(limits), 0644); err != nil {
+		t.Fatalf("Failed to write limits: %v", err)
+	}
+	for fd := 0; fd < open; fd++ {
+		os.WriteFile(filepath.Join(dir, "fd", strconv.Itoa(fd)), nil, 0644)
+	}
+}
+
+// TestReadFDUsage validates reading descriptor limits and usage from a synthetic proc root.
+func TestReadFDUsage(t *testing.T) {
+	t.Run("reads limits and open descriptors", func(t *testing.T) {
+		root := t.TempDir()
+		writeFDFixture(t, root, "self", limitsFile("1024", "1048576"), 256)
+
+		usage, err := ReadFDUsage(root, "self")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if usage.Open != 256 || usage.SoftLimit != 1024 || usage.HardLimit != 1048576 {
+			t.Errorf("Expected 256 open of 1024/1048576, got %+v", usage)
+		}
+		if percent, ok := usage.UsedPercent(); !ok || percent != 25 {
+			t.Errorf("Expected 25%% used, got %v", percent)
+		}
+	})
+
+	t.Run("treats unlimited as no limit", func(t *testing.T) {
+		root := t.TempDir()
+		writeFDFixture(t, root, "self", limitsFile("unlimited", "unlimited"), 3)
+
+		usage, err := ReadFDUsage(root, "self")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, ok := usage.UsedPercent(); ok || usage.SoftLimit != 0 {
+			t.Errorf("Expected no usage percent without a limit, got %+v", usage)
+		}
+		if entries := fdEntries("", usage, time.Now(), nil); len(entries) != 0 {
+			t.Errorf("Expected no limit entries, got %+v", entries)
+		}
+	})
+
+	t.Run("rejects limits without open files", func(t *testing.T) {
+		root := t.TempDir()
+		writeFDFixture(t, root, "self", "Limit  Soft Limit  Hard Limit  Units\n", 0)
+
+		if _, err := ReadFDUsage(root, "self"); err == nil {
+			t.Error("Expected an error for a missing open files limit")
+		}
+	})
+}
+
+// TestFDLimitCollection validates collecting descriptor limits of the daemon
+// and tracked processes.
+func TestFDLimitCollection(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("collects the daemon's usage", func(t *testing.T) {
+		root := t.TempDir()
+		writeFDFixture(t, root, "self", limitsFile("1024", "4096"), 512)
+		buffer := &mockTelemetryBuffer{}
+		collector := NewSystemCollector(time.Second, buffer)
+		collector.procRoot = root
+		collector.SetFDLimits(true)
+
+		collector.collectFDLimits(base)
+
+		values := make(map[string]interface{})
+		for _, entry := range buffer.entries {
+			values[entry.Name] = entry.Value
+		}
+		if values["fd_open"] != 512 || values["fd_soft_limit"] != uint64(1024) || values["fd_hard_limit"] != uint64(4096) || values[FDUsageMetric] != 50.0 {
+			t.Errorf("Expected 512 of 1024/4096 open at 50%%, got %v", values)
+		}
+	})
+
+	t.Run("collects tracked processes' usage", func(t *testing.T) {
+		root := t.TempDir()
+		writeFakeProcess(t, root, fakeProcess{pid: 100, comm: "postgres", fds: 3})
+		writeFDFixture(t, root, "100", limitsFile("4", "8"), 3)
+
+		matchers, _ := ParseProcessMatchers([]string{"postgres"})
+		buffer := &mockTelemetryBuffer{}
+		collector := NewSystemCollector(time.Second, buffer)
+		collector.SetFDLimits(true)
+		collector.SetProcessCollector(NewProcessCollector(root, matchers, 0, buffer))
+
+		if err := collector.processes.Collect(base); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		values := processValues(buffer.entries)
+		if values["100/process_open_fds"] != 3 || values["100/process_fd_soft_limit"] != uint64(4) || values["100/"+ProcessFDUsageMetric] != 75.0 {
+			t.Errorf("Expected 3 of 4 descriptors at 75%%, got %v", values)
+		}
+	})
+
+	t.Run("is off by default", func(t *testing.T) {
+		root := t.TempDir()
+		writeFakeProcess(t, root, fakeProcess{pid: 100, comm: "postgres", fds: 3})
+		writeFDFixture(t, root, "100", limitsFile("4", "8"), 3)
+
+		matchers, _ := ParseProcessMatchers([]string{"postgres"})
+		buffer := &mockTelemetryBuffer{}
+		NewProcessCollector(root, matchers, 0, buffer).Collect(base)
+
+		if _, ok := processValues(buffer.entries)["100/"+ProcessFDUsageMetric]; ok {
+			t.Error("Expected no descriptor limits without the option")
+		}
+	})
+}
+
+// TestFDUsageDetector validates fd_exhaustion incidents from descriptor usage.
+func TestFDUsageDetector(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	tags := map[string]string{"comm": "postgres", "pid": "100", "matcher": "postgres"}
+	sample := func(detector *FDUsageDetector, at time.Time, open int, soft uint64) {
+		usage := FDUsage{Open: open, SoftLimit: soft}
+		detector.Add(types.TelemetryEntry{Timestamp: at, Name: "process_open_fds", Value: open, Tags: tags})
+		for _, entry := range fdEntries("process_", usage, at, tags) {
+			detector.Add(entry)
+		}
+	}
+	newDetector := func() (*FDUsageDetector, *mockTelemetryBuffer, *[]types.IncidentReport) {
+		buffer := &mockTelemetryBuffer{}
+		var reports []types.IncidentReport
+		detector := NewFDUsageDetector(90, buffer, func(report types.IncidentReport) {
+			reports = append(reports, report)
+		})
+		return detector, buffer, &reports
+	}
+
+	t.Run("raises an incident over the threshold", func(t *testing.T) {
+		detector, buffer, reports := newDetector()
+
+		sample(detector, base, 500, 1024)
+		sample(detector, base.Add(time.Second), 950, 1024)
+
+		if len(buffer.entries) != 6 {
+			t.Errorf("Expected all entries forwarded, got %d", len(buffer.entries))
+		}
+		if len(*reports) != 1 {
+			t.Fatalf("Expected 1 incident, got %d", len(*reports))
+		}
+		report := (*reports)[0]
+		if report.Type != IncidentFDExhaustion || report.Severity != types.SeverityHigh {
+			t.Errorf("Expected high fd_exhaustion incident, got %s %s", report.Severity, report.Type)
+		}
+		if report.Context["process"] != "postgres" || report.Context["open_fds"] != 950 || report.Context["soft_limit"] != uint64(1024) {
+			t.Errorf("Expected process, open descriptors and limit in context, got %v", report.Context)
+		}
+		if report.ID != "fd-exhaustion-postgres-100-1730559846" {
+			t.Errorf("Expected ID 'fd-exhaustion-postgres-100-1730559846', got %q", report.ID)
+		}
+	})
+
+	t.Run("reports once until usage recovers", func(t *testing.T) {
+		detector, _, reports := newDetector()
+
+		sample(detector, base, 950, 1024)
+		sample(detector, base.Add(time.Second), 1000, 1024)
+		sample(detector, base.Add(2*time.Second), 100, 1024)
+		sample(detector, base.Add(3*time.Second), 1024, 1024)
+
+		if len(*reports) != 2 {
+			t.Fatalf("Expected 2 incidents, got %d", len(*reports))
+		}
+		if (*reports)[1].Severity != types.SeverityCritical {
+			t.Errorf("Expected critical severity at the limit, got %s", (*reports)[1].Severity)
+		}
+	})
+
+	t.Run("reports the daemon itself", func(t *testing.T) {
+		detector, _, reports := newDetector()
+
+		detector.Add(types.TelemetryEntry{Timestamp: base, Name: FDUsageMetric, Value: 95.0})
+
+		if len(*reports) != 1 || (*reports)[0].Context["process"] != "blackbox-daemon" || (*reports)[0].Context["pid"] != strconv.Itoa(os.Getpid()) {
+			t.Errorf("Expected an incident for the daemon, got %+v", *reports)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package telemetry
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// foldedSample is one process_cpu_seconds_total reading of a tracked process.
+type foldedSample struct {
+	// seconds is the process's cumulative CPU time
+	seconds float64
+	// at is when the reading was taken
+	at time.Time
+}
+
+// WriteFoldedCPU writes the CPU time of the tracked processes in entries as
+// folded stacks, one "comm;pid ticks" line per process, the input format of
+// flame-graph tools such as flamegraph.pl, inferno and speedscope. A process's
+// count is the CPU time, in clock ticks, it consumed across its
+// process_cpu_seconds_total samples, so at least two samples are needed.
+// Processes without CPU use are omitted and lines are sorted by stack. It
+// returns the number of stacks written.
+func WriteFoldedCPU(w io.Writer, entries []types.TelemetryEntry) (int, error) {
+	samples := make(map[string][]foldedSample)
+	for _, entry := range entries {
+		if entry.Name != "process_cpu_seconds_total" {
+			continue
+		}
+		seconds, ok := Float64Value(entry.Value)
+		if !ok {
+			continue
+		}
+		stack := foldedFrame(entry.Tags["comm"]) + ";" + foldedFrame(entry.Tags["pid"])
+		samples[stack] = append(samples[stack], foldedSample{seconds: seconds, at: entry.Timestamp})
+	}
+
+	stacks := make([]string, 0, len(samples))
+	for stack := range samples {
+		stacks = append(stacks, stack)
+	}
+	sort.Strings(stacks)
+
+	written := 0
+	for _, stack := range stacks {
+		ticks := cpuTicks(samples[stack])
+		if ticks == 0 {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s %d\n", stack, ticks); err != nil {
+			return written, fmt.Errorf("failed to write folded stack: %w", err)
+		}
+		written++
+	}
+	return written, nil
+}
+
+// cpuTicks returns the CPU time consumed across the samples in clock ticks.
+// A decrease, such as a reused PID, starts counting again from the new value.
+func cpuTicks(samples []foldedSample) int64 {
+	sort.Slice(samples, func(i, j int) bool { return samples[i].at.Before(samples[j].at) })
+
+	var seconds float64
+	for i := 1; i < len(samples); i++ {
+		if delta := samples[i].seconds - samples[i-1].seconds; delta > 0 {
+			seconds += delta
+		}
+	}
+	return int64(math.Round(seconds * clockTicksPerSecond))
+}
+
+// foldedFrame makes a tag value safe as a folded stack frame, which must not
+// contain the ";" frame separator or whitespace.
+func foldedFrame(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == ';' || r == ' ' || r == '\t' || r == '\n' {
+			return '_'
+		}
+		return r
+	}, value)
+}
@@ -0,0 +1,76 @@
+package telemetry
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// processCPUSamples returns synthetic process_cpu_seconds_total samples of a
+// process, one second apart.
+func processCPUSamples(base time.Time, comm, pid string, seconds ...float64) []types.TelemetryEntry {
+	entries := make([]types.TelemetryEntry, 0, len(seconds))
+	for i, value := range seconds {
+		entries = append(entries, types.TelemetryEntry{
+			Timestamp: base.Add(time.Duration(i) * time.Second),
+			Source:    types.SourceSystem,
+			Type:      types.TypeProcess,
+			Name:      "process_cpu_seconds_total",
+			Value:     value,
+			Tags:      map[string]string{"comm": comm, "pid": pid, "matcher": comm},
+		})
+	}
+	return entries
+}
+
+// TestWriteFoldedCPU validates exporting per-process CPU time as folded stacks.
+func TestWriteFoldedCPU(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("folds CPU ticks per process", func(t *testing.T) {
+		var entries []types.TelemetryEntry
+		entries = append(entries, processCPUSamples(base, "nginx", "812", 10.0, 10.5, 11.25)...)
+		entries = append(entries, processCPUSamples(base, "java", "4242", 100.0, 103.0)...)
+		entries = append(entries, processCPUSamples(base, "idle", "77", 3.0, 3.0)...)
+		entries = append(entries, processCPUSamples(base, "single", "5", 9.0)...)
+		entries = append(entries, types.TelemetryEntry{Timestamp: base, Name: "process_rss_bytes", Value: uint64(4096), Tags: map[string]string{"comm": "nginx", "pid": "812"}})
+
+		var out bytes.Buffer
+		written, err := WriteFoldedCPU(&out, entries)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		expected := "java;4242 300\nnginx;812 125\n"
+		if out.String() != expected || written != 2 {
+			t.Errorf("Expected %q (2 stacks), got %q (%d stacks)", expected, out.String(), written)
+		}
+	})
+
+	t.Run("handles unordered samples and reused PIDs", func(t *testing.T) {
+		entries := processCPUSamples(base, "worker", "99", 5.0, 6.0, 0.5, 1.0)
+		entries[0], entries[3] = entries[3], entries[0]
+
+		var out bytes.Buffer
+		if _, err := WriteFoldedCPU(&out, entries); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if out.String() != "worker;99 150\n" {
+			t.Errorf("Expected 150 ticks, got %q", out.String())
+		}
+	})
+
+	t.Run("sanitizes frames", func(t *testing.T) {
+		entries := processCPUSamples(base, "kworker/0:1 events;x", "", 1.0, 2.0)
+
+		var out bytes.Buffer
+		if _, err := WriteFoldedCPU(&out, entries); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if out.String() != "kworker/0:1_events_x;unknown 100\n" {
+			t.Errorf("Expected sanitized stack, got %q", out.String())
+		}
+	})
+}
@@ -0,0 +1,115 @@
+package telemetry
+
+import (
+	"fmt"
+	"math"
+)
+
+// HistogramType is the "type" a sidecar sets on a telemetry value carrying a
+// pre-bucketed histogram instead of a single number.
+const HistogramType = "histogram"
+
+// ValueTypeMetadataKey is the metadata key marking entries whose value is not
+// a plain number or string, e.g. HistogramType for a Histogram value.
+const ValueTypeMetadataKey = "value_type"
+
+// Histogram is a pre-bucketed distribution submitted by a sidecar, e.g. of
+// request latencies:
+//
+//	{"type": "histogram", "buckets": [0.05, 0.1, 0.5], "counts": [120, 30, 8], "count": 161, "sum": 12.7}
+//
+// Counts holds the observations per bucket, each above the previous bound and
+// at most its own, not accumulated across buckets as in Prometheus.
+type Histogram struct {
+	// Type is always HistogramType
+	Type string `json:"type"`
+	// Buckets are the upper bounds of the buckets, strictly increasing
+	Buckets []float64 `json:"buckets"`
+	// Counts are the observations in each bucket
+	Counts []uint64 `json:"counts"`
+	// Count is the number of observations, including those above the last
+	// bound (defaults to the sum of Counts)
+	Count uint64 `json:"count"`
+	// Sum is the sum of the observed values
+	Sum float64 `json:"sum"`
+	// Cumulative reports that the counts, count and sum are totals since the
+	// sidecar started, like a Prometheus histogram, rather than observations
+	// since its previous submission
+	Cumulative bool `json:"cumulative,omitempty"`
+}
+
+// ParseHistogram recognizes a sidecar telemetry value carrying a histogram, a
+// JSON object with "type": "histogram". It returns false for any other value
+// and an error for a histogram that is malformed.
+func ParseHistogram(value interface{}) (Histogram, bool, error) {
+	object, ok := value.(map[string]interface{})
+	if !ok || object["type"] != HistogramType {
+		return Histogram{}, false, nil
+	}
+
+	buckets, ok := object["buckets"].([]interface{})
+	if !ok || len(buckets) == 0 {
+		return Histogram{}, true, fmt.Errorf("histogram needs a list of buckets")
+	}
+	counts, ok := object["counts"].([]interface{})
+	if !ok || len(counts) != len(buckets) {
+		return Histogram{}, true, fmt.Errorf("histogram needs one count per bucket, got %d counts for %d buckets", len(counts), len(buckets))
+	}
+
+	histogram := Histogram{
+		Type:    HistogramType,
+		Buckets: make([]float64, len(buckets)),
+		Counts:  make([]uint64, len(counts)),
+	}
+	var total uint64
+	for i := range buckets {
+		bound, ok := Float64Value(buckets[i])
+		if !ok || math.IsNaN(bound) || (i > 0 && bound <= histogram.Buckets[i-1]) {
+			return Histogram{}, true, fmt.Errorf("histogram buckets must be strictly increasing numbers")
+		}
+		histogram.Buckets[i] = bound
+
+		count, err := histogramCount(counts[i])
+		if err != nil {
+			return Histogram{}, true, err
+		}
+		histogram.Counts[i] = count
+		total += count
+	}
+
+	histogram.Count = total
+	if value, ok := object["count"]; ok {
+		count, err := histogramCount(value)
+		if err != nil {
+			return Histogram{}, true, err
+		}
+		if count < total {
+			return Histogram{}, true, fmt.Errorf("histogram count %d is less than its %d bucketed observations", count, total)
+		}
+		histogram.Count = count
+	}
+	if value, ok := object["sum"]; ok {
+		sum, ok := Float64Value(value)
+		if !ok {
+			return Histogram{}, true, fmt.Errorf("histogram sum must be a number")
+		}
+		histogram.Sum = sum
+	}
+	if value, ok := object["cumulative"]; ok {
+		cumulative, ok := value.(bool)
+		if !ok {
+			return Histogram{}, true, fmt.Errorf("histogram cumulative must be a boolean")
+		}
+		histogram.Cumulative = cumulative
+	}
+	return histogram, true, nil
+}
+
+// histogramCount converts a JSON number to an observation count.
+func histogramCount(value interface{}) (uint64, error) {
+	count, ok := Float64Value(value)
+	if !ok || count < 0 || count != math.Trunc(count) {
+		return 0, fmt.Errorf("histogram counts must be non-negative integers")
+	}
+	return uint64(count), nil
+}
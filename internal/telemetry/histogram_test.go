@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// decodeValue decodes a sidecar telemetry value the way the API server does.
+func decodeValue(t *testing.T, value string) interface{} {
+	t.Helper()
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber()
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		t.Fatalf("Failed to decode %s: %v", value, err)
+	}
+	return decoded
+}
+
+// TestParseHistogram validates recognizing and validating sidecar histograms.
+func TestParseHistogram(t *testing.T) {
+	t.Run("parses a histogram", func(t *testing.T) {
+		histogram, ok, err := ParseHistogram(decodeValue(t, `{"type":"histogram","buckets":[0.05,0.1,0.5],"counts":[120,30,8],"count":161,"sum":12.7,"cumulative":true}`))
+		if err != nil || !ok {
+			t.Fatalf("Expected a histogram, got %v, %v", ok, err)
+		}
+		if len(histogram.Buckets) != 3 || histogram.Buckets[2] != 0.5 || histogram.Counts[0] != 120 {
+			t.Errorf("Expected buckets and counts, got %+v", histogram)
+		}
+		if histogram.Count != 161 || histogram.Sum != 12.7 || !histogram.Cumulative {
+			t.Errorf("Expected count 161, sum 12.7 and cumulative, got %+v", histogram)
+		}
+	})
+
+	t.Run("defaults the count to the bucketed observations", func(t *testing.T) {
+		histogram, _, err := ParseHistogram(decodeValue(t, `{"type":"histogram","buckets":[1,2],"counts":[3,4]}`))
+		if err != nil || histogram.Count != 7 {
+			t.Errorf("Expected count 7, got %d (%v)", histogram.Count, err)
+		}
+	})
+
+	t.Run("ignores other values", func(t *testing.T) {
+		for _, value := range []string{`42`, `"ok"`, `{"type":"summary"}`} {
+			if _, ok, err := ParseHistogram(decodeValue(t, value)); ok || err != nil {
+				t.Errorf("Expected %s not to be a histogram, got %v, %v", value, ok, err)
+			}
+		}
+	})
+
+	t.Run("rejects malformed histograms", func(t *testing.T) {
+		tests := map[string]string{
+			"no buckets":             `{"type":"histogram","counts":[]}`,
+			"mismatched counts":      `{"type":"histogram","buckets":[1,2],"counts":[1]}`,
+			"decreasing buckets":     `{"type":"histogram","buckets":[2,1],"counts":[1,1]}`,
+			"negative count":         `{"type":"histogram","buckets":[1],"counts":[-1]}`,
+			"fractional count":       `{"type":"histogram","buckets":[1],"counts":[1.5]}`,
+			"count below buckets":    `{"type":"histogram","buckets":[1],"counts":[5],"count":4}`,
+			"non-numeric sum":        `{"type":"histogram","buckets":[1],"counts":[5],"sum":"x"}`,
+			"non-boolean cumulative": `{"type":"histogram","buckets":[1],"counts":[5],"cumulative":"yes"}`,
+		}
+		for name, value := range tests {
+			t.Run(name, func(t *testing.T) {
+				if _, ok, err := ParseHistogram(decodeValue(t, value)); !ok || err == nil {
+					t.Errorf("Expected an error for %s, got %v, %v", value, ok, err)
+				}
+			})
+		}
+	})
+}
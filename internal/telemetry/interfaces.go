@@ -0,0 +1,97 @@
+package telemetry
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DefaultExcludedInterfaces are the interfaces left out of network telemetry by
+// default: loopback, the bridges, veth pairs and overlay devices created by
+// common CNI plugins (Calico, Flannel, Cilium, Weave, Antrea, kube-proxy IPVS)
+// and the IPv6 tunnel devices (sit, ip6tnl, ip6gre) present on dual-stack
+// nodes. In pods and on nodes running many pods these dominate /proc/net/dev
+// and drown out the interfaces that carry real traffic, such as eth0.
+var DefaultExcludedInterfaces = []string{
+	"lo",
+	"veth*",
+	"cali*",
+	"tunl*",
+	"vxlan.calico",
+	"vxlan-v6.calico",
+	"flannel*",
+	"cni*",
+	"cilium_*",
+	"lxc*",
+	"weave",
+	"datapath",
+	"vxlan-*",
+	"antrea-*",
+	"genev_sys_*",
+	"kube-ipvs*",
+	"kube-bridge",
+	"docker*",
+	"sit*",
+	"ip6tnl*",
+	"ip6gre*",
+	"ip6_vti*",
+}
+
+// InterfaceFilter selects the network interfaces collected from /proc/net/dev
+// using shell glob patterns (as matched by path.Match).
+type InterfaceFilter struct {
+	// Include limits collection to matching interfaces (empty includes all)
+	Include []string
+	// Exclude drops matching interfaces, taking precedence over Include
+	Exclude []string
+}
+
+// DefaultInterfaceFilter collects every interface except DefaultExcludedInterfaces.
+func DefaultInterfaceFilter() InterfaceFilter {
+	return InterfaceFilter{Exclude: DefaultExcludedInterfaces}
+}
+
+// Allows reports whether telemetry should be collected for the interface.
+func (f InterfaceFilter) Allows(iface string) bool {
+	if matchesAnyPattern(f.Exclude, iface) {
+		return false
+	}
+	return len(f.Include) == 0 || matchesAnyPattern(f.Include, iface)
+}
+
+// matchesAnyPattern reports whether the name matches one of the glob patterns.
+func matchesAnyPattern(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseInterfacePatterns splits a comma-separated list of interface glob
+// patterns, e.g. "eth*,ens*", rejecting malformed patterns.
+func ParseInterfacePatterns(spec string) ([]string, error) {
+	var patterns []string
+	for _, pattern := range strings.Split(spec, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if err := ValidateInterfacePatterns([]string{pattern}); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// ValidateInterfacePatterns checks that every pattern is a valid glob.
+func ValidateInterfacePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid interface pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
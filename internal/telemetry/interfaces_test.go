@@ -0,0 +1,101 @@
+package telemetry
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+// cniNetDev is /proc/net/dev from a Calico/Flannel node running several pods.
+const cniNetDev = `Inter-|   Receive                                                |  Transmit
+ face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed
+    lo: 9837123   81234    0    0    0     0          0         0  9837123   81234    0    0    0     0       0          0
+  eth0: 73456789  512345    0    0    0     0          0         0 12345678  234567    0    0    0     0       0          0
+  ens5: 1234567     9876    0    0    0     0          0         0   765432    5432    0    0    0     0       0          0
+cali4f2a1b3c9d8: 456789 3456 0 0 0 0 0 0 345678 2345 0 0 0 0 0 0
+cali9e8d7c6b5a4: 456789 3456 0 0 0 0 0 0 345678 2345 0 0 0 0 0 0
+tunl0: 1000 10 0 0 0 0 0 0 1000 10 0 0 0 0 0 0
+vxlan.calico: 2000 20 0 0 0 0 0 0 2000 20 0 0 0 0 0 0
+flannel.1: 3000 30 0 0 0 0 0 0 3000 30 0 0 0 0 0 0
+  cni0: 4000 40 0 0 0 0 0 0 4000 40 0 0 0 0 0 0
+veth1a2b3c4d: 5000 50 0 0 0 0 0 0 5000 50 0 0 0 0 0 0
+veth5e6f7a8b: 5000 50 0 0 0 0 0 0 5000 50 0 0 0 0 0 0
+cilium_host: 6000 60 0 0 0 0 0 0 6000 60 0 0 0 0 0 0
+lxc12345678: 7000 70 0 0 0 0 0 0 7000 70 0 0 0 0 0 0
+kube-ipvs0: 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
+  sit0: 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
+ip6tnl0: 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0
+docker0: 8000 80 0 0 0 0 0 0 8000 80 0 0 0 0 0 0
+`
+
+// collectedInterfaces returns the distinct interfaces in the buffered telemetry.
+func collectedInterfaces(buffer *mockTelemetryBuffer) []string {
+	seen := make(map[string]bool)
+	for _, entry := range buffer.entries {
+		seen[entry.Tags["interface"]] = true
+	}
+	interfaces := make([]string, 0, len(seen))
+	for iface := range seen {
+		interfaces = append(interfaces, iface)
+	}
+	sort.Strings(interfaces)
+	return interfaces
+}
+
+// TestInterfaceFilter validates network interface selection.
+func TestInterfaceFilter(t *testing.T) {
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("excludes CNI interfaces by default", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		collector := NewSystemCollector(time.Second, buffer)
+
+		collector.recordNetDev(cniNetDev, now)
+
+		interfaces := collectedInterfaces(buffer)
+		if len(interfaces) != 2 || interfaces[0] != "ens5" || interfaces[1] != "eth0" {
+			t.Errorf("Expected only ens5 and eth0, got %v", interfaces)
+		}
+		if len(buffer.entries) != 12 {
+			t.Errorf("Expected 6 metrics for each of 2 interfaces, got %d", len(buffer.entries))
+		}
+	})
+
+	t.Run("includes only matching interfaces", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		collector := NewSystemCollector(time.Second, buffer)
+		collector.SetInterfaceFilter(InterfaceFilter{Include: []string{"eth*"}, Exclude: DefaultExcludedInterfaces})
+
+		collector.recordNetDev(cniNetDev, now)
+
+		if interfaces := collectedInterfaces(buffer); len(interfaces) != 1 || interfaces[0] != "eth0" {
+			t.Errorf("Expected only eth0, got %v", interfaces)
+		}
+	})
+
+	t.Run("collects everything without exclusions", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		collector := NewSystemCollector(time.Second, buffer)
+		collector.SetInterfaceFilter(InterfaceFilter{Exclude: []string{"lo"}})
+
+		collector.recordNetDev(cniNetDev, now)
+
+		if interfaces := collectedInterfaces(buffer); len(interfaces) != 16 {
+			t.Errorf("Expected all 16 non-loopback interfaces, got %d: %v", len(interfaces), interfaces)
+		}
+	})
+
+	t.Run("parses and validates patterns", func(t *testing.T) {
+		patterns, err := ParseInterfacePatterns(" eth*, ens[0-9] ,,")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(patterns) != 2 || patterns[0] != "eth*" || patterns[1] != "ens[0-9]" {
+			t.Errorf("Expected [eth* ens[0-9]], got %v", patterns)
+		}
+
+		if _, err := ParseInterfacePatterns("eth[0-"); err == nil {
+			t.Error("Expected error for malformed pattern")
+		}
+	})
+}
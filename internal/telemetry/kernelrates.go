@@ -0,0 +1,136 @@
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// kernelCounters are the cumulative counters of /proc/stat at a point in time.
+type kernelCounters struct {
+	// contextSwitches is the ctxt line, context switches since boot
+	contextSwitches uint64
+	// interrupts is the total of the intr line, interrupts serviced since boot
+	interrupts uint64
+	// softirqs is the total of the softirq line, softirqs serviced since boot
+	softirqs uint64
+	// at is when the counters were read
+	at time.Time
+}
+
+// KernelRateCollector reports the per-second rates of context switches,
+// interrupts and softirqs from the cumulative counters of /proc/stat, which
+// help diagnose latency spikes caused by scheduler or interrupt storms. Rates
+// are computed from the difference to the previous collection, so the first
+// collection only records the counters.
+type KernelRateCollector struct {
+	// procRoot is the proc filesystem mount, normally /proc
+	procRoot string
+	// buffer receives the collected telemetry entries
+	buffer TelemetryBuffer
+	// previous holds the counters of the last collection (nil before the first)
+	previous *kernelCounters
+	// transformer rewrites entries before they are buffered, set by the
+	// SystemCollector (nil leaves them unchanged)
+	transformer *Transformer
+}
+
+// NewKernelRateCollector creates a collector reading <procRoot>/stat (empty
+// procRoot uses /proc).
+func NewKernelRateCollector(procRoot string, buffer TelemetryBuffer) *KernelRateCollector {
+	if procRoot == "" {
+		procRoot = "/proc"
+	}
+	return &KernelRateCollector{
+		procRoot: procRoot,
+		buffer:   buffer,
+	}
+}
+
+// Collect reads the counters and records context_switches_per_sec,
+// interrupts_per_sec and softirqs_per_sec since the previous collection. A
+// counter that decreased is skipped for the cycle.
+func (kc *KernelRateCollector) Collect(timestamp time.Time) error {
+	data, err := os.ReadFile(filepath.Join(kc.procRoot, "stat"))
+	if err != nil {
+		return err
+	}
+	current, err := parseKernelCounters(string(data))
+	if err != nil {
+		return err
+	}
+	current.at = timestamp
+
+	previous := kc.previous
+	kc.previous = &current
+	if previous == nil {
+		return nil
+	}
+	elapsed := current.at.Sub(previous.at).Seconds()
+	if elapsed <= 0 {
+		return nil
+	}
+
+	rates := []struct {
+		name              string
+		current, previous uint64
+	}{
+		{"context_switches_per_sec", current.contextSwitches, previous.contextSwitches},
+		{"interrupts_per_sec", current.interrupts, previous.interrupts},
+		{"softirqs_per_sec", current.softirqs, previous.softirqs},
+	}
+	for _, rate := range rates {
+		if rate.current < rate.previous {
+			continue
+		}
+		kc.buffer.Add(kc.transformer.Apply(types.TelemetryEntry{
+			Timestamp: timestamp,
+			Source:    types.SourceSystem,
+			Type:      types.TypeCPU,
+			Name:      rate.name,
+			Value:     float64(rate.current-rate.previous) / elapsed,
+		}))
+	}
+	return nil
+}
+
+// parseKernelCounters parses the ctxt, intr and softirq lines of /proc/stat
+// content. The intr and softirq lines start with their total, followed by the
+// per-source counts, which are ignored.
+func parseKernelCounters(data string) (kernelCounters, error) {
+	var counters kernelCounters
+	found := 0
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		var target *uint64
+		switch fields[0] {
+		case "ctxt":
+			target = &counters.contextSwitches
+		case "intr":
+			target = &counters.interrupts
+		case "softirq":
+			target = &counters.softirqs
+		default:
+			continue
+		}
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return kernelCounters{}, fmt.Errorf("invalid %s line in stat: %w", fields[0], err)
+		}
+		*target = value
+		found++
+	}
+	if found < 3 {
+		return kernelCounters{}, fmt.Errorf("stat lacks the ctxt, intr or softirq line")
+	}
+	return counters, nil
+}
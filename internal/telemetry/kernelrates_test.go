@@ -0,0 +1,122 @@
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// kernelStatFirst and kernelStatSecond are /proc/stat samples taken 10s apart.
+const (
+	kernelStatFirst = `cpu  4705 356 584 3699 23 23 0 0 0 0
+cpu0 4705 356 584 3699 23 23 0 0 0 0
+intr 1462898 27 9 0 0 0 0 3 0 1 0
+ctxt 2250000
+btime 1730559845
+processes 26442
+procs_running 2
+procs_blocked 0
+softirq 580000 1 250000 2 10000 0 0 300000 19997 0 0
+`
+	kernelStatSecond = `cpu  4805 356 604 3779 23 23 0 0 0 0
+cpu0 4805 356 604 3779 23 23 0 0 0 0
+intr 1472898 27 9 0 0 0 0 3 0 1 0
+ctxt 2300000
+btime 1730559845
+processes 26450
+procs_running 1
+procs_blocked 0
+softirq 582500 1 251000 2 10500 0 0 301000 20000 0 0
+`
+)
+
+// writeKernelStat writes stat content to the proc root.
+func writeKernelStat(t *testing.T, root, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(root, "stat"), []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write stat: %v", err)
+	}
+}
+
+// TestKernelRateCollector validates computing kernel activity rates from two
+// successive /proc/stat samples.
+func TestKernelRateCollector(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("computes per-second rates from deltas", func(t *testing.T) {
+		root := t.TempDir()
+		buffer := &mockTelemetryBuffer{}
+		collector := NewKernelRateCollector(root, buffer)
+
+		writeKernelStat(t, root, kernelStatFirst)
+		if err := collector.Collect(base); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(buffer.entries) != 0 {
+			t.Fatalf("Expected no rates from the first sample, got %d entries", len(buffer.entries))
+		}
+
+		writeKernelStat(t, root, kernelStatSecond)
+		if err := collector.Collect(base.Add(10 * time.Second)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		expected := map[string]float64{
+			"context_switches_per_sec": 5000,
+			"interrupts_per_sec":       1000,
+			"softirqs_per_sec":         250,
+		}
+		if len(buffer.entries) != len(expected) {
+			t.Fatalf("Expected %d entries, got %d", len(expected), len(buffer.entries))
+		}
+		for _, entry := range buffer.entries {
+			if entry.Value != expected[entry.Name] {
+				t.Errorf("Expected %s %v, got %v", entry.Name, expected[entry.Name], entry.Value)
+			}
+			if !entry.Timestamp.Equal(base.Add(10 * time.Second)) {
+				t.Errorf("Expected %s at the second sample, got %v", entry.Name, entry.Timestamp)
+			}
+		}
+	})
+
+	t.Run("skips counters that decreased", func(t *testing.T) {
+		root := t.TempDir()
+		buffer := &mockTelemetryBuffer{}
+		collector := NewKernelRateCollector(root, buffer)
+
+		writeKernelStat(t, root, kernelStatSecond)
+		collector.Collect(base)
+		writeKernelStat(t, root, "intr 100\nctxt 2400000\nsoftirq 100\n")
+		if err := collector.Collect(base.Add(10 * time.Second)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if len(buffer.entries) != 1 || buffer.entries[0].Name != "context_switches_per_sec" || buffer.entries[0].Value != 10000.0 {
+			t.Errorf("Expected only the context switch rate, got %+v", buffer.entries)
+		}
+	})
+
+	t.Run("fails for a stat without the counters", func(t *testing.T) {
+		root := t.TempDir()
+		collector := NewKernelRateCollector(root, &mockTelemetryBuffer{})
+
+		if err := collector.Collect(base); err == nil {
+			t.Error("Expected error for a missing stat file")
+		}
+		writeKernelStat(t, root, "cpu  4705 356 584 3699\nctxt 2250000\n")
+		if err := collector.Collect(base); err == nil {
+			t.Error("Expected error for a stat without intr and softirq lines")
+		}
+	})
+
+	t.Run("is set on the system collector", func(t *testing.T) {
+		root := t.TempDir()
+		buffer := &mockTelemetryBuffer{}
+		system := NewSystemCollector(time.Second, buffer)
+		system.SetKernelRateCollector(NewKernelRateCollector(root, buffer))
+		if system.kernelRates == nil || system.kernelRates.procRoot != root {
+			t.Error("Expected the kernel rate collector to be set")
+		}
+	})
+}
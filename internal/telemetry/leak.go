@@ -0,0 +1,259 @@
+package telemetry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// IncidentAnomaly identifies incidents raised for abnormal telemetry trends,
+// such as memory that keeps climbing without plateauing.
+const IncidentAnomaly types.IncidentType = "anomaly"
+
+const (
+	// DefaultLeakMetric is the telemetry metric watched for leaks
+	DefaultLeakMetric = "memory_usage_percent"
+	// DefaultLeakWindow is the span of samples the growth rate is fitted over
+	DefaultLeakWindow = 30 * time.Minute
+	// DefaultLeakSustain is how long the growth rate must stay above the threshold
+	DefaultLeakSustain = 10 * time.Minute
+	// leakSmoothing is the weight of a new sample in the exponential moving
+	// average that damps noise before fitting
+	leakSmoothing = 0.3
+	// leakMinSamples is the fewest samples a slope is fitted from
+	leakMinSamples = 5
+)
+
+// LeakConfig configures memory leak detection.
+type LeakConfig struct {
+	// Metric is the telemetry entry name watched, e.g. "memory_usage_percent"
+	// or "process_rss_bytes"; series with different tags are tracked separately
+	Metric string
+	// Window is the span of samples the growth rate is fitted over
+	Window time.Duration
+	// SlopeThreshold is the growth rate, in metric units per minute, above
+	// which the metric is considered leaking
+	SlopeThreshold float64
+	// Sustain is how long the growth rate must exceed the threshold before an
+	// incident is raised
+	Sustain time.Duration
+	// Limit is the value at which memory is exhausted, used to project the time
+	// to exhaustion (zero uses 100 for "_percent" metrics and projects nothing otherwise)
+	Limit float64
+}
+
+// leakSample is a smoothed metric value at a point in time.
+type leakSample struct {
+	at    time.Time
+	value float64
+}
+
+// leakSeries tracks the recent samples of one tagged series of the metric.
+type leakSeries struct {
+	// tags identifies the series, e.g. the process of process_rss_bytes
+	tags map[string]string
+	// samples holds the smoothed values within the window, oldest first
+	samples []leakSample
+	// rising is when the slope first exceeded the threshold (zero when below)
+	rising time.Time
+	// reported records that the current rise has already raised an incident
+	reported bool
+}
+
+// LeakDetector watches a memory metric for steady growth. It sits in front of
+// the telemetry buffer, forwarding every entry, and fits a least-squares line
+// to the smoothed samples of the configured metric over the window. When the
+// slope stays above the threshold for the sustain period, it raises an anomaly
+// incident with the projected time to exhaustion. Incidents are debounced: a
+// series reports once per rise and again only after its growth has stopped.
+type LeakDetector struct {
+	// mutex protects series
+	mutex sync.Mutex
+	// config holds the metric, window and thresholds
+	config LeakConfig
+	// next receives every entry passed to Add (nil discards them)
+	next TelemetryBuffer
+	// report receives raised incidents
+	report func(types.IncidentReport)
+	// series maps tag sets to their tracked samples
+	series map[string]*leakSeries
+}
+
+// NewLeakDetector creates a detector that forwards entries to next and passes
+// incidents to report. Zero window and sustain use the defaults.
+func NewLeakDetector(config LeakConfig, next TelemetryBuffer, report func(types.IncidentReport)) *LeakDetector {
+	if config.Metric == "" {
+		config.Metric = DefaultLeakMetric
+	}
+	if config.Window <= 0 {
+		config.Window = DefaultLeakWindow
+	}
+	if config.Sustain <= 0 {
+		config.Sustain = DefaultLeakSustain
+	}
+	if config.Limit == 0 && strings.HasSuffix(config.Metric, "_percent") {
+		config.Limit = 100
+	}
+
+	return &LeakDetector{
+		config: config,
+		next:   next,
+		report: report,
+		series: make(map[string]*leakSeries),
+	}
+}
+
+// Add forwards the entry and checks samples of the watched metric for leaks.
+// It returns the error of the next buffer, if any.
+func (ld *LeakDetector) Add(entry types.TelemetryEntry) error {
+	var err error
+	if ld.next != nil {
+		err = ld.next.Add(entry)
+	}
+	if entry.Name != ld.config.Metric {
+		return err
+	}
+
+	value, ok := Float64Value(entry.Value)
+	if !ok {
+		return err
+	}
+	if report, raised := ld.observe(entry, value); raised {
+		ld.report(report)
+	}
+	return err
+}
+
+// observe records a sample and returns an incident when the series has been
+// growing faster than the threshold for the sustain period.
+func (ld *LeakDetector) observe(entry types.TelemetryEntry, value float64) (types.IncidentReport, bool) {
+	ld.mutex.Lock()
+	defer ld.mutex.Unlock()
+
+	key := tagKey(entry.Tags)
+	series, ok := ld.series[key]
+	if !ok {
+		ld.pruneLocked(entry.Timestamp)
+		series = &leakSeries{tags: entry.Tags}
+		ld.series[key] = series
+	}
+
+	if n := len(series.samples); n > 0 {
+		value = leakSmoothing*value + (1-leakSmoothing)*series.samples[n-1].value
+	}
+	series.samples = append(series.samples, leakSample{at: entry.Timestamp, value: value})
+
+	cutoff := entry.Timestamp.Add(-ld.config.Window)
+	drop := 0
+	for drop < len(series.samples) && series.samples[drop].at.Before(cutoff) {
+		drop++
+	}
+	series.samples = series.samples[drop:]
+
+	// Wait for the samples to cover half the window before fitting
+	if len(series.samples) < leakMinSamples || entry.Timestamp.Sub(series.samples[0].at) < ld.config.Window/2 {
+		return types.IncidentReport{}, false
+	}
+
+	slope := regressionSlope(series.samples)
+	if slope*60 <= ld.config.SlopeThreshold {
+		series.rising = time.Time{}
+		series.reported = false
+		return types.IncidentReport{}, false
+	}
+	if series.rising.IsZero() {
+		series.rising = entry.Timestamp
+	}
+	if series.reported || entry.Timestamp.Sub(series.rising) < ld.config.Sustain {
+		return types.IncidentReport{}, false
+	}
+
+	series.reported = true
+	return ld.buildIncident(series, entry.Timestamp, slope), true
+}
+
+// pruneLocked drops series that have not been sampled within the window, such
+// as processes that have exited.
+func (ld *LeakDetector) pruneLocked(now time.Time) {
+	cutoff := now.Add(-ld.config.Window)
+	for key, series := range ld.series {
+		if n := len(series.samples); n == 0 || series.samples[n-1].at.Before(cutoff) {
+			delete(ld.series, key)
+		}
+	}
+}
+
+// buildIncident creates the anomaly report for a leaking series. slope is in
+// metric units per second.
+func (ld *LeakDetector) buildIncident(series *leakSeries, now time.Time, slope float64) types.IncidentReport {
+	current := series.samples[len(series.samples)-1].value
+
+	context := map[string]interface{}{
+		"metric":           ld.config.Metric,
+		"current_value":    current,
+		"slope_per_minute": slope * 60,
+		"slope_threshold":  ld.config.SlopeThreshold,
+		"window":           ld.config.Window.String(),
+		"rising_since":     series.rising,
+	}
+	if len(series.tags) > 0 {
+		context["tags"] = series.tags
+	}
+
+	severity := types.SeverityMedium
+	message := fmt.Sprintf("%s is growing by %.3f per minute", ld.config.Metric, slope*60)
+	if ld.config.Limit > current {
+		exhaustion := time.Duration((ld.config.Limit - current) / slope * float64(time.Second)).Round(time.Second)
+		context["limit"] = ld.config.Limit
+		context["time_to_exhaustion"] = exhaustion.String()
+		context["time_to_exhaustion_seconds"] = exhaustion.Seconds()
+		message = fmt.Sprintf("%s, projected to reach %g in %s", message, ld.config.Limit, exhaustion)
+		if exhaustion <= ld.config.Window {
+			severity = types.SeverityHigh
+		}
+	}
+
+	return types.IncidentReport{
+		ID:        fmt.Sprintf("memory-leak-%s-%d", ld.config.Metric, now.Unix()),
+		Timestamp: now,
+		Severity:  severity,
+		Type:      IncidentAnomaly,
+		Message:   message,
+		Context:   context,
+	}
+}
+
+// regressionSlope returns the least-squares slope of the samples in units per second.
+func regressionSlope(samples []leakSample) float64 {
+	origin := samples[0].at
+	n := float64(len(samples))
+
+	var sumX, sumY, sumXY, sumXX float64
+	for _, sample := range samples {
+		x := sample.at.Sub(origin).Seconds()
+		sumX += x
+		sumY += sample.value
+		sumXY += x * sample.value
+		sumXX += x * x
+	}
+
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0
+	}
+	return (n*sumXY - sumX*sumY) / denominator
+}
+
+// tagKey returns a stable key for a tag set.
+func tagKey(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
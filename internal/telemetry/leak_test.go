@@ -0,0 +1,144 @@
+package telemetry
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// newTestLeakDetector returns a detector forwarding to a mock buffer that records incidents.
+func newTestLeakDetector(config LeakConfig) (*LeakDetector, *mockTelemetryBuffer, *[]types.IncidentReport) {
+	var reports []types.IncidentReport
+	buffer := &mockTelemetryBuffer{}
+	detector := NewLeakDetector(config, buffer, func(report types.IncidentReport) {
+		reports = append(reports, report)
+	})
+	return detector, buffer, &reports
+}
+
+// feedSeries adds one memory_usage_percent sample per minute, starting at base,
+// with values from the given function of the minute.
+func feedSeries(detector *LeakDetector, base time.Time, from, to int, value func(minute int) float64) {
+	for minute := from; minute < to; minute++ {
+		detector.Add(types.TelemetryEntry{
+			Timestamp: base.Add(time.Duration(minute) * time.Minute),
+			Source:    types.SourceSystem,
+			Type:      types.TypeMemory,
+			Name:      "memory_usage_percent",
+			Value:     value(minute),
+		})
+	}
+}
+
+// TestLeakDetector validates leak detection over synthetic memory series.
+func TestLeakDetector(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 0, 0, 0, time.UTC)
+	config := LeakConfig{Window: 20 * time.Minute, SlopeThreshold: 0.5, Sustain: 5 * time.Minute}
+
+	t.Run("raises incident for a steadily rising series", func(t *testing.T) {
+		detector, buffer, reports := newTestLeakDetector(config)
+
+		// Rises by 1% per minute with ±0.5% noise
+		feedSeries(detector, base, 0, 30, func(minute int) float64 {
+			return 20 + float64(minute) + 0.5*math.Sin(float64(minute)*2.1)
+		})
+
+		if len(buffer.entries) != 30 {
+			t.Errorf("Expected all entries forwarded, got %d", len(buffer.entries))
+		}
+		if len(*reports) != 1 {
+			t.Fatalf("Expected 1 incident, got %d", len(*reports))
+		}
+
+		report := (*reports)[0]
+		if report.Type != IncidentAnomaly {
+			t.Errorf("Expected type %s, got %s", IncidentAnomaly, report.Type)
+		}
+		// Fitting starts at minute 10 and the rise must be sustained for 5 minutes
+		if want := base.Add(15 * time.Minute); !report.Timestamp.Equal(want) {
+			t.Errorf("Expected incident at %v, got %v", want, report.Timestamp)
+		}
+		slope, _ := report.Context["slope_per_minute"].(float64)
+		if slope < 0.8 || slope > 1.2 {
+			t.Errorf("Expected slope near 1 per minute, got %v", slope)
+		}
+		seconds, _ := report.Context["time_to_exhaustion_seconds"].(float64)
+		if seconds < 60*60 || seconds > 80*60 {
+			t.Errorf("Expected about 70 minutes to exhaustion, got %v seconds", seconds)
+		}
+		if report.Severity != types.SeverityMedium {
+			t.Errorf("Expected severity medium, got %s", report.Severity)
+		}
+	})
+
+	t.Run("ignores a plateaued series", func(t *testing.T) {
+		detector, _, reports := newTestLeakDetector(config)
+
+		feedSeries(detector, base, 0, 60, func(minute int) float64 {
+			return 50 + 2*math.Sin(float64(minute))
+		})
+
+		if len(*reports) != 0 {
+			t.Errorf("Expected no incident for a flat series, got %d", len(*reports))
+		}
+	})
+
+	t.Run("ignores a short spike", func(t *testing.T) {
+		detector, _, reports := newTestLeakDetector(config)
+
+		feedSeries(detector, base, 0, 60, func(minute int) float64 {
+			if minute >= 20 && minute < 23 {
+				return 60
+			}
+			return 40
+		})
+
+		if len(*reports) != 0 {
+			t.Errorf("Expected no incident for a spike shorter than the sustain period, got %d", len(*reports))
+		}
+	})
+
+	t.Run("reports again only after growth stops", func(t *testing.T) {
+		detector, _, reports := newTestLeakDetector(config)
+
+		rising := func(minute int) float64 { return float64(minute) }
+		feedSeries(detector, base, 0, 40, rising)
+		if len(*reports) != 1 {
+			t.Fatalf("Expected 1 incident while rising, got %d", len(*reports))
+		}
+
+		feedSeries(detector, base, 40, 80, func(minute int) float64 { return 40 })
+		feedSeries(detector, base, 80, 120, func(minute int) float64 { return float64(minute - 80) })
+		if len(*reports) != 2 {
+			t.Errorf("Expected a second incident for a new rise, got %d", len(*reports))
+		}
+	})
+
+	t.Run("tracks tagged series separately", func(t *testing.T) {
+		detector, _, reports := newTestLeakDetector(LeakConfig{
+			Metric:         "process_rss_bytes",
+			Window:         20 * time.Minute,
+			SlopeThreshold: 1 << 20,
+			Sustain:        time.Minute,
+		})
+
+		for minute := 0; minute < 20; minute++ {
+			at := base.Add(time.Duration(minute) * time.Minute)
+			detector.Add(types.TelemetryEntry{Timestamp: at, Name: "process_rss_bytes", Value: uint64(100<<20 + minute*(10<<20)), Tags: map[string]string{"process": "leaky"}})
+			detector.Add(types.TelemetryEntry{Timestamp: at, Name: "process_rss_bytes", Value: uint64(100 << 20), Tags: map[string]string{"process": "steady"}})
+		}
+
+		if len(*reports) != 1 {
+			t.Fatalf("Expected 1 incident, got %d", len(*reports))
+		}
+		tags, _ := (*reports)[0].Context["tags"].(map[string]string)
+		if tags["process"] != "leaky" {
+			t.Errorf("Expected incident for the leaky process, got %v", tags)
+		}
+		if _, ok := (*reports)[0].Context["time_to_exhaustion"]; ok {
+			t.Error("Expected no exhaustion projection without a limit")
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package telemetry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// IncidentLowMemory identifies incidents raised when the node runs low on
+// available memory, before the OOM killer starts terminating processes.
+const IncidentLowMemory types.IncidentType = "low_memory"
+
+const (
+	// DefaultLowMemorySustain is how long memory must stay low before an incident is raised
+	DefaultLowMemorySustain = time.Minute
+	// DefaultLowMemoryTopProcesses is how many of the largest tracked processes are reported
+	DefaultLowMemoryTopProcesses = 5
+	// lowMemoryProcessExpiry is how long a tracked process's RSS is kept
+	// without a new sample, so exited processes are not reported
+	lowMemoryProcessExpiry = 2 * time.Minute
+)
+
+// LowMemoryConfig configures low memory detection. At least one of
+// MinAvailableBytes and MaxUsedPercent must be set.
+type LowMemoryConfig struct {
+	// MinAvailableBytes is the memory_available_bytes below which memory is low (0 disables)
+	MinAvailableBytes uint64
+	// MaxUsedPercent is the memory_usage_percent at or above which memory is low (0 disables)
+	MaxUsedPercent float64
+	// Sustain is how long memory must stay low before an incident is raised
+	Sustain time.Duration
+	// TopProcesses is how many of the largest tracked processes are reported
+	TopProcesses int
+}
+
+// ProcessMemory is a tracked process and its resident memory, reported with
+// low memory incidents.
+type ProcessMemory struct {
+	Comm     string `json:"comm"`
+	PID      string `json:"pid"`
+	RSSBytes uint64 `json:"rss_bytes"`
+	// seen is when the process was last sampled
+	seen time.Time
+}
+
+// LowMemoryDetector raises low_memory incidents when the node's available
+// memory stays below a floor, or its memory usage above a ceiling, for the
+// sustain period. It sits in front of the telemetry buffer, forwarding every
+// entry, watches the system memory metrics and remembers the RSS of tracked
+// processes, reporting the largest as likely culprits. Incidents are
+// debounced: memory pressure reports once and again only after memory has
+// recovered.
+type LowMemoryDetector struct {
+	// mutex protects the fields below config
+	mutex sync.Mutex
+	// config holds the thresholds and sustain period
+	config LowMemoryConfig
+	// next receives every entry passed to Add (nil discards them)
+	next TelemetryBuffer
+	// report receives raised incidents
+	report func(types.IncidentReport)
+	// available is the last memory_available_bytes seen (nil until reported)
+	available interface{}
+	// usedPercent is the last memory_usage_percent seen (nil until reported)
+	usedPercent interface{}
+	// availableLow and usedHigh record which threshold the last samples breached
+	availableLow, usedHigh bool
+	// lowSince is when memory became low (zero while it is not)
+	lowSince time.Time
+	// reported records that the current low period has already raised an incident
+	reported bool
+	// processes maps tracked processes to their last RSS
+	processes map[string]ProcessMemory
+}
+
+// NewLowMemoryDetector creates a detector that forwards entries to next and
+// passes incidents to report. Zero sustain and top processes use the defaults.
+func NewLowMemoryDetector(config LowMemoryConfig, next TelemetryBuffer, report func(types.IncidentReport)) *LowMemoryDetector {
+	if config.Sustain <= 0 {
+		config.Sustain = DefaultLowMemorySustain
+	}
+	if config.TopProcesses <= 0 {
+		config.TopProcesses = DefaultLowMemoryTopProcesses
+	}
+
+	return &LowMemoryDetector{
+		config:    config,
+		next:      next,
+		report:    report,
+		processes: make(map[string]ProcessMemory),
+	}
+}
+
+// Add forwards the entry and checks system memory against the thresholds.
+// It returns the error of the next buffer, if any.
+func (lm *LowMemoryDetector) Add(entry types.TelemetryEntry) error {
+	var err error
+	if lm.next != nil {
+		err = lm.next.Add(entry)
+	}
+	if entry.Source != types.SourceSystem {
+		return err
+	}
+
+	switch entry.Name {
+	case "memory_available_bytes", "memory_usage_percent":
+		if report, raised := lm.observe(entry); raised {
+			lm.report(report)
+		}
+	case "process_rss_bytes":
+		lm.observeProcess(entry)
+	}
+	return err
+}
+
+// observe records a system memory sample and returns an incident when memory
+// has been low for the sustain period.
+func (lm *LowMemoryDetector) observe(entry types.TelemetryEntry) (types.IncidentReport, bool) {
+	value, ok := Float64Value(entry.Value)
+	if !ok {
+		return types.IncidentReport{}, false
+	}
+
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+
+	if entry.Name == "memory_available_bytes" {
+		lm.available = entry.Value
+		lm.availableLow = lm.config.MinAvailableBytes > 0 && value < float64(lm.config.MinAvailableBytes)
+	} else {
+		lm.usedPercent = entry.Value
+		lm.usedHigh = lm.config.MaxUsedPercent > 0 && value >= lm.config.MaxUsedPercent
+	}
+
+	if !lm.availableLow && !lm.usedHigh {
+		lm.lowSince = time.Time{}
+		lm.reported = false
+		return types.IncidentReport{}, false
+	}
+	if lm.lowSince.IsZero() {
+		lm.lowSince = entry.Timestamp
+	}
+	if lm.reported || entry.Timestamp.Sub(lm.lowSince) < lm.config.Sustain {
+		return types.IncidentReport{}, false
+	}
+
+	lm.reported = true
+	return lm.buildIncident(entry.Timestamp), true
+}
+
+// observeProcess records the RSS of a tracked process.
+func (lm *LowMemoryDetector) observeProcess(entry types.TelemetryEntry) {
+	rss, ok := Float64Value(entry.Value)
+	if !ok {
+		return
+	}
+
+	lm.mutex.Lock()
+	defer lm.mutex.Unlock()
+
+	key := tagKey(entry.Tags)
+	if _, ok := lm.processes[key]; !ok {
+		for k, process := range lm.processes {
+			if entry.Timestamp.Sub(process.seen) > lowMemoryProcessExpiry {
+				delete(lm.processes, k)
+			}
+		}
+	}
+	lm.processes[key] = ProcessMemory{
+		Comm:     entry.Tags["comm"],
+		PID:      entry.Tags["pid"],
+		RSSBytes: uint64(rss),
+		seen:     entry.Timestamp,
+	}
+}
+
+// topProcessesLocked returns the tracked processes sampled recently, largest
+// RSS first, bounded by the configured count.
+func (lm *LowMemoryDetector) topProcessesLocked(now time.Time) []ProcessMemory {
+	var processes []ProcessMemory
+	for _, process := range lm.processes {
+		if now.Sub(process.seen) <= lowMemoryProcessExpiry {
+			processes = append(processes, process)
+		}
+	}
+	sort.Slice(processes, func(i, j int) bool {
+		if processes[i].RSSBytes != processes[j].RSSBytes {
+			return processes[i].RSSBytes > processes[j].RSSBytes
+		}
+		return processes[i].PID < processes[j].PID
+	})
+	if len(processes) > lm.config.TopProcesses {
+		processes = processes[:lm.config.TopProcesses]
+	}
+	return processes
+}
+
+// buildIncident creates the low_memory report for memory low since lowSince.
+func (lm *LowMemoryDetector) buildIncident(now time.Time) types.IncidentReport {
+	context := map[string]interface{}{
+		"low_since": lm.lowSince,
+		"sustain":   lm.config.Sustain.String(),
+	}
+	var reasons []string
+	if lm.available != nil {
+		context["available_bytes"] = lm.available
+	}
+	if lm.usedPercent != nil {
+		context["usage_percent"] = lm.usedPercent
+	}
+	if lm.config.MinAvailableBytes > 0 {
+		context["min_available_bytes"] = lm.config.MinAvailableBytes
+		if lm.availableLow {
+			reasons = append(reasons, fmt.Sprintf("%v bytes available (floor %d)", lm.available, lm.config.MinAvailableBytes))
+		}
+	}
+	if lm.config.MaxUsedPercent > 0 {
+		context["max_used_percent"] = lm.config.MaxUsedPercent
+		if lm.usedHigh {
+			used, _ := Float64Value(lm.usedPercent)
+			reasons = append(reasons, fmt.Sprintf("%.1f%% used (threshold %.1f%%)", used, lm.config.MaxUsedPercent))
+		}
+	}
+	if processes := lm.topProcessesLocked(now); len(processes) > 0 {
+		context["top_processes"] = processes
+	}
+
+	severity := types.SeverityHigh
+	if available, ok := Float64Value(lm.available); ok && lm.availableLow && available < float64(lm.config.MinAvailableBytes)/2 {
+		severity = types.SeverityCritical
+	}
+
+	return types.IncidentReport{
+		ID:        fmt.Sprintf("low-memory-%d", now.Unix()),
+		Timestamp: now,
+		Severity:  severity,
+		Type:      IncidentLowMemory,
+		Message:   fmt.Sprintf("Node memory has been low for %s: %s", now.Sub(lm.lowSince).Round(time.Second), strings.Join(reasons, ", ")),
+		Context:   context,
+	}
+}
@@ -0,0 +1,127 @@
+package telemetry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestLowMemoryDetector validates low_memory incidents from synthetic memory pressure.
+func TestLowMemoryDetector(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	const gib = 1 << 30
+	memory := func(detector *LowMemoryDetector, at time.Time, available uint64, percent float64) {
+		detector.Add(types.TelemetryEntry{Timestamp: at, Source: types.SourceSystem, Type: types.TypeMemory, Name: "memory_available_bytes", Value: available})
+		detector.Add(types.TelemetryEntry{Timestamp: at, Source: types.SourceSystem, Type: types.TypeMemory, Name: "memory_usage_percent", Value: percent})
+	}
+	rss := func(detector *LowMemoryDetector, at time.Time, comm, pid string, bytes uint64) {
+		detector.Add(types.TelemetryEntry{Timestamp: at, Source: types.SourceSystem, Type: types.TypeProcess, Name: "process_rss_bytes", Value: bytes,
+			Tags: map[string]string{"comm": comm, "pid": pid, "matcher": comm}})
+	}
+	newDetector := func(config LowMemoryConfig) (*LowMemoryDetector, *mockTelemetryBuffer, *[]types.IncidentReport) {
+		buffer := &mockTelemetryBuffer{}
+		var reports []types.IncidentReport
+		detector := NewLowMemoryDetector(config, buffer, func(report types.IncidentReport) {
+			reports = append(reports, report)
+		})
+		return detector, buffer, &reports
+	}
+
+	t.Run("raises an incident once memory stays low", func(t *testing.T) {
+		detector, buffer, reports := newDetector(LowMemoryConfig{MinAvailableBytes: gib, Sustain: time.Minute, TopProcesses: 2})
+		rss(detector, base, "postgres", "100", 6*gib)
+		rss(detector, base, "java", "200", 9*gib)
+		rss(detector, base, "nginx", "300", gib/4)
+
+		memory(detector, base, 4*gib, 75)
+		memory(detector, base.Add(10*time.Second), gib/2+gib/4, 95)
+		memory(detector, base.Add(40*time.Second), gib/2+gib/8, 96)
+		if len(*reports) != 0 {
+			t.Fatalf("Expected no incident before the sustain period, got %d", len(*reports))
+		}
+		memory(detector, base.Add(70*time.Second), gib/2+gib/8, 96)
+
+		if len(buffer.entries) != 11 {
+			t.Errorf("Expected all entries forwarded, got %d", len(buffer.entries))
+		}
+		if len(*reports) != 1 {
+			t.Fatalf("Expected 1 incident, got %d", len(*reports))
+		}
+		report := (*reports)[0]
+		if report.Type != IncidentLowMemory || report.Severity != types.SeverityHigh {
+			t.Errorf("Expected high low_memory incident, got %s %s", report.Severity, report.Type)
+		}
+		if report.Context["available_bytes"] != uint64(gib/2+gib/8) || report.Context["min_available_bytes"] != uint64(gib) {
+			t.Errorf("Expected available memory and floor in context, got %v", report.Context)
+		}
+		if !report.Context["low_since"].(time.Time).Equal(base.Add(10 * time.Second)) {
+			t.Errorf("Expected low since the first low sample, got %v", report.Context["low_since"])
+		}
+		top, ok := report.Context["top_processes"].([]ProcessMemory)
+		if !ok || len(top) != 2 || top[0].Comm != "java" || top[1].Comm != "postgres" || top[0].RSSBytes != 9*gib {
+			t.Errorf("Expected java and postgres as top processes, got %+v", report.Context["top_processes"])
+		}
+		if report.ID != "low-memory-1730559915" {
+			t.Errorf("Expected ID 'low-memory-1730559915', got %q", report.ID)
+		}
+	})
+
+	t.Run("ignores short spikes", func(t *testing.T) {
+		detector, _, reports := newDetector(LowMemoryConfig{MaxUsedPercent: 90, Sustain: time.Minute})
+
+		for i := 0; i < 10; i++ {
+			percent := 50.0
+			if i%3 == 0 {
+				percent = 97
+			}
+			memory(detector, base.Add(time.Duration(i)*20*time.Second), 4*gib, percent)
+		}
+
+		if len(*reports) != 0 {
+			t.Errorf("Expected no incident for spikes shorter than the sustain period, got %d", len(*reports))
+		}
+	})
+
+	t.Run("reports once until memory recovers", func(t *testing.T) {
+		detector, _, reports := newDetector(LowMemoryConfig{MaxUsedPercent: 90, Sustain: time.Minute})
+
+		for i := 0; i <= 5; i++ {
+			memory(detector, base.Add(time.Duration(i)*30*time.Second), gib, 95)
+		}
+		memory(detector, base.Add(4*time.Minute), 8*gib, 40)
+		for i := 0; i <= 2; i++ {
+			memory(detector, base.Add(5*time.Minute+time.Duration(i)*30*time.Second), gib, 95)
+		}
+
+		if len(*reports) != 2 {
+			t.Fatalf("Expected 2 incidents, got %d", len(*reports))
+		}
+		if _, ok := (*reports)[0].Context["top_processes"]; ok {
+			t.Error("Expected no top processes without tracked processes")
+		}
+	})
+
+	t.Run("is critical below half the floor", func(t *testing.T) {
+		detector, _, reports := newDetector(LowMemoryConfig{MinAvailableBytes: gib, Sustain: time.Minute})
+
+		memory(detector, base, gib/4, 98)
+		memory(detector, base.Add(time.Minute), gib/4, 98)
+
+		if len(*reports) != 1 || (*reports)[0].Severity != types.SeverityCritical {
+			t.Errorf("Expected a critical incident, got %+v", *reports)
+		}
+	})
+
+	t.Run("ignores sidecar memory metrics", func(t *testing.T) {
+		detector, _, reports := newDetector(LowMemoryConfig{MaxUsedPercent: 90, Sustain: time.Minute})
+
+		for i := 0; i <= 3; i++ {
+			detector.Add(types.TelemetryEntry{Timestamp: base.Add(time.Duration(i) * time.Minute), Source: types.SourceSidecar, Name: "memory_usage_percent", Value: 99.0})
+		}
+
+		if len(*reports) != 0 {
+			t.Errorf("Expected no incident from sidecar telemetry, got %d", len(*reports))
+		}
+	})
+}
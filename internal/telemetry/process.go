@@ -0,0 +1,283 @@
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// DefaultMaxTrackedProcesses bounds how many matching processes are reported per cycle.
+const DefaultMaxTrackedProcesses = 20
+
+// clockTicksPerSecond is USER_HZ, the unit of CPU times in /proc/<pid>/stat.
+// It is 100 on all mainstream Linux architectures.
+const clockTicksPerSecond = 100
+
+// ProcessMatcher selects processes to track, either by exact process name
+// (/proc/<pid>/comm) or by a regular expression over the full command line.
+type ProcessMatcher struct {
+	// Comm matches the process name exactly when set
+	Comm string
+	// Cmdline matches the space-joined command line when set
+	Cmdline *regexp.Regexp
+}
+
+// String returns the matcher in the form it was configured.
+func (pm ProcessMatcher) String() string {
+	if pm.Cmdline != nil {
+		return "cmdline:" + pm.Cmdline.String()
+	}
+	return pm.Comm
+}
+
+// matches reports whether a process with the given name and command line matches.
+func (pm ProcessMatcher) matches(comm, cmdline string) bool {
+	if pm.Cmdline != nil {
+		return pm.Cmdline.MatchString(cmdline)
+	}
+	return comm == pm.Comm
+}
+
+// ParseProcessMatchers parses matcher specs: a plain name ("postgres" or
+// "comm:postgres") matches the process name exactly, and "cmdline:<regexp>"
+// matches the command line, e.g. "cmdline:java .*kafka".
+func ParseProcessMatchers(specs []string) ([]ProcessMatcher, error) {
+	var matchers []ProcessMatcher
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+
+		if pattern, ok := strings.CutPrefix(spec, "cmdline:"); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cmdline pattern %q: %w", pattern, err)
+			}
+			matchers = append(matchers, ProcessMatcher{Cmdline: re})
+			continue
+		}
+
+		comm := strings.TrimPrefix(spec, "comm:")
+		if comm == "" {
+			return nil, fmt.Errorf("invalid process matcher %q: empty name", spec)
+		}
+		matchers = append(matchers, ProcessMatcher{Comm: comm})
+	}
+	return matchers, nil
+}
+
+// cpuSample is a process's cumulative CPU time at a point in time.
+type cpuSample struct {
+	ticks uint64
+	at    time.Time
+}
+
+// ProcessCollector reports RSS, CPU and open file descriptors for processes
+// matching configured name or command line matchers. Matching PIDs are looked up
+// on every cycle, so a restarted process is picked up under its new PID.
+type ProcessCollector struct {
+	// procRoot is the proc filesystem mount, normally /proc
+	procRoot string
+	// matchers select the processes to track
+	matchers []ProcessMatcher
+	// maxProcesses bounds the number of processes reported per cycle
+	maxProcesses int
+	// buffer receives the collected telemetry entries
+	buffer TelemetryBuffer
+	// previous holds the last CPU sample per PID for computing CPU percent
+	previous map[int]cpuSample
+	// pageSize converts resident pages to bytes
+	pageSize uint64
+	// transformer rewrites entries before they are buffered, set by the
+	// SystemCollector (nil leaves them unchanged)
+	transformer *Transformer
+	// fdLimits adds each process's descriptor limits and usage percentage,
+	// set by the SystemCollector
+	fdLimits bool
+}
+
+// NewProcessCollector creates a collector for processes under procRoot (empty
+// uses /proc) matching any of the matchers. At most maxProcesses processes are
+// reported per cycle (zero or less uses DefaultMaxTrackedProcesses).
+func NewProcessCollector(procRoot string, matchers []ProcessMatcher, maxProcesses int, buffer TelemetryBuffer) *ProcessCollector {
+	if procRoot == "" {
+		procRoot = "/proc"
+	}
+	if maxProcesses <= 0 {
+		maxProcesses = DefaultMaxTrackedProcesses
+	}
+	return &ProcessCollector{
+		procRoot:     procRoot,
+		matchers:     matchers,
+		maxProcesses: maxProcesses,
+		buffer:       buffer,
+		previous:     make(map[int]cpuSample),
+		pageSize:     uint64(os.Getpagesize()),
+	}
+}
+
+// trackedProcess is a process matched in the current cycle.
+type trackedProcess struct {
+	pid     int
+	comm    string
+	matcher ProcessMatcher
+}
+
+// Collect finds matching processes and records their metrics. Processes that
+// exit while being read are skipped.
+func (pc *ProcessCollector) Collect(timestamp time.Time) error {
+	processes, err := pc.findProcesses()
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[int]bool, len(processes))
+	for _, process := range processes {
+		seen[process.pid] = true
+		pc.collectProcess(process, timestamp)
+	}
+
+	// Forget PIDs that exited so a reused PID does not inherit their CPU history
+	for pid := range pc.previous {
+		if !seen[pid] {
+			delete(pc.previous, pid)
+		}
+	}
+	return nil
+}
+
+// findProcesses returns matching processes in PID order, bounded by maxProcesses.
+func (pc *ProcessCollector) findProcesses() ([]trackedProcess, error) {
+	entries, err := os.ReadDir(pc.procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []int
+	for _, entry := range entries {
+		if pid, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			pids = append(pids, pid)
+		}
+	}
+	sort.Ints(pids)
+
+	var processes []trackedProcess
+	for _, pid := range pids {
+		comm, err := pc.readFile(pid, "comm")
+		if err != nil {
+			continue
+		}
+		comm = strings.TrimSpace(comm)
+
+		cmdline := ""
+		if raw, err := pc.readFile(pid, "cmdline"); err == nil {
+			cmdline = strings.TrimSpace(strings.ReplaceAll(raw, "\x00", " "))
+		}
+
+		for _, matcher := range pc.matchers {
+			if matcher.matches(comm, cmdline) {
+				processes = append(processes, trackedProcess{pid: pid, comm: comm, matcher: matcher})
+				break
+			}
+		}
+		if len(processes) == pc.maxProcesses {
+			break
+		}
+	}
+	return processes, nil
+}
+
+// collectProcess records the metrics of a single process.
+func (pc *ProcessCollector) collectProcess(process trackedProcess, timestamp time.Time) {
+	tags := map[string]string{
+		"comm":    process.comm,
+		"pid":     strconv.Itoa(process.pid),
+		"matcher": process.matcher.String(),
+	}
+	add := func(name string, value interface{}) {
+		pc.buffer.Add(pc.transformer.Apply(types.TelemetryEntry{
+			Timestamp: timestamp,
+			Source:    types.SourceSystem,
+			Type:      types.TypeProcess,
+			Name:      name,
+			Value:     value,
+			Tags:      tags,
+		}))
+	}
+
+	if statm, err := pc.readFile(process.pid, "statm"); err == nil {
+		fields := strings.Fields(statm)
+		if len(fields) >= 2 {
+			if pages, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				add("process_rss_bytes", pages*pc.pageSize)
+			}
+		}
+	}
+
+	if ticks, err := pc.readCPUTicks(process.pid); err == nil {
+		add("process_cpu_seconds_total", float64(ticks)/clockTicksPerSecond)
+
+		if prev, ok := pc.previous[process.pid]; ok && timestamp.After(prev.at) && ticks >= prev.ticks {
+			elapsed := timestamp.Sub(prev.at).Seconds()
+			add("process_cpu_percent", float64(ticks-prev.ticks)/clockTicksPerSecond/elapsed*100)
+		}
+		pc.previous[process.pid] = cpuSample{ticks: ticks, at: timestamp}
+	}
+
+	if fds, err := os.ReadDir(filepath.Join(pc.procRoot, strconv.Itoa(process.pid), "fd")); err == nil {
+		add("process_open_fds", len(fds))
+
+		if pc.fdLimits {
+			if limits, err := pc.readFile(process.pid, "limits"); err == nil {
+				if usage, err := parseFDLimits(limits); err == nil {
+					usage.Open = len(fds)
+					for _, entry := range fdEntries("process_", usage, timestamp, tags) {
+						pc.buffer.Add(pc.transformer.Apply(entry))
+					}
+				}
+			}
+		}
+	}
+}
+
+// readCPUTicks returns the user plus system CPU time of a process in clock ticks.
+func (pc *ProcessCollector) readCPUTicks(pid int) (uint64, error) {
+	stat, err := pc.readFile(pid, "stat")
+	if err != nil {
+		return 0, err
+	}
+
+	// The command name may contain spaces and parentheses; fields follow the last ')'
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, fmt.Errorf("invalid stat format for pid %d", pid)
+	}
+	fields := strings.Fields(stat[end+1:])
+	// utime and stime are fields 14 and 15 of stat, i.e. 11 and 12 after the command name
+	if len(fields) < 13 {
+		return 0, fmt.Errorf("invalid stat format for pid %d", pid)
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return utime + stime, nil
+}
+
+// readFile reads a file from a process directory.
+func (pc *ProcessCollector) readFile(pid int, name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(pc.procRoot, strconv.Itoa(pid), name))
+	return string(data), err
+}
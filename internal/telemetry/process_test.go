@@ -0,0 +1,190 @@
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// fakeProcess describes a process written to a synthetic proc root.
+type fakeProcess struct {
+	pid      int
+	comm     string
+	cmdline  []string
+	rssPages int
+	ticks    int
+	fds      int
+}
+
+// writeFakeProcess creates /proc/<pid> files for the process under root.
+func writeFakeProcess(t *testing.T, root string, p fakeProcess) {
+	t.Helper()
+	dir := filepath.Join(root, strconv.Itoa(p.pid))
+	if err := os.MkdirAll(filepath.Join(dir, "fd"), 0755); err != nil {
+		t.Fatalf("Failed to create process dir: %v", err)
+	}
+
+	// utime and stime are the 14th and 15th fields; split ticks between them
+	stat := fmt.Sprintf("%d (%s) S 1 1 1 0 -1 4194560 100 0 0 0 %d %d 0 0 20 0 1 0 100 1000000 %d",
+		p.pid, p.comm, p.ticks/2, p.ticks-p.ticks/2, p.rssPages)
+	files := map[string]string{
+		"comm":    p.comm + "\n",
+		"cmdline": strings.Join(p.cmdline, "\x00") + "\x00",
+		"stat":    stat,
+		"statm":   fmt.Sprintf("5000 %d 300 10 0 800 0\n", p.rssPages),
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	for fd := 0; fd < p.fds; fd++ {
+		os.WriteFile(filepath.Join(dir, "fd", strconv.Itoa(fd)), nil, 0644)
+	}
+}
+
+// processValues indexes collected entries by "pid/name".
+func processValues(entries []types.TelemetryEntry) map[string]interface{} {
+	values := make(map[string]interface{})
+	for _, entry := range entries {
+		values[entry.Tags["pid"]+"/"+entry.Name] = entry.Value
+	}
+	return values
+}
+
+// TestProcessCollector validates per-process metrics against a synthetic proc root.
+func TestProcessCollector(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	pageSize := uint64(os.Getpagesize())
+
+	t.Run("collects metrics for matching processes", func(t *testing.T) {
+		root := t.TempDir()
+		writeFakeProcess(t, root, fakeProcess{pid: 100, comm: "postgres", cmdline: []string{"/usr/bin/postgres", "-D", "/data"}, rssPages: 256, ticks: 500, fds: 3})
+		writeFakeProcess(t, root, fakeProcess{pid: 200, comm: "java", cmdline: []string{"java", "-jar", "kafka.jar"}, rssPages: 1024, ticks: 1000, fds: 5})
+		writeFakeProcess(t, root, fakeProcess{pid: 300, comm: "bash", cmdline: []string{"bash"}, rssPages: 10, ticks: 1, fds: 1})
+		os.MkdirAll(filepath.Join(root, "sys"), 0755)
+
+		matchers, _ := ParseProcessMatchers([]string{"postgres", "cmdline:java .*kafka"})
+		buffer := &mockTelemetryBuffer{}
+		collector := NewProcessCollector(root, matchers, 0, buffer)
+
+		if err := collector.Collect(base); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		values := processValues(buffer.entries)
+		if values["100/process_rss_bytes"] != 256*pageSize {
+			t.Errorf("Expected postgres RSS %d, got %v", 256*pageSize, values["100/process_rss_bytes"])
+		}
+		if values["100/process_cpu_seconds_total"] != 5.0 {
+			t.Errorf("Expected postgres CPU seconds 5, got %v", values["100/process_cpu_seconds_total"])
+		}
+		if values["200/process_open_fds"] != 5 {
+			t.Errorf("Expected java open fds 5, got %v", values["200/process_open_fds"])
+		}
+		if _, ok := values["300/process_rss_bytes"]; ok {
+			t.Error("Expected unmatched process to be ignored")
+		}
+		if _, ok := values["100/process_cpu_percent"]; ok {
+			t.Error("Expected no CPU percent on first sample")
+		}
+
+		for _, entry := range buffer.entries {
+			if entry.Tags["pid"] == "200" && (entry.Tags["comm"] != "java" || entry.Tags["matcher"] != "cmdline:java .*kafka") {
+				t.Errorf("Expected java tags, got %v", entry.Tags)
+			}
+			if entry.Source != types.SourceSystem || entry.Type != types.TypeProcess {
+				t.Errorf("Expected system process entry, got %s %s", entry.Source, entry.Type)
+			}
+		}
+	})
+
+	t.Run("computes CPU percent between samples", func(t *testing.T) {
+		root := t.TempDir()
+		writeFakeProcess(t, root, fakeProcess{pid: 100, comm: "postgres", ticks: 500})
+		matchers, _ := ParseProcessMatchers([]string{"postgres"})
+		buffer := &mockTelemetryBuffer{}
+		collector := NewProcessCollector(root, matchers, 0, buffer)
+
+		collector.Collect(base)
+		// 50 ticks over 1 second is half a core
+		writeFakeProcess(t, root, fakeProcess{pid: 100, comm: "postgres", ticks: 550})
+		buffer.entries = nil
+		collector.Collect(base.Add(time.Second))
+
+		if cpu := processValues(buffer.entries)["100/process_cpu_percent"]; cpu != 50.0 {
+			t.Errorf("Expected 50%% CPU, got %v", cpu)
+		}
+	})
+
+	t.Run("follows restarted process to new pid", func(t *testing.T) {
+		root := t.TempDir()
+		writeFakeProcess(t, root, fakeProcess{pid: 100, comm: "postgres", ticks: 500})
+		matchers, _ := ParseProcessMatchers([]string{"postgres"})
+		buffer := &mockTelemetryBuffer{}
+		collector := NewProcessCollector(root, matchers, 0, buffer)
+		collector.Collect(base)
+
+		os.RemoveAll(filepath.Join(root, "100"))
+		writeFakeProcess(t, root, fakeProcess{pid: 400, comm: "postgres", ticks: 10})
+		buffer.entries = nil
+		collector.Collect(base.Add(time.Second))
+
+		values := processValues(buffer.entries)
+		if values["400/process_cpu_seconds_total"] != 0.1 {
+			t.Errorf("Expected restarted process to be tracked, got %v", values)
+		}
+		if _, ok := values["400/process_cpu_percent"]; ok {
+			t.Error("Expected no CPU percent for a new pid")
+		}
+		if _, ok := collector.previous[100]; ok {
+			t.Error("Expected exited pid to be forgotten")
+		}
+	})
+
+	t.Run("bounds tracked processes", func(t *testing.T) {
+		root := t.TempDir()
+		for pid := 1; pid <= 5; pid++ {
+			writeFakeProcess(t, root, fakeProcess{pid: pid, comm: "worker", ticks: 1})
+		}
+		matchers, _ := ParseProcessMatchers([]string{"worker"})
+		buffer := &mockTelemetryBuffer{}
+		collector := NewProcessCollector(root, matchers, 2, buffer)
+
+		collector.Collect(base)
+
+		pids := make(map[string]bool)
+		for _, entry := range buffer.entries {
+			pids[entry.Tags["pid"]] = true
+		}
+		if len(pids) != 2 || !pids["1"] || !pids["2"] {
+			t.Errorf("Expected the 2 lowest pids, got %v", pids)
+		}
+	})
+}
+
+// TestParseProcessMatchers validates process matcher parsing.
+func TestParseProcessMatchers(t *testing.T) {
+	matchers, err := ParseProcessMatchers([]string{"postgres", "comm:nginx", " cmdline:java .*kafka ", ""})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(matchers) != 3 {
+		t.Fatalf("Expected 3 matchers, got %d", len(matchers))
+	}
+	if matchers[1].Comm != "nginx" || !matchers[2].matches("java", "java -jar kafka.jar") {
+		t.Errorf("Expected comm and cmdline matchers, got %v", matchers)
+	}
+
+	for _, spec := range []string{"cmdline:(", "comm:"} {
+		if _, err := ParseProcessMatchers([]string{spec}); err == nil {
+			t.Errorf("Expected error for %q", spec)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// socketCounterRates maps the cumulative /proc/net/snmp counters, by protocol
+// and field, to the names of their per-second rates.
+var socketCounterRates = []struct {
+	protocol, field, name string
+}{
+	{"Tcp", "RetransSegs", "tcp_retrans_segs_per_sec"},
+	{"Tcp", "InErrs", "tcp_in_errs_per_sec"},
+	{"Tcp", "OutRsts", "tcp_out_rsts_per_sec"},
+	{"Udp", "InErrors", "udp_in_errors_per_sec"},
+	{"Udp", "RcvbufErrors", "udp_rcvbuf_errors_per_sec"},
+	{"Udp", "SndbufErrors", "udp_sndbuf_errors_per_sec"},
+	{"Udp", "NoPorts", "udp_no_ports_per_sec"},
+}
+
+// socketGauges maps the /proc/net/sockstat fields, by protocol and field, to
+// the names of the entries reporting them. Fields counted in pages are
+// converted to bytes.
+var socketGauges = []struct {
+	protocol, field, name string
+	pages                 bool
+}{
+	{"sockets", "used", "sockets_used", false},
+	{"TCP", "inuse", "tcp_sockets_inuse", false},
+	{"TCP", "orphan", "tcp_sockets_orphan", false},
+	{"TCP", "tw", "tcp_sockets_time_wait", false},
+	{"TCP", "alloc", "tcp_sockets_alloc", false},
+	{"TCP", "mem", "tcp_memory_bytes", true},
+	{"UDP", "inuse", "udp_sockets_inuse", false},
+	{"UDP", "mem", "udp_memory_bytes", true},
+}
+
+// socketCounters are the cumulative /proc/net/snmp counters at a point in time.
+type socketCounters struct {
+	// values holds the counters by protocol and field, e.g. values["Udp"]["InErrors"]
+	values map[string]map[string]int64
+	// at is when the counters were read
+	at time.Time
+}
+
+// SocketStatCollector reports socket usage from /proc/net/sockstat and the
+// per-second rates of TCP retransmissions and errors and UDP datagram errors
+// from the cumulative counters of /proc/net/snmp. Dropped datagrams and
+// retransmissions point at network stack problems, such as full socket
+// buffers or packet loss, that interface byte counters do not show. Rates are
+// computed from the difference to the previous collection, so the first
+// collection only reports the socket usage.
+type SocketStatCollector struct {
+	// procRoot is the proc filesystem mount, normally /proc
+	procRoot string
+	// buffer receives the collected telemetry entries
+	buffer TelemetryBuffer
+	// pageSize converts the sockstat memory fields from pages to bytes
+	pageSize int
+	// previous holds the counters of the last collection (nil before the first)
+	previous *socketCounters
+	// transformer rewrites entries before they are buffered, set by the
+	// SystemCollector (nil leaves them unchanged)
+	transformer *Transformer
+}
+
+// NewSocketStatCollector creates a collector reading <procRoot>/net/snmp and
+// <procRoot>/net/sockstat (empty procRoot uses /proc).
+func NewSocketStatCollector(procRoot string, buffer TelemetryBuffer) *SocketStatCollector {
+	if procRoot == "" {
+		procRoot = "/proc"
+	}
+	return &SocketStatCollector{
+		procRoot: procRoot,
+		buffer:   buffer,
+		pageSize: os.Getpagesize(),
+	}
+}
+
+// Collect records the socket usage gauges and the counter rates since the
+// previous collection. A counter the kernel does not report, or one that
+// decreased, is skipped for the cycle.
+func (sc *SocketStatCollector) Collect(timestamp time.Time) error {
+	sockstat, err := os.ReadFile(filepath.Join(sc.procRoot, "net", "sockstat"))
+	if err != nil {
+		return err
+	}
+	snmp, err := os.ReadFile(filepath.Join(sc.procRoot, "net", "snmp"))
+	if err != nil {
+		return err
+	}
+	usage, err := parseSockstat(string(sockstat))
+	if err != nil {
+		return err
+	}
+	counters, err := parseSNMP(string(snmp))
+	if err != nil {
+		return err
+	}
+
+	for _, gauge := range socketGauges {
+		value, ok := usage[gauge.protocol][gauge.field]
+		if !ok {
+			continue
+		}
+		if gauge.pages {
+			value *= int64(sc.pageSize)
+		}
+		sc.add(timestamp, gauge.name, float64(value))
+	}
+
+	current := &socketCounters{values: counters, at: timestamp}
+	previous := sc.previous
+	sc.previous = current
+	if previous == nil {
+		return nil
+	}
+	elapsed := current.at.Sub(previous.at).Seconds()
+	if elapsed <= 0 {
+		return nil
+	}
+
+	for _, rate := range socketCounterRates {
+		now, ok := current.values[rate.protocol][rate.field]
+		if !ok {
+			continue
+		}
+		before, ok := previous.values[rate.protocol][rate.field]
+		if !ok || now < before {
+			continue
+		}
+		sc.add(timestamp, rate.name, float64(now-before)/elapsed)
+	}
+	return nil
+}
+
+// add transforms a network entry and adds it to the buffer.
+func (sc *SocketStatCollector) add(timestamp time.Time, name string, value float64) {
+	sc.buffer.Add(sc.transformer.Apply(types.TelemetryEntry{
+		Timestamp: timestamp,
+		Source:    types.SourceSystem,
+		Type:      types.TypeNetwork,
+		Name:      name,
+		Value:     value,
+	}))
+}
+
+// parseSNMP parses /proc/net/snmp content, where each protocol has a line of
+// field names followed by a line of values, both prefixed with the protocol,
+// e.g. "Udp: InDatagrams NoPorts ..." and "Udp: 1234 5 ...".
+func parseSNMP(data string) (map[string]map[string]int64, error) {
+	counters := make(map[string]map[string]int64)
+	lines := strings.Split(data, "\n")
+	for i := 0; i+1 < len(lines); i += 2 {
+		names, values := strings.Fields(lines[i]), strings.Fields(lines[i+1])
+		if len(names) == 0 {
+			break
+		}
+		if len(values) != len(names) || values[0] != names[0] {
+			return nil, fmt.Errorf("mismatched %s lines in snmp", strings.TrimSuffix(names[0], ":"))
+		}
+
+		protocol := strings.TrimSuffix(names[0], ":")
+		fields := make(map[string]int64, len(names)-1)
+		for j := 1; j < len(names); j++ {
+			// Tcp MaxConn is -1 when the connection count is unlimited
+			value, err := strconv.ParseInt(values[j], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %s in snmp: %w", protocol, names[j], err)
+			}
+			fields[names[j]] = value
+		}
+		counters[protocol] = fields
+	}
+	if len(counters) == 0 {
+		return nil, fmt.Errorf("snmp lists no protocols")
+	}
+	return counters, nil
+}
+
+// parseSockstat parses /proc/net/sockstat content, a line per protocol of
+// field name and value pairs, e.g. "TCP: inuse 5 orphan 0 tw 2 alloc 7 mem 1".
+func parseSockstat(data string) (map[string]map[string]int64, error) {
+	usage := make(map[string]map[string]int64)
+	for _, line := range strings.Split(data, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || len(fields)%2 != 1 {
+			continue
+		}
+
+		protocol := strings.TrimSuffix(fields[0], ":")
+		values := make(map[string]int64, len(fields)/2)
+		for j := 1; j+1 < len(fields); j += 2 {
+			value, err := strconv.ParseInt(fields[j+1], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %s in sockstat: %w", protocol, fields[j], err)
+			}
+			values[fields[j]] = value
+		}
+		usage[protocol] = values
+	}
+	if len(usage) == 0 {
+		return nil, fmt.Errorf("sockstat lists no protocols")
+	}
+	return usage, nil
+}
@@ -0,0 +1,183 @@
+package telemetry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// snmpFirst and snmpSecond are /proc/net/snmp samples taken 10s apart, and
+// sockstatSample a /proc/net/sockstat sample.
+const (
+	snmpFirst = `Ip: Forwarding DefaultTTL InReceives InHdrErrors
+Ip: 1 64 2345678 0
+Icmp: InMsgs InErrors
+Icmp: 120 0
+Tcp: RtoAlgorithm RtoMin RtoMax MaxConn ActiveOpens PassiveOpens AttemptFails EstabResets CurrEstab InSegs OutSegs RetransSegs InErrs OutRsts InCsumErrors
+Tcp: 1 200 120000 -1 5000 3000 12 40 25 900000 850000 1200 3 400 0
+Udp: InDatagrams NoPorts InErrors OutDatagrams RcvbufErrors SndbufErrors InCsumErrors IgnoredMulti MemErrors
+Udp: 40000 30 100 38000 80 0 0 0 0
+UdpLite: InDatagrams NoPorts InErrors OutDatagrams RcvbufErrors SndbufErrors InCsumErrors IgnoredMulti MemErrors
+UdpLite: 0 0 0 0 0 0 0 0 0
+`
+	snmpSecond = `Ip: Forwarding DefaultTTL InReceives InHdrErrors
+Ip: 1 64 2355678 0
+Icmp: InMsgs InErrors
+Icmp: 122 0
+Tcp: RtoAlgorithm RtoMin RtoMax MaxConn ActiveOpens PassiveOpens AttemptFails EstabResets CurrEstab InSegs OutSegs RetransSegs InErrs OutRsts InCsumErrors
+Tcp: 1 200 120000 -1 5020 3010 12 40 26 905000 855000 1450 3 450 0
+Udp: InDatagrams NoPorts InErrors OutDatagrams RcvbufErrors SndbufErrors InCsumErrors IgnoredMulti MemErrors
+Udp: 41000 40 600 38500 580 0 0 0 0
+UdpLite: InDatagrams NoPorts InErrors OutDatagrams RcvbufErrors SndbufErrors InCsumErrors IgnoredMulti MemErrors
+UdpLite: 0 0 0 0 0 0 0 0 0
+`
+	sockstatSample = `sockets: used 290
+TCP: inuse 25 orphan 1 tw 12 alloc 31 mem 4
+UDP: inuse 6 mem 2
+UDPLITE: inuse 0
+RAW: inuse 0
+FRAG: inuse 0 memory 0
+`
+)
+
+// writeSocketStats writes net/snmp and net/sockstat content to the proc root.
+func writeSocketStats(t *testing.T, root, snmp, sockstat string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(root, "net"), 0755); err != nil {
+		t.Fatalf("Failed to create net dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "net", "snmp"), []byte(snmp), 0644); err != nil {
+		t.Fatalf("Failed to write snmp: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "net", "sockstat"), []byte(sockstat), 0644); err != nil {
+		t.Fatalf("Failed to write sockstat: %v", err)
+	}
+}
+
+// TestSocketStatCollector validates reporting socket usage and counter rates
+// from synthetic /proc/net/snmp and /proc/net/sockstat samples.
+func TestSocketStatCollector(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	// values returns the entries of a buffer by name.
+	values := func(buffer *mockTelemetryBuffer) map[string]float64 {
+		result := make(map[string]float64)
+		for _, entry := range buffer.entries {
+			result[entry.Name], _ = entry.Value.(float64)
+		}
+		return result
+	}
+
+	t.Run("reports socket usage on every collection", func(t *testing.T) {
+		root := t.TempDir()
+		buffer := &mockTelemetryBuffer{}
+		collector := NewSocketStatCollector(root, buffer)
+		collector.pageSize = 4096
+		writeSocketStats(t, root, snmpFirst, sockstatSample)
+
+		if err := collector.Collect(base); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		expected := map[string]float64{
+			"sockets_used":          290,
+			"tcp_sockets_inuse":     25,
+			"tcp_sockets_orphan":    1,
+			"tcp_sockets_time_wait": 12,
+			"tcp_sockets_alloc":     31,
+			"tcp_memory_bytes":      4 * 4096,
+			"udp_sockets_inuse":     6,
+			"udp_memory_bytes":      2 * 4096,
+		}
+		got := values(buffer)
+		if len(got) != len(expected) {
+			t.Fatalf("Expected %d gauges and no rates from the first sample, got %v", len(expected), got)
+		}
+		for name, value := range expected {
+			if got[name] != value {
+				t.Errorf("Expected %s %v, got %v", name, value, got[name])
+			}
+		}
+	})
+
+	t.Run("computes per-second rates from deltas", func(t *testing.T) {
+		root := t.TempDir()
+		buffer := &mockTelemetryBuffer{}
+		collector := NewSocketStatCollector(root, buffer)
+
+		writeSocketStats(t, root, snmpFirst, sockstatSample)
+		collector.Collect(base)
+		buffer.entries = nil
+		writeSocketStats(t, root, snmpSecond, sockstatSample)
+		if err := collector.Collect(base.Add(10 * time.Second)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		expected := map[string]float64{
+			"tcp_retrans_segs_per_sec":  25,
+			"tcp_in_errs_per_sec":       0,
+			"tcp_out_rsts_per_sec":      5,
+			"udp_in_errors_per_sec":     50,
+			"udp_rcvbuf_errors_per_sec": 50,
+			"udp_sndbuf_errors_per_sec": 0,
+			"udp_no_ports_per_sec":      1,
+		}
+		got := values(buffer)
+		for name, value := range expected {
+			if rate, ok := got[name]; !ok || rate != value {
+				t.Errorf("Expected %s %v, got %v", name, value, got[name])
+			}
+		}
+	})
+
+	t.Run("skips counters that are missing or decreased", func(t *testing.T) {
+		root := t.TempDir()
+		buffer := &mockTelemetryBuffer{}
+		collector := NewSocketStatCollector(root, buffer)
+
+		writeSocketStats(t, root, snmpSecond, sockstatSample)
+		collector.Collect(base)
+		buffer.entries = nil
+		writeSocketStats(t, root, "Tcp: RetransSegs InErrs OutRsts\nTcp: 1500 1 10\nUdp: InErrors NoPorts\nUdp: 700 40\n", sockstatSample)
+		if err := collector.Collect(base.Add(10 * time.Second)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		got := values(buffer)
+		if got["tcp_retrans_segs_per_sec"] != 5 || got["udp_in_errors_per_sec"] != 10 {
+			t.Errorf("Expected the increased counters, got %v", got)
+		}
+		for _, name := range []string{"tcp_out_rsts_per_sec", "udp_rcvbuf_errors_per_sec"} {
+			if _, ok := got[name]; ok {
+				t.Errorf("Expected %s skipped, got %v", name, got[name])
+			}
+		}
+	})
+
+	t.Run("fails for missing or malformed files", func(t *testing.T) {
+		root := t.TempDir()
+		collector := NewSocketStatCollector(root, &mockTelemetryBuffer{})
+
+		if err := collector.Collect(base); err == nil {
+			t.Error("Expected error for missing files")
+		}
+		writeSocketStats(t, root, "Tcp: RetransSegs InErrs\nTcp: 1500\n", sockstatSample)
+		if err := collector.Collect(base); err == nil {
+			t.Error("Expected error for mismatched snmp lines")
+		}
+		writeSocketStats(t, root, snmpFirst, "TCP: inuse many\n")
+		if err := collector.Collect(base); err == nil {
+			t.Error("Expected error for an invalid sockstat value")
+		}
+	})
+
+	t.Run("is set on the system collector", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		system := NewSystemCollector(time.Second, buffer)
+		system.SetSocketStatCollector(NewSocketStatCollector("", buffer))
+		if system.socketStats == nil || system.socketStats.procRoot != "/proc" {
+			t.Error("Expected the socket stat collector to be set")
+		}
+	})
+}
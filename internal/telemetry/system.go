@@ -24,28 +24,210 @@ type SystemCollector struct {
 	interval time.Duration
 	// buffer receives the collected telemetry entries
 	buffer TelemetryBuffer
+	// processes optionally tracks metrics of named processes
+	processes *ProcessCollector
+	// kernelRates optionally reports context switch, interrupt and softirq rates
+	kernelRates *KernelRateCollector
+	// zombies optionally reports zombie processes and raises incidents when they accumulate
+	zombies *ZombieCollector
+	// socketStats optionally reports socket usage and TCP and UDP error rates
+	socketStats *SocketStatCollector
+	// netDevErrors counts /proc/net/dev lines skipped as malformed
+	netDevErrors uint64
+	// interfaces selects the network interfaces collected (nil uses DefaultInterfaceFilter)
+	interfaces *InterfaceFilter
+	// intervalChanged wakes Start to reset its ticker after SetInterval
+	intervalChanged chan struct{}
+	// iowaitIdle counts iowait as idle rather than busy time in CPU usage
+	iowaitIdle bool
+	// transformer rewrites entries before they are buffered (nil leaves them unchanged)
+	transformer *Transformer
+	// fdLimits adds the daemon's, and tracked processes', descriptor limits and usage percentage
+	fdLimits bool
+	// procRoot is the proc filesystem the daemon's own descriptors are read
+	// from (empty uses /proc)
+	procRoot string
+	// startupDelay postpones the first collection after Start
+	startupDelay time.Duration
+	// ready is closed once a full collection cycle has succeeded
+	ready chan struct{}
 }
 
 // TelemetryBuffer interface for adding telemetry entries to storage.
 // This abstraction allows the collector to work with different buffer implementations.
+// Add returns an error when the entry was dropped, e.g. because the buffer is full.
 type TelemetryBuffer interface {
-	Add(entry types.TelemetryEntry)
+	Add(entry types.TelemetryEntry) error
 }
 
 // NewSystemCollector creates a new system telemetry collector with the specified
 // collection interval and target buffer for storing telemetry.
 func NewSystemCollector(interval time.Duration, buffer TelemetryBuffer) *SystemCollector {
 	return &SystemCollector{
-		interval: interval,
-		buffer:   buffer,
+		interval:        interval,
+		buffer:          buffer,
+		intervalChanged: make(chan struct{}, 1),
+		ready:           make(chan struct{}),
 	}
 }
 
-// Start begins collecting system telemetry on the configured interval.
-// This method runs continuously until the context is cancelled and should be
-// called in a separate goroutine.
+// SetInterval changes the collection interval of a running collector, e.g.
+// when the configuration is reloaded. The next collection happens one new
+// interval after the change.
+func (sc *SystemCollector) SetInterval(interval time.Duration) {
+	sc.mutex.Lock()
+	sc.interval = interval
+	sc.mutex.Unlock()
+
+	select {
+	case sc.intervalChanged <- struct{}{}:
+	default:
+	}
+}
+
+// Interval returns the current collection interval.
+func (sc *SystemCollector) Interval() time.Duration {
+	sc.mutex.RLock()
+	defer sc.mutex.RUnlock()
+	return sc.interval
+}
+
+// SetProcessCollector adds per-process metrics for named processes to every
+// collection cycle.
+func (sc *SystemCollector) SetProcessCollector(pc *ProcessCollector) {
+	sc.processes = pc
+	pc.transformer = sc.transformer
+	pc.fdLimits = sc.fdLimits
+}
+
+// SetKernelRateCollector adds context switch, interrupt and softirq rates to
+// every collection cycle.
+func (sc *SystemCollector) SetKernelRateCollector(kc *KernelRateCollector) {
+	sc.kernelRates = kc
+	kc.transformer = sc.transformer
+}
+
+// SetZombieCollector adds the zombie process count to every collection cycle.
+func (sc *SystemCollector) SetZombieCollector(zc *ZombieCollector) {
+	sc.zombies = zc
+	zc.transformer = sc.transformer
+}
+
+// SetSocketStatCollector adds socket usage and TCP and UDP error rates to
+// every collection cycle.
+func (sc *SystemCollector) SetSocketStatCollector(ssc *SocketStatCollector) {
+	sc.socketStats = ssc
+	ssc.transformer = sc.transformer
+}
+
+// SetFDLimits sets whether the soft and hard open file limits of the daemon,
+// read from /proc/self/limits, and of tracked processes are collected along
+// with their open descriptors as a percentage of the soft limit
+// (fd_usage_percent and process_fd_usage_percent).
+func (sc *SystemCollector) SetFDLimits(enabled bool) {
+	sc.fdLimits = enabled
+	if sc.processes != nil {
+		sc.processes.fdLimits = enabled
+	}
+}
+
+// SetTransformer applies the transform rules to every entry collected,
+// including tracked process metrics, before it is buffered.
+func (sc *SystemCollector) SetTransformer(transformer *Transformer) {
+	sc.transformer = transformer
+	if sc.processes != nil {
+		sc.processes.transformer = transformer
+	}
+	if sc.kernelRates != nil {
+		sc.kernelRates.transformer = transformer
+	}
+	if sc.zombies != nil {
+		sc.zombies.transformer = transformer
+	}
+	if sc.socketStats != nil {
+		sc.socketStats.transformer = transformer
+	}
+}
+
+// add transforms an entry and adds it to the buffer.
+func (sc *SystemCollector) add(entry types.TelemetryEntry) {
+	sc.buffer.Add(sc.transformer.Apply(entry))
+}
+
+// SetInterfaceFilter sets which network interfaces are collected, replacing
+// the default exclusion of loopback, CNI and tunnel interfaces. Host-network
+// deployments that want bridge or veth traffic can pass a filter without
+// exclusions.
+func (sc *SystemCollector) SetInterfaceFilter(filter InterfaceFilter) {
+	sc.interfaces = &filter
+}
+
+// SetIOWaitBusy sets whether time spent waiting for I/O counts as busy in the
+// CPU usage percentages (the default). Counting it as idle keeps I/O-bound
+// nodes from appearing CPU saturated.
+func (sc *SystemCollector) SetIOWaitBusy(busy bool) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	sc.iowaitIdle = !busy
+}
+
+// SetStartupDelay postpones the first collection by the delay after Start,
+// e.g. so values read right after node boot are not buffered. Zero collects
+// immediately.
+func (sc *SystemCollector) SetStartupDelay(delay time.Duration) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	sc.startupDelay = delay
+}
+
+// Ready reports whether a full collection cycle has succeeded.
+func (sc *SystemCollector) Ready() bool {
+	select {
+	case <-sc.ready:
+		return true
+	default:
+		return false
+	}
+}
+
+// WaitReady blocks until a full collection cycle has succeeded or the
+// context is done, returning the context's error in the latter case.
+func (sc *SystemCollector) WaitReady(ctx context.Context) error {
+	select {
+	case <-sc.ready:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Check returns an error until a full collection cycle has succeeded, so the
+// collector can gate the daemon's readiness.
+func (sc *SystemCollector) Check() error {
+	if !sc.Ready() {
+		return fmt.Errorf("waiting for the first collection cycle")
+	}
+	return nil
+}
+
+// Start begins collecting system telemetry on the configured interval, after
+// the startup delay. This method runs continuously until the context is
+// cancelled and should be called in a separate goroutine.
 func (sc *SystemCollector) Start(ctx context.Context) error {
-	ticker := time.NewTicker(sc.interval)
+	sc.mutex.RLock()
+	delay := sc.startupDelay
+	sc.mutex.RUnlock()
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	ticker := time.NewTicker(sc.Interval())
 	defer ticker.Stop()
 
 	// Collect initial metrics
@@ -57,6 +239,8 @@ func (sc *SystemCollector) Start(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-sc.intervalChanged:
+			ticker.Reset(sc.Interval())
 		case <-ticker.C:
 			if err := sc.collectMetrics(); err != nil {
 				// Log error but continue collecting
@@ -101,38 +285,81 @@ func (sc *SystemCollector) collectMetrics() error {
 		return fmt.Errorf("load metrics: %w", err)
 	}
 
+	// Collect context switch, interrupt and softirq rates
+	if sc.kernelRates != nil {
+		if err := sc.kernelRates.Collect(timestamp); err != nil {
+			return fmt.Errorf("kernel rate metrics: %w", err)
+		}
+	}
+
+	// Collect socket usage and TCP and UDP error rates
+	if sc.socketStats != nil {
+		if err := sc.socketStats.Collect(timestamp); err != nil {
+			return fmt.Errorf("socket statistics: %w", err)
+		}
+	}
+
+	// Collect zombie processes
+	if sc.zombies != nil {
+		if err := sc.zombies.Collect(timestamp); err != nil {
+			return fmt.Errorf("zombie process metrics: %w", err)
+		}
+	}
+
+	// Collect tracked process metrics
+	if sc.processes != nil {
+		if err := sc.processes.Collect(timestamp); err != nil {
+			return fmt.Errorf("tracked process metrics: %w", err)
+		}
+	}
+
+	sc.mutex.Lock()
+	if !sc.Ready() {
+		close(sc.ready)
+	}
+	sc.mutex.Unlock()
 	return nil
 }
 
 // collectCPUMetrics collects CPU usage per core by parsing /proc/stat.
-// This provides detailed CPU utilization metrics for each CPU core.
+// It calculates usage percentage based on user, nice, system, idle, and other CPU time values.
 func (sc *SystemCollector) collectCPUMetrics(timestamp time.Time) error {
 	data, err := ioutil.ReadFile("/proc/stat")
 	if err != nil {
 		return err
 	}
 
-	lines := strings.Split(string(data), "\n")
+	sc.recordCPUStat(string(data), timestamp)
+	return nil
+}
+
+// recordCPUStat adds the usage of every cpu line of /proc/stat content to the buffer.
+func (sc *SystemCollector) recordCPUStat(data string, timestamp time.Time) {
+	sc.mutex.RLock()
+	iowaitIdle := sc.iowaitIdle
+	sc.mutex.RUnlock()
+
+	lines := strings.Split(data, "\n")
 	for _, line := range lines {
 		if strings.HasPrefix(line, "cpu") {
 			fields := strings.Fields(line)
-			if len(fields) < 8 {
+			times, ok := parseCPUTimes(fields[1:])
+			if !ok {
 				continue
 			}
 
-			cpuName := fields[0]
-			user, _ := strconv.ParseUint(fields[1], 10, 64)
-			nice, _ := strconv.ParseUint(fields[2], 10, 64)
-			system, _ := strconv.ParseUint(fields[3], 10, 64)
-			idle, _ := strconv.ParseUint(fields[4], 10, 64)
-			iowait, _ := strconv.ParseUint(fields[5], 10, 64)
-			irq, _ := strconv.ParseUint(fields[6], 10, 64)
-			softirq, _ := strconv.ParseUint(fields[7], 10, 64)
-
-			total := user + nice + system + idle + iowait + irq + softirq
+			total := times.total()
+			if total == 0 {
+				continue
+			}
+			idle := times.idle
+			if iowaitIdle {
+				idle += times.iowait
+			}
 			usage := float64(total-idle) / float64(total) * 100
 
-			sc.buffer.Add(types.TelemetryEntry{
+			cpuName := fields[0]
+			sc.add(types.TelemetryEntry{
 				Timestamp: timestamp,
 				Source:    types.SourceSystem,
 				Type:      types.TypeCPU,
@@ -144,8 +371,47 @@ func (sc *SystemCollector) collectCPUMetrics(timestamp time.Time) error {
 			})
 		}
 	}
+}
 
-	return nil
+// cpuTimes holds the time counters of a /proc/stat cpu line, in clock ticks.
+type cpuTimes struct {
+	user, nice, system, idle, iowait, irq, softirq, steal uint64
+}
+
+// total returns the CPU time across all states. guest and guest_nice are not
+// added, as the kernel already includes them in user and nice.
+func (ct cpuTimes) total() uint64 {
+	return ct.user + ct.nice + ct.system + ct.idle + ct.iowait + ct.irq + ct.softirq + ct.steal
+}
+
+// parseCPUTimes parses the time fields of a /proc/stat cpu line, without the
+// cpu name. Kernels report between 4 fields (user, nice, system, idle) and 10
+// (adding iowait, irq, softirq, steal, guest and guest_nice); fields a kernel
+// does not report are zero.
+func parseCPUTimes(fields []string) (cpuTimes, bool) {
+	if len(fields) < 4 {
+		return cpuTimes{}, false
+	}
+
+	var values [8]uint64
+	for i := 0; i < len(values) && i < len(fields); i++ {
+		value, err := strconv.ParseUint(fields[i], 10, 64)
+		if err != nil {
+			return cpuTimes{}, false
+		}
+		values[i] = value
+	}
+
+	return cpuTimes{
+		user:    values[0],
+		nice:    values[1],
+		system:  values[2],
+		idle:    values[3],
+		iowait:  values[4],
+		irq:     values[5],
+		softirq: values[6],
+		steal:   values[7],
+	}, true
 }
 
 // collectMemoryMetrics collects memory usage information by parsing /proc/meminfo.
@@ -185,7 +451,7 @@ func (sc *SystemCollector) collectMemoryMetrics(timestamp time.Time) error {
 
 	for _, metric := range metrics {
 		if value, ok := memInfo[metric.key]; ok {
-			sc.buffer.Add(types.TelemetryEntry{
+			sc.add(types.TelemetryEntry{
 				Timestamp: timestamp,
 				Source:    types.SourceSystem,
 				Type:      types.TypeMemory,
@@ -201,7 +467,7 @@ func (sc *SystemCollector) collectMemoryMetrics(timestamp time.Time) error {
 			used := total - available
 			usagePercent := float64(used) / float64(total) * 100
 
-			sc.buffer.Add(types.TelemetryEntry{
+			sc.add(types.TelemetryEntry{
 				Timestamp: timestamp,
 				Source:    types.SourceSystem,
 				Type:      types.TypeMemory,
@@ -216,49 +482,55 @@ func (sc *SystemCollector) collectMemoryMetrics(timestamp time.Time) error {
 
 // collectNetworkMetrics collects network interface statistics by parsing /proc/net/dev.
 // It gathers RX/TX bytes, packets, and errors for each network interface (excluding loopback).
+// Malformed lines are skipped and counted; the first one is logged.
 func (sc *SystemCollector) collectNetworkMetrics(timestamp time.Time) error {
 	data, err := ioutil.ReadFile("/proc/net/dev")
 	if err != nil {
 		return err
 	}
 
-	lines := strings.Split(string(data), "\n")
-	for i, line := range lines {
-		if i < 2 { // Skip header lines
-			continue
-		}
+	sc.recordNetDev(string(data), timestamp)
+	return nil
+}
 
-		fields := strings.Fields(line)
-		if len(fields) < 17 {
-			continue
+// recordNetDev adds telemetry for the interfaces in /proc/net/dev content that
+// pass the interface filter.
+func (sc *SystemCollector) recordNetDev(data string, timestamp time.Time) {
+	filter := DefaultInterfaceFilter()
+	if sc.interfaces != nil {
+		filter = *sc.interfaces
+	}
+
+	stats, errs := parseNetDev(data)
+	if len(errs) > 0 {
+		sc.mutex.Lock()
+		if sc.netDevErrors == 0 {
+			fmt.Printf("Skipping malformed /proc/net/dev line: %v\n", errs[0])
 		}
+		sc.netDevErrors += uint64(len(errs))
+		sc.mutex.Unlock()
+	}
 
-		iface := strings.TrimSuffix(fields[0], ":")
-		if iface == "lo" { // Skip loopback
+	for _, stat := range stats {
+		iface := stat.iface
+		if !filter.Allows(iface) {
 			continue
 		}
 
-		rxBytes, _ := strconv.ParseUint(fields[1], 10, 64)
-		rxPackets, _ := strconv.ParseUint(fields[2], 10, 64)
-		rxErrors, _ := strconv.ParseUint(fields[3], 10, 64)
-		txBytes, _ := strconv.ParseUint(fields[9], 10, 64)
-		txPackets, _ := strconv.ParseUint(fields[10], 10, 64)
-		txErrors, _ := strconv.ParseUint(fields[11], 10, 64)
-
 		metrics := []struct {
 			name  string
 			value uint64
 		}{
-			{fmt.Sprintf("network_rx_bytes_%s", iface), rxBytes},
-			{fmt.Sprintf("network_rx_packets_%s", iface), rxPackets},
-			{fmt.Sprintf("network_rx_errors_%s", iface), rxErrors},
-			{fmt.Sprintf("network_tx_bytes_%s", iface), txBytes},
-			{fmt.Sprintf("network_tx_packets_%s", iface), txPackets},
-			{fmt.Sprintf("network_tx_errors_%s", iface), txErrors},
+			{fmt.Sprintf("network_rx_bytes_%s", iface), stat.rxBytes},
+			{fmt.Sprintf("network_rx_packets_%s", iface), stat.rxPackets},
+			{fmt.Sprintf("network_rx_errors_%s", iface), stat.rxErrors},
+			{fmt.Sprintf("network_tx_bytes_%s", iface), stat.txBytes},
+			{fmt.Sprintf("network_tx_packets_%s", iface), stat.txPackets},
+			{fmt.Sprintf("network_tx_errors_%s", iface), stat.txErrors},
 		}
 
 		for _, metric := range metrics {
-			sc.buffer.Add(types.TelemetryEntry{
+			sc.add(types.TelemetryEntry{
 				Timestamp: timestamp,
 				Source:    types.SourceSystem,
 				Type:      types.TypeNetwork,
@@ -270,8 +542,81 @@ func (sc *SystemCollector) collectNetworkMetrics(timestamp time.Time) error {
 			})
 		}
 	}
+}
+
+// NetDevParseErrors returns the number of /proc/net/dev lines skipped as malformed.
+func (sc *SystemCollector) NetDevParseErrors() uint64 {
+	sc.mutex.RLock()
+	defer sc.mutex.RUnlock()
+	return sc.netDevErrors
+}
+
+// netDevStats holds the counters of one interface from /proc/net/dev.
+type netDevStats struct {
+	iface     string
+	rxBytes   uint64
+	rxPackets uint64
+	rxErrors  uint64
+	txBytes   uint64
+	txPackets uint64
+	txErrors  uint64
+}
+
+// netDevMinFields is the number of counters needed after the interface name:
+// eight receive columns followed by transmit bytes, packets and errors.
+const netDevMinFields = 11
+
+// parseNetDev parses the contents of /proc/net/dev. The interface name ends at
+// the first colon, whether or not whitespace follows it ("eth0: 123" and
+// "eth0:123" are both accepted), and any number of counters beyond the ones
+// used is tolerated. Header and blank lines are ignored; other lines that
+// cannot be parsed are returned as errors and skipped.
+func parseNetDev(data string) ([]netDevStats, []error) {
+	var stats []netDevStats
+	var errs []error
+
+	for i, line := range strings.Split(data, "\n") {
+		if strings.TrimSpace(line) == "" || strings.Contains(line, "|") {
+			continue
+		}
+
+		name, counters, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			errs = append(errs, fmt.Errorf("line %d: missing interface name: %q", i+1, line))
+			continue
+		}
+
+		fields := strings.Fields(counters)
+		if len(fields) < netDevMinFields {
+			errs = append(errs, fmt.Errorf("line %d: interface %s has %d counters, expected at least %d", i+1, name, len(fields), netDevMinFields))
+			continue
+		}
+
+		values := make([]uint64, netDevMinFields)
+		var parseErr error
+		for j := range values {
+			if values[j], parseErr = strconv.ParseUint(fields[j], 10, 64); parseErr != nil {
+				break
+			}
+		}
+		if parseErr != nil {
+			errs = append(errs, fmt.Errorf("line %d: interface %s: %w", i+1, name, parseErr))
+			continue
+		}
+
+		stats = append(stats, netDevStats{
+			iface:     name,
+			rxBytes:   values[0],
+			rxPackets: values[1],
+			rxErrors:  values[2],
+			txBytes:   values[8],
+			txPackets: values[9],
+			txErrors:  values[10],
+		})
+	}
 
-	return nil
+	return stats, errs
 }
 
 // collectDiskMetrics collects disk I/O statistics by parsing /proc/diskstats.
@@ -313,7 +658,7 @@ func (sc *SystemCollector) collectDiskMetrics(timestamp time.Time) error {
 		}
 
 		for _, metric := range metrics {
-			sc.buffer.Add(types.TelemetryEntry{
+			sc.add(types.TelemetryEntry{
 				Timestamp: timestamp,
 				Source:    types.SourceSystem,
 				Type:      types.TypeDisk,
@@ -335,7 +680,7 @@ func (sc *SystemCollector) collectProcessMetrics(timestamp time.Time) error {
 	// Count open file descriptors
 	fdCount, err := sc.countOpenFiles()
 	if err == nil {
-		sc.buffer.Add(types.TelemetryEntry{
+		sc.add(types.TelemetryEntry{
 			Timestamp: timestamp,
 			Source:    types.SourceSystem,
 			Type:      types.TypeProcess,
@@ -344,10 +689,14 @@ func (sc *SystemCollector) collectProcessMetrics(timestamp time.Time) error {
 		})
 	}
 
+	if sc.fdLimits {
+		sc.collectFDLimits(timestamp)
+	}
+
 	// Count processes
 	procCount, err := sc.countProcesses()
 	if err == nil {
-		sc.buffer.Add(types.TelemetryEntry{
+		sc.add(types.TelemetryEntry{
 			Timestamp: timestamp,
 			Source:    types.SourceSystem,
 			Type:      types.TypeProcess,
@@ -359,6 +708,30 @@ func (sc *SystemCollector) collectProcessMetrics(timestamp time.Time) error {
 	return nil
 }
 
+// collectFDLimits collects the daemon's own open descriptors, limits and
+// usage percentage. Failures leave them out of the cycle.
+func (sc *SystemCollector) collectFDLimits(timestamp time.Time) {
+	procRoot := sc.procRoot
+	if procRoot == "" {
+		procRoot = "/proc"
+	}
+	usage, err := ReadFDUsage(procRoot, "self")
+	if err != nil {
+		return
+	}
+
+	sc.add(types.TelemetryEntry{
+		Timestamp: timestamp,
+		Source:    types.SourceSystem,
+		Type:      types.TypeProcess,
+		Name:      "fd_open",
+		Value:     usage.Open,
+	})
+	for _, entry := range fdEntries("", usage, timestamp, nil) {
+		sc.add(entry)
+	}
+}
+
 // collectLoadMetrics collects system load averages by parsing /proc/loadavg.
 // It gathers 1-minute, 5-minute, and 15-minute load averages.
 func (sc *SystemCollector) collectLoadMetrics(timestamp time.Time) error {
@@ -383,7 +756,7 @@ func (sc *SystemCollector) collectLoadMetrics(timestamp time.Time) error {
 		}
 
 		for _, load := range loads {
-			sc.buffer.Add(types.TelemetryEntry{
+			sc.add(types.TelemetryEntry{
 				Timestamp: timestamp,
 				Source:    types.SourceSystem,
 				Type:      types.TypeProcess,
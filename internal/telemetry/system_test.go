@@ -23,8 +23,9 @@ type mockTelemetryBuffer struct {
 }
 
 // Add records telemetry entries for test validation.
-func (m *mockTelemetryBuffer) Add(entry types.TelemetryEntry) {
+func (m *mockTelemetryBuffer) Add(entry types.TelemetryEntry) error {
 	m.entries = append(m.entries, entry)
+	return nil
 }
 
 // TestNewSystemCollector validates collector creation and configuration.
@@ -82,6 +83,31 @@ func TestStart(t *testing.T) {
 	})
 }
 
+// TestSetInterval validates changing the collection interval of a running collector.
+func TestSetInterval(t *testing.T) {
+	buffer := &mockTelemetryBuffer{}
+	collector := NewSystemCollector(time.Hour, buffer)
+
+	collector.SetInterval(10 * time.Millisecond)
+	if collector.Interval() != 10*time.Millisecond {
+		t.Errorf("Expected interval 10ms, got %v", collector.Interval())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := collector.Start(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected context deadline exceeded, got %v", err)
+	}
+
+	rounds := make(map[time.Time]bool)
+	for _, entry := range buffer.entries {
+		rounds[entry.Timestamp] = true
+	}
+	if len(rounds) < 2 {
+		t.Errorf("Expected collection on the new interval rather than hourly, got %d rounds", len(rounds))
+	}
+}
+
 // TestCollectCPUMetrics validates CPU metric collection functionality.
 func TestCollectCPUMetrics(t *testing.T) {
 	// Create a temporary /proc/stat file for testing
@@ -128,6 +154,55 @@ ctxt 67890
 	})
 }
 
+// TestRecordCPUStat validates CPU usage from /proc/stat lines with every
+// number of time fields, including steal time on virtualized nodes.
+func TestRecordCPUStat(t *testing.T) {
+	// cpu0 spends 30% idle, 4% in iowait and 3% stolen out of 10000 ticks;
+	// guest and guest_nice are already part of user and nice
+	statContent := `cpu0 4000 500 1500 3000 400 100 200 300 250 50
+cpu1 100 0 100 200
+cpu2 1 2 3
+cpu3 10 x 10 10 0 0 0 0
+intr 12345
+`
+	timestamp := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	usages := func(collector *SystemCollector, buffer *mockTelemetryBuffer) map[string]float64 {
+		collector.recordCPUStat(statContent, timestamp)
+		result := make(map[string]float64)
+		for _, entry := range buffer.entries {
+			result[entry.Name] = entry.Value.(float64)
+		}
+		return result
+	}
+
+	t.Run("counts steal and iowait as busy by default", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		result := usages(NewSystemCollector(time.Second, buffer), buffer)
+
+		expected := map[string]float64{"cpu0_usage_percent": 70, "cpu1_usage_percent": 50}
+		if len(result) != len(expected) {
+			t.Fatalf("Expected %v, got %v", expected, result)
+		}
+		for name, usage := range expected {
+			if result[name] != usage {
+				t.Errorf("Expected %s %v, got %v", name, usage, result[name])
+			}
+		}
+	})
+
+	t.Run("counts iowait as idle when configured", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		collector := NewSystemCollector(time.Second, buffer)
+		collector.SetIOWaitBusy(false)
+		result := usages(collector, buffer)
+
+		if result["cpu0_usage_percent"] != 66 {
+			t.Errorf("Expected cpu0 usage 66, got %v", result["cpu0_usage_percent"])
+		}
+	})
+}
+
 // TestCollectMemoryMetrics validates memory metric collection.
 func TestCollectMemoryMetrics(t *testing.T) {
 	t.Run("memory metrics structure validation", func(t *testing.T) {
@@ -210,6 +285,61 @@ func TestCollectNetworkMetrics(t *testing.T) {
 	})
 }
 
+// TestParseNetDev validates parsing /proc/net/dev, including format variants.
+func TestParseNetDev(t *testing.T) {
+	t.Run("parses standard and no-space formats", func(t *testing.T) {
+		data := "Inter-|   Receive                                                |  Transmit\n" +
+			" face |bytes    packets errs drop fifo frame compressed multicast|bytes    packets errs drop fifo colls carrier compressed\n" +
+			"    lo:    1000      10    0    0    0     0          0         0     1000      10    0    0    0     0       0          0\n" +
+			"  eth0: 1234567    8901    2    0    0     0          0         0  7654321    1098    3    0    0     0       0          0\n" +
+			"eth1:12345 67 0 0 0 0 0 0 54321 76 1 0 0 0 0 0\n" +
+			"wlan0:5 6 7 8 9 10 11 12 13 14 15\n"
+
+		stats, errs := parseNetDev(data)
+		if len(errs) != 0 {
+			t.Fatalf("Expected no errors, got %v", errs)
+		}
+		if len(stats) != 4 {
+			t.Fatalf("Expected 4 interfaces, got %d", len(stats))
+		}
+
+		eth0 := stats[1]
+		if eth0.iface != "eth0" || eth0.rxBytes != 1234567 || eth0.rxPackets != 8901 || eth0.rxErrors != 2 ||
+			eth0.txBytes != 7654321 || eth0.txPackets != 1098 || eth0.txErrors != 3 {
+			t.Errorf("Expected eth0 counters, got %+v", eth0)
+		}
+		eth1 := stats[2]
+		if eth1.iface != "eth1" || eth1.rxBytes != 12345 || eth1.txBytes != 54321 || eth1.txErrors != 1 {
+			t.Errorf("Expected eth1 counters from no-space format, got %+v", eth1)
+		}
+		if wlan0 := stats[3]; wlan0.iface != "wlan0" || wlan0.rxBytes != 5 || wlan0.txErrors != 15 {
+			t.Errorf("Expected wlan0 counters with minimal columns, got %+v", wlan0)
+		}
+	})
+
+	t.Run("skips and reports malformed lines", func(t *testing.T) {
+		data := "  eth0: 1 2 3 4 5 6 7 8 9 10 11 12 13 14 15 16\n" +
+			"  eth1: 1 2 3\n" +
+			"  eth2: 1 2 x 4 5 6 7 8 9 10 11\n" +
+			"garbage without colon\n" +
+			"\n"
+
+		stats, errs := parseNetDev(data)
+		if len(stats) != 1 || stats[0].iface != "eth0" {
+			t.Errorf("Expected only eth0 to parse, got %+v", stats)
+		}
+		if len(errs) != 3 {
+			t.Fatalf("Expected 3 errors, got %v", errs)
+		}
+		if !strings.Contains(errs[0].Error(), "eth1 has 3 counters") {
+			t.Errorf("Expected field count error for eth1, got %v", errs[0])
+		}
+		if !strings.Contains(errs[1].Error(), "eth2") || !strings.Contains(errs[2].Error(), "missing interface name") {
+			t.Errorf("Expected parse and name errors, got %v", errs[1:])
+		}
+	})
+}
+
 // TestCollectDiskMetrics validates disk I/O metric collection.
 func TestCollectDiskMetrics(t *testing.T) {
 	t.Run("disk metrics structure validation", func(t *testing.T) {
@@ -354,6 +484,51 @@ func TestCountProcesses(t *testing.T) {
 	})
 }
 
+// TestStartupDelay validates postponing the first collection and reporting
+// readiness once a collection cycle succeeded.
+func TestStartupDelay(t *testing.T) {
+	t.Run("is not ready before the first cycle", func(t *testing.T) {
+		collector := NewSystemCollector(time.Hour, &mockTelemetryBuffer{})
+		if collector.Ready() || collector.Check() == nil {
+			t.Error("Expected the collector not ready")
+		}
+	})
+
+	t.Run("collects nothing during the delay", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		collector := NewSystemCollector(time.Hour, buffer)
+		collector.SetStartupDelay(time.Hour)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := collector.Start(ctx); err != context.DeadlineExceeded {
+			t.Errorf("Expected context deadline exceeded, got %v", err)
+		}
+		if len(buffer.entries) != 0 || collector.Ready() {
+			t.Errorf("Expected no entries and not ready, got %d entries", len(buffer.entries))
+		}
+	})
+
+	t.Run("becomes ready after the delay and first cycle", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		collector := NewSystemCollector(time.Hour, buffer)
+		collector.SetStartupDelay(20 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		started := time.Now()
+		if err := collector.Start(ctx); err != context.DeadlineExceeded {
+			t.Errorf("Expected context deadline exceeded, got %v", err)
+		}
+		if len(buffer.entries) == 0 || buffer.entries[0].Timestamp.Sub(started) < 20*time.Millisecond {
+			t.Error("Expected entries collected after the delay")
+		}
+		if !collector.Ready() || collector.Check() != nil {
+			t.Error("Expected the collector ready")
+		}
+	})
+}
+
 // TestMetricIntegration validates end-to-end metric collection and buffering.
 func TestMetricIntegration(t *testing.T) {
 	t.Run("collects and buffers metrics", func(t *testing.T) {
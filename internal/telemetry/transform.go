@@ -0,0 +1,188 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// Transform rule actions.
+const (
+	// TransformRename rewrites the names matching the rule's pattern to its
+	// replacement, which may refer to capture groups as $1 or ${name}
+	TransformRename = "rename"
+	// TransformDropTag removes the rule's tag
+	TransformDropTag = "drop_tag"
+	// TransformAddTag sets the rule's tag to its value, replacing any existing value
+	TransformAddTag = "add_tag"
+	// TransformScale multiplies numeric values by the rule's factor, e.g. to
+	// convert kilobytes to bytes; integer values scaled by a whole factor stay
+	// exact integers, and non-numeric values are left unchanged
+	TransformScale = "scale"
+)
+
+// TransformRule is one step of the ingestion pipeline, similar to a
+// Prometheus relabeling rule.
+type TransformRule struct {
+	// Action is rename, drop_tag, add_tag or scale
+	Action string `json:"action"`
+	// Match is a regular expression the whole metric name must match for the
+	// rule to apply; required for rename, optional (matching every entry) otherwise
+	Match string `json:"match,omitempty"`
+	// Replacement is the new name for rename
+	Replacement string `json:"replacement,omitempty"`
+	// Tag is the tag dropped or added
+	Tag string `json:"tag,omitempty"`
+	// Value is the value of an added tag
+	Value string `json:"value,omitempty"`
+	// Factor is the multiplier for scale
+	Factor float64 `json:"factor,omitempty"`
+}
+
+// compiledRule is a validated transform rule.
+type compiledRule struct {
+	TransformRule
+	// match is the anchored name pattern (nil matches every entry)
+	match *regexp.Regexp
+}
+
+// Transformer applies an ordered list of transform rules to telemetry entries
+// before they are buffered, so metrics can be renamed, retagged or rescaled
+// without changing the sidecars or collectors producing them. It is shared by
+// the API and the system collector. A nil Transformer leaves entries unchanged.
+type Transformer struct {
+	rules []compiledRule
+}
+
+// NewTransformer validates and compiles the rules, which are applied in order.
+func NewTransformer(rules []TransformRule) (*Transformer, error) {
+	compiled := make([]compiledRule, 0, len(rules))
+	for i, rule := range rules {
+		cr := compiledRule{TransformRule: rule}
+		if rule.Match != "" {
+			re, err := regexp.Compile("^(?:" + rule.Match + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("transform rule %d: invalid match %q: %w", i, rule.Match, err)
+			}
+			cr.match = re
+		}
+
+		switch rule.Action {
+		case TransformRename:
+			if cr.match == nil || rule.Replacement == "" {
+				return nil, fmt.Errorf("transform rule %d: rename requires match and replacement", i)
+			}
+		case TransformDropTag:
+			if rule.Tag == "" {
+				return nil, fmt.Errorf("transform rule %d: drop_tag requires tag", i)
+			}
+		case TransformAddTag:
+			if rule.Tag == "" || rule.Value == "" {
+				return nil, fmt.Errorf("transform rule %d: add_tag requires tag and value", i)
+			}
+		case TransformScale:
+			if rule.Factor == 0 {
+				return nil, fmt.Errorf("transform rule %d: scale requires a non-zero factor", i)
+			}
+		default:
+			return nil, fmt.Errorf("transform rule %d: unknown action %q (expected rename, drop_tag, add_tag or scale)", i, rule.Action)
+		}
+		compiled = append(compiled, cr)
+	}
+	return &Transformer{rules: compiled}, nil
+}
+
+// Apply returns the entry with every matching rule applied in order. Later
+// rules see the result of earlier ones, so a rule can match a renamed metric.
+// Tags are copied before being changed, as entries of one submission may
+// share their tag map.
+func (t *Transformer) Apply(entry types.TelemetryEntry) types.TelemetryEntry {
+	if t == nil {
+		return entry
+	}
+
+	tagsCopied := false
+	setTags := func(change func(tags map[string]string)) {
+		if !tagsCopied {
+			tags := make(map[string]string, len(entry.Tags)+1)
+			for key, value := range entry.Tags {
+				tags[key] = value
+			}
+			entry.Tags = tags
+			tagsCopied = true
+		}
+		change(entry.Tags)
+	}
+
+	for _, rule := range t.rules {
+		if rule.match != nil && !rule.match.MatchString(entry.Name) {
+			continue
+		}
+
+		switch rule.Action {
+		case TransformRename:
+			entry.Name = rule.match.ReplaceAllString(entry.Name, rule.Replacement)
+		case TransformDropTag:
+			if _, ok := entry.Tags[rule.Tag]; ok {
+				setTags(func(tags map[string]string) { delete(tags, rule.Tag) })
+			}
+		case TransformAddTag:
+			setTags(func(tags map[string]string) { tags[rule.Tag] = rule.Value })
+		case TransformScale:
+			entry.Value = scaleValue(entry.Value, rule.Factor)
+		}
+	}
+	return entry
+}
+
+// scaleValue multiplies a numeric value by factor. Integers, including integer
+// json.Numbers, scaled by a whole factor are multiplied exactly so large
+// counters keep every digit: json.Numbers stay json.Numbers and other integers
+// become int64 (or uint64 when too large). Everything else is converted to
+// float64, which rounds integers beyond 2^53. Non-numeric values are returned
+// unchanged.
+func scaleValue(value interface{}, factor float64) interface{} {
+	if integer, ok := integerValue(value); ok && factor == math.Trunc(factor) && math.Abs(factor) < 1<<63 {
+		scaled := integer.Mul(integer, big.NewInt(int64(factor)))
+		if _, ok := value.(json.Number); ok {
+			return json.Number(scaled.String())
+		}
+		if scaled.IsInt64() {
+			return scaled.Int64()
+		}
+		if scaled.IsUint64() {
+			return scaled.Uint64()
+		}
+	}
+	if f, ok := Float64Value(value); ok {
+		return f * factor
+	}
+	return value
+}
+
+// integerValue returns the value as a big.Int when it is an integer type or a
+// json.Number holding an integer.
+func integerValue(value interface{}) (*big.Int, bool) {
+	switch v := value.(type) {
+	case int:
+		return big.NewInt(int64(v)), true
+	case int32:
+		return big.NewInt(int64(v)), true
+	case int64:
+		return big.NewInt(v), true
+	case uint:
+		return new(big.Int).SetUint64(uint64(v)), true
+	case uint32:
+		return new(big.Int).SetUint64(uint64(v)), true
+	case uint64:
+		return new(big.Int).SetUint64(v), true
+	case json.Number:
+		return new(big.Int).SetString(string(v), 10)
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,145 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestTransformer validates the ingestion transform rules.
+func TestTransformer(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	newTransformer := func(t *testing.T, rules ...TransformRule) *Transformer {
+		t.Helper()
+		transformer, err := NewTransformer(rules)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return transformer
+	}
+
+	t.Run("renames metrics by pattern", func(t *testing.T) {
+		transformer := newTransformer(t, TransformRule{Action: TransformRename, Match: "jvm_(.+)_bytes", Replacement: "java_${1}_bytes"})
+
+		renamed := transformer.Apply(types.TelemetryEntry{Name: "jvm_heap_used_bytes", Value: 1024.0})
+		if renamed.Name != "java_heap_used_bytes" {
+			t.Errorf("Expected java_heap_used_bytes, got %s", renamed.Name)
+		}
+		if kept := transformer.Apply(types.TelemetryEntry{Name: "old_jvm_heap_used_bytes"}); kept.Name != "old_jvm_heap_used_bytes" {
+			t.Errorf("Expected the pattern to match whole names only, got %s", kept.Name)
+		}
+	})
+
+	t.Run("drops and adds tags without changing shared tag maps", func(t *testing.T) {
+		transformer := newTransformer(t,
+			TransformRule{Action: TransformDropTag, Tag: "container_id"},
+			TransformRule{Action: TransformAddTag, Match: "gc_.*", Tag: "team", Value: "platform"},
+		)
+		shared := map[string]string{"pod_name": "api-1", "container_id": "containerd://abc"}
+
+		gc := transformer.Apply(types.TelemetryEntry{Name: "gc_pause_ms", Tags: shared})
+		if _, ok := gc.Tags["container_id"]; ok || gc.Tags["team"] != "platform" || gc.Tags["pod_name"] != "api-1" {
+			t.Errorf("Expected container_id dropped and team added, got %v", gc.Tags)
+		}
+		heap := transformer.Apply(types.TelemetryEntry{Name: "heap_bytes", Tags: shared})
+		if _, ok := heap.Tags["team"]; ok {
+			t.Errorf("Expected team only added to gc metrics, got %v", heap.Tags)
+		}
+		if len(shared) != 2 || shared["container_id"] == "" {
+			t.Errorf("Expected shared tags untouched, got %v", shared)
+		}
+	})
+
+	t.Run("scales numeric values", func(t *testing.T) {
+		transformer := newTransformer(t, TransformRule{Action: TransformScale, Match: ".*_kb", Factor: 1024})
+
+		tests := []struct {
+			value    interface{}
+			expected interface{}
+		}{
+			{4, int64(4096)},
+			{uint64(4), int64(4096)},
+			{4.0, 4096.0},
+			{json.Number("4"), json.Number("4096")},
+			{json.Number("4.5"), 4608.0},
+		}
+		for _, tt := range tests {
+			scaled := transformer.Apply(types.TelemetryEntry{Timestamp: base, Name: "rss_kb", Value: tt.value})
+			if scaled.Value != tt.expected {
+				t.Errorf("Expected %v (%T) scaled to %v (%T), got %v (%T)", tt.value, tt.value, tt.expected, tt.expected, scaled.Value, scaled.Value)
+			}
+		}
+		if text := transformer.Apply(types.TelemetryEntry{Name: "state_kb", Value: "running"}); text.Value != "running" {
+			t.Errorf("Expected non-numeric value unchanged, got %v", text.Value)
+		}
+	})
+
+	t.Run("keeps large integers exact", func(t *testing.T) {
+		transformer := newTransformer(t, TransformRule{Action: TransformScale, Factor: 1000})
+
+		if scaled := transformer.Apply(types.TelemetryEntry{Name: "bytes_total", Value: json.Number("9007199254740993")}); scaled.Value != json.Number("9007199254740993000") {
+			t.Errorf("Expected exact json.Number 9007199254740993000, got %v", scaled.Value)
+		}
+		if scaled := transformer.Apply(types.TelemetryEntry{Name: "bytes_total", Value: uint64(1) << 60}); scaled.Value != 1.152921504606847e+21 {
+			t.Errorf("Expected an overflowing integer scaled as a float, got %v (%T)", scaled.Value, scaled.Value)
+		}
+
+		halved := newTransformer(t, TransformRule{Action: TransformScale, Factor: 0.5})
+		if scaled := halved.Apply(types.TelemetryEntry{Name: "bytes_total", Value: json.Number("3")}); scaled.Value != 1.5 {
+			t.Errorf("Expected a fractional factor to produce 1.5, got %v (%T)", scaled.Value, scaled.Value)
+		}
+	})
+
+	t.Run("applies rules in order", func(t *testing.T) {
+		transformer := newTransformer(t,
+			TransformRule{Action: TransformRename, Match: "mem_kb", Replacement: "memory_bytes"},
+			TransformRule{Action: TransformScale, Match: "memory_bytes", Factor: 1024},
+		)
+
+		entry := transformer.Apply(types.TelemetryEntry{Name: "mem_kb", Value: 2.0})
+		if entry.Name != "memory_bytes" || entry.Value != 2048.0 {
+			t.Errorf("Expected memory_bytes 2048, got %s %v", entry.Name, entry.Value)
+		}
+	})
+
+	t.Run("nil transformer leaves entries unchanged", func(t *testing.T) {
+		var transformer *Transformer
+		if entry := transformer.Apply(types.TelemetryEntry{Name: "load1", Value: 1.5}); entry.Name != "load1" || entry.Value != 1.5 {
+			t.Errorf("Expected entry unchanged, got %+v", entry)
+		}
+	})
+
+	t.Run("rejects invalid rules", func(t *testing.T) {
+		invalid := []TransformRule{
+			{Action: "relabel"},
+			{Action: TransformRename, Replacement: "x"},
+			{Action: TransformRename, Match: "x"},
+			{Action: TransformRename, Match: "(", Replacement: "x"},
+			{Action: TransformDropTag},
+			{Action: TransformAddTag, Tag: "team"},
+			{Action: TransformScale},
+		}
+		for _, rule := range invalid {
+			if _, err := NewTransformer([]TransformRule{rule}); err == nil {
+				t.Errorf("Expected error for %+v", rule)
+			}
+		}
+	})
+
+	t.Run("system collector transforms collected entries", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		collector := NewSystemCollector(time.Second, buffer)
+		collector.SetTransformer(newTransformer(t,
+			TransformRule{Action: TransformRename, Match: "(cpu\\d+)_usage_percent", Replacement: "${1}_busy_ratio"},
+			TransformRule{Action: TransformScale, Match: ".*_busy_ratio", Factor: 0.01},
+		))
+
+		collector.recordCPUStat("cpu0 50 0 0 50\n", base)
+		if len(buffer.entries) != 1 || buffer.entries[0].Name != "cpu0_busy_ratio" || buffer.entries[0].Value != 0.5 {
+			t.Errorf("Expected cpu0_busy_ratio 0.5, got %+v", buffer.entries)
+		}
+	})
+}
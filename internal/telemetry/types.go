@@ -0,0 +1,109 @@
+package telemetry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// BuiltinTypes are the telemetry types defined by pkg/types, which are always valid.
+var BuiltinTypes = []types.TelemetryType{
+	types.TypeCPU,
+	types.TypeMemory,
+	types.TypeNetwork,
+	types.TypeDisk,
+	types.TypeProcess,
+	types.TypeRuntime,
+	types.TypeApplication,
+	types.TypeCustom,
+}
+
+// validTypeName restricts custom type names to lowercase identifiers, like the
+// built-in types.
+var validTypeName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// TypeDefinition registers a custom telemetry type, e.g. "queue" for queue
+// depths or "business" for KPIs, so it can be filtered and exported like the
+// built-in types.
+type TypeDefinition struct {
+	// Name is the type, a lowercase identifier distinct from the built-in types
+	Name string `json:"name"`
+	// Keywords are the substrings of sidecar metric names inferred as this
+	// type (defaults to the name)
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+// TypeRegistry holds the valid telemetry types: the built-in types and the
+// custom types registered at startup. A nil TypeRegistry knows only the
+// built-in types.
+type TypeRegistry struct {
+	// custom are the custom types in registration order, each with its keywords
+	custom []TypeDefinition
+	// valid contains every built-in and custom type
+	valid map[types.TelemetryType]bool
+}
+
+// NewTypeRegistry validates the custom type definitions and returns a registry
+// of them and the built-in types.
+func NewTypeRegistry(definitions []TypeDefinition) (*TypeRegistry, error) {
+	registry := &TypeRegistry{valid: make(map[types.TelemetryType]bool, len(BuiltinTypes)+len(definitions))}
+	for _, builtin := range BuiltinTypes {
+		registry.valid[builtin] = true
+	}
+
+	for i, definition := range definitions {
+		if !validTypeName.MatchString(definition.Name) {
+			return nil, fmt.Errorf("telemetry type %d: name %q must be a lowercase identifier", i, definition.Name)
+		}
+		if registry.valid[types.TelemetryType(definition.Name)] {
+			return nil, fmt.Errorf("telemetry type %d: %q is already defined", i, definition.Name)
+		}
+
+		keywords := make([]string, 0, len(definition.Keywords))
+		for _, keyword := range definition.Keywords {
+			if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+				keywords = append(keywords, keyword)
+			}
+		}
+		if len(keywords) == 0 {
+			keywords = []string{definition.Name}
+		}
+
+		registry.custom = append(registry.custom, TypeDefinition{Name: definition.Name, Keywords: keywords})
+		registry.valid[types.TelemetryType(definition.Name)] = true
+	}
+	return registry, nil
+}
+
+// Valid reports whether the type is a built-in or registered custom type.
+func (tr *TypeRegistry) Valid(telemetryType types.TelemetryType) bool {
+	if tr == nil {
+		for _, builtin := range BuiltinTypes {
+			if builtin == telemetryType {
+				return true
+			}
+		}
+		return false
+	}
+	return tr.valid[telemetryType]
+}
+
+// Infer returns the first custom type with a keyword contained in the metric
+// name, ignoring case, and false when none matches.
+func (tr *TypeRegistry) Infer(name string) (types.TelemetryType, bool) {
+	if tr == nil {
+		return "", false
+	}
+
+	name = strings.ToLower(name)
+	for _, definition := range tr.custom {
+		for _, keyword := range definition.Keywords {
+			if strings.Contains(name, keyword) {
+				return types.TelemetryType(definition.Name), true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,70 @@
+package telemetry
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestTypeRegistry validates registering and inferring custom telemetry types.
+func TestTypeRegistry(t *testing.T) {
+	t.Run("accepts built-in and registered types", func(t *testing.T) {
+		registry, err := NewTypeRegistry([]TypeDefinition{{Name: "queue"}, {Name: "business", Keywords: []string{"Revenue", " orders "}}})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for _, valid := range []types.TelemetryType{types.TypeCPU, types.TypeCustom, "queue", "business"} {
+			if !registry.Valid(valid) {
+				t.Errorf("Expected %s to be valid", valid)
+			}
+		}
+		if registry.Valid("kpi") {
+			t.Error("Expected unregistered type to be invalid")
+		}
+	})
+
+	t.Run("infers custom types by keyword", func(t *testing.T) {
+		registry, _ := NewTypeRegistry([]TypeDefinition{{Name: "queue"}, {Name: "business", Keywords: []string{"Revenue", " orders "}}})
+		tests := map[string]types.TelemetryType{
+			"jobs_queue_depth":  "queue",
+			"daily_REVENUE_usd": "business",
+			"orders_total":      "business",
+		}
+		for name, expected := range tests {
+			if got, ok := registry.Infer(name); !ok || got != expected {
+				t.Errorf("Expected %s for %s, got %q", expected, name, got)
+			}
+		}
+		if got, ok := registry.Infer("heap_used"); ok {
+			t.Errorf("Expected no custom type, got %s", got)
+		}
+	})
+
+	t.Run("nil registry knows only built-in types", func(t *testing.T) {
+		var registry *TypeRegistry
+		if !registry.Valid(types.TypeDisk) || registry.Valid("queue") {
+			t.Error("Expected only built-in types to be valid")
+		}
+		if _, ok := registry.Infer("queue_depth"); ok {
+			t.Error("Expected no inference without a registry")
+		}
+	})
+
+	t.Run("rejects invalid definitions", func(t *testing.T) {
+		tests := []struct {
+			definitions []TypeDefinition
+			want        string
+		}{
+			{[]TypeDefinition{{Name: ""}}, "lowercase identifier"},
+			{[]TypeDefinition{{Name: "Queue"}}, "lowercase identifier"},
+			{[]TypeDefinition{{Name: "memory"}}, "already defined"},
+			{[]TypeDefinition{{Name: "queue"}, {Name: "queue"}}, "already defined"},
+		}
+		for _, tt := range tests {
+			if _, err := NewTypeRegistry(tt.definitions); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Expected %q error for %+v, got %v", tt.want, tt.definitions, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package telemetry
+
+import (
+	"encoding/json"
+)
+
+// Float64Value converts a numeric telemetry value to float64. Sidecar numbers
+// arrive as json.Number; integers beyond 2^53 lose precision in the
+// conversion, so callers needing exact counters should keep the original value.
+func Float64Value(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,34 @@
+package telemetry
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestFloat64Value validates numeric conversion of telemetry values.
+func TestFloat64Value(t *testing.T) {
+	t.Run("converts native numbers", func(t *testing.T) {
+		for _, value := range []interface{}{42, int32(42), int64(42), uint(42), uint32(42), uint64(42), float32(42), 42.0} {
+			if f, ok := Float64Value(value); !ok || f != 42 {
+				t.Errorf("Expected 42 for %T, got %v (%v)", value, f, ok)
+			}
+		}
+	})
+
+	t.Run("accepts json numbers", func(t *testing.T) {
+		if f, ok := Float64Value(json.Number("81.5")); !ok || f != 81.5 {
+			t.Errorf("Expected 81.5, got %v (%v)", f, ok)
+		}
+		if _, ok := Float64Value(json.Number("not a number")); ok {
+			t.Error("Expected invalid json number rejected")
+		}
+	})
+
+	t.Run("rejects non-numbers", func(t *testing.T) {
+		for _, value := range []interface{}{"12", true, nil} {
+			if _, ok := Float64Value(value); ok {
+				t.Errorf("Expected %v rejected", value)
+			}
+		}
+	})
+}
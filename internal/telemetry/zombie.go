@@ -0,0 +1,181 @@
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// IncidentZombieAccumulation identifies incidents raised when zombie (defunct)
+// processes accumulate, a sign of a parent failing to reap its children.
+const IncidentZombieAccumulation types.IncidentType = "zombie_accumulation"
+
+// maxZombieSamples bounds the command names and parent PIDs reported with an
+// incident, so a runaway parent cannot bloat the report.
+const maxZombieSamples = 10
+
+// zombieProcess is a process found in the zombie state.
+type zombieProcess struct {
+	comm string
+	ppid int
+}
+
+// ZombieCollector reports zombie_processes_total, the number of processes in
+// state Z, and raises a zombie_accumulation incident once the count reaches a
+// threshold. Incidents are debounced: accumulation reports once and again
+// only after the count has dropped below the threshold.
+type ZombieCollector struct {
+	// procRoot is the proc filesystem mount, normally /proc
+	procRoot string
+	// buffer receives the collected telemetry entries
+	buffer TelemetryBuffer
+	// threshold is the zombie count that raises an incident (0 disables incidents)
+	threshold int
+	// report receives raised incidents
+	report func(types.IncidentReport)
+	// reported records that the current accumulation has already raised an incident
+	reported bool
+	// transformer rewrites entries before they are buffered, set by the
+	// SystemCollector (nil leaves them unchanged)
+	transformer *Transformer
+}
+
+// NewZombieCollector creates a collector scanning the processes under procRoot
+// (empty uses /proc) that passes incidents to report once threshold zombies
+// exist. A threshold of zero or less only records the count.
+func NewZombieCollector(procRoot string, threshold int, buffer TelemetryBuffer, report func(types.IncidentReport)) *ZombieCollector {
+	if procRoot == "" {
+		procRoot = "/proc"
+	}
+	return &ZombieCollector{
+		procRoot:  procRoot,
+		buffer:    buffer,
+		threshold: threshold,
+		report:    report,
+	}
+}
+
+// Collect counts the zombie processes, records the count and raises an
+// incident when it newly reaches the threshold. Processes that exit while
+// being read are skipped.
+func (zc *ZombieCollector) Collect(timestamp time.Time) error {
+	zombies, err := zc.findZombies()
+	if err != nil {
+		return err
+	}
+
+	zc.buffer.Add(zc.transformer.Apply(types.TelemetryEntry{
+		Timestamp: timestamp,
+		Source:    types.SourceSystem,
+		Type:      types.TypeProcess,
+		Name:      "zombie_processes_total",
+		Value:     len(zombies),
+	}))
+
+	if zc.threshold <= 0 || zc.report == nil {
+		return nil
+	}
+	if len(zombies) < zc.threshold {
+		zc.reported = false
+		return nil
+	}
+	if !zc.reported {
+		zc.reported = true
+		zc.report(zc.buildIncident(zombies, timestamp))
+	}
+	return nil
+}
+
+// findZombies returns the processes in state Z in PID order.
+func (zc *ZombieCollector) findZombies() ([]zombieProcess, error) {
+	entries, err := os.ReadDir(zc.procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var pids []int
+	for _, entry := range entries {
+		if pid, err := strconv.Atoi(entry.Name()); err == nil && entry.IsDir() {
+			pids = append(pids, pid)
+		}
+	}
+	sort.Ints(pids)
+
+	var zombies []zombieProcess
+	for _, pid := range pids {
+		data, err := os.ReadFile(filepath.Join(zc.procRoot, strconv.Itoa(pid), "stat"))
+		if err != nil {
+			continue
+		}
+		if zombie, ok := parseZombieStat(string(data)); ok {
+			zombies = append(zombies, zombie)
+		}
+	}
+	return zombies, nil
+}
+
+// parseZombieStat returns the command name and parent PID of a
+// /proc/<pid>/stat line, e.g. "4242 (worker) Z 4200 ...", when the process is
+// a zombie.
+func parseZombieStat(stat string) (zombieProcess, bool) {
+	// The command name may contain spaces and parentheses; fields follow the last ')'
+	start, end := strings.IndexByte(stat, '('), strings.LastIndexByte(stat, ')')
+	if start < 0 || end < start {
+		return zombieProcess{}, false
+	}
+	fields := strings.Fields(stat[end+1:])
+	// state and ppid are fields 3 and 4 of stat, i.e. 0 and 1 after the command name
+	if len(fields) < 2 || fields[0] != "Z" {
+		return zombieProcess{}, false
+	}
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return zombieProcess{}, false
+	}
+	return zombieProcess{comm: stat[start+1 : end], ppid: ppid}, true
+}
+
+// buildIncident creates the zombie_accumulation report, with a sample of the
+// zombies' command names and of their parents, the processes failing to reap them.
+func (zc *ZombieCollector) buildIncident(zombies []zombieProcess, timestamp time.Time) types.IncidentReport {
+	var comms []string
+	var parents []int
+	seenComms, seenParents := make(map[string]bool), make(map[int]bool)
+	for _, zombie := range zombies {
+		if !seenComms[zombie.comm] && len(comms) < maxZombieSamples {
+			seenComms[zombie.comm] = true
+			comms = append(comms, zombie.comm)
+		}
+		if !seenParents[zombie.ppid] && len(parents) < maxZombieSamples {
+			seenParents[zombie.ppid] = true
+			parents = append(parents, zombie.ppid)
+		}
+	}
+	sort.Strings(comms)
+	sort.Ints(parents)
+
+	severity := types.SeverityMedium
+	if len(zombies) >= 10*zc.threshold {
+		severity = types.SeverityHigh
+	}
+
+	return types.IncidentReport{
+		ID:        fmt.Sprintf("zombie-accumulation-%d", timestamp.Unix()),
+		Timestamp: timestamp,
+		Severity:  severity,
+		Type:      IncidentZombieAccumulation,
+		Message:   fmt.Sprintf("%d zombie processes have not been reaped (threshold %d)", len(zombies), zc.threshold),
+		Context: map[string]interface{}{
+			"zombie_count":       len(zombies),
+			"threshold":          zc.threshold,
+			"zombie_comms":       comms,
+			"zombie_parent_pids": parents,
+		},
+	}
+}
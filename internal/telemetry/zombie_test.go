@@ -0,0 +1,128 @@
+package telemetry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// writeProcessStat writes /proc/<pid>/stat for a process in the given state
+// under root.
+func writeProcessStat(t *testing.T, root string, pid int, comm, state string, ppid int) {
+	t.Helper()
+	dir := filepath.Join(root, strconv.Itoa(pid))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("Failed to create process dir: %v", err)
+	}
+	stat := fmt.Sprintf("%d (%s) %s %d %d %d 0 -1 4194560 100 0 0 0 5 5 0 0 20 0 1 0 100 0 0", pid, comm, state, ppid, ppid, ppid)
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644); err != nil {
+		t.Fatalf("Failed to write stat: %v", err)
+	}
+}
+
+// TestZombieCollector validates counting zombie processes against a synthetic
+// proc root.
+func TestZombieCollector(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	// zombieRoot returns a proc root with a parent that failed to reap three
+	// children and a running process.
+	zombieRoot := func(t *testing.T) string {
+		root := t.TempDir()
+		writeProcessStat(t, root, 1, "init", "S", 0)
+		writeProcessStat(t, root, 4200, "supervisor", "S", 1)
+		writeProcessStat(t, root, 4201, "worker", "Z", 4200)
+		writeProcessStat(t, root, 4202, "worker", "Z", 4200)
+		writeProcessStat(t, root, 4203, "health (check)", "Z", 4200)
+		os.WriteFile(filepath.Join(root, "stat"), []byte("cpu  1 2 3 4\n"), 0644)
+		return root
+	}
+
+	t.Run("counts processes in state Z", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		collector := NewZombieCollector(zombieRoot(t), 0, buffer, nil)
+
+		if err := collector.Collect(base); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(buffer.entries) != 1 {
+			t.Fatalf("Expected 1 entry, got %d", len(buffer.entries))
+		}
+		entry := buffer.entries[0]
+		if entry.Name != "zombie_processes_total" || entry.Value != 3 || entry.Type != types.TypeProcess {
+			t.Errorf("Expected 3 zombie processes, got %s %v", entry.Name, entry.Value)
+		}
+	})
+
+	t.Run("raises an incident at the threshold", func(t *testing.T) {
+		var reports []types.IncidentReport
+		collector := NewZombieCollector(zombieRoot(t), 3, &mockTelemetryBuffer{}, func(report types.IncidentReport) {
+			reports = append(reports, report)
+		})
+
+		if err := collector.Collect(base); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 incident, got %d", len(reports))
+		}
+		report := reports[0]
+		if report.Type != IncidentZombieAccumulation || report.Severity != types.SeverityMedium {
+			t.Errorf("Expected a medium zombie_accumulation incident, got %s %s", report.Severity, report.Type)
+		}
+		if report.Context["zombie_count"] != 3 || report.Context["threshold"] != 3 {
+			t.Errorf("Expected 3 zombies at threshold 3, got %v", report.Context)
+		}
+		if comms := report.Context["zombie_comms"]; !reflect.DeepEqual(comms, []string{"health (check)", "worker"}) {
+			t.Errorf("Expected the distinct zombie names, got %v", comms)
+		}
+		if parents := report.Context["zombie_parent_pids"]; !reflect.DeepEqual(parents, []int{4200}) {
+			t.Errorf("Expected the supervisor as parent, got %v", parents)
+		}
+	})
+
+	t.Run("reports again only after recovering", func(t *testing.T) {
+		root := zombieRoot(t)
+		var reports []types.IncidentReport
+		collector := NewZombieCollector(root, 2, &mockTelemetryBuffer{}, func(report types.IncidentReport) {
+			reports = append(reports, report)
+		})
+
+		collector.Collect(base)
+		collector.Collect(base.Add(time.Second))
+		if len(reports) != 1 {
+			t.Fatalf("Expected 1 incident while zombies remain, got %d", len(reports))
+		}
+
+		os.RemoveAll(filepath.Join(root, "4202"))
+		os.RemoveAll(filepath.Join(root, "4203"))
+		collector.Collect(base.Add(2 * time.Second))
+		writeProcessStat(t, root, 4204, "worker", "Z", 4200)
+		collector.Collect(base.Add(3 * time.Second))
+		if len(reports) != 2 {
+			t.Errorf("Expected a second incident after recovering, got %d", len(reports))
+		}
+	})
+
+	t.Run("fails for a missing proc root", func(t *testing.T) {
+		collector := NewZombieCollector(filepath.Join(t.TempDir(), "missing"), 1, &mockTelemetryBuffer{}, nil)
+		if err := collector.Collect(base); err == nil {
+			t.Error("Expected error for a missing proc root")
+		}
+	})
+
+	t.Run("is set on the system collector", func(t *testing.T) {
+		buffer := &mockTelemetryBuffer{}
+		system := NewSystemCollector(time.Second, buffer)
+		system.SetZombieCollector(NewZombieCollector("", 0, buffer, nil))
+		if system.zombies == nil || system.zombies.procRoot != "/proc" {
+			t.Error("Expected the zombie collector to be set")
+		}
+	})
+}
@@ -0,0 +1,71 @@
+// Package version exposes build metadata for BlackBox-Daemon. The values are
+// injected at link time, for example:
+//
+//	go build -ldflags "-X github.com/verygoodsoftwarecompany/blackbox-daemon/internal/version.Version=v1.2.3 \
+//	  -X github.com/verygoodsoftwarecompany/blackbox-daemon/internal/version.Commit=abc1234"
+package version
+
+import "encoding/json"
+
+// Build metadata, overridden via -ldflags at build time.
+var (
+	// Version is the release version of the daemon
+	Version = "dev"
+	// Commit is the source control revision the daemon was built from
+	Commit = "unknown"
+	// BuildTime is the UTC time the binary was built
+	BuildTime = "unknown"
+)
+
+// IncidentSchemaVersion is the version of the incident report structure,
+// including the context keys the daemon sets. Bump it whenever fields or
+// context keys are renamed, removed or change meaning, so consumers can branch
+// on the version they parse. New fields, keys and incident types are additive
+// and keep the version.
+const IncidentSchemaVersion = 1
+
+// Incident context keys used when tagging incidents with build metadata.
+const (
+	// ContextVersionKey holds the daemon version in IncidentReport.Context
+	ContextVersionKey = "blackbox_version"
+	// ContextCommitKey holds the daemon commit in IncidentReport.Context
+	ContextCommitKey = "blackbox_commit"
+	// ContextSchemaVersionKey holds the incident schema version in IncidentReport.Context
+	ContextSchemaVersionKey = "schema_version"
+)
+
+// String returns the version and commit in "version (commit)" form.
+func String() string {
+	return Version + " (" + Commit + ")"
+}
+
+// Tag records the daemon version, commit and incident schema version in an
+// incident context map so that incidents from different daemon builds can be
+// told apart. Every incident is tagged when it is created. A nil map is
+// allocated; the (possibly new) map is returned.
+func Tag(context map[string]interface{}) map[string]interface{} {
+	if context == nil {
+		context = make(map[string]interface{})
+	}
+	context[ContextVersionKey] = Version
+	context[ContextCommitKey] = Commit
+	context[ContextSchemaVersionKey] = IncidentSchemaVersion
+	return context
+}
+
+// SchemaVersion returns the schema version recorded in an incident context,
+// including one decoded from JSON, or 0 for incidents that were never tagged,
+// such as those stored by daemons predating the version.
+func SchemaVersion(context map[string]interface{}) int {
+	switch v := context[ContextSchemaVersionKey].(type) {
+	case int:
+		return v
+	case float64:
+		return int(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	default:
+		return 0
+	}
+}
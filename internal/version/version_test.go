@@ -0,0 +1,70 @@
+package version
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestTag validates that build metadata is recorded in incident context.
+func TestTag(t *testing.T) {
+	originalVersion, originalCommit := Version, Commit
+	defer func() { Version, Commit = originalVersion, originalCommit }()
+	Version, Commit = "v1.2.3", "abc1234"
+
+	t.Run("allocates nil context", func(t *testing.T) {
+		context := Tag(nil)
+		if context[ContextVersionKey] != "v1.2.3" {
+			t.Errorf("Expected version 'v1.2.3', got %v", context[ContextVersionKey])
+		}
+		if context[ContextCommitKey] != "abc1234" {
+			t.Errorf("Expected commit 'abc1234', got %v", context[ContextCommitKey])
+		}
+	})
+
+	t.Run("preserves existing context", func(t *testing.T) {
+		context := Tag(map[string]interface{}{"exit_code": 137})
+		if context["exit_code"] != 137 {
+			t.Errorf("Expected existing key to be preserved, got %v", context["exit_code"])
+		}
+		if context[ContextVersionKey] != "v1.2.3" {
+			t.Errorf("Expected version 'v1.2.3', got %v", context[ContextVersionKey])
+		}
+	})
+
+	t.Run("records the schema version", func(t *testing.T) {
+		context := Tag(nil)
+		if context[ContextSchemaVersionKey] != IncidentSchemaVersion || SchemaVersion(context) != IncidentSchemaVersion {
+			t.Errorf("Expected schema version %d, got %v", IncidentSchemaVersion, context[ContextSchemaVersionKey])
+		}
+	})
+
+	t.Run("string includes commit", func(t *testing.T) {
+		if String() != "v1.2.3 (abc1234)" {
+			t.Errorf("Expected 'v1.2.3 (abc1234)', got %q", String())
+		}
+	})
+}
+
+// TestSchemaVersion validates reading the schema version of decoded incidents.
+func TestSchemaVersion(t *testing.T) {
+	for name, tc := range map[string]struct {
+		value    interface{}
+		expected int
+	}{
+		"int":         {value: 1, expected: 1},
+		"json float":  {value: 2.0, expected: 2},
+		"json number": {value: json.Number("3"), expected: 3},
+		"missing":     {value: nil, expected: 0},
+		"malformed":   {value: "v1", expected: 0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			context := map[string]interface{}{}
+			if tc.value != nil {
+				context[ContextSchemaVersionKey] = tc.value
+			}
+			if version := SchemaVersion(context); version != tc.expected {
+				t.Errorf("Expected %d, got %d", tc.expected, version)
+			}
+		})
+	}
+}
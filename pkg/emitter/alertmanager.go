@@ -0,0 +1,335 @@
+package emitter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+const (
+	// DefaultAlertName is the alertname label of the alerts raised for incidents
+	DefaultAlertName = "BlackBoxIncident"
+	// DefaultAlertmanagerTimeout bounds each request to Alertmanager
+	DefaultAlertmanagerTimeout = 10 * time.Second
+	// alertmanagerAlertsPath is the Alertmanager v2 API endpoint receiving alerts
+	alertmanagerAlertsPath = "/api/v2/alerts"
+	// firingRetention is how long the labels of a container's firing alerts
+	// are remembered for resolving them
+	firingRetention = 24 * time.Hour
+	// resolvedIncidentType is the type of the incidents the resolution
+	// handler raises when a crashed container recovers
+	resolvedIncidentType types.IncidentType = "resolved"
+)
+
+// AlertmanagerEmitter posts incidents as alerts to the Alertmanager v2 API, so
+// teams routing alerts through Alertmanager receive incidents alongside their
+// Prometheus alerts. Each incident becomes an alert labelled with its
+// namespace, pod, container, type and severity and annotated with its
+// message. Recoveries reported by the resolution handler are sent as the same
+// alerts with endsAt set, so Alertmanager resolves them. It requires the json
+// formatter.
+type AlertmanagerEmitter struct {
+	// url is the Alertmanager alerts endpoint
+	url string
+	// alertName is the alertname label
+	alertName string
+	// labels are extra static labels added to every alert
+	labels map[string]string
+	// headers are extra static headers added to every request
+	headers map[string]string
+	// generatorURL links alerts back to the daemon (optional)
+	generatorURL string
+	// endsAfter sets the endsAt of firing alerts this long after the incident
+	// (zero leaves it to Alertmanager's resolve_timeout)
+	endsAfter time.Duration
+	// client sends the requests
+	client *http.Client
+	// clock supplies resolution times
+	clock clock.Clock
+	// mutex protects firing
+	mutex sync.Mutex
+	// firing maps "namespace/pod/container" to the labels of the crash alerts
+	// sent for the container, which its resolution must repeat
+	firing map[string]*firingAlerts
+}
+
+// firingAlerts are the crash alerts sent for one container.
+type firingAlerts struct {
+	// labels are the distinct label sets sent
+	labels []map[string]string
+	// lastSent is when an alert was last sent
+	lastSent time.Time
+}
+
+// alertmanagerAlert is an alert in the Alertmanager v2 API.
+type alertmanagerAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	StartsAt     *time.Time        `json:"startsAt,omitempty"`
+	EndsAt       *time.Time        `json:"endsAt,omitempty"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+}
+
+// NewAlertmanagerEmitter creates an Alertmanager emitter. Supported config
+// keys are "url" (required; the Alertmanager base URL, to which
+// /api/v2/alerts is appended unless present), "alertname" (default
+// BlackBoxIncident), "labels" (a map of extra labels), "headers" (a map of
+// extra headers, e.g. for authentication), "generator_url", "ends_after" (a
+// duration string; firing alerts end this long after the incident unless
+// resent, default unset) and "timeout" (a duration string, default 10s).
+func NewAlertmanagerEmitter(config map[string]interface{}) (*AlertmanagerEmitter, error) {
+	url, _ := config["url"].(string)
+	if url == "" {
+		return nil, fmt.Errorf("alertmanager emitter: url is required")
+	}
+	url = strings.TrimRight(url, "/")
+	if !strings.HasSuffix(url, alertmanagerAlertsPath) {
+		url += alertmanagerAlertsPath
+	}
+
+	ae := &AlertmanagerEmitter{
+		url:       url,
+		alertName: DefaultAlertName,
+		labels:    make(map[string]string),
+		headers:   make(map[string]string),
+		client:    &http.Client{Timeout: DefaultAlertmanagerTimeout},
+		clock:     clock.Real{},
+		firing:    make(map[string]*firingAlerts),
+	}
+
+	if val, ok := config["alertname"].(string); ok && val != "" {
+		ae.alertName = val
+	}
+	if val, ok := config["labels"].(map[string]interface{}); ok {
+		for name, value := range val {
+			ae.labels[name] = fmt.Sprint(value)
+		}
+	}
+	if val, ok := config["headers"].(map[string]interface{}); ok {
+		for name, value := range val {
+			ae.headers[name] = fmt.Sprint(value)
+		}
+	}
+	if val, ok := config["generator_url"].(string); ok {
+		ae.generatorURL = val
+	}
+	if val, ok := config["ends_after"].(string); ok && val != "" {
+		endsAfter, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("alertmanager emitter: invalid ends_after: %w", err)
+		}
+		if endsAfter < 0 {
+			return nil, fmt.Errorf("alertmanager emitter: ends_after must not be negative")
+		}
+		ae.endsAfter = endsAfter
+	}
+	if val, ok := config["timeout"].(string); ok && val != "" {
+		timeout, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("alertmanager emitter: invalid timeout: %w", err)
+		}
+		ae.client.Timeout = timeout
+	}
+
+	return ae, nil
+}
+
+// Name returns the emitter name for identification and logging.
+func (ae *AlertmanagerEmitter) Name() string {
+	return "alertmanager"
+}
+
+// Emit posts the incident as an alert, or a resolution as the resolved alerts
+// of its container, returning an error for payloads without an incident,
+// transport failures and non-2xx responses.
+func (ae *AlertmanagerEmitter) Emit(data []byte) error {
+	payload := parseIncidentPayload(data)
+	if payload.Incident == nil {
+		return fmt.Errorf("alertmanager emitter: payload has no incident (the json formatter is required)")
+	}
+
+	body, err := json.Marshal(ae.alerts(*payload.Incident))
+	if err != nil {
+		return fmt.Errorf("alertmanager emitter: failed to encode alerts: %w", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, ae.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("alertmanager emitter: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range ae.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := ae.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("alertmanager emitter: request to %s failed: %w", ae.url, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("alertmanager emitter: %s responded with status %d", ae.url, resp.StatusCode)
+	}
+	return nil
+}
+
+// alerts returns the alerts to post for an incident.
+func (ae *AlertmanagerEmitter) alerts(incident types.IncidentReport) []alertmanagerAlert {
+	container, _ := incident.Context["container_name"].(string)
+	key := incident.Namespace + "/" + incident.PodName + "/" + container
+	now := ae.clock.Now()
+
+	if incident.Type == resolvedIncidentType {
+		return ae.resolvedAlerts(incident, key, container, now)
+	}
+
+	labels := ae.alertLabels(incident.Namespace, incident.PodName, container, incident.Type, incident.Severity)
+	alert := alertmanagerAlert{
+		Labels:       labels,
+		Annotations:  alertAnnotations(incident),
+		StartsAt:     &incident.Timestamp,
+		GeneratorURL: ae.generatorURL,
+	}
+	if ae.endsAfter > 0 {
+		endsAt := incident.Timestamp.Add(ae.endsAfter)
+		alert.EndsAt = &endsAt
+	}
+	if incident.Type == types.IncidentCrash || incident.Type == types.IncidentOOM {
+		ae.remember(key, labels, now)
+	}
+	return []alertmanagerAlert{alert}
+}
+
+// resolvedAlerts returns the container's firing crash alerts with endsAt set
+// to the recovery. When they are unknown, e.g. after a restart, the alert is
+// rebuilt from the type and severity the resolution reports.
+func (ae *AlertmanagerEmitter) resolvedAlerts(incident types.IncidentReport, key, container string, now time.Time) []alertmanagerAlert {
+	ae.mutex.Lock()
+	firing := ae.firing[key]
+	delete(ae.firing, key)
+	ae.mutex.Unlock()
+
+	var labelSets []map[string]string
+	if firing != nil {
+		labelSets = firing.labels
+	} else {
+		resolvedType, _ := incident.Context["resolved_type"].(string)
+		labelSets = []map[string]string{
+			ae.alertLabels(incident.Namespace, incident.PodName, container, types.IncidentType(resolvedType), incident.Severity),
+		}
+	}
+
+	endsAt := incident.Timestamp
+	if endsAt.IsZero() {
+		endsAt = now
+	}
+	var startsAt *time.Time
+	if downSince, ok := incident.Context["down_since"].(string); ok {
+		if since, err := time.Parse(time.RFC3339, downSince); err == nil {
+			startsAt = &since
+		}
+	}
+	alerts := make([]alertmanagerAlert, len(labelSets))
+	for i, labels := range labelSets {
+		alerts[i] = alertmanagerAlert{
+			Labels:       labels,
+			Annotations:  alertAnnotations(incident),
+			StartsAt:     startsAt,
+			EndsAt:       &endsAt,
+			GeneratorURL: ae.generatorURL,
+		}
+	}
+	return alerts
+}
+
+// remember records the labels of a crash alert sent for a container, and
+// forgets containers without alerts for longer than firingRetention.
+func (ae *AlertmanagerEmitter) remember(key string, labels map[string]string, now time.Time) {
+	ae.mutex.Lock()
+	defer ae.mutex.Unlock()
+
+	for other, firing := range ae.firing {
+		if now.Sub(firing.lastSent) > firingRetention {
+			delete(ae.firing, other)
+		}
+	}
+
+	firing := ae.firing[key]
+	if firing == nil {
+		firing = &firingAlerts{}
+		ae.firing[key] = firing
+	}
+	firing.lastSent = now
+	for _, sent := range firing.labels {
+		if sameLabels(sent, labels) {
+			return
+		}
+	}
+	firing.labels = append(firing.labels, labels)
+}
+
+// alertLabels returns the labels identifying an incident's alert. Empty
+// values are omitted, as Alertmanager treats them as absent.
+func (ae *AlertmanagerEmitter) alertLabels(namespace, pod, container string, incidentType types.IncidentType, severity types.IncidentSeverity) map[string]string {
+	labels := make(map[string]string, len(ae.labels)+6)
+	for name, value := range ae.labels {
+		labels[name] = value
+	}
+	for name, value := range map[string]string{
+		"alertname":     ae.alertName,
+		"namespace":     namespace,
+		"pod":           pod,
+		"container":     container,
+		"incident_type": string(incidentType),
+		"severity":      string(severity),
+	} {
+		if value != "" {
+			labels[name] = value
+		}
+	}
+	return labels
+}
+
+// alertAnnotations returns the annotations describing an incident.
+func alertAnnotations(incident types.IncidentReport) map[string]string {
+	summary := fmt.Sprintf("%s incident", incident.Type)
+	if incident.PodName != "" {
+		summary += fmt.Sprintf(" in %s/%s", incident.Namespace, incident.PodName)
+	}
+	annotations := map[string]string{
+		"summary":     summary,
+		"incident_id": incident.ID,
+	}
+	if incident.Message != "" {
+		annotations["description"] = incident.Message
+	}
+	return annotations
+}
+
+// sameLabels reports whether two label sets are equal.
+func sameLabels(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, value := range a {
+		if other, ok := b[name]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// Close releases idle connections held by the HTTP client.
+func (ae *AlertmanagerEmitter) Close() error {
+	ae.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,190 @@
+package emitter
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// postedAlert is an alert as the stub Alertmanager decodes it.
+type postedAlert struct {
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     string            `json:"startsAt"`
+	EndsAt       string            `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+}
+
+// alertPayload returns the json formatter output of an incident.
+func alertPayload(t *testing.T, incident types.IncidentReport) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"incident": incident, "telemetry": []types.TelemetryEntry{}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return data
+}
+
+// TestAlertmanagerEmitter validates posting incidents as Alertmanager alerts.
+func TestAlertmanagerEmitter(t *testing.T) {
+	crashedAt := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	crash := types.IncidentReport{
+		ID: "crash-1", Timestamp: crashedAt, PodName: "api-1", Namespace: "prod",
+		Severity: types.SeverityHigh, Type: types.IncidentCrash, Message: "Container app in pod prod/api-1 restarted (count: 1)",
+		Context: map[string]interface{}{"container_name": "app"},
+	}
+
+	// newEmitter returns an emitter posting to a stub Alertmanager.
+	newEmitter := func(t *testing.T, status int, config map[string]interface{}) (*AlertmanagerEmitter, chan receivedRequest, string) {
+		t.Helper()
+		server, received := newWebhookServer(t, status)
+		if config == nil {
+			config = map[string]interface{}{}
+		}
+		config["url"] = server.URL + "/"
+		emitter, err := NewAlertmanagerEmitter(config)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return emitter, received, server.URL
+	}
+
+	// decode returns the alerts of a posted request.
+	decode := func(t *testing.T, req receivedRequest) []postedAlert {
+		t.Helper()
+		var alerts []postedAlert
+		if err := json.Unmarshal(req.body, &alerts); err != nil {
+			t.Fatalf("Expected an alert array, got %s", req.body)
+		}
+		return alerts
+	}
+
+	t.Run("posts the incident as an alert", func(t *testing.T) {
+		emitter, received, _ := newEmitter(t, http.StatusOK, map[string]interface{}{
+			"labels":        map[string]interface{}{"cluster": "eu-1"},
+			"generator_url": "http://blackbox.example/incidents",
+			"ends_after":    "1h",
+		})
+
+		if err := emitter.Emit(alertPayload(t, crash)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		req := <-received
+		if req.header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected JSON content type, got %q", req.header.Get("Content-Type"))
+		}
+		alerts := decode(t, req)
+		if len(alerts) != 1 {
+			t.Fatalf("Expected 1 alert, got %d", len(alerts))
+		}
+		alert := alerts[0]
+		expected := map[string]string{
+			"alertname": "BlackBoxIncident", "namespace": "prod", "pod": "api-1", "container": "app",
+			"incident_type": "crash", "severity": "high", "cluster": "eu-1",
+		}
+		if !sameLabels(alert.Labels, expected) {
+			t.Errorf("Expected labels %v, got %v", expected, alert.Labels)
+		}
+		if alert.Annotations["summary"] != "crash incident in prod/api-1" || alert.Annotations["description"] != crash.Message || alert.Annotations["incident_id"] != "crash-1" {
+			t.Errorf("Expected incident annotations, got %v", alert.Annotations)
+		}
+		if alert.StartsAt != "2024-11-02T15:04:05Z" || alert.EndsAt != "2024-11-02T16:04:05Z" {
+			t.Errorf("Expected startsAt and endsAt an hour later, got %q and %q", alert.StartsAt, alert.EndsAt)
+		}
+		if alert.GeneratorURL != "http://blackbox.example/incidents" {
+			t.Errorf("Expected generator URL, got %q", alert.GeneratorURL)
+		}
+	})
+
+	t.Run("resolves the container's alerts on recovery", func(t *testing.T) {
+		emitter, received, _ := newEmitter(t, http.StatusOK, nil)
+
+		oom := crash
+		oom.ID, oom.Type, oom.Severity = "oom-1", types.IncidentOOM, types.SeverityCritical
+		for _, incident := range []types.IncidentReport{crash, oom, crash} {
+			if err := emitter.Emit(alertPayload(t, incident)); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			<-received
+		}
+
+		recovered := types.IncidentReport{
+			ID: "crash-1-resolved", Timestamp: crashedAt.Add(10 * time.Minute), PodName: "api-1", Namespace: "prod",
+			Severity: types.SeverityCritical, Type: "resolved", Message: "Container app in pod prod/api-1 recovered",
+			Context: map[string]interface{}{"container_name": "app", "resolved_type": "crash", "down_since": crashedAt.Format(time.RFC3339)},
+		}
+		if err := emitter.Emit(alertPayload(t, recovered)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		alerts := decode(t, <-received)
+		if len(alerts) != 2 {
+			t.Fatalf("Expected the crash and OOM alerts resolved, got %+v", alerts)
+		}
+		if alerts[0].Labels["incident_type"] != "crash" || alerts[0].Labels["severity"] != "high" ||
+			alerts[1].Labels["incident_type"] != "oom" || alerts[1].Labels["severity"] != "critical" {
+			t.Errorf("Expected the firing label sets, got %v and %v", alerts[0].Labels, alerts[1].Labels)
+		}
+		for _, alert := range alerts {
+			if alert.StartsAt != "2024-11-02T15:04:05Z" || alert.EndsAt != "2024-11-02T15:14:05Z" {
+				t.Errorf("Expected the downtime as startsAt and endsAt, got %q and %q", alert.StartsAt, alert.EndsAt)
+			}
+		}
+	})
+
+	t.Run("resolves unknown alerts from the resolution", func(t *testing.T) {
+		emitter, received, _ := newEmitter(t, http.StatusOK, nil)
+
+		recovered := types.IncidentReport{
+			ID: "crash-1-resolved", Timestamp: crashedAt, PodName: "api-1", Namespace: "prod", Severity: types.SeverityHigh, Type: "resolved",
+			Context: map[string]interface{}{"container_name": "app", "resolved_type": "crash"},
+		}
+		if err := emitter.Emit(alertPayload(t, recovered)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		alerts := decode(t, <-received)
+		if len(alerts) != 1 || alerts[0].Labels["incident_type"] != "crash" || alerts[0].EndsAt != "2024-11-02T15:04:05Z" {
+			t.Errorf("Expected a resolved crash alert, got %+v", alerts)
+		}
+	})
+
+	t.Run("fails for payloads without an incident and error responses", func(t *testing.T) {
+		emitter, received, _ := newEmitter(t, http.StatusBadRequest, nil)
+
+		if err := emitter.Emit([]byte("crash incident in prod/api-1")); err == nil {
+			t.Error("Expected error for a non-JSON payload")
+		}
+		if err := emitter.Emit(alertPayload(t, crash)); err == nil {
+			t.Error("Expected error for a 400 response")
+		}
+		<-received
+	})
+
+	t.Run("targets the v2 alerts endpoint", func(t *testing.T) {
+		emitter, _, url := newEmitter(t, http.StatusOK, nil)
+		if emitter.url != url+"/api/v2/alerts" {
+			t.Errorf("Expected the v2 alerts endpoint, got %s", emitter.url)
+		}
+		if emitter.Name() != "alertmanager" {
+			t.Errorf("Expected alertmanager, got %s", emitter.Name())
+		}
+	})
+
+	t.Run("rejects invalid configuration", func(t *testing.T) {
+		for _, config := range []map[string]interface{}{
+			{},
+			{"url": "http://alertmanager:9093", "ends_after": "soon"},
+			{"url": "http://alertmanager:9093", "ends_after": "-1m"},
+			{"url": "http://alertmanager:9093", "timeout": "soon"},
+		} {
+			if _, err := NewAlertmanagerEmitter(config); err == nil {
+				t.Errorf("Expected error for %v", config)
+			}
+		}
+	})
+}
@@ -0,0 +1,37 @@
+package emitter
+
+// NewFromConfig creates the emitter described by the configuration. The
+// sqlite, webhook, forward, journald and alertmanager emitters are
+// constructed here; every other type is created by CreateEmitter.
+func NewFromConfig(config EmitterConfig) (Emitter, error) {
+	switch config.Type {
+	case "sqlite":
+		se, err := NewSQLiteEmitter(config.Config)
+		if err != nil {
+			return nil, err
+		}
+		return se, nil
+	case "webhook":
+		we, err := NewWebhookEmitter(config.Config)
+		if err != nil {
+			return nil, err
+		}
+		return we, nil
+	case "forward":
+		fe, err := NewForwardEmitter(config.Config)
+		if err != nil {
+			return nil, err
+		}
+		return fe, nil
+	case "journald":
+		return newJournald(config.Config)
+	case "alertmanager":
+		ae, err := NewAlertmanagerEmitter(config.Config)
+		if err != nil {
+			return nil, err
+		}
+		return ae, nil
+	default:
+		return CreateEmitter(config)
+	}
+}
@@ -0,0 +1,52 @@
+package emitter
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestNewFromConfig validates creating emitters from their configuration.
+func TestNewFromConfig(t *testing.T) {
+	t.Run("creates each emitter type", func(t *testing.T) {
+		for _, config := range []EmitterConfig{
+			{Type: "sqlite", Config: map[string]interface{}{"path": filepath.Join(t.TempDir(), "incidents.db")}},
+			{Type: "webhook", Config: map[string]interface{}{"url": "http://localhost"}},
+			{Type: "forward", Config: map[string]interface{}{"url": "http://localhost"}},
+			{Type: "alertmanager", Config: map[string]interface{}{"url": "http://localhost"}},
+		} {
+			emitter, err := NewFromConfig(config)
+			if err != nil {
+				t.Errorf("Expected %s emitter, got %v", config.Type, err)
+				continue
+			}
+			if emitter.Name() != config.Type {
+				t.Errorf("Expected %s emitter, got %s", config.Type, emitter.Name())
+			}
+			emitter.Close()
+		}
+	})
+
+	t.Run("returns no emitter for an invalid configuration", func(t *testing.T) {
+		for _, config := range []EmitterConfig{
+			{Type: "sqlite", Config: map[string]interface{}{}},
+			{Type: "webhook", Config: map[string]interface{}{}},
+			{Type: "forward", Config: map[string]interface{}{}},
+			{Type: "alertmanager", Config: map[string]interface{}{}},
+			{Type: "journald", Config: map[string]interface{}{"socket": filepath.Join(t.TempDir(), "missing.socket")}},
+		} {
+			emitter, err := NewFromConfig(config)
+			if err == nil {
+				t.Errorf("Expected error for %s without required config", config.Type)
+			}
+			if emitter != nil {
+				t.Errorf("Expected no %s emitter, got %T", config.Type, emitter)
+			}
+		}
+	})
+
+	t.Run("creates other types with CreateEmitter", func(t *testing.T) {
+		if _, err := NewFromConfig(EmitterConfig{Type: "not-an-emitter"}); err == nil {
+			t.Error("Expected error for an unknown type")
+		}
+	})
+}
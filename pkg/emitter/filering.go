@@ -0,0 +1,261 @@
+package emitter
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+const (
+	// incidentRingIndex is the name of the index file of an incident ring
+	incidentRingIndex = "index.json"
+	// MaxIncidentRingSize bounds the files of an incident ring
+	MaxIncidentRingSize = 100000
+)
+
+// IncidentRingEntry describes an incident file of an incident ring in its index.
+type IncidentRingEntry struct {
+	// File is the incident file name within the ring directory, e.g. "incident-3.json"
+	File string `json:"file"`
+	// ID is the incident ID
+	ID string `json:"id"`
+	// Timestamp is when the incident occurred
+	Timestamp time.Time `json:"timestamp"`
+	// Type is the incident type
+	Type types.IncidentType `json:"type"`
+	// Severity is the incident severity
+	Severity types.IncidentSeverity `json:"severity"`
+	// PodName is the pod the incident concerns (empty for node incidents)
+	PodName string `json:"pod_name,omitempty"`
+	// Namespace is the namespace of the pod
+	Namespace string `json:"namespace,omitempty"`
+	// WrittenAt is when the file was written
+	WrittenAt time.Time `json:"written_at"`
+}
+
+// incidentRingIndexFile is the content of index.json.
+type incidentRingIndexFile struct {
+	// Size is the number of files in the ring
+	Size int `json:"size"`
+	// Next is the sequence number of the next incident
+	Next uint64 `json:"next"`
+	// Incidents lists the incident files, oldest first
+	Incidents []IncidentRingEntry `json:"incidents"`
+}
+
+// IncidentRing keeps the last incidents as individual files in a directory,
+// a bounded on-disk incident archive for post-mortem browsing. Incident n is
+// written to incident-<n mod size>.json, overwriting the oldest incident once
+// the ring is full, and index.json lists the files with their incident IDs and
+// timestamps, oldest first. The ring continues from its index after a restart.
+type IncidentRing struct {
+	// mutex serializes writes of the incident files and the index
+	mutex sync.Mutex
+	// dir is the ring directory
+	dir string
+	// size is the number of incident files kept
+	size int
+	// index mirrors index.json
+	index incidentRingIndexFile
+}
+
+// NewIncidentRing creates the ring directory if needed and loads its index.
+// A ring written with another size is renumbered, keeping its newest size
+// incidents, so the ring can be resized.
+func NewIncidentRing(dir string, size int) (*IncidentRing, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("incident ring directory is required")
+	}
+	if size <= 0 || size > MaxIncidentRingSize {
+		return nil, fmt.Errorf("incident ring size must be between 1 and %d", MaxIncidentRingSize)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create incident ring directory: %w", err)
+	}
+
+	ring := &IncidentRing{dir: dir, size: size, index: incidentRingIndexFile{Size: size}}
+	if err := ring.load(); err != nil {
+		return nil, err
+	}
+	if err := ring.writeIndex(); err != nil {
+		return nil, err
+	}
+	return ring, nil
+}
+
+// load reads the index of a previous ring in the directory, if any.
+func (ir *IncidentRing) load() error {
+	data, err := os.ReadFile(filepath.Join(ir.dir, incidentRingIndex))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read incident ring index: %w", err)
+	}
+	var index incidentRingIndexFile
+	if err := json.Unmarshal(data, &index); err != nil {
+		return fmt.Errorf("invalid incident ring index: %w", err)
+	}
+
+	ir.index.Next = index.Next
+	ir.index.Incidents = index.Incidents
+	if index.Size != ir.size {
+		return ir.resize()
+	}
+	return nil
+}
+
+// resize renumbers the loaded incidents of a ring of another size into the
+// first slots, oldest first, and removes the files of older incidents that no
+// longer fit.
+func (ir *IncidentRing) resize() error {
+	incidents := ir.index.Incidents
+	if len(incidents) > ir.size {
+		for _, entry := range incidents[:len(incidents)-ir.size] {
+			os.Remove(filepath.Join(ir.dir, entry.File))
+		}
+		incidents = incidents[len(incidents)-ir.size:]
+	}
+
+	// Move the files aside first, as their new names may still be taken
+	for i, entry := range incidents {
+		if err := os.Rename(filepath.Join(ir.dir, entry.File), filepath.Join(ir.dir, fmt.Sprintf(".resize-%d", i))); err != nil {
+			return fmt.Errorf("failed to resize incident ring: %w", err)
+		}
+	}
+	for i := range incidents {
+		incidents[i].File = ringFile(i)
+		if err := os.Rename(filepath.Join(ir.dir, fmt.Sprintf(".resize-%d", i)), filepath.Join(ir.dir, incidents[i].File)); err != nil {
+			return fmt.Errorf("failed to resize incident ring: %w", err)
+		}
+	}
+	ir.index.Incidents = incidents
+	ir.index.Next = uint64(len(incidents))
+	return nil
+}
+
+// ringFile returns the incident file name of a slot.
+func ringFile(slot int) string {
+	return fmt.Sprintf("incident-%d.json", slot)
+}
+
+// WriteIncident writes the payload to the next file of the ring and updates
+// the index. It returns false without writing when the payload carries no
+// incident, and the caller should write to its single configured path instead.
+func (ir *IncidentRing) WriteIncident(data []byte) (bool, error) {
+	payload := parseIncidentPayload(data)
+	if payload.Incident == nil {
+		return false, nil
+	}
+
+	ir.mutex.Lock()
+	defer ir.mutex.Unlock()
+
+	sequence := ir.index.Next
+	entry := IncidentRingEntry{
+		File:      ringFile(int(sequence % uint64(ir.size))),
+		ID:        payload.Incident.ID,
+		Timestamp: payload.Incident.Timestamp,
+		Type:      payload.Incident.Type,
+		Severity:  payload.Incident.Severity,
+		PodName:   payload.Incident.PodName,
+		Namespace: payload.Incident.Namespace,
+		WrittenAt: time.Now().UTC(),
+	}
+	if err := writeFileAtomic(filepath.Join(ir.dir, entry.File), data); err != nil {
+		return false, fmt.Errorf("failed to write incident file: %w", err)
+	}
+
+	incidents := ir.index.Incidents[:0]
+	for _, existing := range ir.index.Incidents {
+		if existing.File != entry.File {
+			incidents = append(incidents, existing)
+		}
+	}
+	ir.index.Incidents = append(incidents, entry)
+	ir.index.Next = sequence + 1
+	if err := ir.writeIndex(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Entries returns the index entries, oldest first.
+func (ir *IncidentRing) Entries() []IncidentRingEntry {
+	ir.mutex.Lock()
+	defer ir.mutex.Unlock()
+	return append([]IncidentRingEntry(nil), ir.index.Incidents...)
+}
+
+// writeIndex replaces index.json with the current index.
+func (ir *IncidentRing) writeIndex() error {
+	if ir.index.Incidents == nil {
+		ir.index.Incidents = []IncidentRingEntry{}
+	}
+	data, err := json.MarshalIndent(ir.index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode incident ring index: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(ir.dir, incidentRingIndex), data); err != nil {
+		return fmt.Errorf("failed to write incident ring index: %w", err)
+	}
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file in the target's directory,
+// syncs it and renames it over the target, so readers never see a partial
+// file and a crash leaves the previous content in place.
+func writeFileAtomic(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// ParseIncidentRingConfig reads the file emitter's "ring_dir" and "ring_size"
+// config keys. It returns nil when no ring is configured.
+func ParseIncidentRingConfig(config map[string]interface{}) (*IncidentRing, error) {
+	dir, _ := config["ring_dir"].(string)
+	if dir == "" {
+		return nil, nil
+	}
+
+	var size int
+	switch val := config["ring_size"].(type) {
+	case float64:
+		size = int(val)
+		if float64(size) != val {
+			return nil, fmt.Errorf("ring_size must be a whole number")
+		}
+	case int:
+		size = val
+	case nil:
+		return nil, fmt.Errorf("ring_size is required with ring_dir")
+	default:
+		return nil, fmt.Errorf("ring_size must be a number")
+	}
+	return NewIncidentRing(dir, size)
+}
@@ -0,0 +1,180 @@
+package emitter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestIncidentRing validates the rotating set of incident files and its index.
+func TestIncidentRing(t *testing.T) {
+	timestamp := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	// writeIncidents writes incidents incident-0 to incident-<count-1>, a second apart.
+	writeIncidents := func(t *testing.T, ring *IncidentRing, from, count int) {
+		t.Helper()
+		for i := from; i < from+count; i++ {
+			ok, err := ring.WriteIncident(sqliteTestPayload(t, fmt.Sprintf("incident-%d", i), timestamp.Add(time.Duration(i)*time.Second)))
+			if err != nil || !ok {
+				t.Fatalf("Expected incident %d written, got ok=%v err=%v", i, ok, err)
+			}
+		}
+	}
+
+	// readIndex decodes index.json of the ring directory.
+	readIndex := func(t *testing.T, dir string) incidentRingIndexFile {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, "index.json"))
+		if err != nil {
+			t.Fatalf("Failed to read index: %v", err)
+		}
+		var index incidentRingIndexFile
+		if err := json.Unmarshal(data, &index); err != nil {
+			t.Fatalf("Failed to decode index: %v", err)
+		}
+		return index
+	}
+
+	// fileIncidentID returns the incident ID stored in a ring file.
+	fileIncidentID := func(t *testing.T, dir, file string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		payload := parseIncidentPayload(data)
+		if payload.Incident == nil {
+			t.Fatalf("Expected an incident in %s", file)
+		}
+		return payload.Incident.ID
+	}
+
+	t.Run("rotates over the oldest files once full", func(t *testing.T) {
+		dir := t.TempDir()
+		ring, err := NewIncidentRing(dir, 3)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		writeIncidents(t, ring, 0, 5)
+
+		files, _ := filepath.Glob(filepath.Join(dir, "incident-*.json"))
+		if len(files) != 3 {
+			t.Errorf("Expected 3 incident files, got %v", files)
+		}
+		expected := map[string]string{
+			"incident-0.json": "incident-3",
+			"incident-1.json": "incident-4",
+			"incident-2.json": "incident-2",
+		}
+		for file, id := range expected {
+			if got := fileIncidentID(t, dir, file); got != id {
+				t.Errorf("Expected %s to hold %s, got %s", file, id, got)
+			}
+		}
+	})
+
+	t.Run("indexes the files oldest first", func(t *testing.T) {
+		dir := t.TempDir()
+		ring, _ := NewIncidentRing(dir, 3)
+		writeIncidents(t, ring, 0, 5)
+
+		index := readIndex(t, dir)
+		if index.Size != 3 || index.Next != 5 || len(index.Incidents) != 3 {
+			t.Fatalf("Expected 3 of 5 incidents indexed, got size %d, next %d, %d entries", index.Size, index.Next, len(index.Incidents))
+		}
+		for i, want := range []struct{ id, file string }{
+			{"incident-2", "incident-2.json"},
+			{"incident-3", "incident-0.json"},
+			{"incident-4", "incident-1.json"},
+		} {
+			entry := index.Incidents[i]
+			if entry.ID != want.id || entry.File != want.file {
+				t.Errorf("Expected entry %d %s in %s, got %s in %s", i, want.id, want.file, entry.ID, entry.File)
+			}
+			if got := fileIncidentID(t, dir, entry.File); got != entry.ID {
+				t.Errorf("Expected %s to hold %s, got %s", entry.File, entry.ID, got)
+			}
+		}
+		last := index.Incidents[2]
+		if !last.Timestamp.Equal(timestamp.Add(4*time.Second)) || last.PodName != "web-1" || last.Severity != "high" || last.WrittenAt.IsZero() {
+			t.Errorf("Expected the incident fields indexed, got %+v", last)
+		}
+		if entries := ring.Entries(); len(entries) != 3 || entries[0].ID != "incident-2" {
+			t.Errorf("Expected Entries to match the index, got %+v", entries)
+		}
+	})
+
+	t.Run("continues after a restart", func(t *testing.T) {
+		dir := t.TempDir()
+		ring, _ := NewIncidentRing(dir, 3)
+		writeIncidents(t, ring, 0, 2)
+
+		reopened, err := NewIncidentRing(dir, 3)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		writeIncidents(t, reopened, 2, 2)
+
+		index := readIndex(t, dir)
+		if len(index.Incidents) != 3 || index.Incidents[0].ID != "incident-1" || index.Incidents[2].File != "incident-0.json" {
+			t.Errorf("Expected the ring to continue over incident-0, got %+v", index.Incidents)
+		}
+	})
+
+	t.Run("keeps the newest incidents when resized", func(t *testing.T) {
+		dir := t.TempDir()
+		ring, _ := NewIncidentRing(dir, 4)
+		writeIncidents(t, ring, 0, 6)
+
+		resized, err := NewIncidentRing(dir, 2)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		files, _ := filepath.Glob(filepath.Join(dir, "incident-*.json"))
+		if len(files) != 2 {
+			t.Errorf("Expected 2 incident files after shrinking, got %v", files)
+		}
+		if got := fileIncidentID(t, dir, "incident-0.json"); got != "incident-4" {
+			t.Errorf("Expected incident-4 moved to the first slot, got %s", got)
+		}
+
+		writeIncidents(t, resized, 6, 1)
+		index := readIndex(t, dir)
+		if index.Size != 2 || len(index.Incidents) != 2 || index.Incidents[0].ID != "incident-5" || index.Incidents[1].ID != "incident-6" {
+			t.Errorf("Expected incident-5 and incident-6, got %+v", index.Incidents)
+		}
+	})
+
+	t.Run("falls back without an incident", func(t *testing.T) {
+		dir := t.TempDir()
+		ring, _ := NewIncidentRing(dir, 3)
+
+		if ok, err := ring.WriteIncident([]byte("=== INCIDENT REPORT ===\n")); ok || err != nil {
+			t.Errorf("Expected non-JSON payloads to fall back, got ok=%v err=%v", ok, err)
+		}
+		if index := readIndex(t, dir); len(index.Incidents) != 0 {
+			t.Errorf("Expected an empty index, got %+v", index.Incidents)
+		}
+	})
+
+	t.Run("parses the file emitter config", func(t *testing.T) {
+		if ring, err := ParseIncidentRingConfig(map[string]interface{}{"path": "/var/log/blackbox/incidents.log"}); ring != nil || err != nil {
+			t.Errorf("Expected no ring without ring_dir, got %v, %v", ring, err)
+		}
+
+		ring, err := ParseIncidentRingConfig(map[string]interface{}{"ring_dir": t.TempDir(), "ring_size": float64(50)})
+		if err != nil || ring == nil || ring.size != 50 {
+			t.Errorf("Expected a ring of 50 files, got %v, %v", ring, err)
+		}
+
+		for _, size := range []interface{}{nil, "50", float64(0), float64(2.5), float64(MaxIncidentRingSize + 1)} {
+			if _, err := ParseIncidentRingConfig(map[string]interface{}{"ring_dir": t.TempDir(), "ring_size": size}); err == nil {
+				t.Errorf("Expected error for ring_size %v", size)
+			}
+		}
+	})
+}
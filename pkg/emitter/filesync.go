@@ -0,0 +1,153 @@
+package emitter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SyncPolicy controls when the file emitter flushes written incidents from OS
+// buffers to disk with fsync.
+type SyncPolicy string
+
+const (
+	// SyncNone leaves flushing to the OS, which is fastest but may lose the
+	// last incidents if the node loses power
+	SyncNone SyncPolicy = "none"
+	// SyncEveryWrite syncs after every write, so an emitted incident survives
+	// power loss; recommended for incident logs on durability-sensitive nodes
+	SyncEveryWrite SyncPolicy = "every-write"
+	// SyncInterval syncs written data at most one interval after it was
+	// written, bounding the loss window while batching fsyncs
+	SyncInterval SyncPolicy = "interval"
+)
+
+// DefaultSyncInterval is how long written data may stay unsynced under SyncInterval.
+const DefaultSyncInterval = time.Second
+
+// ParseSyncPolicy parses a sync policy name. An empty name is SyncNone.
+func ParseSyncPolicy(name string) (SyncPolicy, error) {
+	switch policy := SyncPolicy(name); policy {
+	case "":
+		return SyncNone, nil
+	case SyncNone, SyncEveryWrite, SyncInterval:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid sync policy %q (expected none, every-write or interval)", name)
+	}
+}
+
+// ParseSyncConfig reads the file emitter's "sync" policy and "sync_interval"
+// duration config keys, defaulting to SyncNone and DefaultSyncInterval.
+func ParseSyncConfig(config map[string]interface{}) (SyncPolicy, time.Duration, error) {
+	name, _ := config["sync"].(string)
+	policy, err := ParseSyncPolicy(name)
+	if err != nil {
+		return "", 0, err
+	}
+
+	interval := DefaultSyncInterval
+	if val, ok := config["sync_interval"].(string); ok && val != "" {
+		interval, err = time.ParseDuration(val)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid sync_interval: %w", err)
+		}
+		if interval <= 0 {
+			return "", 0, fmt.Errorf("sync_interval must be positive")
+		}
+	}
+	return policy, interval, nil
+}
+
+// SyncFile is the part of *os.File a SyncedWriter uses.
+type SyncFile interface {
+	Write(data []byte) (int, error)
+	Sync() error
+}
+
+// SyncedWriter writes to a file and syncs it according to a SyncPolicy. It is
+// safe for concurrent use.
+type SyncedWriter struct {
+	// mutex serializes writes and syncs
+	mutex sync.Mutex
+	// file receives the writes
+	file SyncFile
+	// policy decides when the file is synced
+	policy SyncPolicy
+	// interval is how long data may stay unsynced under SyncInterval
+	interval time.Duration
+	// dirty records data written since the last sync
+	dirty bool
+	// timer syncs dirty data under SyncInterval (nil while none is scheduled)
+	timer *time.Timer
+}
+
+// NewSyncedWriter creates a writer syncing file according to policy. The
+// interval only applies to SyncInterval; zero or less uses DefaultSyncInterval.
+func NewSyncedWriter(file SyncFile, policy SyncPolicy, interval time.Duration) *SyncedWriter {
+	if interval <= 0 {
+		interval = DefaultSyncInterval
+	}
+	return &SyncedWriter{file: file, policy: policy, interval: interval}
+}
+
+// Write writes data and, under SyncEveryWrite, syncs it before returning.
+// Under SyncInterval a sync is scheduled if none is pending.
+func (sw *SyncedWriter) Write(data []byte) (int, error) {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+
+	n, err := sw.file.Write(data)
+	if err != nil {
+		return n, err
+	}
+
+	switch sw.policy {
+	case SyncEveryWrite:
+		if err := sw.file.Sync(); err != nil {
+			return n, fmt.Errorf("failed to sync: %w", err)
+		}
+	case SyncInterval:
+		sw.dirty = true
+		if sw.timer == nil {
+			sw.timer = time.AfterFunc(sw.interval, sw.syncScheduled)
+		}
+	}
+	return n, nil
+}
+
+// syncScheduled syncs the data written since the scheduling write.
+func (sw *SyncedWriter) syncScheduled() {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+
+	sw.timer = nil
+	if err := sw.syncLocked(); err != nil {
+		fmt.Printf("Warning: file emitter %v\n", err)
+	}
+}
+
+// Sync syncs data written but not yet synced under SyncInterval. Call it
+// before closing the file.
+func (sw *SyncedWriter) Sync() error {
+	sw.mutex.Lock()
+	defer sw.mutex.Unlock()
+
+	if sw.timer != nil {
+		sw.timer.Stop()
+		sw.timer = nil
+	}
+	return sw.syncLocked()
+}
+
+// syncLocked syncs the file if data was written since the last sync.
+func (sw *SyncedWriter) syncLocked() error {
+	if !sw.dirty {
+		return nil
+	}
+	sw.dirty = false
+	if err := sw.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package emitter
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingFile records writes and counts syncs.
+type countingFile struct {
+	mutex sync.Mutex
+	data  bytes.Buffer
+	syncs int
+	// synced, when set, receives a value after each sync
+	synced chan struct{}
+}
+
+// Write appends data.
+func (f *countingFile) Write(data []byte) (int, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.data.Write(data)
+}
+
+// Sync counts the sync.
+func (f *countingFile) Sync() error {
+	f.mutex.Lock()
+	f.syncs++
+	f.mutex.Unlock()
+	if f.synced != nil {
+		f.synced <- struct{}{}
+	}
+	return nil
+}
+
+// syncCount returns the number of syncs.
+func (f *countingFile) syncCount() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.syncs
+}
+
+// TestSyncedWriter validates syncing the file emitter's writes per policy.
+func TestSyncedWriter(t *testing.T) {
+	t.Run("syncs every write", func(t *testing.T) {
+		file := &countingFile{}
+		writer := NewSyncedWriter(file, SyncEveryWrite, 0)
+
+		for i := 0; i < 3; i++ {
+			if _, err := writer.Write([]byte("incident\n")); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+
+		if file.syncCount() != 3 {
+			t.Errorf("Expected 3 syncs, got %d", file.syncCount())
+		}
+		if file.data.String() != strings.Repeat("incident\n", 3) {
+			t.Errorf("Expected 3 incidents written, got %q", file.data.String())
+		}
+	})
+
+	t.Run("leaves syncing to the OS by default", func(t *testing.T) {
+		file := &countingFile{}
+		writer := NewSyncedWriter(file, SyncNone, 0)
+
+		writer.Write([]byte("incident\n"))
+		writer.Sync()
+
+		if file.syncCount() != 0 {
+			t.Errorf("Expected no syncs, got %d", file.syncCount())
+		}
+	})
+
+	t.Run("batches syncs per interval", func(t *testing.T) {
+		file := &countingFile{synced: make(chan struct{}, 1)}
+		writer := NewSyncedWriter(file, SyncInterval, 20*time.Millisecond)
+
+		for i := 0; i < 5; i++ {
+			writer.Write([]byte("incident\n"))
+		}
+		if file.syncCount() != 0 {
+			t.Errorf("Expected no sync before the interval, got %d", file.syncCount())
+		}
+
+		select {
+		case <-file.synced:
+		case <-time.After(time.Second):
+			t.Fatal("Expected the batch to be synced after the interval")
+		}
+		if file.syncCount() != 1 {
+			t.Errorf("Expected 1 sync for the batch, got %d", file.syncCount())
+		}
+	})
+
+	t.Run("syncs pending writes on demand", func(t *testing.T) {
+		file := &countingFile{}
+		writer := NewSyncedWriter(file, SyncInterval, time.Hour)
+
+		writer.Write([]byte("incident\n"))
+		if err := writer.Sync(); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		writer.Sync()
+
+		if file.syncCount() != 1 {
+			t.Errorf("Expected 1 sync, got %d", file.syncCount())
+		}
+	})
+}
+
+// TestParseSyncConfig validates reading the sync policy from emitter config.
+func TestParseSyncConfig(t *testing.T) {
+	t.Run("defaults to none", func(t *testing.T) {
+		policy, interval, err := ParseSyncConfig(map[string]interface{}{"path": "/var/log/blackbox/incidents.log"})
+		if err != nil || policy != SyncNone || interval != DefaultSyncInterval {
+			t.Errorf("Expected none with the default interval, got %s %v (%v)", policy, interval, err)
+		}
+	})
+
+	t.Run("parses interval policy", func(t *testing.T) {
+		policy, interval, err := ParseSyncConfig(map[string]interface{}{"sync": "interval", "sync_interval": "5s"})
+		if err != nil || policy != SyncInterval || interval != 5*time.Second {
+			t.Errorf("Expected interval every 5s, got %s %v (%v)", policy, interval, err)
+		}
+	})
+
+	t.Run("rejects unknown policies", func(t *testing.T) {
+		if _, _, err := ParseSyncConfig(map[string]interface{}{"sync": "always"}); err == nil || !strings.Contains(err.Error(), "invalid sync policy") {
+			t.Errorf("Expected invalid policy error, got %v", err)
+		}
+		if _, _, err := ParseSyncConfig(map[string]interface{}{"sync": "interval", "sync_interval": "0s"}); err == nil {
+			t.Error("Expected an error for a zero interval")
+		}
+	})
+}
@@ -0,0 +1,190 @@
+package emitter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/telemetry"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+const (
+	// DefaultForwardTimeout bounds each request to the upstream daemon
+	DefaultForwardTimeout = 30 * time.Second
+	// forwardRuntime is reported as the sidecar runtime of forwarded telemetry
+	forwardRuntime = "blackbox"
+)
+
+// ForwardEmitter sends incidents to an upstream BlackBox daemon's API, so node
+// daemons can report to a regional aggregator. It expects the output of the
+// json formatter, posts the incident to /api/v1/incident and, when enabled,
+// the telemetry leading up to it to /api/v1/telemetry/batch, authenticating
+// with the upstream API key.
+type ForwardEmitter struct {
+	// url is the upstream daemon's base URL, including any API base path
+	url string
+	// apiKey is sent as a bearer token
+	apiKey string
+	// forwardTelemetry also sends the incident's telemetry
+	forwardTelemetry bool
+	// client sends the requests
+	client *http.Client
+}
+
+// NewForwardEmitter creates a forward emitter. Supported config keys are "url"
+// (required, e.g. "http://aggregator:8080"), "api_key", "forward_telemetry"
+// (default false) and "timeout" (a duration string, default 30s).
+func NewForwardEmitter(config map[string]interface{}) (*ForwardEmitter, error) {
+	url, _ := config["url"].(string)
+	if url == "" {
+		return nil, fmt.Errorf("forward emitter: url is required")
+	}
+
+	fe := &ForwardEmitter{
+		url:    strings.TrimSuffix(url, "/"),
+		client: &http.Client{Timeout: DefaultForwardTimeout},
+	}
+
+	if val, ok := config["api_key"].(string); ok {
+		fe.apiKey = val
+	}
+	if val, ok := config["forward_telemetry"].(bool); ok {
+		fe.forwardTelemetry = val
+	}
+	if val, ok := config["timeout"].(string); ok && val != "" {
+		timeout, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("forward emitter: invalid timeout: %w", err)
+		}
+		fe.client.Timeout = timeout
+	}
+
+	return fe, nil
+}
+
+// Name returns the emitter name for identification and logging.
+func (fe *ForwardEmitter) Name() string {
+	return "forward"
+}
+
+// Emit forwards the incident, then its telemetry if enabled. It returns an
+// error for output that is not from the json formatter, transport failures and
+// non-2xx responses.
+func (fe *ForwardEmitter) Emit(data []byte) error {
+	var payload incidentPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return fmt.Errorf("forward emitter: expected json formatter output: %w", err)
+	}
+	if payload.Incident == nil {
+		return fmt.Errorf("forward emitter: expected json formatter output: no incident")
+	}
+
+	if err := fe.post("/api/v1/incident", payload.Incident); err != nil {
+		return err
+	}
+
+	if !fe.forwardTelemetry {
+		return nil
+	}
+	batch := telemetryBatch(*payload.Incident, payload.Telemetry)
+	if len(batch) == 0 {
+		return nil
+	}
+	return fe.post("/api/v1/telemetry/batch", batch)
+}
+
+// post sends body as JSON to the upstream route.
+func (fe *ForwardEmitter) post(route string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("forward emitter: failed to encode request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fe.url+route, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("forward emitter: failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if fe.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+fe.apiKey)
+	}
+
+	resp, err := fe.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("forward emitter: request to %s failed: %w", fe.url+route, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("forward emitter: %s responded with status %d", fe.url+route, resp.StatusCode)
+	}
+	return nil
+}
+
+// telemetryBatch groups telemetry by timestamp into sidecar submissions
+// attributed to the incident's pod, the shape the upstream batch endpoint
+// accepts. Entries sharing a name and timestamp, such as per-process metrics,
+// are told apart by their tags. Telemetry of incidents without a pod is not
+// forwarded, as the upstream requires a pod name and namespace. Counters the
+// json formatter encoded as deltas are sent as "<name>_delta", so the upstream
+// does not record them as the absolute counter.
+func telemetryBatch(incident types.IncidentReport, entries []types.TelemetryEntry) []types.SidecarTelemetry {
+	if incident.PodName == "" || incident.Namespace == "" {
+		return nil
+	}
+
+	var batch []types.SidecarTelemetry
+	index := make(map[time.Time]int)
+	for _, entry := range entries {
+		i, ok := index[entry.Timestamp]
+		if !ok {
+			i = len(batch)
+			index[entry.Timestamp] = i
+			batch = append(batch, types.SidecarTelemetry{
+				PodName:     incident.PodName,
+				Namespace:   incident.Namespace,
+				ContainerID: incident.ContainerID,
+				Runtime:     forwardRuntime,
+				Timestamp:   entry.Timestamp,
+				Data:        make(map[string]interface{}),
+			})
+		}
+
+		if telemetry.IsDelta(entry) {
+			entry.Name += "_delta"
+		}
+		key := entry.Name
+		if _, taken := batch[i].Data[key]; taken {
+			key = taggedName(entry)
+		}
+		batch[i].Data[key] = entry.Value
+	}
+	return batch
+}
+
+// taggedName returns the entry's name followed by its tags in sorted order,
+// e.g. "process_cpu_seconds_total{comm=nginx,pid=812}".
+func taggedName(entry types.TelemetryEntry) string {
+	if len(entry.Tags) == 0 {
+		return entry.Name
+	}
+	tags := make([]string, 0, len(entry.Tags))
+	for key, value := range entry.Tags {
+		tags = append(tags, key+"="+value)
+	}
+	sort.Strings(tags)
+	return entry.Name + "{" + strings.Join(tags, ",") + "}"
+}
+
+// Close releases idle connections held by the HTTP client.
+func (fe *ForwardEmitter) Close() error {
+	fe.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,167 @@
+package emitter
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// forwardedRequest captures a request the stub upstream daemon received.
+type forwardedRequest struct {
+	path string
+	auth string
+	body []byte
+}
+
+// newUpstreamServer starts a stub upstream daemon that records requests and
+// responds with status.
+func newUpstreamServer(t *testing.T, status int) (*httptest.Server, func() []forwardedRequest) {
+	t.Helper()
+	var mutex sync.Mutex
+	var received []forwardedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		received = append(received, forwardedRequest{path: r.URL.Path, auth: r.Header.Get("Authorization"), body: body})
+		mutex.Unlock()
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []forwardedRequest {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]forwardedRequest(nil), received...)
+	}
+}
+
+// TestForwardEmitter validates forwarding incidents and telemetry to an upstream daemon.
+func TestForwardEmitter(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	incident := types.IncidentReport{
+		ID:        "crash-1",
+		Timestamp: base,
+		PodName:   "api-1",
+		Namespace: "prod",
+		Severity:  types.SeverityHigh,
+		Type:      types.IncidentCrash,
+		Message:   "container exited",
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"incident": incident,
+		"telemetry": []types.TelemetryEntry{
+			{Timestamp: base, Name: "memory_usage_percent", Value: 81.5},
+			{Timestamp: base, Name: "process_rss_bytes", Value: 4096, Tags: map[string]string{"comm": "nginx", "pid": "812"}},
+			{Timestamp: base, Name: "process_rss_bytes", Value: 8192, Tags: map[string]string{"comm": "java", "pid": "4242"}},
+			{Timestamp: base.Add(time.Second), Name: "memory_usage_percent", Value: 82.0},
+		},
+		"generated_at": base,
+	})
+	if err != nil {
+		t.Fatalf("Failed to build payload: %v", err)
+	}
+
+	t.Run("forwards the incident with the API key", func(t *testing.T) {
+		server, received := newUpstreamServer(t, http.StatusOK)
+		emitter, err := NewForwardEmitter(map[string]interface{}{"url": server.URL + "/", "api_key": "upstream-key"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if err := emitter.Emit(payload); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		requests := received()
+		if len(requests) != 1 {
+			t.Fatalf("Expected only the incident forwarded, got %d requests", len(requests))
+		}
+		if requests[0].path != "/api/v1/incident" || requests[0].auth != "Bearer upstream-key" {
+			t.Errorf("Expected authenticated POST to /api/v1/incident, got %q with %q", requests[0].path, requests[0].auth)
+		}
+
+		var forwarded types.IncidentReport
+		if err := json.Unmarshal(requests[0].body, &forwarded); err != nil {
+			t.Fatalf("Expected incident JSON, got %v", err)
+		}
+		if forwarded.ID != incident.ID || forwarded.PodName != "api-1" || forwarded.Severity != types.SeverityHigh || !forwarded.Timestamp.Equal(base) {
+			t.Errorf("Expected the incident forwarded unchanged, got %+v", forwarded)
+		}
+	})
+
+	t.Run("forwards telemetry as a sidecar batch", func(t *testing.T) {
+		server, received := newUpstreamServer(t, http.StatusOK)
+		emitter, err := NewForwardEmitter(map[string]interface{}{"url": server.URL, "forward_telemetry": true})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if err := emitter.Emit(payload); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		requests := received()
+		if len(requests) != 2 || requests[1].path != "/api/v1/telemetry/batch" {
+			t.Fatalf("Expected incident then telemetry batch, got %+v", requests)
+		}
+
+		var batch []types.SidecarTelemetry
+		if err := json.Unmarshal(requests[1].body, &batch); err != nil {
+			t.Fatalf("Expected batch JSON, got %v", err)
+		}
+		if len(batch) != 2 {
+			t.Fatalf("Expected one submission per timestamp, got %d", len(batch))
+		}
+		first := batch[0]
+		if first.PodName != "api-1" || first.Namespace != "prod" || !first.Timestamp.Equal(base) {
+			t.Errorf("Expected submission attributed to prod/api-1, got %+v", first)
+		}
+		if first.Data["memory_usage_percent"] != 81.5 || first.Data["process_rss_bytes"] != 4096.0 || first.Data["process_rss_bytes{comm=java,pid=4242}"] != 8192.0 {
+			t.Errorf("Expected entries keyed by name, tagged on collision, got %v", first.Data)
+		}
+	})
+
+	t.Run("skips telemetry of node-level incidents", func(t *testing.T) {
+		node := incident
+		node.PodName, node.Namespace = "", ""
+		if batch := telemetryBatch(node, []types.TelemetryEntry{{Timestamp: base, Name: "load1", Value: 3.0}}); batch != nil {
+			t.Errorf("Expected no telemetry batch, got %+v", batch)
+		}
+	})
+
+	t.Run("keys delta counters apart from absolute ones", func(t *testing.T) {
+		batch := telemetryBatch(incident, []types.TelemetryEntry{
+			{Timestamp: base, Name: "requests_total", Value: 120},
+			{Timestamp: base.Add(time.Second), Name: "requests_total", Value: 7, Metadata: map[string]interface{}{"delta": true}},
+		})
+		if len(batch) != 2 || batch[0].Data["requests_total"] != 120 || batch[1].Data["requests_total_delta"] != 7 {
+			t.Errorf("Expected the delta keyed requests_total_delta, got %+v", batch)
+		}
+	})
+
+	t.Run("reports upstream errors", func(t *testing.T) {
+		server, _ := newUpstreamServer(t, http.StatusUnauthorized)
+		emitter, err := NewForwardEmitter(map[string]interface{}{"url": server.URL})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if err := emitter.Emit(payload); err == nil {
+			t.Error("Expected error for unauthorized upstream")
+		}
+		if err := emitter.Emit([]byte("Incident crash-1 at 15:04")); err == nil {
+			t.Error("Expected error for non-JSON output")
+		}
+	})
+
+	t.Run("requires url", func(t *testing.T) {
+		if _, err := NewForwardEmitter(map[string]interface{}{}); err == nil {
+			t.Error("Expected error for missing url")
+		}
+	})
+}
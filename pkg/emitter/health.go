@@ -0,0 +1,139 @@
+package emitter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultFailureThreshold is how many consecutive failed emits make a
+	// critical emitter unhealthy
+	DefaultFailureThreshold = 3
+	// DefaultProbeInterval is how often MonitoredEmitter.Start probes connectivity
+	DefaultProbeInterval = 30 * time.Second
+)
+
+// Prober is implemented by emitters that can check their destination without
+// emitting, e.g. by pinging a database. MonitoredEmitter probes them periodically.
+type Prober interface {
+	// Probe returns an error when the destination is unreachable
+	Probe() error
+}
+
+// IsCritical reports whether the emitter configuration sets "critical": true,
+// i.e. the daemon should report unhealthy when the emitter keeps failing.
+func IsCritical(config EmitterConfig) bool {
+	critical, _ := config.Config["critical"].(bool)
+	return critical
+}
+
+// MonitoredEmitter wraps an emitter and tracks whether it works. Failed emits
+// are logged; a critical emitter whose last threshold emits failed, or whose
+// last connectivity probe failed, reports an error from Check so the daemon's
+// deep health check can fail and Kubernetes reschedule the pod.
+type MonitoredEmitter struct {
+	Emitter
+	// mutex protects failures, lastErr and probeErr
+	mutex sync.Mutex
+	// critical makes failures affect Check
+	critical bool
+	// threshold is how many consecutive failures make the emitter unhealthy
+	threshold int
+	// failures counts consecutive failed emits
+	failures int
+	// lastErr is the error of the last failed emit
+	lastErr error
+	// probeErr is the error of the last probe (nil when it succeeded or never ran)
+	probeErr error
+}
+
+// NewMonitoredEmitter wraps the emitter. Only critical emitters affect Check;
+// a threshold of zero or less uses DefaultFailureThreshold.
+func NewMonitoredEmitter(emitter Emitter, critical bool, threshold int) *MonitoredEmitter {
+	if threshold <= 0 {
+		threshold = DefaultFailureThreshold
+	}
+	return &MonitoredEmitter{
+		Emitter:   emitter,
+		critical:  critical,
+		threshold: threshold,
+	}
+}
+
+// Emit emits through the wrapped emitter and records the outcome. A success
+// resets the consecutive failure count.
+func (me *MonitoredEmitter) Emit(data []byte) error {
+	err := me.Emitter.Emit(data)
+
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	if err == nil {
+		me.failures = 0
+		return nil
+	}
+	me.failures++
+	me.lastErr = err
+	fmt.Printf("Warning: emitter %s failed (%d consecutive): %v\n", me.Emitter.Name(), me.failures, err)
+	return err
+}
+
+// Probe checks the wrapped emitter's destination when it implements Prober and
+// records the result for Check.
+func (me *MonitoredEmitter) Probe() error {
+	prober, ok := me.Emitter.(Prober)
+	if !ok {
+		return nil
+	}
+	err := prober.Probe()
+
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+	if err != nil && me.probeErr == nil {
+		fmt.Printf("Warning: emitter %s probe failed: %v\n", me.Emitter.Name(), err)
+	}
+	me.probeErr = err
+	return err
+}
+
+// Check returns an error when the emitter is critical and its last threshold
+// emits or its last probe failed. Non-critical emitters are always healthy.
+func (me *MonitoredEmitter) Check() error {
+	me.mutex.Lock()
+	defer me.mutex.Unlock()
+
+	if !me.critical {
+		return nil
+	}
+	if me.failures >= me.threshold {
+		return fmt.Errorf("emitter %s failed %d consecutive emits: %w", me.Emitter.Name(), me.failures, me.lastErr)
+	}
+	if me.probeErr != nil {
+		return fmt.Errorf("emitter %s probe failed: %w", me.Emitter.Name(), me.probeErr)
+	}
+	return nil
+}
+
+// Start probes the wrapped emitter every interval until the context is
+// cancelled. It returns immediately for emitters that are not Probers.
+func (me *MonitoredEmitter) Start(ctx context.Context, interval time.Duration) error {
+	if _, ok := me.Emitter.(Prober); !ok {
+		return nil
+	}
+	if interval <= 0 {
+		interval = DefaultProbeInterval
+	}
+
+	me.Probe()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			me.Probe()
+		}
+	}
+}
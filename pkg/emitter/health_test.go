@@ -0,0 +1,118 @@
+package emitter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// flakyEmitter is an emitter whose Emit and Probe results can be toggled.
+type flakyEmitter struct {
+	mutex    sync.Mutex
+	emitErr  error
+	probeErr error
+	probes   int
+}
+
+func (fe *flakyEmitter) Emit(data []byte) error {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+	return fe.emitErr
+}
+
+func (fe *flakyEmitter) Probe() error {
+	fe.mutex.Lock()
+	defer fe.mutex.Unlock()
+	fe.probes++
+	return fe.probeErr
+}
+
+func (fe *flakyEmitter) Name() string { return "flaky" }
+func (fe *flakyEmitter) Close() error { return nil }
+
+// TestMonitoredEmitter validates failure tracking and health checks of wrapped emitters.
+func TestMonitoredEmitter(t *testing.T) {
+	t.Run("critical emitter fails after the threshold", func(t *testing.T) {
+		flaky := &flakyEmitter{emitErr: errors.New("connection refused")}
+		monitored := NewMonitoredEmitter(flaky, true, 2)
+
+		monitored.Emit([]byte("a"))
+		if err := monitored.Check(); err != nil {
+			t.Errorf("Expected healthy below the threshold, got %v", err)
+		}
+		if err := monitored.Emit([]byte("b")); err == nil {
+			t.Error("Expected the emit error to be returned")
+		}
+		err := monitored.Check()
+		if err == nil || !strings.Contains(err.Error(), "connection refused") {
+			t.Errorf("Expected unhealthy with the last error, got %v", err)
+		}
+
+		flaky.mutex.Lock()
+		flaky.emitErr = nil
+		flaky.mutex.Unlock()
+		monitored.Emit([]byte("c"))
+		if err := monitored.Check(); err != nil {
+			t.Errorf("Expected healthy after a successful emit, got %v", err)
+		}
+	})
+
+	t.Run("non-critical emitter stays healthy", func(t *testing.T) {
+		flaky := &flakyEmitter{emitErr: errors.New("connection refused"), probeErr: errors.New("unreachable")}
+		monitored := NewMonitoredEmitter(flaky, false, 1)
+
+		monitored.Emit([]byte("a"))
+		monitored.Probe()
+		if err := monitored.Check(); err != nil {
+			t.Errorf("Expected healthy, got %v", err)
+		}
+	})
+
+	t.Run("failed probe makes a critical emitter unhealthy", func(t *testing.T) {
+		flaky := &flakyEmitter{probeErr: errors.New("unreachable")}
+		monitored := NewMonitoredEmitter(flaky, true, 0)
+
+		monitored.Probe()
+		if err := monitored.Check(); err == nil || !strings.Contains(err.Error(), "probe failed") {
+			t.Errorf("Expected probe failure, got %v", err)
+		}
+
+		flaky.mutex.Lock()
+		flaky.probeErr = nil
+		flaky.mutex.Unlock()
+		monitored.Probe()
+		if err := monitored.Check(); err != nil {
+			t.Errorf("Expected healthy after a successful probe, got %v", err)
+		}
+	})
+
+	t.Run("probes periodically until cancelled", func(t *testing.T) {
+		flaky := &flakyEmitter{}
+		monitored := NewMonitoredEmitter(flaky, true, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := monitored.Start(ctx, 10*time.Millisecond); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("Expected deadline exceeded, got %v", err)
+		}
+
+		flaky.mutex.Lock()
+		defer flaky.mutex.Unlock()
+		if flaky.probes < 2 {
+			t.Errorf("Expected at least 2 probes, got %d", flaky.probes)
+		}
+	})
+}
+
+// TestIsCritical validates reading the critical flag from emitter configurations.
+func TestIsCritical(t *testing.T) {
+	if IsCritical(EmitterConfig{Type: "webhook", Config: map[string]interface{}{}}) {
+		t.Error("Expected emitters to be non-critical by default")
+	}
+	if !IsCritical(EmitterConfig{Type: "webhook", Config: map[string]interface{}{"critical": true}}) {
+		t.Error("Expected critical emitter")
+	}
+}
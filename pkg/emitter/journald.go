@@ -0,0 +1,264 @@
+//go:build linux
+
+package emitter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// DefaultJournalSocket is the systemd journal's native protocol socket.
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+// JournaldEmitter writes incidents to the systemd journal over its native
+// protocol, so they can be filtered with journalctl by severity, pod,
+// namespace or type instead of grepping a flat file. For json formatter output
+// the MESSAGE is a one-line summary, PRIORITY follows the incident severity
+// and the incident is described by BLACKBOX_* fields with the full report in
+// BLACKBOX_REPORT. Output of other formatters is sent as the MESSAGE at the
+// default priority.
+type JournaldEmitter struct {
+	// conn is connected to the journal socket
+	conn *net.UnixConn
+	// identifier is sent as SYSLOG_IDENTIFIER
+	identifier string
+	// priority is used for payloads without an incident
+	priority int
+	// fields are extra static fields sent with every entry
+	fields map[string]string
+}
+
+// journalFieldName matches valid journal field names: uppercase letters,
+// digits and underscores, not starting with an underscore.
+var journalFieldName = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_]*$`)
+
+// NewJournaldEmitter connects to the journal. Supported config keys are
+// "socket" (default DefaultJournalSocket), "identifier" (default
+// "blackbox-daemon"), "priority" (syslog priority 0-7 for payloads without an
+// incident, default 4, warning) and "fields" (a map of extra fields). It
+// returns an error when the journal socket is not available, e.g. when the
+// host does not run systemd.
+func NewJournaldEmitter(config map[string]interface{}) (*JournaldEmitter, error) {
+	socket := DefaultJournalSocket
+	if val, ok := config["socket"].(string); ok && val != "" {
+		socket = val
+	}
+
+	je := &JournaldEmitter{
+		identifier: "blackbox-daemon",
+		priority:   4,
+		fields:     make(map[string]string),
+	}
+
+	if val, ok := config["identifier"].(string); ok && val != "" {
+		je.identifier = val
+	}
+	if val, ok := config["priority"]; ok {
+		priority, err := journalPriority(val)
+		if err != nil {
+			return nil, err
+		}
+		je.priority = priority
+	}
+	if val, ok := config["fields"].(map[string]interface{}); ok {
+		for name, value := range val {
+			if !journalFieldName.MatchString(name) {
+				return nil, fmt.Errorf("journald emitter: invalid field name %q (uppercase letters, digits and underscores)", name)
+			}
+			je.fields[name] = fmt.Sprint(value)
+		}
+	}
+
+	if _, err := os.Stat(socket); err != nil {
+		return nil, fmt.Errorf("journald emitter: journal socket %s is not available (not running under systemd?): %w", socket, err)
+	}
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return nil, fmt.Errorf("journald emitter: failed to connect to %s: %w", socket, err)
+	}
+	je.conn = conn
+
+	return je, nil
+}
+
+// journalPriority reads a syslog priority from a config value.
+func journalPriority(value interface{}) (int, error) {
+	var priority int
+	switch v := value.(type) {
+	case float64:
+		priority = int(v)
+	case int:
+		priority = v
+	case string:
+		parsed, err := strconv.Atoi(v)
+		if err != nil {
+			return 0, fmt.Errorf("journald emitter: invalid priority %q", v)
+		}
+		priority = parsed
+	default:
+		return 0, fmt.Errorf("journald emitter: invalid priority %v", value)
+	}
+	if priority < 0 || priority > 7 {
+		return 0, fmt.Errorf("journald emitter: priority must be between 0 and 7, got %d", priority)
+	}
+	return priority, nil
+}
+
+// Name returns the emitter name for identification and logging.
+func (je *JournaldEmitter) Name() string {
+	return "journald"
+}
+
+// Emit sends the formatted incident as one journal entry. Entries too large
+// for a datagram are passed to the journal through a temporary file, as
+// systemd's own clients do.
+func (je *JournaldEmitter) Emit(data []byte) error {
+	entry := je.entry(data)
+	if _, err := je.conn.Write(entry); err != nil {
+		if !errors.Is(err, syscall.EMSGSIZE) && !errors.Is(err, syscall.ENOBUFS) {
+			return fmt.Errorf("journald emitter: failed to write entry: %w", err)
+		}
+		return je.sendFile(entry)
+	}
+	return nil
+}
+
+// entry serializes the journal fields of a payload.
+func (je *JournaldEmitter) entry(data []byte) []byte {
+	fields := map[string]string{
+		"SYSLOG_IDENTIFIER": je.identifier,
+		"PRIORITY":          strconv.Itoa(je.priority),
+		"MESSAGE":           strings.TrimRight(string(data), "\n"),
+	}
+	for name, value := range je.fields {
+		fields[name] = value
+	}
+
+	if payload := parseIncidentPayload(data); payload.Incident != nil {
+		incident := payload.Incident
+		fields["MESSAGE"] = incidentSummary(*incident)
+		fields["PRIORITY"] = strconv.Itoa(severityPriority(incident.Severity, je.priority))
+		fields["BLACKBOX_INCIDENT_ID"] = incident.ID
+		fields["BLACKBOX_INCIDENT_TYPE"] = string(incident.Type)
+		fields["BLACKBOX_SEVERITY"] = string(incident.Severity)
+		fields["BLACKBOX_POD"] = incident.PodName
+		fields["BLACKBOX_NAMESPACE"] = incident.Namespace
+		fields["BLACKBOX_CONTAINER_ID"] = incident.ContainerID
+		fields["BLACKBOX_REPORT"] = string(data)
+	}
+
+	names := make([]string, 0, len(fields))
+	for name, value := range fields {
+		if value != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	for _, name := range names {
+		writeJournalField(&buf, name, fields[name])
+	}
+	return buf.Bytes()
+}
+
+// incidentSummary returns the one-line MESSAGE of an incident.
+func incidentSummary(incident types.IncidentReport) string {
+	summary := fmt.Sprintf("%s incident", incident.Type)
+	if incident.PodName != "" {
+		summary += fmt.Sprintf(" in %s/%s", incident.Namespace, incident.PodName)
+	}
+	if incident.Message != "" {
+		summary += ": " + incident.Message
+	}
+	return summary
+}
+
+// severityPriority maps an incident severity to a syslog priority.
+func severityPriority(severity types.IncidentSeverity, fallback int) int {
+	switch severity {
+	case types.SeverityCritical:
+		return 2 // crit
+	case types.SeverityHigh:
+		return 3 // err
+	case types.SeverityMedium:
+		return 4 // warning
+	case types.SeverityLow:
+		return 5 // notice
+	default:
+		return fallback
+	}
+}
+
+// writeJournalField appends a field in the native protocol: "NAME=value\n",
+// or, for values containing newlines, the name, a newline, the value's length
+// as a 64-bit little-endian integer, the value and a newline.
+func writeJournalField(buf *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		buf.WriteString(name + "=" + value + "\n")
+		return
+	}
+	buf.WriteString(name + "\n")
+	binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value + "\n")
+}
+
+// sendFile passes an entry too large for a datagram to the journal as a file
+// descriptor of an unlinked temporary file.
+func (je *JournaldEmitter) sendFile(entry []byte) error {
+	file, err := os.CreateTemp("/dev/shm", "blackbox-journal-")
+	if err != nil {
+		file, err = os.CreateTemp("", "blackbox-journal-")
+		if err != nil {
+			return fmt.Errorf("journald emitter: failed to create entry file: %w", err)
+		}
+	}
+	defer file.Close()
+	os.Remove(file.Name())
+
+	if _, err := file.Write(entry); err != nil {
+		return fmt.Errorf("journald emitter: failed to write entry file: %w", err)
+	}
+	// WriteMsgUnix refuses connected datagram sockets, so send on the raw socket
+	raw, err := je.conn.SyscallConn()
+	if err != nil {
+		return fmt.Errorf("journald emitter: failed to pass entry file: %w", err)
+	}
+	rights := syscall.UnixRights(int(file.Fd()))
+	var sendErr error
+	if err := raw.Write(func(fd uintptr) bool {
+		sendErr = syscall.Sendmsg(int(fd), nil, rights, nil, 0)
+		return sendErr != syscall.EAGAIN
+	}); err != nil {
+		return fmt.Errorf("journald emitter: failed to pass entry file: %w", err)
+	}
+	if sendErr != nil {
+		return fmt.Errorf("journald emitter: failed to pass entry file: %w", sendErr)
+	}
+	return nil
+}
+
+// Close closes the connection to the journal socket.
+func (je *JournaldEmitter) Close() error {
+	return je.conn.Close()
+}
+
+// newJournald creates a journald emitter from its configuration.
+func newJournald(config map[string]interface{}) (Emitter, error) {
+	je, err := NewJournaldEmitter(config)
+	if err != nil {
+		return nil, err
+	}
+	return je, nil
+}
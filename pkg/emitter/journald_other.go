@@ -0,0 +1,10 @@
+//go:build !linux
+
+package emitter
+
+import "fmt"
+
+// newJournald fails: the systemd journal is only available on Linux.
+func newJournald(config map[string]interface{}) (Emitter, error) {
+	return nil, fmt.Errorf("journald emitter: the systemd journal is only available on Linux")
+}
@@ -0,0 +1,202 @@
+//go:build linux
+
+package emitter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// newJournalSocket listens on a datagram socket standing in for the journal.
+func newJournalSocket(t *testing.T) (string, *net.UnixConn) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "journal.socket")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("Failed to listen on %s: %v", path, err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return path, conn
+}
+
+// readJournalEntry receives one entry, reading it from a passed file
+// descriptor if the emitter sent one, and decodes its fields.
+func readJournalEntry(t *testing.T, conn *net.UnixConn) map[string]string {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1<<20)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.ReadMsgUnix(buf, oob)
+	if err != nil {
+		t.Fatalf("Failed to read journal entry: %v", err)
+	}
+
+	data := buf[:n]
+	if oobn > 0 {
+		messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+		if err != nil {
+			t.Fatalf("Failed to parse control message: %v", err)
+		}
+		fds, err := syscall.ParseUnixRights(&messages[0])
+		if err != nil {
+			t.Fatalf("Failed to parse passed descriptor: %v", err)
+		}
+		file := os.NewFile(uintptr(fds[0]), "journal-entry")
+		defer file.Close()
+		file.Seek(0, 0)
+		var contents bytes.Buffer
+		contents.ReadFrom(file)
+		data = contents.Bytes()
+	}
+	return parseJournalEntry(t, data)
+}
+
+// parseJournalEntry decodes the native journal protocol.
+func parseJournalEntry(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for len(data) > 0 {
+		line := bytes.IndexByte(data, '\n')
+		if line < 0 {
+			t.Fatalf("Unterminated field in %q", data)
+		}
+		if eq := bytes.IndexByte(data[:line], '='); eq >= 0 {
+			fields[string(data[:eq])] = string(data[eq+1 : line])
+			data = data[line+1:]
+			continue
+		}
+
+		name := string(data[:line])
+		data = data[line+1:]
+		size := binary.LittleEndian.Uint64(data[:8])
+		fields[name] = string(data[8 : 8+size])
+		if data[8+size] != '\n' {
+			t.Fatalf("Expected newline after binary field %s", name)
+		}
+		data = data[9+size:]
+	}
+	return fields
+}
+
+// TestJournaldEmitter validates writing incidents to the journal with structured fields.
+func TestJournaldEmitter(t *testing.T) {
+	incident := types.IncidentReport{
+		ID:          "oom-1",
+		Timestamp:   time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC),
+		PodName:     "api-1",
+		Namespace:   "prod",
+		ContainerID: "containerd://abc",
+		Severity:    types.SeverityCritical,
+		Type:        types.IncidentOOM,
+		Message:     "OOMKilled",
+	}
+	payload, _ := json.MarshalIndent(map[string]interface{}{"incident": incident, "telemetry": []types.TelemetryEntry{}}, "", "  ")
+
+	t.Run("maps incident fields and severity", func(t *testing.T) {
+		path, journal := newJournalSocket(t)
+		emitter, err := NewJournaldEmitter(map[string]interface{}{"socket": path, "fields": map[string]interface{}{"CLUSTER": "prod-east"}})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer emitter.Close()
+
+		if err := emitter.Emit(payload); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		fields := readJournalEntry(t, journal)
+		expected := map[string]string{
+			"MESSAGE":                "oom incident in prod/api-1: OOMKilled",
+			"PRIORITY":               "2",
+			"SYSLOG_IDENTIFIER":      "blackbox-daemon",
+			"BLACKBOX_INCIDENT_ID":   "oom-1",
+			"BLACKBOX_INCIDENT_TYPE": "oom",
+			"BLACKBOX_SEVERITY":      "critical",
+			"BLACKBOX_POD":           "api-1",
+			"BLACKBOX_NAMESPACE":     "prod",
+			"BLACKBOX_CONTAINER_ID":  "containerd://abc",
+			"BLACKBOX_REPORT":        string(payload),
+			"CLUSTER":                "prod-east",
+		}
+		for name, value := range expected {
+			if fields[name] != value {
+				t.Errorf("Expected %s=%q, got %q", name, value, fields[name])
+			}
+		}
+	})
+
+	t.Run("maps every severity to a syslog priority", func(t *testing.T) {
+		expected := map[types.IncidentSeverity]int{types.SeverityCritical: 2, types.SeverityHigh: 3, types.SeverityMedium: 4, types.SeverityLow: 5, "": 6}
+		for severity, priority := range expected {
+			if got := severityPriority(severity, 6); got != priority {
+				t.Errorf("Expected priority %d for %q, got %d", priority, severity, got)
+			}
+		}
+	})
+
+	t.Run("sends other formats as the message", func(t *testing.T) {
+		path, journal := newJournalSocket(t)
+		emitter, err := NewJournaldEmitter(map[string]interface{}{"socket": path, "identifier": "blackbox", "priority": 3.0})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer emitter.Close()
+
+		if err := emitter.Emit([]byte("=== INCIDENT oom-1 ===\nPod: prod/api-1\n")); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		fields := readJournalEntry(t, journal)
+		if fields["MESSAGE"] != "=== INCIDENT oom-1 ===\nPod: prod/api-1" || fields["PRIORITY"] != "3" || fields["SYSLOG_IDENTIFIER"] != "blackbox" {
+			t.Errorf("Expected the payload as a multi-line message, got %v", fields)
+		}
+		if _, ok := fields["BLACKBOX_INCIDENT_ID"]; ok {
+			t.Error("Expected no incident fields")
+		}
+	})
+
+	t.Run("passes oversized entries as a file", func(t *testing.T) {
+		path, journal := newJournalSocket(t)
+		emitter, err := NewJournaldEmitter(map[string]interface{}{"socket": path})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer emitter.Close()
+
+		large := strings.Repeat("telemetry line\n", 40000)
+		if err := emitter.Emit([]byte(large)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		if fields := readJournalEntry(t, journal); fields["MESSAGE"] != strings.TrimRight(large, "\n") {
+			t.Errorf("Expected the full %d byte message, got %d bytes", len(large)-1, len(fields["MESSAGE"]))
+		}
+	})
+
+	t.Run("fails clearly without a journal", func(t *testing.T) {
+		_, err := NewJournaldEmitter(map[string]interface{}{"socket": filepath.Join(t.TempDir(), "missing.socket")})
+		if err == nil || !strings.Contains(err.Error(), "not running under systemd") {
+			t.Errorf("Expected journal unavailable error, got %v", err)
+		}
+	})
+
+	t.Run("rejects invalid configuration", func(t *testing.T) {
+		path, _ := newJournalSocket(t)
+		if _, err := NewJournaldEmitter(map[string]interface{}{"socket": path, "priority": 9.0}); err == nil {
+			t.Error("Expected error for priority above 7")
+		}
+		if _, err := NewJournaldEmitter(map[string]interface{}{"socket": path, "fields": map[string]interface{}{"_PID": "1"}}); err == nil {
+			t.Error("Expected error for a trusted field name")
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package emitter
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// PathTemplate computes a file path per incident from a Go template over the
+// incident fields, so the file emitter can archive one file per incident:
+//
+//	/var/log/blackbox/{{.Namespace}}/{{.Timestamp.Format "20060102T150405Z"}}-{{.PodName}}-{{.ID}}.json
+//
+// Field values are sanitized into single path components, so a pod name or
+// incident ID cannot escape the template's directory.
+type PathTemplate struct {
+	// text is the template source, kept for error messages
+	text string
+	// tmpl is the parsed template
+	tmpl *template.Template
+	// root is the static directory prefix every computed path must stay under
+	root string
+}
+
+// NewPathTemplate parses a path template (the file emitter's "path_template"
+// config key).
+func NewPathTemplate(text string) (*PathTemplate, error) {
+	tmpl, err := template.New("path").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid path template: %w", err)
+	}
+
+	root := text
+	if i := strings.Index(root, "{{"); i >= 0 {
+		root = filepath.Dir(root[:i] + "x")
+	}
+	return &PathTemplate{text: text, tmpl: tmpl, root: filepath.Clean(root)}, nil
+}
+
+// Path returns the file path for a formatted payload. Payloads that do not
+// carry an incident (non-JSON formats or bare telemetry) return false, and the
+// caller should write to its single configured path instead.
+func (pt *PathTemplate) Path(data []byte) (string, bool, error) {
+	payload := parseIncidentPayload(data)
+	if payload.Incident == nil {
+		return "", false, nil
+	}
+
+	var buf bytes.Buffer
+	if err := pt.tmpl.Execute(&buf, sanitizeIncident(*payload.Incident)); err != nil {
+		return "", false, fmt.Errorf("failed to render path template %q: %w", pt.text, err)
+	}
+
+	path := filepath.Clean(buf.String())
+	if pt.root != "." && path != pt.root && !strings.HasPrefix(path, pt.root+string(filepath.Separator)) {
+		return "", false, fmt.Errorf("path template %q rendered %q outside %s", pt.text, path, pt.root)
+	}
+	return path, true, nil
+}
+
+// WriteIncident appends the payload to its templated path, creating parent
+// directories as needed. It returns false without writing when the payload
+// carries no incident.
+func (pt *PathTemplate) WriteIncident(data []byte) (bool, error) {
+	path, ok, err := pt.Path(data)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, fmt.Errorf("failed to create incident directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, fmt.Errorf("failed to open incident file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(data); err != nil {
+		return false, fmt.Errorf("failed to write incident file: %w", err)
+	}
+	if err := file.Sync(); err != nil {
+		return false, fmt.Errorf("failed to sync incident file: %w", err)
+	}
+	return true, nil
+}
+
+// sanitizeIncident returns a copy of the incident whose string fields and
+// context values are safe to use as path components.
+func sanitizeIncident(report types.IncidentReport) types.IncidentReport {
+	report.ID = sanitizePathComponent(report.ID)
+	report.PodName = sanitizePathComponent(report.PodName)
+	report.Namespace = sanitizePathComponent(report.Namespace)
+	report.ContainerID = sanitizePathComponent(report.ContainerID)
+	report.Message = sanitizePathComponent(report.Message)
+	report.Severity = types.IncidentSeverity(sanitizePathComponent(string(report.Severity)))
+	report.Type = types.IncidentType(sanitizePathComponent(string(report.Type)))
+
+	context := make(map[string]interface{}, len(report.Context))
+	for key, value := range report.Context {
+		context[key] = sanitizePathComponent(fmt.Sprint(value))
+	}
+	report.Context = context
+	return report
+}
+
+// sanitizePathComponent replaces everything but letters, digits, '-', '_' and
+// '.' with '_', and rejects the "." and ".." directory names.
+func sanitizePathComponent(value string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		default:
+			return '_'
+		}
+	}, value)
+
+	switch sanitized {
+	case "":
+		return "unknown"
+	case ".", "..":
+		return strings.Repeat("_", len(sanitized))
+	}
+	return sanitized
+}
@@ -0,0 +1,87 @@
+package emitter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestPathTemplate validates per-incident file paths rendered from templates.
+func TestPathTemplate(t *testing.T) {
+	timestamp := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("renders incident fields", func(t *testing.T) {
+		pt, err := NewPathTemplate(`/var/log/blackbox/{{.Namespace}}/{{.Timestamp.Format "20060102T150405Z"}}-{{.PodName}}-{{.ID}}.json`)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		path, ok, err := pt.Path(sqliteTestPayload(t, "incident-1", timestamp))
+		if err != nil || !ok {
+			t.Fatalf("Expected a path, got ok=%v err=%v", ok, err)
+		}
+		if want := "/var/log/blackbox/default/20241102T150405Z-web-1-incident-1.json"; path != want {
+			t.Errorf("Expected %s, got %s", want, path)
+		}
+	})
+
+	t.Run("sanitizes path traversal in fields", func(t *testing.T) {
+		pt, _ := NewPathTemplate(`/var/log/blackbox/{{.ID}}/{{index .Context "exit_code"}}.json`)
+
+		path, ok, err := pt.Path(sqliteTestPayload(t, "../../etc/passwd", timestamp))
+		if err != nil || !ok {
+			t.Fatalf("Expected a path, got ok=%v err=%v", ok, err)
+		}
+		if want := "/var/log/blackbox/.._.._etc_passwd/137.json"; path != want {
+			t.Errorf("Expected %s, got %s", want, path)
+		}
+
+		path, _, _ = pt.Path(sqliteTestPayload(t, "..", timestamp))
+		if !strings.HasPrefix(path, "/var/log/blackbox/__/") {
+			t.Errorf("Expected .. to be replaced, got %s", path)
+		}
+	})
+
+	t.Run("rejects paths outside the template directory", func(t *testing.T) {
+		pt, _ := NewPathTemplate(`/var/log/blackbox/{{"../.."}}/{{.ID}}`)
+
+		if _, _, err := pt.Path(sqliteTestPayload(t, "incident-1", timestamp)); err == nil {
+			t.Error("Expected error for a path escaping /var/log/blackbox")
+		}
+	})
+
+	t.Run("falls back without an incident", func(t *testing.T) {
+		pt, _ := NewPathTemplate(`/var/log/blackbox/{{.ID}}.log`)
+
+		if _, ok, err := pt.Path([]byte("=== INCIDENT REPORT ===\n")); ok || err != nil {
+			t.Errorf("Expected no templated path for non-JSON payloads, got ok=%v err=%v", ok, err)
+		}
+	})
+
+	t.Run("rejects invalid templates", func(t *testing.T) {
+		if _, err := NewPathTemplate(`/var/log/{{.ID`); err == nil {
+			t.Error("Expected error for an invalid template")
+		}
+	})
+
+	t.Run("writes incidents creating directories", func(t *testing.T) {
+		dir := t.TempDir()
+		pt, _ := NewPathTemplate(filepath.Join(dir, "{{.Namespace}}", "{{.PodName}}", "{{.ID}}.json"))
+
+		payload := sqliteTestPayload(t, "incident-1", timestamp)
+		written, err := pt.WriteIncident(payload)
+		if err != nil || !written {
+			t.Fatalf("Expected incident to be written, got written=%v err=%v", written, err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "default", "web-1", "incident-1.json"))
+		if err != nil {
+			t.Fatalf("Expected incident file, got %v", err)
+		}
+		if string(data) != string(payload) {
+			t.Errorf("Expected file to hold the payload, got %s", data)
+		}
+	})
+}
@@ -0,0 +1,322 @@
+package emitter
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+	// Registers the pure-Go "sqlite" database/sql driver, so no cgo is needed
+	_ "modernc.org/sqlite"
+)
+
+// DefaultSQLiteDriver is the database/sql driver name used by the SQLite emitter
+// unless overridden with the "driver" config key. It is registered by
+// modernc.org/sqlite, a pure-Go SQLite imported by this package.
+const DefaultSQLiteDriver = "sqlite"
+
+// sqliteSchema creates the incident and telemetry tables on first use.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS incidents (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	incident_id  TEXT,
+	timestamp    TEXT,
+	pod_name     TEXT,
+	namespace    TEXT,
+	container_id TEXT,
+	severity     TEXT,
+	type         TEXT,
+	message      TEXT,
+	context      TEXT,
+	payload      BLOB NOT NULL,
+	emitted_at   TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS incidents_timestamp ON incidents (timestamp);
+CREATE TABLE IF NOT EXISTS telemetry (
+	incident       INTEGER NOT NULL REFERENCES incidents (id) ON DELETE CASCADE,
+	timestamp      TEXT,
+	source         TEXT,
+	type           TEXT,
+	name           TEXT,
+	value          TEXT,
+	tags           TEXT
+);
+CREATE INDEX IF NOT EXISTS telemetry_incident ON telemetry (incident);
+`
+
+// sqliteTimeLayout stores timestamps as sortable UTC text.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// SQLiteEmitter writes incident reports into a local SQLite database, giving
+// single-node deployments a queryable store without an external log pipeline.
+// JSON-formatted payloads are split into incident columns and, optionally, one
+// telemetry row per snapshot entry; other formats are stored as the raw payload.
+type SQLiteEmitter struct {
+	// mutex serializes writes since SQLite allows a single writer
+	mutex sync.Mutex
+	// db is the open database handle
+	db *sql.DB
+	// path is the database file location
+	path string
+	// storeTelemetry controls whether telemetry snapshots are written alongside incidents
+	storeTelemetry bool
+}
+
+// StoredIncident is an incident row read back from the SQLite store.
+type StoredIncident struct {
+	// RowID identifies the row and links it to its telemetry
+	RowID int64 `json:"row_id"`
+	// Incident holds the decoded incident columns (empty for non-JSON payloads)
+	Incident types.IncidentReport `json:"incident"`
+	// Payload is the formatted output exactly as emitted
+	Payload []byte `json:"payload"`
+	// EmittedAt is when the payload was written to the store
+	EmittedAt time.Time `json:"emitted_at"`
+}
+
+// incidentPayload mirrors the JSON formatter output.
+type incidentPayload struct {
+	Incident  *types.IncidentReport  `json:"incident"`
+	Telemetry []types.TelemetryEntry `json:"telemetry"`
+}
+
+// NewSQLiteEmitter opens (creating if needed) the SQLite database at the configured
+// path and ensures the schema exists. Supported config keys are "path" (required),
+// "store_telemetry" (default true), "create_dirs" (default true) and "driver".
+func NewSQLiteEmitter(config map[string]interface{}) (*SQLiteEmitter, error) {
+	path, _ := config["path"].(string)
+	if path == "" {
+		return nil, fmt.Errorf("sqlite emitter: path is required")
+	}
+
+	driver := DefaultSQLiteDriver
+	if val, ok := config["driver"].(string); ok && val != "" {
+		driver = val
+	}
+
+	storeTelemetry := true
+	if val, ok := config["store_telemetry"].(bool); ok {
+		storeTelemetry = val
+	}
+
+	createDirs := true
+	if val, ok := config["create_dirs"].(bool); ok {
+		createDirs = val
+	}
+	if createDirs {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return nil, fmt.Errorf("sqlite emitter: failed to create directory: %w", err)
+		}
+	}
+
+	db, err := sql.Open(driver, path)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite emitter: failed to open %s: %w", path, err)
+	}
+	// A single connection avoids "database is locked" errors between writers
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("sqlite emitter: failed to create schema: %w", err)
+	}
+
+	return &SQLiteEmitter{
+		db:             db,
+		path:           path,
+		storeTelemetry: storeTelemetry,
+	}, nil
+}
+
+// Name returns the emitter name for identification and logging.
+func (se *SQLiteEmitter) Name() string {
+	return "sqlite"
+}
+
+// Emit stores a formatted incident. The incident and its telemetry are written in
+// a single transaction so a partially stored incident is never visible.
+func (se *SQLiteEmitter) Emit(data []byte) error {
+	se.mutex.Lock()
+	defer se.mutex.Unlock()
+
+	payload := parseIncidentPayload(data)
+
+	tx, err := se.db.Begin()
+	if err != nil {
+		return fmt.Errorf("sqlite emitter: failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var incident types.IncidentReport
+	if payload.Incident != nil {
+		incident = *payload.Incident
+	}
+	context, err := json.Marshal(incident.Context)
+	if err != nil {
+		return fmt.Errorf("sqlite emitter: failed to encode incident context: %w", err)
+	}
+
+	result, err := tx.Exec(
+		`INSERT INTO incidents (incident_id, timestamp, pod_name, namespace, container_id, severity, type, message, context, payload, emitted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		incident.ID, formatSQLiteTime(incident.Timestamp), incident.PodName, incident.Namespace,
+		incident.ContainerID, string(incident.Severity), string(incident.Type), incident.Message,
+		string(context), data, formatSQLiteTime(time.Now()),
+	)
+	if err != nil {
+		return fmt.Errorf("sqlite emitter: failed to insert incident: %w", err)
+	}
+
+	if se.storeTelemetry && len(payload.Telemetry) > 0 {
+		rowID, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("sqlite emitter: failed to read incident row id: %w", err)
+		}
+		if err := insertTelemetry(tx, rowID, payload.Telemetry); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqlite emitter: failed to commit: %w", err)
+	}
+	return nil
+}
+
+// insertTelemetry writes the telemetry snapshot rows for an incident.
+func insertTelemetry(tx *sql.Tx, rowID int64, entries []types.TelemetryEntry) error {
+	stmt, err := tx.Prepare(`INSERT INTO telemetry (incident, timestamp, source, type, name, value, tags) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("sqlite emitter: failed to prepare telemetry insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, entry := range entries {
+		value, err := json.Marshal(entry.Value)
+		if err != nil {
+			return fmt.Errorf("sqlite emitter: failed to encode value of %s: %w", entry.Name, err)
+		}
+		tags, err := json.Marshal(entry.Tags)
+		if err != nil {
+			return fmt.Errorf("sqlite emitter: failed to encode tags of %s: %w", entry.Name, err)
+		}
+		if _, err := stmt.Exec(rowID, formatSQLiteTime(entry.Timestamp), string(entry.Source),
+			string(entry.Type), entry.Name, string(value), string(tags)); err != nil {
+			return fmt.Errorf("sqlite emitter: failed to insert telemetry: %w", err)
+		}
+	}
+	return nil
+}
+
+// Incidents returns up to limit stored incidents with timestamps at or after since,
+// newest first. A zero since returns incidents from any time; a non-positive limit
+// returns all matches.
+func (se *SQLiteEmitter) Incidents(since time.Time, limit int) ([]StoredIncident, error) {
+	query := `SELECT id, incident_id, timestamp, pod_name, namespace, container_id, severity, type, message, context, payload, emitted_at
+		FROM incidents WHERE timestamp >= ? ORDER BY timestamp DESC, id DESC`
+	args := []interface{}{formatSQLiteTime(since)}
+	if since.IsZero() {
+		args[0] = ""
+	}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	rows, err := se.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite emitter: failed to query incidents: %w", err)
+	}
+	defer rows.Close()
+
+	var incidents []StoredIncident
+	for rows.Next() {
+		var stored StoredIncident
+		var timestamp, severity, incidentType, context, emittedAt string
+		if err := rows.Scan(&stored.RowID, &stored.Incident.ID, &timestamp, &stored.Incident.PodName,
+			&stored.Incident.Namespace, &stored.Incident.ContainerID, &severity, &incidentType,
+			&stored.Incident.Message, &context, &stored.Payload, &emittedAt); err != nil {
+			return nil, fmt.Errorf("sqlite emitter: failed to read incident: %w", err)
+		}
+		stored.Incident.Timestamp = parseSQLiteTime(timestamp)
+		stored.Incident.Severity = types.IncidentSeverity(severity)
+		stored.Incident.Type = types.IncidentType(incidentType)
+		stored.EmittedAt = parseSQLiteTime(emittedAt)
+		json.Unmarshal([]byte(context), &stored.Incident.Context)
+		incidents = append(incidents, stored)
+	}
+	return incidents, rows.Err()
+}
+
+// Telemetry returns the telemetry snapshot stored with an incident row, in
+// chronological order.
+func (se *SQLiteEmitter) Telemetry(rowID int64) ([]types.TelemetryEntry, error) {
+	rows, err := se.db.Query(
+		`SELECT timestamp, source, type, name, value, tags FROM telemetry WHERE incident = ? ORDER BY timestamp`,
+		rowID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite emitter: failed to query telemetry: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []types.TelemetryEntry
+	for rows.Next() {
+		var entry types.TelemetryEntry
+		var timestamp, source, telemetryType, value, tags string
+		if err := rows.Scan(&timestamp, &source, &telemetryType, &entry.Name, &value, &tags); err != nil {
+			return nil, fmt.Errorf("sqlite emitter: failed to read telemetry: %w", err)
+		}
+		entry.Timestamp = parseSQLiteTime(timestamp)
+		entry.Source = types.TelemetrySource(source)
+		entry.Type = types.TelemetryType(telemetryType)
+		json.Unmarshal([]byte(value), &entry.Value)
+		json.Unmarshal([]byte(tags), &entry.Tags)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Probe pings the database so a critical SQLite emitter reports an
+// unreachable database before the next incident is emitted.
+func (se *SQLiteEmitter) Probe() error {
+	if err := se.db.Ping(); err != nil {
+		return fmt.Errorf("failed to ping SQLite database: %w", err)
+	}
+	return nil
+}
+
+// Close closes the database handle.
+func (se *SQLiteEmitter) Close() error {
+	se.mutex.Lock()
+	defer se.mutex.Unlock()
+	return se.db.Close()
+}
+
+// parseIncidentPayload decodes JSON formatter output. Payloads in other formats
+// yield an empty result and are stored raw.
+func parseIncidentPayload(data []byte) incidentPayload {
+	var payload incidentPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return incidentPayload{}
+	}
+	return payload
+}
+
+// formatSQLiteTime formats a timestamp as sortable UTC text, or empty when zero.
+func formatSQLiteTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// parseSQLiteTime parses a stored timestamp, returning the zero time when empty.
+func parseSQLiteTime(value string) time.Time {
+	t, _ := time.Parse(sqliteTimeLayout, value)
+	return t
+}
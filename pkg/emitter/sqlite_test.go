@@ -0,0 +1,215 @@
+package emitter
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// sqliteTestPayload returns a JSON formatter style payload for an incident with telemetry.
+func sqliteTestPayload(t *testing.T, id string, timestamp time.Time) []byte {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{
+		"incident": types.IncidentReport{
+			ID:        id,
+			Timestamp: timestamp,
+			PodName:   "web-1",
+			Namespace: "default",
+			Severity:  types.SeverityHigh,
+			Type:      types.IncidentCrash,
+			Message:   "container exited",
+			Context:   map[string]interface{}{"exit_code": 137},
+		},
+		"telemetry": []types.TelemetryEntry{
+			{Timestamp: timestamp.Add(-2 * time.Second), Source: types.SourceSystem, Type: types.TypeCPU, Name: "cpu_usage", Value: 91.5},
+			{Timestamp: timestamp.Add(-time.Second), Source: types.SourceSystem, Type: types.TypeNetwork, Name: "rx_bytes", Value: 1024, Tags: map[string]string{"interface": "eth0"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build payload: %v", err)
+	}
+	return data
+}
+
+// newTestSQLiteEmitter opens a SQLite emitter in a temp directory.
+func newTestSQLiteEmitter(t *testing.T, config map[string]interface{}) *SQLiteEmitter {
+	t.Helper()
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+	config["path"] = filepath.Join(t.TempDir(), "incidents.db")
+	emitter, err := NewSQLiteEmitter(config)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	t.Cleanup(func() { emitter.Close() })
+	return emitter
+}
+
+// TestSQLiteEmitterConfig validates SQLite emitter configuration handling.
+func TestSQLiteEmitterConfig(t *testing.T) {
+	t.Run("requires path", func(t *testing.T) {
+		if _, err := NewSQLiteEmitter(map[string]interface{}{}); err == nil {
+			t.Error("Expected error for missing path")
+		}
+	})
+
+	t.Run("rejects unknown driver", func(t *testing.T) {
+		_, err := NewSQLiteEmitter(map[string]interface{}{
+			"path":   filepath.Join(t.TempDir(), "incidents.db"),
+			"driver": "not-a-driver",
+		})
+		if err == nil {
+			t.Error("Expected error for unknown driver")
+		}
+	})
+
+	t.Run("is created from config as sqlite", func(t *testing.T) {
+		_, err := NewFromConfig(EmitterConfig{Type: "sqlite", Config: map[string]interface{}{}})
+		if err == nil || err.Error() != "sqlite emitter: path is required" {
+			t.Errorf("Expected sqlite factory path error, got %v", err)
+		}
+	})
+}
+
+// TestSQLitePayloadParsing validates decoding of formatter output before storage.
+func TestSQLitePayloadParsing(t *testing.T) {
+	timestamp := time.Date(2024, time.November, 2, 15, 4, 5, 123, time.FixedZone("UTC+2", 2*60*60))
+
+	t.Run("decodes JSON formatter output", func(t *testing.T) {
+		payload := parseIncidentPayload(sqliteTestPayload(t, "incident-1", timestamp))
+		if payload.Incident == nil || payload.Incident.ID != "incident-1" {
+			t.Fatalf("Expected incident-1, got %+v", payload.Incident)
+		}
+		if len(payload.Telemetry) != 2 {
+			t.Errorf("Expected 2 telemetry entries, got %d", len(payload.Telemetry))
+		}
+	})
+
+	t.Run("ignores non-JSON output", func(t *testing.T) {
+		payload := parseIncidentPayload([]byte("timestamp,source,type\n"))
+		if payload.Incident != nil || payload.Telemetry != nil {
+			t.Errorf("Expected empty payload, got %+v", payload)
+		}
+	})
+
+	t.Run("stores sortable UTC timestamps", func(t *testing.T) {
+		formatted := formatSQLiteTime(timestamp)
+		if formatted != "2024-11-02T13:04:05.000000123Z" {
+			t.Errorf("Expected UTC timestamp, got %q", formatted)
+		}
+		if !parseSQLiteTime(formatted).Equal(timestamp) {
+			t.Errorf("Expected round trip to %v, got %v", timestamp, parseSQLiteTime(formatted))
+		}
+		if formatSQLiteTime(time.Time{}) != "" || !parseSQLiteTime("").IsZero() {
+			t.Error("Expected zero time to be stored as empty")
+		}
+	})
+}
+
+// TestSQLiteEmitter validates storing and querying incidents.
+func TestSQLiteEmitter(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("stores incidents with telemetry", func(t *testing.T) {
+		emitter := newTestSQLiteEmitter(t, nil)
+		if err := emitter.Emit(sqliteTestPayload(t, "incident-1", base)); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		incidents, err := emitter.Incidents(time.Time{}, 0)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(incidents) != 1 || incidents[0].Incident.ID != "incident-1" {
+			t.Fatalf("Expected incident-1, got %+v", incidents)
+		}
+		if incidents[0].Incident.Severity != types.SeverityHigh {
+			t.Errorf("Expected severity high, got %v", incidents[0].Incident.Severity)
+		}
+
+		entries, err := emitter.Telemetry(incidents[0].RowID)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(entries) != 2 || entries[1].Tags["interface"] != "eth0" {
+			t.Errorf("Expected 2 telemetry entries with tags, got %+v", entries)
+		}
+	})
+
+	t.Run("skips telemetry when disabled", func(t *testing.T) {
+		emitter := newTestSQLiteEmitter(t, map[string]interface{}{"store_telemetry": false})
+		emitter.Emit(sqliteTestPayload(t, "incident-1", base))
+
+		incidents, _ := emitter.Incidents(time.Time{}, 0)
+		entries, err := emitter.Telemetry(incidents[0].RowID)
+		if err != nil || len(entries) != 0 {
+			t.Errorf("Expected no telemetry, got %d (err=%v)", len(entries), err)
+		}
+	})
+
+	t.Run("filters by time and limits newest first", func(t *testing.T) {
+		emitter := newTestSQLiteEmitter(t, nil)
+		for i, id := range []string{"old", "middle", "new"} {
+			emitter.Emit(sqliteTestPayload(t, id, base.Add(time.Duration(i)*time.Minute)))
+		}
+
+		incidents, err := emitter.Incidents(base.Add(time.Minute), 1)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if len(incidents) != 1 || incidents[0].Incident.ID != "new" {
+			t.Errorf("Expected only the newest incident, got %+v", incidents)
+		}
+	})
+
+	t.Run("stores raw payloads from other formatters", func(t *testing.T) {
+		emitter := newTestSQLiteEmitter(t, nil)
+		raw := []byte("timestamp,source,type\n")
+		if err := emitter.Emit(raw); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		incidents, _ := emitter.Incidents(time.Time{}, 0)
+		if len(incidents) != 1 || string(incidents[0].Payload) != string(raw) {
+			t.Errorf("Expected raw payload to be stored, got %+v", incidents)
+		}
+	})
+
+	t.Run("handles concurrent writes", func(t *testing.T) {
+		emitter := newTestSQLiteEmitter(t, nil)
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			payload := sqliteTestPayload(t, "incident", base.Add(time.Duration(i)*time.Second))
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := emitter.Emit(payload); err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if incidents, _ := emitter.Incidents(time.Time{}, 0); len(incidents) != 20 {
+			t.Errorf("Expected 20 incidents, got %d", len(incidents))
+		}
+	})
+}
+
+// TestSQLiteEmitterProbe validates probing the database connection.
+func TestSQLiteEmitterProbe(t *testing.T) {
+	emitter := newTestSQLiteEmitter(t, nil)
+	if err := emitter.Probe(); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	emitter.Close()
+	if err := emitter.Probe(); err == nil {
+		t.Error("Expected error after closing the database")
+	}
+}
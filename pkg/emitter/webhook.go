@@ -0,0 +1,160 @@
+package emitter
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+)
+
+const (
+	// DefaultSignatureHeader carries the HMAC-SHA256 signature of webhook payloads
+	DefaultSignatureHeader = "X-Blackbox-Signature-256"
+	// DefaultTimestampHeader carries the Unix time a webhook payload was sent
+	DefaultTimestampHeader = "X-Blackbox-Timestamp"
+	// DefaultWebhookTimeout bounds each webhook request
+	DefaultWebhookTimeout = 30 * time.Second
+	// signaturePrefix identifies the algorithm in signature header values
+	signaturePrefix = "sha256="
+)
+
+// WebhookEmitter POSTs formatted incidents to an HTTP endpoint. When a secret is
+// configured, each request is signed with HMAC-SHA256 in the style of GitHub's
+// X-Hub-Signature-256 so receivers can verify the payload came from the daemon.
+type WebhookEmitter struct {
+	// url is the endpoint incidents are posted to
+	url string
+	// secret is the HMAC key; requests are unsigned when empty
+	secret []byte
+	// signatureHeader is the header carrying the signature
+	signatureHeader string
+	// timestampHeader is the header carrying the send time
+	timestampHeader string
+	// signTimestamp signs "timestamp.body" instead of the body alone to prevent replay
+	signTimestamp bool
+	// contentType is sent as the Content-Type header
+	contentType string
+	// headers are extra static headers added to every request
+	headers map[string]string
+	// client sends the requests
+	client *http.Client
+	// clock supplies request timestamps
+	clock clock.Clock
+}
+
+// NewWebhookEmitter creates a webhook emitter. Supported config keys are "url"
+// (required), "secret", "signature_header", "timestamp_header", "sign_timestamp"
+// (default false), "content_type" (default application/json), "timeout" (a
+// duration string, default 30s) and "headers" (a map of extra headers).
+func NewWebhookEmitter(config map[string]interface{}) (*WebhookEmitter, error) {
+	url, _ := config["url"].(string)
+	if url == "" {
+		return nil, fmt.Errorf("webhook emitter: url is required")
+	}
+
+	we := &WebhookEmitter{
+		url:             url,
+		signatureHeader: DefaultSignatureHeader,
+		timestampHeader: DefaultTimestampHeader,
+		contentType:     "application/json",
+		headers:         make(map[string]string),
+		client:          &http.Client{Timeout: DefaultWebhookTimeout},
+		clock:           clock.Real{},
+	}
+
+	if val, ok := config["secret"].(string); ok {
+		we.secret = []byte(val)
+	}
+	if val, ok := config["signature_header"].(string); ok && val != "" {
+		we.signatureHeader = val
+	}
+	if val, ok := config["timestamp_header"].(string); ok && val != "" {
+		we.timestampHeader = val
+	}
+	if val, ok := config["sign_timestamp"].(bool); ok {
+		we.signTimestamp = val
+	}
+	if val, ok := config["content_type"].(string); ok && val != "" {
+		we.contentType = val
+	}
+	if val, ok := config["timeout"].(string); ok && val != "" {
+		timeout, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("webhook emitter: invalid timeout: %w", err)
+		}
+		we.client.Timeout = timeout
+	}
+	if val, ok := config["headers"].(map[string]interface{}); ok {
+		for name, value := range val {
+			we.headers[name] = fmt.Sprint(value)
+		}
+	}
+
+	return we, nil
+}
+
+// SignPayload returns the signature header value for a payload: "sha256=" followed
+// by the hex HMAC-SHA256 of the body, or of "timestamp.body" when a timestamp is
+// given. Receivers compute the same value and compare it with hmac.Equal.
+func SignPayload(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	if timestamp != "" {
+		mac.Write([]byte(timestamp + "."))
+	}
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Name returns the emitter name for identification and logging.
+func (we *WebhookEmitter) Name() string {
+	return "webhook"
+}
+
+// Emit posts the formatted incident, returning an error for transport failures
+// and non-2xx responses.
+func (we *WebhookEmitter) Emit(data []byte) error {
+	req, err := http.NewRequest(http.MethodPost, we.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("webhook emitter: failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", we.contentType)
+	for name, value := range we.headers {
+		req.Header.Set(name, value)
+	}
+
+	timestamp := strconv.FormatInt(we.clock.Now().Unix(), 10)
+	req.Header.Set(we.timestampHeader, timestamp)
+	if len(we.secret) > 0 {
+		signed := ""
+		if we.signTimestamp {
+			signed = timestamp
+		}
+		req.Header.Set(we.signatureHeader, SignPayload(we.secret, signed, data))
+	}
+
+	resp, err := we.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook emitter: request to %s failed: %w", we.url, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook emitter: %s responded with status %d", we.url, resp.StatusCode)
+	}
+	return nil
+}
+
+// Close releases idle connections held by the HTTP client.
+func (we *WebhookEmitter) Close() error {
+	we.client.CloseIdleConnections()
+	return nil
+}
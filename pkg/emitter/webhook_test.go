@@ -0,0 +1,134 @@
+package emitter
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+)
+
+// receivedRequest captures what a webhook test server received.
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// newWebhookServer starts a test server that records requests and responds with status.
+func newWebhookServer(t *testing.T, status int) (*httptest.Server, chan receivedRequest) {
+	t.Helper()
+	received := make(chan receivedRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedRequest{header: r.Header.Clone(), body: body}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+// expectedSignature independently computes the HMAC-SHA256 signature header value.
+func expectedSignature(secret, message string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(message))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// TestWebhookEmitter validates posting and signing incident payloads.
+func TestWebhookEmitter(t *testing.T) {
+	payload := []byte(`{"incident":{"id":"incident-1"}}`)
+	now := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("signs payload body", func(t *testing.T) {
+		server, received := newWebhookServer(t, http.StatusOK)
+		emitter, err := NewWebhookEmitter(map[string]interface{}{"url": server.URL, "secret": "s3cret"})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		emitter.clock = clock.NewFake(now)
+
+		if err := emitter.Emit(payload); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		req := <-received
+		if string(req.body) != string(payload) {
+			t.Errorf("Expected payload to be posted, got %q", req.body)
+		}
+		if got := req.header.Get(DefaultSignatureHeader); got != expectedSignature("s3cret", string(payload)) {
+			t.Errorf("Expected signature over body, got %q", got)
+		}
+		if got := req.header.Get(DefaultTimestampHeader); got != "1730559845" {
+			t.Errorf("Expected timestamp header '1730559845', got %q", got)
+		}
+		if got := req.header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("Expected JSON content type, got %q", got)
+		}
+	})
+
+	t.Run("signs timestamp and body with custom headers", func(t *testing.T) {
+		server, received := newWebhookServer(t, http.StatusAccepted)
+		emitter, _ := NewWebhookEmitter(map[string]interface{}{
+			"url":              server.URL,
+			"secret":           "s3cret",
+			"sign_timestamp":   true,
+			"signature_header": "X-Hub-Signature-256",
+			"timestamp_header": "X-Hub-Timestamp",
+			"headers":          map[string]interface{}{"X-Cluster": "prod-east"},
+		})
+		emitter.clock = clock.NewFake(now)
+
+		if err := emitter.Emit(payload); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		req := <-received
+		expected := expectedSignature("s3cret", "1730559845."+string(payload))
+		if got := req.header.Get("X-Hub-Signature-256"); got != expected {
+			t.Errorf("Expected signature over timestamp and body %q, got %q", expected, got)
+		}
+		if req.header.Get("X-Hub-Timestamp") != "1730559845" || req.header.Get("X-Cluster") != "prod-east" {
+			t.Errorf("Expected custom headers, got %v", req.header)
+		}
+		if got := SignPayload([]byte("s3cret"), "1730559845", payload); got != expected {
+			t.Errorf("Expected SignPayload to match, got %q", got)
+		}
+	})
+
+	t.Run("omits signature without secret", func(t *testing.T) {
+		server, received := newWebhookServer(t, http.StatusOK)
+		emitter, _ := NewWebhookEmitter(map[string]interface{}{"url": server.URL})
+
+		emitter.Emit(payload)
+
+		if req := <-received; req.header.Get(DefaultSignatureHeader) != "" {
+			t.Errorf("Expected no signature, got %q", req.header.Get(DefaultSignatureHeader))
+		}
+	})
+
+	t.Run("fails on error status", func(t *testing.T) {
+		server, _ := newWebhookServer(t, http.StatusUnauthorized)
+		emitter, _ := NewWebhookEmitter(map[string]interface{}{"url": server.URL, "secret": "wrong"})
+
+		if err := emitter.Emit(payload); err == nil {
+			t.Error("Expected error for 401 response")
+		}
+	})
+
+	t.Run("validates config", func(t *testing.T) {
+		if _, err := NewWebhookEmitter(map[string]interface{}{}); err == nil {
+			t.Error("Expected error for missing url")
+		}
+		if _, err := NewWebhookEmitter(map[string]interface{}{"url": "http://localhost", "timeout": "soon"}); err == nil {
+			t.Error("Expected error for invalid timeout")
+		}
+		if emitter, err := NewFromConfig(EmitterConfig{Type: "webhook", Config: map[string]interface{}{"url": "http://localhost"}}); err != nil || emitter.Name() != "webhook" {
+			t.Errorf("Expected webhook emitter from config, got %v", err)
+		}
+	})
+}
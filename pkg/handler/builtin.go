@@ -0,0 +1,305 @@
+package handler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// DefaultDedupWindow is how long the dedup handler suppresses repeats of an incident.
+const DefaultDedupWindow = 5 * time.Minute
+
+// durationOption reads a duration string option, returning fallback when unset.
+func durationOption(config map[string]interface{}, key string, fallback time.Duration) (time.Duration, error) {
+	val, ok := config[key].(string)
+	if !ok || val == "" {
+		return fallback, nil
+	}
+	duration, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", key, err)
+	}
+	return duration, nil
+}
+
+// numberOption reads a number option, returning fallback when unset.
+func numberOption(config map[string]interface{}, key string, fallback float64) (float64, error) {
+	raw, ok := config[key]
+	if !ok {
+		return fallback, nil
+	}
+	val, ok := raw.(float64)
+	if !ok {
+		return 0, fmt.Errorf("%s must be a number", key)
+	}
+	return val, nil
+}
+
+// MetricsHandler counts incidents by type and severity.
+type MetricsHandler struct {
+	counter IncidentCounter
+	// minSeverity is the lowest severity counted (empty counts every incident)
+	minSeverity types.IncidentSeverity
+}
+
+// NewMetricsHandler creates a handler that counts incidents with counter.
+func NewMetricsHandler(counter IncidentCounter) *MetricsHandler {
+	return &MetricsHandler{counter: counter}
+}
+
+// SetMinSeverity stops incidents below severity from being counted, so
+// low-severity noise does not inflate the incident rate used for alerting.
+// It does not affect emission.
+func (mh *MetricsHandler) SetMinSeverity(severity types.IncidentSeverity) {
+	mh.minSeverity = severity
+}
+
+// HandleIncident counts the incident. Incidents without a severity are
+// counted as medium, matching the default applied to API reports.
+func (mh *MetricsHandler) HandleIncident(report types.IncidentReport) {
+	severity := report.Severity
+	if severity == "" {
+		severity = types.SeverityMedium
+	}
+	if mh.minSeverity != "" && severityRank(severity) < severityRank(mh.minSeverity) {
+		return
+	}
+	mh.counter.IncrementIncidents(string(report.Type), string(severity))
+}
+
+// severityOption reads a severity option, returning an empty severity when unset.
+func severityOption(config map[string]interface{}, key string) (types.IncidentSeverity, error) {
+	val, ok := config[key].(string)
+	if !ok || val == "" {
+		return "", nil
+	}
+	severity := types.IncidentSeverity(val)
+	if severityRank(severity) == 0 {
+		return "", fmt.Errorf("invalid %s: %q (expected low, medium, high or critical)", key, val)
+	}
+	return severity, nil
+}
+
+// DedupHandler is a Filter that suppresses incidents repeating one of the same
+// type for the same container within a time window, so a crash-looping pod
+// produces one report per window instead of one per restart.
+type DedupHandler struct {
+	// mutex protects seen
+	mutex sync.Mutex
+	// window is how long repeats are suppressed
+	window time.Duration
+	// seen maps incident keys to when they were last allowed
+	seen map[string]time.Time
+	// clock supplies the current time
+	clock clock.Clock
+}
+
+// NewDedupHandler creates a handler suppressing repeats within window.
+func NewDedupHandler(window time.Duration) *DedupHandler {
+	return &DedupHandler{
+		window: window,
+		seen:   make(map[string]time.Time),
+		clock:  clock.Real{},
+	}
+}
+
+// Allow reports whether the incident is the first of its kind within the window.
+func (dh *DedupHandler) Allow(report types.IncidentReport) bool {
+	key := fmt.Sprintf("%s/%s/%s/%s", report.Type, report.Namespace, report.PodName, report.ContainerID)
+	now := dh.clock.Now()
+
+	dh.mutex.Lock()
+	defer dh.mutex.Unlock()
+
+	for k, last := range dh.seen {
+		if now.Sub(last) >= dh.window {
+			delete(dh.seen, k)
+		}
+	}
+	if _, ok := dh.seen[key]; ok {
+		return false
+	}
+	dh.seen[key] = now
+	return true
+}
+
+// HandleIncident does nothing; deduplication happens in Allow.
+func (dh *DedupHandler) HandleIncident(report types.IncidentReport) {}
+
+// OutputHandler formats and emits incidents together with the telemetry
+// leading up to them.
+type OutputHandler struct {
+	// output formats and emits the report
+	output Processor
+	// telemetry supplies the snapshot attached to the report (nil emits no telemetry)
+	telemetry TelemetrySource
+	// window limits the telemetry snapshot (zero uses the full buffer window)
+	window time.Duration
+}
+
+// NewOutputHandler creates a handler emitting incidents through output with a
+// telemetry snapshot of the given window.
+func NewOutputHandler(output Processor, telemetry TelemetrySource, window time.Duration) *OutputHandler {
+	return &OutputHandler{output: output, telemetry: telemetry, window: window}
+}
+
+// HandleIncident emits the incident, logging failures.
+func (oh *OutputHandler) HandleIncident(report types.IncidentReport) {
+	var entries []types.TelemetryEntry
+	if oh.telemetry != nil {
+		entries = oh.telemetry.Snapshot(report.Timestamp, oh.window)
+	}
+	if err := oh.output.Process(entries, report); err != nil {
+		fmt.Printf("Error emitting incident %s: %v\n", report.ID, err)
+	}
+}
+
+func init() {
+	// Options: "min_severity" (low, medium, high or critical, default counts all)
+	RegisterHandler("metrics", func(config map[string]interface{}, deps Dependencies) (Handler, error) {
+		if deps.Metrics == nil {
+			return nil, fmt.Errorf("metrics collector is not available")
+		}
+		minSeverity, err := severityOption(config, "min_severity")
+		if err != nil {
+			return nil, err
+		}
+		metrics := NewMetricsHandler(deps.Metrics)
+		metrics.SetMinSeverity(minSeverity)
+		return metrics, nil
+	})
+
+	// Options: "window" (duration string, default 5m)
+	RegisterHandler("dedup", func(config map[string]interface{}, deps Dependencies) (Handler, error) {
+		window, err := durationOption(config, "window", DefaultDedupWindow)
+		if err != nil {
+			return nil, err
+		}
+		return NewDedupHandler(window), nil
+	})
+
+	// Options: "rate" (incidents per second, default 20), "burst" (incidents,
+	// default five seconds of the rate)
+	RegisterHandler("ratelimit", func(config map[string]interface{}, deps Dependencies) (Handler, error) {
+		rate, err := numberOption(config, "rate", DefaultIncidentRate)
+		if err != nil {
+			return nil, err
+		}
+		if rate <= 0 {
+			return nil, fmt.Errorf("rate must be positive")
+		}
+		burst, err := numberOption(config, "burst", rate*DefaultIncidentBurstSeconds)
+		if err != nil {
+			return nil, err
+		}
+		if burst < 1 {
+			return nil, fmt.Errorf("burst must be at least 1")
+		}
+		limiter := NewRateLimitHandler(rate, burst)
+		if shed, ok := deps.Metrics.(ShedCounter); ok {
+			limiter.SetShedCounter(shed)
+		}
+		return limiter, nil
+	})
+
+	// Options: "window" (duration string, default 2m)
+	RegisterHandler("cooldown", func(config map[string]interface{}, deps Dependencies) (Handler, error) {
+		window, err := durationOption(config, "window", DefaultCooldownWindow)
+		if err != nil {
+			return nil, err
+		}
+		if window <= 0 {
+			return nil, fmt.Errorf("window must be positive")
+		}
+		return NewCooldownHandler(window), nil
+	})
+
+	// Options: "window" (duration string, default 30s), "min_pods" (number,
+	// default 2), "types" (list of incident types, default crash and oom)
+	RegisterHandler("rollup", func(config map[string]interface{}, deps Dependencies) (Handler, error) {
+		window, err := durationOption(config, "window", DefaultRollupWindow)
+		if err != nil {
+			return nil, err
+		}
+		if window <= 0 {
+			return nil, fmt.Errorf("window must be positive")
+		}
+		minPods, err := numberOption(config, "min_pods", DefaultRollupMinPods)
+		if err != nil {
+			return nil, err
+		}
+		if minPods < 2 {
+			return nil, fmt.Errorf("min_pods must be at least 2")
+		}
+		incidentTypes := DefaultRollupTypes
+		if raw, ok := config["types"]; ok {
+			list, ok := raw.([]interface{})
+			if !ok || len(list) == 0 {
+				return nil, fmt.Errorf("types must be a list of incident types")
+			}
+			incidentTypes = nil
+			for _, item := range list {
+				incidentType, ok := item.(string)
+				if !ok || incidentType == "" {
+					return nil, fmt.Errorf("types must be a list of incident types")
+				}
+				incidentTypes = append(incidentTypes, types.IncidentType(incidentType))
+			}
+		}
+		return NewRollupHandler(window, int(minPods), incidentTypes), nil
+	})
+
+	// Options: "quiet_period" (duration string, default 5m)
+	RegisterHandler("resolution", func(config map[string]interface{}, deps Dependencies) (Handler, error) {
+		quietPeriod, err := durationOption(config, "quiet_period", DefaultQuietPeriod)
+		if err != nil {
+			return nil, err
+		}
+		if quietPeriod <= 0 {
+			return nil, fmt.Errorf("quiet_period must be positive")
+		}
+		return NewResolutionHandler(quietPeriod), nil
+	})
+
+	// Options: "patterns" (list of regular expressions), "defaults" (bool,
+	// default true, also applies DefaultRedactPatterns)
+	RegisterHandler("redact", func(config map[string]interface{}, deps Dependencies) (Handler, error) {
+		var patterns []string
+		if defaults, ok := config["defaults"].(bool); !ok || defaults {
+			patterns = append(patterns, DefaultRedactPatterns...)
+		}
+		if raw, ok := config["patterns"]; ok {
+			list, ok := raw.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("patterns must be a list of regular expressions")
+			}
+			for _, item := range list {
+				pattern, ok := item.(string)
+				if !ok {
+					return nil, fmt.Errorf("patterns must be a list of regular expressions")
+				}
+				patterns = append(patterns, pattern)
+			}
+		}
+		redactor, err := NewRedactor(patterns)
+		if err != nil {
+			return nil, err
+		}
+		return NewRedactHandler(redactor, nil), nil
+	})
+
+	// Options: "snapshot_window" (duration string, default the full buffer window)
+	RegisterHandler("output", func(config map[string]interface{}, deps Dependencies) (Handler, error) {
+		if deps.Output == nil {
+			return nil, fmt.Errorf("output formatter chain is not available")
+		}
+		window, err := durationOption(config, "snapshot_window", 0)
+		if err != nil {
+			return nil, err
+		}
+		return NewOutputHandler(deps.Output, deps.Telemetry, window), nil
+	})
+}
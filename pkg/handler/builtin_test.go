@@ -0,0 +1,174 @@
+package handler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// mockCounter records incident counts by "type/severity".
+type mockCounter struct {
+	counts map[string]int
+}
+
+// IncrementIncidents records an incident count.
+func (m *mockCounter) IncrementIncidents(incidentType, severity string) {
+	m.counts[incidentType+"/"+severity]++
+}
+
+// mockProcessor records processed incidents and their telemetry.
+type mockProcessor struct {
+	incidents []types.IncidentReport
+	entries   [][]types.TelemetryEntry
+	err       error
+}
+
+// Process records the incident.
+func (m *mockProcessor) Process(entries []types.TelemetryEntry, incident types.IncidentReport) error {
+	m.incidents = append(m.incidents, incident)
+	m.entries = append(m.entries, entries)
+	return m.err
+}
+
+// mockTelemetry returns a fixed snapshot and records the requested window.
+type mockTelemetry struct {
+	window time.Duration
+}
+
+// Snapshot returns a single entry and records the window.
+func (m *mockTelemetry) Snapshot(at time.Time, window time.Duration) []types.TelemetryEntry {
+	m.window = window
+	return []types.TelemetryEntry{{Timestamp: at, Name: "cpu_usage_percent", Value: 42.0}}
+}
+
+// TestBuiltinHandlers validates the metrics, dedup and output handlers built from config.
+func TestBuiltinHandlers(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+	crash := types.IncidentReport{ID: "crash-1", Timestamp: base, PodName: "api-1", Namespace: "prod", Type: types.IncidentCrash, Severity: types.SeverityCritical}
+
+	t.Run("metrics counts incidents", func(t *testing.T) {
+		counter := &mockCounter{counts: make(map[string]int)}
+		handler, err := CreateHandler(HandlerConfig{Type: "metrics"}, Dependencies{Metrics: counter})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		handler.HandleIncident(crash)
+
+		if key := fmt.Sprintf("%s/%s", types.IncidentCrash, types.SeverityCritical); counter.counts[key] != 1 {
+			t.Errorf("Expected 1 count for %s, got %v", key, counter.counts)
+		}
+		if _, err := CreateHandler(HandlerConfig{Type: "metrics"}, Dependencies{}); err == nil {
+			t.Error("Expected error without a metrics collector")
+		}
+	})
+
+	t.Run("metrics labels every severity", func(t *testing.T) {
+		counter := &mockCounter{counts: make(map[string]int)}
+		handler, err := CreateHandler(HandlerConfig{Type: "metrics"}, Dependencies{Metrics: counter})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		for _, severity := range []types.IncidentSeverity{types.SeverityLow, types.SeverityMedium, types.SeverityHigh, types.SeverityCritical, ""} {
+			report := crash
+			report.Severity = severity
+			handler.HandleIncident(report)
+		}
+
+		expected := map[string]int{"crash/low": 1, "crash/medium": 2, "crash/high": 1, "crash/critical": 1}
+		if len(counter.counts) != len(expected) {
+			t.Errorf("Expected %v, got %v", expected, counter.counts)
+		}
+		for key, count := range expected {
+			if counter.counts[key] != count {
+				t.Errorf("Expected %d for %s, got %d", count, key, counter.counts[key])
+			}
+		}
+	})
+
+	t.Run("metrics skips incidents below the minimum severity", func(t *testing.T) {
+		counter := &mockCounter{counts: make(map[string]int)}
+		handler, err := CreateHandler(HandlerConfig{Type: "metrics", Config: map[string]interface{}{"min_severity": "high"}}, Dependencies{Metrics: counter})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		for _, severity := range []types.IncidentSeverity{types.SeverityLow, types.SeverityMedium, types.SeverityHigh, types.SeverityCritical, ""} {
+			report := crash
+			report.Severity = severity
+			handler.HandleIncident(report)
+		}
+
+		if len(counter.counts) != 2 || counter.counts["crash/high"] != 1 || counter.counts["crash/critical"] != 1 {
+			t.Errorf("Expected only high and critical counted, got %v", counter.counts)
+		}
+
+		if _, err := CreateHandler(HandlerConfig{Type: "metrics", Config: map[string]interface{}{"min_severity": "severe"}}, Dependencies{Metrics: counter}); err == nil {
+			t.Error("Expected error for unknown severity")
+		}
+	})
+
+	t.Run("dedup suppresses repeats within the window", func(t *testing.T) {
+		handler, err := CreateHandler(HandlerConfig{Type: "dedup", Config: map[string]interface{}{"window": "1m"}}, Dependencies{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		dedup := handler.(*DedupHandler)
+		fake := clock.NewFake(base)
+		dedup.clock = fake
+
+		other := crash
+		other.PodName = "api-2"
+		if !dedup.Allow(crash) || dedup.Allow(crash) || !dedup.Allow(other) {
+			t.Error("Expected first incident per pod allowed and repeat suppressed")
+		}
+
+		fake.Advance(time.Minute)
+		if !dedup.Allow(crash) {
+			t.Error("Expected incident to be allowed after the window")
+		}
+	})
+
+	t.Run("output emits incidents with telemetry", func(t *testing.T) {
+		processor := &mockProcessor{}
+		telemetry := &mockTelemetry{}
+		handler, err := CreateHandler(HandlerConfig{Type: "output", Config: map[string]interface{}{"snapshot_window": "30s"}},
+			Dependencies{Output: processor, Telemetry: telemetry})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		handler.HandleIncident(crash)
+
+		if len(processor.incidents) != 1 || processor.incidents[0].ID != "crash-1" || len(processor.entries[0]) != 1 {
+			t.Errorf("Expected incident emitted with telemetry, got %+v", processor.incidents)
+		}
+		if telemetry.window != 30*time.Second {
+			t.Errorf("Expected 30s snapshot window, got %v", telemetry.window)
+		}
+	})
+
+	t.Run("default pipeline counts every incident but emits once", func(t *testing.T) {
+		counter := &mockCounter{counts: make(map[string]int)}
+		processor := &mockProcessor{}
+		chain, err := CreateHandlerChain([]HandlerConfig{{Type: "metrics"}, {Type: "dedup"}, {Type: "output"}},
+			Dependencies{Metrics: counter, Output: processor})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		chain.HandleIncident(crash)
+		chain.HandleIncident(crash)
+
+		if counter.counts[fmt.Sprintf("%s/%s", types.IncidentCrash, types.SeverityCritical)] != 2 {
+			t.Errorf("Expected both incidents counted, got %v", counter.counts)
+		}
+		if len(processor.incidents) != 1 {
+			t.Errorf("Expected duplicate to be suppressed before output, got %d emitted", len(processor.incidents))
+		}
+	})
+}
@@ -0,0 +1,188 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// DefaultCooldownWindow is how long the cooldown handler aggregates further
+// incidents for a pod after passing one on.
+const DefaultCooldownWindow = 2 * time.Minute
+
+// CooldownHandler is a Filter that passes on at most one incident per pod
+// within a cooldown window, whatever its type. Later incidents for the pod are
+// counted instead, and when the window expires a single summary incident
+// reporting them is sent to the handlers after it in the chain. This keeps a
+// node-wide failure, in which dozens of pods crash at once, from flooding the
+// emitters. Incidents without a pod, such as node-level disk-full incidents,
+// are always passed on.
+type CooldownHandler struct {
+	// mutex protects pods and next
+	mutex sync.Mutex
+	// window is how long a pod's further incidents are aggregated
+	window time.Duration
+	// pods maps "namespace/pod" to its active cooldown
+	pods map[string]*podCooldown
+	// next receives the summary incidents (nil drops them)
+	next Handler
+	// clock supplies the current time
+	clock clock.Clock
+	// afterFunc schedules f to run after d, e.g. time.AfterFunc
+	afterFunc func(d time.Duration, f func())
+}
+
+// podCooldown tracks the incidents aggregated for one pod.
+type podCooldown struct {
+	// started is when the incident that began the cooldown was passed on
+	started time.Time
+	// until is when the cooldown expires
+	until time.Time
+	// suppressed counts the incidents aggregated during the cooldown
+	suppressed int
+	// types counts the aggregated incidents by type
+	types map[string]int
+	// worst is the most severe aggregated incident, the latest on ties
+	worst types.IncidentReport
+}
+
+// NewCooldownHandler creates a handler aggregating each pod's incidents for
+// window after passing one on.
+func NewCooldownHandler(window time.Duration) *CooldownHandler {
+	return &CooldownHandler{
+		window: window,
+		pods:   make(map[string]*podCooldown),
+		clock:  clock.Real{},
+		afterFunc: func(d time.Duration, f func()) {
+			time.AfterFunc(d, f)
+		},
+	}
+}
+
+// SetNext sets the handler that receives summary incidents.
+func (ch *CooldownHandler) SetNext(next Handler) {
+	ch.mutex.Lock()
+	defer ch.mutex.Unlock()
+	ch.next = next
+}
+
+// Allow reports whether the incident starts a new cooldown for its pod. An
+// incident arriving during the pod's cooldown is aggregated and rejected.
+func (ch *CooldownHandler) Allow(report types.IncidentReport) bool {
+	if report.PodName == "" {
+		return true
+	}
+	key := report.Namespace + "/" + report.PodName
+	now := ch.clock.Now()
+
+	ch.mutex.Lock()
+	summaries, next := ch.expire(now)
+	cooldown, active := ch.pods[key]
+	if active {
+		cooldown.add(report)
+	} else {
+		ch.pods[key] = &podCooldown{started: now, until: now.Add(ch.window), types: make(map[string]int)}
+		ch.afterFunc(ch.window, ch.Expire)
+	}
+	ch.mutex.Unlock()
+
+	forward(next, summaries)
+	return !active
+}
+
+// HandleIncident does nothing; aggregation happens in Allow.
+func (ch *CooldownHandler) HandleIncident(report types.IncidentReport) {}
+
+// Expire ends the elapsed cooldowns, sending a summary for each pod that had
+// incidents aggregated. It runs when a cooldown's timer fires.
+func (ch *CooldownHandler) Expire() {
+	now := ch.clock.Now()
+
+	ch.mutex.Lock()
+	summaries, next := ch.expire(now)
+	ch.mutex.Unlock()
+
+	forward(next, summaries)
+}
+
+// expire removes the cooldowns elapsed at now and returns their summaries,
+// sorted by namespace and pod, with the handler they should be sent to. The
+// caller must hold ch.mutex.
+func (ch *CooldownHandler) expire(now time.Time) ([]types.IncidentReport, Handler) {
+	var summaries []types.IncidentReport
+	for key, cooldown := range ch.pods {
+		if now.Before(cooldown.until) {
+			continue
+		}
+		delete(ch.pods, key)
+		if cooldown.suppressed > 0 {
+			summaries = append(summaries, cooldown.summary(ch.window, now))
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		if summaries[i].Namespace != summaries[j].Namespace {
+			return summaries[i].Namespace < summaries[j].Namespace
+		}
+		return summaries[i].PodName < summaries[j].PodName
+	})
+	return summaries, ch.next
+}
+
+// forward sends the summaries to next, if set.
+func forward(next Handler, summaries []types.IncidentReport) {
+	if next == nil {
+		return
+	}
+	for _, summary := range summaries {
+		next.HandleIncident(summary)
+	}
+}
+
+// add aggregates an incident into the cooldown.
+func (pc *podCooldown) add(report types.IncidentReport) {
+	pc.suppressed++
+	pc.types[string(report.Type)]++
+	if pc.suppressed == 1 || severityRank(report.Severity) >= severityRank(pc.worst.Severity) {
+		pc.worst = report
+	}
+}
+
+// summary builds the incident reporting the aggregated incidents. It takes
+// the type, severity and context of the most severe one.
+func (pc *podCooldown) summary(window time.Duration, now time.Time) types.IncidentReport {
+	report := pc.worst
+	report.ID = pc.worst.ID + "-cooldown"
+	report.Timestamp = now
+	report.Message = fmt.Sprintf("%d further incidents for pod %s/%s during %v cooldown (most severe: %s)",
+		pc.suppressed, report.Namespace, report.PodName, window, pc.worst.Message)
+
+	report.Context = make(map[string]interface{}, len(pc.worst.Context)+4)
+	for key, value := range pc.worst.Context {
+		report.Context[key] = value
+	}
+	report.Context["cooldown_suppressed"] = pc.suppressed
+	report.Context["cooldown_types"] = pc.types
+	report.Context["cooldown_start"] = pc.started.Format(time.RFC3339)
+	report.Context["cooldown_window"] = window.String()
+	return report
+}
+
+// severityRank orders incident severities from low to critical.
+func severityRank(severity types.IncidentSeverity) int {
+	switch severity {
+	case types.SeverityLow:
+		return 1
+	case types.SeverityMedium:
+		return 2
+	case types.SeverityHigh:
+		return 3
+	case types.SeverityCritical:
+		return 4
+	default:
+		return 0
+	}
+}
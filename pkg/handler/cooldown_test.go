@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestCooldownHandler validates aggregating a pod's incidents during its cooldown.
+func TestCooldownHandler(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	// newChain returns a cooldown handler chained before a recorder, with its
+	// timers captured instead of scheduled.
+	newChain := func(t *testing.T) (*CooldownHandler, *MultiHandler, *clock.Fake, *[]func(), *[]types.IncidentReport) {
+		t.Helper()
+		handler, err := CreateHandler(HandlerConfig{Type: "cooldown", Config: map[string]interface{}{"window": "1m"}}, Dependencies{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		cooldown := handler.(*CooldownHandler)
+		fake := clock.NewFake(base)
+		cooldown.clock = fake
+		timers := &[]func(){}
+		cooldown.afterFunc = func(d time.Duration, f func()) {
+			if d != time.Minute {
+				t.Errorf("Expected 1m timer, got %v", d)
+			}
+			*timers = append(*timers, f)
+		}
+
+		received := &[]types.IncidentReport{}
+		chain := NewMultiHandler(cooldown, HandlerFunc(func(report types.IncidentReport) {
+			*received = append(*received, report)
+		}))
+		return cooldown, chain, fake, timers, received
+	}
+
+	t.Run("aggregates rapid incidents into one summary", func(t *testing.T) {
+		_, chain, fake, timers, received := newChain(t)
+
+		for i := 0; i < 5; i++ {
+			chain.HandleIncident(types.IncidentReport{ID: "crash", Timestamp: base, PodName: "api-1", Namespace: "prod", Type: types.IncidentCrash, Severity: types.SeverityHigh, Message: "container exited"})
+			fake.Advance(time.Second)
+		}
+		chain.HandleIncident(types.IncidentReport{ID: "oom", Timestamp: base, PodName: "api-1", Namespace: "prod", Type: types.IncidentOOM, Severity: types.SeverityCritical, Message: "OOMKilled"})
+		chain.HandleIncident(types.IncidentReport{ID: "other", Timestamp: base, PodName: "api-2", Namespace: "prod", Type: types.IncidentCrash, Severity: types.SeverityLow})
+
+		if len(*received) != 2 || (*received)[0].ID != "crash" || (*received)[1].ID != "other" {
+			t.Fatalf("Expected first incident per pod passed on, got %+v", *received)
+		}
+		if len(*timers) != 2 {
+			t.Fatalf("Expected one timer per pod, got %d", len(*timers))
+		}
+
+		fake.Advance(time.Minute)
+		(*timers)[0]()
+
+		if len(*received) != 3 {
+			t.Fatalf("Expected a single summary, got %d incidents", len(*received))
+		}
+		summary := (*received)[2]
+		if summary.ID != "oom-cooldown" || summary.Type != types.IncidentOOM || summary.Severity != types.SeverityCritical {
+			t.Errorf("Expected summary of the most severe incident, got %+v", summary)
+		}
+		if summary.Context["cooldown_suppressed"] != 5 {
+			t.Errorf("Expected 5 aggregated incidents, got %v", summary.Context["cooldown_suppressed"])
+		}
+		if counts := summary.Context["cooldown_types"].(map[string]int); counts["crash"] != 4 || counts["oom"] != 1 {
+			t.Errorf("Expected 4 crash and 1 oom incidents, got %v", counts)
+		}
+
+		(*timers)[1]()
+		if len(*received) != 3 {
+			t.Errorf("Expected no summary for a pod without aggregated incidents, got %d incidents", len(*received))
+		}
+	})
+
+	t.Run("passes incidents on after the cooldown", func(t *testing.T) {
+		cooldown, _, fake, _, received := newChain(t)
+		crash := types.IncidentReport{ID: "crash", PodName: "api-1", Namespace: "prod", Type: types.IncidentCrash}
+
+		if !cooldown.Allow(crash) || cooldown.Allow(crash) {
+			t.Fatal("Expected the first incident allowed and the repeat aggregated")
+		}
+
+		fake.Advance(time.Minute)
+		if !cooldown.Allow(crash) {
+			t.Error("Expected incident allowed after the cooldown")
+		}
+		if len(*received) != 1 || (*received)[0].ID != "crash-cooldown" {
+			t.Errorf("Expected the expired cooldown summarized, got %+v", *received)
+		}
+	})
+
+	t.Run("ignores incidents without a pod", func(t *testing.T) {
+		cooldown, _, _, timers, _ := newChain(t)
+		node := types.IncidentReport{ID: "disk", Type: types.IncidentType("disk_full")}
+
+		if !cooldown.Allow(node) || !cooldown.Allow(node) || len(*timers) != 0 {
+			t.Error("Expected node-level incidents always allowed")
+		}
+	})
+
+	t.Run("rejects non-positive window", func(t *testing.T) {
+		if _, err := CreateHandler(HandlerConfig{Type: "cooldown", Config: map[string]interface{}{"window": "0s"}}, Dependencies{}); err == nil {
+			t.Error("Expected error for zero window")
+		}
+	})
+}
@@ -0,0 +1,219 @@
+// Package handler provides the incident handler pipeline. Handlers register a
+// factory by name, like emitters, and configuration references them by type
+// with arbitrary options. The configured handlers are constructed at startup
+// and chained with MultiHandler, so custom incident processing can be added
+// without changing the daemon.
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Handler processes incident reports.
+type Handler interface {
+	HandleIncident(report types.IncidentReport)
+}
+
+// HandlerFunc adapts a function to the Handler interface.
+type HandlerFunc func(report types.IncidentReport)
+
+// HandleIncident calls f(report).
+func (f HandlerFunc) HandleIncident(report types.IncidentReport) {
+	f(report)
+}
+
+// Filter is implemented by handlers that decide whether an incident continues
+// down a MultiHandler chain, such as the built-in dedup handler.
+type Filter interface {
+	Allow(report types.IncidentReport) bool
+}
+
+// Forwarder is implemented by handlers that emit incidents of their own, such
+// as the summary raised by the built-in cooldown handler. A MultiHandler sets
+// next to the handlers after them in the chain.
+type Forwarder interface {
+	SetNext(next Handler)
+}
+
+// Decorator is implemented by Forwarders that wrap the handlers after them in
+// a MultiHandler chain, such as the built-in redact handler. The chain passes
+// incidents and pod notifications only to the decorator, which decides what
+// reaches the handlers after it.
+type Decorator interface {
+	Forwarder
+	// Decorates marks the handler as a decorator
+	Decorates()
+}
+
+// PodObserver is implemented by handlers that follow pod lifecycle changes,
+// such as the built-in resolution handler. A MultiHandler passes the pod
+// watcher's starts and stops on to them.
+type PodObserver interface {
+	OnPodStart(pod *corev1.Pod)
+	OnPodStop(pod *corev1.Pod)
+}
+
+// HandlerConfig selects a registered handler by type and passes it options.
+type HandlerConfig struct {
+	// Type is the name the handler factory was registered under
+	Type string `json:"type"`
+	// Config holds handler-specific options
+	Config map[string]interface{} `json:"config,omitempty"`
+}
+
+// IncidentCounter records incident counts, e.g. as Prometheus metrics.
+type IncidentCounter interface {
+	IncrementIncidents(incidentType, severity string)
+}
+
+// Processor formats and emits an incident with its telemetry, e.g. a formatter chain.
+type Processor interface {
+	Process(entries []types.TelemetryEntry, incident types.IncidentReport) error
+}
+
+// TelemetrySource returns the telemetry leading up to an incident, e.g. the ring buffer.
+type TelemetrySource interface {
+	Snapshot(at time.Time, window time.Duration) []types.TelemetryEntry
+}
+
+// Dependencies are the daemon components handler factories may use. Fields
+// not available in a given setup are nil.
+type Dependencies struct {
+	// Metrics counts incidents
+	Metrics IncidentCounter
+	// Output formats and emits incident reports
+	Output Processor
+	// Telemetry supplies the telemetry captured with incident reports
+	Telemetry TelemetrySource
+}
+
+// HandlerFactory creates a handler from its configuration options.
+type HandlerFactory func(config map[string]interface{}, deps Dependencies) (Handler, error)
+
+var (
+	// registryMutex protects registry
+	registryMutex sync.RWMutex
+	// registry maps handler types to their factories
+	registry = make(map[string]HandlerFactory)
+)
+
+// RegisterHandler makes a handler available to configuration under the given
+// type name. Registering a name again replaces the previous factory.
+func RegisterHandler(name string, factory HandlerFactory) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry[name] = factory
+}
+
+// Registered reports whether a handler type has been registered.
+func Registered(name string) bool {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+	_, ok := registry[name]
+	return ok
+}
+
+// RegisteredHandlers returns the registered handler types in sorted order.
+func RegisteredHandlers() []string {
+	registryMutex.RLock()
+	defer registryMutex.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// CreateHandler constructs the handler selected by the configuration.
+func CreateHandler(config HandlerConfig, deps Dependencies) (Handler, error) {
+	registryMutex.RLock()
+	factory, ok := registry[config.Type]
+	registryMutex.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown handler type: %s", config.Type)
+	}
+
+	handler, err := factory(config.Config, deps)
+	if err != nil {
+		return nil, fmt.Errorf("handler %s: %w", config.Type, err)
+	}
+	return handler, nil
+}
+
+// CreateHandlerChain constructs the configured handlers in order and chains them.
+func CreateHandlerChain(configs []HandlerConfig, deps Dependencies) (*MultiHandler, error) {
+	handlers := make([]Handler, 0, len(configs))
+	for i, config := range configs {
+		handler, err := CreateHandler(config, deps)
+		if err != nil {
+			return nil, fmt.Errorf("incident handler %d: %w", i, err)
+		}
+		handlers = append(handlers, handler)
+	}
+	return NewMultiHandler(handlers...), nil
+}
+
+// MultiHandler passes each incident to its handlers in order. A handler that
+// implements Filter and rejects an incident stops it from reaching the
+// handlers after it. Handlers that implement Forwarder send the incidents they
+// raise to the handlers after them, and a Decorator alone passes incidents on
+// to them. Handlers that implement PodObserver are notified of the pods the
+// MultiHandler is notified of.
+type MultiHandler struct {
+	handlers []Handler
+}
+
+// NewMultiHandler creates a chain of the given handlers.
+func NewMultiHandler(handlers ...Handler) *MultiHandler {
+	for i, handler := range handlers {
+		if forwarder, ok := handler.(Forwarder); ok {
+			forwarder.SetNext(&MultiHandler{handlers: handlers[i+1:]})
+		}
+	}
+	return &MultiHandler{handlers: handlers}
+}
+
+// HandleIncident passes the incident down the chain.
+func (mh *MultiHandler) HandleIncident(report types.IncidentReport) {
+	for _, handler := range mh.handlers {
+		if filter, ok := handler.(Filter); ok && !filter.Allow(report) {
+			return
+		}
+		handler.HandleIncident(report)
+		if _, ok := handler.(Decorator); ok {
+			return
+		}
+	}
+}
+
+// OnPodStart notifies the handlers implementing PodObserver of a running pod.
+func (mh *MultiHandler) OnPodStart(pod *corev1.Pod) {
+	for _, handler := range mh.handlers {
+		if observer, ok := handler.(PodObserver); ok {
+			observer.OnPodStart(pod)
+		}
+		if _, ok := handler.(Decorator); ok {
+			return
+		}
+	}
+}
+
+// OnPodStop notifies the handlers implementing PodObserver of a stopped pod.
+func (mh *MultiHandler) OnPodStop(pod *corev1.Pod) {
+	for _, handler := range mh.handlers {
+		if observer, ok := handler.(PodObserver); ok {
+			observer.OnPodStop(pod)
+		}
+		if _, ok := handler.(Decorator); ok {
+			return
+		}
+	}
+}
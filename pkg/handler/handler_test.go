@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// labelingHandler is a custom handler that records incidents with a configured label.
+type labelingHandler struct {
+	label    string
+	received *[]string
+}
+
+// HandleIncident records the incident ID prefixed with the label.
+func (lh *labelingHandler) HandleIncident(report types.IncidentReport) {
+	*lh.received = append(*lh.received, lh.label+":"+report.ID)
+}
+
+// rejectingFilter is a handler that rejects every incident.
+type rejectingFilter struct{}
+
+// Allow rejects the incident.
+func (rejectingFilter) Allow(report types.IncidentReport) bool { return false }
+
+// HandleIncident is never called for rejected incidents.
+func (rejectingFilter) HandleIncident(report types.IncidentReport) {}
+
+// TestCustomHandler validates registering and constructing a custom handler from config.
+func TestCustomHandler(t *testing.T) {
+	var received []string
+	RegisterHandler("test-labeling", func(config map[string]interface{}, deps Dependencies) (Handler, error) {
+		label, _ := config["label"].(string)
+		return &labelingHandler{label: label, received: &received}, nil
+	})
+
+	var configs []HandlerConfig
+	raw := `[{"type":"test-labeling","config":{"label":"first"}},{"type":"test-labeling","config":{"label":"second"}}]`
+	if err := json.Unmarshal([]byte(raw), &configs); err != nil {
+		t.Fatalf("Expected valid handler config JSON, got %v", err)
+	}
+
+	chain, err := CreateHandlerChain(configs, Dependencies{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	chain.HandleIncident(types.IncidentReport{ID: "incident-1"})
+
+	if len(received) != 2 || received[0] != "first:incident-1" || received[1] != "second:incident-1" {
+		t.Errorf("Expected handlers to run in order with their options, got %v", received)
+	}
+	if !Registered("test-labeling") {
+		t.Error("Expected custom handler to be registered")
+	}
+}
+
+// TestCreateHandler validates handler construction errors.
+func TestCreateHandler(t *testing.T) {
+	t.Run("rejects unknown type", func(t *testing.T) {
+		if _, err := CreateHandler(HandlerConfig{Type: "missing"}, Dependencies{}); err == nil {
+			t.Error("Expected error for unknown handler type")
+		}
+	})
+
+	t.Run("reports failing handler by position", func(t *testing.T) {
+		_, err := CreateHandlerChain([]HandlerConfig{{Type: "dedup"}, {Type: "dedup", Config: map[string]interface{}{"window": "soon"}}}, Dependencies{})
+		if err == nil || err.Error() != `incident handler 1: handler dedup: invalid window: time: invalid duration "soon"` {
+			t.Errorf("Expected positional error, got %v", err)
+		}
+	})
+
+	t.Run("lists built-in handlers", func(t *testing.T) {
+		for _, name := range []string{"cooldown", "dedup", "metrics", "output", "resolution"} {
+			if !Registered(name) {
+				t.Errorf("Expected built-in handler %s to be registered", name)
+			}
+		}
+		if names := RegisteredHandlers(); len(names) < 5 {
+			t.Errorf("Expected at least 5 registered handlers, got %v", names)
+		}
+	})
+}
+
+// TestMultiHandler validates that filters stop incidents from reaching later handlers.
+func TestMultiHandler(t *testing.T) {
+	var calls []string
+	record := func(name string) Handler {
+		return HandlerFunc(func(report types.IncidentReport) { calls = append(calls, name) })
+	}
+
+	chain := NewMultiHandler(record("before"), rejectingFilter{}, record("after"))
+	chain.HandleIncident(types.IncidentReport{ID: "incident-1", Timestamp: time.Now()})
+
+	if len(calls) != 1 || calls[0] != "before" {
+		t.Errorf("Expected chain to stop at the filter, got %v", calls)
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// IncidentHeartbeat is the type of the synthetic incidents emitted by a
+// Heartbeat, which carry periodic telemetry snapshots rather than a failure.
+const IncidentHeartbeat types.IncidentType = "heartbeat"
+
+// Heartbeat runs the output formatter chain over the current telemetry window
+// on an interval, with a synthetic heartbeat incident, in addition to the real
+// incidents, so the daemon doubles as a periodic telemetry snapshotter.
+// Heartbeats are passed to the output directly rather than through the
+// handler chain, so they are not deduplicated, rate limited or counted as
+// incidents.
+type Heartbeat struct {
+	// mutex protects sequence
+	mutex sync.Mutex
+	// output formats and emits the heartbeats
+	output Processor
+	// telemetry supplies the snapshot attached to each heartbeat (nil emits no telemetry)
+	telemetry TelemetrySource
+	// interval is how often heartbeats are emitted
+	interval time.Duration
+	// window limits the telemetry snapshot (zero uses the full buffer window)
+	window time.Duration
+	// sequence numbers the heartbeats emitted
+	sequence uint64
+	// clock supplies heartbeat timestamps
+	clock clock.Clock
+}
+
+// NewHeartbeat creates a heartbeat emitting through output every interval
+// with a telemetry snapshot of the given window.
+func NewHeartbeat(output Processor, telemetry TelemetrySource, interval, window time.Duration) *Heartbeat {
+	return &Heartbeat{
+		output:    output,
+		telemetry: telemetry,
+		interval:  interval,
+		window:    window,
+		clock:     clock.Real{},
+	}
+}
+
+// Start emits a heartbeat every interval until the context is cancelled. The
+// first heartbeat follows one interval after the start, once telemetry has
+// been collected.
+func (hb *Heartbeat) Start(ctx context.Context) error {
+	ticker := time.NewTicker(hb.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := hb.Emit(); err != nil {
+				fmt.Printf("Error emitting heartbeat: %v\n", err)
+			}
+		}
+	}
+}
+
+// Emit runs the output over the current telemetry window with a heartbeat
+// incident.
+func (hb *Heartbeat) Emit() error {
+	now := hb.clock.Now()
+	hb.mutex.Lock()
+	hb.sequence++
+	sequence := hb.sequence
+	hb.mutex.Unlock()
+
+	report := types.IncidentReport{
+		ID:        fmt.Sprintf("heartbeat-%d-%d", now.Unix(), sequence),
+		Timestamp: now,
+		Severity:  types.SeverityLow,
+		Type:      IncidentHeartbeat,
+		Message:   fmt.Sprintf("Periodic telemetry snapshot (every %s)", hb.interval),
+		Context: map[string]interface{}{
+			"heartbeat_sequence": sequence,
+			"heartbeat_interval": hb.interval.String(),
+		},
+	}
+
+	var entries []types.TelemetryEntry
+	if hb.telemetry != nil {
+		entries = hb.telemetry.Snapshot(now, hb.window)
+	}
+	if err := hb.output.Process(entries, report); err != nil {
+		return fmt.Errorf("heartbeat %s: %w", report.ID, err)
+	}
+	return nil
+}
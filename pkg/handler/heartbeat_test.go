@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// chanProcessor passes processed incidents to a channel.
+type chanProcessor struct {
+	incidents chan types.IncidentReport
+}
+
+// Process sends the incident to the channel.
+func (c *chanProcessor) Process(entries []types.TelemetryEntry, incident types.IncidentReport) error {
+	c.incidents <- incident
+	return nil
+}
+
+// TestHeartbeat validates periodic emission of telemetry snapshots.
+func TestHeartbeat(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	t.Run("emits a heartbeat incident with the telemetry window", func(t *testing.T) {
+		processor := &mockProcessor{}
+		telemetry := &mockTelemetry{}
+		heartbeat := NewHeartbeat(processor, telemetry, time.Minute, 30*time.Second)
+		fake := clock.NewFake(base)
+		heartbeat.clock = fake
+
+		for i := 0; i < 2; i++ {
+			if err := heartbeat.Emit(); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			fake.Advance(time.Minute)
+		}
+
+		if len(processor.incidents) != 2 {
+			t.Fatalf("Expected 2 heartbeats, got %d", len(processor.incidents))
+		}
+		report := processor.incidents[1]
+		if report.Type != IncidentHeartbeat || report.Severity != types.SeverityLow || !report.Timestamp.Equal(base.Add(time.Minute)) {
+			t.Errorf("Expected a low severity heartbeat a minute later, got %s, %s at %v", report.Type, report.Severity, report.Timestamp)
+		}
+		if report.Context["heartbeat_sequence"] != uint64(2) || report.Context["heartbeat_interval"] != "1m0s" {
+			t.Errorf("Expected the second heartbeat every 1m, got %v", report.Context)
+		}
+		if report.ID == processor.incidents[0].ID {
+			t.Errorf("Expected distinct heartbeat IDs, got %s twice", report.ID)
+		}
+		if telemetry.window != 30*time.Second || len(processor.entries[1]) != 1 {
+			t.Errorf("Expected the 30s telemetry window, got %v and %d entries", telemetry.window, len(processor.entries[1]))
+		}
+	})
+
+	t.Run("reports output failures", func(t *testing.T) {
+		heartbeat := NewHeartbeat(&mockProcessor{err: fmt.Errorf("emitter down")}, nil, time.Minute, 0)
+
+		if err := heartbeat.Emit(); err == nil {
+			t.Error("Expected error when the output fails")
+		}
+	})
+
+	t.Run("emits at the configured interval", func(t *testing.T) {
+		const interval = 20 * time.Millisecond
+		processor := &chanProcessor{incidents: make(chan types.IncidentReport, 10)}
+		heartbeat := NewHeartbeat(processor, nil, interval, 0)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		start := time.Now()
+		go func() { done <- heartbeat.Start(ctx) }()
+
+		var received []time.Time
+		for len(received) < 3 {
+			select {
+			case report := <-processor.incidents:
+				received = append(received, report.Timestamp)
+			case <-time.After(time.Second):
+				t.Fatalf("Expected 3 heartbeats within a second, got %d", len(received))
+			}
+		}
+		cancel()
+		if err := <-done; err != context.Canceled {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+
+		if received[0].Sub(start) < interval/2 {
+			t.Errorf("Expected the first heartbeat after an interval, got it after %v", received[0].Sub(start))
+		}
+		for i := 1; i < len(received); i++ {
+			if gap := received[i].Sub(received[i-1]); gap < interval/2 {
+				t.Errorf("Expected heartbeats %v apart, got %v", interval, gap)
+			}
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+const (
+	// DefaultIncidentRate is how many incidents per second the ratelimit
+	// handler passes on before shedding. It is far above what a healthy node
+	// produces, so only a catastrophic failure reaches it.
+	DefaultIncidentRate = 20.0
+	// DefaultIncidentBurstSeconds sizes the default burst as this many seconds
+	// of the rate.
+	DefaultIncidentBurstSeconds = 5
+	// StormQuietPeriod is how long no incident must be shed before an incident
+	// storm is considered over and its summary sent.
+	StormQuietPeriod = 10 * time.Second
+)
+
+// IncidentStorm is the type of the summary incident sent when an incident
+// storm subsides.
+const IncidentStorm types.IncidentType = "incident_storm"
+
+// ShedCounter records incidents dropped by the rate limit, e.g. as Prometheus
+// metrics. The ratelimit handler uses the metrics dependency when it
+// implements ShedCounter.
+type ShedCounter interface {
+	IncrementIncidentsShed(incidentType string)
+}
+
+// RateLimitHandler is a Filter that caps the incidents passed on across the
+// whole node with a token bucket. Incidents above the rate are shed: counted
+// and dropped instead of processed. Once no incident has been shed for
+// StormQuietPeriod, a single incident storm summary reporting them is sent to
+// the handlers after it in the chain. It is a last-resort backstop protecting
+// the emitters during a catastrophic failure, distinct from the per-pod
+// cooldown and dedup handlers.
+type RateLimitHandler struct {
+	// mutex protects tokens, last, storm and next
+	mutex sync.Mutex
+	// rate is how many incidents per second are passed on
+	rate float64
+	// burst is the most incidents passed on at once after a quiet period
+	burst float64
+	// tokens is the number of incidents that may currently be passed on
+	tokens float64
+	// last is when tokens was last refilled (zero before the first incident)
+	last time.Time
+	// storm tracks the incidents shed since shedding began (nil when not shedding)
+	storm *incidentStorm
+	// shed counts shed incidents (nil counts nothing)
+	shed ShedCounter
+	// next receives the storm summaries (nil drops them)
+	next Handler
+	// clock supplies the current time
+	clock clock.Clock
+	// afterFunc schedules f to run after d, e.g. time.AfterFunc
+	afterFunc func(d time.Duration, f func())
+}
+
+// incidentStorm tracks the incidents shed during a storm.
+type incidentStorm struct {
+	// started is when the first incident was shed
+	started time.Time
+	// lastShed is when the latest incident was shed
+	lastShed time.Time
+	// shed counts the shed incidents
+	shed int
+	// types counts the shed incidents by type
+	types map[string]int
+	// pods holds the "namespace/pod" of each pod with shed incidents
+	pods map[string]bool
+}
+
+// NewRateLimitHandler creates a handler passing on rate incidents per second,
+// with bursts of up to burst incidents.
+func NewRateLimitHandler(rate, burst float64) *RateLimitHandler {
+	return &RateLimitHandler{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		clock:  clock.Real{},
+		afterFunc: func(d time.Duration, f func()) {
+			time.AfterFunc(d, f)
+		},
+	}
+}
+
+// SetShedCounter sets the counter recording shed incidents.
+func (rh *RateLimitHandler) SetShedCounter(shed ShedCounter) {
+	rh.shed = shed
+}
+
+// SetNext sets the handler that receives storm summaries.
+func (rh *RateLimitHandler) SetNext(next Handler) {
+	rh.mutex.Lock()
+	defer rh.mutex.Unlock()
+	rh.next = next
+}
+
+// Allow reports whether the incident is within the rate limit. An incident
+// above it is shed and recorded for the storm summary.
+func (rh *RateLimitHandler) Allow(report types.IncidentReport) bool {
+	now := rh.clock.Now()
+
+	rh.mutex.Lock()
+	defer rh.mutex.Unlock()
+
+	if !rh.last.IsZero() {
+		rh.tokens += now.Sub(rh.last).Seconds() * rh.rate
+		if rh.tokens > rh.burst {
+			rh.tokens = rh.burst
+		}
+	}
+	rh.last = now
+	if rh.tokens >= 1 {
+		rh.tokens--
+		return true
+	}
+
+	if rh.storm == nil {
+		rh.storm = &incidentStorm{started: now, types: make(map[string]int), pods: make(map[string]bool)}
+		fmt.Printf("Warning: incident rate above %g per second, shedding incidents\n", rh.rate)
+		rh.afterFunc(StormQuietPeriod, rh.Expire)
+	}
+	rh.storm.add(report, now)
+	if rh.shed != nil {
+		rh.shed.IncrementIncidentsShed(string(report.Type))
+	}
+	return false
+}
+
+// HandleIncident does nothing; rate limiting happens in Allow.
+func (rh *RateLimitHandler) HandleIncident(report types.IncidentReport) {}
+
+// Expire ends the storm once no incident has been shed for StormQuietPeriod,
+// sending its summary, and otherwise checks again when the period would
+// elapse. It runs when the storm's timer fires.
+func (rh *RateLimitHandler) Expire() {
+	now := rh.clock.Now()
+
+	rh.mutex.Lock()
+	storm := rh.storm
+	if storm == nil {
+		rh.mutex.Unlock()
+		return
+	}
+	if quiet := now.Sub(storm.lastShed); quiet < StormQuietPeriod {
+		rh.afterFunc(StormQuietPeriod-quiet, rh.Expire)
+		rh.mutex.Unlock()
+		return
+	}
+	rh.storm = nil
+	next := rh.next
+	rh.mutex.Unlock()
+
+	forward(next, []types.IncidentReport{storm.summary(rh.rate, now)})
+}
+
+// add records a shed incident.
+func (is *incidentStorm) add(report types.IncidentReport, now time.Time) {
+	is.shed++
+	is.lastShed = now
+	is.types[string(report.Type)]++
+	if report.PodName != "" {
+		is.pods[report.Namespace+"/"+report.PodName] = true
+	}
+}
+
+// summary builds the incident reporting the shed incidents.
+func (is *incidentStorm) summary(rate float64, now time.Time) types.IncidentReport {
+	duration := is.lastShed.Sub(is.started)
+	return types.IncidentReport{
+		ID:        fmt.Sprintf("incident-storm-%d", is.started.UnixNano()),
+		Timestamp: now,
+		Severity:  types.SeverityCritical,
+		Type:      IncidentStorm,
+		Message: fmt.Sprintf("Incident storm: %d incidents from %d pods shed over %v above the limit of %g per second",
+			is.shed, len(is.pods), duration, rate),
+		Context: map[string]interface{}{
+			"storm_shed":       is.shed,
+			"storm_types":      is.types,
+			"storm_pods":       len(is.pods),
+			"storm_start":      is.started.Format(time.RFC3339),
+			"storm_end":        is.lastShed.Format(time.RFC3339),
+			"storm_rate_limit": rate,
+		},
+	}
+}
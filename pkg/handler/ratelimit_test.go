@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// sheddingCounter records incident counts and shed incidents by type.
+type sheddingCounter struct {
+	mockCounter
+	shed map[string]int
+}
+
+// IncrementIncidentsShed records a shed incident.
+func (sc *sheddingCounter) IncrementIncidentsShed(incidentType string) {
+	sc.shed[incidentType]++
+}
+
+// TestRateLimitHandler validates shedding incidents above the global rate limit.
+func TestRateLimitHandler(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	// newChain returns a rate limit handler of 10 incidents per second with a
+	// burst of 20, chained before a recorder, with its timers captured instead
+	// of scheduled.
+	newChain := func(t *testing.T) (*MultiHandler, *sheddingCounter, *clock.Fake, *[]func(), *[]types.IncidentReport) {
+		t.Helper()
+		counter := &sheddingCounter{mockCounter: mockCounter{counts: make(map[string]int)}, shed: make(map[string]int)}
+		handler, err := CreateHandler(HandlerConfig{Type: "ratelimit", Config: map[string]interface{}{
+			"rate":  float64(10),
+			"burst": float64(20),
+		}}, Dependencies{Metrics: counter})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		limiter := handler.(*RateLimitHandler)
+		fake := clock.NewFake(base)
+		limiter.clock = fake
+		timers := &[]func(){}
+		limiter.afterFunc = func(d time.Duration, f func()) {
+			*timers = append(*timers, f)
+		}
+
+		received := &[]types.IncidentReport{}
+		chain := NewMultiHandler(limiter, HandlerFunc(func(report types.IncidentReport) {
+			*received = append(*received, report)
+		}))
+		return chain, counter, fake, timers, received
+	}
+
+	// flood sends n crash incidents for distinct pods in the same instant.
+	flood := func(chain *MultiHandler, n int) {
+		for i := 0; i < n; i++ {
+			chain.HandleIncident(types.IncidentReport{
+				ID:        fmt.Sprintf("crash-%d", i),
+				PodName:   fmt.Sprintf("api-%d", i),
+				Namespace: "prod",
+				Type:      types.IncidentCrash,
+				Severity:  types.SeverityHigh,
+			})
+		}
+	}
+
+	t.Run("sheds incidents above the limit", func(t *testing.T) {
+		chain, counter, _, timers, received := newChain(t)
+
+		flood(chain, 500)
+
+		if len(*received) != 20 {
+			t.Errorf("Expected the burst of 20 incidents passed on, got %d", len(*received))
+		}
+		if counter.shed["crash"] != 480 {
+			t.Errorf("Expected 480 shed incidents counted, got %d", counter.shed["crash"])
+		}
+		if len(*timers) != 1 {
+			t.Errorf("Expected one storm timer, got %d", len(*timers))
+		}
+	})
+
+	t.Run("refills at the configured rate", func(t *testing.T) {
+		chain, _, fake, _, received := newChain(t)
+
+		flood(chain, 25)
+		fake.Advance(time.Second)
+		flood(chain, 25)
+
+		if len(*received) != 30 {
+			t.Errorf("Expected 20 burst and 10 refilled incidents passed on, got %d", len(*received))
+		}
+	})
+
+	t.Run("sends a single storm summary once shedding stops", func(t *testing.T) {
+		chain, _, fake, timers, received := newChain(t)
+
+		flood(chain, 100)
+		fake.Advance(5 * time.Second)
+		flood(chain, 100)
+		passed := len(*received)
+		if passed != 40 {
+			t.Fatalf("Expected a full burst passed on each time, got %d incidents", passed)
+		}
+
+		fake.Advance(StormQuietPeriod - 5*time.Second)
+		(*timers)[0]()
+		if len(*received) != passed {
+			t.Fatalf("Expected no summary while the storm continues, got %d incidents", len(*received)-passed)
+		}
+		if len(*timers) != 2 {
+			t.Fatalf("Expected the storm check rescheduled, got %d timers", len(*timers))
+		}
+
+		fake.Advance(5 * time.Second)
+		(*timers)[1]()
+		if len(*received) != passed+1 {
+			t.Fatalf("Expected one storm summary, got %d incidents", len(*received)-passed)
+		}
+		summary := (*received)[len(*received)-1]
+		if summary.Type != IncidentStorm || summary.Severity != types.SeverityCritical {
+			t.Errorf("Expected critical incident storm summary, got %s/%s", summary.Type, summary.Severity)
+		}
+		if shed := summary.Context["storm_shed"]; shed != 160 {
+			t.Errorf("Expected 160 shed incidents in the summary, got %v", shed)
+		}
+		if pods := summary.Context["storm_pods"]; pods != 80 {
+			t.Errorf("Expected 80 distinct pods, got %v", pods)
+		}
+
+		(*timers)[1]()
+		if len(*received) != passed+1 {
+			t.Error("Expected the summary sent only once")
+		}
+	})
+
+	t.Run("uses generous defaults", func(t *testing.T) {
+		handler, err := CreateHandler(HandlerConfig{Type: "ratelimit"}, Dependencies{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		limiter := handler.(*RateLimitHandler)
+		if limiter.rate != DefaultIncidentRate || limiter.burst != DefaultIncidentRate*DefaultIncidentBurstSeconds {
+			t.Errorf("Expected rate %v and burst %v, got %v and %v", DefaultIncidentRate, DefaultIncidentRate*DefaultIncidentBurstSeconds, limiter.rate, limiter.burst)
+		}
+	})
+
+	t.Run("rejects invalid options", func(t *testing.T) {
+		for _, config := range []map[string]interface{}{
+			{"rate": float64(0)},
+			{"rate": "fast"},
+			{"burst": float64(0.5)},
+		} {
+			if _, err := CreateHandler(HandlerConfig{Type: "ratelimit", Config: config}, Dependencies{}); err == nil {
+				t.Errorf("Expected error for %v", config)
+			}
+		}
+	})
+}
@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultQuietPeriod is how long a crashed container must keep running before
+// the resolution handler considers its incident resolved.
+const DefaultQuietPeriod = 5 * time.Minute
+
+// IncidentResolved is the type of the incidents raised by the resolution
+// handler when a crashed container has recovered.
+const IncidentResolved types.IncidentType = "resolved"
+
+// ResolutionHandler tracks crash and OOM incidents by fingerprint (namespace,
+// pod and container name) and, once the crashed container has been running
+// for the quiet period without restarting, sends a resolution incident with
+// the computed downtime to the handlers after it in the chain. The resolution
+// names the incident it resolves in its context, so emitters can close alerts
+// they opened, e.g. auto-resolve a PagerDuty incident.
+//
+// Recovery is observed through OnPodStart, which the daemon calls with every
+// running pod it watches. Incidents without a container name, such as pod
+// phase failures, are not tracked.
+type ResolutionHandler struct {
+	// mutex protects open and next
+	mutex sync.Mutex
+	// quietPeriod is how long a container must run to be considered stable
+	quietPeriod time.Duration
+	// open maps fingerprints to their unresolved incidents
+	open map[string]*openIncident
+	// next receives the resolution incidents (nil drops them)
+	next Handler
+	// clock supplies the current time
+	clock clock.Clock
+	// afterFunc schedules f to run after d, e.g. time.AfterFunc
+	afterFunc func(d time.Duration, f func())
+}
+
+// openIncident tracks an unresolved incident for one container.
+type openIncident struct {
+	// first is the incident that opened the fingerprint
+	first types.IncidentReport
+	// severity is the most severe of the tracked incidents
+	severity types.IncidentSeverity
+	// since is when the container first went down
+	since time.Time
+	// lastCrash is when the container last went down
+	lastCrash time.Time
+	// crashes counts the distinct crashes tracked
+	crashes int
+	// recovering is when the run being watched for stability started (zero
+	// while the container is not running)
+	recovering time.Time
+	// containerID is the ID of the running container
+	containerID string
+	// generation invalidates scheduled resolutions when the container state changes
+	generation int
+}
+
+// NewResolutionHandler creates a handler resolving incidents once their
+// container has run for quietPeriod.
+func NewResolutionHandler(quietPeriod time.Duration) *ResolutionHandler {
+	return &ResolutionHandler{
+		quietPeriod: quietPeriod,
+		open:        make(map[string]*openIncident),
+		clock:       clock.Real{},
+		afterFunc: func(d time.Duration, f func()) {
+			time.AfterFunc(d, f)
+		},
+	}
+}
+
+// SetNext sets the handler that receives resolution incidents.
+func (rh *ResolutionHandler) SetNext(next Handler) {
+	rh.mutex.Lock()
+	defer rh.mutex.Unlock()
+	rh.next = next
+}
+
+// fingerprint returns the key incidents for a container are tracked under.
+func fingerprint(namespace, pod, container string) string {
+	return namespace + "/" + pod + "/" + container
+}
+
+// HandleIncident opens or extends the fingerprint of crash and OOM incidents.
+// Reports repeating a crash already tracked, which the pod watcher sends on
+// every status update of a restarted container, are not counted again.
+func (rh *ResolutionHandler) HandleIncident(report types.IncidentReport) {
+	if report.Type != types.IncidentCrash && report.Type != types.IncidentOOM {
+		return
+	}
+	container, _ := report.Context["container_name"].(string)
+	if container == "" || report.PodName == "" {
+		return
+	}
+	key := fingerprint(report.Namespace, report.PodName, container)
+	crashed := crashTime(report)
+
+	rh.mutex.Lock()
+	defer rh.mutex.Unlock()
+
+	incident, ok := rh.open[key]
+	if !ok {
+		rh.open[key] = &openIncident{
+			first:     report,
+			severity:  report.Severity,
+			since:     crashed,
+			lastCrash: crashed,
+			crashes:   1,
+		}
+		return
+	}
+	if severityRank(report.Severity) > severityRank(incident.severity) {
+		incident.severity = report.Severity
+	}
+	if !crashed.After(incident.lastCrash) {
+		return
+	}
+	incident.lastCrash = crashed
+	incident.crashes++
+	// A crash after the watched run started means that run was not stable
+	if !incident.recovering.IsZero() && !crashed.Before(incident.recovering) {
+		incident.recovering = time.Time{}
+		incident.generation++
+	}
+}
+
+// crashTime returns when the container went down: the finished_at time the
+// pod watcher reports, falling back to the incident timestamp.
+func crashTime(report types.IncidentReport) time.Time {
+	switch v := report.Context["finished_at"].(type) {
+	case metav1.Time:
+		if !v.IsZero() {
+			return v.Time
+		}
+	case time.Time:
+		if !v.IsZero() {
+			return v
+		}
+	case string:
+		if parsed, err := time.Parse(time.RFC3339, v); err == nil {
+			return parsed
+		}
+	}
+	return report.Timestamp
+}
+
+// OnPodStart watches the pod's containers with open incidents for stability.
+// A container running since after its last crash is scheduled to be resolved
+// once it has run for the quiet period; one that is not running cancels any
+// scheduled resolution.
+func (rh *ResolutionHandler) OnPodStart(pod *corev1.Pod) {
+	if pod == nil {
+		return
+	}
+	now := rh.clock.Now()
+
+	rh.mutex.Lock()
+	var resolutions []types.IncidentReport
+	for _, status := range pod.Status.ContainerStatuses {
+		key := fingerprint(pod.Namespace, pod.Name, status.Name)
+		incident, ok := rh.open[key]
+		if !ok {
+			continue
+		}
+
+		if status.State.Running == nil {
+			if !incident.recovering.IsZero() {
+				incident.recovering = time.Time{}
+				incident.generation++
+			}
+			continue
+		}
+		started := status.State.Running.StartedAt.Time
+		if started.Equal(incident.recovering) || started.Before(incident.lastCrash) {
+			continue
+		}
+
+		incident.recovering = started
+		incident.containerID = status.ContainerID
+		incident.generation++
+		stable := started.Add(rh.quietPeriod)
+		if !now.Before(stable) {
+			delete(rh.open, key)
+			resolutions = append(resolutions, incident.resolution(key, status.Name, now))
+			continue
+		}
+		generation := incident.generation
+		rh.afterFunc(stable.Sub(now), func() { rh.resolve(key, generation) })
+	}
+	next := rh.next
+	rh.mutex.Unlock()
+
+	forward(next, resolutions)
+}
+
+// OnPodStop forgets the incidents of a deleted pod, which can no longer recover.
+func (rh *ResolutionHandler) OnPodStop(pod *corev1.Pod) {
+	if pod == nil {
+		return
+	}
+	prefix := pod.Namespace + "/" + pod.Name + "/"
+
+	rh.mutex.Lock()
+	defer rh.mutex.Unlock()
+	for key := range rh.open {
+		if strings.HasPrefix(key, prefix) {
+			delete(rh.open, key)
+		}
+	}
+}
+
+// resolve sends the resolution of the fingerprint's incident when its timer
+// fires, unless the container state changed since it was scheduled.
+func (rh *ResolutionHandler) resolve(key string, generation int) {
+	now := rh.clock.Now()
+
+	rh.mutex.Lock()
+	incident, ok := rh.open[key]
+	if !ok || incident.generation != generation {
+		rh.mutex.Unlock()
+		return
+	}
+	delete(rh.open, key)
+	container, _ := incident.first.Context["container_name"].(string)
+	resolution := incident.resolution(key, container, now)
+	next := rh.next
+	rh.mutex.Unlock()
+
+	forward(next, []types.IncidentReport{resolution})
+}
+
+// resolution builds the incident reporting the recovery. The downtime is the
+// time from the first crash to the start of the stable run.
+func (oi *openIncident) resolution(key, container string, now time.Time) types.IncidentReport {
+	downtime := oi.recovering.Sub(oi.since)
+	if downtime < 0 {
+		downtime = 0
+	}
+	containerID := oi.containerID
+	if containerID == "" {
+		containerID = oi.first.ContainerID
+	}
+
+	return types.IncidentReport{
+		ID:          oi.first.ID + "-resolved",
+		Timestamp:   now,
+		PodName:     oi.first.PodName,
+		Namespace:   oi.first.Namespace,
+		ContainerID: containerID,
+		Severity:    oi.severity,
+		Type:        IncidentResolved,
+		Message: fmt.Sprintf("Container %s in pod %s/%s recovered after %v downtime (%d crashes)",
+			container, oi.first.Namespace, oi.first.PodName, downtime, oi.crashes),
+		Context: map[string]interface{}{
+			"resolved_incident_id": oi.first.ID,
+			"resolved_type":        string(oi.first.Type),
+			"fingerprint":          key,
+			"container_name":       container,
+			"crash_count":          oi.crashes,
+			"down_since":           oi.since.Format(time.RFC3339),
+			"recovered_at":         oi.recovering.Format(time.RFC3339),
+			"downtime":             downtime.String(),
+			"downtime_seconds":     downtime.Seconds(),
+		},
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TestResolutionHandler validates resolving crash incidents once their container is stable.
+func TestResolutionHandler(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	// newChain returns a resolution handler chained before a recorder, with
+	// its timers captured instead of scheduled.
+	newChain := func(t *testing.T) (*MultiHandler, *clock.Fake, *[]func(), *[]types.IncidentReport) {
+		t.Helper()
+		handler, err := CreateHandler(HandlerConfig{Type: "resolution", Config: map[string]interface{}{"quiet_period": "2m"}}, Dependencies{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		resolution := handler.(*ResolutionHandler)
+		fake := clock.NewFake(base)
+		resolution.clock = fake
+		timers := &[]func(){}
+		resolution.afterFunc = func(d time.Duration, f func()) {
+			*timers = append(*timers, f)
+		}
+
+		received := &[]types.IncidentReport{}
+		chain := NewMultiHandler(resolution, HandlerFunc(func(report types.IncidentReport) {
+			*received = append(*received, report)
+		}))
+		return chain, fake, timers, received
+	}
+
+	// crash returns a container restart incident for a crash at finished.
+	crash := func(id string, finished time.Time) types.IncidentReport {
+		return types.IncidentReport{
+			ID:          id,
+			Timestamp:   finished.Add(5 * time.Second),
+			PodName:     "api-1",
+			Namespace:   "prod",
+			ContainerID: "containerd://old",
+			Severity:    types.SeverityHigh,
+			Type:        types.IncidentCrash,
+			Message:     "Container app in pod prod/api-1 restarted",
+			Context: map[string]interface{}{
+				"container_name": "app",
+				"finished_at":    metav1.NewTime(finished),
+			},
+		}
+	}
+
+	// pod returns the pod with its app container running since started, or
+	// waiting to restart when started is zero.
+	pod := func(started time.Time) *corev1.Pod {
+		status := corev1.ContainerStatus{Name: "app", ContainerID: "containerd://new"}
+		if started.IsZero() {
+			status.State.Waiting = &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}
+		} else {
+			status.State.Running = &corev1.ContainerStateRunning{StartedAt: metav1.NewTime(started)}
+		}
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "prod"},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, ContainerStatuses: []corev1.ContainerStatus{status}},
+		}
+	}
+
+	t.Run("resolves a crash once the container is stable", func(t *testing.T) {
+		chain, fake, timers, received := newChain(t)
+
+		chain.HandleIncident(crash("crash-1", base))
+		fake.Advance(30 * time.Second)
+		chain.OnPodStart(pod(base.Add(30 * time.Second)))
+
+		if len(*received) != 1 || len(*timers) != 1 {
+			t.Fatalf("Expected the crash passed on and one timer, got %d incidents and %d timers", len(*received), len(*timers))
+		}
+
+		fake.Advance(2 * time.Minute)
+		(*timers)[0]()
+
+		if len(*received) != 2 {
+			t.Fatalf("Expected a resolution, got %d incidents", len(*received))
+		}
+		resolution := (*received)[1]
+		if resolution.Type != IncidentResolved || resolution.ID != "crash-1-resolved" || resolution.Severity != types.SeverityHigh {
+			t.Errorf("Expected resolution of crash-1, got %+v", resolution)
+		}
+		if resolution.ContainerID != "containerd://new" || resolution.Context["resolved_incident_id"] != "crash-1" {
+			t.Errorf("Expected the recovered container to resolve crash-1, got %s %v", resolution.ContainerID, resolution.Context["resolved_incident_id"])
+		}
+		if resolution.Context["downtime"] != "30s" || resolution.Context["downtime_seconds"] != 30.0 || resolution.Context["crash_count"] != 1 {
+			t.Errorf("Expected 30s downtime after 1 crash, got %v", resolution.Context)
+		}
+		if resolution.Context["fingerprint"] != "prod/api-1/app" {
+			t.Errorf("Expected fingerprint prod/api-1/app, got %v", resolution.Context["fingerprint"])
+		}
+	})
+
+	t.Run("waits out a crash loop", func(t *testing.T) {
+		chain, fake, timers, received := newChain(t)
+
+		chain.HandleIncident(crash("crash-1", base))
+		chain.OnPodStart(pod(base.Add(10 * time.Second)))
+		// The watcher re-reports the restart with every status update
+		chain.HandleIncident(crash("crash-1-repeat", base))
+
+		fake.Advance(time.Minute)
+		chain.OnPodStart(pod(time.Time{}))
+		chain.HandleIncident(crash("crash-2", base.Add(time.Minute)))
+		fake.Advance(20 * time.Second)
+		chain.OnPodStart(pod(base.Add(80 * time.Second)))
+
+		(*timers)[0]()
+		if len(*received) != 3 {
+			t.Fatalf("Expected no resolution while crash looping, got %d incidents", len(*received))
+		}
+
+		fake.Advance(2 * time.Minute)
+		(*timers)[1]()
+		if len(*received) != 4 {
+			t.Fatalf("Expected a resolution, got %d incidents", len(*received))
+		}
+		resolution := (*received)[3]
+		if resolution.Context["resolved_incident_id"] != "crash-1" || resolution.Context["crash_count"] != 2 || resolution.Context["downtime"] != "1m20s" {
+			t.Errorf("Expected crash-1 resolved after 2 crashes and 1m20s downtime, got %v", resolution.Context)
+		}
+	})
+
+	t.Run("resolves immediately when already stable", func(t *testing.T) {
+		chain, fake, timers, received := newChain(t)
+
+		chain.HandleIncident(crash("crash-1", base))
+		fake.Advance(5 * time.Minute)
+		chain.OnPodStart(pod(base.Add(time.Second)))
+
+		if len(*timers) != 0 || len(*received) != 2 || (*received)[1].Type != IncidentResolved {
+			t.Errorf("Expected an immediate resolution, got %d timers and %+v", len(*timers), *received)
+		}
+		chain.OnPodStart(pod(base.Add(time.Second)))
+		if len(*received) != 2 {
+			t.Errorf("Expected a single resolution, got %d incidents", len(*received))
+		}
+	})
+
+	t.Run("forgets deleted pods and untracked incidents", func(t *testing.T) {
+		chain, _, timers, received := newChain(t)
+
+		chain.HandleIncident(crash("crash-1", base))
+		chain.HandleIncident(types.IncidentReport{ID: "failed", PodName: "api-1", Namespace: "prod", Type: types.IncidentCrash})
+		chain.HandleIncident(types.IncidentReport{ID: "manual", PodName: "api-1", Namespace: "prod", Type: types.IncidentManual, Context: map[string]interface{}{"container_name": "app"}})
+		chain.OnPodStop(pod(time.Time{}))
+		chain.OnPodStart(pod(base.Add(time.Second)))
+
+		if len(*timers) != 0 || len(*received) != 3 {
+			t.Errorf("Expected no resolution for a deleted pod, got %d timers and %d incidents", len(*timers), len(*received))
+		}
+	})
+
+	t.Run("rejects invalid quiet period", func(t *testing.T) {
+		for _, period := range []string{"0s", "soon"} {
+			if _, err := CreateHandler(HandlerConfig{Type: "resolution", Config: map[string]interface{}{"quiet_period": period}}, Dependencies{}); err == nil {
+				t.Errorf("Expected error for quiet_period %q", period)
+			}
+		}
+	})
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+const (
+	// DefaultRollupWindow is how long the rollup handler collects a workload's
+	// incidents after the first one
+	DefaultRollupWindow = 30 * time.Second
+	// DefaultRollupMinPods is how many pods of a workload must fail within the
+	// window for their incidents to be rolled up
+	DefaultRollupMinPods = 2
+)
+
+// DefaultRollupTypes are the incident types the rollup handler groups.
+var DefaultRollupTypes = []types.IncidentType{types.IncidentCrash, types.IncidentOOM}
+
+// RollupHandler is a Filter that groups the crash incidents of pods belonging
+// to the same workload, such as the pods of a Deployment crashing together
+// after a bad rollout. The first incident of a workload opens a window during
+// which its incidents are held. When the window expires, if at least minPods
+// pods failed, a single rollup incident listing the affected pods is sent to
+// the handlers after it in the chain instead of the individual incidents;
+// otherwise the held incidents are sent on unchanged. Incidents without a
+// workload, which the pod watcher resolves from owner references, and of
+// other types are always passed on.
+type RollupHandler struct {
+	// mutex protects groups and next
+	mutex sync.Mutex
+	// window is how long a workload's incidents are collected
+	window time.Duration
+	// minPods is how many distinct pods must fail for a rollup
+	minPods int
+	// types are the incident types grouped
+	types map[types.IncidentType]bool
+	// groups maps "namespace/workload" to its open window
+	groups map[string]*workloadGroup
+	// next receives the rollups and released incidents (nil drops them)
+	next Handler
+	// clock supplies the current time
+	clock clock.Clock
+	// afterFunc schedules f to run after d, e.g. time.AfterFunc
+	afterFunc func(d time.Duration, f func())
+}
+
+// workloadGroup holds the incidents collected for one workload.
+type workloadGroup struct {
+	// workload is the workload in "kind/name" form
+	workload string
+	// until is when the window expires
+	until time.Time
+	// incidents are the held incidents in arrival order
+	incidents []types.IncidentReport
+}
+
+// NewRollupHandler creates a handler rolling up the incidents of the given
+// types for workloads with at least minPods failing pods within window.
+func NewRollupHandler(window time.Duration, minPods int, incidentTypes []types.IncidentType) *RollupHandler {
+	grouped := make(map[types.IncidentType]bool, len(incidentTypes))
+	for _, incidentType := range incidentTypes {
+		grouped[incidentType] = true
+	}
+	return &RollupHandler{
+		window:  window,
+		minPods: minPods,
+		types:   grouped,
+		groups:  make(map[string]*workloadGroup),
+		clock:   clock.Real{},
+		afterFunc: func(d time.Duration, f func()) {
+			time.AfterFunc(d, f)
+		},
+	}
+}
+
+// SetNext sets the handler that receives rollups and released incidents.
+func (rh *RollupHandler) SetNext(next Handler) {
+	rh.mutex.Lock()
+	defer rh.mutex.Unlock()
+	rh.next = next
+}
+
+// Allow reports whether the incident is passed on immediately. Incidents of a
+// grouped type for a known workload are held and rejected.
+func (rh *RollupHandler) Allow(report types.IncidentReport) bool {
+	workload, _ := report.Context["workload"].(string)
+	if workload == "" || !rh.types[report.Type] {
+		return true
+	}
+	key := report.Namespace + "/" + workload
+	now := rh.clock.Now()
+
+	rh.mutex.Lock()
+	released, next := rh.expire(now)
+	group, open := rh.groups[key]
+	if !open {
+		group = &workloadGroup{workload: workload, until: now.Add(rh.window)}
+		rh.groups[key] = group
+		rh.afterFunc(rh.window, rh.Expire)
+	}
+	group.incidents = append(group.incidents, report)
+	rh.mutex.Unlock()
+
+	forward(next, released)
+	return false
+}
+
+// HandleIncident does nothing; grouping happens in Allow.
+func (rh *RollupHandler) HandleIncident(report types.IncidentReport) {}
+
+// Expire closes the elapsed windows, sending a rollup or the held incidents
+// for each. It runs when a window's timer fires.
+func (rh *RollupHandler) Expire() {
+	now := rh.clock.Now()
+
+	rh.mutex.Lock()
+	released, next := rh.expire(now)
+	rh.mutex.Unlock()
+
+	forward(next, released)
+}
+
+// expire removes the windows elapsed at now and returns the incidents to send
+// for them, ordered by namespace and workload, with the handler they should
+// be sent to. The caller must hold rh.mutex.
+func (rh *RollupHandler) expire(now time.Time) ([]types.IncidentReport, Handler) {
+	var keys []string
+	for key, group := range rh.groups {
+		if !now.Before(group.until) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var released []types.IncidentReport
+	for _, key := range keys {
+		group := rh.groups[key]
+		delete(rh.groups, key)
+		if len(group.pods()) >= rh.minPods {
+			released = append(released, group.rollup(rh.window))
+		} else {
+			released = append(released, group.incidents...)
+		}
+	}
+	return released, rh.next
+}
+
+// pods returns the distinct pods of the held incidents in sorted order.
+func (wg *workloadGroup) pods() []string {
+	seen := make(map[string]bool, len(wg.incidents))
+	var pods []string
+	for _, incident := range wg.incidents {
+		if !seen[incident.PodName] {
+			seen[incident.PodName] = true
+			pods = append(pods, incident.PodName)
+		}
+	}
+	sort.Strings(pods)
+	return pods
+}
+
+// rollup builds the incident reporting the group's failed pods. It takes the
+// type, severity and context of the most severe incident, the latest on ties,
+// and the timestamp of the first, so its telemetry snapshot covers the lead-up
+// to the failure.
+func (wg *workloadGroup) rollup(window time.Duration) types.IncidentReport {
+	first, worst := wg.incidents[0], wg.incidents[0]
+	incidentTypes := make(map[string]int)
+	ids := make([]string, len(wg.incidents))
+	for i, incident := range wg.incidents {
+		incidentTypes[string(incident.Type)]++
+		ids[i] = incident.ID
+		if severityRank(incident.Severity) >= severityRank(worst.Severity) {
+			worst = incident
+		}
+	}
+	pods := wg.pods()
+
+	report := types.IncidentReport{
+		ID:        fmt.Sprintf("%s-rollup", first.ID),
+		Timestamp: first.Timestamp,
+		Namespace: first.Namespace,
+		Severity:  worst.Severity,
+		Type:      worst.Type,
+		Message:   fmt.Sprintf("%s: %d pods %s within %v", wg.workload, len(pods), rollupVerb(incidentTypes), window),
+		Context:   make(map[string]interface{}, len(worst.Context)+5),
+	}
+	for key, value := range worst.Context {
+		report.Context[key] = value
+	}
+	report.Context["rollup_pods"] = pods
+	report.Context["rollup_incidents"] = len(wg.incidents)
+	report.Context["rollup_incident_ids"] = ids
+	report.Context["rollup_types"] = incidentTypes
+	report.Context["rollup_window"] = window.String()
+	return report
+}
+
+// rollupVerb describes what happened to the pods of a rollup.
+func rollupVerb(incidentTypes map[string]int) string {
+	if len(incidentTypes) == 1 {
+		switch {
+		case incidentTypes[string(types.IncidentCrash)] > 0:
+			return "crashed"
+		case incidentTypes[string(types.IncidentOOM)] > 0:
+			return "were OOM killed"
+		}
+	}
+	return "failed"
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/internal/clock"
+	"github.com/verygoodsoftwarecompany/blackbox-daemon/pkg/types"
+)
+
+// TestRollupHandler validates rolling up the crashes of a workload's pods.
+func TestRollupHandler(t *testing.T) {
+	base := time.Date(2024, time.November, 2, 15, 4, 5, 0, time.UTC)
+
+	// newChain returns a rollup handler chained before a recorder, with its
+	// timers captured instead of scheduled.
+	newChain := func(t *testing.T, config map[string]interface{}) (*MultiHandler, *clock.Fake, *[]func(), *[]types.IncidentReport) {
+		t.Helper()
+		handler, err := CreateHandler(HandlerConfig{Type: "rollup", Config: config}, Dependencies{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		rollup := handler.(*RollupHandler)
+		fake := clock.NewFake(base)
+		rollup.clock = fake
+		timers := &[]func(){}
+		rollup.afterFunc = func(d time.Duration, f func()) {
+			*timers = append(*timers, f)
+		}
+
+		received := &[]types.IncidentReport{}
+		chain := NewMultiHandler(rollup, HandlerFunc(func(report types.IncidentReport) {
+			*received = append(*received, report)
+		}))
+		return chain, fake, timers, received
+	}
+
+	// crash returns a crash incident of a pod of the api Deployment.
+	crash := func(id, pod string, severity types.IncidentSeverity) types.IncidentReport {
+		return types.IncidentReport{
+			ID: id, Timestamp: base, PodName: pod, Namespace: "prod", Type: types.IncidentCrash, Severity: severity,
+			Message: "container exited",
+			Context: map[string]interface{}{"workload": "deployment/api", "workload_kind": "Deployment", "workload_name": "api"},
+		}
+	}
+
+	t.Run("rolls up pods of a deployment crashing together", func(t *testing.T) {
+		chain, fake, timers, received := newChain(t, map[string]interface{}{"window": "30s"})
+
+		chain.HandleIncident(crash("c1", "api-7d9f-b", types.SeverityHigh))
+		fake.Advance(5 * time.Second)
+		chain.HandleIncident(crash("c2", "api-7d9f-a", types.SeverityHigh))
+		chain.HandleIncident(crash("c3", "api-7d9f-c", types.SeverityCritical))
+		chain.HandleIncident(crash("c4", "api-7d9f-a", types.SeverityMedium))
+
+		if len(*received) != 0 {
+			t.Fatalf("Expected incidents held during the window, got %+v", *received)
+		}
+		if len(*timers) != 1 {
+			t.Fatalf("Expected one timer per workload, got %d", len(*timers))
+		}
+
+		fake.Advance(25 * time.Second)
+		(*timers)[0]()
+
+		if len(*received) != 1 {
+			t.Fatalf("Expected a single rollup, got %d incidents", len(*received))
+		}
+		rollup := (*received)[0]
+		if rollup.ID != "c1-rollup" || rollup.Severity != types.SeverityCritical || rollup.Type != types.IncidentCrash {
+			t.Errorf("Expected rollup of the most severe incident, got %+v", rollup)
+		}
+		if rollup.PodName != "" || rollup.Namespace != "prod" || !rollup.Timestamp.Equal(base) {
+			t.Errorf("Expected workload-level rollup at the first incident, got %+v", rollup)
+		}
+		if rollup.Message != "deployment/api: 3 pods crashed within 30s" {
+			t.Errorf("Expected rollup message, got %q", rollup.Message)
+		}
+		if pods := rollup.Context["rollup_pods"]; !reflect.DeepEqual(pods, []string{"api-7d9f-a", "api-7d9f-b", "api-7d9f-c"}) {
+			t.Errorf("Expected sorted affected pods, got %v", pods)
+		}
+		if rollup.Context["rollup_incidents"] != 4 {
+			t.Errorf("Expected 4 rolled up incidents, got %v", rollup.Context["rollup_incidents"])
+		}
+		if ids := rollup.Context["rollup_incident_ids"]; !reflect.DeepEqual(ids, []string{"c1", "c2", "c3", "c4"}) {
+			t.Errorf("Expected rolled up incident IDs, got %v", ids)
+		}
+		if rollup.Context["workload_kind"] != "Deployment" {
+			t.Errorf("Expected workload context kept, got %v", rollup.Context)
+		}
+	})
+
+	t.Run("releases a single pod's incidents unchanged", func(t *testing.T) {
+		chain, fake, timers, received := newChain(t, nil)
+
+		chain.HandleIncident(crash("c1", "api-7d9f-a", types.SeverityHigh))
+		chain.HandleIncident(crash("c2", "api-7d9f-a", types.SeverityHigh))
+
+		fake.Advance(DefaultRollupWindow)
+		(*timers)[0]()
+
+		if len(*received) != 2 || (*received)[0].ID != "c1" || (*received)[1].ID != "c2" {
+			t.Errorf("Expected held incidents released in order, got %+v", *received)
+		}
+	})
+
+	t.Run("passes on incidents without a workload or of other types", func(t *testing.T) {
+		chain, _, timers, received := newChain(t, nil)
+
+		chain.HandleIncident(types.IncidentReport{ID: "bare", PodName: "api-1", Namespace: "prod", Type: types.IncidentCrash})
+		timeout := crash("t1", "api-7d9f-a", types.SeverityLow)
+		timeout.Type = types.IncidentTimeout
+		chain.HandleIncident(timeout)
+
+		if len(*received) != 2 || len(*timers) != 0 {
+			t.Errorf("Expected incidents passed on immediately, got %+v", *received)
+		}
+	})
+
+	t.Run("keeps workloads apart", func(t *testing.T) {
+		chain, fake, timers, received := newChain(t, nil)
+
+		chain.HandleIncident(crash("c1", "api-7d9f-a", types.SeverityHigh))
+		other := crash("w1", "worker-5c8d-a", types.SeverityHigh)
+		other.Context = map[string]interface{}{"workload": "statefulset/worker"}
+		chain.HandleIncident(other)
+		chain.HandleIncident(crash("c2", "api-7d9f-b", types.SeverityHigh))
+
+		fake.Advance(DefaultRollupWindow)
+		(*timers)[0]()
+
+		if len(*received) != 2 || (*received)[0].ID != "c1-rollup" || (*received)[1].ID != "w1" {
+			t.Errorf("Expected api rollup and released worker incident, got %+v", *received)
+		}
+	})
+
+	t.Run("rejects invalid options", func(t *testing.T) {
+		for _, config := range []map[string]interface{}{
+			{"window": "0s"},
+			{"min_pods": float64(1)},
+			{"types": "crash"},
+			{"types": []interface{}{}},
+		} {
+			if _, err := CreateHandler(HandlerConfig{Type: "rollup", Config: config}, Dependencies{}); err == nil {
+				t.Errorf("Expected error for %v", config)
+			}
+		}
+	})
+}